@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/ccoveille/go-safecast"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/wavesplatform/gowaves/pkg/exporter"
+	"github.com/wavesplatform/gowaves/pkg/logging"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+	"github.com/wavesplatform/gowaves/pkg/state"
+	"github.com/wavesplatform/gowaves/pkg/util/fdlimit"
+	"github.com/wavesplatform/gowaves/pkg/versioning"
+)
+
+func main() {
+	if err := run(); err != nil {
+		zap.S().Error(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		logLevel = zap.LevelFlag("log-level", zapcore.InfoLevel,
+			"Logging level. Supported levels: DEBUG, INFO, WARN, ERROR, FATAL. Default logging level INFO.")
+		statePath      = flag.String("state-path", "", "Path to node's state directory")
+		blockchainType = flag.String("blockchain-type", "mainnet", "Blockchain type: mainnet/testnet/stagenet")
+		cfgPath        = flag.String("cfg-path", "", "Path to configuration JSON file, only for custom blockchain.")
+		blockchainPath = flag.String("blockchain-path", "",
+			"Path to binary blockchain file to write. If the file exists, the export continues from its last block.")
+		snapshotsPath = flag.String("snapshots-path", "",
+			"Path to binary snapshots file to write for the import in light mode. Not exported if empty.")
+		height = flag.Uint64("height", 0,
+			"Height of the last block to export. The state height is used by default.")
+		buildExtendedAPI = flag.Bool("build-extended-api", false,
+			"State stores data for extended API. Must match the flag the state was imported with.")
+		buildStateHashes = flag.Bool("build-state-hashes", false,
+			"State stores state hashes. Must match the flag the state was imported with.")
+		disableBloomFilter = flag.Bool("disable-bloom", false, "Disable bloom filter for state.")
+	)
+
+	flag.Parse()
+
+	logger := logging.SetupSimpleLogger(*logLevel)
+	defer func() {
+		err := logger.Sync()
+		if err != nil && errors.Is(err, os.ErrInvalid) {
+			panic(fmt.Sprintf("Failed to close logging subsystem: %v\n", err))
+		}
+	}()
+	zap.S().Infof("Gowaves Exporter version: %s", versioning.Version)
+
+	if *statePath == "" {
+		return errors.New("state path is required")
+	}
+	if *blockchainPath == "" {
+		return errors.New("blockchain path is required")
+	}
+
+	maxFDs, err := fdlimit.MaxFDs()
+	if err != nil {
+		return fmt.Errorf("initialization error: %w", err)
+	}
+	if _, err = fdlimit.RaiseMaxFDs(maxFDs); err != nil {
+		return fmt.Errorf("initialization error: %w", err)
+	}
+
+	cfg, err := blockchainSettings(*cfgPath, *blockchainType)
+	if err != nil {
+		return err
+	}
+
+	params := state.DefaultStateParams()
+	const fdSigma = 10
+	c, err := safecast.ToInt(maxFDs - fdSigma)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	params.DbParams.OpenFilesCacheCapacity = c
+	params.DbParams.DisableBloomFilter = *disableBloomFilter
+	params.StoreExtendedApiData = *buildExtendedAPI
+	params.BuildStateHashes = *buildStateHashes
+	params.ProvideExtendedApi = false
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	return export(ctx, *statePath, *height, exporter.ExportParams{
+		Schema:         cfg.AddressSchemeCharacter,
+		BlockchainPath: *blockchainPath,
+		SnapshotsPath:  *snapshotsPath,
+	}, params, cfg)
+}
+
+func blockchainSettings(cfgPath, blockchainType string) (*settings.BlockchainSettings, error) {
+	if cfgPath == "" {
+		return settings.BlockchainSettingsByTypeName(blockchainType)
+	}
+	f, err := os.Open(filepath.Clean(cfgPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open configuration file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	cfg, err := settings.ReadBlockchainSettings(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+	return cfg, nil
+}
+
+func export(
+	ctx context.Context,
+	statePath string,
+	height proto.Height,
+	exportParams exporter.ExportParams,
+	params state.StateParams,
+	cfg *settings.BlockchainSettings,
+) (err error) {
+	s, err := state.NewState(statePath, true, params, cfg, false)
+	if err != nil {
+		return fmt.Errorf("failed to open state: %w", err)
+	}
+	defer func() {
+		if clErr := s.Close(); clErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to close state: %w", clErr))
+		}
+	}()
+	if height == 0 {
+		if height, err = s.Height(); err != nil {
+			return fmt.Errorf("failed to get state height: %w", err)
+		}
+	}
+	e, err := exporter.NewExporter(exportParams, s)
+	if err != nil {
+		return fmt.Errorf("failed to create exporter: %w", err)
+	}
+	defer func() {
+		if clErr := e.Close(); clErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to close exporter: %w", clErr))
+		}
+	}()
+	start := e.Height()
+	if start >= height {
+		zap.S().Infof("Blocks up to height %d are already exported, nothing to do", start)
+		return nil
+	}
+	zap.S().Infof("Exporting blocks from height %d to %d", start+1, height)
+	if exErr := e.Export(ctx, height); exErr != nil {
+		if errors.Is(exErr, context.Canceled) {
+			zap.S().Infof("Export interrupted at height %d, run again to continue", e.Height())
+			return nil
+		}
+		return fmt.Errorf("failed to export: %w", exErr)
+	}
+	zap.S().Infof("Blocks from height %d to %d exported", start+1, e.Height())
+	return nil
+}
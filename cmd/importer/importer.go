@@ -20,7 +20,9 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"github.com/wavesplatform/gowaves/pkg/importer"
+	"github.com/wavesplatform/gowaves/pkg/keyvalue"
 	"github.com/wavesplatform/gowaves/pkg/logging"
+	"github.com/wavesplatform/gowaves/pkg/ride"
 	"github.com/wavesplatform/gowaves/pkg/settings"
 	"github.com/wavesplatform/gowaves/pkg/state"
 	"github.com/wavesplatform/gowaves/pkg/util/fdlimit"
@@ -66,11 +68,15 @@ type cfg struct {
 	writeBufferSize           int
 	buildDataForExtendedAPI   bool
 	buildStateHashes          bool
+	rideEngineName            string
+	rideEngine                ride.Engine
 	lightNodeMode             bool
 	snapshotsPath             string
 	cpuProfilePath            string
 	memProfilePath            string
 	disableBloomFilter        bool
+	dbBackendName             string
+	dbBackend                 keyvalue.Backend
 }
 
 func parseFlags() cfg {
@@ -98,6 +104,8 @@ func parseFlags() cfg {
 			"WARNING: this slows down the import, use only if you do really need extended API.")
 	flag.BoolVar(&c.buildStateHashes, "build-state-hashes", false,
 		"Calculate and store state hashes for each block height.")
+	flag.StringVar(&c.rideEngineName, "ride-engine", ride.TreeEngine.String(),
+		"Engine to execute Ride scripts: 'tree' or 'vm' for the bytecode Ride VM.")
 	flag.BoolVar(&c.lightNodeMode, "light-node", false,
 		"Run the node in the light mode in which snapshots are imported without validation")
 	flag.StringVar(&c.snapshotsPath, "snapshots-path", "", "Path to binary snapshots file.")
@@ -106,6 +114,9 @@ func parseFlags() cfg {
 	flag.StringVar(&c.memProfilePath, "memprofile", "", "Write memory profile to this file.")
 	flag.BoolVar(&c.disableBloomFilter, "disable-bloom", false,
 		"Disable bloom filter. Less memory usage, but decrease performance.")
+	flag.StringVar(&c.dbBackendName, "db-backend", "",
+		"State database backend: 'leveldb' or 'badger'. By default the backend of existing state is used, "+
+			"'leveldb' for the new state.")
 	flag.Parse()
 	return c
 }
@@ -120,6 +131,18 @@ func (c *cfg) validateFlags() error {
 	if c.lightNodeMode && c.snapshotsPath == "" {
 		return errors.New("option snapshots-path is not specified in light mode, please specify it")
 	}
+	engine, err := ride.ParseEngine(c.rideEngineName)
+	if err != nil {
+		return fmt.Errorf("invalid option ride-engine: %w", err)
+	}
+	c.rideEngine = engine
+	if c.dbBackendName != "" {
+		backend, bErr := keyvalue.ParseBackend(c.dbBackendName)
+		if bErr != nil {
+			return fmt.Errorf("invalid option db-backend: %w", bErr)
+		}
+		c.dbBackend = backend
+	}
 	return nil
 }
 
@@ -130,8 +153,10 @@ func (c *cfg) params(maxFDs int) state.StateParams {
 	params.VerificationGoroutinesNum = c.verificationGoroutinesNum
 	params.DbParams.WriteBuffer = c.writeBufferSize * MiB
 	params.DbParams.DisableBloomFilter = c.disableBloomFilter
+	params.DbParams.Backend = c.dbBackend
 	params.StoreExtendedApiData = c.buildDataForExtendedAPI
 	params.BuildStateHashes = c.buildStateHashes
+	params.RideEngine = c.rideEngine
 	params.ProvideExtendedApi = false // We do not need to provide any APIs during import.
 	return params
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/wavesplatform/gowaves/pkg/keyvalue"
+	"github.com/wavesplatform/gowaves/pkg/logging"
+	"github.com/wavesplatform/gowaves/pkg/state"
+	"github.com/wavesplatform/gowaves/pkg/versioning"
+)
+
+func main() {
+	if err := run(); err != nil {
+		zap.S().Error(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		logLevel = zap.LevelFlag("log-level", zapcore.InfoLevel,
+			"Logging level. Supported levels: DEBUG, INFO, WARN, ERROR, FATAL. Default logging level INFO.")
+		statePath = flag.String("state-path", "", "Path to node's state directory. The node must be stopped.")
+		backend   = flag.String("backend", "", "Database backend to migrate the state to: 'leveldb' or 'badger'.")
+		keepOld   = flag.Bool("keep-old", false,
+			"Keep the old database in the state directory under the name suffixed with its backend.")
+	)
+
+	flag.Parse()
+
+	logger := logging.SetupSimpleLogger(*logLevel)
+	defer func() {
+		err := logger.Sync()
+		if err != nil && errors.Is(err, os.ErrInvalid) {
+			panic(fmt.Sprintf("Failed to close logging subsystem: %v\n", err))
+		}
+	}()
+	zap.S().Infof("Gowaves Database Migration version: %s", versioning.Version)
+
+	if *statePath == "" {
+		return errors.New("state path is required")
+	}
+	if *backend == "" {
+		return errors.New("backend is required")
+	}
+	b, err := keyvalue.ParseBackend(*backend)
+	if err != nil {
+		return err
+	}
+	if mErr := state.MigrateDatabase(*statePath, b, *keepOld); mErr != nil {
+		return fmt.Errorf("failed to migrate database: %w", mErr)
+	}
+	zap.S().Infof("State database in '%s' uses %s backend", *statePath, b)
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	stderrs "errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+const trustedNodeRequestTimeout = 30 * time.Second
+
+// bootstrapState restores the state from the state snapshot if the state directory is empty.
+// The snapshot source is either a local file or an HTTP(S) URL.
+func bootstrapState(
+	ctx context.Context,
+	nc *config,
+	statePath string,
+	params state.StateParams,
+	cfg *settings.BlockchainSettings,
+) (retErr error) {
+	if nc.bootstrapSnapshot == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(statePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "failed to read state directory")
+	}
+	if len(entries) != 0 {
+		zap.S().Infof("State directory '%s' is not empty, bootstrap from snapshot is skipped", statePath)
+		return nil
+	}
+	anchor, err := bootstrapAnchor(ctx, nc)
+	if err != nil {
+		return err
+	}
+	zap.S().Infof("Bootstrapping state from snapshot '%s', it may take a while...", nc.bootstrapSnapshot)
+	r, err := openBootstrapSnapshot(ctx, nc.bootstrapSnapshot)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if clErr := r.Close(); clErr != nil {
+			retErr = stderrs.Join(retErr, errors.Wrap(clErr, "failed to close state snapshot"))
+		}
+	}()
+	m, err := state.ImportStateSnapshot(r, statePath, params, cfg, anchor)
+	if err != nil {
+		return errors.Wrap(err, "failed to import state snapshot")
+	}
+	zap.S().Infof("State bootstrapped from snapshot at height %d, block '%s'", m.Height, m.BlockID.String())
+	return nil
+}
+
+// bootstrapAnchor returns the anchor which confirms the snapshot by the pinned block ID and by the state hashes
+// of the trusted nodes, at least one of them is required.
+func bootstrapAnchor(ctx context.Context, nc *config) (state.StateSnapshotAnchor, error) {
+	if nc.bootstrapBlockID == "" && nc.bootstrapTrustedNodes == "" {
+		return nil, errors.New("block ID or trusted nodes are required to verify the state snapshot")
+	}
+	var pinned state.StateSnapshotAnchor
+	if nc.bootstrapBlockID != "" {
+		id, err := proto.NewBlockIDFromBase58(nc.bootstrapBlockID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid bootstrap block ID '%s'", nc.bootstrapBlockID)
+		}
+		pinned = state.PinnedBlockAnchor(id)
+	}
+	return func(m *state.StateSnapshotManifest) error {
+		if pinned != nil {
+			if err := pinned(m); err != nil {
+				return err
+			}
+		}
+		return verifyByTrustedNodes(ctx, nc.bootstrapTrustedNodes, m)
+	}, nil
+}
+
+func openBootstrapSnapshot(ctx context.Context, source string) (io.ReadCloser, error) {
+	u, err := url.Parse(source)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		f, oErr := os.Open(filepath.Clean(source))
+		if oErr != nil {
+			return nil, errors.Wrap(oErr, "failed to open state snapshot file")
+		}
+		return f, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to download state snapshot")
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, errors.Errorf("failed to download state snapshot: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// verifyByTrustedNodes checks the snapshot state hash of the manifest against the hashes
+// provided by the trusted nodes' APIs.
+func verifyByTrustedNodes(ctx context.Context, nodes string, m *state.StateSnapshotManifest) error {
+	if nodes == "" {
+		return nil
+	}
+	if m.SnapshotStateHash == nil {
+		return errors.New("state snapshot has no snapshot state hash to verify by trusted nodes")
+	}
+	for _, addr := range strings.Split(nodes, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		sh, err := trustedSnapshotStateHash(ctx, addr, m.Height)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get snapshot state hash from trusted node '%s'", addr)
+		}
+		if sh != *m.SnapshotStateHash {
+			return errors.Errorf("snapshot state hash at height %d '%s' differs from '%s' of trusted node '%s'",
+				m.Height, m.SnapshotStateHash.String(), sh.String(), addr,
+			)
+		}
+		zap.S().Infof("Snapshot state hash at height %d confirmed by trusted node '%s'", m.Height, addr)
+	}
+	return nil
+}
+
+func trustedSnapshotStateHash(ctx context.Context, addr string, height uint64) (crypto.Digest, error) {
+	c, err := client.NewClient(client.Options{BaseUrl: addr, Client: &http.Client{}})
+	if err != nil {
+		return crypto.Digest{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, trustedNodeRequestTimeout)
+	defer cancel()
+	sh, _, err := c.Debug.SnapshotStateHash(ctx, height)
+	return sh, err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/wavesplatform/gowaves/pkg/settings"
+)
+
+const (
+	configFlag      = "config"
+	printConfigFlag = "print-config"
+	envPrefix       = "GOWAVES_"
+	hiddenValue     = "<hidden>"
+)
+
+// secretFlags are not shown by '-print-config'.
+var secretFlags = []string{"api-key", "wallet-password"}
+
+// envName returns the name of the environment variable overriding the flag, for example, GOWAVES_STATE_PATH
+// for 'state-path' flag.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// mergeConfig sets the flags from the configuration file and the environment variables.
+// Flags set in the command line take precedence over the environment variables,
+// which take precedence over the configuration file.
+func mergeConfig(fs *flag.FlagSet) error {
+	fromCommandLine := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { fromCommandLine[f.Name] = true })
+	fromEnv := make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		if fromCommandLine[f.Name] || f.Name == printConfigFlag {
+			return
+		}
+		if v, ok := os.LookupEnv(envName(f.Name)); ok {
+			fromEnv[f.Name] = v
+		}
+	})
+
+	path := fs.Lookup(configFlag).Value.String()
+	if v, ok := fromEnv[configFlag]; ok {
+		path = v
+	}
+	if path != "" {
+		opts, err := settings.ReadConfigFile(path)
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(opts))
+		for name := range opts {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if name == configFlag || name == printConfigFlag || fs.Lookup(name) == nil {
+				return errors.Errorf("unknown option '%s' in configuration file '%s'", name, path)
+			}
+			if _, ok := fromEnv[name]; ok || fromCommandLine[name] {
+				continue
+			}
+			if err = fs.Set(name, opts[name]); err != nil {
+				return errors.Wrapf(err, "invalid value %q of option '%s' in configuration file '%s'",
+					opts[name], name, path)
+			}
+		}
+	}
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		v, ok := fromEnv[f.Name]
+		if !ok || err != nil {
+			return
+		}
+		if sErr := fs.Set(f.Name, v); sErr != nil {
+			err = errors.Wrapf(sErr, "invalid value %q of environment variable '%s'", v, envName(f.Name))
+		}
+	})
+	return err
+}
+
+// printConfig writes the effective configuration in YAML format, the output can be used as configuration file.
+// Values of secret options are hidden.
+func printConfig(w io.Writer, fs *flag.FlagSet) error {
+	opts := make(map[string]any)
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == configFlag || f.Name == printConfigFlag {
+			return
+		}
+		if slices.Contains(secretFlags, f.Name) && f.Value.String() != "" {
+			opts[f.Name] = hiddenValue
+			return
+		}
+		opts[f.Name] = f.Value.String()
+		if g, ok := f.Value.(flag.Getter); ok {
+			switch v := g.Get().(type) {
+			case bool, int, int64, uint, uint64, float64:
+				opts[f.Name] = v
+			}
+		}
+	})
+	enc := yaml.NewEncoder(w)
+	if err := enc.Encode(opts); err != nil {
+		return errors.Wrap(err, "failed to print configuration")
+	}
+	return enc.Close()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type testFlags struct {
+	fs             *flag.FlagSet
+	statePath      string
+	apiAddr        string
+	limit          int
+	disableNTP     bool
+	apiKey         string
+	walletPassword string
+}
+
+func newTestFlags() *testFlags {
+	f := &testFlags{fs: flag.NewFlagSet("node", flag.ContinueOnError)}
+	f.fs.SetOutput(io.Discard)
+	f.fs.String(configFlag, "", "")
+	f.fs.Bool(printConfigFlag, false, "")
+	f.fs.StringVar(&f.statePath, "state-path", "", "")
+	f.fs.StringVar(&f.apiAddr, "api-address", "127.0.0.1:8080", "")
+	f.fs.IntVar(&f.limit, "limit-connections", 60, "")
+	f.fs.BoolVar(&f.disableNTP, "disable-ntp", false, "")
+	f.fs.StringVar(&f.apiKey, "api-key", "", "")
+	f.fs.StringVar(&f.walletPassword, "wallet-password", "", "")
+	return f
+}
+
+func writeConfigFile(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "node.yml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+	return path
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "GOWAVES_STATE_PATH", envName("state-path"))
+	assert.Equal(t, "GOWAVES_CONFIG", envName("config"))
+}
+
+func TestMergeConfigPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `
+state-path: /from/file
+api-address: 0.0.0.0:6869
+limit-connections: 10
+disable-ntp: true
+`)
+	t.Setenv("GOWAVES_API_ADDRESS", "0.0.0.0:7000")
+	t.Setenv("GOWAVES_LIMIT_CONNECTIONS", "20")
+	f := newTestFlags()
+	require.NoError(t, f.fs.Parse([]string{"-config", path, "-limit-connections", "30"}))
+
+	require.NoError(t, mergeConfig(f.fs))
+	assert.Equal(t, "/from/file", f.statePath) // Only in the file.
+	assert.Equal(t, "0.0.0.0:7000", f.apiAddr) // Environment overrides the file.
+	assert.Equal(t, 30, f.limit)               // Command line overrides the environment and the file.
+	assert.True(t, f.disableNTP)
+}
+
+func TestMergeConfigPathFromEnv(t *testing.T) {
+	t.Setenv("GOWAVES_CONFIG", writeConfigFile(t, "state-path: /from/file"))
+	f := newTestFlags()
+	require.NoError(t, f.fs.Parse(nil))
+
+	require.NoError(t, mergeConfig(f.fs))
+	assert.Equal(t, "/from/file", f.statePath)
+}
+
+func TestMergeConfigWithoutFile(t *testing.T) {
+	t.Setenv("GOWAVES_STATE_PATH", "/from/env")
+	f := newTestFlags()
+	require.NoError(t, f.fs.Parse(nil))
+
+	require.NoError(t, mergeConfig(f.fs))
+	assert.Equal(t, "/from/env", f.statePath)
+	assert.Equal(t, "127.0.0.1:8080", f.apiAddr)
+}
+
+func TestMergeConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		file string
+		env  map[string]string
+		err  string
+	}{
+		{name: "unknown option", file: "unknown: 1", err: "unknown option 'unknown'"},
+		{name: "nested config", file: "config: other.yml", err: "unknown option 'config'"},
+		{name: "invalid value in file", file: "limit-connections: many", err: "invalid value \"many\""},
+		{
+			name: "invalid value in environment",
+			file: "state-path: /from/file",
+			env:  map[string]string{"GOWAVES_LIMIT_CONNECTIONS": "many"},
+			err:  "environment variable 'GOWAVES_LIMIT_CONNECTIONS'",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			f := newTestFlags()
+			require.NoError(t, f.fs.Parse([]string{"-config", writeConfigFile(t, test.file)}))
+			assert.ErrorContains(t, mergeConfig(f.fs), test.err)
+		})
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	f := newTestFlags()
+	require.NoError(t, f.fs.Parse([]string{"-state-path", "/state", "-api-key", "secret", "-disable-ntp"}))
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, printConfig(buf, f.fs))
+	assert.NotContains(t, buf.String(), "secret")
+	var printed map[string]any
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &printed))
+	assert.Equal(t, map[string]any{
+		"state-path":        "/state",
+		"api-address":       "127.0.0.1:8080",
+		"limit-connections": 60,
+		"disable-ntp":       true,
+		"api-key":           hiddenValue,
+		"wallet-password":   "", // Empty secrets are shown to make clear that they are not set.
+	}, printed)
+
+	// The printed configuration can be used as configuration file.
+	path := writeConfigFile(t, buf.String())
+	g := newTestFlags()
+	require.NoError(t, g.fs.Parse([]string{"-config", path, "-api-key", "secret"}))
+	require.NoError(t, mergeConfig(g.fs))
+	assert.Equal(t, "/state", g.statePath)
+	assert.True(t, g.disableNTP)
+	assert.Equal(t, "secret", g.apiKey)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/wavesplatform/gowaves/pkg/api"
 	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/grpc/server"
+	"github.com/wavesplatform/gowaves/pkg/keyvalue"
 	"github.com/wavesplatform/gowaves/pkg/libs/microblock_cache"
 	"github.com/wavesplatform/gowaves/pkg/libs/ntptime"
 	"github.com/wavesplatform/gowaves/pkg/logging"
@@ -34,15 +35,19 @@ import (
 	"github.com/wavesplatform/gowaves/pkg/miner/scheduler"
 	"github.com/wavesplatform/gowaves/pkg/miner/utxpool"
 	"github.com/wavesplatform/gowaves/pkg/node"
+	"github.com/wavesplatform/gowaves/pkg/node/blockchain_updates"
 	"github.com/wavesplatform/gowaves/pkg/node/blocks_applier"
 	"github.com/wavesplatform/gowaves/pkg/node/messages"
 	"github.com/wavesplatform/gowaves/pkg/node/network"
 	"github.com/wavesplatform/gowaves/pkg/node/peers"
 	peersPersistentStorage "github.com/wavesplatform/gowaves/pkg/node/peers/storage"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
+	"github.com/wavesplatform/gowaves/pkg/p2p/secure"
 	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/ride"
 	"github.com/wavesplatform/gowaves/pkg/services"
 	"github.com/wavesplatform/gowaves/pkg/settings"
+	"github.com/wavesplatform/gowaves/pkg/signer"
 	"github.com/wavesplatform/gowaves/pkg/state"
 	"github.com/wavesplatform/gowaves/pkg/types"
 	"github.com/wavesplatform/gowaves/pkg/util/common"
@@ -82,6 +87,8 @@ type config struct {
 	declAddr                   string
 	nodeName                   string
 	cfgPath                    string
+	configPath                 string
+	printConfig                bool
 	apiAddr                    string
 	apiKey                     string
 	apiMaxConnections          int
@@ -95,14 +102,26 @@ type config struct {
 	buildExtendedAPI           bool
 	serveExtendedAPI           bool
 	buildStateHashes           bool
+	historicalIndex            bool
+	archival                   bool
+	bootstrapSnapshot          string
+	bootstrapTrustedNodes      string
+	bootstrapBlockID           string
+	rideEngine                 string
 	bindAddress                string
 	disableOutgoingConnections bool
 	minerVoteFeatures          string
 	disableBloomFilter         bool
+	dbBackend                  string
 	reward                     int64
 	obsolescencePeriod         time.Duration
 	walletPath                 string
 	walletPassword             string
+	signerAddress              string
+	signerTokenFile            string
+	identityKeyFile            string
+	trustedIdentityKeys        string
+	signerTimeout              time.Duration
 	limitAllConnections        uint
 	minPeersMining             int
 	disableMiner               bool
@@ -113,9 +132,16 @@ type config struct {
 	dropPeers                  bool
 	dbFileDescriptors          uint
 	newConnectionsLimit        int
+	alwaysConnect              string
+	allowOnly                  string
+	limitConnectionsPerSubnet  int
 	disableNTP                 bool
 	microblockInterval         time.Duration
 	enableLightMode            bool
+	utxJournalPath             string
+	utxSenderMaxTransactions   int
+	utxSenderMaxBytes          uint64
+	utxWhitelist               string
 }
 
 var errConfigNotParsed = stderrs.New("config is not parsed")
@@ -134,7 +160,17 @@ func (c *config) StatePath() (string, error) {
 	return path, nil
 }
 
+// initialPeers returns the addresses of peers to connect to on start, by default the well-known peers
+// of the blockchain are used if outgoing connections are enabled.
+func (c *config) initialPeers() string {
+	if c.peerAddresses == "" && !c.disableOutgoingConnections {
+		return defaultPeers[c.blockchainType]
+	}
+	return c.peerAddresses
+}
+
 func (c *config) logParameters() {
+	zap.S().Debugf("config: %s", c.configPath)
 	zap.S().Debugf("log-level: %s", c.logLevel)
 	zap.S().Debugf("log-dev: %t", c.logDevelopment)
 	zap.S().Debugf("log-network: %t", c.logNetwork)
@@ -151,6 +187,12 @@ func (c *config) logParameters() {
 	zap.S().Debugf("build-extended-api: %t", c.buildExtendedAPI)
 	zap.S().Debugf("serve-extended-api: %t", c.serveExtendedAPI)
 	zap.S().Debugf("build-state-hashes: %t", c.buildStateHashes)
+	zap.S().Debugf("historical-index: %t", c.historicalIndex)
+	zap.S().Debugf("archival: %t", c.archival)
+	zap.S().Debugf("bootstrap-snapshot: %s", c.bootstrapSnapshot)
+	zap.S().Debugf("bootstrap-trusted-nodes: %s", c.bootstrapTrustedNodes)
+	zap.S().Debugf("bootstrap-block-id: %s", c.bootstrapBlockID)
+	zap.S().Debugf("ride-engine: %s", c.rideEngine)
 	zap.S().Debugf("bind-address: %s", c.bindAddress)
 	zap.S().Debugf("vote: %s", c.minerVoteFeatures)
 	zap.S().Debugf("reward: %d", c.reward)
@@ -158,21 +200,34 @@ func (c *config) logParameters() {
 	zap.S().Debugf("disable-miner %t", c.disableMiner)
 	zap.S().Debugf("wallet-path: %s", c.walletPath)
 	zap.S().Debugf("hashed wallet-password: %s", crypto.MustKeccak256([]byte(c.walletPassword)).Hex())
+	zap.S().Debugf("signer: %s", c.signerAddress)
+	zap.S().Debugf("signer-token-file: %s", c.signerTokenFile)
+	zap.S().Debugf("signer-timeout: %s", c.signerTimeout)
 	zap.S().Debugf("limit-connections: %d", c.limitAllConnections)
 	zap.S().Debugf("profiler: %t", c.profiler)
 	zap.S().Debugf("disable-bloom: %t", c.disableBloomFilter)
+	zap.S().Debugf("db-backend: %s", c.dbBackend)
 	zap.S().Debugf("drop-peers: %t", c.dropPeers)
 	zap.S().Debugf("db-file-descriptors: %v", c.dbFileDescriptors)
 	zap.S().Debugf("new-connections-limit: %v", c.newConnectionsLimit)
+	zap.S().Debugf("always-connect: %s", c.alwaysConnect)
+	zap.S().Debugf("allow-only: %s", c.allowOnly)
+	zap.S().Debugf("limit-connections-per-subnet: %d", c.limitConnectionsPerSubnet)
+	zap.S().Debugf("identity-key-file: %s", c.identityKeyFile)
+	zap.S().Debugf("trusted-identity-keys: %s", c.trustedIdentityKeys)
 	zap.S().Debugf("enable-metamask: %t", c.enableMetaMaskAPI)
 	zap.S().Debugf("disable-ntp: %t", c.disableNTP)
 	zap.S().Debugf("microblock-interval: %s", c.microblockInterval)
 	zap.S().Debugf("enable-light-mode: %t", c.enableLightMode)
+	zap.S().Debugf("utx-journal-path: %s", c.utxJournalPath)
+	zap.S().Debugf("utx-sender-max-transactions: %d", c.utxSenderMaxTransactions)
+	zap.S().Debugf("utx-sender-max-bytes: %d", c.utxSenderMaxBytes)
+	zap.S().Debugf("utx-whitelist: %s", c.utxWhitelist)
 }
 
-func (c *config) parse() {
+func (c *config) parse() error {
 	if c.isParsed { // no need to parse twice
-		return
+		return nil
 	}
 	defer func() { c.isParsed = true }()
 	const (
@@ -181,7 +236,15 @@ func (c *config) parse() {
 		defaultConnectionsLimit           = 60
 		defaultNewConnectionLimit         = 10
 		defaultMicroblockInterval         = 5 * time.Second
+		defaultSignerTimeout              = 5 * time.Second
 	)
+	flag.StringVar(&c.configPath, configFlag, "",
+		"Path to node configuration file: YAML ('.yaml', '.yml') with options named as "+
+			"command line flags, or Scala node configuration in HOCON ('.conf'), only the options supported by "+
+			"Go node are read from it. Command line flags and environment variables named as 'GOWAVES_STATE_PATH' "+
+			"for 'state-path' flag override the options from the file.")
+	flag.BoolVar(&c.printConfig, printConfigFlag, false,
+		"Print the effective configuration of the node in YAML format and exit.")
 	l := zap.LevelFlag("log-level", zapcore.InfoLevel,
 		"Logging level. Supported levels: DEBUG, INFO, WARN, ERROR, FATAL.")
 	flag.BoolVar(&c.logDevelopment, "log-dev", false,
@@ -226,6 +289,25 @@ func (c *config) parse() {
 			"and start serving at this point.")
 	flag.BoolVar(&c.buildStateHashes, "build-state-hashes", false,
 		"Calculate and store state hashes for each block height.")
+	flag.BoolVar(&c.historicalIndex, "historical-index", false,
+		"Store the index of balances and data entries to serve queries at heights below the rollback window. "+
+			"The index is built starting from the current height of the state.")
+	flag.BoolVar(&c.archival, "archival", false,
+		"Keep the full history of the state instead of removing records beyond the rollback window. "+
+			"Requires much more disk space. For an existing state the full history is kept since the current "+
+			"start of the rollback window.")
+	flag.StringVar(&c.bootstrapSnapshot, "bootstrap-snapshot", "",
+		"Path or HTTP(S) URL of the state snapshot to bootstrap the state from instead of syncing from genesis. "+
+			"Used only if the state directory is empty. The snapshot can be exported by '/debug/snapshot' API method.")
+	flag.StringVar(&c.bootstrapTrustedNodes, "bootstrap-trusted-nodes", "",
+		"Comma separated list of trusted Go nodes' API addresses to check the snapshot state hash of the "+
+			"bootstrapped state against.")
+	flag.StringVar(&c.bootstrapBlockID, "bootstrap-block-id", "",
+		"ID of the block the state snapshot must be made at, obtained from a trusted source. "+
+			"Either this flag or 'bootstrap-trusted-nodes' is required to bootstrap the state.")
+	flag.StringVar(&c.rideEngine, "ride-engine", ride.TreeEngine.String(),
+		"Engine to execute Ride scripts: 'tree' to walk the tree of a script, "+
+			"'vm' to compile scripts into bytecode and execute them by the Ride VM.")
 	flag.StringVar(&c.bindAddress, "bind-address", "",
 		"Bind address for incoming connections. If empty, will be same as declared address")
 	flag.BoolVar(&c.disableOutgoingConnections, "no-connections", false,
@@ -234,11 +316,19 @@ func (c *config) parse() {
 	flag.StringVar(&c.minerVoteFeatures, "vote", "", "Miner vote features.")
 	flag.BoolVar(&c.disableBloomFilter, "disable-bloom", false,
 		"Disable bloom filter. Less memory usage, but decrease performance.")
+	flag.StringVar(&c.dbBackend, "db-backend", "",
+		"State database backend: 'leveldb' or 'badger'. By default the backend of existing state is used, "+
+			"'leveldb' for the new state.")
 	flag.Int64Var(&c.reward, "reward", 0, "Miner reward: for example 600000000.")
 	flag.DurationVar(&c.obsolescencePeriod, "obsolescence", defaultObsolescenceDuration,
 		"Blockchain obsolescence period. Disable mining if last block older then given value.")
 	flag.StringVar(&c.walletPath, "wallet-path", "", "Path to wallet, or ~/.waves by default.")
 	flag.StringVar(&c.walletPassword, "wallet-password", "", "Pass password for wallet.")
+	flag.StringVar(&c.signerAddress, "signer", "",
+		"Address of external signer, for example 'unix:///var/run/signer.sock' or 'tcp://10.0.0.2:6870'. "+
+			"If set, the wallet is not loaded and all signing is done by the signer.")
+	flag.StringVar(&c.signerTokenFile, "signer-token-file", "", "Path to the file with external signer token.")
+	flag.DurationVar(&c.signerTimeout, "signer-timeout", defaultSignerTimeout, "Timeout of external signer requests.")
 	flag.UintVar(&c.limitAllConnections, "limit-connections", defaultConnectionsLimit,
 		"Total limit of network connections, both inbound and outbound. Divided in half to limit each direction.")
 	flag.IntVar(&c.minPeersMining, "min-peers-mining", 1,
@@ -259,14 +349,67 @@ func (c *config) parse() {
 	flag.IntVar(&c.newConnectionsLimit, "new-connections-limit", defaultNewConnectionLimit,
 		"Number of new outbound connections established simultaneously, defaults to 10. Should be positive. "+
 			"Big numbers can badly affect file descriptors consumption.")
+	flag.StringVar(&c.alwaysConnect, "always-connect", "",
+		"Comma separated list of peers' addresses in form \"ip:port\" to keep connected all the time. "+
+			"Connection limits, bans and restrictions are not applied to these peers.")
+	flag.StringVar(&c.allowOnly, "allow-only", "",
+		"Comma separated list of subnets in CIDR notation or IP addresses. If set, the node connects to and "+
+			"accepts connections only from the peers in these subnets and 'always-connect' peers.")
+	flag.IntVar(&c.limitConnectionsPerSubnet, "limit-connections-per-subnet", 0,
+		"Maximum number of connections to peers from one /24 IPv4 or /64 IPv6 subnet. Unlimited by default.")
+	flag.StringVar(&c.identityKeyFile, "identity-key-file", "",
+		"Path to the file with Base58 encoded identity key of the node, the key is generated if the file "+
+			"does not exist. If set, the traffic with peers supporting it is encrypted and authenticated. "+
+			"Without 'trusted-identity-keys' an active man in the middle can downgrade connections to plaintext "+
+			"or impersonate the peers, so only passive eavesdropping is prevented.")
+	flag.StringVar(&c.trustedIdentityKeys, "trusted-identity-keys", "",
+		"Comma separated list of Base58 encoded identity public keys of trusted nodes. If set, the node "+
+			"requires encryption with all peers: plaintext connections and connections with the nodes having "+
+			"other keys are rejected, so the node connects only to the listed nodes. "+
+			"Requires 'identity-key-file' flag.")
 	flag.BoolVar(&c.disableNTP, "disable-ntp", false,
 		"Disable NTP synchronization. Useful when running the node in a docker container.")
 	flag.DurationVar(&c.microblockInterval, "microblock-interval", defaultMicroblockInterval,
 		"Interval between microblocks.")
 	flag.BoolVar(&c.enableLightMode, "enable-light-mode", false,
 		"Start node in light mode")
+	flag.StringVar(&c.utxJournalPath, "utx-journal-path", "",
+		"Path to the journal file of UTX pool. Transactions of UTX pool are persisted and restored after restart "+
+			"if the path is set. Disabled by default.")
+	flag.IntVar(&c.utxSenderMaxTransactions, "utx-sender-max-transactions", 0,
+		"Maximum number of transactions of one sender in UTX pool. Unlimited by default.")
+	flag.Uint64Var(&c.utxSenderMaxBytes, "utx-sender-max-bytes", 0,
+		"Maximum size in bytes of transactions of one sender in UTX pool. Unlimited by default.")
+	flag.StringVar(&c.utxWhitelist, "utx-whitelist", "",
+		"Comma separated list of addresses whose transactions are not limited and never evicted from UTX pool.")
 	flag.Parse()
+	if err := mergeConfig(flag.CommandLine); err != nil {
+		return err
+	}
 	c.logLevel = *l
+	return c.validate()
+}
+
+// validate checks the values of flags which can be checked before the start of the node.
+func (c *config) validate() error {
+	if c.cfgPath == "" {
+		if _, err := settings.BlockchainSettingsByTypeName(c.blockchainType); err != nil {
+			return errors.Wrapf(err, "invalid 'blockchain-type' flag value %q", c.blockchainType)
+		}
+	}
+	if c.newConnectionsLimit <= 0 {
+		return errors.Errorf("'new-connections-limit' flag should be positive, got %d", c.newConnectionsLimit)
+	}
+	if c.limitConnectionsPerSubnet < 0 {
+		return errors.New("'limit-connections-per-subnet' flag should not be negative")
+	}
+	if c.trustedIdentityKeys != "" && c.identityKeyFile == "" {
+		return errors.New("'trusted-identity-keys' flag requires 'identity-key-file' flag")
+	}
+	if c.bootstrapSnapshot != "" && c.bootstrapBlockID == "" && c.bootstrapTrustedNodes == "" {
+		return errors.New("'bootstrap-snapshot' flag requires 'bootstrap-block-id' or 'bootstrap-trusted-nodes' flag")
+	}
+	return nil
 }
 
 func loggerSetup(nc *config) func() {
@@ -295,7 +438,17 @@ func main() {
 
 func realMain() int {
 	nc := new(config)
-	nc.parse()
+	if err := nc.parse(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		return 2
+	}
+	if nc.printConfig {
+		if err := printConfig(os.Stdout, flag.CommandLine); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		return 0
+	}
 	syncFn := loggerSetup(nc)
 	defer syncFn()
 	err := run(nc)
@@ -367,11 +520,6 @@ func runNode(ctx context.Context, nc *config) (_ io.Closer, retErr error) {
 		return nil, errors.Wrap(err, "failed to get blockchain settings")
 	}
 
-	conf, err := nodeSettings(nc, cfg.AddressSchemeCharacter)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get node settings")
-	}
-
 	wal, err := embeddedWallet(nc, cfg.AddressSchemeCharacter)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get embedded wallet")
@@ -392,6 +540,10 @@ func runNode(ctx context.Context, nc *config) (_ io.Closer, retErr error) {
 		return nil, errors.Wrap(err, "failed to create state parameters")
 	}
 
+	if bErr := bootstrapState(ctx, nc, path, params, cfg); bErr != nil {
+		return nil, errors.Wrap(bErr, "failed to bootstrap state from snapshot")
+	}
+
 	st, err := state.NewState(path, true, params, cfg, nc.enableLightMode)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to initialize node's state")
@@ -409,9 +561,9 @@ func runNode(ctx context.Context, nc *config) (_ io.Closer, retErr error) {
 	}
 
 	parent := peer.NewParent(nc.enableLightMode)
-	declAddr := proto.NewTCPAddrFromString(conf.DeclaredAddr)
+	declAddr := proto.NewTCPAddrFromString(nc.declAddr)
 
-	peerManager, err := createPeerManager(nc, conf, parent, declAddr)
+	peerManager, err := createPeerManager(nc, proto.NetworkStrFromScheme(cfg.AddressSchemeCharacter), parent, declAddr)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create peer manager")
 	}
@@ -433,11 +585,11 @@ func runNode(ctx context.Context, nc *config) (_ io.Closer, retErr error) {
 		return nil, errors.Wrap(err, "failed to initialize application")
 	}
 
-	if pErr := spawnPeersByAddresses(ctx, conf.Addresses, peerManager); pErr != nil {
+	if pErr := spawnPeersByAddresses(ctx, nc.initialPeers(), peerManager); pErr != nil {
 		return nil, errors.Wrap(pErr, "failed to spawn peers by addresses")
 	}
 
-	if apiErr := runAPIs(ctx, nc, conf, app, svs); apiErr != nil {
+	if apiErr := runAPIs(ctx, nc, app, svs); apiErr != nil {
 		return nil, errors.Wrap(apiErr, "failed to run APIs")
 	}
 
@@ -464,6 +616,10 @@ func startNode(
 	n := node.NewNode(svs, declAddr, bindAddr, nc.microblockInterval, nc.enableLightMode)
 	go n.Run(ctx, parent, svs.InternalChannel, networkInfoCh, ntw.SyncPeer())
 
+	if svs.BlockchainUpdates != nil {
+		go svs.BlockchainUpdates.Run(ctx)
+	}
+
 	go minerScheduler.Reschedule() // Reschedule mining after node start
 
 	return n
@@ -517,13 +673,27 @@ func stateParams(nc *config, ntpTime types.Time) (state.StateParams, error) {
 			nc.dbFileDescriptors,
 		)
 	}
+	engine, err := ride.ParseEngine(nc.rideEngine)
+	if err != nil {
+		return state.StateParams{}, errors.Wrap(err, "invalid 'ride-engine' flag value")
+	}
 	params := state.DefaultStateParams()
 	params.DbParams.OpenFilesCacheCapacity = int(dbFileDescriptors)
 	params.StoreExtendedApiData = nc.buildExtendedAPI
 	params.ProvideExtendedApi = nc.serveExtendedAPI
 	params.BuildStateHashes = nc.buildStateHashes
+	params.StoreHistoricalIndex = nc.historicalIndex
+	params.Archival = nc.archival
+	params.RideEngine = engine
 	params.Time = ntpTime
 	params.DbParams.DisableBloomFilter = nc.disableBloomFilter
+	if nc.dbBackend != "" {
+		backend, bErr := keyvalue.ParseBackend(nc.dbBackend)
+		if bErr != nil {
+			return state.StateParams{}, errors.Wrap(bErr, "invalid 'db-backend' flag value")
+		}
+		params.DbParams.Backend = backend
+	}
 	return params, nil
 }
 
@@ -609,21 +779,19 @@ func runGRPCServer(ctx context.Context, addr string, nc *config, svs services.Se
 	return nil
 }
 
-func nodeSettings(nc *config, scheme proto.Scheme) (*settings.NodeSettings, error) {
-	conf := &settings.NodeSettings{}
-	err := settings.ApplySettings(conf, FromArgs(scheme, nc), settings.FromJavaEnviron)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to apply node settings")
-	}
-
-	err = conf.Validate()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to validate node settings")
-	}
-	return conf, nil
-}
-
 func embeddedWallet(nc *config, scheme proto.Scheme) (types.EmbeddedWallet, error) {
+	if nc.signerAddress != "" {
+		network, address, err := signer.ParseAddress(nc.signerAddress)
+		if err != nil {
+			return nil, err
+		}
+		token, err := signer.LoadToken(nc.signerTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		zap.S().Infof("Using external signer at '%s'", nc.signerAddress)
+		return wallet.NewExternalWallet(signer.NewRemote(network, address, token, nc.signerTimeout), scheme), nil
+	}
 	wal := wallet.NewEmbeddedWallet(wallet.NewLoader(nc.walletPath), wallet.NewWallet(), scheme)
 	if nc.walletPassword != "" {
 		if err := wal.Load([]byte(nc.walletPassword)); err != nil {
@@ -702,7 +870,7 @@ func closeIfErrorf(closer io.Closer, retErr error, format string, args ...interf
 
 func createPeerManager(
 	nc *config,
-	conf *settings.NodeSettings,
+	network string,
 	parent peer.Parent,
 	declAddr proto.TCPAddr,
 ) (*peers.PeerManagerImpl, error) {
@@ -711,18 +879,27 @@ func createPeerManager(
 		return nil, errors.Wrap(err, "failed to get node's nonce")
 	}
 
+	secureCfg, err := secureTransport(nc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to configure secure transport")
+	}
 	peerSpawnerImpl := peers.NewPeerSpawner(
 		parent,
-		conf.WavesNetwork,
+		network,
 		declAddr,
 		nc.nodeName,
 		nodeNonce.Uint64(),
 		proto.ProtocolVersion(),
+		secureCfg,
 	)
 	peerStorage, err := peersPersistentStorage.NewCBORStorage(nc.statePath, time.Now())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to open or create peers storage")
 	}
+	rules, err := connectionRules(nc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse connection rules")
+	}
 	if nc.dropPeers {
 		if err := peerStorage.DropStorage(); err != nil {
 			return nil, errors.Wrap(err, "failed to drop peers storage (drop peers storage manually)")
@@ -734,13 +911,63 @@ func createPeerManager(
 		peerStorage,
 		int(nc.limitAllConnections/2),
 		proto.ProtocolVersion(),
-		conf.WavesNetwork,
+		network,
 		!nc.disableOutgoingConnections,
 		nc.newConnectionsLimit,
 		nc.blackListResidenceTime,
+		rules,
 	), nil
 }
 
+func connectionRules(nc *config) (peers.ConnectionRules, error) {
+	rules := peers.ConnectionRules{MaxConnectionsPerSubnet: nc.limitConnectionsPerSubnet}
+	if nc.alwaysConnect != "" {
+		for _, s := range strings.Split(nc.alwaysConnect, ",") {
+			peerInfos, err := proto.NewPeerInfosFromString(strings.TrimSpace(s))
+			if err != nil {
+				return peers.ConnectionRules{}, errors.Wrapf(err, "invalid address %q in 'always-connect' flag", s)
+			}
+			for _, pi := range peerInfos {
+				rules.AlwaysConnect = append(rules.AlwaysConnect, proto.NewTCPAddr(pi.Addr, int(pi.Port)))
+			}
+		}
+	}
+	if nc.allowOnly != "" {
+		for _, s := range strings.Split(nc.allowOnly, ",") {
+			subnet, err := peers.ParseSubnet(strings.TrimSpace(s))
+			if err != nil {
+				return peers.ConnectionRules{}, errors.Wrap(err, "invalid 'allow-only' flag")
+			}
+			rules.AllowOnly = append(rules.AllowOnly, subnet)
+		}
+	}
+	return rules, nil
+}
+
+func secureTransport(nc *config) (*secure.Config, error) {
+	if nc.identityKeyFile == "" {
+		return nil, nil
+	}
+	sk, err := secure.LoadIdentityKey(nc.identityKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	var trusted []crypto.PublicKey
+	if nc.trustedIdentityKeys != "" {
+		for _, s := range strings.Split(nc.trustedIdentityKeys, ",") {
+			pk, pkErr := crypto.NewPublicKeyFromBase58(strings.TrimSpace(s))
+			if pkErr != nil {
+				return nil, errors.Wrapf(pkErr, "invalid key %q in 'trusted-identity-keys' flag", s)
+			}
+			trusted = append(trusted, pk)
+		}
+	}
+	cfg := secure.NewConfig(sk, trusted)
+	zap.S().Infof("Secure transport is enabled, node identity key '%s', required: %t",
+		cfg.PublicKey(), cfg.Required())
+	return cfg, nil
+}
+
 func createServices(
 	nc *config,
 	st state.State,
@@ -755,59 +982,93 @@ func createServices(
 	if err != nil {
 		return services.Services{}, errors.Wrap(err, "failed to initialize UTX")
 	}
+	utx, err := createUtxPool(nc, st, utxValidator, cfg, ntpTime)
+	if err != nil {
+		return services.Services{}, errors.Wrap(err, "failed to initialize UTX")
+	}
+	var (
+		applier   services.BlocksApplier = blocks_applier.NewBlocksApplier()
+		publisher *blockchain_updates.Publisher
+	)
+	microCache := microblock_cache.NewMicroBlockCache()
+	if nc.enableGrpcAPI {
+		publisher, err = blockchain_updates.NewPublisher(st, microCache, cfg.AddressSchemeCharacter)
+		if err != nil {
+			return services.Services{}, errors.Wrap(err, "failed to initialize blockchain updates")
+		}
+		applier = blockchain_updates.NewBlocksApplier(blocks_applier.NewBlocksApplier(), publisher)
+	}
 	return services.Services{
-		State:           st,
-		Peers:           peerManager,
-		Scheduler:       scheduler,
-		BlocksApplier:   blocks_applier.NewBlocksApplier(),
-		UtxPool:         utxpool.New(utxPoolMaxSizeBytes, utxValidator, cfg),
-		Scheme:          cfg.AddressSchemeCharacter,
-		Time:            ntpTime,
-		Wallet:          wal,
-		MicroBlockCache: microblock_cache.NewMicroBlockCache(),
-		InternalChannel: messages.NewInternalChannel(),
-		MinPeersMining:  nc.minPeersMining,
-		SkipMessageList: parent.SkipMessageList,
+		State:             st,
+		Peers:             peerManager,
+		Scheduler:         scheduler,
+		BlocksApplier:     applier,
+		UtxPool:           utx,
+		Scheme:            cfg.AddressSchemeCharacter,
+		Time:              ntpTime,
+		Wallet:            wal,
+		MicroBlockCache:   microCache,
+		InternalChannel:   messages.NewInternalChannel(),
+		MinPeersMining:    nc.minPeersMining,
+		SkipMessageList:   parent.SkipMessageList,
+		BlockchainUpdates: publisher,
 	}, nil
 }
 
+func createUtxPool(
+	nc *config,
+	st state.State,
+	validator utxpool.Validator,
+	cfg *settings.BlockchainSettings,
+	ntpTime types.Time,
+) (types.UtxPool, error) {
+	limits := utxpool.Limits{
+		MaxSenderTransactions: nc.utxSenderMaxTransactions,
+		MaxSenderBytes:        nc.utxSenderMaxBytes,
+	}
+	if nc.utxWhitelist != "" {
+		for _, s := range strings.Split(nc.utxWhitelist, ",") {
+			addr, err := proto.NewAddressFromString(strings.TrimSpace(s))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid address %q in 'utx-whitelist' flag", s)
+			}
+			limits.Whitelist = append(limits.Whitelist, addr)
+		}
+	}
+	if nc.utxJournalPath == "" {
+		return utxpool.NewWithLimits(utxPoolMaxSizeBytes, limits, validator, cfg), nil
+	}
+	utx, err := utxpool.NewWithJournal(utxPoolMaxSizeBytes, limits, validator, cfg, nc.utxJournalPath)
+	if err != nil {
+		return nil, err
+	}
+	// Restored transactions are validated all together against the current state.
+	utxpool.NewCleaner(st, utx, ntpTime).Clean()
+	return utx, nil
+}
+
 func runAPIs(
 	ctx context.Context,
 	nc *config,
-	conf *settings.NodeSettings,
 	app *api.App,
 	svs services.Services,
 ) error {
 	if nc.enableGrpcAPI {
-		if sErr := runGRPCServer(ctx, conf.GrpcAddr, nc, svs); sErr != nil {
+		if sErr := runGRPCServer(ctx, nc.grpcAddr, nc, svs); sErr != nil {
 			return errors.Wrap(sErr, "failed to run gRPC server")
 		}
 	}
 
 	webAPI := api.NewNodeAPI(app, svs.State)
 	go func() {
-		zap.S().Infof("Starting node HTTP API on '%v'", conf.HttpAddr)
-		if runErr := api.Run(ctx, conf.HttpAddr, webAPI, apiRunOptsFromCLIFlags(nc)); runErr != nil {
+		zap.S().Infof("Starting node HTTP API on '%v'", nc.apiAddr)
+		if runErr := api.Run(ctx, nc.apiAddr, webAPI, apiRunOptsFromCLIFlags(nc)); runErr != nil {
 			zap.S().Errorf("Failed to start API: %v", runErr)
 		}
 	}()
 	return nil
 }
 
-func FromArgs(scheme proto.Scheme, c *config) func(s *settings.NodeSettings) error {
-	return func(s *settings.NodeSettings) error {
-		s.DeclaredAddr = c.declAddr
-		s.HttpAddr = c.apiAddr
-		s.GrpcAddr = c.grpcAddr
-		s.WavesNetwork = proto.NetworkStrFromScheme(scheme)
-		s.Addresses = c.peerAddresses
-		if c.peerAddresses == "" && !c.disableOutgoingConnections {
-			s.Addresses = defaultPeers[c.blockchainType]
-		}
-		return nil
-	}
-}
-
 func apiRunOptsFromCLIFlags(c *config) *api.RunOptions {
 	// TODO: add more run flags to CLI flags
 	opts := api.DefaultRunOptions()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"sort"
@@ -37,20 +38,22 @@ func NewHttpServer(r Retransmitter) *HttpServer {
 }
 
 type ActiveConnection struct {
-	Addr          string        `json:"addr"`
-	DeclAddr      string        `json:"decl_addr"`
-	Direction     string        `json:"direction"`
-	RemoteAddr    string        `json:"remote_addr"`
-	LocalAddr     string        `json:"local_addr"`
-	Version       proto.Version `json:"version"`
-	AppName       string        `json:"app_name"`
-	NodeName      string        `json:"node_name"`
-	SendClosed    bool          `json:"send_closed"`
-	ReceiveClosed bool          `json:"receive_closed"`
+	Addr       string        `json:"addr"`
+	DeclAddr   string        `json:"decl_addr"`
+	Direction  string        `json:"direction"`
+	RemoteAddr string        `json:"remote_addr"`
+	LocalAddr  string        `json:"local_addr"`
+	Version    proto.Version `json:"version"`
+	AppName    string        `json:"app_name"`
+	NodeName   string        `json:"node_name"`
 }
 
 type ActiveConnections []ActiveConnection
 
+type localAddressable interface {
+	LocalAddr() net.Addr
+}
+
 func (a ActiveConnections) Len() int           { return len(a) }
 func (a ActiveConnections) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ActiveConnections) Less(i, j int) bool { return a[i].Addr < a[j].Addr }
@@ -65,18 +68,19 @@ func (a *HttpServer) ActiveConnections(rw http.ResponseWriter, _ *http.Request)
 	var out ActiveConnections
 	addr2peer := a.retransmitter.ActiveConnections()
 	addr2peer.Each(func(p peer.Peer) {
-		c := p.Connection()
+		var localAddr string
+		if la, ok := p.(localAddressable); ok {
+			localAddr = la.LocalAddr().String()
+		}
 		out = append(out, ActiveConnection{
-			Addr:          p.RemoteAddr().String(),
-			Direction:     p.Direction().String(),
-			DeclAddr:      p.Handshake().DeclaredAddr.String(),
-			RemoteAddr:    p.RemoteAddr().String(),
-			LocalAddr:     p.Connection().Conn().LocalAddr().String(),
-			Version:       p.Handshake().Version,
-			AppName:       p.Handshake().AppName,
-			NodeName:      p.Handshake().NodeName,
-			SendClosed:    c.SendClosed(),
-			ReceiveClosed: c.ReceiveClosed(),
+			Addr:       p.RemoteAddr().String(),
+			Direction:  p.Direction().String(),
+			DeclAddr:   p.Handshake().DeclaredAddr.String(),
+			RemoteAddr: p.RemoteAddr().String(),
+			LocalAddr:  localAddr,
+			Version:    p.Handshake().Version,
+			AppName:    p.Handshake().AppName,
+			NodeName:   p.Handshake().NodeName,
 		})
 	})
 
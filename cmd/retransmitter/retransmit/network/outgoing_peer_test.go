@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/wavesplatform/gowaves/pkg/networking"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
 
 	"github.com/stretchr/testify/assert"
@@ -98,6 +99,7 @@ func TestOutgoingPeer_SendMessage(t *testing.T) {
 	}
 
 	params := OutgoingPeerParams{
+		Network:  networking.NewNetwork(),
 		Address:  server.Addr().String(),
 		Parent:   parent,
 		DeclAddr: proto.TCPAddr{},
@@ -5,7 +5,7 @@ import (
 	"net"
 
 	"github.com/wavesplatform/gowaves/cmd/retransmitter/retransmit/network"
-	"github.com/wavesplatform/gowaves/pkg/p2p/conn"
+	"github.com/wavesplatform/gowaves/pkg/networking"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 )
@@ -17,15 +17,17 @@ type PeerSpawner interface {
 
 type PeerOutgoingSpawnerImpl struct {
 	parent       peer.Parent
+	network      *networking.Network
 	wavesNetwork string
 	declAddr     proto.TCPAddr
-	skipFunc     conn.SkipFilter
+	skipFunc     peer.SkipFilter
 }
 
-func NewPeerSpawner(skipFunc conn.SkipFilter, parent peer.Parent, WavesNetwork string, declAddr proto.TCPAddr) *PeerOutgoingSpawnerImpl {
+func NewPeerSpawner(skipFunc peer.SkipFilter, parent peer.Parent, WavesNetwork string, declAddr proto.TCPAddr) *PeerOutgoingSpawnerImpl {
 	return &PeerOutgoingSpawnerImpl{
 		skipFunc:     skipFunc,
 		parent:       parent,
+		network:      networking.NewNetwork(),
 		wavesNetwork: WavesNetwork,
 		declAddr:     declAddr,
 	}
@@ -33,6 +35,7 @@ func NewPeerSpawner(skipFunc conn.SkipFilter, parent peer.Parent, WavesNetwork s
 
 func (a *PeerOutgoingSpawnerImpl) SpawnOutgoing(ctx context.Context, address string) {
 	params := network.OutgoingPeerParams{
+		Network:      a.network,
 		Address:      address,
 		WavesNetwork: a.wavesNetwork,
 		Parent:       a.parent,
@@ -45,6 +48,7 @@ func (a *PeerOutgoingSpawnerImpl) SpawnOutgoing(ctx context.Context, address str
 
 func (a *PeerOutgoingSpawnerImpl) SpawnIncoming(ctx context.Context, c net.Conn) {
 	params := network.IncomingPeerParams{
+		Network:      a.network,
 		WavesNetwork: a.wavesNetwork,
 		Conn:         c,
 		Skip:         a.skipFunc,
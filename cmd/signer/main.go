@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/howeyc/gopass"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/wavesplatform/gowaves/pkg/logging"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/signer"
+	"github.com/wavesplatform/gowaves/pkg/versioning"
+	"github.com/wavesplatform/gowaves/pkg/wallet"
+)
+
+func main() {
+	if err := run(); err != nil {
+		zap.S().Error(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		logLevel = zap.LevelFlag("log-level", zapcore.InfoLevel,
+			"Logging level. Supported levels: DEBUG, INFO, WARN, ERROR, FATAL. Default logging level INFO.")
+		walletPath   = flag.String("wallet", "", "Path to the wallet file, or ~/.waves by default.")
+		passwordFile = flag.String("wallet-password-file", "",
+			"Path to the file with the wallet password. The password is requested interactively if not set.")
+		listen = flag.String("listen", "",
+			"Address to listen on, for example 'unix:///var/run/signer.sock' or 'tcp://10.0.0.2:6870'.")
+		tokenFile = flag.String("token-file", "", "Path to the file with the token shared with the node.")
+	)
+
+	flag.Parse()
+
+	logger := logging.SetupSimpleLogger(*logLevel)
+	defer func() {
+		err := logger.Sync()
+		if err != nil && errors.Is(err, os.ErrInvalid) {
+			panic(fmt.Sprintf("Failed to close logging subsystem: %v\n", err))
+		}
+	}()
+	zap.S().Infof("Gowaves Signer version: %s", versioning.Version)
+
+	if *listen == "" {
+		return errors.New("listen address is required")
+	}
+	network, address, err := signer.ParseAddress(*listen)
+	if err != nil {
+		return err
+	}
+	token, err := signer.LoadToken(*tokenFile)
+	if err != nil {
+		return err
+	}
+	password, err := readPassword(*passwordFile)
+	if err != nil {
+		return err
+	}
+	// The scheme is used only to sign transactions, which are signed on the node side.
+	w := wallet.NewEmbeddedWallet(wallet.NewLoader(*walletPath), wallet.NewWallet(), proto.MainNetScheme)
+	if lErr := w.Load(password); lErr != nil {
+		return fmt.Errorf("failed to load wallet: %w", lErr)
+	}
+	pks, err := w.PublicKeys()
+	if err != nil {
+		return fmt.Errorf("failed to get wallet accounts: %w", err)
+	}
+	for _, pk := range pks {
+		zap.S().Infof("Account with public key '%s' is available for signing", pk.String())
+	}
+
+	l, err := listenSigner(network, address)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	zap.S().Infof("Signer is listening on '%s'", *listen)
+	if sErr := signer.NewServer(w, token).Serve(ctx, l); sErr != nil {
+		return fmt.Errorf("signer failed: %w", sErr)
+	}
+	zap.S().Info("Signer stopped")
+	return nil
+}
+
+// listenSigner creates the listener, the stale Unix socket file is replaced and the new one is made
+// accessible only by the owner.
+func listenSigner(network, address string) (net.Listener, error) {
+	if network == "unix" {
+		if err := os.Remove(address); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+	if network == "unix" {
+		if chErr := os.Chmod(address, 0600); chErr != nil {
+			_ = l.Close()
+			return nil, fmt.Errorf("failed to restrict socket permissions: %w", chErr)
+		}
+	}
+	return l, nil
+}
+
+func readPassword(path string) ([]byte, error) {
+	if path != "" {
+		b, err := os.ReadFile(path) // #nosec: in this case check for prevent G304 (CWE-22) is not necessary
+		if err != nil {
+			return nil, fmt.Errorf("failed to read wallet password: %w", err)
+		}
+		return []byte(strings.TrimRight(string(b), "\r\n")), nil
+	}
+	fmt.Print("Enter password to decode your wallet: ")
+	p, err := gopass.GetPasswd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the input password: %w", err)
+	}
+	return p, nil
+}
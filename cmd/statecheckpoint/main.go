@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ccoveille/go-safecast"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/wavesplatform/gowaves/pkg/logging"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+	"github.com/wavesplatform/gowaves/pkg/state"
+	"github.com/wavesplatform/gowaves/pkg/util/fdlimit"
+	"github.com/wavesplatform/gowaves/pkg/versioning"
+)
+
+const nodeAPITimeout = 6 * time.Hour
+
+func main() {
+	if err := run(); err != nil {
+		zap.S().Error(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		logLevel = zap.LevelFlag("log-level", zapcore.InfoLevel,
+			"Logging level. Supported levels: DEBUG, INFO, WARN, ERROR, FATAL. Default logging level INFO.")
+		statePath      = flag.String("state-path", "", "Path to node's state directory")
+		blockchainType = flag.String("blockchain-type", "mainnet", "Blockchain type: mainnet/testnet/stagenet")
+		cfgPath        = flag.String("cfg-path", "", "Path to configuration JSON file, only for custom blockchain.")
+		output         = flag.String("output", "",
+			"Directory to write the checkpoint to. Must be absent or empty.")
+		restore = flag.Bool("restore", false,
+			"Restore the checkpoint from the directory set by 'input' flag into the empty 'state-path' directory.")
+		input   = flag.String("input", "", "Directory of the checkpoint to restore.")
+		archive = flag.Bool("snapshot", false,
+			"Use portable state snapshot archive file instead of checkpoint directory for 'output' and 'input'.")
+		blockID = flag.String("block-id", "",
+			"ID of the block the imported state snapshot must be made at, obtained from a trusted source. "+
+				"Required to restore the state snapshot.")
+		nodeAPI = flag.String("node-api", "",
+			"URL of the running node's API, e.g. 'http://127.0.0.1:6869'. If set, the running node writes "+
+				"the checkpoint to the 'output' directory on its host.")
+		apiKey           = flag.String("api-key", "", "API key of the running node.")
+		buildExtendedAPI = flag.Bool("build-extended-api", false,
+			"State stores data for extended API. Must match the flag the state was imported with.")
+		buildStateHashes = flag.Bool("build-state-hashes", false,
+			"State stores state hashes. Must match the flag the state was imported with.")
+		disableBloomFilter = flag.Bool("disable-bloom", false, "Disable bloom filter for state.")
+	)
+
+	flag.Parse()
+
+	logger := logging.SetupSimpleLogger(*logLevel)
+	defer func() {
+		err := logger.Sync()
+		if err != nil && errors.Is(err, os.ErrInvalid) {
+			panic(fmt.Sprintf("Failed to close logging subsystem: %v\n", err))
+		}
+	}()
+	zap.S().Infof("Gowaves State Checkpoint version: %s", versioning.Version)
+
+	if *nodeAPI != "" {
+		return checkpointByNode(*nodeAPI, *apiKey, *output)
+	}
+
+	maxFDs, err := fdlimit.MaxFDs()
+	if err != nil {
+		return fmt.Errorf("initialization error: %w", err)
+	}
+	if _, err = fdlimit.RaiseMaxFDs(maxFDs); err != nil {
+		return fmt.Errorf("initialization error: %w", err)
+	}
+
+	cfg, err := blockchainSettings(*cfgPath, *blockchainType)
+	if err != nil {
+		return err
+	}
+
+	params := state.DefaultStateParams()
+	const fdSigma = 10
+	c, err := safecast.ToInt(maxFDs - fdSigma)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	params.DbParams.OpenFilesCacheCapacity = c
+	params.DbParams.DisableBloomFilter = *disableBloomFilter
+	params.StoreExtendedApiData = *buildExtendedAPI
+	params.BuildStateHashes = *buildStateHashes
+
+	if *statePath == "" {
+		return errors.New("state path is required")
+	}
+	if *restore {
+		if *input == "" {
+			return errors.New("checkpoint input directory is required")
+		}
+		if *archive {
+			return importStateSnapshot(*input, *blockID, *statePath, params, cfg)
+		}
+		info, rErr := state.RestoreCheckpoint(*input, *statePath, params, cfg)
+		if rErr != nil {
+			return fmt.Errorf("failed to restore checkpoint: %w", rErr)
+		}
+		zap.S().Infof("Checkpoint at height %d (block '%s') restored to '%s'",
+			info.Height, info.BlockID.String(), *statePath)
+		return nil
+	}
+	if *output == "" {
+		return errors.New("checkpoint output directory is required")
+	}
+	return checkpointOffline(*statePath, *output, *archive, params, cfg)
+}
+
+func blockchainSettings(cfgPath, blockchainType string) (*settings.BlockchainSettings, error) {
+	if cfgPath == "" {
+		return settings.BlockchainSettingsByTypeName(blockchainType)
+	}
+	f, err := os.Open(filepath.Clean(cfgPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open configuration file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	cfg, err := settings.ReadBlockchainSettings(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+	return cfg, nil
+}
+
+// checkpointOffline writes the checkpoint of the state which is not used by a running node.
+func checkpointOffline(
+	statePath, output string,
+	archive bool,
+	params state.StateParams,
+	cfg *settings.BlockchainSettings,
+) (err error) {
+	s, err := state.NewState(statePath, true, params, cfg, false)
+	if err != nil {
+		return fmt.Errorf("failed to open state: %w", err)
+	}
+	defer func() {
+		if clErr := s.Close(); clErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to close state: %w", clErr))
+		}
+	}()
+	cp, err := s.NewCheckpoint()
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	defer cp.Release()
+	if archive {
+		if eErr := exportStateSnapshot(cp, output); eErr != nil {
+			return fmt.Errorf("failed to export state snapshot: %w", eErr)
+		}
+	} else if wErr := cp.WriteTo(output); wErr != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", wErr)
+	}
+	zap.S().Infof("Checkpoint at height %d (block '%s') written to '%s'",
+		cp.Info().Height, cp.Info().BlockID.String(), output)
+	return nil
+}
+
+func exportStateSnapshot(cp *state.Checkpoint, output string) (err error) {
+	f, err := os.OpenFile(filepath.Clean(output), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if clErr := f.Close(); clErr != nil {
+			err = errors.Join(err, clErr)
+		}
+		if err != nil {
+			_ = os.Remove(output)
+		}
+	}()
+	if eErr := cp.ExportStateSnapshot(f); eErr != nil {
+		return eErr
+	}
+	return f.Sync()
+}
+
+func importStateSnapshot(
+	input, blockID, statePath string,
+	params state.StateParams,
+	cfg *settings.BlockchainSettings,
+) (err error) {
+	if blockID == "" {
+		return errors.New("block ID is required to verify the state snapshot")
+	}
+	id, err := proto.NewBlockIDFromBase58(blockID)
+	if err != nil {
+		return fmt.Errorf("invalid block ID: %w", err)
+	}
+	f, err := os.Open(filepath.Clean(input))
+	if err != nil {
+		return fmt.Errorf("failed to open state snapshot: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	m, err := state.ImportStateSnapshot(f, statePath, params, cfg, state.PinnedBlockAnchor(id))
+	if err != nil {
+		return fmt.Errorf("failed to import state snapshot: %w", err)
+	}
+	zap.S().Infof("State snapshot at height %d (block '%s') imported to '%s'",
+		m.Height, m.BlockID.String(), statePath)
+	return nil
+}
+
+// checkpointByNode asks the running node to write the checkpoint to the output directory on the node's host.
+func checkpointByNode(nodeAPI, apiKey, output string) error {
+	if output == "" {
+		return errors.New("checkpoint output directory is required")
+	}
+	u, err := url.JoinPath(nodeAPI, "/debug/checkpoint")
+	if err != nil {
+		return fmt.Errorf("invalid node API URL: %w", err)
+	}
+	body, err := json.Marshal(struct {
+		Path string `json:"path"`
+	}{Path: output})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), nodeAPITimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", apiKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request checkpoint: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("node failed to write checkpoint (%s): %s", resp.Status, string(data))
+	}
+	var info state.CheckpointInfo
+	if err = json.Unmarshal(data, &info); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	zap.S().Infof("Checkpoint at height %d (block '%s') written by node to '%s'",
+		info.Height, info.BlockID.String(), output)
+	return nil
+}
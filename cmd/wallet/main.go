@@ -31,11 +31,14 @@ const (
 	seedPhraseOpt        = "seed-phrase"
 	seedPhraseBase58Opt  = "seed-phrase-base58"
 	accountSeedBase58Opt = "account-seed-base58"
+	importScalaOpt       = "import-scala-wallet"
 
 	schemeOpt = "scheme"
 )
 
-var primaryFlags = []string{newOpt, showOpt, seedPhraseOpt, seedPhraseBase58Opt, accountSeedBase58Opt}
+var primaryFlags = []string{
+	newOpt, showOpt, seedPhraseOpt, seedPhraseBase58Opt, accountSeedBase58Opt, importScalaOpt,
+}
 
 const (
 	defaultBitSize    = 160
@@ -56,6 +59,7 @@ Examples:
 	./wallet -seed-phrase "..."			Import a seed phrase
 	./wallet -seed-phrase-base58 "..."		Import a Base58 encoded seed phrase
 	./wallet -account-seed-base58 "..."		Import a Base58 encoded account seed
+	./wallet -import-scala-wallet wallet.dat	Import accounts from the Scala node wallet file
 `
 
 func schemeFromString(s string) (proto.Scheme, error) {
@@ -71,6 +75,7 @@ type Opts struct {
 	seedPhrase        string
 	base58SeedPhrase  string
 	base58AccountSeed string
+	scalaWalletPath   string
 }
 
 func main() {
@@ -87,6 +92,7 @@ func main() {
 	flag.StringVar(&opts.seedPhrase, seedPhraseOpt, "", "Import a seed phrase (Primary flag)")
 	flag.StringVar(&opts.base58SeedPhrase, seedPhraseBase58Opt, "", "Import a base58-encoded seed phrase (Primary flag)")
 	flag.StringVar(&opts.base58AccountSeed, accountSeedBase58Opt, "", "Import a base58-encoded account seed (Primary flag)")
+	flag.StringVar(&opts.scalaWalletPath, importScalaOpt, "", "Import accounts from the Scala node wallet.dat file (Primary flag)")
 	flag.StringVar(&walletPath, "wallet", "", "Path to the wallet file")
 	flag.IntVar(&accountNumber, "number", 0, "Account number. 0 is default")
 	flag.StringVar(&sch, schemeOpt, "W", "Network scheme: MainNet=W, TestNet=T, StageNet=S, CustomNet=E. MainNet is default")
@@ -127,6 +133,11 @@ func main() {
 		if err != nil {
 			log.Printf("Failed to create a new wallet: %v", err)
 		}
+	case importScalaOpt:
+		err = importScalaWallet(opts.scalaWalletPath, walletPath, scheme)
+		if err != nil {
+			log.Printf("Failed to import Scala wallet: %v", err)
+		}
 	default:
 		showUsageAndExit()
 	}
@@ -151,10 +162,28 @@ func ReadWallet(walletPath string) (wallet.Wallet, []byte, error) {
 		return nil, nil, errors.Wrap(err, "failed to decode the wallet")
 
 	}
+	if wallet.NeedsUpgrade(b) {
+		if uErr := writeWallet(walletPath, wlt, pass); uErr != nil {
+			return nil, nil, errors.Wrap(uErr, "failed to upgrade the wallet")
+		}
+		fmt.Println("Wallet has been upgraded to the new format")
+	}
 
 	return wlt, pass, nil
 }
 
+// writeWallet encodes the wallet with the password and atomically replaces the wallet file.
+func writeWallet(walletPath string, wlt wallet.Wallet, password []byte) error {
+	bts, err := wlt.Encode(password)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode the wallet with the provided password")
+	}
+	if err := wallet.NewLoader(walletPath).Save(bts); err != nil {
+		return errors.Wrap(err, "failed to write the wallet's data to the wallet")
+	}
+	return nil
+}
+
 var reASCII = regexp.MustCompile(`^[\x20-\x7E]+$`)
 
 func isASCII(s []byte) bool {
@@ -392,33 +421,9 @@ func createWallet(
 		return errors.New("failed to generate wallet's credentials")
 	}
 
-	var oldWallet bool
-	var password []byte
-	var wlt wallet.Wallet
-	if exists(walletPath) {
-		fmt.Print("Wallet already exists. Do you want to [A]dd / [O]verwrite / [C]ancel? ")
-		var a string
-		_, err := fmt.Scanf("%s", &a)
-		if err != nil {
-			return errors.Wrap(err, "failed to get the answer on rewriting the existing wallet")
-		}
-		answer := strings.ToLower(a)
-		switch answer {
-		case "o":
-			wlt = wallet.NewWallet()
-		case "a":
-			oldWallet = true
-			wlt, password, err = ReadWallet(walletPath)
-			if err != nil {
-				return err
-			}
-		case "c":
-			return nil
-		default:
-			return nil
-		}
-	} else {
-		wlt = wallet.NewWallet()
+	wlt, password, err := openWalletForUpdate(walletPath)
+	if err != nil || wlt == nil {
+		return err
 	}
 
 	err = wlt.AddAccountSeed(walletCredentials.accountSeed.Bytes())
@@ -426,34 +431,127 @@ func createWallet(
 		return errors.Wrap(err, "failed to add the account seed to the wallet")
 	}
 
-	if !oldWallet {
-		fmt.Print("Enter password to encode your account seed: ")
-		password, err = gopass.GetPasswd()
+	if password == nil {
+		password, err = readNewPassword()
 		if err != nil {
-			return errors.Wrap(err, "failed to get the password to encode the account seed")
+			return err
 		}
+	}
 
-		if len(password) == 0 {
-			return errors.Wrap(err, "the password's length is zero")
-		}
+	err = writeWallet(walletPath, wlt, password)
+	if err != nil {
+		return err
 	}
+	fmt.Printf("New account has been to wallet successfully %s\n", walletPath)
+	fmt.Printf("Account Seed:   %s\n", walletCredentials.accountSeed.String())
+	fmt.Printf("Public Key:     %s\n", walletCredentials.pk.String())
+	fmt.Printf("Secret Key:     %s\n", walletCredentials.sk.String())
+	fmt.Printf("Address:        %s\n", walletCredentials.address.String())
+	return nil
+}
 
-	bts, err := wlt.Encode(password)
+// openWalletForUpdate asks the user what to do with the existing wallet. It returns the existing wallet with
+// its password if the user chooses to add accounts, or the new wallet without the password.
+// The nil wallet is returned if the operation was cancelled.
+func openWalletForUpdate(walletPath string) (wallet.Wallet, []byte, error) {
+	if !exists(walletPath) {
+		return wallet.NewWallet(), nil, nil
+	}
+	fmt.Print("Wallet already exists. Do you want to [A]dd / [O]verwrite / [C]ancel? ")
+	var a string
+	_, err := fmt.Scanf("%s", &a)
 	if err != nil {
-		return errors.Wrap(err, "failed to encode the wallet with the provided password")
+		return nil, nil, errors.Wrap(err, "failed to get the answer on rewriting the existing wallet")
+	}
+	answer := strings.ToLower(a)
+	switch answer {
+	case "o":
+		return wallet.NewWallet(), nil, nil
+	case "a":
+		return ReadWallet(walletPath)
+	default:
+		return nil, nil, nil
+	}
+}
 
+func readNewPassword() ([]byte, error) {
+	fmt.Print("Enter password to encode your account seed: ")
+	password, err := gopass.GetPasswd()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the password to encode the account seed")
+	}
+	if len(password) == 0 {
+		return nil, errors.New("the password's length is zero")
 	}
+	return password, nil
+}
 
-	err = os.WriteFile(walletPath, bts, 0600)
+func importScalaWallet(scalaWalletPath, walletPath string, scheme proto.Scheme) error {
+	if scalaWalletPath == "" {
+		return errors.Wrap(wrongProgramArguments, "no Scala wallet file was provided")
+	}
+	walletPath, err := getWalletPath(walletPath)
 	if err != nil {
-		return errors.Wrap(err, "failed to write the wallet's data to the wallet")
+		return errors.Wrap(err, "failed to handle wallet's path")
+	}
+	data, err := os.ReadFile(filepath.Clean(scalaWalletPath))
+	if err != nil {
+		return errors.Wrap(err, "failed to read the Scala wallet")
+	}
+	fmt.Print("Enter password of the Scala wallet: ")
+	scalaPassword, err := gopass.GetPasswd()
+	if err != nil {
+		return errors.Wrap(err, "failed to get the input password")
+	}
+	fmt.Println("Decrypting the Scala wallet, it may take a while...")
+	sw, err := wallet.DecodeScalaWallet(data, scalaPassword)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode the Scala wallet")
+	}
+	if len(sw.AccountSeeds) == 0 {
+		return errors.New("no accounts in the Scala wallet")
+	}
 
+	wlt, password, err := openWalletForUpdate(walletPath)
+	if err != nil || wlt == nil {
+		return err
+	}
+	known := make(map[string]struct{}, len(wlt.AccountSeeds()))
+	for _, s := range wlt.AccountSeeds() {
+		known[string(s)] = struct{}{}
+	}
+	var imported [][]byte
+	for _, s := range sw.AccountSeeds {
+		if _, ok := known[string(s)]; ok {
+			continue
+		}
+		known[string(s)] = struct{}{}
+		if aErr := wlt.AddAccountSeed(s); aErr != nil {
+			return errors.Wrap(aErr, "failed to add the account seed to the wallet")
+		}
+		imported = append(imported, s)
+	}
+	if len(imported) == 0 {
+		fmt.Println("All accounts of the Scala wallet are already in the wallet")
+		return nil
+	}
+	if password == nil {
+		password, err = readNewPassword()
+		if err != nil {
+			return err
+		}
+	}
+	if wErr := writeWallet(walletPath, wlt, password); wErr != nil {
+		return wErr
+	}
+	fmt.Printf("%d accounts have been imported to wallet %s\n", len(imported), walletPath)
+	for _, s := range imported {
+		_, _, address, genErr := generateOnAccountSeed(s, scheme)
+		if genErr != nil {
+			return errors.Wrap(genErr, "failed to receive wallet's credentials")
+		}
+		fmt.Printf("Address:        %s\n", address.String())
 	}
-	fmt.Printf("New account has been to wallet successfully %s\n", walletPath)
-	fmt.Printf("Account Seed:   %s\n", walletCredentials.accountSeed.String())
-	fmt.Printf("Public Key:     %s\n", walletCredentials.pk.String())
-	fmt.Printf("Secret Key:     %s\n", walletCredentials.sk.String())
-	fmt.Printf("Address:        %s\n", walletCredentials.address.String())
 	return nil
 }
 
@@ -1,6 +1,36 @@
 package api
 
-import "github.com/pkg/errors"
+import (
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+
+	apiErrs "github.com/wavesplatform/gowaves/pkg/api/errors"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+// maxDataKeysPerRequest limits the number of keys requested in one call of /addresses/data/{address}.
+const maxDataKeysPerRequest = 1000
+
+type addressBalance struct {
+	Address       proto.WavesAddress `json:"address"`
+	Confirmations uint64             `json:"confirmations"`
+	Balance       uint64             `json:"balance"`
+	Height        proto.Height       `json:"height,omitempty"`
+}
+
+type addressBalanceDetails struct {
+	Address    proto.WavesAddress `json:"address"`
+	Regular    uint64             `json:"regular"`
+	Generating uint64             `json:"generating"`
+	Available  uint64             `json:"available"`
+	Effective  uint64             `json:"effective"`
+}
 
 func (a *App) Addresses() ([]string, error) {
 	accounts, err := a.Accounts()
@@ -15,3 +45,219 @@ func (a *App) Addresses() ([]string, error) {
 
 	return addresses, nil
 }
+
+func addressFromURLParam(r *http.Request) (proto.WavesAddress, error) {
+	addr, err := proto.NewAddressFromString(chi.URLParam(r, "address"))
+	if err != nil {
+		return proto.WavesAddress{}, apiErrs.InvalidAddress
+	}
+	return addr, nil
+}
+
+// heightFromQuery returns the value of optional 'height' query parameter, zero means that parameter is not set.
+func heightFromQuery(r *http.Request) (proto.Height, error) {
+	s := r.URL.Query().Get("height")
+	if s == "" {
+		return 0, nil
+	}
+	height, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || height == 0 {
+		return 0, apiErrs.NewCustomValidationError("invalid height")
+	}
+	return height, nil
+}
+
+func (a *NodeApi) AddressesBalance(w http.ResponseWriter, r *http.Request) error {
+	addr, err := addressFromURLParam(r)
+	if err != nil {
+		return err
+	}
+	height, err := heightFromQuery(r)
+	if err != nil {
+		return err
+	}
+	var balance uint64
+	if height != 0 {
+		balance, err = a.state.WavesBalanceAtHeight(proto.NewRecipientFromAddress(addr), height)
+	} else {
+		balance, err = a.state.WavesBalance(proto.NewRecipientFromAddress(addr))
+	}
+	if err != nil {
+		if state.IsInvalidInput(err) {
+			return apiErrs.NewCustomValidationError(err.Error())
+		}
+		return errors.Wrapf(err, "failed to get Waves balance of address %q", addr.String())
+	}
+	resp := addressBalance{Address: addr, Confirmations: 0, Balance: balance, Height: height}
+	if err := trySendJson(w, resp); err != nil {
+		return errors.Wrap(err, "AddressesBalance")
+	}
+	return nil
+}
+
+func (a *NodeApi) AddressesBalanceWithConfirmations(w http.ResponseWriter, r *http.Request) error {
+	addr, err := addressFromURLParam(r)
+	if err != nil {
+		return err
+	}
+	confirmations, err := strconv.ParseUint(chi.URLParam(r, "confirmations"), 10, 64)
+	if err != nil {
+		return apiErrs.NewCustomValidationError("invalid confirmations")
+	}
+	balance, err := a.state.WavesBalanceWithConfirmations(proto.NewRecipientFromAddress(addr), confirmations)
+	if err != nil {
+		if state.IsInvalidInput(err) {
+			return apiErrs.NewCustomValidationError(err.Error())
+		}
+		return errors.Wrapf(err, "failed to get Waves balance of address %q", addr.String())
+	}
+	resp := addressBalance{Address: addr, Confirmations: confirmations, Balance: balance}
+	if err := trySendJson(w, resp); err != nil {
+		return errors.Wrap(err, "AddressesBalanceWithConfirmations")
+	}
+	return nil
+}
+
+func (a *NodeApi) AddressesBalanceDetails(w http.ResponseWriter, r *http.Request) error {
+	addr, err := addressFromURLParam(r)
+	if err != nil {
+		return err
+	}
+	balance, err := a.state.FullWavesBalance(proto.NewRecipientFromAddress(addr))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get full Waves balance of address %q", addr.String())
+	}
+	resp := addressBalanceDetails{
+		Address:    addr,
+		Regular:    balance.Regular,
+		Generating: balance.Generating,
+		Available:  balance.Available,
+		Effective:  balance.Effective,
+	}
+	if err := trySendJson(w, resp); err != nil {
+		return errors.Wrap(err, "AddressesBalanceDetails")
+	}
+	return nil
+}
+
+func (a *NodeApi) AddressesEffectiveBalance(w http.ResponseWriter, r *http.Request) error {
+	addr, err := addressFromURLParam(r)
+	if err != nil {
+		return err
+	}
+	balance, err := a.state.FullWavesBalance(proto.NewRecipientFromAddress(addr))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get full Waves balance of address %q", addr.String())
+	}
+	resp := addressBalance{Address: addr, Confirmations: 0, Balance: balance.Effective}
+	if err := trySendJson(w, resp); err != nil {
+		return errors.Wrap(err, "AddressesEffectiveBalance")
+	}
+	return nil
+}
+
+func (a *NodeApi) AddressesDataGet(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	return a.addressesData(w, r, query.Get("matches"), query["key"])
+}
+
+func (a *NodeApi) AddressesDataPost(w http.ResponseWriter, r *http.Request) error {
+	var keys []string
+	// Parameters of media type, like charset, don't matter for the form.
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err == nil && mediaType == "application/x-www-form-urlencoded" {
+		if err := r.ParseForm(); err != nil {
+			return apiErrs.NewCustomValidationError(err.Error())
+		}
+		keys = r.PostForm["key"]
+	} else {
+		var data struct {
+			Keys []string `json:"keys"`
+		}
+		if err := tryParseJson(r.Body, &data); err != nil {
+			return apiErrs.NewWrongJsonError(err.Error(), nil)
+		}
+		keys = data.Keys
+	}
+	return a.addressesData(w, r, r.URL.Query().Get("matches"), keys)
+}
+
+func (a *NodeApi) addressesData(w http.ResponseWriter, r *http.Request, matches string, keys []string) error {
+	addr, err := addressFromURLParam(r)
+	if err != nil {
+		return err
+	}
+	if matches != "" && len(keys) > 0 {
+		return apiErrs.NewCustomValidationError("Cannot specify key and matches both")
+	}
+	if len(keys) > maxDataKeysPerRequest {
+		return apiErrs.NewTooBigArrayAllocationError(maxDataKeysPerRequest)
+	}
+	recipient := proto.NewRecipientFromAddress(addr)
+	entries := make(proto.DataEntries, 0, len(keys))
+	if len(keys) > 0 {
+		for _, key := range keys {
+			entry, rErr := a.state.RetrieveEntry(recipient, key)
+			if rErr != nil {
+				if state.IsNotFound(rErr) {
+					continue
+				}
+				return errors.Wrapf(rErr, "failed to retrieve data entry %q of address %q", key, addr.String())
+			}
+			entries = append(entries, entry)
+		}
+	} else {
+		all, rErr := a.state.RetrieveEntries(recipient)
+		if rErr != nil && !state.IsNotFound(rErr) {
+			return errors.Wrapf(rErr, "failed to retrieve data entries of address %q", addr.String())
+		}
+		var re *regexp.Regexp
+		if matches != "" {
+			re, err = regexp.Compile("^(?:" + matches + ")$") // keys must match entirely
+			if err != nil {
+				return apiErrs.NewCustomValidationError(err.Error())
+			}
+		}
+		for _, entry := range all {
+			if re != nil && !re.MatchString(entry.GetKey()) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	if err := trySendJson(w, entries); err != nil {
+		return errors.Wrap(err, "AddressesData")
+	}
+	return nil
+}
+
+func (a *NodeApi) AddressesDataKey(w http.ResponseWriter, r *http.Request) error {
+	addr, err := addressFromURLParam(r)
+	if err != nil {
+		return err
+	}
+	key := chi.URLParam(r, "key")
+	height, err := heightFromQuery(r)
+	if err != nil {
+		return err
+	}
+	var entry proto.DataEntry
+	if height != 0 {
+		entry, err = a.state.RetrieveEntryAtHeight(proto.NewRecipientFromAddress(addr), key, height)
+	} else {
+		entry, err = a.state.RetrieveEntry(proto.NewRecipientFromAddress(addr), key)
+	}
+	if err != nil {
+		if state.IsInvalidInput(err) {
+			return apiErrs.NewCustomValidationError(err.Error())
+		}
+		if state.IsNotFound(err) {
+			return apiErrs.DataKeyDoesNotExist
+		}
+		return errors.Wrapf(err, "failed to retrieve data entry %q of address %q", key, addr.String())
+	}
+	if err := trySendJson(w, entry); err != nil {
+		return errors.Wrap(err, "AddressesDataKey")
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/mock"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/services"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+const testAddress = "3PAWwWa6GbwcJaFzwqXQN5KQm7H96Y7SHTQ"
+
+func newAddressesTestRouter(t *testing.T, st state.State) http.Handler {
+	app, err := NewApp("api-key", nil, services.Services{State: st, Scheme: proto.MainNetScheme})
+	require.NoError(t, err)
+	r, err := NewNodeAPI(app, st).routes(&RunOptions{})
+	require.NoError(t, err)
+	return r
+}
+
+func doAddressesRequest(t *testing.T, h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestNodeApi_AddressesBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	addr := proto.MustAddressFromString(testAddress)
+	rcp := proto.NewRecipientFromAddress(addr)
+
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().WavesBalance(rcp).Return(uint64(100), nil)
+	st.EXPECT().WavesBalanceWithConfirmations(rcp, uint64(10)).Return(uint64(50), nil)
+	st.EXPECT().FullWavesBalance(rcp).Return(&proto.FullWavesBalance{
+		Regular: 100, Generating: 40, Available: 90, Effective: 80,
+	}, nil).Times(2)
+	h := newAddressesTestRouter(t, st)
+
+	resp := doAddressesRequest(t, h, http.MethodGet, "/addresses/balance/"+testAddress, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"address":"`+testAddress+`","confirmations":0,"balance":100}`, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/addresses/balance/"+testAddress+"/10", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"address":"`+testAddress+`","confirmations":10,"balance":50}`, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/addresses/balance/details/"+testAddress, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t,
+		`{"address":"`+testAddress+`","regular":100,"generating":40,"available":90,"effective":80}`,
+		resp.Body.String(),
+	)
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/addresses/effectiveBalance/"+testAddress, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"address":"`+testAddress+`","confirmations":0,"balance":80}`, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/addresses/balance/invalid", "")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestNodeApi_AddressesData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	addr := proto.MustAddressFromString(testAddress)
+	rcp := proto.NewRecipientFromAddress(addr)
+	entries := []proto.DataEntry{
+		&proto.IntegerDataEntry{Key: "int_1", Value: 1},
+		&proto.StringDataEntry{Key: "str_1", Value: "one"},
+		&proto.BooleanDataEntry{Key: "xint_2", Value: true},
+	}
+
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().RetrieveEntries(rcp).Return(entries, nil).Times(2)
+	st.EXPECT().RetrieveEntry(rcp, "int_1").Return(entries[0], nil).Times(4)
+	st.EXPECT().RetrieveEntry(rcp, "missing").Return(nil, state.NewStateError(state.NotFoundError, nil)).Times(4)
+	h := newAddressesTestRouter(t, st)
+
+	decode := func(resp *httptest.ResponseRecorder) proto.DataEntries {
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var res proto.DataEntries
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &res))
+		return res
+	}
+
+	res := decode(doAddressesRequest(t, h, http.MethodGet, "/addresses/data/"+testAddress, ""))
+	assert.Equal(t, proto.DataEntries(entries), res)
+
+	res = decode(doAddressesRequest(t, h, http.MethodGet, "/addresses/data/"+testAddress+"?matches=int_.*", ""))
+	assert.Equal(t, proto.DataEntries{entries[0]}, res)
+
+	res = decode(doAddressesRequest(t, h, http.MethodGet,
+		"/addresses/data/"+testAddress+"?key=int_1&key=missing", ""))
+	assert.Equal(t, proto.DataEntries{entries[0]}, res)
+
+	res = decode(doAddressesRequest(t, h, http.MethodPost,
+		"/addresses/data/"+testAddress, `{"keys":["missing","int_1"]}`))
+	assert.Equal(t, proto.DataEntries{entries[0]}, res)
+
+	req := httptest.NewRequest(http.MethodPost, "/addresses/data/"+testAddress, strings.NewReader("key=missing&key=int_1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	res = decode(resp)
+	assert.Equal(t, proto.DataEntries{entries[0]}, res)
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/addresses/data/"+testAddress+"?key=a&matches=b", "")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/addresses/data/"+testAddress+"/int_1", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"key":"int_1","type":"integer","value":1}`, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/addresses/data/"+testAddress+"/missing", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestNodeApi_AtHeight(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	addr := proto.MustAddressFromString(testAddress)
+	rcp := proto.NewRecipientFromAddress(addr)
+	assetID := crypto.MustDigestFromBase58("DHgwrRvVyqJsepd32YbBqUeDH4GJ1N984X8QoekjgH8J")
+	unavailable := state.NewStateError(state.InvalidInputError, errors.New("history unavailable"))
+
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().WavesBalanceAtHeight(rcp, uint64(10)).Return(uint64(70), nil)
+	st.EXPECT().WavesBalanceAtHeight(rcp, uint64(5)).Return(uint64(0), unavailable)
+	st.EXPECT().AssetBalance(rcp, proto.AssetIDFromDigest(assetID)).Return(uint64(300), nil)
+	st.EXPECT().AssetBalanceAtHeight(rcp, proto.AssetIDFromDigest(assetID), uint64(10)).Return(uint64(200), nil)
+	st.EXPECT().RetrieveEntryAtHeight(rcp, "int_1", uint64(10)).
+		Return(&proto.IntegerDataEntry{Key: "int_1", Value: 5}, nil)
+	st.EXPECT().RetrieveEntryAtHeight(rcp, "missing", uint64(10)).
+		Return(nil, state.NewStateError(state.NotFoundError, nil))
+	h := newAddressesTestRouter(t, st)
+
+	resp := doAddressesRequest(t, h, http.MethodGet, "/addresses/balance/"+testAddress+"?height=10", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"address":"`+testAddress+`","confirmations":0,"balance":70,"height":10}`, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/addresses/balance/"+testAddress+"?height=5", "")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/addresses/balance/"+testAddress+"?height=0", "")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/assets/balance/"+testAddress+"/"+assetID.String(), "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t,
+		`{"address":"`+testAddress+`","assetId":"`+assetID.String()+`","balance":300}`,
+		resp.Body.String(),
+	)
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/assets/balance/"+testAddress+"/"+assetID.String()+"?height=10", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t,
+		`{"address":"`+testAddress+`","assetId":"`+assetID.String()+`","balance":200,"height":10}`,
+		resp.Body.String(),
+	)
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/addresses/data/"+testAddress+"/int_1?height=10", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"key":"int_1","type":"integer","value":5}`, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/addresses/data/"+testAddress+"/missing?height=10", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
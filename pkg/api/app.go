@@ -7,8 +7,10 @@ import (
 
 	"github.com/pkg/errors"
 
+	apiErrs "github.com/wavesplatform/gowaves/pkg/api/errors"
 	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/miner/scheduler"
+	"github.com/wavesplatform/gowaves/pkg/miner/utxpool"
 	"github.com/wavesplatform/gowaves/pkg/node/messages"
 	"github.com/wavesplatform/gowaves/pkg/node/peers"
 	"github.com/wavesplatform/gowaves/pkg/proto"
@@ -30,17 +32,24 @@ type SchedulerEmits interface {
 const (
 	defaultBlockRequestLimit = 100
 	defaultAssetDetailsLimit = 100
+
+	defaultTransactionsByAddressLimit = 1000
 )
 
 type appSettings struct {
 	BlockRequestLimit uint64
 	AssetDetailsLimit int
+	// TransactionsByAddressLimit bounds both the page size of /transactions/address
+	// and the number of IDs accepted by /transactions/status.
+	TransactionsByAddressLimit int
 }
 
 func defaultAppSettings() *appSettings {
 	return &appSettings{
 		BlockRequestLimit: defaultBlockRequestLimit,
 		AssetDetailsLimit: defaultAssetDetailsLimit,
+
+		TransactionsByAddressLimit: defaultTransactionsByAddressLimit,
 	}
 }
 
@@ -125,6 +134,10 @@ func (a *App) TransactionsBroadcast(ctx context.Context, b []byte) (proto.Transa
 		return nil, errors.New("timeout waiting response from internal")
 	case err := <-respCh:
 		if err != nil {
+			var re *utxpool.RejectionError
+			if errors.As(err, &re) {
+				return nil, apiErrs.NewTransactionRejectedError(re.Reason.String(), err.Error())
+			}
 			return nil, err
 		}
 		return realType, nil
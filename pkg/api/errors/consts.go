@@ -49,6 +49,7 @@ const (
 	OrderInvalidErrorID               TransactionErrorID = 403
 	InvalidChainIdErrorID             TransactionErrorID = 404
 	InvalidProofsErrorID              TransactionErrorID = 405
+	TransactionRejectedErrorID        TransactionErrorID = 406
 	InvalidTransactionIdErrorID       TransactionErrorID = 4001
 	InvalidBlockIdErrorID             TransactionErrorID = 4002
 	InvalidAssetIdErrorID             TransactionErrorID = 4007
@@ -94,6 +95,7 @@ var errorNames = map[Identifier]string{
 	OrderInvalidErrorID:               "OrderInvalidError",
 	InvalidChainIdErrorID:             "InvalidChainIdError",
 	InvalidProofsErrorID:              "InvalidProofsError",
+	TransactionRejectedErrorID:        "TransactionRejectedError",
 	InvalidTransactionIdErrorID:       "InvalidTransactionIdError",
 	InvalidBlockIdErrorID:             "InvalidBlockIdError",
 	InvalidAssetIdErrorID:             "InvalidAssetIdError",
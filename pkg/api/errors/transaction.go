@@ -44,6 +44,10 @@ type (
 	InvalidBlockIdError       transactionError
 	InvalidAssetIdError       transactionError
 	AssetIdNotSpecifiedError  transactionError
+	TransactionRejectedError  struct {
+		transactionError
+		Reason string `json:"reason"`
+	}
 )
 
 var (
@@ -119,3 +123,17 @@ func NewAssetsDoesNotExistError(ids []string) *AssetsDoesNotExistError {
 		IDs: ids,
 	}
 }
+
+// NewTransactionRejectedError is returned when a valid transaction is not accepted to UTX pool by its limits.
+func NewTransactionRejectedError(reason, message string) *TransactionRejectedError {
+	return &TransactionRejectedError{
+		transactionError: transactionError{
+			genericError: genericError{
+				ID:       TransactionRejectedErrorID,
+				HttpCode: http.StatusBadRequest,
+				Message:  message,
+			},
+		},
+		Reason: reason,
+	}
+}
@@ -47,10 +47,16 @@ type (
 	MistimingError                            validationError
 	DataKeyDoesNotExistError                  validationError
 	ScriptCompilerError                       validationError
-	ScriptExecutionError                      validationErrorWithTransaction
 	TransactionNotAllowedByAccountScriptError validationErrorWithTransaction
 )
 
+// ScriptExecutionError describes failed script evaluation, it contains spent complexity and the call stack.
+type ScriptExecutionError struct {
+	validationErrorWithTransaction
+	Complexity int      `json:"complexity"`
+	CallStack  []string `json:"callStack,omitempty"`
+}
+
 func (e StateCheckFailedError) MarshalJSON() ([]byte, error) {
 	errorJson, err := json.Marshal(e.validationErrorWithTransaction)
 	if err != nil {
@@ -171,6 +177,32 @@ func NewCustomValidationError(message string) *CustomValidationError {
 	}
 }
 
+func NewScriptCompilerError(message string) *ScriptCompilerError {
+	return &ScriptCompilerError{
+		genericError: genericError{
+			ID:       ScriptCompilerErrorID,
+			HttpCode: http.StatusBadRequest,
+			Message:  message,
+		},
+	}
+}
+
+func NewScriptExecutionError(message string, complexity int, callStack []string) *ScriptExecutionError {
+	return &ScriptExecutionError{
+		validationErrorWithTransaction: validationErrorWithTransaction{
+			validationError: validationError{
+				genericError: genericError{
+					ID:       ScriptExecutionErrorErrorID,
+					HttpCode: http.StatusBadRequest,
+					Message:  message,
+				},
+			},
+		},
+		Complexity: complexity,
+		CallStack:  callStack,
+	}
+}
+
 func NewAliasDoesNotExistError(aliasFull string) *AliasDoesNotExistError {
 	return &AliasDoesNotExistError{
 		genericError: genericError{
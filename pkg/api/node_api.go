@@ -7,8 +7,10 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -33,6 +35,8 @@ const (
 type NodeApi struct {
 	state state.State
 	app   *App
+	// checkpointing is set while the state checkpoint is being written.
+	checkpointing atomic.Bool
 }
 
 func NewNodeAPI(app *App, state state.State) *NodeApi {
@@ -568,12 +572,18 @@ func (a *NodeApi) NodeStatus(w http.ResponseWriter, r *http.Request) error {
 		StateHeight      uint64 `json:"stateHeight"`
 		UpdatedTimestamp int64  `json:"updatedTimestamp"`
 		UpdatedDate      string `json:"updatedDate"`
+		Archival         bool   `json:"archival"`
+		ArchivalHeight   uint64 `json:"archivalHeight,omitempty"`
 	}
 
 	stateHeight, err := a.app.state.Height()
 	if err != nil {
 		return errors.Wrap(err, "failed to get state height in NodeStatus HTTP endpoint")
 	}
+	archivalHeight, err := a.state.ArchivalHeight()
+	if err != nil {
+		return errors.Wrap(err, "failed to get archival height in NodeStatus HTTP endpoint")
+	}
 
 	blockHeader := a.state.TopBlock()
 	updatedTimestampMillis := int64(blockHeader.Timestamp)
@@ -584,6 +594,8 @@ func (a *NodeApi) NodeStatus(w http.ResponseWriter, r *http.Request) error {
 		StateHeight:      stateHeight,
 		UpdatedTimestamp: updatedTimestampMillis,
 		UpdatedDate:      time.UnixMilli(updatedTimestampMillis).UTC().Format(time.RFC3339Nano),
+		Archival:         archivalHeight != 0,
+		ArchivalHeight:   archivalHeight,
 	}
 	if err := trySendJson(w, out); err != nil {
 		return errors.Wrap(err, "NodeStatus")
@@ -632,6 +644,57 @@ func (a *NodeApi) PeersBlackListed(w http.ResponseWriter, _ *http.Request) error
 	return nil
 }
 
+func (a *NodeApi) PeersReputation(w http.ResponseWriter, _ *http.Request) error {
+	rs := a.app.PeersReputation()
+	if err := trySendJson(w, rs); err != nil {
+		return errors.Wrap(err, "PeersReputation")
+	}
+	return nil
+}
+
+func (a *NodeApi) PeersBannedSubnets(w http.ResponseWriter, _ *http.Request) error {
+	rs := a.app.PeersBannedSubnets()
+	if err := trySendJson(w, rs); err != nil {
+		return errors.Wrap(err, "PeersBannedSubnets")
+	}
+	return nil
+}
+
+type PeersBanRequest struct {
+	Subnet string `json:"subnet"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (a *NodeApi) PeersBan(w http.ResponseWriter, r *http.Request) error {
+	req := &PeersBanRequest{}
+	if err := tryParseJson(r.Body, req); err != nil {
+		return errors.Wrap(err, "failed to parse PeersBan request body as JSON")
+	}
+	rs, err := a.app.PeersBan(req.Subnet, req.Reason)
+	if err != nil {
+		return errors.Wrapf(err, "failed to ban subnet %q", req.Subnet)
+	}
+	if err := trySendJson(w, rs); err != nil {
+		return errors.Wrap(err, "PeersBan")
+	}
+	return nil
+}
+
+func (a *NodeApi) PeersUnban(w http.ResponseWriter, r *http.Request) error {
+	req := &PeersBanRequest{}
+	if err := tryParseJson(r.Body, req); err != nil {
+		return errors.Wrap(err, "failed to parse PeersUnban request body as JSON")
+	}
+	rs, err := a.app.PeersUnban(req.Subnet)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unban subnet %q", req.Subnet)
+	}
+	if err := trySendJson(w, rs); err != nil {
+		return errors.Wrap(err, "PeersUnban")
+	}
+	return nil
+}
+
 func (a *NodeApi) PeersClearBlackList(w http.ResponseWriter, _ *http.Request) error {
 	rs := a.app.PeersClearBlackList()
 	if err := trySendJson(w, rs); err != nil {
@@ -732,6 +795,66 @@ func (a *NodeApi) RollbackTo(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+type checkpointRequest struct {
+	Path string `json:"path"`
+}
+
+// Checkpoint writes a consistent copy of the state at the current height into the directory on the node's host.
+// The node keeps working while the copy is written.
+func (a *NodeApi) Checkpoint(w http.ResponseWriter, r *http.Request) error {
+	req := &checkpointRequest{}
+	if err := tryParseJson(r.Body, req); err != nil {
+		return errors.Wrap(err, "failed to parse Checkpoint body as JSON")
+	}
+	if !filepath.IsAbs(req.Path) {
+		return apiErrs.NewCustomValidationError("Checkpoint path should be absolute")
+	}
+	if !a.checkpointing.CompareAndSwap(false, true) {
+		return apiErrs.NewCustomValidationError("Checkpoint is already in progress")
+	}
+	defer a.checkpointing.Store(false)
+	cp, err := a.state.NewCheckpoint()
+	if err != nil {
+		return errors.Wrap(err, "failed to create checkpoint")
+	}
+	defer cp.Release()
+	if wErr := cp.WriteTo(req.Path); wErr != nil {
+		if errors.Is(wErr, state.ErrCheckpointInvalidated) {
+			return apiErrs.NewCustomValidationError(wErr.Error())
+		}
+		return errors.Wrapf(wErr, "failed to write checkpoint to '%s'", req.Path)
+	}
+	if err = trySendJson(w, cp.Info()); err != nil {
+		return errors.Wrap(err, "Checkpoint")
+	}
+	return nil
+}
+
+// StateSnapshot streams the state snapshot archive of the state at the current height.
+// The archive can be used to bootstrap another node, see state.ImportStateSnapshot.
+func (a *NodeApi) StateSnapshot(w http.ResponseWriter, _ *http.Request) error {
+	if !a.checkpointing.CompareAndSwap(false, true) {
+		return apiErrs.NewCustomValidationError("Checkpoint is already in progress")
+	}
+	defer a.checkpointing.Store(false)
+	cp, err := a.state.NewCheckpoint()
+	if err != nil {
+		return errors.Wrap(err, "failed to create checkpoint")
+	}
+	defer cp.Release()
+	info := cp.Info()
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"state-%c-%d.tar.gz\"", info.Scheme, info.Height),
+	)
+	if eErr := cp.ExportStateSnapshot(w); eErr != nil {
+		// Response is partially sent, so the only way to report the error is to abort the connection.
+		zap.S().Errorf("Failed to export state snapshot at height %d: %v", info.Height, eErr)
+		panic(http.ErrAbortHandler)
+	}
+	return nil
+}
+
 type walletLoadKeysRequest struct {
 	Password string `json:"password"`
 }
@@ -987,6 +1110,133 @@ func (a *NodeApi) assetsDetailsByIDs(w http.ResponseWriter, fullQueryParam strin
 	return nil
 }
 
+type assetBalance struct {
+	Address proto.WavesAddress `json:"address"`
+	AssetID crypto.Digest      `json:"assetId"`
+	Balance uint64             `json:"balance"`
+	Height  proto.Height       `json:"height,omitempty"`
+}
+
+func (a *NodeApi) AssetsBalance(w http.ResponseWriter, r *http.Request) error {
+	addr, err := addressFromURLParam(r)
+	if err != nil {
+		return err
+	}
+	fullAssetID, err := crypto.NewDigestFromBase58(chi.URLParam(r, "assetId"))
+	if err != nil {
+		return apiErrs.InvalidAssetId
+	}
+	height, err := heightFromQuery(r)
+	if err != nil {
+		return err
+	}
+	var (
+		rcp     = proto.NewRecipientFromAddress(addr)
+		assetID = proto.AssetIDFromDigest(fullAssetID)
+		balance uint64
+	)
+	if height != 0 {
+		balance, err = a.state.AssetBalanceAtHeight(rcp, assetID, height)
+	} else {
+		balance, err = a.state.AssetBalance(rcp, assetID)
+	}
+	if err != nil {
+		if state.IsInvalidInput(err) {
+			return apiErrs.NewCustomValidationError(err.Error())
+		}
+		return errors.Wrapf(err, "failed to get balance of asset %q of address %q", fullAssetID, addr.String())
+	}
+	resp := assetBalance{Address: addr, AssetID: fullAssetID, Balance: balance, Height: height}
+	if err := trySendJson(w, resp); err != nil {
+		return errors.Wrap(err, "AssetsBalance")
+	}
+	return nil
+}
+
+// maxAssetDistributionLimit is the maximum number of holders on a page of asset distribution.
+const maxAssetDistributionLimit = 1000
+
+type assetDistributionPage struct {
+	HasNext  bool                   `json:"hasNext"`
+	LastItem *proto.WavesAddress    `json:"lastItem"`
+	Items    assetDistributionItems `json:"items"`
+}
+
+// assetDistributionItems is marshaled into JSON object that keeps the order of holders.
+type assetDistributionItems []proto.AssetHolding
+
+func (items assetDistributionItems) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, len(items)*(proto.WavesAddressSize*2+24))
+	buf = append(buf, '{')
+	for i, h := range items {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendQuote(buf, h.Address.String())
+		buf = append(buf, ':')
+		buf = strconv.AppendUint(buf, h.Balance, 10)
+	}
+	return append(buf, '}'), nil
+}
+
+func (a *NodeApi) AssetsDistribution(w http.ResponseWriter, r *http.Request) error {
+	fullAssetID, err := crypto.NewDigestFromBase58(chi.URLParam(r, "assetId"))
+	if err != nil {
+		return apiErrs.InvalidAssetId
+	}
+	height, err := strconv.ParseUint(chi.URLParam(r, "height"), 10, 64)
+	if err != nil {
+		return apiErrs.NewCustomValidationError("invalid height")
+	}
+	limit, err := strconv.Atoi(chi.URLParam(r, "limit"))
+	if err != nil || limit <= 0 || limit > maxAssetDistributionLimit {
+		return apiErrs.NewCustomValidationError(
+			fmt.Sprintf("Limit should be between 1 and %d", maxAssetDistributionLimit),
+		)
+	}
+	var after *proto.WavesAddress
+	if s := r.URL.Query().Get("after"); s != "" {
+		addr, aErr := proto.NewAddressFromString(s)
+		if aErr != nil {
+			return apiErrs.InvalidAddress
+		}
+		after = &addr
+	}
+	current, err := a.state.Height()
+	if err != nil {
+		return errors.Wrap(err, "failed to get height")
+	}
+	if height >= current {
+		// Balances at the current height may be changed by the next microblocks or a rollback.
+		return apiErrs.NewCustomValidationError(
+			"Using 'assetDistribution' on current height can lead to inconsistent result",
+		)
+	}
+	assetID := proto.AssetIDFromDigest(fullAssetID)
+	exists, err := a.state.IsAssetExist(assetID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check existence of asset %q", fullAssetID)
+	}
+	if !exists {
+		return apiErrs.NewAssetDoesNotExistError(fullAssetID)
+	}
+	holdings, hasNext, err := a.state.AssetDistribution(assetID, height, after, limit)
+	if err != nil {
+		if state.IsInvalidInput(err) {
+			return apiErrs.NewCustomValidationError(fmt.Sprintf("Unable to get distribution past height %d", height))
+		}
+		return errors.Wrapf(err, "failed to get distribution of asset %q", fullAssetID)
+	}
+	page := assetDistributionPage{HasNext: hasNext, Items: holdings}
+	if len(holdings) > 0 {
+		page.LastItem = &holdings[len(holdings)-1].Address
+	}
+	if err := trySendJson(w, page); err != nil {
+		return errors.Wrap(err, "AssetsDistribution")
+	}
+	return nil
+}
+
 func (a *NodeApi) version(w http.ResponseWriter, _ *http.Request) error {
 	rs := a.app.version()
 	if err := trySendJson(w, rs); err != nil {
@@ -1,11 +1,20 @@
 package api
 
 import (
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/mock"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/state"
 )
 
 const apiKey = "X-API-Key"
@@ -53,3 +62,119 @@ func TestNodeApi_FindFirstInvalidRuneInBase58String(t *testing.T) {
 		assert.Equal(t, testCase.expected, actual)
 	}
 }
+
+func TestNodeApi_AssetsDistribution(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	assetID := crypto.MustDigestFromBase58("DHgwrRvVyqJsepd32YbBqUeDH4GJ1N984X8QoekjgH8J")
+	unknownID := crypto.MustDigestFromBase58("7TpcEDn2gQV1GsSAXmMG1TVrRLVqAwBvUydZJLxCgEwS")
+	holder1 := proto.MustAddressFromString(testAddress)
+	holder2 := proto.MustAddressFromString("3PP2ywCpyvC57rN4vUZhJjQrmGMTWnjFKi7")
+	holdings := []proto.AssetHolding{{Address: holder1, Balance: 100}, {Address: holder2, Balance: 50}}
+	unavailable := state.NewStateError(state.InvalidInputError, errors.New("history unavailable"))
+
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().Height().Return(proto.Height(100), nil).AnyTimes()
+	st.EXPECT().IsAssetExist(proto.AssetIDFromDigest(assetID)).Return(true, nil).AnyTimes()
+	st.EXPECT().IsAssetExist(proto.AssetIDFromDigest(unknownID)).Return(false, nil)
+	st.EXPECT().AssetDistribution(proto.AssetIDFromDigest(assetID), proto.Height(90), nil, 2).
+		Return(holdings, true, nil)
+	st.EXPECT().AssetDistribution(proto.AssetIDFromDigest(assetID), proto.Height(90), &holder2, 2).
+		Return(nil, false, nil)
+	st.EXPECT().AssetDistribution(proto.AssetIDFromDigest(assetID), proto.Height(10), nil, 2).
+		Return(nil, false, unavailable)
+	h := newAddressesTestRouter(t, st)
+
+	target := func(id crypto.Digest, height, limit string) string {
+		return "/assets/" + id.String() + "/distribution/" + height + "/limit/" + limit
+	}
+	resp := doAddressesRequest(t, h, http.MethodGet, target(assetID, "90", "2"), "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t,
+		`{"hasNext":true,"lastItem":"`+holder2.String()+`","items":{"`+
+			holder1.String()+`":100,"`+holder2.String()+`":50}}`,
+		strings.TrimSpace(resp.Body.String()),
+	)
+
+	resp = doAddressesRequest(t, h, http.MethodGet, target(assetID, "90", "2")+"?after="+holder2.String(), "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `{"hasNext":false,"lastItem":null,"items":{}}`, resp.Body.String())
+
+	for _, test := range []struct {
+		target string
+		code   int
+	}{
+		{target(assetID, "10", "2"), http.StatusBadRequest},    // below the available history
+		{target(assetID, "100", "2"), http.StatusBadRequest},   // current height
+		{target(assetID, "90", "0"), http.StatusBadRequest},    // zero limit
+		{target(assetID, "90", "1001"), http.StatusBadRequest}, // too big limit
+		{target(assetID, "90", "2") + "?after=invalid", http.StatusBadRequest},
+		{target(unknownID, "90", "2"), http.StatusNotFound},
+	} {
+		resp = doAddressesRequest(t, h, http.MethodGet, test.target, "")
+		assert.Equal(t, test.code, resp.Code, test.target)
+	}
+}
+
+func TestNodeApi_NodeStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().Height().Return(proto.Height(100), nil).Times(2)
+	st.EXPECT().TopBlock().Return(&proto.Block{BlockHeader: proto.BlockHeader{Timestamp: 1700000000000}}).Times(2)
+	st.EXPECT().ArchivalHeight().Return(proto.Height(0), nil)
+	st.EXPECT().ArchivalHeight().Return(proto.Height(42), nil)
+	h := newAddressesTestRouter(t, st)
+
+	resp := doAddressesRequest(t, h, http.MethodGet, "/node/status", "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `{"blockchainHeight":100,"stateHeight":100,"updatedTimestamp":1700000000000,`+
+		`"updatedDate":"2023-11-14T22:13:20Z","archival":false}`, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/node/status", "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `{"blockchainHeight":100,"stateHeight":100,"updatedTimestamp":1700000000000,`+
+		`"updatedDate":"2023-11-14T22:13:20Z","archival":true,"archivalHeight":42}`, resp.Body.String())
+}
+
+func TestNodeApi_Checkpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().NewCheckpoint().Return(nil, errors.New("boom"))
+	h := newAddressesTestRouter(t, st)
+
+	doRequest := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/debug/checkpoint", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(apiKey, key)
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := doRequest("wrong", `{"path":"/tmp/checkpoint"}`)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	resp = doRequest("api-key", `{"path":"checkpoint"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Checkpoint path should be absolute")
+
+	resp = doRequest("api-key", `{"path":"/tmp/checkpoint"}`)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
+
+func TestNodeApi_StateSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().NewCheckpoint().Return(nil, errors.New("boom"))
+	h := newAddressesTestRouter(t, st)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/snapshot", nil)
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/debug/snapshot", nil)
+	req.Header.Set(apiKey, "api-key")
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/wavesplatform/gowaves/pkg/node/peers"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 	"github.com/wavesplatform/gowaves/pkg/util/common"
@@ -172,6 +173,28 @@ func (a *App) PeersBlackListed() []RestrictedPeerInfo {
 	return out
 }
 
+type PeerReputationInfo struct {
+	Hostname  string `json:"hostname"`
+	Score     int64  `json:"score"`
+	Timestamp int64  `json:"timestamp"` // timestamp of the last reputation update in millis
+}
+
+// PeersReputation is a list of peers with non-zero reputation, the scores are decayed to the current time.
+func (a *App) PeersReputation() []PeerReputationInfo {
+	reputations := a.peers.Reputations()
+
+	out := make([]PeerReputationInfo, 0, len(reputations))
+	for _, r := range reputations {
+		out = append(out, PeerReputationInfo{
+			Hostname:  "/" + r.IP.String(),
+			Score:     int64(r.Score),
+			Timestamp: r.UpdateTimestampMillis,
+		})
+	}
+
+	return out
+}
+
 type PeersClearBlackListResponse struct {
 	Result string `json:"result"`
 }
@@ -186,6 +209,55 @@ func (a *App) PeersClearBlackList() PeersClearBlackListResponse {
 	return resp
 }
 
+type BannedSubnetInfo struct {
+	Subnet    string `json:"subnet"`
+	Timestamp int64  `json:"timestamp"` // timestamp of the ban in millis
+	Reason    string `json:"reason,omitempty"`
+}
+
+func (a *App) PeersBannedSubnets() []BannedSubnetInfo {
+	banned := a.peers.BannedSubnets()
+
+	out := make([]BannedSubnetInfo, 0, len(banned))
+	for _, b := range banned {
+		out = append(out, BannedSubnetInfo{
+			Subnet:    b.Subnet.String(),
+			Timestamp: b.BanTimestampMillis,
+			Reason:    b.Reason,
+		})
+	}
+
+	return out
+}
+
+type PeersBanResponse struct {
+	Subnet string `json:"subnet"`
+	Result string `json:"result"`
+}
+
+// PeersBan bans the subnet in CIDR notation or a single IP address.
+func (a *App) PeersBan(subnet, reason string) (*PeersBanResponse, error) {
+	s, err := peers.ParseSubnet(subnet)
+	if err != nil {
+		return nil, wrapToBadRequestError(err)
+	}
+	if bErr := a.peers.BanSubnet(s, reason); bErr != nil {
+		return nil, bErr
+	}
+	return &PeersBanResponse{Subnet: s.String(), Result: "subnet banned"}, nil
+}
+
+func (a *App) PeersUnban(subnet string) (*PeersBanResponse, error) {
+	s, err := peers.ParseSubnet(subnet)
+	if err != nil {
+		return nil, wrapToBadRequestError(err)
+	}
+	if uErr := a.peers.UnbanSubnet(s); uErr != nil {
+		return nil, uErr
+	}
+	return &PeersBanResponse{Subnet: s.String(), Result: "subnet unbanned"}, nil
+}
+
 type PeersSpawnedResponse struct {
 	Peers []proto.IpPort `json:"peers"`
 }
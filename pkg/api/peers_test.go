@@ -2,6 +2,7 @@ package api
 
 import (
 	"net"
+	"net/netip"
 	"testing"
 	"time"
 
@@ -116,3 +117,77 @@ func TestApp_PeersBlackList(t *testing.T) {
 		assert.Equal(t, expected, actual)
 	}
 }
+
+func TestApp_PeersReputation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	peerManager := mock.NewMockPeerManager(ctrl)
+
+	now := time.Now()
+
+	ips := []string{"13.3.4.1", "5.3.6.7"}
+	testData := []storage.Reputation{
+		{IP: storage.IPFromString(ips[0]), Score: 120, UpdateTimestampMillis: now.UnixMilli()},
+		{IP: storage.IPFromString(ips[1]), Score: -250, UpdateTimestampMillis: now.Add(time.Minute).UnixMilli()},
+	}
+
+	peerManager.EXPECT().Reputations().Return(testData)
+
+	app, err := NewApp("key", nil, services.Services{Peers: peerManager})
+	require.NoError(t, err)
+
+	reputations := app.PeersReputation()
+	require.Len(t, reputations, len(testData))
+
+	for i, actual := range reputations {
+		r := testData[i]
+		expected := PeerReputationInfo{
+			Hostname:  "/" + ips[i],
+			Score:     int64(r.Score),
+			Timestamp: r.UpdateTimestampMillis,
+		}
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestApp_PeersBan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	peerManager := mock.NewMockPeerManager(ctrl)
+	peerManager.EXPECT().BanSubnet(netip.MustParsePrefix("13.3.4.0/24"), "some reason").Return(nil)
+	peerManager.EXPECT().UnbanSubnet(netip.MustParsePrefix("5.3.6.7/32")).Return(nil)
+
+	app, err := NewApp("key", nil, services.Services{Peers: peerManager})
+	require.NoError(t, err)
+
+	rs, err := app.PeersBan("13.3.4.1/24", "some reason")
+	require.NoError(t, err)
+	assert.Equal(t, "13.3.4.0/24", rs.Subnet)
+
+	rs, err = app.PeersUnban("5.3.6.7")
+	require.NoError(t, err)
+	assert.Equal(t, "5.3.6.7/32", rs.Subnet)
+
+	_, err = app.PeersBan("13.3.4", "")
+	var badRequestErr *BadRequestError
+	assert.ErrorAs(t, err, &badRequestErr)
+}
+
+func TestApp_PeersBannedSubnets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	peerManager := mock.NewMockPeerManager(ctrl)
+	peerManager.EXPECT().BannedSubnets().Return([]storage.BannedSubnet{
+		storage.NewBannedSubnet(netip.MustParsePrefix("13.3.4.0/24"), now.UnixMilli(), "some reason"),
+	})
+
+	app, err := NewApp("key", nil, services.Services{Peers: peerManager})
+	require.NoError(t, err)
+
+	expected := []BannedSubnetInfo{{Subnet: "13.3.4.0/24", Timestamp: now.UnixMilli(), Reason: "some reason"}}
+	assert.Equal(t, expected, app.PeersBannedSubnets())
+}
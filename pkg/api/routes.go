@@ -119,10 +119,19 @@ func (a *NodeApi) routes(opts *RunOptions) (chi.Router, error) {
 			r.Get("/details/{id}", wrapper(a.AssetsDetailsByID))
 			r.Get("/details", wrapper(a.AssetsDetailsByIDsGet))
 			r.Post("/details", wrapper(a.AssetsDetailsByIDsPost))
+			r.Get("/balance/{address}/{assetId}", wrapper(a.AssetsBalance))
+			r.Get("/{assetId}/distribution/{height:\\d+}/limit/{limit:\\d+}", wrapper(a.AssetsDistribution))
 		})
 
 		r.Route("/addresses", func(r chi.Router) {
 			r.Get("/", wrapper(a.Addresses))
+			r.Get("/balance/{address}", wrapper(a.AddressesBalance))
+			r.Get("/balance/{address}/{confirmations:\\d+}", wrapper(a.AddressesBalanceWithConfirmations))
+			r.Get("/balance/details/{address}", wrapper(a.AddressesBalanceDetails))
+			r.Get("/effectiveBalance/{address}", wrapper(a.AddressesEffectiveBalance))
+			r.Get("/data/{address}", wrapper(a.AddressesDataGet))
+			r.Post("/data/{address}", wrapper(a.AddressesDataPost))
+			r.Get("/data/{address}/{key}", wrapper(a.AddressesDataKey))
 		})
 
 		r.Route("/alias", func(r chi.Router) {
@@ -134,6 +143,17 @@ func (a *NodeApi) routes(opts *RunOptions) (chi.Router, error) {
 			r.Get("/unconfirmed/size", wrapper(a.unconfirmedSize))
 			r.Get("/info/{id}", wrapper(a.TransactionInfo))
 			r.Post("/broadcast", wrapper(a.TransactionsBroadcast))
+			r.Get("/address/{address}/limit/{limit:\\d+}", wrapper(a.TransactionsByAddress))
+			r.Get("/status", wrapper(a.TransactionsStatusGet))
+			r.Post("/status", wrapper(a.TransactionsStatusPost))
+			r.Post("/calculateFee", wrapper(a.TransactionsCalculateFee))
+		})
+
+		r.Route("/utils", func(r chi.Router) {
+			r.Post("/script/compileCode", wrapper(a.UtilsScriptCompileCode))
+			r.Post("/script/estimate", wrapper(a.UtilsScriptEstimate))
+			r.Post("/script/decompile", wrapper(a.UtilsScriptDecompile))
+			r.Post("/script/evaluate/{address}", wrapper(a.UtilsScriptEvaluate))
 		})
 
 		r.Route("/peers", func(r chi.Router) {
@@ -141,11 +161,15 @@ func (a *NodeApi) routes(opts *RunOptions) (chi.Router, error) {
 			r.Get("/connected", wrapper(a.PeersConnected))
 			r.Get("/suspended", wrapper(a.PeersSuspended))
 			r.Get("/blacklisted", wrapper(a.PeersBlackListed))
+			r.Get("/reputation", wrapper(a.PeersReputation))
+			r.Get("/bans", wrapper(a.PeersBannedSubnets))
 
 			rAuth := r.With(checkAuthMiddleware)
 
 			rAuth.Post("/connect", wrapper(a.PeersConnect))
 			rAuth.Post("/clearblacklist", wrapper(a.PeersClearBlackList))
+			rAuth.Post("/ban", wrapper(a.PeersBan))
+			rAuth.Post("/unban", wrapper(a.PeersUnban))
 		})
 
 		r.Route("/debug", func(r chi.Router) {
@@ -157,6 +181,8 @@ func (a *NodeApi) routes(opts *RunOptions) (chi.Router, error) {
 			rAuth.Post("/print", wrapper(a.debugPrint))
 			rAuth.Post("/rollback", wrapper(a.RollbackToHeight))
 			rAuth.Post("/rollback-to/{id}", wrapper(a.RollbackTo))
+			rAuth.Post("/checkpoint", wrapper(a.Checkpoint))
+			rAuth.Get("/snapshot", wrapper(a.StateSnapshot))
 		})
 		r.Route("/node", func(r chi.Router) {
 			r.Get("/version", wrapper(a.version))
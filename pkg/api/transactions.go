@@ -0,0 +1,269 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+
+	apiErrs "github.com/wavesplatform/gowaves/pkg/api/errors"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+// Values of the "status" field of /transactions/status response, same as in the Scala node.
+const (
+	txStatusConfirmed   = "confirmed"
+	txStatusUnconfirmed = "unconfirmed"
+	txStatusNotFound    = "not_found"
+)
+
+// transactionWithMeta is a transaction JSON extended with "height" and "applicationStatus" fields.
+type transactionWithMeta struct {
+	tx     proto.Transaction
+	height proto.Height
+	status proto.TransactionStatus
+}
+
+func (t transactionWithMeta) MarshalJSON() ([]byte, error) {
+	txJSON, err := json.Marshal(t.tx)
+	if err != nil {
+		return nil, err
+	}
+	txJSON = bytes.TrimSpace(txJSON)
+	if len(txJSON) < 2 || txJSON[len(txJSON)-1] != '}' {
+		return nil, errors.Errorf("unexpected transaction JSON %q", txJSON)
+	}
+	meta, err := json.Marshal(struct {
+		Height            proto.Height            `json:"height"`
+		ApplicationStatus proto.TransactionStatus `json:"applicationStatus"`
+	}{t.height, t.status})
+	if err != nil {
+		return nil, err
+	}
+	// merge two JSON objects: {tx fields} + {meta fields}
+	res := make([]byte, 0, len(txJSON)+len(meta))
+	res = append(res, txJSON[:len(txJSON)-1]...)
+	if len(txJSON) > 2 { // tx object is not empty
+		res = append(res, ',')
+	}
+	res = append(res, meta[1:]...)
+	return res, nil
+}
+
+type transactionStatus struct {
+	ID                string                   `json:"id"`
+	Status            string                   `json:"status"`
+	Height            proto.Height             `json:"height,omitempty"`
+	Confirmations     *uint64                  `json:"confirmations,omitempty"`
+	ApplicationStatus *proto.TransactionStatus `json:"applicationStatus,omitempty"`
+}
+
+func (a *NodeApi) TransactionsByAddress(w http.ResponseWriter, r *http.Request) error {
+	addr, err := addressFromURLParam(r)
+	if err != nil {
+		return err
+	}
+	limit, err := strconv.Atoi(chi.URLParam(r, "limit"))
+	if err != nil || limit <= 0 {
+		return apiErrs.NewCustomValidationError("invalid limit")
+	}
+	if maxLimit := a.app.settings.TransactionsByAddressLimit; limit > maxLimit {
+		return apiErrs.NewTooBigArrayAllocationError(maxLimit)
+	}
+	var after []byte
+	if s := r.URL.Query().Get("after"); s != "" {
+		id, err := crypto.NewDigestFromBase58(s)
+		if err != nil {
+			return apiErrs.NewCustomValidationError("Unable to decode transaction id")
+		}
+		if _, err := a.state.TransactionHeightByID(id.Bytes()); err != nil {
+			if state.IsNotFound(err) {
+				return apiErrs.TransactionDoesNotExist
+			}
+			return errors.Wrapf(err, "failed to get height of transaction %q", s)
+		}
+		after = id.Bytes()
+	}
+	txs, err := a.transactionsByAddress(addr, limit, after)
+	if err != nil {
+		return err
+	}
+	// the Scala node wraps the list into one more array
+	if err := trySendJson(w, [][]transactionWithMeta{txs}); err != nil {
+		return errors.Wrap(err, "TransactionsByAddress")
+	}
+	return nil
+}
+
+// transactionsByAddress returns at most limit transactions of the address, newest first.
+// If after is not nil, transactions are returned starting from the one that precedes the transaction with this ID.
+func (a *NodeApi) transactionsByAddress(addr proto.WavesAddress, limit int, after []byte) ([]transactionWithMeta, error) {
+	extended, err := a.state.ProvidesExtendedApi()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check extended API availability")
+	}
+	if !extended {
+		return nil, apiErrs.NewCustomValidationError("Node is started without extended API, transactions by address are unavailable")
+	}
+	iter, err := a.state.NewAddrTransactionsIterator(addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create transactions iterator for address %q", addr.String())
+	}
+	defer iter.Release()
+
+	scheme := a.app.services.Scheme
+	txs := make([]transactionWithMeta, 0, limit)
+	skip := after != nil
+	for len(txs) < limit && iter.Next() {
+		tx, status, err := iter.Transaction()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get transaction from iterator")
+		}
+		id, err := tx.GetID(scheme)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get transaction ID")
+		}
+		if skip {
+			skip = !bytes.Equal(id, after)
+			continue
+		}
+		height, err := a.state.TransactionHeightByID(id)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get transaction height")
+		}
+		txs = append(txs, transactionWithMeta{tx: tx, height: height, status: status})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, errors.Wrapf(err, "failed to iterate over transactions of address %q", addr.String())
+	}
+	return txs, nil
+}
+
+func (a *NodeApi) TransactionsStatusGet(w http.ResponseWriter, r *http.Request) error {
+	return a.transactionsStatus(w, r.URL.Query()["id"])
+}
+
+func (a *NodeApi) TransactionsStatusPost(w http.ResponseWriter, r *http.Request) error {
+	var data struct {
+		IDs []string `json:"ids"`
+	}
+	if err := tryParseJson(r.Body, &data); err != nil {
+		return apiErrs.NewWrongJsonError(err.Error(), nil)
+	}
+	return a.transactionsStatus(w, data.IDs)
+}
+
+func (a *NodeApi) transactionsStatus(w http.ResponseWriter, ids []string) error {
+	if len(ids) == 0 {
+		return apiErrs.NewCustomValidationError("Transaction ID was not specified")
+	}
+	if limit := a.app.settings.TransactionsByAddressLimit; len(ids) > limit {
+		return apiErrs.NewTooBigArrayAllocationError(limit)
+	}
+	var (
+		digests    = make([]crypto.Digest, 0, len(ids))
+		invalidIDs []string
+	)
+	for _, id := range ids {
+		d, err := crypto.NewDigestFromBase58(id)
+		if err != nil {
+			invalidIDs = append(invalidIDs, id)
+		} else {
+			digests = append(digests, d)
+		}
+	}
+	if len(invalidIDs) != 0 {
+		return apiErrs.NewInvalidIDsError(invalidIDs)
+	}
+	height, err := a.state.Height()
+	if err != nil {
+		return errors.Wrap(err, "failed to get state height")
+	}
+	statuses := make([]transactionStatus, 0, len(digests))
+	for _, d := range digests {
+		st, err := a.transactionStatus(d, height)
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, st)
+	}
+	if err := trySendJson(w, statuses); err != nil {
+		return errors.Wrap(err, "TransactionsStatus")
+	}
+	return nil
+}
+
+func (a *NodeApi) transactionStatus(id crypto.Digest, height proto.Height) (transactionStatus, error) {
+	res := transactionStatus{ID: id.String()}
+	_, status, err := a.state.TransactionByIDWithStatus(id.Bytes())
+	if err != nil {
+		if !state.IsNotFound(err) {
+			return transactionStatus{}, errors.Wrapf(err, "failed to get transaction %q", res.ID)
+		}
+		if a.app.utx != nil && a.app.utx.ExistsByID(id.Bytes()) {
+			res.Status = txStatusUnconfirmed
+		} else {
+			res.Status = txStatusNotFound
+		}
+		return res, nil
+	}
+	txHeight, err := a.state.TransactionHeightByID(id.Bytes())
+	if err != nil {
+		return transactionStatus{}, errors.Wrapf(err, "failed to get height of transaction %q", res.ID)
+	}
+	confirmations := height - txHeight
+	res.Status = txStatusConfirmed
+	res.Height = txHeight
+	res.Confirmations = &confirmations
+	res.ApplicationStatus = &status
+	return res, nil
+}
+
+// feeCalculation is the response of /transactions/calculateFee, the asset is null for Waves.
+type feeCalculation struct {
+	FeeAssetID proto.OptionalAsset `json:"feeAssetId"`
+	FeeAmount  uint64              `json:"feeAmount"`
+}
+
+// TransactionsCalculateFee calculates the minimal fee of unsigned transaction. The fee is calculated in Waves
+// or in sponsored asset if the "feeAssetId" field of the request is set.
+func (a *NodeApi) TransactionsCalculateFee(w http.ResponseWriter, r *http.Request) error {
+	b, err := io.ReadAll(io.LimitReader(r.Body, postMessageSizeLimit))
+	if err != nil {
+		return errors.Wrap(err, "failed to read request body")
+	}
+	tt := proto.TransactionTypeVersion{}
+	if err := json.Unmarshal(b, &tt); err != nil {
+		return apiErrs.NewWrongJsonError(err.Error(), nil)
+	}
+	tx, err := proto.GuessTransactionType(&tt)
+	if err != nil {
+		return apiErrs.NewCustomValidationError(err.Error())
+	}
+	if err := proto.UnmarshalTransactionFromJSON(b, a.app.services.Scheme, tx); err != nil {
+		return apiErrs.NewWrongJsonError(err.Error(), nil)
+	}
+	var feeAsset struct {
+		ID proto.OptionalAsset `json:"feeAssetId"`
+	}
+	if err := json.Unmarshal(b, &feeAsset); err != nil {
+		return apiErrs.NewWrongJsonError(err.Error(), nil)
+	}
+	fee, err := a.state.MinimalFee(tx, feeAsset.ID)
+	if err != nil {
+		if state.IsInvalidInput(err) {
+			return apiErrs.NewCustomValidationError(err.Error())
+		}
+		return errors.Wrap(err, "failed to calculate minimal fee")
+	}
+	if err := trySendJson(w, feeCalculation{FeeAssetID: feeAsset.ID, FeeAmount: fee}); err != nil {
+		return errors.Wrap(err, "TransactionsCalculateFee")
+	}
+	return nil
+}
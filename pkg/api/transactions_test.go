@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/mock"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+func newTestDataTransactions(t *testing.T, n int) []proto.Transaction {
+	_, pk, err := crypto.GenerateKeyPair([]byte("seed"))
+	require.NoError(t, err)
+	txs := make([]proto.Transaction, n)
+	for i := range txs {
+		tx := proto.NewUnsignedDataWithProofs(1, pk, 100000, uint64(1000+i))
+		require.NoError(t, tx.GenerateID(proto.MainNetScheme))
+		txs[i] = tx
+	}
+	return txs
+}
+
+func TestNodeApi_TransactionsByAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	addr := proto.MustAddressFromString(testAddress)
+	txs := newTestDataTransactions(t, 3) // newest first
+	ids := make([]crypto.Digest, len(txs))
+	for i, tx := range txs {
+		id, err := tx.GetID(proto.MainNetScheme)
+		require.NoError(t, err)
+		ids[i], err = crypto.NewDigestFromBytes(id)
+		require.NoError(t, err)
+	}
+
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().ProvidesExtendedApi().Return(true, nil).AnyTimes()
+	st.EXPECT().NewAddrTransactionsIterator(addr).DoAndReturn(func(proto.Address) (state.TransactionIterator, error) {
+		iter := mock.NewMockTransactionIterator(ctrl)
+		i := -1
+		iter.EXPECT().Next().DoAndReturn(func() bool { i++; return i < len(txs) }).AnyTimes()
+		iter.EXPECT().Transaction().DoAndReturn(func() (proto.Transaction, proto.TransactionStatus, error) {
+			return txs[i], proto.TransactionSucceeded, nil
+		}).AnyTimes()
+		iter.EXPECT().Error().Return(nil).AnyTimes()
+		iter.EXPECT().Release()
+		return iter, nil
+	}).Times(2)
+	for i, id := range ids {
+		st.EXPECT().TransactionHeightByID(id.Bytes()).Return(proto.Height(10-i), nil).AnyTimes()
+	}
+	h := newAddressesTestRouter(t, st)
+
+	type txMeta struct {
+		ID                crypto.Digest           `json:"id"`
+		Height            proto.Height            `json:"height"`
+		ApplicationStatus proto.TransactionStatus `json:"applicationStatus"`
+	}
+	decode := func(target string) []txMeta {
+		resp := doAddressesRequest(t, h, http.MethodGet, target, "")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var res [][]txMeta
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &res))
+		require.Len(t, res, 1)
+		return res[0]
+	}
+
+	res := decode("/transactions/address/" + testAddress + "/limit/2")
+	assert.Equal(t, []txMeta{
+		{ID: ids[0], Height: 10, ApplicationStatus: proto.TransactionSucceeded},
+		{ID: ids[1], Height: 9, ApplicationStatus: proto.TransactionSucceeded},
+	}, res)
+
+	res = decode("/transactions/address/" + testAddress + "/limit/2?after=" + ids[1].String())
+	assert.Equal(t, []txMeta{{ID: ids[2], Height: 8, ApplicationStatus: proto.TransactionSucceeded}}, res)
+
+	resp := doAddressesRequest(t, h, http.MethodGet, "/transactions/address/"+testAddress+"/limit/1001", "")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestNodeApi_TransactionsStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	txs := newTestDataTransactions(t, 2)
+	confirmedID, err := txs[0].GetID(proto.MainNetScheme)
+	require.NoError(t, err)
+	missingID, err := txs[1].GetID(proto.MainNetScheme)
+	require.NoError(t, err)
+
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().Height().Return(proto.Height(15), nil).Times(2)
+	st.EXPECT().TransactionByIDWithStatus(confirmedID).Return(txs[0], proto.TransactionFailed, nil).Times(2)
+	st.EXPECT().TransactionHeightByID(confirmedID).Return(proto.Height(10), nil).Times(2)
+	st.EXPECT().TransactionByIDWithStatus(missingID).
+		Return(nil, proto.TransactionStatus(0), state.NewStateError(state.NotFoundError, nil)).Times(2)
+	h := newAddressesTestRouter(t, st)
+
+	confirmed := crypto.Digest(confirmedID).String()
+	missing := crypto.Digest(missingID).String()
+	expected := `[
+		{"id":"` + confirmed + `","status":"confirmed","height":10,"confirmations":5,"applicationStatus":"script_execution_failed"},
+		{"id":"` + missing + `","status":"not_found"}
+	]`
+
+	resp := doAddressesRequest(t, h, http.MethodGet, "/transactions/status?id="+confirmed+"&id="+missing, "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, expected, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodPost, "/transactions/status",
+		`{"ids":["`+confirmed+`","`+missing+`"]}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, expected, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodGet, "/transactions/status?id=invalid", "")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestNodeApi_TransactionsCalculateFee(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewMockState(ctrl)
+	h := newAddressesTestRouter(t, st)
+
+	const txJSON = `{"type": 4, "version": 2, "senderPublicKey": "%s", "recipient": "%s", ` +
+		`"amount": 100, "fee": 0, "timestamp": 1, "assetId": null%s}`
+	_, pk, err := crypto.GenerateKeyPair([]byte("test"))
+	require.NoError(t, err)
+	asset := crypto.MustDigestFromBase58("B2u2TBpTYHWCuMuKLnbQfLvdLJ3zjgPiy3iMS2TSYugZ")
+
+	st.EXPECT().MinimalFee(gomock.Any(), proto.NewOptionalAssetWaves()).DoAndReturn(
+		func(tx proto.Transaction, _ proto.OptionalAsset) (uint64, error) {
+			assert.Equal(t, proto.TransferTransaction, tx.GetTypeInfo().Type)
+			return 100000, nil
+		})
+	resp := doAddressesRequest(t, h, http.MethodPost, "/transactions/calculateFee",
+		fmt.Sprintf(txJSON, pk.String(), testAddress, ""))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `{"feeAssetId": null, "feeAmount": 100000}`, resp.Body.String())
+
+	st.EXPECT().MinimalFee(gomock.Any(), *proto.NewOptionalAssetFromDigest(asset)).Return(uint64(7), nil)
+	resp = doAddressesRequest(t, h, http.MethodPost, "/transactions/calculateFee",
+		fmt.Sprintf(txJSON, pk.String(), testAddress, `, "feeAssetId": "`+asset.String()+`"`))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `{"feeAssetId": "`+asset.String()+`", "feeAmount": 7}`, resp.Body.String())
+
+	st.EXPECT().MinimalFee(gomock.Any(), gomock.Any()).
+		Return(uint64(0), state.NewStateError(state.InvalidInputError, errors.New("not sponsored")))
+	resp = doAddressesRequest(t, h, http.MethodPost, "/transactions/calculateFee",
+		fmt.Sprintf(txJSON, pk.String(), testAddress, `, "feeAssetId": "`+asset.String()+`"`))
+	assert.Equal(t, http.StatusBadRequest, resp.Code, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodPost, "/transactions/calculateFee", `{"type": 100}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, resp.Body.String())
+}
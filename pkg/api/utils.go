@@ -0,0 +1,400 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	apiErrs "github.com/wavesplatform/gowaves/pkg/api/errors"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/ride"
+	"github.com/wavesplatform/gowaves/pkg/ride/ast"
+	"github.com/wavesplatform/gowaves/pkg/ride/compiler"
+	"github.com/wavesplatform/gowaves/pkg/ride/decompiler"
+	"github.com/wavesplatform/gowaves/pkg/ride/serialization"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+const scriptBase64Prefix = "base64:"
+
+type scriptComplexity struct {
+	Script               proto.Script   `json:"script"`
+	Complexity           int            `json:"complexity"`
+	VerifierComplexity   int            `json:"verifierComplexity"`
+	CallableComplexities map[string]int `json:"callableComplexities"`
+	ExtraFee             uint64         `json:"extraFee"`
+}
+
+type scriptEstimation struct {
+	scriptComplexity
+	ScriptText string `json:"scriptText"`
+}
+
+type decompiledScript struct {
+	StdLibVersion ast.LibraryVersion `json:"STDLIB_VERSION"`
+	ContentType   string             `json:"CONTENT_TYPE"`
+	ScriptType    string             `json:"SCRIPT_TYPE"`
+	Script        string             `json:"script"`
+}
+
+func readRequestBody(r *http.Request) (string, error) {
+	b, err := io.ReadAll(io.LimitReader(r.Body, postMessageSizeLimit))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read request body")
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// readScriptFromBody reads base64 encoded script from the request body, the "base64:" prefix is optional.
+func readScriptFromBody(r *http.Request) (proto.Script, *ast.Tree, error) {
+	body, err := readRequestBody(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	script, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(body, scriptBase64Prefix))
+	if err != nil {
+		return nil, nil, apiErrs.NewCustomValidationError("Unable to decode script: " + err.Error())
+	}
+	tree, err := serialization.Parse(script)
+	if err != nil {
+		return nil, nil, apiErrs.NewScriptCompilerError(err.Error())
+	}
+	return script, tree, nil
+}
+
+// estimateScript estimates the tree with the current estimator version and calculates
+// the extra fee required for transactions sent from an account with this script.
+func (a *NodeApi) estimateScript(script proto.Script, tree *ast.Tree) (scriptComplexity, error) {
+	v, err := a.state.EstimatorVersion()
+	if err != nil {
+		return scriptComplexity{}, errors.Wrap(err, "failed to get estimator version")
+	}
+	est, err := ride.EstimateTree(tree, v)
+	if err != nil {
+		return scriptComplexity{}, apiErrs.NewScriptCompilerError(err.Error())
+	}
+	rideV5Activated, err := a.state.IsActivated(int16(settings.RideV5))
+	if err != nil {
+		return scriptComplexity{}, errors.Wrap(err, "failed to check RideV5 activation")
+	}
+	callables := est.Functions
+	if callables == nil {
+		callables = map[string]int{}
+	}
+	var extraFee uint64 = state.ScriptExtraFee
+	switch {
+	case tree.IsDApp() && !tree.HasVerifier():
+		extraFee = 0
+	case rideV5Activated && est.Verifier <= state.FreeVerifierComplexity:
+		extraFee = 0
+	}
+	return scriptComplexity{
+		Script:               script,
+		Complexity:           est.Estimation,
+		VerifierComplexity:   est.Verifier,
+		CallableComplexities: callables,
+		ExtraFee:             extraFee,
+	}, nil
+}
+
+func (a *NodeApi) UtilsScriptCompileCode(w http.ResponseWriter, r *http.Request) error {
+	var compact bool
+	if s := r.URL.Query().Get("compact"); s != "" {
+		var err error
+		if compact, err = strconv.ParseBool(s); err != nil {
+			return apiErrs.NewCustomValidationError("invalid compact parameter")
+		}
+	}
+	code, err := readRequestBody(r)
+	if err != nil {
+		return err
+	}
+	script, errs := compiler.Compile(code, compact, false)
+	if len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, e := range errs {
+			messages[i] = e.Error()
+		}
+		return apiErrs.NewScriptCompilerError(strings.Join(messages, "; "))
+	}
+	tree, err := serialization.Parse(script)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse compiled script")
+	}
+	res, err := a.estimateScript(script, tree)
+	if err != nil {
+		return err
+	}
+	if err := trySendJson(w, res); err != nil {
+		return errors.Wrap(err, "UtilsScriptCompileCode")
+	}
+	return nil
+}
+
+func (a *NodeApi) UtilsScriptEstimate(w http.ResponseWriter, r *http.Request) error {
+	script, tree, err := readScriptFromBody(r)
+	if err != nil {
+		return err
+	}
+	complexity, err := a.estimateScript(script, tree)
+	if err != nil {
+		return err
+	}
+	text, err := decompiler.Decompile(tree)
+	if err != nil {
+		return apiErrs.NewScriptCompilerError(err.Error())
+	}
+	if err := trySendJson(w, scriptEstimation{scriptComplexity: complexity, ScriptText: text}); err != nil {
+		return errors.Wrap(err, "UtilsScriptEstimate")
+	}
+	return nil
+}
+
+func (a *NodeApi) UtilsScriptDecompile(w http.ResponseWriter, r *http.Request) error {
+	_, tree, err := readScriptFromBody(r)
+	if err != nil {
+		return err
+	}
+	text, err := decompiler.Decompile(tree)
+	if err != nil {
+		return apiErrs.NewScriptCompilerError(err.Error())
+	}
+	contentType := "EXPRESSION"
+	if tree.IsDApp() {
+		contentType = "DAPP"
+	}
+	res := decompiledScript{
+		StdLibVersion: tree.LibVersion,
+		ContentType:   contentType,
+		ScriptType:    decompiler.ScriptType(tree),
+		Script:        text,
+	}
+	if err := trySendJson(w, res); err != nil {
+		return errors.Wrap(err, "UtilsScriptDecompile")
+	}
+	return nil
+}
+
+const evaluationResultName = "evaluationResult"
+
+type scriptEvaluationRequest struct {
+	Expr string              `json:"expr,omitempty"`
+	Call *proto.FunctionCall `json:"call,omitempty"`
+}
+
+type scriptEvaluation struct {
+	Address      string              `json:"address"`
+	Expr         string              `json:"expr,omitempty"`
+	Call         *proto.FunctionCall `json:"call,omitempty"`
+	Result       *ride.ResultValue   `json:"result,omitempty"`
+	Complexity   int                 `json:"complexity"`
+	StateChanges *stateChanges       `json:"stateChanges,omitempty"`
+}
+
+type transferChange struct {
+	Address proto.Recipient     `json:"address"`
+	Asset   proto.OptionalAsset `json:"asset"`
+	Amount  int64               `json:"amount"`
+}
+
+type issueChange struct {
+	AssetID     crypto.Digest `json:"assetId"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Quantity    int64         `json:"quantity"`
+	Decimals    int32         `json:"decimals"`
+	Reissuable  bool          `json:"isReissuable"`
+	Nonce       int64         `json:"nonce"`
+}
+
+type reissueChange struct {
+	AssetID    crypto.Digest `json:"assetId"`
+	Quantity   int64         `json:"quantity"`
+	Reissuable bool          `json:"isReissuable"`
+}
+
+type burnChange struct {
+	AssetID  crypto.Digest `json:"assetId"`
+	Quantity int64         `json:"quantity"`
+}
+
+type sponsorFeeChange struct {
+	AssetID crypto.Digest `json:"assetId"`
+	MinFee  int64         `json:"minSponsoredAssetFee"`
+}
+
+type leaseChange struct {
+	ID        crypto.Digest   `json:"id"`
+	Recipient proto.Recipient `json:"recipient"`
+	Amount    int64           `json:"amount"`
+	Nonce     int64           `json:"nonce"`
+}
+
+type leaseCancelChange struct {
+	ID crypto.Digest `json:"id"`
+}
+
+// stateChanges is the JSON representation of script actions, it has the same layout as the state changes
+// of Invoke transactions in the Scala node API.
+type stateChanges struct {
+	Data         proto.DataEntries   `json:"data"`
+	Transfers    []transferChange    `json:"transfers"`
+	Issues       []issueChange       `json:"issues"`
+	Reissues     []reissueChange     `json:"reissues"`
+	Burns        []burnChange        `json:"burns"`
+	SponsorFees  []sponsorFeeChange  `json:"sponsorFees"`
+	Leases       []leaseChange       `json:"leases"`
+	LeaseCancels []leaseCancelChange `json:"leaseCancels"`
+}
+
+func newStateChanges(actions []proto.ScriptAction) *stateChanges {
+	sc := &stateChanges{
+		Data:         proto.DataEntries{},
+		Transfers:    []transferChange{},
+		Issues:       []issueChange{},
+		Reissues:     []reissueChange{},
+		Burns:        []burnChange{},
+		SponsorFees:  []sponsorFeeChange{},
+		Leases:       []leaseChange{},
+		LeaseCancels: []leaseCancelChange{},
+	}
+	for _, action := range actions {
+		switch a := action.(type) {
+		case *proto.DataEntryScriptAction:
+			sc.Data = append(sc.Data, a.Entry)
+		case *proto.TransferScriptAction:
+			sc.Transfers = append(sc.Transfers, transferChange{Address: a.Recipient, Asset: a.Asset, Amount: a.Amount})
+		case *proto.IssueScriptAction:
+			sc.Issues = append(sc.Issues, issueChange{
+				AssetID:     a.ID,
+				Name:        a.Name,
+				Description: a.Description,
+				Quantity:    a.Quantity,
+				Decimals:    a.Decimals,
+				Reissuable:  a.Reissuable,
+				Nonce:       a.Nonce,
+			})
+		case *proto.ReissueScriptAction:
+			sc.Reissues = append(sc.Reissues, reissueChange{AssetID: a.AssetID, Quantity: a.Quantity, Reissuable: a.Reissuable})
+		case *proto.BurnScriptAction:
+			sc.Burns = append(sc.Burns, burnChange{AssetID: a.AssetID, Quantity: a.Quantity})
+		case *proto.SponsorshipScriptAction:
+			sc.SponsorFees = append(sc.SponsorFees, sponsorFeeChange{AssetID: a.AssetID, MinFee: a.MinFee})
+		case *proto.LeaseScriptAction:
+			sc.Leases = append(sc.Leases, leaseChange{ID: a.ID, Recipient: a.Recipient, Amount: a.Amount, Nonce: a.Nonce})
+		case *proto.LeaseCancelScriptAction:
+			sc.LeaseCancels = append(sc.LeaseCancels, leaseCancelChange{ID: a.LeaseID})
+		}
+	}
+	return sc
+}
+
+// compileExpression compiles the expression in the context of the account script, so global variables and
+// functions declared in the script can be used in the expression. Compiler accepts only boolean expressions
+// as scripts, so the expression is assigned to a variable of a dummy script and extracted from it.
+// The expression could be passed as a compiled script with "base64:" prefix, in this case the body of the script
+// is used as is.
+func compileExpression(expr string, script *ast.Tree) (ast.Node, error) {
+	if strings.HasPrefix(expr, scriptBase64Prefix) {
+		script, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(expr, scriptBase64Prefix))
+		if err != nil {
+			return nil, apiErrs.NewCustomValidationError("Unable to decode expression: " + err.Error())
+		}
+		tree, err := serialization.Parse(script)
+		if err != nil {
+			return nil, apiErrs.NewScriptCompilerError(err.Error())
+		}
+		if tree.IsDApp() {
+			return nil, apiErrs.NewScriptCompilerError("expression expected, but dApp script is given")
+		}
+		return tree.Verifier, nil
+	}
+	code := fmt.Sprintf("{-# STDLIB_VERSION %d #-}\n{-# CONTENT_TYPE EXPRESSION #-}\n{-# SCRIPT_TYPE ACCOUNT #-}\n"+
+		"let %s = {\n%s\n}\ntrue", script.LibVersion, evaluationResultName, expr)
+	tree, errs := compiler.CompileToTreeWithDeclarations(code, script.Declarations)
+	if len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, e := range errs {
+			messages[i] = e.Error()
+		}
+		return nil, apiErrs.NewScriptCompilerError(strings.Join(messages, "; "))
+	}
+	a, ok := tree.Verifier.(*ast.AssignmentNode)
+	if !ok || a.Name != evaluationResultName {
+		return nil, errors.Errorf("unexpected node %T in compiled expression", tree.Verifier)
+	}
+	return a.Expression, nil
+}
+
+// UtilsScriptEvaluate evaluates an expression or calls a callable function in the context of the account script.
+// Neither state nor UTX pool are affected by the evaluation.
+func (a *NodeApi) UtilsScriptEvaluate(w http.ResponseWriter, r *http.Request) error {
+	addr, err := addressFromURLParam(r)
+	if err != nil {
+		return err
+	}
+	req := &scriptEvaluationRequest{}
+	if err := tryParseJson(io.LimitReader(r.Body, postMessageSizeLimit), req); err != nil {
+		return apiErrs.NewWrongJsonError(err.Error(), nil)
+	}
+	if (req.Expr == "") == (req.Call == nil) {
+		return apiErrs.NewCustomValidationError("Exactly one of 'expr' or 'call' must be specified")
+	}
+	rcp := proto.NewRecipientFromAddress(addr)
+	info, err := a.state.ScriptBasicInfoByAccount(rcp)
+	hasScript := err == nil
+	if err != nil && !state.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get script info of address %q", addr.String())
+	}
+	var res ride.Result
+	if req.Call != nil {
+		if !hasScript || !info.IsDApp {
+			return apiErrs.NewCustomValidationError(fmt.Sprintf("Address %s is not dApp", addr.String()))
+		}
+		res, err = a.state.CallFunctionReadOnly(addr, *req.Call)
+	} else {
+		// Expression on account without script is evaluated in the empty context, as the state does.
+		script := ast.NewTree(ast.ContentTypeExpression, ast.CurrentMaxLibraryVersion())
+		if hasScript {
+			script, err = a.state.NewestScriptByAccount(rcp)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get script of address %q", addr.String())
+			}
+		}
+		expr, cErr := compileExpression(req.Expr, script)
+		if cErr != nil {
+			return cErr
+		}
+		res, err = a.state.EvaluateExpression(addr, expr)
+	}
+	if err != nil {
+		if ride.GetEvaluationErrorType(err) == ride.Undefined {
+			return errors.Wrapf(err, "failed to evaluate script of address %q", addr.String())
+		}
+		return apiErrs.NewScriptExecutionError(err.Error(),
+			ride.EvaluationErrorSpentComplexity(err), ride.EvaluationErrorCallStack(err))
+	}
+	evaluation := scriptEvaluation{
+		Address:    addr.String(),
+		Expr:       req.Expr,
+		Call:       req.Call,
+		Complexity: res.Complexity(),
+	}
+	if v, ok := ride.UserResultValue(res); ok {
+		evaluation.Result = &v
+	}
+	if req.Call != nil {
+		evaluation.StateChanges = newStateChanges(res.ScriptActions())
+	}
+	if err := trySendJson(w, evaluation); err != nil {
+		return errors.Wrap(err, "UtilsScriptEvaluate")
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/mock"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/ride"
+	"github.com/wavesplatform/gowaves/pkg/ride/ast"
+	"github.com/wavesplatform/gowaves/pkg/ride/compiler"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+const testDAppCode = `
+{-# STDLIB_VERSION 6 #-}
+{-# CONTENT_TYPE DAPP #-}
+{-# SCRIPT_TYPE ACCOUNT #-}
+
+let limit = 10
+func double(x: Int) = x * 2
+
+@Callable(i)
+func call(v: Int) = [IntegerEntry("v", double(v))]
+
+@Verifier(tx)
+func verify() = sigVerify(tx.bodyBytes, tx.proofs[0], tx.senderPublicKey)
+`
+
+func TestNodeApi_UtilsScript(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().EstimatorVersion().Return(4, nil).Times(2)
+	st.EXPECT().IsActivated(int16(settings.RideV5)).Return(true, nil).Times(2)
+	h := newAddressesTestRouter(t, st)
+
+	script, errs := compiler.Compile(testDAppCode, false, false)
+	require.Empty(t, errs)
+
+	resp := doAddressesRequest(t, h, http.MethodPost, "/utils/script/compileCode", testDAppCode)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var compiled scriptComplexity
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &compiled))
+	assert.Equal(t, script, []byte(compiled.Script))
+	assert.Positive(t, compiled.VerifierComplexity)
+	assert.LessOrEqual(t, compiled.VerifierComplexity, state.FreeVerifierComplexity)
+	assert.Contains(t, compiled.CallableComplexities, "call")
+	assert.Equal(t, uint64(0), compiled.ExtraFee)
+
+	encoded := "base64:" + base64.StdEncoding.EncodeToString(script)
+	resp = doAddressesRequest(t, h, http.MethodPost, "/utils/script/estimate", encoded)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var estimated scriptEstimation
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &estimated))
+	assert.Equal(t, compiled, estimated.scriptComplexity)
+	assert.Contains(t, estimated.ScriptText, "@Callable(i)\nfunc call (v: Int) = ")
+
+	resp = doAddressesRequest(t, h, http.MethodPost, "/utils/script/decompile", encoded)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var decompiled decompiledScript
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &decompiled))
+	assert.Equal(t, "DAPP", decompiled.ContentType)
+	assert.Equal(t, "ACCOUNT", decompiled.ScriptType)
+	assert.Equal(t, estimated.ScriptText, decompiled.Script)
+
+	resp = doAddressesRequest(t, h, http.MethodPost, "/utils/script/compileCode", "let x = ")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	resp = doAddressesRequest(t, h, http.MethodPost, "/utils/script/decompile", "base64:AAAA")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestNodeApi_UtilsScriptEvaluate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	addr := proto.MustAddressFromString(testAddress)
+	rcp := proto.NewRecipientFromAddress(addr)
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().ScriptBasicInfoByAccount(rcp).Return(&proto.ScriptBasicInfo{
+		LibraryVersion: ast.LibV6, IsDApp: true,
+	}, nil).Times(5)
+	dApp, errs := compiler.CompileToTree(testDAppCode)
+	require.Empty(t, errs)
+	st.EXPECT().NewestScriptByAccount(rcp).Return(dApp, nil).Times(4)
+	h := newAddressesTestRouter(t, st)
+	target := "/utils/script/evaluate/" + testAddress
+
+	st.EXPECT().EvaluateExpression(addr, gomock.Any()).DoAndReturn(
+		func(_ proto.WavesAddress, expr ast.Node) (ride.Result, error) {
+			fc, ok := expr.(*ast.FunctionCallNode)
+			require.True(t, ok)
+			assert.Equal(t, "1100", fc.Function.Name()) // list constructor
+			return ride.ScriptResult{}, nil
+		})
+	resp := doAddressesRequest(t, h, http.MethodPost, target, `{"expr": "[1, 2]"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var evaluation scriptEvaluation
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &evaluation))
+	assert.Equal(t, testAddress, evaluation.Address)
+	assert.Equal(t, "[1, 2]", evaluation.Expr)
+	assert.Nil(t, evaluation.StateChanges)
+
+	st.EXPECT().EvaluateExpression(addr, gomock.Any()).DoAndReturn(
+		func(_ proto.WavesAddress, expr ast.Node) (ride.Result, error) {
+			sum, ok := expr.(*ast.FunctionCallNode)
+			require.True(t, ok)
+			assert.Equal(t, ast.NativeFunction("100"), sum.Function) // integer sum
+			assert.Equal(t, &ast.FunctionCallNode{
+				Function:  ast.UserFunction("double"),
+				Arguments: []ast.Node{ast.NewReferenceNode("limit")},
+			}, sum.Arguments[0])
+			return ride.ScriptResult{}, nil
+		})
+	resp = doAddressesRequest(t, h, http.MethodPost, target, `{"expr": "double(limit) + 1"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	fc := proto.NewFunctionCall("call", proto.Arguments{proto.NewIntegerArgument(1)})
+	st.EXPECT().CallFunctionReadOnly(addr, fc).Return(ride.DAppResult{}, nil)
+	resp = doAddressesRequest(t, h, http.MethodPost, target, `{"call": {"function": "call", "args": [{"type": "integer", "value": 1}]}}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	evaluation = scriptEvaluation{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &evaluation))
+	assert.Equal(t, &fc, evaluation.Call)
+	require.NotNil(t, evaluation.StateChanges)
+	assert.Empty(t, evaluation.StateChanges.Data)
+
+	st.EXPECT().EvaluateExpression(addr, gomock.Any()).Return(nil,
+		ride.EvaluationErrorSetComplexity(ride.UserError.New("boom"), 42))
+	resp = doAddressesRequest(t, h, http.MethodPost, target, `{"expr": "throw(\"boom\")"}`)
+	require.Equal(t, http.StatusBadRequest, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `{"error": 306, "message": "boom", "complexity": 42}`, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodPost, target, `{}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	resp = doAddressesRequest(t, h, http.MethodPost, target, `{"expr": "let x = "}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	resp = doAddressesRequest(t, h, http.MethodPost, target, `{"expr": 1}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "cannot unmarshal number")
+}
+
+func TestNodeApi_UtilsScriptEvaluateWithoutScript(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	addr := proto.MustAddressFromString(testAddress)
+	rcp := proto.NewRecipientFromAddress(addr)
+	st := mock.NewMockState(ctrl)
+	st.EXPECT().ScriptBasicInfoByAccount(rcp).Return(nil, state.NewStateError(state.NotFoundError, nil)).Times(3)
+	h := newAddressesTestRouter(t, st)
+	target := "/utils/script/evaluate/" + testAddress
+
+	st.EXPECT().EvaluateExpression(addr, gomock.Any()).Return(ride.ScriptResult{}, nil)
+	resp := doAddressesRequest(t, h, http.MethodPost, target, `{"expr": "height + 1"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	resp = doAddressesRequest(t, h, http.MethodPost, target, `{"expr": "double(1)"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Undefined function")
+
+	resp = doAddressesRequest(t, h, http.MethodPost, target, `{"call": {"function": "call", "args": []}}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "is not dApp")
+}
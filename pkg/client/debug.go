@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 )
 
@@ -179,6 +180,31 @@ func (a *Debug) StateHashDebugLast(ctx context.Context) (*proto.StateHashDebug,
 	return a.stateHashDebugAtPath(ctx, "/debug/stateHash/last")
 }
 
+// SnapshotStateHash returns the snapshot state hash at the given height. Only Go nodes provide this method.
+func (a *Debug) SnapshotStateHash(ctx context.Context, height uint64) (crypto.Digest, *Response, error) {
+	url, err := joinUrl(a.options.BaseUrl, fmt.Sprintf("/go/debug/snapshotStateHash/%d", height))
+	if err != nil {
+		return crypto.Digest{}, nil, err
+	}
+
+	req, err := http.NewRequest("GET", url.String(), nil)
+	if err != nil {
+		return crypto.Digest{}, nil, err
+	}
+	out := struct {
+		StateHash proto.HexBytes `json:"stateHash"`
+	}{}
+	response, err := doHttp(ctx, a.options, req, &out)
+	if err != nil {
+		return crypto.Digest{}, response, err
+	}
+	sh, err := crypto.NewDigestFromBytes(out.StateHash)
+	if err != nil {
+		return crypto.Digest{}, response, err
+	}
+	return sh, response, nil
+}
+
 type BalancesHistoryRow struct {
 	Height  uint64 `json:"height"`
 	Balance uint64 `json:"balance"`
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"encoding/hex"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -116,3 +117,13 @@ func TestDebug_BalancesHistory(t *testing.T) {
 	assert.True(t, len(body) > 0)
 	assert.Contains(t, resp.Request.URL.String(), "/debug/balances/history")
 }
+
+func TestDebug_SnapshotStateHash(t *testing.T) {
+	const js = `{"stateHash":"0x0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8"}`
+	client := client(t, NewMockHttpRequestFromString(js, 200))
+	sh, resp, err := client.Debug.SnapshotStateHash(context.Background(), 100)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8", hex.EncodeToString(sh.Bytes()))
+	assert.Contains(t, resp.Request.URL.String(), "/go/debug/snapshotStateHash/100")
+}
@@ -0,0 +1,233 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/wavesplatform/gowaves/pkg/importer"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// flushInterval is the number of blocks after which the files are flushed to disk.
+// Files can't be out of sync more than that after the crash.
+const flushInterval = 1000
+
+type State interface {
+	Height() (proto.Height, error)
+	BlockByHeight(height proto.Height) (*proto.Block, error)
+	SnapshotsAtHeight(height proto.Height) (proto.BlockSnapshot, error)
+}
+
+type ExportParams struct {
+	Schema                        proto.Scheme
+	BlockchainPath, SnapshotsPath string // SnapshotsPath is optional, snapshots are not exported if empty.
+}
+
+func (p ExportParams) validate() error {
+	if p.Schema == 0 {
+		return errors.New("scheme/chainID is empty")
+	}
+	if p.BlockchainPath == "" {
+		return errors.New("blockchain path is empty")
+	}
+	return nil
+}
+
+// Exporter writes blocks and, optionally, block snapshots of the state to the files which can be read by importer.
+// Like in the importer files, the first record is the block next to genesis, because genesis block is generated
+// by the node itself.
+// Exporter appends records to existing files, so the export can be continued from the last exported height.
+type Exporter struct {
+	scheme    proto.Scheme
+	st        State
+	blocks    *recordsFile
+	snapshots *recordsFile // nil if snapshots are not exported.
+
+	h proto.Height // Height of the last exported block.
+}
+
+// NewExporter opens or creates the export files. Incomplete trailing records left by interrupted export are
+// truncated and the last exported block is checked to be the same as the block of the state at the same height.
+func NewExporter(params ExportParams, st State) (_ *Exporter, err error) {
+	if vErr := params.validate(); vErr != nil {
+		return nil, fmt.Errorf("invalid export params: %w", vErr)
+	}
+	e := &Exporter{scheme: params.Schema, st: st}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, e.Close())
+		}
+	}()
+	e.blocks, err = openRecordsFile(params.BlockchainPath, validBlockSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocks file: %w", err)
+	}
+	if params.SnapshotsPath != "" {
+		e.snapshots, err = openRecordsFile(params.SnapshotsPath, validSnapshotSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open snapshots file: %w", err)
+		}
+		if sErr := e.syncFiles(); sErr != nil {
+			return nil, sErr
+		}
+	}
+	e.h = proto.Height(1) + e.blocks.count
+	if e.blocks.count == 0 {
+		return e, nil
+	}
+	if cErr := e.checkLastBlock(); cErr != nil {
+		return nil, cErr
+	}
+	return e, nil
+}
+
+// syncFiles truncates the files to the same number of records, they could differ after the crash.
+func (e *Exporter) syncFiles() error {
+	bc, sc := e.blocks.count, e.snapshots.count
+	if bc == sc {
+		return nil
+	}
+	if max(bc, sc)-min(bc, sc) > flushInterval {
+		return fmt.Errorf("blocks file has %d records and snapshots file has %d records, files are not of one export",
+			bc, sc,
+		)
+	}
+	zap.S().Warnf("Blocks file has %d records and snapshots file has %d records, truncating to %d records",
+		bc, sc, min(bc, sc),
+	)
+	if err := e.blocks.truncate(min(bc, sc)); err != nil {
+		return err
+	}
+	return e.snapshots.truncate(min(bc, sc))
+}
+
+func (e *Exporter) checkLastBlock() error {
+	h := proto.Height(1) + e.blocks.count
+	stateHeight, err := e.st.Height()
+	if err != nil {
+		return fmt.Errorf("failed to get state height: %w", err)
+	}
+	if h > stateHeight {
+		return fmt.Errorf("blocks file ends at height %d which is above state height %d", h, stateHeight)
+	}
+	expected, err := e.st.BlockByHeight(h)
+	if err != nil {
+		return fmt.Errorf("failed to get block at height %d: %w", h, err)
+	}
+	data, err := e.blocks.readLast()
+	if err != nil {
+		return fmt.Errorf("failed to read last block from blocks file: %w", err)
+	}
+	var b proto.Block
+	if expected.Version >= proto.ProtobufBlockVersion {
+		err = b.UnmarshalFromProtobuf(data)
+	} else {
+		err = b.UnmarshalBinary(data, e.scheme)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal last block from blocks file: %w", err)
+	}
+	if b.BlockID() != expected.BlockID() {
+		return fmt.Errorf("block '%s' at height %d in blocks file differs from block '%s' of the state",
+			b.BlockID().String(), h, expected.BlockID().String(),
+		)
+	}
+	return nil
+}
+
+// Height returns the height of the last exported block.
+func (e *Exporter) Height() proto.Height {
+	return e.h
+}
+
+// Export appends blocks and snapshots from the next height after the last exported one up to the given height.
+// Written records are flushed to disk on return, even if the export was interrupted.
+func (e *Exporter) Export(ctx context.Context, height proto.Height) (err error) {
+	stateHeight, err := e.st.Height()
+	if err != nil {
+		return fmt.Errorf("failed to get state height: %w", err)
+	}
+	if height > stateHeight {
+		return fmt.Errorf("export height %d is above state height %d", height, stateHeight)
+	}
+	defer func() {
+		err = errors.Join(err, e.flush())
+	}()
+	for h := e.h + 1; h <= height; h++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if exErr := e.exportHeight(h); exErr != nil {
+			return fmt.Errorf("failed to export height %d: %w", h, exErr)
+		}
+		e.h = h
+		if h%flushInterval == 0 {
+			if flErr := e.flush(); flErr != nil {
+				return flErr
+			}
+			zap.S().Debugf("Exported up to height %d", h)
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) exportHeight(h proto.Height) error {
+	block, err := e.st.BlockByHeight(h)
+	if err != nil {
+		return fmt.Errorf("failed to get block: %w", err)
+	}
+	blockBytes, err := block.Marshal(e.scheme)
+	if err != nil {
+		return fmt.Errorf("failed to marshal block: %w", err)
+	}
+	if e.snapshots == nil {
+		return e.blocks.write(blockBytes)
+	}
+	snapshot, err := e.st.SnapshotsAtHeight(h)
+	if err != nil {
+		return fmt.Errorf("failed to get block snapshot: %w", err)
+	}
+	snapshotBytes, err := snapshot.MarshalBinaryImport()
+	if err != nil {
+		return fmt.Errorf("failed to marshal block snapshot: %w", err)
+	}
+	if wErr := e.blocks.write(blockBytes); wErr != nil {
+		return fmt.Errorf("failed to write block: %w", wErr)
+	}
+	if wErr := e.snapshots.write(snapshotBytes); wErr != nil {
+		return fmt.Errorf("failed to write block snapshot: %w", wErr)
+	}
+	return nil
+}
+
+func (e *Exporter) flush() error {
+	if err := e.blocks.flush(); err != nil {
+		return err
+	}
+	if e.snapshots != nil {
+		return e.snapshots.flush()
+	}
+	return nil
+}
+
+func (e *Exporter) Close() error {
+	var errs []error
+	if e.blocks != nil {
+		errs = append(errs, e.blocks.close())
+	}
+	if e.snapshots != nil {
+		errs = append(errs, e.snapshots.close())
+	}
+	return errors.Join(errs...)
+}
+
+func validBlockSize(size uint32) bool {
+	return size != 0 && size <= importer.MaxBlockSize
+}
+
+func validSnapshotSize(size uint32) bool {
+	return size <= importer.MaxBlockSnapshotSize
+}
@@ -0,0 +1,145 @@
+package exporter
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/importer"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+var testBlocksPath = filepath.Join("..", "state", "testdata", "blocks-10000")
+
+func newTestState(t *testing.T) state.State {
+	st, err := state.NewState(t.TempDir(), true, state.DefaultTestingStateParams(), settings.MustMainNetSettings(), false)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, st.Close())
+	})
+	return st
+}
+
+func importTestBlocks(t *testing.T, st state.State, params importer.ImportParams, height proto.Height) {
+	current, err := st.Height()
+	require.NoError(t, err)
+	require.NoError(t, importer.ApplyFromFile(context.Background(), params, st, height-1, current))
+}
+
+func export(t *testing.T, st state.State, params ExportParams, height proto.Height) {
+	e, err := NewExporter(params, st)
+	require.NoError(t, err)
+	require.NoError(t, e.Export(context.Background(), height))
+	assert.Equal(t, height, e.Height())
+	require.NoError(t, e.Close())
+}
+
+// fileRecords returns the first n records of the file in the importer format.
+func fileRecords(t *testing.T, name string, n int) []byte {
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	pos := 0
+	for range n {
+		require.GreaterOrEqual(t, len(data), pos+uint32Size)
+		pos += uint32Size + int(binary.BigEndian.Uint32(data[pos:]))
+	}
+	require.GreaterOrEqual(t, len(data), pos)
+	return data[:pos]
+}
+
+func TestExportResume(t *testing.T) {
+	st := newTestState(t)
+	importTestBlocks(t, st, importer.ImportParams{Schema: proto.MainNetScheme, BlockchainPath: testBlocksPath}, 100)
+
+	dir := t.TempDir()
+	params := ExportParams{Schema: proto.MainNetScheme, BlockchainPath: filepath.Join(dir, "blockchain")}
+	export(t, st, params, 40)
+	assert.Equal(t, fileRecords(t, testBlocksPath, 39), fileRecords(t, params.BlockchainPath, 39))
+
+	// Interrupted write leaves an incomplete record, which is dropped on resume.
+	f, err := os.OpenFile(params.BlockchainPath, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 1, 0, 1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	export(t, st, params, 100)
+	actual, err := os.ReadFile(params.BlockchainPath)
+	require.NoError(t, err)
+	assert.Equal(t, fileRecords(t, testBlocksPath, 99), actual)
+
+	// Nothing to export.
+	export(t, st, params, 100)
+	e, err := NewExporter(params, st)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, e.Close())
+	}()
+	assert.ErrorContains(t, e.Export(context.Background(), 101), "above state height")
+}
+
+func TestExportSnapshots(t *testing.T) {
+	st := newTestState(t)
+	importTestBlocks(t, st, importer.ImportParams{Schema: proto.MainNetScheme, BlockchainPath: testBlocksPath}, 100)
+
+	dir := t.TempDir()
+	params := ExportParams{
+		Schema:         proto.MainNetScheme,
+		BlockchainPath: filepath.Join(dir, "blockchain"),
+		SnapshotsPath:  filepath.Join(dir, "snapshots"),
+	}
+	export(t, st, params, 60)
+	// Emulate the crash after blocks file was flushed, but snapshots file was not.
+	require.NoError(t, os.WriteFile(params.SnapshotsPath, fileRecords(t, params.SnapshotsPath, 50), 0600))
+	export(t, st, params, 100)
+
+	// Import exported files in light mode.
+	light := newTestState(t)
+	importTestBlocks(t, light, importer.ImportParams{
+		Schema:         proto.MainNetScheme,
+		BlockchainPath: params.BlockchainPath,
+		SnapshotsPath:  params.SnapshotsPath,
+		LightNodeMode:  true,
+	}, 100)
+	for _, h := range []proto.Height{50, 51, 100} {
+		expected, err := st.BlockByHeight(h)
+		require.NoError(t, err)
+		actual, err := light.BlockByHeight(h)
+		require.NoError(t, err)
+		assert.Equal(t, expected.BlockID(), actual.BlockID())
+		expectedSnapshot, err := st.SnapshotsAtHeight(h)
+		require.NoError(t, err)
+		actualSnapshot, err := light.SnapshotsAtHeight(h)
+		require.NoError(t, err)
+		assert.Equal(t, len(expectedSnapshot.TxSnapshots), len(actualSnapshot.TxSnapshots))
+	}
+}
+
+func TestExportBlockMismatch(t *testing.T) {
+	st := newTestState(t)
+	importTestBlocks(t, st, importer.ImportParams{Schema: proto.MainNetScheme, BlockchainPath: testBlocksPath}, 30)
+
+	dir := t.TempDir()
+	params := ExportParams{Schema: proto.MainNetScheme, BlockchainPath: filepath.Join(dir, "blockchain")}
+	export(t, st, params, 20)
+	// Change the signature of the last block.
+	data, err := os.ReadFile(params.BlockchainPath)
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(params.BlockchainPath, data, 0600))
+	_, err = NewExporter(params, st)
+	assert.ErrorContains(t, err, "differs from block")
+
+	// Blocks file is longer than the state.
+	params.BlockchainPath = filepath.Join(dir, "long")
+	require.NoError(t, os.WriteFile(params.BlockchainPath, fileRecords(t, testBlocksPath, 40), 0600))
+	_, err = NewExporter(params, st)
+	assert.ErrorContains(t, err, "above state height")
+}
@@ -0,0 +1,137 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	uint32Size          = 4
+	bufioWriterBuffSize = 256 * 1024
+)
+
+// recordsFile is a file of size prefixed records, the format of blocks and snapshots files of importer.
+// Records are appended to the end of the file, incomplete trailing record is truncated on opening.
+type recordsFile struct {
+	name      string
+	validSize func(uint32) bool
+	f         *os.File
+	w         *bufio.Writer
+	count     uint64 // Number of complete records in the file.
+	last      int64  // Offset of the last record.
+}
+
+func openRecordsFile(name string, validSize func(uint32) bool) (*recordsFile, error) {
+	f, err := os.OpenFile(filepath.Clean(name), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file '%s': %w", name, err)
+	}
+	rf := &recordsFile{name: name, validSize: validSize, f: f}
+	if tErr := rf.truncate(^uint64(0)); tErr != nil {
+		return nil, errors.Join(tErr, f.Close())
+	}
+	return rf, nil
+}
+
+// scan reads the sizes of at most limit records and returns the number of complete records,
+// the offset of the last one and the offset of the end of the last one.
+// Only the size prefixes are read, records themselves are skipped.
+func (rf *recordsFile) scan(limit uint64) (uint64, int64, int64, error) {
+	info, err := rf.f.Stat()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	var (
+		fileSize = info.Size()
+		count    uint64
+		last     int64
+		end      int64
+		buf      [uint32Size]byte
+	)
+	for count < limit && end+uint32Size <= fileSize {
+		if _, rErr := rf.f.ReadAt(buf[:], end); rErr != nil {
+			return 0, 0, 0, rErr
+		}
+		size := binary.BigEndian.Uint32(buf[:])
+		if !rf.validSize(size) {
+			return 0, 0, 0, fmt.Errorf("corrupted file '%s': invalid record size %d at pos %d", rf.name, size, end)
+		}
+		next := end + uint32Size + int64(size)
+		if next > fileSize {
+			break // Incomplete record.
+		}
+		last = end
+		end = next
+		count++
+	}
+	return count, last, end, nil
+}
+
+// truncate leaves at most count records in the file and positions the file for appending.
+func (rf *recordsFile) truncate(count uint64) error {
+	n, last, end, err := rf.scan(count)
+	if err != nil {
+		return fmt.Errorf("failed to read file '%s': %w", rf.name, err)
+	}
+	if tErr := rf.f.Truncate(end); tErr != nil {
+		return fmt.Errorf("failed to truncate file '%s': %w", rf.name, tErr)
+	}
+	if _, sErr := rf.f.Seek(end, io.SeekStart); sErr != nil {
+		return fmt.Errorf("failed to seek file '%s': %w", rf.name, sErr)
+	}
+	rf.count, rf.last = n, last
+	rf.w = bufio.NewWriterSize(rf.f, bufioWriterBuffSize)
+	return nil
+}
+
+// readLast reads the last record of the file, it must be called before any writes.
+func (rf *recordsFile) readLast() ([]byte, error) {
+	if rf.count == 0 {
+		return nil, errors.New("no records")
+	}
+	var buf [uint32Size]byte
+	if _, err := rf.f.ReadAt(buf[:], rf.last); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(buf[:]))
+	if _, err := rf.f.ReadAt(data, rf.last+uint32Size); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (rf *recordsFile) write(data []byte) error {
+	size := uint32(len(data)) // #nosec: the size is checked below
+	if uint64(len(data)) != uint64(size) || !rf.validSize(size) {
+		return fmt.Errorf("invalid record size %d", len(data))
+	}
+	var buf [uint32Size]byte
+	binary.BigEndian.PutUint32(buf[:], size)
+	if _, err := rf.w.Write(buf[:]); err != nil {
+		return err
+	}
+	if _, err := rf.w.Write(data); err != nil {
+		return err
+	}
+	rf.count++
+	return nil
+}
+
+func (rf *recordsFile) flush() error {
+	if err := rf.w.Flush(); err != nil {
+		return fmt.Errorf("failed to write file '%s': %w", rf.name, err)
+	}
+	if err := rf.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync file '%s': %w", rf.name, err)
+	}
+	return nil
+}
+
+func (rf *recordsFile) close() error {
+	return errors.Join(rf.flush(), rf.f.Close())
+}
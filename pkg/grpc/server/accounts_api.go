@@ -2,9 +2,11 @@ package server
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
@@ -12,8 +14,44 @@ import (
 	pb "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves"
 	g "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/node/grpc"
 	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/state"
 )
 
+// heightMetadataKey is the key of optional request metadata that sets the height of historical balances and data.
+// The height is passed in metadata because the requests of protobuf-schemas have no such field. The metadata is
+// read by GetBalances and GetDataEntries, the value is a decimal height, for example "height: 1000".
+const heightMetadataKey = "height"
+
+// heightFromMetadata returns the height passed in the metadata of request, zero means the latest state.
+func heightFromMetadata(ctx context.Context) (proto.Height, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0, nil
+	}
+	values := md.Get(heightMetadataKey)
+	if len(values) == 0 {
+		return 0, nil
+	}
+	height, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil || height == 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid height '%s'", values[0])
+	}
+	return height, nil
+}
+
+// historicalStatusError converts the error of historical request to the status: invalid or unavailable height
+// is the fault of client, missing value is not found, all other errors are internal.
+func historicalStatusError(err error) error {
+	switch {
+	case state.IsInvalidInput(err):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case state.IsNotFound(err):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
 func (s *Server) GetBalances(req *g.BalancesRequest, srv g.AccountsApi_GetBalancesServer) error {
 	c := proto.ProtobufConverter{FallbackChainID: s.scheme}
 	addr, err := c.Address(s.scheme, req.Address)
@@ -21,17 +59,21 @@ func (s *Server) GetBalances(req *g.BalancesRequest, srv g.AccountsApi_GetBalanc
 		return status.Error(codes.InvalidArgument, err.Error())
 	}
 	rcp := proto.NewRecipientFromAddress(addr)
+	height, err := heightFromMetadata(srv.Context())
+	if err != nil {
+		return err
+	}
 	if len(req.Assets) == 0 {
 		// TODO(nickeskov): send waves balance AND all assets balances (portfolio)
 		//  by the given address according to the scala node implementation
-		if err := s.sendWavesBalance(rcp, srv); err != nil {
-			return status.Error(codes.Internal, err.Error())
+		if err := s.sendWavesBalance(rcp, height, srv); err != nil {
+			return err
 		}
 	}
 	for _, asset := range req.Assets {
 		if len(asset) == 0 {
-			if err := s.sendWavesBalance(rcp, srv); err != nil {
-				return status.Error(codes.Internal, err.Error())
+			if err := s.sendWavesBalance(rcp, height, srv); err != nil {
+				return err
 			}
 		} else {
 			// Asset.
@@ -39,9 +81,14 @@ func (s *Server) GetBalances(req *g.BalancesRequest, srv g.AccountsApi_GetBalanc
 			if err != nil {
 				return status.Error(codes.InvalidArgument, err.Error())
 			}
-			balance, err := s.state.AssetBalance(rcp, proto.AssetIDFromDigest(fullAssetID))
+			var balance uint64
+			if height != 0 {
+				balance, err = s.state.AssetBalanceAtHeight(rcp, proto.AssetIDFromDigest(fullAssetID), height)
+			} else {
+				balance, err = s.state.AssetBalance(rcp, proto.AssetIDFromDigest(fullAssetID))
+			}
 			if err != nil {
-				return status.Error(codes.NotFound, err.Error())
+				return historicalStatusError(err)
 			}
 			var res g.BalanceResponse
 			res.Balance = &g.BalanceResponse_Asset{
@@ -120,6 +167,13 @@ func (s *Server) GetDataEntries(req *g.DataRequest, srv g.AccountsApi_GetDataEnt
 		return status.Error(codes.InvalidArgument, err.Error())
 	}
 	rcp := proto.NewRecipientFromAddress(addr)
+	height, err := heightFromMetadata(srv.Context())
+	if err != nil {
+		return err
+	}
+	if height != 0 {
+		return s.sendDataEntriesAtHeight(req, rcp, height, srv)
+	}
 	if req.Key != "" {
 		entry, err := s.state.RetrieveEntry(rcp, req.Key)
 		if err != nil {
@@ -165,15 +219,64 @@ func (s *Server) ResolveAlias(_ context.Context, req *wrapperspb.StringValue) (*
 	return &wrapperspb.BytesValue{Value: addr.Bytes()}, nil
 }
 
-func (s *Server) sendWavesBalance(rcp proto.Recipient, srv g.AccountsApi_GetBalancesServer) error {
+// sendDataEntriesAtHeight sends the entry with requested key or all entries of the account as they were at
+// the given height. Every key ever written to the account is kept in the state, so the keys of current entries
+// include all keys which could exist at that height.
+func (s *Server) sendDataEntriesAtHeight(
+	req *g.DataRequest, rcp proto.Recipient, height proto.Height, srv g.AccountsApi_GetDataEntriesServer,
+) error {
+	keys := []string{req.Key}
+	if req.Key == "" {
+		entries, err := s.state.RetrieveEntries(rcp)
+		if err != nil {
+			if state.IsNotFound(err) {
+				return nil
+			}
+			return status.Error(codes.Internal, err.Error())
+		}
+		keys = make([]string, len(entries))
+		for i, entry := range entries {
+			keys[i] = entry.GetKey()
+		}
+	}
+	for _, key := range keys {
+		entry, err := s.state.RetrieveEntryAtHeight(rcp, key, height)
+		if err != nil {
+			if state.IsNotFound(err) {
+				continue // Entry was not set or was removed at that height.
+			}
+			return historicalStatusError(err)
+		}
+		res := &g.DataEntryResponse{Address: req.Address, Entry: entry.ToProtobuf()}
+		if err := srv.Send(res); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+	return nil
+}
+
+// sendWavesBalance sends the full Waves balance or, if the height is set, the regular balance at that height,
+// because other parts of the full balance are not stored historically.
+func (s *Server) sendWavesBalance(rcp proto.Recipient, height proto.Height, srv g.AccountsApi_GetBalancesServer) error {
 	var res g.BalanceResponse
-	balanceInfo, err := s.state.FullWavesBalance(rcp)
-	if err != nil {
-		res.Balance = &g.BalanceResponse_Waves{Waves: &g.BalanceResponse_WavesBalances{}}
+	if height != 0 {
+		balance, err := s.state.WavesBalanceAtHeight(rcp, height)
+		if err != nil {
+			return historicalStatusError(err)
+		}
+		res.Balance = &g.BalanceResponse_Waves{Waves: &g.BalanceResponse_WavesBalances{Regular: int64(balance)}}
 	} else {
-		res.Balance = &g.BalanceResponse_Waves{Waves: balanceInfo.ToProtobuf()}
+		balanceInfo, err := s.state.FullWavesBalance(rcp)
+		if err != nil {
+			res.Balance = &g.BalanceResponse_Waves{Waves: &g.BalanceResponse_WavesBalances{}}
+		} else {
+			res.Balance = &g.BalanceResponse_Waves{Waves: balanceInfo.ToProtobuf()}
+		}
 	}
-	return srv.Send(&res)
+	if err := srv.Send(&res); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
 }
 
 type getActiveLeasesHandler struct {
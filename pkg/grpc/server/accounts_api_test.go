@@ -5,12 +5,18 @@ import (
 	"io"
 	"testing"
 
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/wavesplatform/gowaves/pkg/crypto"
 	g "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/node/grpc"
+	"github.com/wavesplatform/gowaves/pkg/mock"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 	"github.com/wavesplatform/gowaves/pkg/settings"
 )
@@ -49,6 +55,83 @@ func TestGetBalances(t *testing.T) {
 	assert.Equal(t, io.EOF, err)
 }
 
+func TestGetBalancesAtHeight(t *testing.T) {
+	params := defaultStateParams()
+	st := newTestState(t, true, params, settings.MustMainNetSettings())
+	ctx := withAutoCancel(t, context.Background())
+	err := server.initServer(st, nil, nil)
+	require.NoError(t, err)
+
+	conn := connectAutoClose(t, grpcTestAddr)
+
+	cl := g.NewAccountsApiClient(conn)
+	addr, err := proto.NewAddressFromString("3PAWwWa6GbwcJaFzwqXQN5KQm7H96Y7SHTQ")
+	require.NoError(t, err)
+	req := &g.BalancesRequest{
+		Address: addr.Body(),
+		Assets:  [][]byte{{}},
+	}
+	stream, err := cl.GetBalances(metadata.AppendToOutgoingContext(ctx, heightMetadataKey, "1"), req)
+	require.NoError(t, err)
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	correctBalance := &g.BalanceResponse_Waves{Waves: &g.BalanceResponse_WavesBalances{Regular: 9999999500000000}}
+	assert.Equal(t, correctBalance, res.Balance)
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+
+	for _, h := range []string{"0", "abc", "100500"} {
+		stream, err = cl.GetBalances(metadata.AppendToOutgoingContext(ctx, heightMetadataKey, h), req)
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), h)
+	}
+}
+
+func TestGetDataEntriesAtHeight(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewMockState(ctrl)
+	ctx := withAutoCancel(t, context.Background())
+	err := server.initServer(st, nil, createTestNetWallet(t))
+	require.NoError(t, err)
+
+	cl := g.NewAccountsApiClient(connectAutoClose(t, grpcTestAddr))
+	addr, err := proto.NewAddressFromString("3PAWwWa6GbwcJaFzwqXQN5KQm7H96Y7SHTQ")
+	require.NoError(t, err)
+	rcp := proto.NewRecipientFromAddress(addr)
+	const height = 10
+	st.EXPECT().RetrieveEntries(rcp).Return([]proto.DataEntry{
+		&proto.IntegerDataEntry{Key: "a", Value: 2},
+		&proto.DeleteDataEntry{Key: "b"},
+		&proto.StringDataEntry{Key: "c", Value: "new"},
+	}, nil)
+	st.EXPECT().RetrieveEntryAtHeight(rcp, "a", proto.Height(height)).
+		Return(&proto.IntegerDataEntry{Key: "a", Value: 1}, nil)
+	st.EXPECT().RetrieveEntryAtHeight(rcp, "b", proto.Height(height)).
+		Return(&proto.BooleanDataEntry{Key: "b", Value: true}, nil)
+	st.EXPECT().RetrieveEntryAtHeight(rcp, "c", proto.Height(height)).Return(nil, proto.ErrNotFound)
+
+	hctx := metadata.AppendToOutgoingContext(ctx, heightMetadataKey, "10")
+	stream, err := cl.GetDataEntries(hctx, &g.DataRequest{Address: addr.Body()})
+	require.NoError(t, err)
+	var keys []string
+	for {
+		res, rErr := stream.Recv()
+		if rErr == io.EOF {
+			break
+		}
+		require.NoError(t, rErr)
+		keys = append(keys, res.Entry.Key)
+	}
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	st.EXPECT().RetrieveEntryAtHeight(rcp, "a", proto.Height(height)).Return(nil, errors.New("storage failure"))
+	stream, err = cl.GetDataEntries(hctx, &g.DataRequest{Address: addr.Body(), Key: "a"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
 func TestGetActiveLeases(t *testing.T) {
 	genesisPath, err := globalPathFromLocal("testdata/genesis/lease_genesis.json")
 	require.NoError(t, err)
@@ -1,6 +1,9 @@
 package server
 
-import "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/node/grpc"
+import (
+	eventsgrpc "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/events/grpc"
+	"github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/node/grpc"
+)
 
 type GrpcHandlers interface {
 	grpc.AccountsApiServer
@@ -8,4 +11,5 @@ type GrpcHandlers interface {
 	grpc.BlockchainApiServer
 	grpc.BlocksApiServer
 	grpc.TransactionsApiServer
+	eventsgrpc.BlockchainUpdatesApiServer
 }
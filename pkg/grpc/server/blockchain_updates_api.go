@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/events"
+	eg "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/events/grpc"
+	"github.com/wavesplatform/gowaves/pkg/node/blockchain_updates"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+const maxBlockUpdatesRange = 1000
+
+func (s *Server) blockchainUpdates() (*blockchain_updates.Publisher, error) {
+	if s.services.BlockchainUpdates == nil {
+		return nil, status.Error(codes.Unavailable, "blockchain updates are disabled")
+	}
+	return s.services.BlockchainUpdates, nil
+}
+
+func (s *Server) GetBlockUpdate(_ context.Context, req *eg.GetBlockUpdateRequest) (*eg.GetBlockUpdateResponse, error) {
+	publisher, err := s.blockchainUpdates()
+	if err != nil {
+		return nil, err
+	}
+	if req.Height <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid height %d", req.Height)
+	}
+	height, err := s.state.Height()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if proto.Height(req.Height) > height {
+		return nil, status.Errorf(codes.NotFound, "block at height %d not found", req.Height)
+	}
+	u, err := publisher.BlockUpdate(proto.Height(req.Height))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &eg.GetBlockUpdateResponse{Update: u}, nil
+}
+
+func (s *Server) GetBlockUpdatesRange(
+	_ context.Context, req *eg.GetBlockUpdatesRangeRequest,
+) (*eg.GetBlockUpdatesRangeResponse, error) {
+	publisher, err := s.blockchainUpdates()
+	if err != nil {
+		return nil, err
+	}
+	if req.FromHeight <= 0 || req.ToHeight < req.FromHeight {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid heights range [%d, %d]", req.FromHeight,
+			req.ToHeight)
+	}
+	if req.ToHeight-req.FromHeight >= maxBlockUpdatesRange {
+		return nil, status.Errorf(codes.InvalidArgument, "heights range is too large, maximum is %d",
+			maxBlockUpdatesRange)
+	}
+	height, err := s.state.Height()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	to := min(proto.Height(req.ToHeight), height)
+	res := &eg.GetBlockUpdatesRangeResponse{}
+	for h := proto.Height(req.FromHeight); h <= to; h++ {
+		u, uErr := publisher.BlockUpdate(h)
+		if uErr != nil {
+			return nil, status.Error(codes.Internal, uErr.Error())
+		}
+		res.Updates = append(res.Updates, u)
+	}
+	return res, nil
+}
+
+func (s *Server) Subscribe(req *eg.SubscribeRequest, srv eg.BlockchainUpdatesApi_SubscribeServer) error {
+	publisher, err := s.blockchainUpdates()
+	if err != nil {
+		return err
+	}
+	if req.FromHeight <= 0 {
+		return status.Errorf(codes.InvalidArgument, "invalid from height %d", req.FromHeight)
+	}
+	if req.ToHeight != 0 && req.ToHeight < req.FromHeight {
+		return status.Errorf(codes.InvalidArgument, "invalid heights range [%d, %d]", req.FromHeight,
+			req.ToHeight)
+	}
+	height, err := s.state.Height()
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if proto.Height(req.FromHeight) > height+1 {
+		return status.Errorf(codes.InvalidArgument, "from height %d is greater than blockchain height %d",
+			req.FromHeight, height)
+	}
+	from, to := proto.Height(req.FromHeight), proto.Height(max(req.ToHeight, 0))
+	err = publisher.Subscribe(srv.Context(), from, to, func(u *events.BlockchainUpdated) error {
+		return srv.Send(&eg.SubscribeEvent{Update: u})
+	})
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, blockchain_updates.ErrSubscriptionOverflow):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
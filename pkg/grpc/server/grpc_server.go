@@ -11,6 +11,7 @@ import (
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 
+	eg "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/events/grpc"
 	g "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/node/grpc"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 	"github.com/wavesplatform/gowaves/pkg/services"
@@ -64,6 +65,7 @@ func createGRPCServerWithHandlers(handlers GrpcHandlers) *grpc.Server {
 	g.RegisterBlockchainApiServer(grpcServer, handlers)
 	g.RegisterBlocksApiServer(grpcServer, handlers)
 	g.RegisterTransactionsApiServer(grpcServer, handlers)
+	eg.RegisterBlockchainUpdatesApiServer(grpcServer, handlers)
 	reflection.Register(grpcServer) // Register reflection service on gRPC server.
 	return grpcServer
 }
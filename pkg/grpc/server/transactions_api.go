@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"time"
 
@@ -12,11 +13,13 @@ import (
 	"github.com/wavesplatform/gowaves/pkg/errs"
 	pb "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves"
 	g "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/node/grpc"
+	"github.com/wavesplatform/gowaves/pkg/miner/utxpool"
 	"github.com/wavesplatform/gowaves/pkg/node/messages"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 	"github.com/wavesplatform/gowaves/pkg/settings"
 	"github.com/wavesplatform/gowaves/pkg/state"
 	"github.com/wavesplatform/gowaves/pkg/util/iterators"
+	"github.com/wavesplatform/gowaves/pkg/wallet"
 )
 
 type getTransactionsHandler struct {
@@ -78,8 +81,56 @@ func (s *Server) GetTransactionSnapshots(
 	req *g.TransactionSnapshotsRequest,
 	srv g.TransactionsApi_GetTransactionSnapshotsServer,
 ) error {
-	unimplementedServer := g.UnimplementedTransactionsApiServer{} // TODO: implement GetTransactionSnapshots
-	return unimplementedServer.GetTransactionSnapshots(req, srv)
+	for _, id := range req.TransactionIds {
+		height, err := s.state.TransactionHeightByID(id)
+		if err != nil {
+			if state.IsNotFound(err) {
+				continue // Unknown transactions are skipped as in GetTransactions.
+			}
+			return status.Error(codes.Internal, err.Error())
+		}
+		snapshot, err := s.transactionSnapshot(id, height)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if err := srv.Send(&g.TransactionSnapshotResponse{Id: id, Snapshot: snapshot}); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+	return nil
+}
+
+// transactionSnapshot finds the snapshot of the transaction among the snapshots of its block.
+func (s *Server) transactionSnapshot(id []byte, height proto.Height) (*pb.TransactionStateSnapshot, error) {
+	block, err := s.state.BlockByHeight(height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get block at height %d", height)
+	}
+	blockSnapshot, err := s.state.SnapshotsAtHeight(height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get snapshots at height %d", height)
+	}
+	if len(blockSnapshot.TxSnapshots) != len(block.Transactions) {
+		return nil, errors.Errorf("number of snapshots %d differs from number of transactions %d at height %d",
+			len(blockSnapshot.TxSnapshots), len(block.Transactions), height)
+	}
+	for i, tx := range block.Transactions {
+		txID, idErr := tx.GetID(s.scheme)
+		if idErr != nil {
+			return nil, errors.Wrap(idErr, "failed to get transaction ID")
+		}
+		if !bytes.Equal(txID, id) {
+			continue
+		}
+		res := &pb.TransactionStateSnapshot{}
+		for _, atomic := range blockSnapshot.TxSnapshots[i] {
+			if aErr := atomic.AppendToProtobuf(res); aErr != nil {
+				return nil, errors.Wrap(aErr, "failed to convert snapshot to protobuf")
+			}
+		}
+		return res, nil
+	}
+	return nil, errors.Errorf("transaction '%s' not found in block at height %d", proto.B58Bytes(id), height)
 }
 
 type getStateChangesHandler struct {
@@ -220,8 +271,34 @@ func (s *Server) GetUnconfirmed(req *g.TransactionsRequest, srv g.TransactionsAp
 	return nil
 }
 
-func (s *Server) Sign(ctx context.Context, req *g.SignRequest) (*pb.SignedTransaction, error) {
-	return g.UnimplementedTransactionsApiServer{}.Sign(ctx, req)
+func (s *Server) Sign(_ context.Context, req *g.SignRequest) (*pb.SignedTransaction, error) {
+	if s.wallet == nil {
+		return nil, status.Error(codes.FailedPrecondition, "wallet is not available")
+	}
+	c := proto.ProtobufConverter{FallbackChainID: s.scheme}
+	tx, err := c.Transaction(req.Transaction)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	signer := req.SignerPublicKey
+	if len(signer) == 0 {
+		signer = req.Transaction.GetSenderPublicKey()
+	}
+	pk, err := crypto.NewPublicKeyFromBytes(signer)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid signer public key: %v", err)
+	}
+	if err := s.wallet.SignTransactionWith(pk, tx); err != nil {
+		if errors.Is(err, wallet.ErrPublicKeyNotFound) {
+			return nil, status.Errorf(codes.NotFound, "no key for public key '%s' in wallet", pk.String())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	res, err := tx.ToProtobufSigned(s.scheme)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return res, nil
 }
 
 func (s *Server) Broadcast(ctx context.Context, tx *pb.SignedTransaction) (out *pb.SignedTransaction, err error) {
@@ -247,6 +324,13 @@ func (s *Server) Broadcast(ctx context.Context, tx *pb.SignedTransaction) (out *
 }
 
 func apiError(err error) error {
+	var re *utxpool.RejectionError
+	if errors.As(err, &re) {
+		if re.Reason == utxpool.AlreadyInPool {
+			return status.Error(codes.AlreadyExists, err.Error())
+		}
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
 	err = errors.Cause(err)
 	switch e := err.(type) {
 	case *errs.NonPositiveAmount:
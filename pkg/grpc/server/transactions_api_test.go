@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"log"
@@ -9,12 +10,14 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
 
 	"github.com/wavesplatform/gowaves/pkg/crypto"
 	pb "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves"
@@ -109,6 +112,50 @@ func TestGetTransactions(t *testing.T) {
 	assert.Equal(t, io.EOF, err)
 }
 
+func TestGetTransactionSnapshots(t *testing.T) {
+	genesisPath, err := globalPathFromLocal("testdata/genesis/lease_genesis.json")
+	require.NoError(t, err)
+	st := stateWithCustomGenesis(t, genesisPath)
+	ctx := withAutoCancel(t, context.Background())
+	sch := createTestNetWallet(t)
+	err = server.initServer(st, nil, sch)
+	require.NoError(t, err)
+
+	conn := connectAutoClose(t, grpcTestAddr)
+
+	id, err := crypto.NewDigestFromBase58("ADXuoPsKMJ59HyLMGzLBbNQD8p2eJ93dciuBPJp3Qhx")
+	require.NoError(t, err)
+	height, err := st.TransactionHeightByID(id.Bytes())
+	require.NoError(t, err)
+	blockSnapshot, err := st.SnapshotsAtHeight(height)
+	require.NoError(t, err)
+	snapshots, err := blockSnapshot.ToProtobuf()
+	require.NoError(t, err)
+	block, err := st.BlockByHeight(height)
+	require.NoError(t, err)
+	var correctSnapshot *pb.TransactionStateSnapshot
+	for i, tx := range block.Transactions {
+		txID, idErr := tx.GetID(server.scheme)
+		require.NoError(t, idErr)
+		if bytes.Equal(txID, id.Bytes()) {
+			correctSnapshot = snapshots[i]
+		}
+	}
+	require.NotNil(t, correctSnapshot)
+
+	cl := g.NewTransactionsApiClient(conn)
+	// Unknown transactions are skipped.
+	req := &g.TransactionSnapshotsRequest{TransactionIds: [][]byte{id.Bytes(), crypto.MustFastHash([]byte{1}).Bytes()}}
+	stream, err := cl.GetTransactionSnapshots(ctx, req)
+	require.NoError(t, err)
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, id.Bytes(), res.Id)
+	assert.True(t, protobuf.Equal(correctSnapshot, res.Snapshot))
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+}
+
 func TestGetStatuses(t *testing.T) {
 	bs := settings.MustMainNetSettings()
 	params := defaultStateParams()
@@ -275,12 +322,30 @@ func TestSign(t *testing.T) {
 
 	cl := g.NewTransactionsApiClient(conn)
 	req := &g.SignRequest{Transaction: txProto, SignerPublicKey: pk.Bytes()}
-	_, err = cl.Sign(ctx, req)
+	res, err := cl.Sign(ctx, req)
+	require.NoError(t, err)
+	c := proto.ProtobufConverter{FallbackChainID: server.scheme}
+	signed, err := c.SignedTransaction(res)
+	require.NoError(t, err)
+	signedTransfer, ok := signed.(*proto.TransferWithSig)
+	require.True(t, ok)
+	valid, err := signedTransfer.Verify(server.scheme, pk)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	// Signer is taken from the transaction if not set.
+	res, err = cl.Sign(ctx, &g.SignRequest{Transaction: txProto})
+	require.NoError(t, err)
+	assert.Len(t, res.Proofs, 1)
+
+	// Unknown signer.
+	otherPK, err := crypto.NewPublicKeyFromBase58("J26jFKB6rRtZ3fzGrK3uMKAkMwMzBAjeE8Hg1RUEfqE3")
+	require.NoError(t, err)
+	_, err = cl.Sign(ctx, &g.SignRequest{Transaction: txProto, SignerPublicKey: otherPK.Bytes()})
 	require.Error(t, err)
 	s, ok := status.FromError(err)
 	require.True(t, ok)
-	require.Equal(t, codes.Unimplemented, s.Code())
-	require.Equal(t, "method Sign not implemented", s.Message())
+	assert.Equal(t, codes.NotFound, s.Code())
 }
 
 func TestBroadcast(t *testing.T) {
@@ -312,3 +377,19 @@ func TestBroadcast(t *testing.T) {
 	_, err = cl.Broadcast(ctx, &pb.SignedTransaction{})
 	require.NoError(t, err)
 }
+
+func TestGetTransactionSnapshotsInternalError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewMockState(ctrl)
+	id := crypto.MustFastHash([]byte{1}).Bytes()
+	st.EXPECT().TransactionHeightByID(id).Return(uint64(0), errors.New("storage failure"))
+	ctx := withAutoCancel(t, context.Background())
+	err := server.initServer(st, nil, createTestNetWallet(t))
+	require.NoError(t, err)
+
+	cl := g.NewTransactionsApiClient(connectAutoClose(t, grpcTestAddr))
+	stream, err := cl.GetTransactionSnapshots(ctx, &g.TransactionSnapshotsRequest{TransactionIds: [][]byte{id}})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
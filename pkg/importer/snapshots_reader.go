@@ -28,7 +28,6 @@ func newSnapshotsReader(scheme proto.Scheme, snapshotsPath string) (*snapshotsRe
 }
 
 func (sr *snapshotsReader) readSize() (uint32, error) {
-	const sanityMaxBlockSnapshotSize = 100 * MiB
 	var buf [uint32Size]byte
 	pos := sr.pos
 	n, err := io.ReadFull(sr.r, buf[:])
@@ -37,7 +36,7 @@ func (sr *snapshotsReader) readSize() (uint32, error) {
 	}
 	sr.pos += n
 	size := binary.BigEndian.Uint32(buf[:])
-	if size > sanityMaxBlockSnapshotSize { // don't check for 0 size because it is valid
+	if size > MaxBlockSnapshotSize { // don't check for 0 size because it is valid
 		return 0, fmt.Errorf("block snapshot size %d is too big at pos %d", size, pos)
 	}
 	return size, nil
@@ -0,0 +1,107 @@
+package keyvalue
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Backend is the storage engine of the KeyVal database.
+type Backend string
+
+const (
+	LevelDB Backend = "leveldb"
+	Badger  Backend = "badger"
+)
+
+func (b Backend) String() string {
+	return string(b)
+}
+
+// ParseBackend returns the backend by its name. Empty name means the default LevelDB backend.
+func ParseBackend(name string) (Backend, error) {
+	switch b := Backend(name); b {
+	case "":
+		return LevelDB, nil
+	case LevelDB, Badger:
+		return b, nil
+	default:
+		return "", errors.Errorf("unsupported database backend '%s'", name)
+	}
+}
+
+// DetectBackend returns the backend of the database stored at the given path.
+// Empty backend is returned if there is no database at the path.
+func DetectBackend(path string) (Backend, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "failed to read database directory '%s'", path)
+	}
+	if len(entries) == 0 {
+		return "", nil
+	}
+	for _, m := range []struct {
+		file    string
+		backend Backend
+	}{
+		{file: "CURRENT", backend: LevelDB},
+		{file: "KEYREGISTRY", backend: Badger},
+	} {
+		if _, sErr := os.Stat(filepath.Join(path, m.file)); sErr == nil {
+			return m.backend, nil
+		}
+	}
+	return "", errors.Errorf("unknown database format at '%s'", path)
+}
+
+// selectBackend checks the requested backend against the backend of the existing database.
+// If no backend was requested, the backend of existing database or the default one is used.
+func selectBackend(path string, requested Backend) (Backend, error) {
+	existing, err := DetectBackend(path)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case existing == "":
+		return ParseBackend(requested.String())
+	case requested == "" || requested == existing:
+		return existing, nil
+	default:
+		return "", errors.Errorf("database at '%s' uses %s backend, but %s backend is requested, migrate the database first",
+			path, existing, requested,
+		)
+	}
+}
+
+// engine is the storage engine of the KeyVal. Caching and bloom filter are done by KeyVal.
+type engine interface {
+	get(key []byte) ([]byte, error)
+	has(key []byte) (bool, error)
+	put(key, val []byte) error
+	delete(key []byte) error
+	write(pairs []pair) error
+	newIterator(prefix, start []byte) Iterator
+	newSnapshot() (engineSnapshot, error)
+	close() error
+}
+
+type engineSnapshot interface {
+	newIterator() Iterator
+	release()
+}
+
+func openEngine(path string, backend Backend, params KeyValParams) (engine, error) {
+	switch backend {
+	case LevelDB:
+		return openLevelDB(path, params)
+	case Badger:
+		return openBadger(path, params)
+	default:
+		return nil, errors.Errorf("unsupported database backend '%s'", backend)
+	}
+}
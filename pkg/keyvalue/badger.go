@@ -0,0 +1,491 @@
+package keyvalue
+
+import (
+	"bytes"
+	"encoding/binary"
+	stderrs "errors"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// Badger limits the size of a transaction to 15% of the memtable size, the memtable is made big enough
+	// to write usual state batches in one transaction.
+	badgerMemTableSize = 128 << 20
+	badgerNumMemtables = 3
+	// badgerJournalChunkSize is the size of the record of journal the batch is split into.
+	badgerJournalChunkSize = 4 << 20
+	uint32Size             = 4
+)
+
+var (
+	// badgerJournalPrefix is the prefix of journal records, the leading bytes keep them apart from the state keys.
+	badgerJournalPrefix    = []byte("\xff\xffjournal/")
+	badgerJournalCommitKey = append(bytes.Clone(badgerJournalPrefix), "commit"...)
+	badgerJournalChunkKey  = append(bytes.Clone(badgerJournalPrefix), "chunk/"...)
+)
+
+type badgerDB struct {
+	db *badger.DB
+}
+
+func openBadger(path string, _ KeyValParams) (*badgerDB, error) {
+	opts := badger.DefaultOptions(path).
+		WithLogger(badgerLogger{zap.S()}).
+		WithMemTableSize(badgerMemTableSize).
+		WithNumMemtables(badgerNumMemtables).
+		WithDetectConflicts(false) // There is a single writer.
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	b := &badgerDB{db: db}
+	if jErr := b.replayJournal(); jErr != nil {
+		return nil, stderrs.Join(errors.Wrap(jErr, "failed to replay journal"), db.Close())
+	}
+	return b, nil
+}
+
+func (b *badgerDB) get(key []byte) ([]byte, error) {
+	var val []byte
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		val, err = item.ValueCopy(nil)
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, ErrNotFound
+	}
+	return val, err
+}
+
+func (b *badgerDB) has(key []byte) (bool, error) {
+	err := b.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(key)
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *badgerDB) put(key, val []byte) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, val)
+	})
+}
+
+func (b *badgerDB) delete(key []byte) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+// write writes the pairs atomically. Badger limits the size of a transaction, so the batch which doesn't fit into
+// one transaction is written to the journal first and then applied by several transactions. The journal of the write
+// interrupted by a crash is replayed when the database is opened, so the batch is never left partially written.
+func (b *badgerDB) write(pairs []pair) error {
+	txn := b.db.NewTransaction(true)
+	defer txn.Discard()
+	for _, p := range pairs {
+		if err := applyPair(txn, p); err != nil {
+			if errors.Is(err, badger.ErrTxnTooBig) {
+				return b.writeThroughJournal(pairs)
+			}
+			return err
+		}
+	}
+	return txn.Commit()
+}
+
+func (b *badgerDB) writeThroughJournal(pairs []pair) error {
+	if err := b.writeJournal(pairs); err != nil {
+		return errors.Wrapf(err, "failed to write journal of batch of %d records", len(pairs))
+	}
+	if err := b.writeSplit(pairs); err != nil {
+		return err
+	}
+	return b.dropJournal()
+}
+
+// writeSplit writes the pairs in order by as many transactions as needed.
+func (b *badgerDB) writeSplit(pairs []pair) error {
+	txn := b.db.NewTransaction(true)
+	defer func() {
+		txn.Discard()
+	}()
+	for _, p := range pairs {
+		err := applyPair(txn, p)
+		if errors.Is(err, badger.ErrTxnTooBig) {
+			if cErr := txn.Commit(); cErr != nil {
+				return cErr
+			}
+			txn = b.db.NewTransaction(true)
+			err = applyPair(txn, p)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return txn.Commit()
+}
+
+// writeJournal stores the pairs in chunks, each chunk is written by its own transaction.
+// The batch becomes committed when the commit record holding the number of chunks is written.
+func (b *badgerDB) writeJournal(pairs []pair) error {
+	var (
+		chunk []byte
+		count uint32
+	)
+	flushChunk := func() error {
+		if err := b.put(binary.BigEndian.AppendUint32(bytes.Clone(badgerJournalChunkKey), count), chunk); err != nil {
+			return err
+		}
+		chunk = chunk[:0]
+		count++
+		return nil
+	}
+	for _, p := range pairs {
+		chunk = appendJournalPair(chunk, p)
+		if len(chunk) >= badgerJournalChunkSize {
+			if err := flushChunk(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(chunk) > 0 {
+		if err := flushChunk(); err != nil {
+			return err
+		}
+	}
+	return b.put(badgerJournalCommitKey, binary.BigEndian.AppendUint32(nil, count))
+}
+
+// replayJournal completes the write of the committed batch. The journal without commit record is just removed,
+// because nothing of its batch has been written yet.
+func (b *badgerDB) replayJournal() error {
+	val, err := b.get(badgerJournalCommitKey)
+	if errors.Is(err, ErrNotFound) {
+		return b.dropJournal()
+	}
+	if err != nil {
+		return err
+	}
+	if len(val) != uint32Size {
+		return errors.Errorf("invalid journal commit record size %d", len(val))
+	}
+	var pairs []pair
+	count := binary.BigEndian.Uint32(val)
+	for i := range count {
+		chunk, gErr := b.get(binary.BigEndian.AppendUint32(bytes.Clone(badgerJournalChunkKey), i))
+		if gErr != nil {
+			return errors.Wrapf(gErr, "failed to read journal chunk %d", i)
+		}
+		if pairs, err = readJournalPairs(pairs, chunk); err != nil {
+			return errors.Wrapf(err, "invalid journal chunk %d", i)
+		}
+	}
+	if wErr := b.writeSplit(pairs); wErr != nil {
+		return wErr
+	}
+	return b.dropJournal()
+}
+
+// dropJournal removes the commit record first, so the partially removed journal is never replayed.
+func (b *badgerDB) dropJournal() error {
+	if err := b.delete(badgerJournalCommitKey); err != nil {
+		return err
+	}
+	var deletions []pair
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = badgerJournalPrefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			deletions = append(deletions, pair{key: it.Item().KeyCopy(nil), deletion: true})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return b.writeSplit(deletions)
+}
+
+func appendJournalPair(buf []byte, p pair) []byte {
+	var flag byte
+	if p.deletion {
+		flag = 1
+	}
+	buf = append(buf, flag)
+	buf = binary.AppendUvarint(buf, uint64(len(p.key)))
+	buf = append(buf, p.key...)
+	buf = binary.AppendUvarint(buf, uint64(len(p.value)))
+	return append(buf, p.value...)
+}
+
+func readJournalPairs(pairs []pair, data []byte) ([]pair, error) {
+	readBytes := func() ([]byte, error) {
+		n, l := binary.Uvarint(data)
+		if l <= 0 || n > uint64(len(data)-l) {
+			return nil, errors.New("invalid length")
+		}
+		b := bytes.Clone(data[l : l+int(n)])
+		data = data[l+int(n):]
+		return b, nil
+	}
+	for len(data) > 0 {
+		deletion := data[0] == 1
+		data = data[1:]
+		key, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+		value, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair{key: key, value: value, deletion: deletion})
+	}
+	return pairs, nil
+}
+
+func applyPair(txn *badger.Txn, p pair) error {
+	if p.deletion {
+		return txn.Delete(p.key)
+	}
+	return txn.Set(p.key, p.value)
+}
+
+func (b *badgerDB) newIterator(prefix, start []byte) Iterator {
+	return &badgerIterator{txn: b.db.NewTransaction(false), ownTxn: true, prefix: prefix, start: start}
+}
+
+func (b *badgerDB) newSnapshot() (engineSnapshot, error) {
+	return badgerSnapshot{txn: b.db.NewTransaction(false)}, nil
+}
+
+func (b *badgerDB) close() error {
+	return b.db.Close()
+}
+
+type badgerSnapshot struct {
+	txn *badger.Txn
+}
+
+func (s badgerSnapshot) newIterator() Iterator {
+	return &badgerIterator{txn: s.txn}
+}
+
+func (s badgerSnapshot) release() {
+	s.txn.Discard()
+}
+
+type iteratorPosition byte
+
+const (
+	beforeFirst iteratorPosition = iota
+	onRecord
+	afterLast
+)
+
+// badgerIterator implements bidirectional Iterator with the semantics of LevelDB iterator on top of
+// unidirectional Badger iterators. Forward and reverse iterators are created on demand.
+type badgerIterator struct {
+	txn    *badger.Txn
+	ownTxn bool
+	prefix []byte
+	start  []byte
+
+	fwd, rev *badger.Iterator
+	cur      *badger.Iterator
+	pos      iteratorPosition
+	key      []byte
+	value    []byte
+	err      error
+	released bool
+}
+
+func (i *badgerIterator) forward() *badger.Iterator {
+	if i.fwd == nil {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = i.prefix
+		i.fwd = i.txn.NewIterator(opts)
+	}
+	return i.fwd
+}
+
+// reverse returns the reverse iterator, it doesn't use prefix option, because it doesn't allow
+// to position on the last key with the prefix.
+func (i *badgerIterator) reverse() *badger.Iterator {
+	if i.rev == nil {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		i.rev = i.txn.NewIterator(opts)
+	}
+	return i.rev
+}
+
+func (i *badgerIterator) load(it *badger.Iterator, otherwise iteratorPosition) bool {
+	if i.err != nil || i.released || !it.Valid() || !i.inRange(it.Item().Key()) {
+		i.pos, i.cur, i.key, i.value = otherwise, nil, nil, nil
+		return false
+	}
+	item := it.Item()
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		i.err = err
+		i.pos, i.cur, i.key, i.value = otherwise, nil, nil, nil
+		return false
+	}
+	i.pos, i.cur, i.key, i.value = onRecord, it, item.KeyCopy(nil), value
+	return true
+}
+
+func (i *badgerIterator) inRange(key []byte) bool {
+	return bytes.HasPrefix(key, i.prefix) && bytes.Compare(key, i.start) >= 0
+}
+
+func (i *badgerIterator) Key() []byte {
+	return i.key
+}
+
+func (i *badgerIterator) Value() []byte {
+	return i.value
+}
+
+func (i *badgerIterator) Next() bool {
+	if i.released {
+		return false
+	}
+	switch i.pos {
+	case beforeFirst:
+		return i.First()
+	case afterLast:
+		return false
+	}
+	if i.cur == i.fwd {
+		i.fwd.Next()
+		return i.load(i.fwd, afterLast)
+	}
+	it := i.forward()
+	it.Seek(i.key)
+	if it.Valid() && bytes.Equal(it.Item().Key(), i.key) {
+		it.Next()
+	}
+	return i.load(it, afterLast)
+}
+
+func (i *badgerIterator) Prev() bool {
+	if i.released {
+		return false
+	}
+	switch i.pos {
+	case beforeFirst:
+		return false
+	case afterLast:
+		return i.Last()
+	}
+	if i.cur == i.rev {
+		i.rev.Next()
+		return i.load(i.rev, beforeFirst)
+	}
+	it := i.reverse()
+	it.Seek(i.key)
+	if it.Valid() && bytes.Equal(it.Item().Key(), i.key) {
+		it.Next()
+	}
+	return i.load(it, beforeFirst)
+}
+
+func (i *badgerIterator) First() bool {
+	if i.released {
+		return false
+	}
+	it := i.forward()
+	if bytes.Compare(i.start, i.prefix) > 0 {
+		it.Seek(i.start)
+	} else {
+		it.Rewind()
+	}
+	return i.load(it, afterLast)
+}
+
+func (i *badgerIterator) Last() bool {
+	if i.released {
+		return false
+	}
+	it := i.reverse()
+	succ := prefixSuccessor(i.prefix)
+	if succ == nil {
+		it.Rewind()
+		return i.load(it, beforeFirst)
+	}
+	it.Seek(succ)
+	if it.Valid() && bytes.Equal(it.Item().Key(), succ) {
+		it.Next()
+	}
+	return i.load(it, beforeFirst)
+}
+
+func (i *badgerIterator) Error() error {
+	return i.err
+}
+
+func (i *badgerIterator) Release() {
+	if i.released {
+		return
+	}
+	i.released = true
+	for _, it := range []*badger.Iterator{i.fwd, i.rev} {
+		if it != nil {
+			it.Close()
+		}
+	}
+	if i.ownTxn {
+		i.txn.Discard()
+	}
+	i.pos, i.cur, i.key, i.value = afterLast, nil, nil, nil
+}
+
+// prefixSuccessor returns the smallest key which is greater than all the keys with the prefix,
+// nil is returned if there is no such key.
+func prefixSuccessor(prefix []byte) []byte {
+	succ := bytes.Clone(prefix)
+	for j := len(succ) - 1; j >= 0; j-- {
+		if succ[j] < 0xff {
+			succ[j]++
+			return succ[:j+1]
+		}
+	}
+	return nil
+}
+
+// badgerLogger passes Badger logs to zap, Badger's info messages are logged at debug level.
+type badgerLogger struct {
+	l *zap.SugaredLogger
+}
+
+func (b badgerLogger) Errorf(format string, args ...interface{}) {
+	b.l.Errorf(format, args...)
+}
+
+func (b badgerLogger) Warningf(format string, args ...interface{}) {
+	b.l.Warnf(format, args...)
+}
+
+func (b badgerLogger) Infof(format string, args ...interface{}) {
+	b.l.Debugf(format, args...)
+}
+
+func (b badgerLogger) Debugf(format string, args ...interface{}) {
+	b.l.Debugf(format, args...)
+}
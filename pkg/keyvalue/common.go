@@ -60,6 +60,8 @@ func SafeValue(k ValueInter) []byte {
 type IterableKeyVal interface {
 	KeyValue
 	NewKeyIterator(prefix []byte) (Iterator, error)
+	// NewKeyIteratorFrom returns the iterator over the keys with the prefix which are not less than the start key.
+	NewKeyIteratorFrom(prefix, start []byte) (Iterator, error)
 }
 
 type CacheParams struct {
@@ -0,0 +1,424 @@
+package keyvalue
+
+import (
+	stderrs "errors"
+	"os"
+	"sync"
+
+	"github.com/coocood/freecache"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type pair struct {
+	key      []byte
+	value    []byte
+	deletion bool
+}
+
+type batch struct {
+	mu    *sync.Mutex
+	pairs []pair
+}
+
+func (b *batch) Delete(key []byte) {
+	b.mu.Lock()
+	keyCopy := make([]byte, len(key))
+	copy(keyCopy[:], key[:])
+	b.pairs = append(b.pairs, pair{key: keyCopy, deletion: true})
+	b.mu.Unlock()
+}
+
+func (b *batch) Put(key, val []byte) {
+	b.mu.Lock()
+	valCopy := make([]byte, len(val))
+	copy(valCopy[:], val[:])
+	keyCopy := make([]byte, len(key))
+	copy(keyCopy[:], key[:])
+	b.pairs = append(b.pairs, pair{key: keyCopy, value: valCopy, deletion: false})
+	b.mu.Unlock()
+}
+
+func (b *batch) addToFilter(filter BloomFilter) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, pair := range b.pairs {
+		if !pair.deletion {
+			if err := filter.add(pair.key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *batch) addToCache(cache *freecache.Cache) {
+	b.mu.Lock()
+	for _, pair := range b.pairs {
+		if pair.deletion {
+			cache.Del(pair.key)
+		} else {
+			if err := cache.Set(pair.key, pair.value, 0); err != nil {
+				// If we can not set the value for some reason, at least make sure the old one is gone.
+				cache.Del(pair.key)
+			}
+		}
+	}
+	b.mu.Unlock()
+}
+
+func (b *batch) writeTo(e engine) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return e.write(b.pairs)
+}
+
+func (b *batch) Reset() {
+	b.mu.Lock()
+	b.pairs = nil
+	b.mu.Unlock()
+}
+
+// KeyVal is the database with cache and bloom filter on top of the storage engine selected by Backend.
+type KeyVal struct {
+	db      engine
+	backend Backend
+	filter  BloomFilter
+	cache   *freecache.Cache
+	mu      *sync.RWMutex
+}
+
+func initBloomFilter(kv *KeyVal, params BloomFilterParams) error {
+	zap.S().Info("Loading stored bloom filter...")
+	filter, err := newBloomFilterFromStore(params)
+	if err == nil {
+		kv.filter = filter
+		zap.S().Info("Bloom filter loaded successfully")
+		return nil
+	}
+	zap.S().Info("No stored bloom filter found")
+	zap.S().Info("Rebuilding bloom filter from DB can take up a few minutes")
+	filter, err = newBloomFilter(params)
+	if err != nil {
+		return err
+	}
+	iter, err := kv.NewKeyIterator([]byte{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			zap.S().Fatalf("Iterator error: %v", err)
+		}
+	}()
+
+	for iter.Next() {
+		if err := filter.add(iter.Key()); err != nil {
+			return err
+		}
+	}
+	kv.filter = filter
+	return nil
+}
+
+type KeyValParams struct {
+	CacheParams
+	BloomFilterParams
+	// Backend is the storage engine of the database. Backend of the existing database is used if empty.
+	Backend                Backend
+	WriteBuffer            int
+	CompactionTableSize    int
+	CompactionTotalSize    int
+	OpenFilesCacheCapacity int
+}
+
+func NewKeyVal(path string, params KeyValParams) (*KeyVal, error) {
+	backend, err := selectBackend(path, params.Backend)
+	if err != nil {
+		return nil, err
+	}
+	db, err := openEngine(path, backend, params)
+	if err != nil {
+		return nil, err
+	}
+	zap.S().Debugf("Database '%s' opened with %s backend", path, backend)
+	cache := freecache.NewCache(params.CacheSize)
+	kv := &KeyVal{db: db, backend: backend, cache: cache, mu: &sync.RWMutex{}}
+	if err := initBloomFilter(kv, params.BloomFilterParams); err != nil {
+		return nil, stderrs.Join(err, db.close())
+	}
+	return kv, nil
+}
+
+// Backend returns the storage engine of the database.
+func (k *KeyVal) Backend() Backend {
+	return k.backend
+}
+
+func (k *KeyVal) NewBatch() (Batch, error) {
+	return &batch{mu: &sync.Mutex{}}, nil
+}
+
+func (k *KeyVal) addToCache(key, val []byte) {
+	if err := k.cache.Set(key, val, 0); err != nil {
+		// If we can not set the value for some reason, at least make sure the old one is gone.
+		k.cache.Del(key)
+	}
+}
+
+func (k *KeyVal) Get(key []byte) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if val, err := k.cache.Get(key); err == nil { // If `segment.NotFound` error is returned it ignored here
+		return val, nil
+	}
+	// No entry in cache, looking up in DB
+	if k.filter != nil {
+		notInTheSet, err := k.filter.notInTheSet(key)
+		if err != nil {
+			return nil, err // Hashing error here
+		}
+		if notInTheSet {
+			return nil, ErrNotFound
+		}
+	}
+	val, err := k.db.get(key)
+	if err != nil {
+		return nil, err
+	}
+	k.addToCache(key, val)
+	return val, nil
+}
+
+func (k *KeyVal) Has(key []byte) (bool, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.filter != nil {
+		notInTheSet, err := k.filter.notInTheSet(key)
+		if err != nil {
+			return false, err
+		}
+		if notInTheSet {
+			return false, nil
+		}
+	}
+	if _, err := k.cache.Get(key); err == nil {
+		return true, nil
+	}
+	return k.db.has(key)
+}
+
+func (k *KeyVal) Delete(key []byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.cache.Del(key)
+	return k.db.delete(key)
+}
+
+func (k *KeyVal) Put(key, val []byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.db.put(key, val); err != nil {
+		return err
+	}
+	if err := k.filter.add(key); err != nil {
+		return err
+	}
+	k.addToCache(key, val)
+	return nil
+}
+
+func (k *KeyVal) Flush(b1 Batch) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	b, ok := b1.(*batch)
+	if !ok {
+		return errors.New("can't convert Batch interface to database batch")
+	}
+	if err := b.writeTo(k.db); err != nil {
+		return err
+	}
+	b.addToCache(k.cache)
+	if err := b.addToFilter(k.filter); err != nil {
+		return err
+	}
+	b.Reset()
+	return nil
+}
+
+func (k *KeyVal) NewKeyIterator(prefix []byte) (Iterator, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.db.newIterator(prefix, nil), nil
+}
+
+func (k *KeyVal) NewKeyIteratorFrom(prefix, start []byte) (Iterator, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.db.newIterator(prefix, start), nil
+}
+
+func (k *KeyVal) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	zap.S().Infof("Cache hit rate: %v", k.cache.HitRate())
+	err := storeBloomFilter(k.filter)
+	if err != nil {
+		zap.S().Errorf("Failed to save bloom filter: %v", err)
+	} else {
+		zap.S().Info("Bloom filter stored successfully")
+	}
+	return k.db.close()
+}
+
+// NewSnapshot returns a consistent read-only view of the database at the moment of the call.
+// Returned snapshot must be released after use.
+func (k *KeyVal) NewSnapshot() (*Snapshot, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	snap, err := k.db.newSnapshot()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get database snapshot")
+	}
+	return &Snapshot{snap: snap, backend: k.backend}, nil
+}
+
+// Snapshot is a frozen state of the KeyVal database.
+type Snapshot struct {
+	snap    engineSnapshot
+	backend Backend
+}
+
+// NewIterator returns the iterator over all the records of the snapshot.
+func (s *Snapshot) NewIterator() Iterator {
+	return s.snap.newIterator()
+}
+
+// WriteTo copies all the records of the snapshot to the new database created at the given path.
+// The new database uses the same backend as the snapshot.
+func (s *Snapshot) WriteTo(path string) (err error) {
+	w, err := NewBulkWriter(path, s.backend)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := w.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	if _, cErr := copyRecords(w, s.snap.newIterator()); cErr != nil {
+		return errors.Wrap(cErr, "failed to iterate over snapshot")
+	}
+	return nil
+}
+
+// Release releases the snapshot. Snapshot can't be used after release.
+func (s *Snapshot) Release() {
+	s.snap.release()
+}
+
+const (
+	// bulkWriteBatchSize is the maximum number of records written to the new database in one batch.
+	bulkWriteBatchSize = 10000
+	// bulkWriteBatchBytes is the maximum size of keys and values written in one batch, it keeps the batch
+	// within the transaction size limit of Badger.
+	bulkWriteBatchBytes = 4 << 20
+)
+
+// BulkWriter fills the new database with records. It bypasses caches and bloom filter,
+// the bloom filter is rebuilt when the database is opened by NewKeyVal.
+type BulkWriter struct {
+	db    engine
+	pairs []pair
+	size  int
+}
+
+// NewBulkWriter creates the new database at the given path, the database must not exist.
+// Empty backend means the default one.
+func NewBulkWriter(path string, backend Backend) (*BulkWriter, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Wrapf(err, "failed to create database at '%s'", path)
+	}
+	if len(entries) != 0 {
+		return nil, errors.Errorf("failed to create database at '%s': already exists", path)
+	}
+	backend, err = ParseBackend(backend.String())
+	if err != nil {
+		return nil, err
+	}
+	db, err := openEngine(path, backend, KeyValParams{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create database at '%s'", path)
+	}
+	return &BulkWriter{db: db, pairs: make([]pair, 0, bulkWriteBatchSize)}, nil
+}
+
+// Put adds the record to the database. Key and value are copied.
+func (w *BulkWriter) Put(key, value []byte) error {
+	w.pairs = append(w.pairs, pair{key: append([]byte(nil), key...), value: append([]byte(nil), value...)})
+	w.size += len(key) + len(value)
+	if len(w.pairs) < bulkWriteBatchSize && w.size < bulkWriteBatchBytes {
+		return nil
+	}
+	if err := w.db.write(w.pairs); err != nil {
+		return errors.Wrap(err, "failed to write batch")
+	}
+	w.pairs, w.size = w.pairs[:0], 0
+	return nil
+}
+
+// Close writes the rest of records and closes the database.
+func (w *BulkWriter) Close() error {
+	if err := w.db.write(w.pairs); err != nil {
+		return stderrs.Join(errors.Wrap(err, "failed to write batch"), w.db.close())
+	}
+	if err := w.db.close(); err != nil {
+		return errors.Wrap(err, "failed to close database")
+	}
+	return nil
+}
+
+// CopyDatabase copies all the records of the database at src path to the new database at dst path
+// which uses the given backend. It is used to migrate the database between backends, the source database
+// must not be used by anyone else during the copying. Returns the number of copied records.
+func CopyDatabase(src, dst string, backend Backend) (_ uint64, err error) {
+	srcBackend, err := DetectBackend(src)
+	if err != nil {
+		return 0, err
+	}
+	if srcBackend == "" {
+		return 0, errors.Errorf("no database found at '%s'", src)
+	}
+	in, err := openEngine(src, srcBackend, KeyValParams{})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to open database at '%s'", src)
+	}
+	defer func() {
+		if clErr := in.close(); clErr != nil {
+			err = stderrs.Join(err, errors.Wrap(clErr, "failed to close source database"))
+		}
+	}()
+	w, err := NewBulkWriter(dst, backend)
+	if err != nil {
+		return 0, err
+	}
+	n, err := copyRecords(w, in.newIterator(nil, nil))
+	if clErr := w.Close(); clErr != nil {
+		err = stderrs.Join(err, clErr)
+	}
+	return n, err
+}
+
+func copyRecords(w *BulkWriter, iter Iterator) (uint64, error) {
+	defer iter.Release()
+	var n uint64
+	for iter.Next() {
+		if err := w.Put(iter.Key(), iter.Value()); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, iter.Error()
+}
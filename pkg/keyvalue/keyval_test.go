@@ -0,0 +1,344 @@
+package keyvalue
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cacheSize           = 100
+	writeBuffer         = 4 * 1024 * 1024
+	sstableSize         = 2 * 1024 * 1024
+	compactionTotalSize = 10 * 1024 * 1024
+)
+
+var backends = []Backend{LevelDB, Badger}
+
+func testKeyValParams(backend Backend) KeyValParams {
+	return KeyValParams{
+		CacheParams:         CacheParams{cacheSize},
+		BloomFilterParams:   BloomFilterParams{n, falsePositiveProbability, NoOpStore{}, false},
+		Backend:             backend,
+		WriteBuffer:         writeBuffer,
+		CompactionTableSize: sstableSize,
+		CompactionTotalSize: compactionTotalSize,
+	}
+}
+
+func newTestKeyVal(t *testing.T, path string, backend Backend) *KeyVal {
+	kv, err := NewKeyVal(path, testKeyValParams(backend))
+	require.NoError(t, err, "NewKeyVal() failed")
+	t.Cleanup(func() {
+		assert.NoError(t, kv.Close(), "Close() failed")
+	})
+	return kv
+}
+
+// runForBackends runs the conformance test for each backend.
+func runForBackends(t *testing.T, test func(t *testing.T, backend Backend)) {
+	for _, backend := range backends {
+		t.Run(backend.String(), func(t *testing.T) {
+			test(t, backend)
+		})
+	}
+}
+
+func TestKeyVal(t *testing.T) {
+	runForBackends(t, testKeyVal)
+}
+
+func testKeyVal(t *testing.T, backend Backend) {
+	kv := newTestKeyVal(t, t.TempDir(), backend)
+	assert.Equal(t, backend, kv.Backend())
+
+	// Test direct DB operations.
+	keyPrefix := []byte("sampleKey")
+	key0 := []byte("sampleKey0")
+	val0 := []byte("sampleValue0")
+	err := kv.Put(key0, val0)
+	assert.NoError(t, err, "Put() failed")
+	receivedVal, err := kv.Get(key0)
+	assert.NoError(t, err, "Get() failed")
+	assert.Equal(t, val0, receivedVal, "saved and retrieved values for same key differ")
+	has, err := kv.Has(key0)
+	assert.NoError(t, err, "Has() failed")
+	assert.Equal(t, has, true, "Has() returned false for value that was saved before")
+	err = kv.Delete(key0)
+	assert.NoError(t, err, "Delete() failed")
+	has, err = kv.Has(key0)
+	assert.NoError(t, err, "Has() failed")
+	assert.Equal(t, has, false, "Has() returned true for deleted value")
+	_, err = kv.Get(key0)
+	assert.ErrorIs(t, err, ErrNotFound)
+	// Test batch operations.
+	key1 := []byte("sampleKey1")
+	val1 := []byte("sampleValue1")
+	batch, err := kv.NewBatch()
+	assert.NoError(t, err, "NewBatch() failed")
+	batch.Put(key0, val0)
+	batch.Put(key1, val1)
+	batch.Delete(key0)
+	err = kv.Flush(batch)
+	assert.NoError(t, err, "Flush() failed")
+	receivedVal, err = kv.Get(key1)
+	assert.NoError(t, err, "Get() failed")
+	assert.Equal(t, val1, receivedVal, "saved and retrieved values for same key differ")
+	has, err = kv.Has(key0)
+	assert.NoError(t, err, "Has() failed")
+	assert.Equal(t, has, false, "Has() returned true for value that was deleted from batch")
+
+	// Add another key-value pair directly.
+	err = kv.Put(key0, val0)
+	assert.NoError(t, err)
+
+	// Test iterator's Next().
+	iter, err := kv.NewKeyIterator([]byte{})
+	assert.NoError(t, err, "NewKeyIterator() failed")
+	for iter.Next() {
+		key := iter.Key()
+		val := iter.Value()
+		receivedVal, err = kv.Get(key)
+		assert.NoError(t, err, "Get() failed")
+		assert.Equal(t, val, receivedVal, "Invalid value in iterator")
+	}
+	iter.Release()
+	err = iter.Error()
+	assert.NoError(t, err, "iterator error")
+
+	// Test iterator's First() / Last().
+	iter, err = kv.NewKeyIterator(keyPrefix)
+	assert.NoError(t, err, "NewKeyIterator() failed")
+	moved := iter.Last()
+	assert.Equal(t, true, moved)
+	assert.Equal(t, key1, iter.Key())
+	assert.Equal(t, val1, iter.Value())
+	moved = iter.First()
+	assert.Equal(t, true, moved)
+	assert.Equal(t, key0, iter.Key())
+	assert.Equal(t, val0, iter.Value())
+	iter.Release()
+	err = iter.Error()
+	assert.NoError(t, err, "iterator error")
+}
+
+func TestKeyValSnapshot(t *testing.T) {
+	runForBackends(t, testKeyValSnapshot)
+}
+
+func testKeyValSnapshot(t *testing.T, backend Backend) {
+	kv := newTestKeyVal(t, t.TempDir(), backend)
+
+	key0, val0 := []byte("key0"), []byte("value0")
+	key1, val1 := []byte("key1"), []byte("value1")
+	require.NoError(t, kv.Put(key0, val0))
+
+	snap, err := kv.NewSnapshot()
+	require.NoError(t, err)
+	defer snap.Release()
+
+	// Changes made after snapshot creation must not get into the copy.
+	require.NoError(t, kv.Put(key1, val1))
+	require.NoError(t, kv.Delete(key0))
+
+	path := filepath.Join(t.TempDir(), "copy")
+	require.NoError(t, snap.WriteTo(path))
+	// Writing to existing database is prohibited.
+	assert.Error(t, snap.WriteTo(path))
+	// Copy uses the same backend.
+	copyBackend, err := DetectBackend(path)
+	require.NoError(t, err)
+	assert.Equal(t, backend, copyBackend)
+
+	cp := newTestKeyVal(t, path, "")
+	v, err := cp.Get(key0)
+	require.NoError(t, err)
+	assert.Equal(t, val0, v)
+	_, err = cp.Get(key1)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestKeyValReopen(t *testing.T) {
+	runForBackends(t, func(t *testing.T, backend Backend) {
+		path := t.TempDir()
+		kv, err := NewKeyVal(path, testKeyValParams(backend))
+		require.NoError(t, err)
+		b, err := kv.NewBatch()
+		require.NoError(t, err)
+		for i := range 1000 {
+			b.Put([]byte(fmt.Sprintf("key%04d", i)), []byte(fmt.Sprintf("value%d", i)))
+		}
+		require.NoError(t, kv.Flush(b))
+		require.NoError(t, kv.Close())
+
+		detected, err := DetectBackend(path)
+		require.NoError(t, err)
+		assert.Equal(t, backend, detected)
+		for _, other := range backends {
+			if other != backend {
+				_, err = NewKeyVal(path, testKeyValParams(other))
+				assert.ErrorContains(t, err, "migrate the database first")
+			}
+		}
+
+		// Existing backend is used by default.
+		kv = newTestKeyVal(t, path, "")
+		assert.Equal(t, backend, kv.Backend())
+		v, err := kv.Get([]byte("key0999"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("value999"), v)
+	})
+}
+
+func TestBadgerWritesBigBatch(t *testing.T) {
+	kv := newTestKeyVal(t, t.TempDir(), Badger)
+	require.NoError(t, kv.Put([]byte("deleted"), []byte("value")))
+	b, err := kv.NewBatch()
+	require.NoError(t, err)
+	value := make([]byte, 512<<10)
+	const count = 64 // 32 MiB exceeds the transaction size limit.
+	for i := range count {
+		b.Put([]byte(fmt.Sprintf("key%02d", i)), value)
+	}
+	b.Delete([]byte("deleted"))
+	require.NoError(t, kv.Flush(b))
+	for i := range count {
+		v, gErr := kv.db.get([]byte(fmt.Sprintf("key%02d", i)))
+		require.NoError(t, gErr)
+		assert.Equal(t, value, v)
+	}
+	has, err := kv.db.has([]byte("deleted"))
+	require.NoError(t, err)
+	assert.False(t, has)
+	assertNoJournal(t, kv.db)
+}
+
+func TestBadgerReplaysJournal(t *testing.T) {
+	pairs := []pair{
+		{key: []byte("key1"), value: []byte("new")},
+		{key: []byte("key2"), deletion: true},
+		{key: []byte("key1"), value: []byte("newest")},
+	}
+	for _, test := range []struct {
+		name      string
+		committed bool
+		key1      []byte
+		hasKey2   bool
+	}{
+		{"committed", true, []byte("newest"), false},
+		{"not committed", false, []byte("old"), true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			path := t.TempDir()
+			db, err := openBadger(path, KeyValParams{})
+			require.NoError(t, err)
+			require.NoError(t, db.put([]byte("key1"), []byte("old")))
+			require.NoError(t, db.put([]byte("key2"), []byte("old")))
+			// The write is interrupted after the journal is written.
+			require.NoError(t, db.writeJournal(pairs))
+			if !test.committed {
+				require.NoError(t, db.delete(badgerJournalCommitKey))
+			}
+			require.NoError(t, db.close())
+
+			kv := newTestKeyVal(t, path, Badger)
+			v, err := kv.Get([]byte("key1"))
+			require.NoError(t, err)
+			assert.Equal(t, test.key1, v)
+			has, err := kv.Has([]byte("key2"))
+			require.NoError(t, err)
+			assert.Equal(t, test.hasKey2, has)
+			assertNoJournal(t, kv.db)
+		})
+	}
+}
+
+func assertNoJournal(t *testing.T, db engine) {
+	it := db.newIterator(badgerJournalPrefix, nil)
+	defer it.Release()
+	assert.False(t, it.Next(), "journal is not removed")
+	require.NoError(t, it.Error())
+}
+
+// TestIteratorConformance compares iterators of the backends using random sequences of moves.
+// Iterators without start key are covered by nil start.
+func TestIteratorConformance(t *testing.T) {
+	keys := [][]byte{{0x00}, {0x01}, {0x01, 0x00}, {0x01, 0x01}, {0x01, 0xff}, {0x01, 0xff, 0x00}, {0x02}, {0x02, 0x01},
+		{0xff}, {0xff, 0x00}, {0xff, 0xff}}
+	prefixes := [][]byte{nil, {}, {0x01}, {0x01, 0xff}, {0x02}, {0x03}, {0xff}, {0xff, 0xff}}
+	starts := [][]byte{nil, {0x00}, {0x01, 0x01}, {0x01, 0xff, 0x01}, {0x02, 0x00}, {0xff, 0x00, 0x00}}
+	dbs := make([]*KeyVal, len(backends))
+	for i, backend := range backends {
+		dbs[i] = newTestKeyVal(t, t.TempDir(), backend)
+		for j, k := range keys {
+			require.NoError(t, dbs[i].Put(k, []byte{byte(j)}))
+		}
+		// Deleted key must not be visible.
+		require.NoError(t, dbs[i].Delete([]byte{0x02}))
+	}
+	type move struct {
+		name string
+		do   func(Iterator) bool
+	}
+	moves := []move{
+		{"Next", Iterator.Next}, {"Prev", Iterator.Prev}, {"First", Iterator.First}, {"Last", Iterator.Last},
+	}
+	rng := rand.New(rand.NewSource(42)) // #nosec: deterministic test
+	for _, prefix := range prefixes {
+		for j := range 20 {
+			start := starts[j%len(starts)]
+			seq := make([]move, 12)
+			for i := range seq {
+				seq[i] = moves[rng.Intn(len(moves))]
+			}
+			results := make([][]string, len(dbs))
+			for i, db := range dbs {
+				iter, err := db.NewKeyIteratorFrom(prefix, start)
+				require.NoError(t, err)
+				for _, m := range seq {
+					ok := m.do(iter)
+					results[i] = append(results[i], fmt.Sprintf("%s:%t:%x:%x", m.name, ok, iter.Key(), iter.Value()))
+				}
+				iter.Release()
+				require.NoError(t, iter.Error())
+			}
+			for i := 1; i < len(results); i++ {
+				require.Equal(t, results[0], results[i], "prefix %x, start %x, backend %s", prefix, start, backends[i])
+			}
+		}
+	}
+}
+
+func TestCopyDatabase(t *testing.T) {
+	for _, from := range backends {
+		for _, to := range backends {
+			t.Run(fmt.Sprintf("%s-%s", from, to), func(t *testing.T) {
+				src := filepath.Join(t.TempDir(), "src")
+				kv, err := NewKeyVal(src, testKeyValParams(from))
+				require.NoError(t, err)
+				for i := range 100 {
+					require.NoError(t, kv.Put([]byte{byte(i)}, []byte{byte(i), byte(i)}))
+				}
+				require.NoError(t, kv.Close())
+
+				dst := filepath.Join(t.TempDir(), "dst")
+				n, err := CopyDatabase(src, dst, to)
+				require.NoError(t, err)
+				assert.Equal(t, uint64(100), n)
+
+				cp := newTestKeyVal(t, dst, to)
+				for i := range 100 {
+					v, gErr := cp.Get([]byte{byte(i)})
+					require.NoError(t, gErr)
+					assert.Equal(t, []byte{byte(i), byte(i)}, v)
+				}
+				_, err = CopyDatabase(src, dst, to)
+				assert.ErrorContains(t, err, "already exists")
+			})
+		}
+	}
+}
@@ -76,6 +76,19 @@ func (a *OrderedBlocks) Add(sig proto.BlockID) bool {
 	return true
 }
 
+// Requested returns IDs of the blocks waiting to be popped in the order of request.
+func (a *OrderedBlocks) Requested() []proto.BlockID {
+	return a.requested
+}
+
+// Received checks that the block and, for light node, its snapshot are received.
+func (a *OrderedBlocks) Received(sig proto.BlockID, isLightNode bool) bool {
+	if a.blocks[sig] == nil {
+		return false
+	}
+	return !isLightNode || a.snapshots[sig] != nil
+}
+
 func (a *OrderedBlocks) RequestedCount() int {
 	return len(a.requested)
 }
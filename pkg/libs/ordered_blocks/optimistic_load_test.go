@@ -58,3 +58,18 @@ func TestOrderedBlocks_AvailableCount(t *testing.T) {
 	o.PopAll(false)
 	require.Equal(t, 0, o.ReceivedCount(false))
 }
+
+func TestOrderedBlocks_Received(t *testing.T) {
+	o := ordered_blocks.NewOrderedBlocks()
+	id := proto.NewBlockIDFromSignature(sig1)
+	o.Add(id)
+	require.False(t, o.Received(id, false))
+
+	o.SetBlock(makeBlock(sig1))
+	require.True(t, o.Received(id, false))
+	require.False(t, o.Received(id, true))
+
+	o.SetSnapshot(id, &proto.BlockSnapshot{})
+	require.True(t, o.Received(id, true))
+	require.False(t, o.Received(proto.NewBlockIDFromSignature(sig2), false))
+}
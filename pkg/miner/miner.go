@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/miner/scheduler"
 	"github.com/wavesplatform/gowaves/pkg/node/messages"
 	"github.com/wavesplatform/gowaves/pkg/node/peers"
@@ -39,7 +40,7 @@ func NewMicroblockMiner(services services.Services, features Features, reward in
 }
 
 func (a *MicroblockMiner) MineKeyBlock(
-	_ context.Context, t proto.Timestamp, k proto.KeyPair, parent proto.BlockID, baseTarget types.BaseTarget,
+	_ context.Context, t proto.Timestamp, pk crypto.PublicKey, parent proto.BlockID, baseTarget types.BaseTarget,
 	gs []byte, _ []byte,
 ) (*proto.Block, proto.MiningLimits, error) {
 	nxt := proto.NxtConsensus{
@@ -55,7 +56,7 @@ func (a *MicroblockMiner) MineKeyBlock(
 		if err != nil {
 			return nil, err
 		}
-		b, err := MineBlock(v, nxt, k, validatedFeatured, t, parent, a.reward, a.services.Scheme)
+		b, err := MineBlock(v, nxt, pk, a.services.Wallet, validatedFeatured, t, parent, a.reward, a.services.Scheme)
 		if err != nil {
 			return nil, err
 		}
@@ -85,7 +86,7 @@ func (a *MicroblockMiner) MineKeyBlock(
 		}
 		b.StateHash = &sh
 		// Resign block
-		if err = b.Sign(a.services.Scheme, k.Secret); err != nil {
+		if err = b.SignWith(a.services.Scheme, signWith(a.services.Wallet, pk)); err != nil {
 			return nil, proto.MiningLimits{}, errors.Wrap(err,
 				"failed to resign key block with filled state hash field")
 		}
@@ -143,13 +144,13 @@ func Run(ctx context.Context, a types.Miner, s Mine, internalCh chan<- messages.
 		case <-ctx.Done():
 			return
 		case v := <-s.Mine():
-			block, limits, err := a.MineKeyBlock(ctx, v.Timestamp, v.KeyPair, v.Parent, v.BaseTarget, v.GenSignature,
+			block, limits, err := a.MineKeyBlock(ctx, v.Timestamp, v.PublicKey, v.Parent, v.BaseTarget, v.GenSignature,
 				v.VRF)
 			if err != nil {
 				zap.S().Errorf("Failed to mine key block: %v", err)
 				continue
 			}
-			internalCh <- messages.NewMinedBlockInternalMessage(block, limits, v.KeyPair, v.VRF)
+			internalCh <- messages.NewMinedBlockInternalMessage(block, limits, v.PublicKey, v.VRF)
 		}
 	}
 }
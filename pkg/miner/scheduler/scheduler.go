@@ -9,6 +9,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/wavesplatform/gowaves/pkg/consensus"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 	"github.com/wavesplatform/gowaves/pkg/settings"
 	"github.com/wavesplatform/gowaves/pkg/state"
@@ -20,7 +21,7 @@ import (
 
 type Emit struct {
 	Timestamp    uint64
-	KeyPair      proto.KeyPair
+	PublicKey    crypto.PublicKey
 	GenSignature []byte
 	VRF          []byte
 	BaseTarget   types.BaseTarget
@@ -28,7 +29,7 @@ type Emit struct {
 }
 
 type Default struct {
-	seeder       seeder
+	signer       types.Signer
 	mine         chan Emit
 	cancel       []func()
 	settings     *settings.BlockchainSettings
@@ -44,7 +45,8 @@ type Default struct {
 type internal interface {
 	schedule(
 		state state.StateInfo,
-		keyPairs []proto.KeyPair,
+		signer types.Signer,
+		pks []crypto.PublicKey,
 		settings *settings.BlockchainSettings,
 		confirmedBlock *proto.Block,
 		confirmedBlockHeight uint64,
@@ -55,7 +57,8 @@ type internalImpl struct{}
 
 func (a internalImpl) schedule(
 	storage state.StateInfo,
-	keyPairs []proto.KeyPair,
+	signer types.Signer,
+	pks []crypto.PublicKey,
 	blockchainSettings *settings.BlockchainSettings,
 	confirmedBlock *proto.Block,
 	confirmedBlockHeight uint64,
@@ -65,9 +68,9 @@ func (a internalImpl) schedule(
 		return nil, errors.Wrap(err, "failed get vrfActivated")
 	}
 	if vrfActivated {
-		return a.scheduleWithVrf(storage, keyPairs, blockchainSettings, confirmedBlock, confirmedBlockHeight)
+		return a.scheduleWithVrf(storage, signer, pks, blockchainSettings, confirmedBlock, confirmedBlockHeight)
 	}
-	return a.scheduleWithoutVrf(storage, keyPairs, blockchainSettings, confirmedBlock, confirmedBlockHeight)
+	return a.scheduleWithoutVrf(storage, pks, blockchainSettings, confirmedBlock, confirmedBlockHeight)
 }
 
 func (a internalImpl) prepareDataForSchedule(
@@ -104,25 +107,23 @@ func (a internalImpl) prepareDataForSchedule(
 	return greatGrandParentTimestamp, blockV5Activated, pos, nil
 }
 
+// scheduleWithVrf schedules mining after BlockV5 activation, the generation signature is the VRF proof
+// calculated by the signer and the hit source is the VRF output extracted from the proof.
 func (a internalImpl) scheduleWithVrf(
 	storage state.StateInfo,
-	keyPairs []proto.KeyPair,
+	signer types.Signer,
+	pks []crypto.PublicKey,
 	blockchainSettings *settings.BlockchainSettings,
 	confirmedBlock *proto.Block,
 	confirmedBlockHeight uint64,
 ) ([]Emit, error) {
-	greatGrandParentTimestamp, blockV5Activated, pos, err := a.prepareDataForSchedule(storage, confirmedBlockHeight,
+	greatGrandParentTimestamp, _, pos, err := a.prepareDataForSchedule(storage, confirmedBlockHeight,
 		blockchainSettings,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	gsp := consensus.NXTGenerationSignatureProvider
-	if blockV5Activated {
-		gsp = consensus.VRFGenerationSignatureProvider
-	}
-
 	heightForHit := pos.HeightForHit(confirmedBlockHeight)
 	hitSourceAtHeight, err := storage.HitSourceAtHeight(heightForHit)
 	if err != nil {
@@ -134,33 +135,37 @@ func (a internalImpl) scheduleWithVrf(
 	)
 
 	var out []Emit
-	for _, keyPair := range keyPairs {
-		sk := keyPair.Secret
-		genSig, err := gsp.GenerationSignature(sk, hitSourceAtHeight)
+	for _, pk := range pks {
+		genSig, err := signer.SignVRF(pk, hitSourceAtHeight)
 		if err != nil {
 			zap.S().Errorf("Scheduler: Failed to schedule mining, can't get generation signature at height %d: %v",
 				heightForHit, err,
 			)
 			continue
 		}
-		source, err := gsp.HitSource(sk, hitSourceAtHeight)
+		ok, source, err := consensus.VRFGenerationSignatureProvider.VerifyGenerationSignature(pk, hitSourceAtHeight,
+			genSig,
+		)
 		if err != nil {
 			zap.S().Errorf("Scheduler: Failed to schedule mining, failed to get hit source at height %d: %v",
 				heightForHit, err,
 			)
 			continue
 		}
-		var vrf []byte
-		if blockV5Activated {
-			vrf = source
+		if !ok {
+			zap.S().Errorf("Scheduler: Failed to schedule mining, signer returned invalid VRF proof for PK %q",
+				pk.String(),
+			)
+			continue
 		}
+		vrf := source
 		hit, err := consensus.GenHit(source)
 		if err != nil {
 			zap.S().Errorf("Scheduler: Failed to schedule mining, failed to generate hit from source: %v", err)
 			continue
 		}
 
-		addr, err := keyPair.Addr(blockchainSettings.AddressSchemeCharacter)
+		addr, err := proto.NewAddressFromPublicKey(blockchainSettings.AddressSchemeCharacter, pk)
 		if err != nil {
 			zap.S().Errorf("Scheduler: Failed to schedule mining, failed to create address from PK: %v", err)
 			continue
@@ -197,7 +202,7 @@ func (a internalImpl) scheduleWithVrf(
 			time.UnixMilli(int64(confirmedBlock.Timestamp+delay)).Format("2006-01-02 15:04:05.000 MST"))
 		out = append(out, Emit{
 			Timestamp:    confirmedBlock.Timestamp + delay,
-			KeyPair:      keyPair,
+			PublicKey:    pk,
 			GenSignature: genSig,
 			VRF:          vrf,
 			BaseTarget:   baseTarget,
@@ -209,7 +214,7 @@ func (a internalImpl) scheduleWithVrf(
 
 func (a internalImpl) scheduleWithoutVrf(
 	storage state.StateInfo,
-	keyPairs []proto.KeyPair,
+	pks []crypto.PublicKey,
 	blockchainSettings *settings.BlockchainSettings,
 	confirmedBlock *proto.Block,
 	confirmedBlockHeight uint64,
@@ -237,8 +242,7 @@ func (a internalImpl) scheduleWithoutVrf(
 		confirmedBlock.BaseTarget,
 	)
 	var out []Emit
-	for _, keyPair := range keyPairs {
-		pk := keyPair.Public
+	for _, pk := range pks {
 		genSigBlock := confirmedBlock.BlockHeader
 		genSig, err := gsp.GenerationSignature(pk, genSigBlock.GenSignature)
 		if err != nil {
@@ -299,7 +303,7 @@ func (a internalImpl) scheduleWithoutVrf(
 			ts, common.UnixMillisToTime(int64(ts)).String()) // #nosec: used only for logging
 		out = append(out, Emit{
 			Timestamp:    ts,
-			KeyPair:      keyPair,
+			PublicKey:    pk,
 			GenSignature: genSig,
 			VRF:          nil, // because without VRF
 			BaseTarget:   baseTarget,
@@ -309,13 +313,9 @@ func (a internalImpl) scheduleWithoutVrf(
 	return out, nil
 }
 
-type seeder interface {
-	AccountSeeds() [][]byte
-}
-
 func NewScheduler(
 	state state.State,
-	seeder seeder,
+	signer types.Signer,
 	settings *settings.BlockchainSettings,
 	tm types.Time,
 	consensus types.MinerConsensus,
@@ -323,16 +323,16 @@ func NewScheduler(
 	if minerDelay <= 0 {
 		return nil, errors.New("minerDelay must be positive")
 	}
-	return newScheduler(internalImpl{}, state, seeder, settings, tm, consensus, minerDelay), nil
+	return newScheduler(internalImpl{}, state, signer, settings, tm, consensus, minerDelay), nil
 }
 
-func newScheduler(internal internal, state state.State, seeder seeder, settings *settings.BlockchainSettings,
+func newScheduler(internal internal, state state.State, signer types.Signer, settings *settings.BlockchainSettings,
 	tm types.Time, consensus types.MinerConsensus, minerDelay time.Duration) *Default {
-	if seeder == nil {
-		seeder = wallet.NewWallet()
+	if signer == nil {
+		signer = wallet.NewEmbeddedWallet(nil, wallet.NewWallet(), 0)
 	}
 	return &Default{
-		seeder:       seeder,
+		signer:       signer,
 		mine:         make(chan Emit, 1),
 		settings:     settings,
 		internal:     internal,
@@ -349,12 +349,17 @@ func (a *Default) Mine() chan Emit {
 }
 
 func (a *Default) Reschedule() {
-	if len(a.seeder.AccountSeeds()) == 0 {
-		zap.S().Debug("Scheduler: Mining is not possible because no seeds registered")
+	pks, err := a.signer.PublicKeys()
+	if err != nil {
+		zap.S().Errorf("Scheduler: Failed to get public keys from signer: %v", err)
+		return
+	}
+	if len(pks) == 0 {
+		zap.S().Debug("Scheduler: Mining is not possible because no accounts registered")
 		return
 	}
 
-	zap.S().Debugf("Scheduler: Trying to mine with %d seeds", len(a.seeder.AccountSeeds()))
+	zap.S().Debugf("Scheduler: Trying to mine with %d accounts", len(pks))
 
 	if !a.consensus.IsMiningAllowed() {
 		zap.S().Debug("Scheduler: Mining is not allowed because of lack of connected nodes")
@@ -383,13 +388,10 @@ func (a *Default) Reschedule() {
 		return
 	}
 
-	a.reschedule(block, h)
+	a.reschedule(pks, block, h)
 }
 
-func (a *Default) reschedule(confirmedBlock *proto.Block, confirmedBlockHeight uint64) {
-	if len(a.seeder.AccountSeeds()) == 0 {
-		return
-	}
+func (a *Default) reschedule(pks []crypto.PublicKey, confirmedBlock *proto.Block, confirmedBlockHeight uint64) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -400,14 +402,8 @@ func (a *Default) reschedule(confirmedBlock *proto.Block, confirmedBlockHeight u
 	a.cancel = nil
 	a.emits = nil
 
-	keyPairs, err := makeKeyPairs(a.seeder.AccountSeeds())
-	if err != nil {
-		zap.S().Errorf("Scheduler: Failed to make key pairs from seeds: %v", err)
-		return
-	}
-
 	rs, err := a.storage.MapR(func(info state.StateInfo) (i interface{}, err error) {
-		return a.internal.schedule(info, keyPairs, a.settings, confirmedBlock, confirmedBlockHeight)
+		return a.internal.schedule(info, a.signer, pks, a.settings, confirmedBlock, confirmedBlockHeight)
 	})
 	if err != nil {
 		zap.S().Errorf("Scheduler: Failed to schedule: %v", err)
@@ -445,15 +441,3 @@ func (a *Default) Emits() []Emit {
 	defer a.mu.Unlock()
 	return a.emits
 }
-
-func makeKeyPairs(seeds [][]byte) ([]proto.KeyPair, error) {
-	var out []proto.KeyPair
-	for _, bts := range seeds {
-		kp, err := proto.NewKeyPair(bts)
-		if err != nil {
-			return nil, err
-		}
-		out = append(out, kp)
-	}
-	return out, nil
-}
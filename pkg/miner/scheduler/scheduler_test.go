@@ -5,9 +5,11 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 	"github.com/wavesplatform/gowaves/pkg/settings"
 	"github.com/wavesplatform/gowaves/pkg/state"
+	"github.com/wavesplatform/gowaves/pkg/types"
 )
 
 type mockInternal struct {
@@ -15,7 +17,8 @@ type mockInternal struct {
 
 func (a mockInternal) schedule(
 	state.StateInfo,
-	[]proto.KeyPair,
+	types.Signer,
+	[]crypto.PublicKey,
 	*settings.BlockchainSettings,
 	*proto.Block,
 	uint64,
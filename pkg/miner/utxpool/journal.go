@@ -0,0 +1,284 @@
+package utxpool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+)
+
+const (
+	journalAddRecord    byte = 1
+	journalRemoveRecord byte = 2
+
+	journalHeaderSize = 1 + crypto.DigestSize // Type of record and transaction ID
+	journalCRCSize    = 4
+	journalMaxTxSize  = 10 * 1024 * 1024 // Greater length means the record is corrupted
+	// Journal is compacted when it has more obsolete records than this number plus the number of transactions in pool.
+	journalCompactionThreshold = 1000
+)
+
+// journalEntry is a transaction that is stored in the journal.
+type journalEntry struct {
+	id crypto.Digest
+	b  []byte
+}
+
+// journal is the write-ahead log of the pool. Record is written to the file before the pool is changed,
+// so the pool can be restored after restart of the node. Records are:
+//
+//	add:    0x01 | transaction ID (32 bytes) | length of transaction bytes (4 bytes) | transaction bytes | CRC32
+//	remove: 0x02 | transaction ID (32 bytes) | CRC32
+//
+// Add records are synced to disk, remove records are not, because a lost removal only brings back the transaction
+// which is validated again on restoration. Corrupted records are skipped on opening, reading continues from
+// the next valid record. A torn record at the end of the file is truncated.
+//
+// Removal of popped transaction is postponed, because popped transactions are usually returned to the pool,
+// like it's done by bulk validation at every height. Returned transaction keeps its add record and nothing is
+// written. Removals of transactions that are not returned are dropped by compaction or written on closing.
+type journal struct {
+	path    string
+	f       *os.File
+	records int
+	popped  map[crypto.Digest]struct{} // Transactions taken from the pool, their removals are not written yet
+}
+
+// openJournal opens or creates the journal file and returns the transactions that are still in pool
+// in the order of their addition.
+func openJournal(path string) (*journal, []journalEntry, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to open journal")
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, errors.Wrapf(err, "failed to read journal '%s'", path)
+	}
+	entries, records, size, skipped := readJournal(data)
+	if skipped > 0 {
+		zap.S().Warnf("%d bytes of corrupted records skipped in journal '%s'", skipped, path)
+	}
+	// Truncate the torn record if any and continue writing at the end of valid records.
+	if err := f.Truncate(size); err != nil {
+		_ = f.Close()
+		return nil, nil, errors.Wrap(err, "failed to truncate journal")
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, nil, errors.Wrap(err, "failed to seek journal")
+	}
+	return &journal{path: path, f: f, records: records, popped: make(map[crypto.Digest]struct{})}, entries, nil
+}
+
+// readJournal returns the transactions of the journal, the number of valid records, the size of the journal
+// without the invalid tail and the number of skipped bytes of corrupted records in the middle of the journal.
+// After a corrupted record reading is resynchronized at the next offset where a valid record starts.
+func readJournal(data []byte) ([]journalEntry, int, int64, int) {
+	var (
+		entries  = make([]journalEntry, 0)
+		index    = make(map[crypto.Digest]int)
+		records  int
+		skipped  int
+		badStart = -1 // Start of the current run of invalid bytes
+	)
+	for off := 0; off < len(data); {
+		rec, err := parseJournalRecord(data[off:])
+		if err != nil {
+			if badStart < 0 {
+				badStart = off
+			}
+			off++
+			continue
+		}
+		if badStart >= 0 {
+			skipped += off - badStart
+			badStart = -1
+		}
+		off += len(rec)
+		records++
+		var id crypto.Digest
+		copy(id[:], rec[1:journalHeaderSize])
+		switch rec[0] {
+		case journalAddRecord:
+			if _, ok := index[id]; ok {
+				continue
+			}
+			b := rec[journalHeaderSize+4 : len(rec)-journalCRCSize]
+			index[id] = len(entries)
+			entries = append(entries, journalEntry{id: id, b: b})
+		case journalRemoveRecord:
+			if i, ok := index[id]; ok {
+				entries[i].b = nil
+				delete(index, id)
+			}
+		}
+	}
+	size := int64(len(data))
+	if badStart >= 0 { // No valid records after the invalid bytes, it's a torn tail.
+		size = int64(badStart)
+	}
+	res := make([]journalEntry, 0, len(index))
+	for _, e := range entries {
+		if e.b != nil {
+			res = append(res, e)
+		}
+	}
+	return res, records, size, skipped
+}
+
+var errCorruptedRecord = errors.New("corrupted journal record")
+
+// parseJournalRecord returns the record at the beginning of data. The returned slice references data.
+func parseJournalRecord(data []byte) ([]byte, error) {
+	if len(data) < journalHeaderSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+	var n int
+	switch data[0] {
+	case journalAddRecord:
+		if len(data) < journalHeaderSize+4 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		l := binary.BigEndian.Uint32(data[journalHeaderSize:])
+		if l > journalMaxTxSize {
+			return nil, errCorruptedRecord
+		}
+		n = journalHeaderSize + 4 + int(l) + journalCRCSize
+	case journalRemoveRecord:
+		n = journalHeaderSize + journalCRCSize
+	default:
+		return nil, errCorruptedRecord
+	}
+	if len(data) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	rec := data[:n:n]
+	crc := binary.BigEndian.Uint32(rec[n-journalCRCSize:])
+	if crc32.ChecksumIEEE(rec[:n-journalCRCSize]) != crc {
+		return nil, errCorruptedRecord
+	}
+	return rec, nil
+}
+
+func appendJournalRecord(buf []byte, t byte, id crypto.Digest, b []byte) []byte {
+	start := len(buf)
+	buf = append(buf, t)
+	buf = append(buf, id[:]...)
+	if t == journalAddRecord {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(b)))
+		buf = append(buf, b...)
+	}
+	return binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf[start:]))
+}
+
+func (j *journal) add(id crypto.Digest, b []byte) error {
+	if _, ok := j.popped[id]; ok { // Returned transaction, its add record is still in effect.
+		delete(j.popped, id)
+		return nil
+	}
+	if _, err := j.f.Write(appendJournalRecord(nil, journalAddRecord, id, b)); err != nil {
+		return err
+	}
+	j.records++
+	return j.f.Sync()
+}
+
+func (j *journal) remove(id crypto.Digest) error {
+	delete(j.popped, id)
+	if _, err := j.f.Write(appendJournalRecord(nil, journalRemoveRecord, id, nil)); err != nil {
+		return err
+	}
+	j.records++
+	return nil
+}
+
+// pop postpones the removal of the transaction taken from the pool.
+func (j *journal) pop(id crypto.Digest) {
+	j.popped[id] = struct{}{}
+}
+
+func (j *journal) needsCompaction(live int) bool {
+	return j.records > 2*live+journalCompactionThreshold
+}
+
+// compact replaces the journal with the new one that contains only the given transactions.
+func (j *journal) compact(entries []journalEntry) error {
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to create journal")
+	}
+	w := bufio.NewWriter(f)
+	var buf []byte
+	for _, e := range entries {
+		buf = appendJournalRecord(buf[:0], journalAddRecord, e.id, e.b)
+		if _, err := w.Write(buf); err != nil {
+			_ = f.Close()
+			return errors.Wrap(err, "failed to write journal")
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "failed to write journal")
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "failed to sync journal")
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "failed to replace journal")
+	}
+	if err := syncDir(filepath.Dir(j.path)); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "failed to sync journal directory")
+	}
+	old := j.f
+	j.f = f
+	j.records = len(entries)
+	clear(j.popped)
+	if err := old.Close(); err != nil {
+		return errors.Wrap(err, "failed to close old journal")
+	}
+	return nil
+}
+
+// syncDir makes the rename of the file in the directory durable.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" { // Directories can't be synced on Windows, rename is durable there.
+		return nil
+	}
+	d, err := os.Open(filepath.Clean(dir))
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		_ = d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+func (j *journal) close() error {
+	for id := range j.popped {
+		if err := j.remove(id); err != nil {
+			_ = j.f.Close()
+			return err
+		}
+	}
+	if err := j.f.Sync(); err != nil {
+		_ = j.f.Close()
+		return err
+	}
+	return j.f.Close()
+}
@@ -0,0 +1,175 @@
+package utxpool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+	"github.com/wavesplatform/gowaves/pkg/util/byte_helpers"
+)
+
+type validatorFunc func(t proto.Transaction) error
+
+func (f validatorFunc) Validate(t proto.Transaction) error {
+	return f(t)
+}
+
+func TestJournalReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utx.journal")
+	j, entries, err := openJournal(path)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	id1, id2, id3 := crypto.Digest{1}, crypto.Digest{2}, crypto.Digest{3}
+	require.NoError(t, j.add(id1, []byte{1, 1}))
+	require.NoError(t, j.add(id2, []byte{2, 2}))
+	require.NoError(t, j.remove(id1))
+	require.NoError(t, j.add(id3, []byte{3, 3}))
+	require.NoError(t, j.close())
+
+	// Torn record at the end of journal is skipped and truncated.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	torn := appendJournalRecord(nil, journalAddRecord, crypto.Digest{4}, []byte{4, 4})
+	_, err = f.Write(torn[:len(torn)-2])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	j, entries, err = openJournal(path)
+	require.NoError(t, err)
+	assert.Equal(t, []journalEntry{{id: id2, b: []byte{2, 2}}, {id: id3, b: []byte{3, 3}}}, entries)
+	assert.Equal(t, 4, j.records)
+	require.NoError(t, j.remove(id2))
+	require.NoError(t, j.close())
+
+	j, entries, err = openJournal(path)
+	require.NoError(t, err)
+	assert.Equal(t, []journalEntry{{id: id3, b: []byte{3, 3}}}, entries)
+	require.NoError(t, j.compact(entries))
+	assert.Equal(t, 1, j.records)
+	require.NoError(t, j.close())
+
+	j, entries, err = openJournal(path)
+	require.NoError(t, err)
+	assert.Equal(t, []journalEntry{{id: id3, b: []byte{3, 3}}}, entries)
+	require.NoError(t, j.close())
+}
+
+func TestJournalPop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utx.journal")
+	j, _, err := openJournal(path)
+	require.NoError(t, err)
+
+	id1, id2 := crypto.Digest{1}, crypto.Digest{2}
+	require.NoError(t, j.add(id1, []byte{1, 1}))
+	require.NoError(t, j.add(id2, []byte{2, 2}))
+	info, err := j.f.Stat()
+	require.NoError(t, err)
+
+	// Popped and returned transaction is not written again.
+	j.pop(id1)
+	j.pop(id2)
+	require.NoError(t, j.add(id1, []byte{1, 1}))
+	assert.Equal(t, 2, j.records)
+	returned, err := j.f.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), returned.Size())
+
+	// Removal of not returned transaction is written on closing.
+	require.NoError(t, j.close())
+	j, entries, err := openJournal(path)
+	require.NoError(t, err)
+	assert.Equal(t, []journalEntry{{id: id1, b: []byte{1, 1}}}, entries)
+	assert.Equal(t, 3, j.records)
+	require.NoError(t, j.close())
+}
+
+func TestJournalCorruptedRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utx.journal")
+	var data []byte
+	data = appendJournalRecord(data, journalAddRecord, crypto.Digest{1}, []byte{1, 1})
+	corrupted := len(data)
+	data = appendJournalRecord(data, journalAddRecord, crypto.Digest{2}, []byte{2, 2})
+	data[corrupted+journalHeaderSize+4] ^= 0xff // Damage transaction bytes of the second record.
+	data = appendJournalRecord(data, journalAddRecord, crypto.Digest{3}, []byte{3, 3})
+	data = appendJournalRecord(data, journalRemoveRecord, crypto.Digest{1}, nil)
+	valid := len(data)
+	data = append(data, journalAddRecord, 0xff, 0xff) // Garbage at the end.
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	// Records after the corrupted one are read, only the invalid tail is truncated.
+	j, entries, err := openJournal(path)
+	require.NoError(t, err)
+	assert.Equal(t, []journalEntry{{id: crypto.Digest{3}, b: []byte{3, 3}}}, entries)
+	assert.Equal(t, 3, j.records)
+	require.NoError(t, j.add(crypto.Digest{4}, []byte{4, 4}))
+	require.NoError(t, j.close())
+	stored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, data[:valid], stored[:valid])
+
+	j, entries, err = openJournal(path)
+	require.NoError(t, err)
+	assert.Equal(t, []journalEntry{{id: crypto.Digest{3}, b: []byte{3, 3}}, {id: crypto.Digest{4}, b: []byte{4, 4}}},
+		entries)
+	require.NoError(t, j.close())
+}
+
+func TestUtxImpl_JournalRestoration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utx.journal")
+	cfg := settings.MustMainNetSettings()
+	burn, transfer := byte_helpers.BurnWithSig, byte_helpers.TransferWithSig
+
+	a, err := NewWithJournal(10000, Limits{}, NoOpValidator{}, cfg, path)
+	require.NoError(t, err)
+	require.NoError(t, a.AddWithBytes(burn.Transaction, burn.TransactionBytes))
+	require.NoError(t, a.AddWithBytes(transfer.Transaction, transfer.TransactionBytes))
+	require.NoError(t, a.Close())
+
+	// Both transactions are restored.
+	a, err = NewWithJournal(10000, Limits{}, NoOpValidator{}, cfg, path)
+	require.NoError(t, err)
+	require.Equal(t, 2, a.Len())
+	require.True(t, a.ExistsByID(burn.Transaction.ID.Bytes()))
+	require.True(t, a.ExistsByID(transfer.Transaction.ID.Bytes()))
+	require.NotNil(t, a.Pop())
+	require.Equal(t, 1, a.Len())
+	require.NoError(t, a.Close())
+
+	// Popped transaction is not restored, invalid transaction is dropped.
+	invalid := validatorFunc(func(proto.Transaction) error { return errors.New("invalid") })
+	a, err = NewWithJournal(10000, Limits{}, invalid, cfg, path)
+	require.NoError(t, err)
+	require.Equal(t, 0, a.Len())
+	require.NoError(t, a.Close())
+
+	a, err = NewWithJournal(10000, Limits{}, NoOpValidator{}, cfg, path)
+	require.NoError(t, err)
+	require.Equal(t, 0, a.Len())
+	require.NoError(t, a.Close())
+}
+
+func TestUtxImpl_JournalRestorationWithOutdatedState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utx.journal")
+	cfg := settings.MustMainNetSettings()
+	burn := byte_helpers.BurnWithSig
+
+	a, err := NewWithJournal(10000, Limits{}, NoOpValidator{}, cfg, path)
+	require.NoError(t, err)
+	require.NoError(t, a.AddWithBytes(burn.Transaction, burn.TransactionBytes))
+	require.NoError(t, a.Close())
+
+	// Transaction is kept to be checked by cleaner after synchronization.
+	outdated := validatorFunc(func(proto.Transaction) error { return errStateOutdated })
+	a, err = NewWithJournal(10000, Limits{}, outdated, cfg, path)
+	require.NoError(t, err)
+	require.Equal(t, 1, a.Len())
+	require.NoError(t, a.Close())
+}
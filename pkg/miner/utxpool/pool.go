@@ -1,8 +1,10 @@
 package utxpool
 
 import (
+	"cmp"
 	"container/heap"
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/mr-tron/base58"
@@ -11,23 +13,41 @@ import (
 	"github.com/wavesplatform/gowaves/pkg/proto"
 	"github.com/wavesplatform/gowaves/pkg/settings"
 	"github.com/wavesplatform/gowaves/pkg/types"
+	"go.uber.org/zap"
 )
 
-type transactionsHeap []*types.TransactionWithBytes
+// poolEntry is a transaction in pool with its positions in the pool's heaps.
+type poolEntry struct {
+	tb         *types.TransactionWithBytes
+	id         crypto.Digest
+	sender     proto.WavesAddress
+	fpb        uint64
+	index      int // Position in transactionsHeap
+	evictIndex int // Position in evictionHeap, -1 for transactions of whitelisted senders
+}
+
+func (e *poolEntry) size() uint64 {
+	return uint64(len(e.tb.B))
+}
+
+// transactionsHeap orders transactions from the most to the least paying per byte.
+type transactionsHeap []*poolEntry
 
 func (a transactionsHeap) Len() int { return len(a) }
 
 func (a transactionsHeap) Less(i, j int) bool {
-	// skip division by zero, check it when we add transaction
-	return a[i].T.GetFee()/uint64(len(a[i].B)) > a[j].T.GetFee()/uint64(len(a[j].B))
+	return a[i].fpb > a[j].fpb
 }
 
 func (a transactionsHeap) Swap(i, j int) {
 	a[i], a[j] = a[j], a[i]
+	a[i].index = i
+	a[j].index = j
 }
 
 func (a *transactionsHeap) Push(x interface{}) {
-	item := x.(*types.TransactionWithBytes)
+	item := x.(*poolEntry)
+	item.index = len(*a)
 	*a = append(*a, item)
 }
 
@@ -39,23 +59,196 @@ func (a *transactionsHeap) Pop() interface{} {
 	return item
 }
 
+// evictionHeap orders transactions that can be evicted from the least to the most paying per byte.
+type evictionHeap []*poolEntry
+
+func (a evictionHeap) Len() int { return len(a) }
+
+func (a evictionHeap) Less(i, j int) bool {
+	return a[i].fpb < a[j].fpb
+}
+
+func (a evictionHeap) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+	a[i].evictIndex = i
+	a[j].evictIndex = j
+}
+
+func (a *evictionHeap) Push(x interface{}) {
+	item := x.(*poolEntry)
+	item.evictIndex = len(*a)
+	*a = append(*a, item)
+}
+
+func (a *evictionHeap) Pop() interface{} {
+	old := *a
+	n := len(old)
+	item := old[n-1]
+	item.evictIndex = -1
+	*a = old[0 : n-1]
+	return item
+}
+
+// ascend calls f for the transactions in order of their fee per byte until f returns false.
+// The heap is not modified, visiting of k cheapest transactions takes O(k*log(k)).
+func (a evictionHeap) ascend(f func(e *poolEntry) bool) {
+	if len(a) == 0 {
+		return
+	}
+	frontier := &heapFrontier{entries: a, positions: []int{0}}
+	for frontier.Len() > 0 {
+		i := heap.Pop(frontier).(int)
+		if !f(a[i]) {
+			return
+		}
+		for _, c := range [...]int{2*i + 1, 2*i + 2} {
+			if c < len(a) {
+				heap.Push(frontier, c)
+			}
+		}
+	}
+}
+
+// heapFrontier holds positions of evictionHeap that are not visited yet but whose parents are.
+type heapFrontier struct {
+	entries   evictionHeap
+	positions []int
+}
+
+func (f *heapFrontier) Len() int { return len(f.positions) }
+
+func (f *heapFrontier) Less(i, j int) bool {
+	return f.entries[f.positions[i]].fpb < f.entries[f.positions[j]].fpb
+}
+
+func (f *heapFrontier) Swap(i, j int) {
+	f.positions[i], f.positions[j] = f.positions[j], f.positions[i]
+}
+
+func (f *heapFrontier) Push(x interface{}) {
+	f.positions = append(f.positions, x.(int))
+}
+
+func (f *heapFrontier) Pop() interface{} {
+	n := len(f.positions)
+	item := f.positions[n-1]
+	f.positions = f.positions[0 : n-1]
+	return item
+}
+
+func feePerByte(tb *types.TransactionWithBytes) uint64 {
+	// skip division by zero, check it when we add transaction
+	return tb.T.GetFee() / uint64(len(tb.B))
+}
+
+// Limits restricts the share of the pool that can be taken by a single sender. Zero value of a limit means
+// that it is not applied. Transaction over the limits replaces the pending transactions of its sender that pay
+// less per byte. Transactions of whitelisted senders are not limited and never evicted from the pool.
+type Limits struct {
+	MaxSenderTransactions int
+	MaxSenderBytes        uint64
+	Whitelist             []proto.WavesAddress
+}
+
+type senderUsage struct {
+	transactions int
+	bytes        uint64
+	entries      []*poolEntry // Transactions of not whitelisted sender, they can be replaced by sender
+}
+
 type UtxImpl struct {
-	mu             sync.Mutex
-	transactions   transactionsHeap
-	transactionIds map[crypto.Digest]struct{}
-	sizeLimit      uint64 // max transaction size in bytes
-	curSize        uint64
-	validator      Validator
-	settings       *settings.BlockchainSettings
+	mu           sync.Mutex
+	transactions transactionsHeap
+	evictable    evictionHeap                 // Transactions of not whitelisted senders
+	entries      map[crypto.Digest]*poolEntry // Transactions in pool by IDs
+	senders      map[proto.WavesAddress]senderUsage
+	sizeLimit    uint64 // max transaction size in bytes
+	curSize      uint64
+	limits       Limits
+	whitelist    map[proto.WavesAddress]struct{}
+	validator    Validator
+	settings     *settings.BlockchainSettings
+	journal      *journal // Optional write-ahead journal, the pool is not persisted if nil
 }
 
 func New(sizeLimit uint64, validator Validator, settings *settings.BlockchainSettings) *UtxImpl {
+	return NewWithLimits(sizeLimit, Limits{}, validator, settings)
+}
+
+// NewWithLimits creates the pool with per-sender limits and the whitelist of priority senders.
+func NewWithLimits(
+	sizeLimit uint64, limits Limits, validator Validator, settings *settings.BlockchainSettings,
+) *UtxImpl {
+	whitelist := make(map[proto.WavesAddress]struct{}, len(limits.Whitelist))
+	for _, addr := range limits.Whitelist {
+		whitelist[addr] = struct{}{}
+	}
 	return &UtxImpl{
-		transactionIds: make(map[crypto.Digest]struct{}),
-		sizeLimit:      sizeLimit,
-		validator:      validator,
-		settings:       settings,
+		entries:   make(map[crypto.Digest]*poolEntry),
+		senders:   make(map[proto.WavesAddress]senderUsage),
+		sizeLimit: sizeLimit,
+		limits:    limits,
+		whitelist: whitelist,
+		validator: validator,
+		settings:  settings,
+	}
+}
+
+// NewWithJournal creates the pool that persists its transactions to the journal file at the given path.
+// Transactions left in the journal by previous run are validated again and the invalid ones are dropped.
+// Transactions are kept without validation if the state is outdated, they will be checked by Cleaner later.
+func NewWithJournal(
+	sizeLimit uint64, limits Limits, validator Validator, settings *settings.BlockchainSettings, path string,
+) (*UtxImpl, error) {
+	j, entries, err := openJournal(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open UTX journal")
+	}
+	a := NewWithLimits(sizeLimit, limits, validator, settings)
+	validate := func(t proto.Transaction) error {
+		if vErr := validator.Validate(t); vErr != nil && !errors.Is(vErr, errStateOutdated) {
+			return vErr
+		}
+		return nil
 	}
+	for _, e := range entries {
+		t, pErr := proto.BytesToTransaction(e.b, settings.AddressSchemeCharacter)
+		if pErr != nil {
+			zap.S().Debugf("Failed to restore transaction '%s' to UTX: %v", e.id.String(), pErr)
+			continue
+		}
+		if aErr := a.add(t, e.b, validate); aErr != nil {
+			zap.S().Debugf("Transaction '%s' dropped from UTX: %v", e.id.String(), aErr)
+		}
+	}
+	// Journal is rewritten to forget the dropped transactions.
+	if cErr := j.compact(a.journalEntries()); cErr != nil {
+		_ = j.close()
+		return nil, errors.Wrap(cErr, "failed to compact UTX journal")
+	}
+	a.journal = j
+	zap.S().Infof("%d of %d transactions restored to UTX from journal", len(a.transactions), len(entries))
+	return a, nil
+}
+
+// Close closes the journal of the pool if any.
+func (a *UtxImpl) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.journal == nil {
+		return nil
+	}
+	err := a.journal.close()
+	a.journal = nil
+	return err
+}
+
+func (a *UtxImpl) journalEntries() []journalEntry {
+	entries := make([]journalEntry, len(a.transactions))
+	for i, e := range a.transactions {
+		entries[i] = journalEntry{id: e.id, b: e.tb.B}
+	}
+	return entries
 }
 
 func (a *UtxImpl) AllTransactions() []*types.TransactionWithBytes {
@@ -63,7 +256,9 @@ func (a *UtxImpl) AllTransactions() []*types.TransactionWithBytes {
 	defer a.mu.Unlock()
 
 	res := make([]*types.TransactionWithBytes, len(a.transactions))
-	copy(res, a.transactions)
+	for i, e := range a.transactions {
+		res[i] = e.tb
+	}
 	return res
 }
 
@@ -98,13 +293,13 @@ func (a *UtxImpl) AddWithBytes(t proto.Transaction, b []byte) error {
 }
 
 func (a *UtxImpl) addWithBytes(t proto.Transaction, b []byte) error {
+	return a.add(t, b, a.validator.Validate)
+}
+
+func (a *UtxImpl) add(t proto.Transaction, b []byte, validate func(proto.Transaction) error) error {
 	if len(b) == 0 {
 		return errors.New("transaction with empty bytes")
 	}
-	// exceed limit
-	if a.curSize+uint64(len(b)) > a.sizeLimit {
-		return errors.Errorf("size overflow, curSize: %d, limit: %d", a.curSize, a.sizeLimit)
-	}
 	if err := t.GenerateID(a.settings.AddressSchemeCharacter); err != nil {
 		return errors.Errorf("failed to generate ID: %v", err)
 	}
@@ -112,24 +307,181 @@ func (a *UtxImpl) addWithBytes(t proto.Transaction, b []byte) error {
 	if err != nil {
 		return err
 	}
-	if a.exists(t) {
-		return proto.NewInfoMsg(errors.Errorf("transaction with id %s exists", base58.Encode(tID)))
+	id := makeDigest(tID, nil)
+	if _, ok := a.entries[id]; ok {
+		return proto.NewInfoMsg(newRejectionError(AlreadyInPool, "transaction with id %s exists", base58.Encode(tID)))
 	}
-	err = a.validator.Validate(t)
+	sender, err := a.sender(t)
 	if err != nil {
-		return err
+		return errors.Wrap(err, "failed to get sender of transaction")
 	}
 	tb := &types.TransactionWithBytes{
 		T: t,
 		B: b,
 	}
-	heap.Push(&a.transactions, tb)
-	id := makeDigest(t.GetID(a.settings.AddressSchemeCharacter))
-	a.transactionIds[id] = struct{}{}
-	a.curSize += uint64(len(b))
+	e := &poolEntry{tb: tb, id: id, sender: sender, fpb: feePerByte(tb), evictIndex: -1}
+	_, whitelisted := a.whitelist[sender]
+	var replaced []*poolEntry
+	if !whitelisted {
+		replaced, err = a.replacementCandidates(e)
+		if err != nil {
+			return err
+		}
+	}
+	evicted, err := a.evictionCandidates(e, whitelisted, replaced)
+	if err != nil {
+		return err
+	}
+	err = validate(t)
+	if err != nil {
+		return err
+	}
+	if a.journal != nil {
+		if jErr := a.journal.add(id, b); jErr != nil {
+			return errors.Wrap(jErr, "failed to write UTX journal")
+		}
+	}
+	for _, r := range replaced {
+		a.evict(r)
+		zap.S().Debugf("Transaction '%s' replaced in UTX by '%s'", r.id.String(), id.String())
+	}
+	for _, v := range evicted {
+		a.evict(v)
+		zap.S().Debugf("Transaction '%s' evicted from UTX", v.id.String())
+	}
+	heap.Push(&a.transactions, e)
+	a.entries[id] = e
+	u := a.senders[sender]
+	u.transactions++
+	u.bytes += e.size()
+	if !whitelisted {
+		heap.Push(&a.evictable, e)
+		u.entries = append(u.entries, e)
+	}
+	a.senders[sender] = u
+	a.curSize += e.size()
+	a.compactJournal()
+	return nil
+}
+
+func (a *UtxImpl) sender(t proto.Transaction) (proto.WavesAddress, error) {
+	addr, err := t.GetSender(a.settings.AddressSchemeCharacter)
+	if err != nil {
+		return proto.WavesAddress{}, err
+	}
+	return addr.ToWavesAddress(a.settings.AddressSchemeCharacter)
+}
+
+func (a *UtxImpl) checkSenderLimits(sender proto.WavesAddress, u senderUsage, size uint64) error {
+	if l := a.limits.MaxSenderTransactions; l > 0 && u.transactions >= l {
+		return newRejectionError(SenderTxLimit, "sender %s already has %d transactions in pool, limit: %d",
+			sender.String(), u.transactions, l)
+	}
+	if l := a.limits.MaxSenderBytes; l > 0 && u.bytes+size > l {
+		return newRejectionError(SenderBytesLimit,
+			"transactions of sender %s take %d bytes in pool, transaction size: %d, limit: %d",
+			sender.String(), u.bytes, size, l)
+	}
 	return nil
 }
 
+// replacementCandidates returns transactions of the same sender that have to be replaced by the new transaction
+// to keep the sender within the limits. Transaction replaces the pending ones of its sender that pay less per
+// byte, starting from the cheapest ones, otherwise it is rejected by the sender's limit.
+func (a *UtxImpl) replacementCandidates(e *poolEntry) ([]*poolEntry, error) {
+	u := a.senders[e.sender]
+	lErr := a.checkSenderLimits(e.sender, u, e.size())
+	if lErr == nil {
+		return nil, nil
+	}
+	pending := slices.Clone(u.entries)
+	slices.SortStableFunc(pending, func(x, y *poolEntry) int {
+		return cmp.Compare(x.fpb, y.fpb)
+	})
+	for i, p := range pending {
+		if p.fpb >= e.fpb {
+			break
+		}
+		u.transactions--
+		u.bytes -= p.size()
+		if a.checkSenderLimits(e.sender, u, e.size()) == nil {
+			return pending[:i+1], nil
+		}
+	}
+	return nil, lErr
+}
+
+// evictionCandidates returns transactions that have to be evicted to make room for a new transaction
+// in addition to the replaced transactions of its sender. Only the transactions that pay less per byte
+// are evicted, starting from the cheapest ones. Transaction of whitelisted sender evicts any transaction
+// of not whitelisted sender.
+func (a *UtxImpl) evictionCandidates(e *poolEntry, whitelisted bool, replaced []*poolEntry) ([]*poolEntry, error) {
+	size := e.size()
+	if size > a.sizeLimit {
+		return nil, newRejectionError(PoolFull, "transaction size %d exceeds pool size limit %d", size, a.sizeLimit)
+	}
+	curSize := a.curSize
+	for _, r := range replaced {
+		curSize -= r.size()
+	}
+	if curSize+size <= a.sizeLimit {
+		return nil, nil
+	}
+	var (
+		needed     = curSize + size - a.sizeLimit
+		freed      uint64
+		candidates []*poolEntry
+	)
+	a.evictable.ascend(func(c *poolEntry) bool {
+		if !whitelisted && c.fpb >= e.fpb {
+			return false
+		}
+		if slices.Contains(replaced, c) {
+			return true
+		}
+		candidates = append(candidates, c)
+		freed += c.size()
+		return freed < needed
+	})
+	if freed >= needed {
+		return candidates, nil
+	}
+	return nil, newRejectionError(PoolFull,
+		"size overflow, curSize: %d, limit: %d, fee per byte %d is too low to replace transactions in pool",
+		a.curSize, a.sizeLimit, e.fpb)
+}
+
+// evict removes the transaction from pool and writes its removal to the journal.
+func (a *UtxImpl) evict(e *poolEntry) {
+	a.remove(e)
+	a.journalRemove(e.id)
+}
+
+// remove forgets the transaction.
+func (a *UtxImpl) remove(e *poolEntry) {
+	heap.Remove(&a.transactions, e.index)
+	if e.evictIndex >= 0 {
+		heap.Remove(&a.evictable, e.evictIndex)
+	}
+	delete(a.entries, e.id)
+	if u, ok := a.senders[e.sender]; ok {
+		u.transactions--
+		u.bytes -= e.size()
+		if i := slices.Index(u.entries, e); i >= 0 {
+			u.entries = slices.Delete(u.entries, i, i+1)
+		}
+		if u.transactions > 0 {
+			a.senders[e.sender] = u
+		} else {
+			delete(a.senders, e.sender)
+		}
+	}
+	if e.size() > a.curSize {
+		panic(fmt.Sprintf("UtxImpl: size of transaction %d > than current size %d", e.size(), a.curSize))
+	}
+	a.curSize -= e.size()
+}
+
 func (a *UtxImpl) Count() int {
 	a.mu.Lock()
 	defer a.mu.Unlock()
@@ -143,7 +495,7 @@ func makeDigest(b []byte, _ error) crypto.Digest {
 }
 
 func (a *UtxImpl) exists(t proto.Transaction) bool {
-	_, ok := a.transactionIds[makeDigest(t.GetID(a.settings.AddressSchemeCharacter))]
+	_, ok := a.entries[makeDigest(t.GetID(a.settings.AddressSchemeCharacter))]
 	return ok
 }
 
@@ -160,7 +512,7 @@ func (a *UtxImpl) ExistsByID(id []byte) bool {
 	if err != nil {
 		return false
 	}
-	_, ok := a.transactionIds[digest]
+	_, ok := a.entries[digest]
 	return ok
 }
 
@@ -168,17 +520,40 @@ func (a *UtxImpl) Pop() *types.TransactionWithBytes {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	if a.transactions.Len() > 0 {
-		tb := heap.Pop(&a.transactions).(*types.TransactionWithBytes)
-		delete(a.transactionIds, makeDigest(tb.T.GetID(a.settings.AddressSchemeCharacter)))
-		if uint64(len(tb.B)) > a.curSize {
-			panic(fmt.Sprintf("UtxImpl Pop: size of transaction %d > than current size %d", len(tb.B), a.curSize))
+		e := a.transactions[0]
+		a.remove(e)
+		if a.journal != nil {
+			a.journal.pop(e.id)
 		}
-		a.curSize -= uint64(len(tb.B))
-		return tb
+		a.compactJournal()
+		return e.tb
 	}
 	return nil
 }
 
+// journalRemove writes the removal of transaction to the journal, failures are only logged because the removed
+// transaction is validated again on restoration anyway.
+func (a *UtxImpl) journalRemove(id crypto.Digest) {
+	if a.journal == nil {
+		return
+	}
+	if err := a.journal.remove(id); err != nil {
+		zap.S().Warnf("Failed to write UTX journal: %v", err)
+	}
+}
+
+// compactJournal rewrites the journal if it has too many obsolete records.
+func (a *UtxImpl) compactJournal() {
+	if a.journal == nil {
+		return
+	}
+	if a.journal.needsCompaction(len(a.transactions)) {
+		if err := a.journal.compact(a.journalEntries()); err != nil {
+			zap.S().Warnf("Failed to compact UTX journal: %v", err)
+		}
+	}
+}
+
 func (a *UtxImpl) CurSize() uint64 {
 	a.mu.Lock()
 	defer a.mu.Unlock()
@@ -3,8 +3,10 @@ package utxpool
 import (
 	"bytes"
 	"math/rand"
+	"slices"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
 	"github.com/wavesplatform/gowaves/pkg/crypto"
@@ -15,8 +17,9 @@ import (
 )
 
 type transaction struct {
-	fee uint64
-	id  []byte
+	fee    uint64
+	id     []byte
+	sender proto.WavesAddress
 }
 
 func (a transaction) BinarySize() int {
@@ -108,7 +111,7 @@ func (a transaction) GetSenderPK() crypto.PublicKey {
 }
 
 func (a transaction) GetSender(_ proto.Scheme) (proto.Address, error) {
-	panic("not implemented")
+	return a.sender, nil
 }
 
 func tr(fee uint64) *transaction {
@@ -119,6 +122,10 @@ func id(b []byte, fee uint64) *transaction {
 	return &transaction{fee: fee, id: b}
 }
 
+func sent(sender proto.WavesAddress, b []byte, fee uint64) *transaction {
+	return &transaction{fee: fee, id: b, sender: sender}
+}
+
 func TestTransactionPool(t *testing.T) {
 	a := New(10000, NoOpValidator{}, settings.MustMainNetSettings())
 
@@ -203,3 +210,147 @@ func TestUtxImpl_TransactionExists(t *testing.T) {
 	require.True(t, a.ExistsByID(byte_helpers.BurnWithSig.Transaction.ID.Bytes()))
 	require.False(t, a.ExistsByID(byte_helpers.TransferWithSig.Transaction.ID.Bytes()))
 }
+
+func TestUtxPool_SenderLimits(t *testing.T) {
+	s1, s2, s3 := proto.WavesAddress{1}, proto.WavesAddress{2}, proto.WavesAddress{3}
+	limits := Limits{MaxSenderTransactions: 2, MaxSenderBytes: 5, Whitelist: []proto.WavesAddress{s3}}
+	a := NewWithLimits(10000, limits, NoOpValidator{}, settings.MustMainNetSettings())
+
+	require.NoError(t, a.AddWithBytes(sent(s1, []byte{1}, 10), []byte{1}))
+	require.NoError(t, a.AddWithBytes(sent(s1, []byte{2}, 10), []byte{1}))
+	err := a.AddWithBytes(sent(s1, []byte{3}, 10), []byte{1})
+	require.True(t, IsRejection(err, SenderTxLimit), err)
+
+	err = a.AddWithBytes(sent(s2, []byte{4}, 10), bytes.Repeat([]byte{1}, 6))
+	require.True(t, IsRejection(err, SenderBytesLimit), err)
+	require.NoError(t, a.AddWithBytes(sent(s2, []byte{5}, 10), bytes.Repeat([]byte{1}, 5)))
+
+	// Whitelisted sender is not limited.
+	for i := byte(0); i < 5; i++ {
+		require.NoError(t, a.AddWithBytes(sent(s3, []byte{6, i}, 10), bytes.Repeat([]byte{1}, 5)))
+	}
+
+	// Duplicate is rejected as already in pool, not by the quota.
+	err = a.AddWithBytes(sent(s1, []byte{2}, 10), []byte{1})
+	require.True(t, IsRejection(err, AlreadyInPool), err)
+	var infoMsg *proto.InfoMsg
+	require.ErrorAs(t, err, &infoMsg)
+
+	// Popped transaction frees the quota of sender.
+	for a.Len() > 0 {
+		a.Pop()
+	}
+	require.NoError(t, a.AddWithBytes(sent(s1, []byte{3}, 10), []byte{1}))
+}
+
+func TestUtxPool_Eviction(t *testing.T) {
+	s1, s2, w := proto.WavesAddress{1}, proto.WavesAddress{2}, proto.WavesAddress{3}
+	limits := Limits{Whitelist: []proto.WavesAddress{w}}
+	a := NewWithLimits(10, limits, NoOpValidator{}, settings.MustMainNetSettings())
+
+	require.NoError(t, a.AddWithBytes(sent(s1, []byte{1}, 20), bytes.Repeat([]byte{1}, 4))) // 5 per byte
+	require.NoError(t, a.AddWithBytes(sent(s1, []byte{2}, 8), bytes.Repeat([]byte{1}, 4)))  // 2 per byte
+	require.NoError(t, a.AddWithBytes(sent(w, []byte{3}, 2), bytes.Repeat([]byte{1}, 2)))   // 1 per byte
+
+	// Not enough cheaper transactions to evict.
+	err := a.AddWithBytes(sent(s2, []byte{4}, 8), bytes.Repeat([]byte{1}, 4))
+	require.True(t, IsRejection(err, PoolFull), err)
+	err = a.AddWithBytes(sent(s2, []byte{4}, 16), bytes.Repeat([]byte{1}, 8))
+	require.True(t, IsRejection(err, PoolFull), err)
+	err = a.AddWithBytes(sent(s2, []byte{4}, 1000), bytes.Repeat([]byte{1}, 11))
+	require.True(t, IsRejection(err, PoolFull), err)
+
+	// The cheapest transaction is replaced, the whitelisted one is kept.
+	require.NoError(t, a.AddWithBytes(sent(s2, []byte{4}, 12), bytes.Repeat([]byte{1}, 4))) // 3 per byte
+	require.EqualValues(t, 10, a.CurSize())
+	require.False(t, a.Exists(id([]byte{2}, 0)))
+	require.True(t, a.Exists(id([]byte{3}, 0)))
+
+	// Whitelisted sender replaces transactions regardless of their fees.
+	require.NoError(t, a.AddWithBytes(sent(w, []byte{5}, 1), bytes.Repeat([]byte{1}, 8)))
+	require.EqualValues(t, 10, a.CurSize())
+	require.Equal(t, 2, a.Len())
+	require.True(t, a.Exists(id([]byte{3}, 0)))
+	require.True(t, a.Exists(id([]byte{5}, 0)))
+	require.Empty(t, a.senders[s1])
+	require.Empty(t, a.senders[s2])
+}
+
+func TestUtxPool_ReplaceByFee(t *testing.T) {
+	s1, s2 := proto.WavesAddress{1}, proto.WavesAddress{2}
+	limits := Limits{MaxSenderTransactions: 2, MaxSenderBytes: 10}
+	a := NewWithLimits(12, limits, NoOpValidator{}, settings.MustMainNetSettings())
+
+	require.NoError(t, a.AddWithBytes(sent(s1, []byte{1}, 20), bytes.Repeat([]byte{1}, 4))) // 5 per byte
+	require.NoError(t, a.AddWithBytes(sent(s1, []byte{2}, 8), bytes.Repeat([]byte{1}, 4)))  // 2 per byte
+
+	// Transaction that doesn't pay more than pending ones is rejected by the quota.
+	err := a.AddWithBytes(sent(s1, []byte{3}, 8), bytes.Repeat([]byte{1}, 4))
+	require.True(t, IsRejection(err, SenderTxLimit), err)
+
+	// The cheapest transaction of sender is replaced.
+	require.NoError(t, a.AddWithBytes(sent(s1, []byte{3}, 12), bytes.Repeat([]byte{1}, 4))) // 3 per byte
+	require.Equal(t, 2, a.Len())
+	require.EqualValues(t, 8, a.CurSize())
+	require.False(t, a.Exists(id([]byte{2}, 0)))
+	require.Equal(t, 2, a.senders[s1].transactions)
+	require.EqualValues(t, 8, a.senders[s1].bytes)
+
+	// Replacement is not possible if the bigger transaction doesn't pay more than all replaced ones.
+	err = a.AddWithBytes(sent(s1, []byte{4}, 32), bytes.Repeat([]byte{1}, 8)) // 4 per byte
+	require.True(t, IsRejection(err, SenderTxLimit), err)
+	require.Equal(t, 2, a.Len())
+
+	// Both transactions are replaced by the one that pays more per byte.
+	require.NoError(t, a.AddWithBytes(sent(s1, []byte{4}, 48), bytes.Repeat([]byte{1}, 8))) // 6 per byte
+	require.Equal(t, 1, a.Len())
+	require.EqualValues(t, 8, a.CurSize())
+	require.True(t, a.Exists(id([]byte{4}, 0)))
+
+	// Replaced transactions free the room in pool, other senders' transactions are evicted if not enough.
+	require.NoError(t, a.AddWithBytes(sent(s2, []byte{5}, 4), bytes.Repeat([]byte{1}, 4))) // 1 per byte
+	require.NoError(t, a.AddWithBytes(sent(s1, []byte{6}, 100), bytes.Repeat([]byte{1}, 10)))
+	require.Equal(t, 1, a.Len())
+	require.EqualValues(t, 10, a.CurSize())
+	require.True(t, a.Exists(id([]byte{6}, 0)))
+	require.Empty(t, a.senders[s2])
+
+	// Pending transaction is kept if the replacing one is invalid.
+	a.validator = validatorFunc(func(proto.Transaction) error { return errors.New("invalid") })
+	require.Error(t, a.AddWithBytes(sent(s1, []byte{7}, 1000), bytes.Repeat([]byte{1}, 8)))
+	require.True(t, a.Exists(id([]byte{6}, 0)))
+	require.EqualValues(t, 10, a.CurSize())
+}
+
+func TestUtxPool_EvictionOrder(t *testing.T) {
+	const n = 200
+	a := New(n, NoOpValidator{}, settings.MustMainNetSettings())
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		b := []byte{byte(i), byte(i >> 8)}
+		require.NoError(t, a.AddWithBytes(sent(proto.WavesAddress{b[0], b[1]}, b, uint64(r.Intn(100)+1)), []byte{1}))
+	}
+	fees := make([]uint64, 0, n)
+	for _, tb := range a.AllTransactions() {
+		fees = append(fees, tb.T.GetFee())
+	}
+	slices.Sort(fees)
+
+	// Each new transaction evicts the cheapest one.
+	for i := 0; i < n/2; i++ {
+		b := []byte{byte(i), byte(i >> 8), 1}
+		require.NoError(t, a.AddWithBytes(sent(proto.WavesAddress{b[0], b[1], b[2]}, b, 1000), []byte{1}))
+	}
+	require.Equal(t, n, a.Len())
+	expected := slices.Clone(fees[n/2:])
+	slices.Reverse(expected)
+	for range n / 2 {
+		require.EqualValues(t, 1000, a.Pop().T.GetFee())
+	}
+	for _, fee := range expected {
+		require.Equal(t, fee, a.Pop().T.GetFee())
+	}
+	require.Zero(t, a.Len())
+	require.Empty(t, a.evictable)
+	require.Empty(t, a.senders)
+}
@@ -0,0 +1,52 @@
+package utxpool
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// RejectionReason explains why the pool refused to accept a valid transaction.
+type RejectionReason byte
+
+const (
+	PoolFull         RejectionReason = iota + 1 // No room in pool even after eviction of cheaper transactions
+	SenderTxLimit                               // Sender has too many transactions in pool
+	SenderBytesLimit                            // Transactions of sender take too many bytes in pool
+	AlreadyInPool                               // Transaction with the same ID is already in pool
+)
+
+func (r RejectionReason) String() string {
+	switch r {
+	case PoolFull:
+		return "PoolFull"
+	case SenderTxLimit:
+		return "SenderTxLimit"
+	case SenderBytesLimit:
+		return "SenderBytesLimit"
+	case AlreadyInPool:
+		return "AlreadyInPool"
+	default:
+		return fmt.Sprintf("RejectionReason(%d)", byte(r))
+	}
+}
+
+// RejectionError is returned by the pool if transaction is not accepted because of pool's limits.
+type RejectionError struct {
+	Reason RejectionReason
+	err    error
+}
+
+func newRejectionError(reason RejectionReason, format string, args ...interface{}) *RejectionError {
+	return &RejectionError{Reason: reason, err: errors.Errorf(format, args...)}
+}
+
+func (e *RejectionError) Error() string {
+	return e.err.Error()
+}
+
+// IsRejection checks that the error is a RejectionError with the given reason.
+func IsRejection(err error, reason RejectionReason) bool {
+	var re *RejectionError
+	return errors.As(err, &re) && re.Reason == reason
+}
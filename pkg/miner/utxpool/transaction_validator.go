@@ -10,6 +10,8 @@ import (
 	"github.com/wavesplatform/gowaves/pkg/types"
 )
 
+var errStateOutdated = errors.New("state outdated, transaction not accepted")
+
 type Validator interface {
 	Validate(t proto.Transaction) error
 }
@@ -36,7 +38,7 @@ func (a *ValidatorImpl) Validate(tx proto.Transaction) error {
 	lastBlock := a.state.TopBlock()
 	lastBlockTime := time.UnixMilli(int64(lastBlock.Timestamp))
 	if now.Add(-a.obsolescence).After(lastBlockTime) {
-		return errors.New("state outdated, transaction not accepted")
+		return errStateOutdated
 	}
 	return a.state.TxValidation(func(validation state.TxValidation) error {
 		_, err := validation.ValidateNextTx(tx, uint64(now.UnixMilli()), lastBlock.Timestamp, lastBlock.Version, false)
@@ -10,7 +10,8 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	waves "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves"
-	grpc "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/node/grpc"
+	grpc "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/events/grpc"
+	grpc0 "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/node/grpc"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
 )
@@ -54,10 +55,10 @@ func (mr *MockGrpcHandlersMockRecorder) Broadcast(arg0, arg1 interface{}) *gomoc
 }
 
 // GetActivationStatus mocks base method.
-func (m *MockGrpcHandlers) GetActivationStatus(arg0 context.Context, arg1 *grpc.ActivationStatusRequest) (*grpc.ActivationStatusResponse, error) {
+func (m *MockGrpcHandlers) GetActivationStatus(arg0 context.Context, arg1 *grpc0.ActivationStatusRequest) (*grpc0.ActivationStatusResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetActivationStatus", arg0, arg1)
-	ret0, _ := ret[0].(*grpc.ActivationStatusResponse)
+	ret0, _ := ret[0].(*grpc0.ActivationStatusResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -69,7 +70,7 @@ func (mr *MockGrpcHandlersMockRecorder) GetActivationStatus(arg0, arg1 interface
 }
 
 // GetActiveLeases mocks base method.
-func (m *MockGrpcHandlers) GetActiveLeases(arg0 *grpc.AccountRequest, arg1 grpc.AccountsApi_GetActiveLeasesServer) error {
+func (m *MockGrpcHandlers) GetActiveLeases(arg0 *grpc0.AccountRequest, arg1 grpc0.AccountsApi_GetActiveLeasesServer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetActiveLeases", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -83,7 +84,7 @@ func (mr *MockGrpcHandlersMockRecorder) GetActiveLeases(arg0, arg1 interface{})
 }
 
 // GetBalances mocks base method.
-func (m *MockGrpcHandlers) GetBalances(arg0 *grpc.BalancesRequest, arg1 grpc.AccountsApi_GetBalancesServer) error {
+func (m *MockGrpcHandlers) GetBalances(arg0 *grpc0.BalancesRequest, arg1 grpc0.AccountsApi_GetBalancesServer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetBalances", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -97,10 +98,10 @@ func (mr *MockGrpcHandlersMockRecorder) GetBalances(arg0, arg1 interface{}) *gom
 }
 
 // GetBaseTarget mocks base method.
-func (m *MockGrpcHandlers) GetBaseTarget(arg0 context.Context, arg1 *emptypb.Empty) (*grpc.BaseTargetResponse, error) {
+func (m *MockGrpcHandlers) GetBaseTarget(arg0 context.Context, arg1 *emptypb.Empty) (*grpc0.BaseTargetResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetBaseTarget", arg0, arg1)
-	ret0, _ := ret[0].(*grpc.BaseTargetResponse)
+	ret0, _ := ret[0].(*grpc0.BaseTargetResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -112,10 +113,10 @@ func (mr *MockGrpcHandlersMockRecorder) GetBaseTarget(arg0, arg1 interface{}) *g
 }
 
 // GetBlock mocks base method.
-func (m *MockGrpcHandlers) GetBlock(arg0 context.Context, arg1 *grpc.BlockRequest) (*grpc.BlockWithHeight, error) {
+func (m *MockGrpcHandlers) GetBlock(arg0 context.Context, arg1 *grpc0.BlockRequest) (*grpc0.BlockWithHeight, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetBlock", arg0, arg1)
-	ret0, _ := ret[0].(*grpc.BlockWithHeight)
+	ret0, _ := ret[0].(*grpc0.BlockWithHeight)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -127,7 +128,7 @@ func (mr *MockGrpcHandlersMockRecorder) GetBlock(arg0, arg1 interface{}) *gomock
 }
 
 // GetBlockRange mocks base method.
-func (m *MockGrpcHandlers) GetBlockRange(arg0 *grpc.BlockRangeRequest, arg1 grpc.BlocksApi_GetBlockRangeServer) error {
+func (m *MockGrpcHandlers) GetBlockRange(arg0 *grpc0.BlockRangeRequest, arg1 grpc0.BlocksApi_GetBlockRangeServer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetBlockRange", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -140,11 +141,41 @@ func (mr *MockGrpcHandlersMockRecorder) GetBlockRange(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockRange", reflect.TypeOf((*MockGrpcHandlers)(nil).GetBlockRange), arg0, arg1)
 }
 
+// GetBlockUpdate mocks base method.
+func (m *MockGrpcHandlers) GetBlockUpdate(arg0 context.Context, arg1 *grpc.GetBlockUpdateRequest) (*grpc.GetBlockUpdateResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlockUpdate", arg0, arg1)
+	ret0, _ := ret[0].(*grpc.GetBlockUpdateResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlockUpdate indicates an expected call of GetBlockUpdate.
+func (mr *MockGrpcHandlersMockRecorder) GetBlockUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockUpdate", reflect.TypeOf((*MockGrpcHandlers)(nil).GetBlockUpdate), arg0, arg1)
+}
+
+// GetBlockUpdatesRange mocks base method.
+func (m *MockGrpcHandlers) GetBlockUpdatesRange(arg0 context.Context, arg1 *grpc.GetBlockUpdatesRangeRequest) (*grpc.GetBlockUpdatesRangeResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlockUpdatesRange", arg0, arg1)
+	ret0, _ := ret[0].(*grpc.GetBlockUpdatesRangeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlockUpdatesRange indicates an expected call of GetBlockUpdatesRange.
+func (mr *MockGrpcHandlersMockRecorder) GetBlockUpdatesRange(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockUpdatesRange", reflect.TypeOf((*MockGrpcHandlers)(nil).GetBlockUpdatesRange), arg0, arg1)
+}
+
 // GetCumulativeScore mocks base method.
-func (m *MockGrpcHandlers) GetCumulativeScore(arg0 context.Context, arg1 *emptypb.Empty) (*grpc.ScoreResponse, error) {
+func (m *MockGrpcHandlers) GetCumulativeScore(arg0 context.Context, arg1 *emptypb.Empty) (*grpc0.ScoreResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetCumulativeScore", arg0, arg1)
-	ret0, _ := ret[0].(*grpc.ScoreResponse)
+	ret0, _ := ret[0].(*grpc0.ScoreResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -171,7 +202,7 @@ func (mr *MockGrpcHandlersMockRecorder) GetCurrentHeight(arg0, arg1 interface{})
 }
 
 // GetDataEntries mocks base method.
-func (m *MockGrpcHandlers) GetDataEntries(arg0 *grpc.DataRequest, arg1 grpc.AccountsApi_GetDataEntriesServer) error {
+func (m *MockGrpcHandlers) GetDataEntries(arg0 *grpc0.DataRequest, arg1 grpc0.AccountsApi_GetDataEntriesServer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetDataEntries", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -185,10 +216,10 @@ func (mr *MockGrpcHandlersMockRecorder) GetDataEntries(arg0, arg1 interface{}) *
 }
 
 // GetInfo mocks base method.
-func (m *MockGrpcHandlers) GetInfo(arg0 context.Context, arg1 *grpc.AssetRequest) (*grpc.AssetInfoResponse, error) {
+func (m *MockGrpcHandlers) GetInfo(arg0 context.Context, arg1 *grpc0.AssetRequest) (*grpc0.AssetInfoResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetInfo", arg0, arg1)
-	ret0, _ := ret[0].(*grpc.AssetInfoResponse)
+	ret0, _ := ret[0].(*grpc0.AssetInfoResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -200,7 +231,7 @@ func (mr *MockGrpcHandlersMockRecorder) GetInfo(arg0, arg1 interface{}) *gomock.
 }
 
 // GetNFTList mocks base method.
-func (m *MockGrpcHandlers) GetNFTList(arg0 *grpc.NFTRequest, arg1 grpc.AssetsApi_GetNFTListServer) error {
+func (m *MockGrpcHandlers) GetNFTList(arg0 *grpc0.NFTRequest, arg1 grpc0.AssetsApi_GetNFTListServer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetNFTList", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -214,10 +245,10 @@ func (mr *MockGrpcHandlersMockRecorder) GetNFTList(arg0, arg1 interface{}) *gomo
 }
 
 // GetScript mocks base method.
-func (m *MockGrpcHandlers) GetScript(arg0 context.Context, arg1 *grpc.AccountRequest) (*grpc.ScriptResponse, error) {
+func (m *MockGrpcHandlers) GetScript(arg0 context.Context, arg1 *grpc0.AccountRequest) (*grpc0.ScriptResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetScript", arg0, arg1)
-	ret0, _ := ret[0].(*grpc.ScriptResponse)
+	ret0, _ := ret[0].(*grpc0.ScriptResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -229,7 +260,7 @@ func (mr *MockGrpcHandlersMockRecorder) GetScript(arg0, arg1 interface{}) *gomoc
 }
 
 // GetStateChanges mocks base method.
-func (m *MockGrpcHandlers) GetStateChanges(arg0 *grpc.TransactionsRequest, arg1 grpc.TransactionsApi_GetStateChangesServer) error {
+func (m *MockGrpcHandlers) GetStateChanges(arg0 *grpc0.TransactionsRequest, arg1 grpc0.TransactionsApi_GetStateChangesServer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetStateChanges", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -243,7 +274,7 @@ func (mr *MockGrpcHandlersMockRecorder) GetStateChanges(arg0, arg1 interface{})
 }
 
 // GetStatuses mocks base method.
-func (m *MockGrpcHandlers) GetStatuses(arg0 *grpc.TransactionsByIdRequest, arg1 grpc.TransactionsApi_GetStatusesServer) error {
+func (m *MockGrpcHandlers) GetStatuses(arg0 *grpc0.TransactionsByIdRequest, arg1 grpc0.TransactionsApi_GetStatusesServer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetStatuses", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -257,7 +288,7 @@ func (mr *MockGrpcHandlersMockRecorder) GetStatuses(arg0, arg1 interface{}) *gom
 }
 
 // GetTransactionSnapshots mocks base method.
-func (m *MockGrpcHandlers) GetTransactionSnapshots(arg0 *grpc.TransactionSnapshotsRequest, arg1 grpc.TransactionsApi_GetTransactionSnapshotsServer) error {
+func (m *MockGrpcHandlers) GetTransactionSnapshots(arg0 *grpc0.TransactionSnapshotsRequest, arg1 grpc0.TransactionsApi_GetTransactionSnapshotsServer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetTransactionSnapshots", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -271,7 +302,7 @@ func (mr *MockGrpcHandlersMockRecorder) GetTransactionSnapshots(arg0, arg1 inter
 }
 
 // GetTransactions mocks base method.
-func (m *MockGrpcHandlers) GetTransactions(arg0 *grpc.TransactionsRequest, arg1 grpc.TransactionsApi_GetTransactionsServer) error {
+func (m *MockGrpcHandlers) GetTransactions(arg0 *grpc0.TransactionsRequest, arg1 grpc0.TransactionsApi_GetTransactionsServer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetTransactions", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -285,7 +316,7 @@ func (mr *MockGrpcHandlersMockRecorder) GetTransactions(arg0, arg1 interface{})
 }
 
 // GetUnconfirmed mocks base method.
-func (m *MockGrpcHandlers) GetUnconfirmed(arg0 *grpc.TransactionsRequest, arg1 grpc.TransactionsApi_GetUnconfirmedServer) error {
+func (m *MockGrpcHandlers) GetUnconfirmed(arg0 *grpc0.TransactionsRequest, arg1 grpc0.TransactionsApi_GetUnconfirmedServer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetUnconfirmed", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -314,7 +345,7 @@ func (mr *MockGrpcHandlersMockRecorder) ResolveAlias(arg0, arg1 interface{}) *go
 }
 
 // Sign mocks base method.
-func (m *MockGrpcHandlers) Sign(arg0 context.Context, arg1 *grpc.SignRequest) (*waves.SignedTransaction, error) {
+func (m *MockGrpcHandlers) Sign(arg0 context.Context, arg1 *grpc0.SignRequest) (*waves.SignedTransaction, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Sign", arg0, arg1)
 	ret0, _ := ret[0].(*waves.SignedTransaction)
@@ -327,3 +358,17 @@ func (mr *MockGrpcHandlersMockRecorder) Sign(arg0, arg1 interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sign", reflect.TypeOf((*MockGrpcHandlers)(nil).Sign), arg0, arg1)
 }
+
+// Subscribe mocks base method.
+func (m *MockGrpcHandlers) Subscribe(arg0 *grpc.SubscribeRequest, arg1 grpc.BlockchainUpdatesApi_SubscribeServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockGrpcHandlersMockRecorder) Subscribe(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockGrpcHandlers)(nil).Subscribe), arg0, arg1)
+}
@@ -8,7 +8,6 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
-	conn "github.com/wavesplatform/gowaves/pkg/p2p/conn"
 	peer "github.com/wavesplatform/gowaves/pkg/p2p/peer"
 	proto "github.com/wavesplatform/gowaves/pkg/proto"
 )
@@ -50,20 +49,6 @@ func (mr *MockPeerMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockPeer)(nil).Close))
 }
 
-// Connection mocks base method.
-func (m *MockPeer) Connection() conn.Connection {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Connection")
-	ret0, _ := ret[0].(conn.Connection)
-	return ret0
-}
-
-// Connection indicates an expected call of Connection.
-func (mr *MockPeerMockRecorder) Connection() *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connection", reflect.TypeOf((*MockPeer)(nil).Connection))
-}
-
 // Direction mocks base method.
 func (m *MockPeer) Direction() peer.Direction {
 	m.ctrl.T.Helper()
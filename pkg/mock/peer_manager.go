@@ -7,6 +7,7 @@ package mock
 import (
 	context "context"
 	net "net"
+	netip "net/netip"
 	reflect "reflect"
 	time "time"
 
@@ -63,6 +64,34 @@ func (mr *MockPeerManagerMockRecorder) AskPeers() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AskPeers", reflect.TypeOf((*MockPeerManager)(nil).AskPeers))
 }
 
+// BanSubnet mocks base method.
+func (m *MockPeerManager) BanSubnet(subnet netip.Prefix, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanSubnet", subnet, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BanSubnet indicates an expected call of BanSubnet.
+func (mr *MockPeerManagerMockRecorder) BanSubnet(subnet, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanSubnet", reflect.TypeOf((*MockPeerManager)(nil).BanSubnet), subnet, reason)
+}
+
+// BannedSubnets mocks base method.
+func (m *MockPeerManager) BannedSubnets() []storage.BannedSubnet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BannedSubnets")
+	ret0, _ := ret[0].([]storage.BannedSubnet)
+	return ret0
+}
+
+// BannedSubnets indicates an expected call of BannedSubnets.
+func (mr *MockPeerManagerMockRecorder) BannedSubnets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BannedSubnets", reflect.TypeOf((*MockPeerManager)(nil).BannedSubnets))
+}
+
 // BlackList mocks base method.
 func (m *MockPeerManager) BlackList() []storage.BlackListedPeer {
 	m.ctrl.T.Helper()
@@ -215,6 +244,34 @@ func (mr *MockPeerManagerMockRecorder) NewConnection(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewConnection", reflect.TypeOf((*MockPeerManager)(nil).NewConnection), arg0)
 }
 
+// PeersWithSameScore mocks base method.
+func (m *MockPeerManager) PeersWithSameScore(p peer.Peer) []peer.Peer {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PeersWithSameScore", p)
+	ret0, _ := ret[0].([]peer.Peer)
+	return ret0
+}
+
+// PeersWithSameScore indicates an expected call of PeersWithSameScore.
+func (mr *MockPeerManagerMockRecorder) PeersWithSameScore(p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeersWithSameScore", reflect.TypeOf((*MockPeerManager)(nil).PeersWithSameScore), p)
+}
+
+// Reputations mocks base method.
+func (m *MockPeerManager) Reputations() []storage.Reputation {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reputations")
+	ret0, _ := ret[0].([]storage.Reputation)
+	return ret0
+}
+
+// Reputations indicates an expected call of Reputations.
+func (mr *MockPeerManagerMockRecorder) Reputations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reputations", reflect.TypeOf((*MockPeerManager)(nil).Reputations))
+}
+
 // Score mocks base method.
 func (m *MockPeerManager) Score(p peer.Peer) (*proto.Score, error) {
 	m.ctrl.T.Helper()
@@ -296,6 +353,20 @@ func (mr *MockPeerManagerMockRecorder) Suspended() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Suspended", reflect.TypeOf((*MockPeerManager)(nil).Suspended))
 }
 
+// UnbanSubnet mocks base method.
+func (m *MockPeerManager) UnbanSubnet(subnet netip.Prefix) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbanSubnet", subnet)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnbanSubnet indicates an expected call of UnbanSubnet.
+func (mr *MockPeerManagerMockRecorder) UnbanSubnet(subnet interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbanSubnet", reflect.TypeOf((*MockPeerManager)(nil).UnbanSubnet), subnet)
+}
+
 // UpdateKnownPeers mocks base method.
 func (m *MockPeerManager) UpdateKnownPeers(arg0 []storage.KnownPeer) error {
 	m.ctrl.T.Helper()
@@ -310,6 +381,18 @@ func (mr *MockPeerManagerMockRecorder) UpdateKnownPeers(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateKnownPeers", reflect.TypeOf((*MockPeerManager)(nil).UpdateKnownPeers), arg0)
 }
 
+// UpdateReputation mocks base method.
+func (m *MockPeerManager) UpdateReputation(p peer.Peer, b storage.Behaviour, reason string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateReputation", p, b, reason)
+}
+
+// UpdateReputation indicates an expected call of UpdateReputation.
+func (mr *MockPeerManagerMockRecorder) UpdateReputation(p, b, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateReputation", reflect.TypeOf((*MockPeerManager)(nil).UpdateReputation), p, b, reason)
+}
+
 // UpdateScore mocks base method.
 func (m *MockPeerManager) UpdateScore(p peer.Peer, score *proto.Score) error {
 	m.ctrl.T.Helper()
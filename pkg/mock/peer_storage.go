@@ -35,6 +35,20 @@ func (m *MockPeerStorage) EXPECT() *MockPeerStorageMockRecorder {
 	return m.recorder
 }
 
+// AddBannedSubnets mocks base method.
+func (m *MockPeerStorage) AddBannedSubnets(banned []storage.BannedSubnet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddBannedSubnets", banned)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddBannedSubnets indicates an expected call of AddBannedSubnets.
+func (mr *MockPeerStorageMockRecorder) AddBannedSubnets(banned interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBannedSubnets", reflect.TypeOf((*MockPeerStorage)(nil).AddBannedSubnets), banned)
+}
+
 // AddOrUpdateKnown mocks base method.
 func (m *MockPeerStorage) AddOrUpdateKnown(known []storage.KnownPeer, now time.Time) error {
 	m.ctrl.T.Helper()
@@ -77,6 +91,20 @@ func (mr *MockPeerStorageMockRecorder) AddToBlackList(blackListed interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToBlackList", reflect.TypeOf((*MockPeerStorage)(nil).AddToBlackList), blackListed)
 }
 
+// BannedSubnets mocks base method.
+func (m *MockPeerStorage) BannedSubnets() []storage.BannedSubnet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BannedSubnets")
+	ret0, _ := ret[0].([]storage.BannedSubnet)
+	return ret0
+}
+
+// BannedSubnets indicates an expected call of BannedSubnets.
+func (mr *MockPeerStorageMockRecorder) BannedSubnets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BannedSubnets", reflect.TypeOf((*MockPeerStorage)(nil).BannedSubnets))
+}
+
 // BlackList mocks base method.
 func (m *MockPeerStorage) BlackList(now time.Time) []storage.BlackListedPeer {
 	m.ctrl.T.Helper()
@@ -91,6 +119,20 @@ func (mr *MockPeerStorageMockRecorder) BlackList(now interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlackList", reflect.TypeOf((*MockPeerStorage)(nil).BlackList), now)
 }
 
+// DeleteBannedSubnets mocks base method.
+func (m *MockPeerStorage) DeleteBannedSubnets(banned []storage.BannedSubnet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBannedSubnets", banned)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBannedSubnets indicates an expected call of DeleteBannedSubnets.
+func (mr *MockPeerStorageMockRecorder) DeleteBannedSubnets(banned interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBannedSubnets", reflect.TypeOf((*MockPeerStorage)(nil).DeleteBannedSubnets), banned)
+}
+
 // DeleteBlackListedByIP mocks base method.
 func (m *MockPeerStorage) DeleteBlackListedByIP(blackListed []storage.BlackListedPeer) error {
 	m.ctrl.T.Helper()
@@ -133,6 +175,20 @@ func (mr *MockPeerStorageMockRecorder) DeleteSuspendedByIP(suspended interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSuspendedByIP", reflect.TypeOf((*MockPeerStorage)(nil).DeleteSuspendedByIP), suspended)
 }
 
+// DropBannedSubnets mocks base method.
+func (m *MockPeerStorage) DropBannedSubnets() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropBannedSubnets")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropBannedSubnets indicates an expected call of DropBannedSubnets.
+func (mr *MockPeerStorageMockRecorder) DropBannedSubnets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropBannedSubnets", reflect.TypeOf((*MockPeerStorage)(nil).DropBannedSubnets))
+}
+
 // DropBlackList mocks base method.
 func (m *MockPeerStorage) DropBlackList() error {
 	m.ctrl.T.Helper()
@@ -161,6 +217,20 @@ func (mr *MockPeerStorageMockRecorder) DropKnown() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropKnown", reflect.TypeOf((*MockPeerStorage)(nil).DropKnown))
 }
 
+// DropReputation mocks base method.
+func (m *MockPeerStorage) DropReputation() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropReputation")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropReputation indicates an expected call of DropReputation.
+func (mr *MockPeerStorageMockRecorder) DropReputation() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropReputation", reflect.TypeOf((*MockPeerStorage)(nil).DropReputation))
+}
+
 // DropStorage mocks base method.
 func (m *MockPeerStorage) DropStorage() error {
 	m.ctrl.T.Helper()
@@ -189,6 +259,20 @@ func (mr *MockPeerStorageMockRecorder) DropSuspended() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropSuspended", reflect.TypeOf((*MockPeerStorage)(nil).DropSuspended))
 }
 
+// IsBannedIP mocks base method.
+func (m *MockPeerStorage) IsBannedIP(ip storage.IP) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsBannedIP", ip)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsBannedIP indicates an expected call of IsBannedIP.
+func (mr *MockPeerStorageMockRecorder) IsBannedIP(ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBannedIP", reflect.TypeOf((*MockPeerStorage)(nil).IsBannedIP), ip)
+}
+
 // IsBlackListedIP mocks base method.
 func (m *MockPeerStorage) IsBlackListedIP(ip storage.IP, now time.Time) bool {
 	m.ctrl.T.Helper()
@@ -259,6 +343,20 @@ func (mr *MockPeerStorageMockRecorder) Known(limit interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Known", reflect.TypeOf((*MockPeerStorage)(nil).Known), limit)
 }
 
+// KnownByReputation mocks base method.
+func (m *MockPeerStorage) KnownByReputation(limit int, now time.Time) []storage.KnownPeer {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KnownByReputation", limit, now)
+	ret0, _ := ret[0].([]storage.KnownPeer)
+	return ret0
+}
+
+// KnownByReputation indicates an expected call of KnownByReputation.
+func (mr *MockPeerStorageMockRecorder) KnownByReputation(limit, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KnownByReputation", reflect.TypeOf((*MockPeerStorage)(nil).KnownByReputation), limit, now)
+}
+
 // RefreshBlackList mocks base method.
 func (m *MockPeerStorage) RefreshBlackList(now time.Time) error {
 	m.ctrl.T.Helper()
@@ -287,6 +385,34 @@ func (mr *MockPeerStorageMockRecorder) RefreshSuspended(now interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshSuspended", reflect.TypeOf((*MockPeerStorage)(nil).RefreshSuspended), now)
 }
 
+// Reputation mocks base method.
+func (m *MockPeerStorage) Reputation(ip storage.IP, now time.Time) int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reputation", ip, now)
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// Reputation indicates an expected call of Reputation.
+func (mr *MockPeerStorageMockRecorder) Reputation(ip, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reputation", reflect.TypeOf((*MockPeerStorage)(nil).Reputation), ip, now)
+}
+
+// Reputations mocks base method.
+func (m *MockPeerStorage) Reputations(now time.Time) []storage.Reputation {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reputations", now)
+	ret0, _ := ret[0].([]storage.Reputation)
+	return ret0
+}
+
+// Reputations indicates an expected call of Reputations.
+func (mr *MockPeerStorageMockRecorder) Reputations(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reputations", reflect.TypeOf((*MockPeerStorage)(nil).Reputations), now)
+}
+
 // Suspended mocks base method.
 func (m *MockPeerStorage) Suspended(now time.Time) []storage.SuspendedPeer {
 	m.ctrl.T.Helper()
@@ -300,3 +426,31 @@ func (mr *MockPeerStorageMockRecorder) Suspended(now interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Suspended", reflect.TypeOf((*MockPeerStorage)(nil).Suspended), now)
 }
+
+// SyncReputation mocks base method.
+func (m *MockPeerStorage) SyncReputation() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncReputation")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncReputation indicates an expected call of SyncReputation.
+func (mr *MockPeerStorageMockRecorder) SyncReputation() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncReputation", reflect.TypeOf((*MockPeerStorage)(nil).SyncReputation))
+}
+
+// UpdateReputation mocks base method.
+func (m *MockPeerStorage) UpdateReputation(ip storage.IP, delta int64, now time.Time) int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateReputation", ip, delta, now)
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// UpdateReputation indicates an expected call of UpdateReputation.
+func (mr *MockPeerStorageMockRecorder) UpdateReputation(ip, delta, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateReputation", reflect.TypeOf((*MockPeerStorage)(nil).UpdateReputation), ip, delta, now)
+}
@@ -11,6 +11,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	crypto "github.com/wavesplatform/gowaves/pkg/crypto"
 	proto "github.com/wavesplatform/gowaves/pkg/proto"
+	ride "github.com/wavesplatform/gowaves/pkg/ride"
 	ast "github.com/wavesplatform/gowaves/pkg/ride/ast"
 	settings "github.com/wavesplatform/gowaves/pkg/settings"
 	state "github.com/wavesplatform/gowaves/pkg/state"
@@ -193,6 +194,21 @@ func (mr *MockStateInfoMockRecorder) ApprovalHeight(featureID interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApprovalHeight", reflect.TypeOf((*MockStateInfo)(nil).ApprovalHeight), featureID)
 }
 
+// ArchivalHeight mocks base method.
+func (m *MockStateInfo) ArchivalHeight() (proto.Height, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchivalHeight")
+	ret0, _ := ret[0].(proto.Height)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchivalHeight indicates an expected call of ArchivalHeight.
+func (mr *MockStateInfoMockRecorder) ArchivalHeight() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchivalHeight", reflect.TypeOf((*MockStateInfo)(nil).ArchivalHeight))
+}
+
 // AssetBalance mocks base method.
 func (m *MockStateInfo) AssetBalance(account proto.Recipient, assetID proto.AssetID) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -208,6 +224,37 @@ func (mr *MockStateInfoMockRecorder) AssetBalance(account, assetID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssetBalance", reflect.TypeOf((*MockStateInfo)(nil).AssetBalance), account, assetID)
 }
 
+// AssetBalanceAtHeight mocks base method.
+func (m *MockStateInfo) AssetBalanceAtHeight(account proto.Recipient, assetID proto.AssetID, height proto.Height) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssetBalanceAtHeight", account, assetID, height)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssetBalanceAtHeight indicates an expected call of AssetBalanceAtHeight.
+func (mr *MockStateInfoMockRecorder) AssetBalanceAtHeight(account, assetID, height interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssetBalanceAtHeight", reflect.TypeOf((*MockStateInfo)(nil).AssetBalanceAtHeight), account, assetID, height)
+}
+
+// AssetDistribution mocks base method.
+func (m *MockStateInfo) AssetDistribution(assetID proto.AssetID, height proto.Height, after *proto.WavesAddress, limit int) ([]proto.AssetHolding, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssetDistribution", assetID, height, after, limit)
+	ret0, _ := ret[0].([]proto.AssetHolding)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AssetDistribution indicates an expected call of AssetDistribution.
+func (mr *MockStateInfoMockRecorder) AssetDistribution(assetID, height, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssetDistribution", reflect.TypeOf((*MockStateInfo)(nil).AssetDistribution), assetID, height, after, limit)
+}
+
 // AssetInfo mocks base method.
 func (m *MockStateInfo) AssetInfo(assetID proto.AssetID) (*proto.AssetInfo, error) {
 	m.ctrl.T.Helper()
@@ -328,6 +375,21 @@ func (mr *MockStateInfoMockRecorder) BlockchainSettings() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockchainSettings", reflect.TypeOf((*MockStateInfo)(nil).BlockchainSettings))
 }
 
+// CallFunctionReadOnly mocks base method.
+func (m *MockStateInfo) CallFunctionReadOnly(dApp proto.WavesAddress, fc proto.FunctionCall) (ride.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CallFunctionReadOnly", dApp, fc)
+	ret0, _ := ret[0].(ride.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CallFunctionReadOnly indicates an expected call of CallFunctionReadOnly.
+func (mr *MockStateInfoMockRecorder) CallFunctionReadOnly(dApp, fc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CallFunctionReadOnly", reflect.TypeOf((*MockStateInfo)(nil).CallFunctionReadOnly), dApp, fc)
+}
+
 // CreateNextSnapshotHash mocks base method.
 func (m *MockStateInfo) CreateNextSnapshotHash(block *proto.Block) (crypto.Digest, error) {
 	m.ctrl.T.Helper()
@@ -388,6 +450,21 @@ func (mr *MockStateInfoMockRecorder) EstimatorVersion() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EstimatorVersion", reflect.TypeOf((*MockStateInfo)(nil).EstimatorVersion))
 }
 
+// EvaluateExpression mocks base method.
+func (m *MockStateInfo) EvaluateExpression(account proto.WavesAddress, expr ast.Node) (ride.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvaluateExpression", account, expr)
+	ret0, _ := ret[0].(ride.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvaluateExpression indicates an expected call of EvaluateExpression.
+func (mr *MockStateInfoMockRecorder) EvaluateExpression(account, expr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateExpression", reflect.TypeOf((*MockStateInfo)(nil).EvaluateExpression), account, expr)
+}
+
 // FullAssetInfo mocks base method.
 func (m *MockStateInfo) FullAssetInfo(assetID proto.AssetID) (*proto.FullAssetInfo, error) {
 	m.ctrl.T.Helper()
@@ -628,6 +705,22 @@ func (mr *MockStateInfoMockRecorder) IsAssetExist(assetID interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAssetExist", reflect.TypeOf((*MockStateInfo)(nil).IsAssetExist), assetID)
 }
 
+// LeaseBalanceAtHeight mocks base method.
+func (m *MockStateInfo) LeaseBalanceAtHeight(account proto.Recipient, height proto.Height) (int64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LeaseBalanceAtHeight", account, height)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LeaseBalanceAtHeight indicates an expected call of LeaseBalanceAtHeight.
+func (mr *MockStateInfoMockRecorder) LeaseBalanceAtHeight(account, height interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaseBalanceAtHeight", reflect.TypeOf((*MockStateInfo)(nil).LeaseBalanceAtHeight), account, height)
+}
+
 // LegacyStateHashAtHeight mocks base method.
 func (m *MockStateInfo) LegacyStateHashAtHeight(height proto.Height) (*proto.StateHash, error) {
 	m.ctrl.T.Helper()
@@ -658,6 +751,21 @@ func (mr *MockStateInfoMockRecorder) MapR(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MapR", reflect.TypeOf((*MockStateInfo)(nil).MapR), arg0)
 }
 
+// MinimalFee mocks base method.
+func (m *MockStateInfo) MinimalFee(tx proto.Transaction, feeAsset proto.OptionalAsset) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MinimalFee", tx, feeAsset)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MinimalFee indicates an expected call of MinimalFee.
+func (mr *MockStateInfoMockRecorder) MinimalFee(tx, feeAsset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MinimalFee", reflect.TypeOf((*MockStateInfo)(nil).MinimalFee), tx, feeAsset)
+}
+
 // NFTList mocks base method.
 func (m *MockStateInfo) NFTList(account proto.Recipient, limit uint64, afterAssetID *proto.AssetID) ([]*proto.FullAssetInfo, error) {
 	m.ctrl.T.Helper()
@@ -688,6 +796,21 @@ func (mr *MockStateInfoMockRecorder) NewAddrTransactionsIterator(addr interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewAddrTransactionsIterator", reflect.TypeOf((*MockStateInfo)(nil).NewAddrTransactionsIterator), addr)
 }
 
+// NewCheckpoint mocks base method.
+func (m *MockStateInfo) NewCheckpoint() (*state.Checkpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewCheckpoint")
+	ret0, _ := ret[0].(*state.Checkpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewCheckpoint indicates an expected call of NewCheckpoint.
+func (mr *MockStateInfoMockRecorder) NewCheckpoint() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewCheckpoint", reflect.TypeOf((*MockStateInfo)(nil).NewCheckpoint))
+}
+
 // NewestScriptByAccount mocks base method.
 func (m *MockStateInfo) NewestScriptByAccount(account proto.Recipient) (*ast.Tree, error) {
 	m.ctrl.T.Helper()
@@ -808,6 +931,21 @@ func (mr *MockStateInfoMockRecorder) RetrieveEntry(account, key interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveEntry", reflect.TypeOf((*MockStateInfo)(nil).RetrieveEntry), account, key)
 }
 
+// RetrieveEntryAtHeight mocks base method.
+func (m *MockStateInfo) RetrieveEntryAtHeight(account proto.Recipient, key string, height proto.Height) (proto.DataEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetrieveEntryAtHeight", account, key, height)
+	ret0, _ := ret[0].(proto.DataEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetrieveEntryAtHeight indicates an expected call of RetrieveEntryAtHeight.
+func (mr *MockStateInfoMockRecorder) RetrieveEntryAtHeight(account, key, height interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveEntryAtHeight", reflect.TypeOf((*MockStateInfo)(nil).RetrieveEntryAtHeight), account, key, height)
+}
+
 // RetrieveIntegerEntry mocks base method.
 func (m *MockStateInfo) RetrieveIntegerEntry(account proto.Recipient, key string) (*proto.IntegerDataEntry, error) {
 	m.ctrl.T.Helper()
@@ -898,6 +1036,21 @@ func (mr *MockStateInfoMockRecorder) ScriptBasicInfoByAccount(account interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScriptBasicInfoByAccount", reflect.TypeOf((*MockStateInfo)(nil).ScriptBasicInfoByAccount), account)
 }
 
+// ScriptBytesByAccountAtHeight mocks base method.
+func (m *MockStateInfo) ScriptBytesByAccountAtHeight(account proto.Recipient, height proto.Height) (proto.Script, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScriptBytesByAccountAtHeight", account, height)
+	ret0, _ := ret[0].(proto.Script)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScriptBytesByAccountAtHeight indicates an expected call of ScriptBytesByAccountAtHeight.
+func (mr *MockStateInfoMockRecorder) ScriptBytesByAccountAtHeight(account, height interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScriptBytesByAccountAtHeight", reflect.TypeOf((*MockStateInfo)(nil).ScriptBytesByAccountAtHeight), account, height)
+}
+
 // ScriptInfoByAccount mocks base method.
 func (m *MockStateInfo) ScriptInfoByAccount(account proto.Recipient) (*proto.ScriptInfo, error) {
 	m.ctrl.T.Helper()
@@ -1108,6 +1261,36 @@ func (mr *MockStateInfoMockRecorder) WavesBalance(account interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WavesBalance", reflect.TypeOf((*MockStateInfo)(nil).WavesBalance), account)
 }
 
+// WavesBalanceAtHeight mocks base method.
+func (m *MockStateInfo) WavesBalanceAtHeight(account proto.Recipient, height proto.Height) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WavesBalanceAtHeight", account, height)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WavesBalanceAtHeight indicates an expected call of WavesBalanceAtHeight.
+func (mr *MockStateInfoMockRecorder) WavesBalanceAtHeight(account, height interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WavesBalanceAtHeight", reflect.TypeOf((*MockStateInfo)(nil).WavesBalanceAtHeight), account, height)
+}
+
+// WavesBalanceWithConfirmations mocks base method.
+func (m *MockStateInfo) WavesBalanceWithConfirmations(account proto.Recipient, confirmations uint64) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WavesBalanceWithConfirmations", account, confirmations)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WavesBalanceWithConfirmations indicates an expected call of WavesBalanceWithConfirmations.
+func (mr *MockStateInfoMockRecorder) WavesBalanceWithConfirmations(account, confirmations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WavesBalanceWithConfirmations", reflect.TypeOf((*MockStateInfo)(nil).WavesBalanceWithConfirmations), account, confirmations)
+}
+
 // MockStateModifier is a mock of StateModifier interface.
 type MockStateModifier struct {
 	ctrl     *gomock.Controller
@@ -1568,6 +1751,21 @@ func (mr *MockStateMockRecorder) ApprovalHeight(featureID interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApprovalHeight", reflect.TypeOf((*MockState)(nil).ApprovalHeight), featureID)
 }
 
+// ArchivalHeight mocks base method.
+func (m *MockState) ArchivalHeight() (proto.Height, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchivalHeight")
+	ret0, _ := ret[0].(proto.Height)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchivalHeight indicates an expected call of ArchivalHeight.
+func (mr *MockStateMockRecorder) ArchivalHeight() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchivalHeight", reflect.TypeOf((*MockState)(nil).ArchivalHeight))
+}
+
 // AssetBalance mocks base method.
 func (m *MockState) AssetBalance(account proto.Recipient, assetID proto.AssetID) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -1583,6 +1781,37 @@ func (mr *MockStateMockRecorder) AssetBalance(account, assetID interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssetBalance", reflect.TypeOf((*MockState)(nil).AssetBalance), account, assetID)
 }
 
+// AssetBalanceAtHeight mocks base method.
+func (m *MockState) AssetBalanceAtHeight(account proto.Recipient, assetID proto.AssetID, height proto.Height) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssetBalanceAtHeight", account, assetID, height)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssetBalanceAtHeight indicates an expected call of AssetBalanceAtHeight.
+func (mr *MockStateMockRecorder) AssetBalanceAtHeight(account, assetID, height interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssetBalanceAtHeight", reflect.TypeOf((*MockState)(nil).AssetBalanceAtHeight), account, assetID, height)
+}
+
+// AssetDistribution mocks base method.
+func (m *MockState) AssetDistribution(assetID proto.AssetID, height proto.Height, after *proto.WavesAddress, limit int) ([]proto.AssetHolding, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssetDistribution", assetID, height, after, limit)
+	ret0, _ := ret[0].([]proto.AssetHolding)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AssetDistribution indicates an expected call of AssetDistribution.
+func (mr *MockStateMockRecorder) AssetDistribution(assetID, height, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssetDistribution", reflect.TypeOf((*MockState)(nil).AssetDistribution), assetID, height, after, limit)
+}
+
 // AssetInfo mocks base method.
 func (m *MockState) AssetInfo(assetID proto.AssetID) (*proto.AssetInfo, error) {
 	m.ctrl.T.Helper()
@@ -1703,6 +1932,21 @@ func (mr *MockStateMockRecorder) BlockchainSettings() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockchainSettings", reflect.TypeOf((*MockState)(nil).BlockchainSettings))
 }
 
+// CallFunctionReadOnly mocks base method.
+func (m *MockState) CallFunctionReadOnly(dApp proto.WavesAddress, fc proto.FunctionCall) (ride.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CallFunctionReadOnly", dApp, fc)
+	ret0, _ := ret[0].(ride.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CallFunctionReadOnly indicates an expected call of CallFunctionReadOnly.
+func (mr *MockStateMockRecorder) CallFunctionReadOnly(dApp, fc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CallFunctionReadOnly", reflect.TypeOf((*MockState)(nil).CallFunctionReadOnly), dApp, fc)
+}
+
 // Close mocks base method.
 func (m *MockState) Close() error {
 	m.ctrl.T.Helper()
@@ -1777,6 +2021,21 @@ func (mr *MockStateMockRecorder) EstimatorVersion() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EstimatorVersion", reflect.TypeOf((*MockState)(nil).EstimatorVersion))
 }
 
+// EvaluateExpression mocks base method.
+func (m *MockState) EvaluateExpression(account proto.WavesAddress, expr ast.Node) (ride.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvaluateExpression", account, expr)
+	ret0, _ := ret[0].(ride.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvaluateExpression indicates an expected call of EvaluateExpression.
+func (mr *MockStateMockRecorder) EvaluateExpression(account, expr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateExpression", reflect.TypeOf((*MockState)(nil).EvaluateExpression), account, expr)
+}
+
 // FullAssetInfo mocks base method.
 func (m *MockState) FullAssetInfo(assetID proto.AssetID) (*proto.FullAssetInfo, error) {
 	m.ctrl.T.Helper()
@@ -2017,6 +2276,22 @@ func (mr *MockStateMockRecorder) IsAssetExist(assetID interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAssetExist", reflect.TypeOf((*MockState)(nil).IsAssetExist), assetID)
 }
 
+// LeaseBalanceAtHeight mocks base method.
+func (m *MockState) LeaseBalanceAtHeight(account proto.Recipient, height proto.Height) (int64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LeaseBalanceAtHeight", account, height)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LeaseBalanceAtHeight indicates an expected call of LeaseBalanceAtHeight.
+func (mr *MockStateMockRecorder) LeaseBalanceAtHeight(account, height interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaseBalanceAtHeight", reflect.TypeOf((*MockState)(nil).LeaseBalanceAtHeight), account, height)
+}
+
 // LegacyStateHashAtHeight mocks base method.
 func (m *MockState) LegacyStateHashAtHeight(height proto.Height) (*proto.StateHash, error) {
 	m.ctrl.T.Helper()
@@ -2061,6 +2336,21 @@ func (mr *MockStateMockRecorder) MapR(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MapR", reflect.TypeOf((*MockState)(nil).MapR), arg0)
 }
 
+// MinimalFee mocks base method.
+func (m *MockState) MinimalFee(tx proto.Transaction, feeAsset proto.OptionalAsset) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MinimalFee", tx, feeAsset)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MinimalFee indicates an expected call of MinimalFee.
+func (mr *MockStateMockRecorder) MinimalFee(tx, feeAsset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MinimalFee", reflect.TypeOf((*MockState)(nil).MinimalFee), tx, feeAsset)
+}
+
 // NFTList mocks base method.
 func (m *MockState) NFTList(account proto.Recipient, limit uint64, afterAssetID *proto.AssetID) ([]*proto.FullAssetInfo, error) {
 	m.ctrl.T.Helper()
@@ -2091,6 +2381,21 @@ func (mr *MockStateMockRecorder) NewAddrTransactionsIterator(addr interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewAddrTransactionsIterator", reflect.TypeOf((*MockState)(nil).NewAddrTransactionsIterator), addr)
 }
 
+// NewCheckpoint mocks base method.
+func (m *MockState) NewCheckpoint() (*state.Checkpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewCheckpoint")
+	ret0, _ := ret[0].(*state.Checkpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewCheckpoint indicates an expected call of NewCheckpoint.
+func (mr *MockStateMockRecorder) NewCheckpoint() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewCheckpoint", reflect.TypeOf((*MockState)(nil).NewCheckpoint))
+}
+
 // NewestScriptByAccount mocks base method.
 func (m *MockState) NewestScriptByAccount(account proto.Recipient) (*ast.Tree, error) {
 	m.ctrl.T.Helper()
@@ -2237,6 +2542,21 @@ func (mr *MockStateMockRecorder) RetrieveEntry(account, key interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveEntry", reflect.TypeOf((*MockState)(nil).RetrieveEntry), account, key)
 }
 
+// RetrieveEntryAtHeight mocks base method.
+func (m *MockState) RetrieveEntryAtHeight(account proto.Recipient, key string, height proto.Height) (proto.DataEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetrieveEntryAtHeight", account, key, height)
+	ret0, _ := ret[0].(proto.DataEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetrieveEntryAtHeight indicates an expected call of RetrieveEntryAtHeight.
+func (mr *MockStateMockRecorder) RetrieveEntryAtHeight(account, key, height interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveEntryAtHeight", reflect.TypeOf((*MockState)(nil).RetrieveEntryAtHeight), account, key, height)
+}
+
 // RetrieveIntegerEntry mocks base method.
 func (m *MockState) RetrieveIntegerEntry(account proto.Recipient, key string) (*proto.IntegerDataEntry, error) {
 	m.ctrl.T.Helper()
@@ -2355,6 +2675,21 @@ func (mr *MockStateMockRecorder) ScriptBasicInfoByAccount(account interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScriptBasicInfoByAccount", reflect.TypeOf((*MockState)(nil).ScriptBasicInfoByAccount), account)
 }
 
+// ScriptBytesByAccountAtHeight mocks base method.
+func (m *MockState) ScriptBytesByAccountAtHeight(account proto.Recipient, height proto.Height) (proto.Script, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScriptBytesByAccountAtHeight", account, height)
+	ret0, _ := ret[0].(proto.Script)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScriptBytesByAccountAtHeight indicates an expected call of ScriptBytesByAccountAtHeight.
+func (mr *MockStateMockRecorder) ScriptBytesByAccountAtHeight(account, height interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScriptBytesByAccountAtHeight", reflect.TypeOf((*MockState)(nil).ScriptBytesByAccountAtHeight), account, height)
+}
+
 // ScriptInfoByAccount mocks base method.
 func (m *MockState) ScriptInfoByAccount(account proto.Recipient) (*proto.ScriptInfo, error) {
 	m.ctrl.T.Helper()
@@ -2607,3 +2942,33 @@ func (mr *MockStateMockRecorder) WavesBalance(account interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WavesBalance", reflect.TypeOf((*MockState)(nil).WavesBalance), account)
 }
+
+// WavesBalanceAtHeight mocks base method.
+func (m *MockState) WavesBalanceAtHeight(account proto.Recipient, height proto.Height) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WavesBalanceAtHeight", account, height)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WavesBalanceAtHeight indicates an expected call of WavesBalanceAtHeight.
+func (mr *MockStateMockRecorder) WavesBalanceAtHeight(account, height interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WavesBalanceAtHeight", reflect.TypeOf((*MockState)(nil).WavesBalanceAtHeight), account, height)
+}
+
+// WavesBalanceWithConfirmations mocks base method.
+func (m *MockState) WavesBalanceWithConfirmations(account proto.Recipient, confirmations uint64) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WavesBalanceWithConfirmations", account, confirmations)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WavesBalanceWithConfirmations indicates an expected call of WavesBalanceWithConfirmations.
+func (mr *MockStateMockRecorder) WavesBalanceWithConfirmations(account, confirmations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WavesBalanceWithConfirmations", reflect.TypeOf((*MockState)(nil).WavesBalanceWithConfirmations), account, confirmations)
+}
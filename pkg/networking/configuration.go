@@ -18,6 +18,7 @@ type Config struct {
 	keepAlive              bool
 	keepAliveInterval      time.Duration
 	connectionWriteTimeout time.Duration
+	readTimeout            time.Duration
 	attributes             []any
 }
 
@@ -57,6 +58,14 @@ func (c *Config) WithWriteTimeout(timeout time.Duration) *Config {
 	return c
 }
 
+// WithReadTimeout sets the maximum time of waiting for the next handshake or message from the connection.
+// The session fails if nothing is received in time. Zero value disables the timeout, which is the default.
+// The timeout works only if the connection supports read deadlines.
+func (c *Config) WithReadTimeout(timeout time.Duration) *Config {
+	c.readTimeout = timeout
+	return c
+}
+
 // WithSlogAttribute adds an attribute to the slice of attributes.
 func (c *Config) WithSlogAttribute(attr slog.Attr) *Config {
 	c.attributes = append(c.attributes, attr)
@@ -40,6 +40,7 @@ type Session struct {
 	receiving   atomic.Bool // Indicates that receiveLoop already running.
 	established atomic.Bool // Indicates that incoming Handshake was successfully accepted.
 	shutdown    sync.Once   // shutdown is used to safely close the Session.
+	failure     sync.Once   // failure is used to close the underlying connection once on a loop failure.
 }
 
 // NewSession is used to construct a new session.
@@ -208,6 +209,7 @@ func (s *Session) sendLoop() error {
 				packet.mu.Unlock()
 				s.logger.Error("Failed to copy data into buffer", "error", rErr)
 				s.asyncSendErr(packet.err, rErr)
+				s.fail(rErr)
 				return rErr
 			}
 			if s.logger.Enabled(s.ctx, slog.LevelDebug) {
@@ -222,6 +224,7 @@ func (s *Session) sendLoop() error {
 				if err != nil {
 					s.logger.Error("Failed to write data into connection", "error", err)
 					s.asyncSendErr(packet.err, err)
+					s.fail(err)
 					return err
 				}
 				s.logger.Debug("Data written into connection")
@@ -245,12 +248,40 @@ func (s *Session) receiveLoop() error {
 				s.config.handler.OnClose(s)
 				return nil // Exit normally on connection close.
 			}
+			s.fail(err)
+			s.config.handler.OnClose(s)
 			return err
 		}
 	}
 }
 
+// fail closes the underlying connection after an unrecoverable error in one of the loops. It unblocks the
+// receive loop, so the handler is notified with OnClose, but the session itself should be closed by the owner.
+func (s *Session) fail(err error) {
+	s.failure.Do(func() {
+		s.logger.Debug("Closing underlying connection on failure", "error", err)
+		if clErr := s.conn.Close(); clErr != nil {
+			s.logger.Debug("Failed to close underlying connection on failure", "error", clErr)
+		}
+	})
+}
+
+// setReadDeadline limits the time of waiting for the next handshake or message, if the timeout is configured
+// and the underlying connection supports deadlines.
+func (s *Session) setReadDeadline() error {
+	if s.config.readTimeout <= 0 {
+		return nil
+	}
+	if d, ok := s.conn.(readDeadlineSetter); ok {
+		return d.SetReadDeadline(time.Now().Add(s.config.readTimeout))
+	}
+	return nil
+}
+
 func (s *Session) receive() error {
+	if err := s.setReadDeadline(); err != nil {
+		return err
+	}
 	if s.established.Load() {
 		hdr := s.config.protocol.EmptyHeader()
 		return s.readMessage(hdr)
@@ -307,6 +338,7 @@ func (s *Session) readMessage(hdr Header) error {
 			s.logger.Error("Failed to discard message", "error", err)
 			return err
 		}
+		return nil
 	}
 	// Read the new data
 	if err := s.readMessagePayload(hdr, s.bufRead); err != nil {
@@ -364,13 +396,15 @@ func (s *Session) keepaliveLoop() error {
 			p, err := s.config.protocol.Ping()
 			if err != nil {
 				s.logger.Error("Failed to get ping message", "error", err)
+				s.fail(ErrKeepAliveProtocolFailure)
 				return ErrKeepAliveProtocolFailure
 			}
 			if sndErr := s.waitForSend(p); sndErr != nil {
 				if errors.Is(sndErr, ErrSessionShutdown) {
 					return nil // Exit normally on session termination.
 				}
-				s.logger.Error("Failed to send ping message", "error", err)
+				s.logger.Error("Failed to send ping message", "error", sndErr)
+				s.fail(ErrKeepAliveTimeout)
 				return ErrKeepAliveTimeout
 			}
 		}
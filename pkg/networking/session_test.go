@@ -7,6 +7,8 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	gonet "net"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -434,6 +436,37 @@ func TestCloseParentContext(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSessionReadTimeout(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	mockProtocol := netmocks.NewMockProtocol(t)
+	mockProtocol.On("EmptyHandshake").Return(&textHandshake{})
+	serverHandler := netmocks.NewMockHandler(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clientConn, serverConn := gonet.Pipe() // Pipe supports deadlines, but nothing is sent from the client side.
+	net := networking.NewNetwork()
+
+	closed := make(chan struct{})
+	conf := testConfig(t, mockProtocol, serverHandler, "server").WithReadTimeout(50 * time.Millisecond)
+	serverSession, err := net.NewSession(ctx, serverConn, conf)
+	require.NoError(t, err)
+	serverHandler.On("OnClose", serverSession).Once().Return().Run(func(_ mock.Arguments) {
+		close(closed)
+	})
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		require.Fail(t, "session was not closed on read timeout")
+	}
+	err = serverSession.Close()
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.NoError(t, clientConn.Close())
+}
+
 func testConfig(t testing.TB, p networking.Protocol, h networking.Handler, direction string) *networking.Config {
 	log := slogt.New(t)
 	return networking.NewConfig().
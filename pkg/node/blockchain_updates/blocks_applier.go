@@ -0,0 +1,66 @@
+package blockchain_updates
+
+import (
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+type blocksApplier interface {
+	BlockExists(state state.State, block *proto.Block) (bool, error)
+	Apply(state state.State, block []*proto.Block) (proto.Height, error)
+	ApplyMicro(state state.State, block *proto.Block) (proto.Height, error)
+	ApplyWithSnapshots(
+		state state.State,
+		block []*proto.Block,
+		snapshots []*proto.BlockSnapshot,
+	) (proto.Height, error)
+	ApplyMicroWithSnapshots(
+		state state.State,
+		block *proto.Block,
+		snapshots *proto.BlockSnapshot,
+	) (proto.Height, error)
+}
+
+// BlocksApplier notifies the Publisher after each application of blocks or microblocks.
+// Failed application can also change the state by rolling it back, so the Publisher is notified anyway.
+type BlocksApplier struct {
+	inner     blocksApplier
+	publisher *Publisher
+}
+
+func NewBlocksApplier(inner blocksApplier, publisher *Publisher) *BlocksApplier {
+	return &BlocksApplier{inner: inner, publisher: publisher}
+}
+
+func (a *BlocksApplier) BlockExists(state state.State, block *proto.Block) (bool, error) {
+	return a.inner.BlockExists(state, block)
+}
+
+func (a *BlocksApplier) Apply(state state.State, blocks []*proto.Block) (proto.Height, error) {
+	return a.notify(a.inner.Apply(state, blocks))
+}
+
+func (a *BlocksApplier) ApplyMicro(state state.State, block *proto.Block) (proto.Height, error) {
+	return a.notify(a.inner.ApplyMicro(state, block))
+}
+
+func (a *BlocksApplier) ApplyWithSnapshots(
+	state state.State,
+	blocks []*proto.Block,
+	snapshots []*proto.BlockSnapshot,
+) (proto.Height, error) {
+	return a.notify(a.inner.ApplyWithSnapshots(state, blocks, snapshots))
+}
+
+func (a *BlocksApplier) ApplyMicroWithSnapshots(
+	state state.State,
+	block *proto.Block,
+	snapshot *proto.BlockSnapshot,
+) (proto.Height, error) {
+	return a.notify(a.inner.ApplyMicroWithSnapshots(state, block, snapshot))
+}
+
+func (a *BlocksApplier) notify(height proto.Height, err error) (proto.Height, error) {
+	a.publisher.Notify()
+	return height, err
+}
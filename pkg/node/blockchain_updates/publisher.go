@@ -0,0 +1,663 @@
+package blockchain_updates
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	pb "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves"
+	"github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/events"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+const (
+	// historyDepth is the number of the latest blocks remembered by the publisher to detect rollbacks.
+	historyDepth = 100
+	// maxAppendsPerPass limits the number of blocks processed under the state lock at once.
+	maxAppendsPerPass = 100
+	// subscriptionBufferSize is the number of updates buffered for a subscriber before it is dropped.
+	subscriptionBufferSize = 1024
+)
+
+// ErrSubscriptionOverflow is returned to a subscriber that does not read updates fast enough.
+var ErrSubscriptionOverflow = errors.New("blockchain updates buffer overflow")
+
+type MicroBlockCache interface {
+	GetBlock(sig proto.BlockID) (*proto.MicroBlock, bool)
+}
+
+// microPoint is the state of a liquid block after the key block or one of its microblocks was applied.
+type microPoint struct {
+	id      proto.BlockID
+	txCount int
+}
+
+type blockEntry struct {
+	parent    proto.BlockID
+	txIDs     [][]byte
+	points    []microPoint
+	activated []int32
+}
+
+func (e *blockEntry) id() proto.BlockID {
+	return e.points[len(e.points)-1].id
+}
+
+type subscription struct {
+	updates chan *events.BlockchainUpdated
+	err     error
+}
+
+// Publisher tracks the changes of the blockchain and produces blockchain updates for subscribers.
+// It is notified by the FSM about applied blocks and microblocks and builds the updates from the blocks
+// and snapshots stored in state by comparing the new state of the blockchain with the last published one.
+type Publisher struct {
+	st     state.StateInfo
+	micro  MicroBlockCache
+	scheme proto.Scheme
+	notify chan struct{}
+
+	mu      sync.Mutex
+	bottom  proto.Height // Height of the first entry in history.
+	history []blockEntry
+	subs    map[*subscription]struct{}
+}
+
+func NewPublisher(st state.StateInfo, micro MicroBlockCache, scheme proto.Scheme) (*Publisher, error) {
+	p := &Publisher{
+		st:     st,
+		micro:  micro,
+		scheme: scheme,
+		notify: make(chan struct{}, 1),
+		subs:   make(map[*subscription]struct{}),
+	}
+	_, err := st.MapR(func(info state.StateInfo) (interface{}, error) {
+		return nil, p.loadHistory(info)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize blockchain updates publisher")
+	}
+	return p, nil
+}
+
+func (p *Publisher) loadHistory(info state.StateInfo) error {
+	height, err := info.Height()
+	if err != nil {
+		return err
+	}
+	p.bottom = 1
+	if height > historyDepth {
+		p.bottom = height - historyDepth + 1
+	}
+	p.history = make([]blockEntry, 0, historyDepth)
+	for h := p.bottom; h <= height; h++ {
+		block, bErr := info.BlockByHeight(h)
+		if bErr != nil {
+			return errors.Wrapf(bErr, "failed to get block at height %d", h)
+		}
+		e, eErr := p.newEntry(info, block, h)
+		if eErr != nil {
+			return eErr
+		}
+		p.history = append(p.history, e)
+	}
+	return nil
+}
+
+func (p *Publisher) newEntry(info state.StateInfo, block *proto.Block, height proto.Height) (blockEntry, error) {
+	ids, err := transactionIDs(block.Transactions, p.scheme)
+	if err != nil {
+		return blockEntry{}, err
+	}
+	activated, err := activatedFeatures(info, height)
+	if err != nil {
+		return blockEntry{}, err
+	}
+	return blockEntry{
+		parent:    block.Parent,
+		txIDs:     ids,
+		points:    []microPoint{{id: block.BlockID(), txCount: len(ids)}},
+		activated: activated,
+	}, nil
+}
+
+// Notify signals the publisher that the blockchain was changed. It never blocks.
+func (p *Publisher) Notify() {
+	select {
+	case p.notify <- struct{}{}:
+	default: // Notification is already pending.
+	}
+}
+
+// Run processes the notifications until the context is done.
+func (p *Publisher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			p.closeSubscriptions(ctx.Err())
+			return
+		case <-p.notify:
+			if err := p.update(); err != nil {
+				zap.S().Errorf("Failed to publish blockchain updates: %v", err)
+			}
+		}
+	}
+}
+
+func (p *Publisher) update() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for more := true; more; {
+		_, err := p.st.MapR(func(info state.StateInfo) (interface{}, error) {
+			var pErr error
+			more, pErr = p.process(info)
+			return nil, pErr
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Publisher) top() proto.Height {
+	return p.bottom + proto.Height(len(p.history)) - 1
+}
+
+func (p *Publisher) entry(height proto.Height) *blockEntry {
+	return &p.history[height-p.bottom]
+}
+
+// process publishes the difference between the last published blockchain and the given state.
+// It returns true if not all the new blocks were processed because of the limit.
+func (p *Publisher) process(info state.StateInfo) (bool, error) {
+	height, err := info.Height()
+	if err != nil {
+		return false, err
+	}
+	h := min(height, p.top())
+	liquid := false
+	for ; h >= p.bottom; h-- {
+		header, hErr := info.HeaderByHeight(h)
+		if hErr != nil {
+			return false, errors.Wrapf(hErr, "failed to get header at height %d", h)
+		}
+		e := p.entry(h)
+		if header.ID == e.id() {
+			break
+		}
+		if header.Parent == e.parent { // The block could be changed by microblocks, it's checked by processLiquid.
+			liquid = true
+			break
+		}
+	}
+	switch {
+	case h >= p.bottom && h < p.top():
+		p.rollbackBlocks(h)
+	case h < p.bottom && (len(p.history) > 0 || h < p.bottom-1):
+		// The fork is deeper than the remembered history, start over from the point before it.
+		zap.S().Warnf("Blockchain updates: rollback deeper than %d blocks detected", historyDepth)
+		if h == 0 {
+			return false, errors.New("genesis block was changed")
+		}
+		h = min(h, p.bottom-1)
+		p.rollbackUnknown(h, info)
+	}
+	if liquid {
+		if lErr := p.processLiquid(info, h); lErr != nil {
+			return false, lErr
+		}
+	}
+	last := min(height, h+maxAppendsPerPass)
+	for n := h + 1; n <= last; n++ {
+		if aErr := p.appendBlock(info, n); aErr != nil {
+			return false, aErr
+		}
+	}
+	return last < height, nil
+}
+
+func (p *Publisher) rollbackBlocks(height proto.Height) {
+	var removed [][]byte
+	var deactivated []int32
+	for n := height + 1; n <= p.top(); n++ {
+		e := p.entry(n)
+		removed = append(removed, e.txIDs...)
+		deactivated = append(deactivated, e.activated...)
+	}
+	target := p.entry(height)
+	p.history = p.history[:height-p.bottom+1]
+	p.broadcast(&events.BlockchainUpdated{
+		Id:     target.id().Bytes(),
+		Height: int32(height),
+		Update: &events.BlockchainUpdated_Rollback_{Rollback: &events.BlockchainUpdated_Rollback{
+			Type:                  events.BlockchainUpdated_Rollback_BLOCK,
+			RemovedTransactionIds: removed,
+			DeactivatedFeatures:   deactivated,
+		}},
+	})
+}
+
+// rollbackUnknown publishes rollback to the block that is not in the history anymore.
+func (p *Publisher) rollbackUnknown(height proto.Height, info state.StateInfo) {
+	var removed [][]byte
+	for i := range p.history {
+		removed = append(removed, p.history[i].txIDs...)
+	}
+	p.history = p.history[:0]
+	p.bottom = height + 1
+	u := &events.BlockchainUpdated{
+		Height: int32(height),
+		Update: &events.BlockchainUpdated_Rollback_{Rollback: &events.BlockchainUpdated_Rollback{
+			Type:                  events.BlockchainUpdated_Rollback_BLOCK,
+			RemovedTransactionIds: removed,
+		}},
+	}
+	if id, err := info.HeightToBlockID(height); err == nil {
+		u.Id = id.Bytes()
+	}
+	p.broadcast(u)
+}
+
+// processLiquid publishes the changes of the liquid block at the given height: rollback of microblocks
+// that are not in the block anymore and the transactions appended after the last published microblock.
+// If the block can't be represented as a continuation of the published one, the block itself is
+// rolled back and appended again.
+func (p *Publisher) processLiquid(info state.StateInfo, height proto.Height) error {
+	block, err := info.BlockByHeight(height)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get block at height %d", height)
+	}
+	ids, err := transactionIDs(block.Transactions, p.scheme)
+	if err != nil {
+		return err
+	}
+	e := p.entry(height)
+	common := 0
+	for common < len(ids) && common < len(e.txIDs) && bytes.Equal(ids[common], e.txIDs[common]) {
+		common++
+	}
+	i := len(e.points) - 1
+	for i >= 0 && e.points[i].txCount > common {
+		i--
+	}
+	if i < 0 || (e.points[i].txCount == len(ids) && e.points[i].id != block.BlockID()) {
+		// The key block itself was replaced.
+		if height == p.bottom {
+			p.rollbackUnknown(height-1, info)
+		} else {
+			p.rollbackBlocks(height - 1)
+		}
+		return p.appendBlock(info, height)
+	}
+	if i < len(e.points)-1 {
+		point := e.points[i]
+		removed := slices.Clone(e.txIDs[point.txCount:])
+		e.points = e.points[:i+1]
+		e.txIDs = e.txIDs[:point.txCount]
+		p.broadcast(&events.BlockchainUpdated{
+			Id:     point.id.Bytes(),
+			Height: int32(height),
+			Update: &events.BlockchainUpdated_Rollback_{Rollback: &events.BlockchainUpdated_Rollback{
+				Type:                  events.BlockchainUpdated_Rollback_MICROBLOCK,
+				RemovedTransactionIds: removed,
+			}},
+		})
+	}
+	if len(ids) > len(e.txIDs) {
+		u, mErr := p.microBlockAppend(info, block, height, e.id(), len(e.txIDs))
+		if mErr != nil {
+			return mErr
+		}
+		e.txIDs = ids
+		e.points = append(e.points, microPoint{id: block.BlockID(), txCount: len(ids)})
+		p.broadcast(u)
+	}
+	return nil
+}
+
+func (p *Publisher) appendBlock(info state.StateInfo, height proto.Height) error {
+	block, err := info.BlockByHeight(height)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get block at height %d", height)
+	}
+	u, err := p.blockAppend(info, block, height)
+	if err != nil {
+		return err
+	}
+	e, err := p.newEntry(info, block, height)
+	if err != nil {
+		return err
+	}
+	p.history = append(p.history, e)
+	if len(p.history) > historyDepth {
+		p.history = p.history[1:]
+		p.bottom++
+	}
+	p.broadcast(u)
+	return nil
+}
+
+// BlockUpdate returns the update that appends the block at the given height.
+func (p *Publisher) BlockUpdate(height proto.Height) (*events.BlockchainUpdated, error) {
+	var u *events.BlockchainUpdated
+	_, err := p.st.MapR(func(info state.StateInfo) (interface{}, error) {
+		block, bErr := info.BlockByHeight(height)
+		if bErr != nil {
+			return nil, bErr
+		}
+		var aErr error
+		u, aErr = p.blockAppend(info, block, height)
+		return nil, aErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Subscribe passes to the handler the updates that append blocks starting from the given height and
+// then the updates as they are published. Zero value of `to` means no upper limit of height.
+// It returns when the context is done, the handler returns an error or the height `to` is reached.
+func (p *Publisher) Subscribe(
+	ctx context.Context, from, to proto.Height, handle func(*events.BlockchainUpdated) error,
+) error {
+	if from == 0 {
+		from = 1
+	}
+	next := from
+	// Replay the history without blocking the publisher until the remaining part is small.
+	for {
+		height, err := p.st.Height()
+		if err != nil {
+			return err
+		}
+		if next+maxAppendsPerPass > height {
+			break
+		}
+		for ; next <= height-maxAppendsPerPass; next++ {
+			if to != 0 && next > to {
+				return nil
+			}
+			u, uErr := p.BlockUpdate(next)
+			if uErr != nil {
+				return uErr
+			}
+			if hErr := handle(u); hErr != nil {
+				return hErr
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+	}
+	sub, backlog, err := p.subscribe(next, to)
+	if err != nil {
+		return err
+	}
+	defer p.unsubscribe(sub)
+	for _, u := range backlog {
+		if hErr := handle(u); hErr != nil {
+			return hErr
+		}
+	}
+	if to != 0 && next+proto.Height(len(backlog)) > to {
+		return nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case u, ok := <-sub.updates:
+			if !ok {
+				return sub.err
+			}
+			if to != 0 && proto.Height(u.Height) > to {
+				return nil
+			}
+			if hErr := handle(u); hErr != nil {
+				return hErr
+			}
+		}
+	}
+}
+
+// subscribe catches up the publisher with the state, registers the subscription and returns the updates
+// of blocks starting from the given height up to the last published one.
+func (p *Publisher) subscribe(from, to proto.Height) (*subscription, []*events.BlockchainUpdated, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sub := &subscription{updates: make(chan *events.BlockchainUpdated, subscriptionBufferSize)}
+	var backlog []*events.BlockchainUpdated
+	for more := true; more; {
+		_, err := p.st.MapR(func(info state.StateInfo) (interface{}, error) {
+			var pErr error
+			more, pErr = p.process(info)
+			if pErr != nil || more {
+				return nil, pErr
+			}
+			last := p.top()
+			if to != 0 {
+				last = min(last, to)
+			}
+			for n := from; n <= last; n++ {
+				block, bErr := info.BlockByHeight(n)
+				if bErr != nil {
+					return nil, bErr
+				}
+				u, uErr := p.blockAppend(info, block, n)
+				if uErr != nil {
+					return nil, uErr
+				}
+				backlog = append(backlog, u)
+			}
+			return nil, nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	p.subs[sub] = struct{}{}
+	return sub, backlog, nil
+}
+
+func (p *Publisher) unsubscribe(sub *subscription) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.subs, sub)
+}
+
+func (p *Publisher) broadcast(u *events.BlockchainUpdated) {
+	for sub := range p.subs {
+		select {
+		case sub.updates <- u:
+		default:
+			sub.err = ErrSubscriptionOverflow
+			close(sub.updates)
+			delete(p.subs, sub)
+		}
+	}
+}
+
+func (p *Publisher) closeSubscriptions(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for sub := range p.subs {
+		sub.err = err
+		close(sub.updates)
+		delete(p.subs, sub)
+	}
+}
+
+func (p *Publisher) blockAppend(
+	info state.StateInfo, block *proto.Block, height proto.Height,
+) (*events.BlockchainUpdated, error) {
+	pbBlock, err := block.ToProtobuf(p.scheme)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert block to protobuf")
+	}
+	vrf, err := info.BlockVRF(&block.BlockHeader, height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to calculate VRF of block at height %d", height)
+	}
+	generator, err := proto.NewAddressFromPublicKey(p.scheme, block.GeneratorPublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create generator address")
+	}
+	rewards, err := info.BlockRewards(generator, height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to calculate rewards of block at height %d", height)
+	}
+	total, err := info.TotalWavesAmount(height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get total Waves amount at height %d", height)
+	}
+	activated, err := activatedFeatures(info, height)
+	if err != nil {
+		return nil, err
+	}
+	u, err := p.transactionsAppend(info, block.Transactions, height, 0)
+	if err != nil {
+		return nil, err
+	}
+	rewards = rewards.Sorted()
+	shares := make([]*pb.RewardShare, len(rewards))
+	for i, r := range rewards {
+		shares[i] = &pb.RewardShare{Address: r.Address().Bytes(), Reward: int64(r.Amount())}
+	}
+	u.Body = &events.BlockchainUpdated_Append_Block{Block: &events.BlockchainUpdated_Append_BlockAppend{
+		Block:              pbBlock,
+		UpdatedWavesAmount: int64(total),
+		ActivatedFeatures:  activated,
+		Vrf:                vrf,
+		RewardShares:       shares,
+	}}
+	return &events.BlockchainUpdated{
+		Id:     block.BlockID().Bytes(),
+		Height: int32(height),
+		Update: &events.BlockchainUpdated_Append_{Append: u},
+	}, nil
+}
+
+func (p *Publisher) microBlockAppend(
+	info state.StateInfo, block *proto.Block, height proto.Height, reference proto.BlockID, skip int,
+) (*events.BlockchainUpdated, error) {
+	txs := block.Transactions[skip:]
+	micro, ok := p.micro.GetBlock(block.BlockID())
+	if !ok || len(micro.Transactions) != len(txs) {
+		// Several microblocks were applied at once, or the microblock is not cached anymore.
+		// Signature of such a combined microblock is unknown and left empty.
+		micro = &proto.MicroBlock{
+			VersionField:          byte(block.Version),
+			Reference:             reference,
+			TotalResBlockSigField: block.BlockSignature,
+			TotalBlockID:          block.BlockID(),
+			TransactionCount:      uint32(len(txs)),
+			Transactions:          txs,
+			SenderPK:              block.GeneratorPublicKey,
+		}
+	}
+	pbMicro, err := micro.ToProtobuf(p.scheme)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert microblock to protobuf")
+	}
+	u, err := p.transactionsAppend(info, block.Transactions, height, skip)
+	if err != nil {
+		return nil, err
+	}
+	u.Body = &events.BlockchainUpdated_Append_MicroBlock{MicroBlock: &events.BlockchainUpdated_Append_MicroBlockAppend{
+		MicroBlock:              pbMicro,
+		UpdatedTransactionsRoot: block.TransactionsRoot,
+	}}
+	return &events.BlockchainUpdated{
+		Id:     block.BlockID().Bytes(),
+		Height: int32(height),
+		Update: &events.BlockchainUpdated_Append_{Append: u},
+	}, nil
+}
+
+// transactionsAppend builds the append update for the block's transactions starting from the given index.
+func (p *Publisher) transactionsAppend(
+	info state.StateInfo, txs proto.Transactions, height proto.Height, skip int,
+) (*events.BlockchainUpdated_Append, error) {
+	snapshot, err := info.SnapshotsAtHeight(height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get snapshots at height %d", height)
+	}
+	if len(snapshot.TxSnapshots) != len(txs) {
+		return nil, errors.Errorf("number of snapshots %d doesn't match number of transactions %d at height %d",
+			len(snapshot.TxSnapshots), len(txs), height)
+	}
+	b := newStateUpdateBuilder(p.scheme, info, height)
+	for _, s := range snapshot.TxSnapshots[:skip] {
+		if sErr := b.skip(s); sErr != nil {
+			return nil, sErr
+		}
+	}
+	res := &events.BlockchainUpdated_Append{
+		TransactionIds:          make([][]byte, 0, len(txs)-skip),
+		TransactionsMetadata:    make([]*events.TransactionMetadata, 0, len(txs)-skip),
+		TransactionStateUpdates: make([]*events.StateUpdate, 0, len(txs)-skip),
+		StateUpdate:             &events.StateUpdate{},
+	}
+	for i := skip; i < len(txs); i++ {
+		id, idErr := txs[i].GetID(p.scheme)
+		if idErr != nil {
+			return nil, errors.Wrap(idErr, "failed to get transaction ID")
+		}
+		md, mdErr := transactionMetadata(info, txs[i], p.scheme)
+		if mdErr != nil {
+			return nil, mdErr
+		}
+		su, suErr := b.build(id, snapshot.TxSnapshots[i])
+		if suErr != nil {
+			return nil, suErr
+		}
+		res.TransactionIds = append(res.TransactionIds, id)
+		res.TransactionsMetadata = append(res.TransactionsMetadata, md)
+		res.TransactionStateUpdates = append(res.TransactionStateUpdates, su)
+	}
+	return res, nil
+}
+
+func transactionIDs(txs proto.Transactions, scheme proto.Scheme) ([][]byte, error) {
+	ids := make([][]byte, len(txs))
+	for i, tx := range txs {
+		id, err := tx.GetID(scheme)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get transaction ID")
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+func activatedFeatures(info state.StateInfo, height proto.Height) ([]int32, error) {
+	features, err := info.AllFeatures()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get features")
+	}
+	var res []int32
+	for _, f := range features {
+		activated, aErr := info.IsActiveAtHeight(f, height)
+		if aErr != nil {
+			return nil, errors.Wrapf(aErr, "failed to check activation of feature %d", f)
+		}
+		if !activated {
+			continue
+		}
+		ah, ahErr := info.ActivationHeight(f)
+		if ahErr != nil {
+			return nil, errors.Wrapf(ahErr, "failed to get activation height of feature %d", f)
+		}
+		if ah == height {
+			res = append(res, int32(f))
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,339 @@
+package blockchain_updates
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/events"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+// chain keeps the blocks and snapshots of a blockchain, the other state methods are not used by the publisher.
+type chain struct {
+	state.StateInfo
+
+	blocks    []*proto.Block
+	snapshots []proto.BlockSnapshot
+}
+
+func (s *chain) Height() (proto.Height, error) {
+	return proto.Height(len(s.blocks)), nil
+}
+
+func (s *chain) BlockByHeight(height proto.Height) (*proto.Block, error) {
+	if height == 0 || height > proto.Height(len(s.blocks)) {
+		return nil, state.NewStateError(state.NotFoundError, proto.ErrNotFound)
+	}
+	return s.blocks[height-1], nil
+}
+
+func (s *chain) HeaderByHeight(height proto.Height) (*proto.BlockHeader, error) {
+	b, err := s.BlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	return &b.BlockHeader, nil
+}
+
+func (s *chain) HeightToBlockID(height proto.Height) (proto.BlockID, error) {
+	b, err := s.BlockByHeight(height)
+	if err != nil {
+		return proto.BlockID{}, err
+	}
+	return b.BlockID(), nil
+}
+
+func (s *chain) SnapshotsAtHeight(height proto.Height) (proto.BlockSnapshot, error) {
+	return s.snapshots[height-1], nil
+}
+
+func (*chain) BlockVRF(*proto.BlockHeader, proto.Height) ([]byte, error) {
+	return nil, nil
+}
+
+func (*chain) BlockRewards(proto.WavesAddress, proto.Height) (proto.Rewards, error) {
+	return nil, nil
+}
+
+func (*chain) TotalWavesAmount(proto.Height) (uint64, error) {
+	return 0, nil
+}
+
+func (*chain) AllFeatures() ([]int16, error) {
+	return nil, nil
+}
+
+func (*chain) FullAssetInfo(proto.AssetID) (*proto.FullAssetInfo, error) {
+	return nil, errors.New("not found")
+}
+
+func (*chain) WavesBalanceAtHeight(proto.Recipient, proto.Height) (uint64, error) {
+	return 0, nil
+}
+
+// chainStub is the chain modified by tests concurrently with the publisher.
+type chainStub struct {
+	*chain
+	mu sync.Mutex
+}
+
+func (s *chainStub) push(block *proto.Block, snapshot proto.BlockSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks = append(s.blocks, block)
+	s.snapshots = append(s.snapshots, snapshot)
+}
+
+// replaceTop replaces the last block, it emulates appending of microblocks or the key block replacement.
+func (s *chainStub) replaceTop(block *proto.Block, snapshot proto.BlockSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks[len(s.blocks)-1] = block
+	s.snapshots[len(s.snapshots)-1] = snapshot
+}
+
+func (s *chainStub) rollback(height proto.Height) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks = s.blocks[:height]
+	s.snapshots = s.snapshots[:height]
+}
+
+func (s *chainStub) MapR(f func(state.StateInfo) (interface{}, error)) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return f(s.chain)
+}
+
+func (s *chainStub) Height() (proto.Height, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.chain.Height()
+}
+
+type noMicroBlocks struct{}
+
+func (noMicroBlocks) GetBlock(proto.BlockID) (*proto.MicroBlock, bool) {
+	return nil, false
+}
+
+type chainBuilder struct {
+	t      *testing.T
+	sk     crypto.SecretKey
+	pk     crypto.PublicKey
+	sender proto.WavesAddress
+	ts     uint64
+}
+
+func newChainBuilder(t *testing.T) *chainBuilder {
+	sk, pk, err := crypto.GenerateKeyPair([]byte("blockchain updates"))
+	require.NoError(t, err)
+	sender, err := proto.NewAddressFromPublicKey(proto.TestNetScheme, pk)
+	require.NoError(t, err)
+	return &chainBuilder{t: t, sk: sk, pk: pk, sender: sender, ts: 1700000000000}
+}
+
+// transfer returns a transfer with a unique ID and the snapshot setting the sender's balance.
+func (c *chainBuilder) transfer(balance uint64) (proto.Transaction, []proto.AtomicSnapshot) {
+	c.ts++
+	tx := proto.NewUnsignedTransferWithProofs(3, c.pk, proto.NewOptionalAssetWaves(), proto.NewOptionalAssetWaves(),
+		c.ts, 1, 100000, proto.NewRecipientFromAddress(c.sender), nil)
+	require.NoError(c.t, tx.Sign(proto.TestNetScheme, c.sk))
+	return tx, []proto.AtomicSnapshot{proto.WavesBalanceSnapshot{Address: c.sender, Balance: balance}}
+}
+
+func (c *chainBuilder) block(
+	parent proto.BlockID, id byte, txs ...proto.Transaction,
+) *proto.Block {
+	return &proto.Block{
+		BlockHeader: proto.BlockHeader{
+			Version:            proto.ProtobufBlockVersion,
+			Timestamp:          c.ts,
+			Parent:             parent,
+			GeneratorPublicKey: c.pk,
+			TransactionCount:   len(txs),
+			ID:                 proto.NewBlockIDFromDigest(crypto.Digest{id}),
+		},
+		Transactions: txs,
+	}
+}
+
+func txID(t *testing.T, tx proto.Transaction) []byte {
+	id, err := tx.GetID(proto.TestNetScheme)
+	require.NoError(t, err)
+	return id
+}
+
+// newTestChain returns the chain of blocks with IDs 1..n having a transaction each.
+func newTestChain(t *testing.T, n int) (*chainStub, *chainBuilder, []proto.Transaction) {
+	c := newChainBuilder(t)
+	st := &chainStub{chain: &chain{}}
+	parent := proto.BlockID{}
+	txs := make([]proto.Transaction, n)
+	for i := range n {
+		tx, s := c.transfer(uint64(i + 1))
+		b := c.block(parent, byte(i+1), tx)
+		st.push(b, proto.BlockSnapshot{TxSnapshots: [][]proto.AtomicSnapshot{s}})
+		txs[i] = tx
+		parent = b.BlockID()
+	}
+	return st, c, txs
+}
+
+func receive(t *testing.T, sub *subscription) *events.BlockchainUpdated {
+	select {
+	case u := <-sub.updates:
+		return u
+	default:
+		require.FailNow(t, "no update published")
+		return nil
+	}
+}
+
+func requireNoUpdates(t *testing.T, sub *subscription) {
+	select {
+	case u := <-sub.updates:
+		require.FailNow(t, "unexpected update", "update at height %d", u.Height)
+	default:
+	}
+}
+
+func TestPublisherBlockRollback(t *testing.T) {
+	st, c, txs := newTestChain(t, 4)
+	p, err := NewPublisher(st, noMicroBlocks{}, proto.TestNetScheme)
+	require.NoError(t, err)
+	sub, backlog, err := p.subscribe(5, 0)
+	require.NoError(t, err)
+	assert.Empty(t, backlog)
+
+	st.rollback(2)
+	require.NoError(t, p.update())
+
+	u := receive(t, sub)
+	assert.Equal(t, int32(2), u.Height)
+	assert.Equal(t, st.blocks[1].BlockID().Bytes(), u.Id)
+	rb := u.GetRollback()
+	require.NotNil(t, rb)
+	assert.Equal(t, events.BlockchainUpdated_Rollback_BLOCK, rb.Type)
+	assert.Equal(t, [][]byte{txID(t, txs[2]), txID(t, txs[3])}, rb.RemovedTransactionIds)
+	requireNoUpdates(t, sub)
+
+	tx, s := c.transfer(10)
+	fork := c.block(st.blocks[1].BlockID(), 30, tx)
+	st.push(fork, proto.BlockSnapshot{TxSnapshots: [][]proto.AtomicSnapshot{s}})
+	require.NoError(t, p.update())
+
+	u = receive(t, sub)
+	assert.Equal(t, int32(3), u.Height)
+	assert.Equal(t, fork.BlockID().Bytes(), u.Id)
+	a := u.GetAppend()
+	require.NotNil(t, a)
+	require.NotNil(t, a.GetBlock())
+	assert.Equal(t, [][]byte{txID(t, tx)}, a.TransactionIds)
+	requireNoUpdates(t, sub)
+}
+
+func TestPublisherMicroBlocks(t *testing.T) {
+	st, c, txs := newTestChain(t, 2)
+	p, err := NewPublisher(st, noMicroBlocks{}, proto.TestNetScheme)
+	require.NoError(t, err)
+	sub, _, err := p.subscribe(3, 0)
+	require.NoError(t, err)
+	key := st.blocks[1]
+
+	// The microblock with a new transaction is appended to the key block.
+	tx1, s1 := c.transfer(20)
+	liquid := c.block(key.Parent, 21, txs[1], tx1)
+	snapshot := proto.BlockSnapshot{TxSnapshots: [][]proto.AtomicSnapshot{st.snapshots[1].TxSnapshots[0], s1}}
+	st.replaceTop(liquid, snapshot)
+	require.NoError(t, p.update())
+
+	u := receive(t, sub)
+	assert.Equal(t, int32(2), u.Height)
+	assert.Equal(t, liquid.BlockID().Bytes(), u.Id)
+	a := u.GetAppend()
+	require.NotNil(t, a)
+	mb := a.GetMicroBlock()
+	require.NotNil(t, mb)
+	assert.Equal(t, key.BlockID().Bytes(), mb.MicroBlock.MicroBlock.Reference)
+	assert.Equal(t, [][]byte{txID(t, tx1)}, a.TransactionIds)
+	require.Len(t, a.TransactionStateUpdates, 1)
+	require.Len(t, a.TransactionStateUpdates[0].Balances, 1)
+	// The balance before the microblock is taken from the key block's transaction.
+	assert.Equal(t, int64(2), a.TransactionStateUpdates[0].Balances[0].AmountBefore)
+	assert.Equal(t, int64(20), a.TransactionStateUpdates[0].Balances[0].AmountAfter.Amount)
+
+	// The microblock is replaced by another one.
+	tx2, s2 := c.transfer(30)
+	liquid = c.block(key.Parent, 22, txs[1], tx2)
+	snapshot = proto.BlockSnapshot{TxSnapshots: [][]proto.AtomicSnapshot{st.snapshots[1].TxSnapshots[0], s2}}
+	st.replaceTop(liquid, snapshot)
+	require.NoError(t, p.update())
+
+	u = receive(t, sub)
+	assert.Equal(t, key.BlockID().Bytes(), u.Id)
+	rb := u.GetRollback()
+	require.NotNil(t, rb)
+	assert.Equal(t, events.BlockchainUpdated_Rollback_MICROBLOCK, rb.Type)
+	assert.Equal(t, [][]byte{txID(t, tx1)}, rb.RemovedTransactionIds)
+
+	u = receive(t, sub)
+	assert.Equal(t, liquid.BlockID().Bytes(), u.Id)
+	require.NotNil(t, u.GetAppend().GetMicroBlock())
+	assert.Equal(t, [][]byte{txID(t, tx2)}, u.GetAppend().TransactionIds)
+
+	// The next key block is appended on top of the liquid block.
+	tx3, s3 := c.transfer(40)
+	next := c.block(liquid.BlockID(), 3, tx3)
+	st.push(next, proto.BlockSnapshot{TxSnapshots: [][]proto.AtomicSnapshot{s3}})
+	require.NoError(t, p.update())
+
+	u = receive(t, sub)
+	assert.Equal(t, int32(3), u.Height)
+	require.NotNil(t, u.GetAppend().GetBlock())
+	requireNoUpdates(t, sub)
+}
+
+func TestPublisherSubscribe(t *testing.T) {
+	st, c, _ := newTestChain(t, 3)
+	p, err := NewPublisher(st, noMicroBlocks{}, proto.TestNetScheme)
+	require.NoError(t, err)
+
+	var heights []int32
+	err = p.Subscribe(context.Background(), 2, 3, func(u *events.BlockchainUpdated) error {
+		heights = append(heights, u.Height)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int32{2, 3}, heights)
+
+	// Without the upper limit the subscription receives the published updates.
+	errStop := errors.New("stop")
+	heights = heights[:0]
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Subscribe(context.Background(), 3, 0, func(u *events.BlockchainUpdated) error {
+			heights = append(heights, u.Height)
+			if u.Height == 4 {
+				return errStop
+			}
+			return nil
+		})
+	}()
+	tx, s := c.transfer(10)
+	st.push(c.block(st.blocks[2].BlockID(), 4, tx), proto.BlockSnapshot{TxSnapshots: [][]proto.AtomicSnapshot{s}})
+	require.NoError(t, p.update())
+	assert.ErrorIs(t, <-done, errStop)
+	assert.Equal(t, []int32{3, 4}, heights)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, p.Subscribe(ctx, 4, 0, func(*events.BlockchainUpdated) error { return nil }), context.Canceled)
+}
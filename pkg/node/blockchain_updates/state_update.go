@@ -0,0 +1,415 @@
+package blockchain_updates
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	pb "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves"
+	"github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/events"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+// stateProvider is the part of state used to fill "before" values of the block's updates.
+type stateProvider interface {
+	FullAssetInfo(assetID proto.AssetID) (*proto.FullAssetInfo, error)
+	WavesBalanceAtHeight(account proto.Recipient, height proto.Height) (uint64, error)
+	AssetBalanceAtHeight(account proto.Recipient, assetID proto.AssetID, height proto.Height) (uint64, error)
+	LeaseBalanceAtHeight(account proto.Recipient, height proto.Height) (int64, int64, error)
+	RetrieveEntryAtHeight(account proto.Recipient, key string, height proto.Height) (proto.DataEntry, error)
+	ScriptBytesByAccountAtHeight(account proto.Recipient, height proto.Height) (proto.Script, error)
+}
+
+type balanceKey struct {
+	address proto.WavesAddress
+	asset   crypto.Digest
+	waves   bool
+}
+
+type leaseBalance struct {
+	in  int64
+	out int64
+}
+
+type dataKey struct {
+	address proto.WavesAddress
+	key     string
+}
+
+type assetChange struct {
+	before *events.StateUpdate_AssetDetails
+	after  *events.StateUpdate_AssetDetails
+}
+
+// stateUpdateBuilder converts transaction snapshots of a block into StateUpdate messages.
+// Values "before" of balances, leases, data entries and account scripts are read from state at the height
+// preceding the block for the first change of a key in the block and taken from the previous transactions
+// of the block afterwards. If the state doesn't keep the history that deep, the value is left empty.
+// Asset details have no history in state, so the details not present in snapshots are taken from the
+// current state and the "before" value of the first change is left empty.
+type stateUpdateBuilder struct {
+	scheme proto.Scheme
+	state  stateProvider
+	height proto.Height // Height of the block.
+
+	balances map[balanceKey]int64
+	leases   map[proto.WavesAddress]leaseBalance
+	data     map[dataKey]*pb.DataEntry
+	scripts  map[proto.WavesAddress][]byte
+	details  map[crypto.Digest]*events.StateUpdate_AssetDetails
+
+	txID   []byte
+	update *events.StateUpdate
+	// Assets changed by the current transaction in order of appearance.
+	changes    []assetChange
+	changesIdx map[crypto.Digest]int
+}
+
+func newStateUpdateBuilder(scheme proto.Scheme, st stateProvider, height proto.Height) *stateUpdateBuilder {
+	return &stateUpdateBuilder{
+		scheme:     scheme,
+		state:      st,
+		height:     height,
+		balances:   make(map[balanceKey]int64),
+		leases:     make(map[proto.WavesAddress]leaseBalance),
+		data:       make(map[dataKey]*pb.DataEntry),
+		scripts:    make(map[proto.WavesAddress][]byte),
+		details:    make(map[crypto.Digest]*events.StateUpdate_AssetDetails),
+		changesIdx: make(map[crypto.Digest]int),
+	}
+}
+
+// build converts the snapshot of the transaction with the given ID into StateUpdate.
+func (b *stateUpdateBuilder) build(txID []byte, snapshot []proto.AtomicSnapshot) (*events.StateUpdate, error) {
+	b.txID = txID
+	b.update = &events.StateUpdate{}
+	b.changes = b.changes[:0]
+	clear(b.changesIdx)
+	for _, s := range snapshot {
+		if err := s.Apply(b); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert snapshot of transaction '%s'", proto.B58Bytes(txID))
+		}
+	}
+	for _, c := range b.changes {
+		b.update.Assets = append(b.update.Assets, &events.StateUpdate_AssetStateUpdate{
+			Before: c.before,
+			After:  c.after,
+		})
+	}
+	res := b.update
+	b.update = nil
+	return res, nil
+}
+
+// skip remembers the values from the snapshot without producing an update.
+// It is used to learn "before" values from the transactions preceding a microblock.
+func (b *stateUpdateBuilder) skip(snapshot []proto.AtomicSnapshot) error {
+	_, err := b.build(nil, snapshot)
+	return err
+}
+
+// assetDetails returns the mutable details of the asset changed by the current transaction.
+func (b *stateUpdateBuilder) assetDetails(id crypto.Digest) *events.StateUpdate_AssetDetails {
+	if i, ok := b.changesIdx[id]; ok {
+		return b.changes[i].after
+	}
+	before := b.details[id]
+	var after *events.StateUpdate_AssetDetails
+	if before != nil {
+		after = copyAssetDetails(before)
+	} else {
+		after = b.loadAssetDetails(id)
+	}
+	b.details[id] = after
+	b.changesIdx[id] = len(b.changes)
+	b.changes = append(b.changes, assetChange{before: before, after: after})
+	return after
+}
+
+func (b *stateUpdateBuilder) loadAssetDetails(id crypto.Digest) *events.StateUpdate_AssetDetails {
+	d := &events.StateUpdate_AssetDetails{AssetId: id.Bytes()}
+	info, err := b.state.FullAssetInfo(proto.AssetIDFromDigest(id))
+	if err != nil {
+		// Asset may be absent in state if it was rolled back, the details are filled from snapshots.
+		return d
+	}
+	d.Issuer = info.IssuerPublicKey.Bytes()
+	d.Decimals = int32(info.Decimals)
+	d.Name = info.Name
+	d.Description = info.Description
+	d.Reissuable = info.Reissuable
+	d.Volume = int64(info.Quantity)
+	d.Sponsorship = int64(info.SponsorshipCost)
+	d.IssueHeight = int32(info.IssueHeight)
+	if len(info.ScriptInfo.Bytes) > 0 {
+		d.ScriptInfo = &events.StateUpdate_AssetDetails_AssetScriptInfo{
+			Script:     info.ScriptInfo.Bytes,
+			Complexity: int64(info.ScriptInfo.Complexity),
+		}
+	}
+	return d
+}
+
+func copyAssetDetails(d *events.StateUpdate_AssetDetails) *events.StateUpdate_AssetDetails {
+	return &events.StateUpdate_AssetDetails{
+		AssetId:         d.AssetId,
+		Issuer:          d.Issuer,
+		Decimals:        d.Decimals,
+		Name:            d.Name,
+		Description:     d.Description,
+		Reissuable:      d.Reissuable,
+		Volume:          d.Volume,
+		ScriptInfo:      d.ScriptInfo, // script info is never modified in place
+		Sponsorship:     d.Sponsorship,
+		Nft:             d.Nft,
+		LastUpdated:     d.LastUpdated,
+		SequenceInBlock: d.SequenceInBlock,
+		IssueHeight:     d.IssueHeight,
+		SafeVolume:      d.SafeVolume,
+	}
+}
+
+// previousHeight returns the height to read "before" values from, false means there is no such height.
+func (b *stateUpdateBuilder) previousHeight() (proto.Height, bool) {
+	return b.height - 1, b.height > 1
+}
+
+func (b *stateUpdateBuilder) balanceBefore(key balanceKey) (int64, error) {
+	if v, ok := b.balances[key]; ok {
+		return v, nil
+	}
+	h, ok := b.previousHeight()
+	if !ok {
+		return 0, nil
+	}
+	rcp := proto.NewRecipientFromAddress(key.address)
+	var (
+		balance uint64
+		err     error
+	)
+	if key.waves {
+		balance, err = b.state.WavesBalanceAtHeight(rcp, h)
+	} else {
+		balance, err = b.state.AssetBalanceAtHeight(rcp, proto.AssetIDFromDigest(key.asset), h)
+	}
+	switch {
+	case state.IsInvalidInput(err):
+		return 0, nil // The history is not available that deep.
+	case err != nil:
+		return 0, errors.Wrapf(err, "failed to get balance of '%s' at height %d", key.address, h)
+	}
+	return int64(balance), nil
+}
+
+func (b *stateUpdateBuilder) appendBalance(key balanceKey, amount int64) error {
+	before, err := b.balanceBefore(key)
+	if err != nil {
+		return err
+	}
+	b.balances[key] = amount
+	var assetID []byte
+	if !key.waves {
+		assetID = key.asset.Bytes()
+	}
+	b.update.Balances = append(b.update.Balances, &events.StateUpdate_BalanceUpdate{
+		Address:      key.address.Bytes(),
+		AmountAfter:  &pb.Amount{AssetId: assetID, Amount: amount},
+		AmountBefore: before,
+	})
+	return nil
+}
+
+func (b *stateUpdateBuilder) ApplyWavesBalance(snapshot proto.WavesBalanceSnapshot) error {
+	return b.appendBalance(balanceKey{address: snapshot.Address, waves: true}, int64(snapshot.Balance))
+}
+
+func (b *stateUpdateBuilder) ApplyAssetBalance(snapshot proto.AssetBalanceSnapshot) error {
+	return b.appendBalance(balanceKey{address: snapshot.Address, asset: snapshot.AssetID}, int64(snapshot.Balance))
+}
+
+func (b *stateUpdateBuilder) leaseBefore(addr proto.WavesAddress) (leaseBalance, error) {
+	if v, ok := b.leases[addr]; ok {
+		return v, nil
+	}
+	h, ok := b.previousHeight()
+	if !ok {
+		return leaseBalance{}, nil
+	}
+	in, out, err := b.state.LeaseBalanceAtHeight(proto.NewRecipientFromAddress(addr), h)
+	switch {
+	case state.IsInvalidInput(err):
+		return leaseBalance{}, nil // The history is not available that deep.
+	case err != nil:
+		return leaseBalance{}, errors.Wrapf(err, "failed to get lease balance of '%s' at height %d", addr, h)
+	}
+	return leaseBalance{in: in, out: out}, nil
+}
+
+func (b *stateUpdateBuilder) ApplyLeaseBalance(snapshot proto.LeaseBalanceSnapshot) error {
+	before, err := b.leaseBefore(snapshot.Address)
+	if err != nil {
+		return err
+	}
+	after := leaseBalance{in: int64(snapshot.LeaseIn), out: int64(snapshot.LeaseOut)}
+	b.leases[snapshot.Address] = after
+	b.update.LeasingForAddress = append(b.update.LeasingForAddress, &events.StateUpdate_LeasingUpdate{
+		Address:   snapshot.Address.Bytes(),
+		InAfter:   after.in,
+		OutAfter:  after.out,
+		InBefore:  before.in,
+		OutBefore: before.out,
+	})
+	return nil
+}
+
+func (b *stateUpdateBuilder) ApplyAlias(proto.AliasSnapshot) error {
+	return nil // Created aliases are not reported in blockchain updates.
+}
+
+func (b *stateUpdateBuilder) ApplyNewAsset(snapshot proto.NewAssetSnapshot) error {
+	d := b.assetDetails(snapshot.AssetID)
+	d.Issuer = snapshot.IssuerPublicKey.Bytes()
+	d.Decimals = int32(snapshot.Decimals)
+	d.Nft = snapshot.IsNFT
+	return nil
+}
+
+func (b *stateUpdateBuilder) ApplyAssetDescription(snapshot proto.AssetDescriptionSnapshot) error {
+	d := b.assetDetails(snapshot.AssetID)
+	d.Name = snapshot.AssetName
+	d.Description = snapshot.AssetDescription
+	return nil
+}
+
+func (b *stateUpdateBuilder) ApplyAssetVolume(snapshot proto.AssetVolumeSnapshot) error {
+	d := b.assetDetails(snapshot.AssetID)
+	d.Reissuable = snapshot.IsReissuable
+	d.Volume = snapshot.TotalQuantity.Int64()
+	d.SafeVolume = snapshot.TotalQuantity.Bytes()
+	return nil
+}
+
+func (b *stateUpdateBuilder) ApplyAssetScript(snapshot proto.AssetScriptSnapshot) error {
+	d := b.assetDetails(snapshot.AssetID)
+	if len(snapshot.Script) == 0 {
+		d.ScriptInfo = nil
+		return nil
+	}
+	d.ScriptInfo = &events.StateUpdate_AssetDetails_AssetScriptInfo{Script: snapshot.Script}
+	return nil
+}
+
+func (b *stateUpdateBuilder) ApplySponsorship(snapshot proto.SponsorshipSnapshot) error {
+	d := b.assetDetails(snapshot.AssetID)
+	d.Sponsorship = int64(snapshot.MinSponsoredFee)
+	return nil
+}
+
+func (b *stateUpdateBuilder) ApplyAccountScript(snapshot proto.AccountScriptSnapshot) error {
+	addr, err := proto.NewAddressFromPublicKey(b.scheme, snapshot.SenderPublicKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to create address from public key")
+	}
+	before, err := b.scriptBefore(addr)
+	if err != nil {
+		return err
+	}
+	b.scripts[addr] = snapshot.Script
+	b.update.Scripts = append(b.update.Scripts, &events.StateUpdate_ScriptUpdate{
+		Address: addr.Bytes(),
+		Before:  before,
+		After:   snapshot.Script,
+	})
+	return nil
+}
+
+func (b *stateUpdateBuilder) scriptBefore(addr proto.WavesAddress) ([]byte, error) {
+	if v, ok := b.scripts[addr]; ok {
+		return v, nil
+	}
+	h, ok := b.previousHeight()
+	if !ok {
+		return nil, nil
+	}
+	script, err := b.state.ScriptBytesByAccountAtHeight(proto.NewRecipientFromAddress(addr), h)
+	switch {
+	case state.IsInvalidInput(err):
+		return nil, nil // The history is not available that deep.
+	case err != nil:
+		return nil, errors.Wrapf(err, "failed to get script of '%s' at height %d", addr, h)
+	}
+	if len(script) == 0 {
+		return nil, nil
+	}
+	return script, nil
+}
+
+func (b *stateUpdateBuilder) ApplyFilledVolumeAndFee(proto.FilledVolumeFeeSnapshot) error {
+	return nil // Orders volumes are not reported in blockchain updates.
+}
+
+func (b *stateUpdateBuilder) ApplyDataEntries(snapshot proto.DataEntriesSnapshot) error {
+	for _, e := range snapshot.DataEntries {
+		k := dataKey{address: snapshot.Address, key: e.GetKey()}
+		after := e.ToProtobuf()
+		before, err := b.dataEntryBefore(k)
+		if err != nil {
+			return err
+		}
+		b.data[k] = after
+		b.update.DataEntries = append(b.update.DataEntries, &events.StateUpdate_DataEntryUpdate{
+			Address:         snapshot.Address.Bytes(),
+			DataEntry:       after,
+			DataEntryBefore: before,
+		})
+	}
+	return nil
+}
+
+func (b *stateUpdateBuilder) dataEntryBefore(k dataKey) (*pb.DataEntry, error) {
+	if v, ok := b.data[k]; ok {
+		return v, nil
+	}
+	h, ok := b.previousHeight()
+	if !ok {
+		return nil, nil
+	}
+	e, err := b.state.RetrieveEntryAtHeight(proto.NewRecipientFromAddress(k.address), k.key, h)
+	switch {
+	case state.IsInvalidInput(err):
+		return nil, nil // The history is not available that deep.
+	case state.IsNotFound(err):
+		return nil, nil // The entry was absent or removed.
+	case err != nil:
+		return nil, errors.Wrapf(err, "failed to get data entry '%s' of '%s' at height %d", k.key, k.address, h)
+	}
+	return e.ToProtobuf(), nil
+}
+
+func (b *stateUpdateBuilder) ApplyNewLease(snapshot proto.NewLeaseSnapshot) error {
+	sender, err := proto.NewAddressFromPublicKey(b.scheme, snapshot.SenderPK)
+	if err != nil {
+		return errors.Wrap(err, "failed to create address from public key")
+	}
+	b.update.IndividualLeases = append(b.update.IndividualLeases, &events.StateUpdate_LeaseUpdate{
+		LeaseId:             snapshot.LeaseID.Bytes(),
+		StatusAfter:         events.StateUpdate_LeaseUpdate_ACTIVE,
+		Amount:              int64(snapshot.Amount),
+		Sender:              sender.Bytes(),
+		Recipient:           snapshot.RecipientAddr.Bytes(),
+		OriginTransactionId: b.txID,
+	})
+	return nil
+}
+
+func (b *stateUpdateBuilder) ApplyCancelledLease(snapshot proto.CancelledLeaseSnapshot) error {
+	b.update.IndividualLeases = append(b.update.IndividualLeases, &events.StateUpdate_LeaseUpdate{
+		LeaseId:     snapshot.LeaseID.Bytes(),
+		StatusAfter: events.StateUpdate_LeaseUpdate_INACTIVE,
+	})
+	return nil
+}
+
+func (b *stateUpdateBuilder) ApplyTransactionsStatus(proto.TransactionStatusSnapshot) error {
+	return nil // Transaction status is reported as a part of transaction metadata.
+}
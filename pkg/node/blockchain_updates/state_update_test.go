@@ -0,0 +1,166 @@
+package blockchain_updates
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+// historyStub returns the values stored at the height `at`, the values at other heights are unavailable.
+type historyStub struct {
+	at      proto.Height
+	waves   map[proto.WavesAddress]uint64
+	assets  map[proto.WavesAddress]uint64
+	leases  map[proto.WavesAddress]leaseBalance
+	data    map[dataKey]proto.DataEntry
+	scripts map[proto.WavesAddress]proto.Script
+}
+
+func (s *historyStub) check(height proto.Height) error {
+	if height != s.at {
+		return state.NewStateError(state.InvalidInputError, errors.Errorf("unavailable height %d", height))
+	}
+	return nil
+}
+
+func (*historyStub) FullAssetInfo(proto.AssetID) (*proto.FullAssetInfo, error) {
+	return nil, errors.New("not found")
+}
+
+func (s *historyStub) WavesBalanceAtHeight(account proto.Recipient, height proto.Height) (uint64, error) {
+	return s.waves[*account.Address()], s.check(height)
+}
+
+func (s *historyStub) AssetBalanceAtHeight(
+	account proto.Recipient, _ proto.AssetID, height proto.Height,
+) (uint64, error) {
+	return s.assets[*account.Address()], s.check(height)
+}
+
+func (s *historyStub) LeaseBalanceAtHeight(account proto.Recipient, height proto.Height) (int64, int64, error) {
+	l := s.leases[*account.Address()]
+	return l.in, l.out, s.check(height)
+}
+
+func (s *historyStub) RetrieveEntryAtHeight(
+	account proto.Recipient, key string, height proto.Height,
+) (proto.DataEntry, error) {
+	if err := s.check(height); err != nil {
+		return nil, err
+	}
+	e, ok := s.data[dataKey{address: *account.Address(), key: key}]
+	if !ok {
+		return nil, state.NewStateError(state.NotFoundError, proto.ErrNotFound)
+	}
+	return e, nil
+}
+
+func (s *historyStub) ScriptBytesByAccountAtHeight(account proto.Recipient, height proto.Height) (proto.Script, error) {
+	return s.scripts[*account.Address()], s.check(height)
+}
+
+func TestStateUpdateBuilderKeepsValuesBefore(t *testing.T) {
+	addr, err := proto.NewAddressFromString("3PAWwWa6GbwcJaFzwqXQN5KQm7H96Y7SHTQ")
+	require.NoError(t, err)
+	assetID := crypto.MustDigestFromBase58("B5CRhnBGEYaLLqSQy5SmJKzrhqHMRgnKoY38ZHsPovY9")
+	b := newStateUpdateBuilder(proto.MainNetScheme, &historyStub{}, 1)
+
+	first, err := b.build([]byte{1}, []proto.AtomicSnapshot{
+		proto.WavesBalanceSnapshot{Address: addr, Balance: 100},
+		proto.NewAssetSnapshot{AssetID: assetID, Decimals: 2},
+		proto.AssetVolumeSnapshot{AssetID: assetID, TotalQuantity: *big.NewInt(1000), IsReissuable: true},
+		proto.DataEntriesSnapshot{Address: addr, DataEntries: proto.DataEntries{
+			&proto.IntegerDataEntry{Key: "k", Value: 1},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, first.Balances, 1)
+	assert.Equal(t, int64(100), first.Balances[0].AmountAfter.Amount)
+	assert.Equal(t, int64(0), first.Balances[0].AmountBefore)
+	require.Len(t, first.Assets, 1)
+	assert.Nil(t, first.Assets[0].Before)
+	assert.Equal(t, int64(1000), first.Assets[0].After.Volume)
+	assert.Equal(t, int32(2), first.Assets[0].After.Decimals)
+	require.Len(t, first.DataEntries, 1)
+	assert.Nil(t, first.DataEntries[0].DataEntryBefore)
+
+	second, err := b.build([]byte{2}, []proto.AtomicSnapshot{
+		proto.WavesBalanceSnapshot{Address: addr, Balance: 70},
+		proto.AssetVolumeSnapshot{AssetID: assetID, TotalQuantity: *big.NewInt(500), IsReissuable: false},
+		proto.DataEntriesSnapshot{Address: addr, DataEntries: proto.DataEntries{
+			&proto.IntegerDataEntry{Key: "k", Value: 2},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, second.Balances, 1)
+	assert.Equal(t, int64(70), second.Balances[0].AmountAfter.Amount)
+	assert.Equal(t, int64(100), second.Balances[0].AmountBefore)
+	require.Len(t, second.Assets, 1)
+	assert.Equal(t, int64(1000), second.Assets[0].Before.Volume)
+	assert.True(t, second.Assets[0].Before.Reissuable)
+	assert.Equal(t, int64(500), second.Assets[0].After.Volume)
+	assert.False(t, second.Assets[0].After.Reissuable)
+	assert.Equal(t, int32(2), second.Assets[0].After.Decimals)
+	require.Len(t, second.DataEntries, 1)
+	assert.Equal(t, int64(1), second.DataEntries[0].DataEntryBefore.GetIntValue())
+	assert.Equal(t, int64(2), second.DataEntries[0].DataEntry.GetIntValue())
+}
+
+func TestStateUpdateBuilderReadsValuesBeforeBlock(t *testing.T) {
+	pk, err := crypto.NewPublicKeyFromBase58("3ZuWRq1qCjvXsg9rrKMB9bX3AoyYKzMbnJP9hyNVjQZ5")
+	require.NoError(t, err)
+	addr, err := proto.NewAddressFromPublicKey(proto.MainNetScheme, pk)
+	require.NoError(t, err)
+	other, err := proto.NewAddressFromString("3PAWwWa6GbwcJaFzwqXQN5KQm7H96Y7SHTQ")
+	require.NoError(t, err)
+	assetID := crypto.MustDigestFromBase58("B5CRhnBGEYaLLqSQy5SmJKzrhqHMRgnKoY38ZHsPovY9")
+	st := &historyStub{
+		at:      9,
+		waves:   map[proto.WavesAddress]uint64{addr: 500},
+		assets:  map[proto.WavesAddress]uint64{addr: 40},
+		leases:  map[proto.WavesAddress]leaseBalance{addr: {in: 3, out: 4}},
+		data:    map[dataKey]proto.DataEntry{{address: addr, key: "k"}: &proto.StringDataEntry{Key: "k", Value: "v"}},
+		scripts: map[proto.WavesAddress]proto.Script{addr: {1, 2, 3}},
+	}
+	snapshot := []proto.AtomicSnapshot{
+		proto.WavesBalanceSnapshot{Address: addr, Balance: 100},
+		proto.AssetBalanceSnapshot{Address: addr, AssetID: assetID, Balance: 50},
+		proto.LeaseBalanceSnapshot{Address: addr, LeaseIn: 5, LeaseOut: 6},
+		proto.DataEntriesSnapshot{Address: addr, DataEntries: proto.DataEntries{
+			&proto.StringDataEntry{Key: "k", Value: "w"},
+			&proto.StringDataEntry{Key: "absent", Value: "w"},
+		}},
+		proto.AccountScriptSnapshot{SenderPublicKey: pk, Script: proto.Script{4}},
+		proto.WavesBalanceSnapshot{Address: other, Balance: 10},
+	}
+
+	u, err := newStateUpdateBuilder(proto.MainNetScheme, st, 10).build([]byte{1}, snapshot)
+	require.NoError(t, err)
+	require.Len(t, u.Balances, 3)
+	assert.Equal(t, int64(500), u.Balances[0].AmountBefore)
+	assert.Equal(t, int64(40), u.Balances[1].AmountBefore)
+	assert.Equal(t, int64(0), u.Balances[2].AmountBefore)
+	require.Len(t, u.LeasingForAddress, 1)
+	assert.Equal(t, int64(3), u.LeasingForAddress[0].InBefore)
+	assert.Equal(t, int64(4), u.LeasingForAddress[0].OutBefore)
+	require.Len(t, u.DataEntries, 2)
+	assert.Equal(t, "v", u.DataEntries[0].DataEntryBefore.GetStringValue())
+	assert.Nil(t, u.DataEntries[1].DataEntryBefore)
+	require.Len(t, u.Scripts, 1)
+	assert.Equal(t, []byte{1, 2, 3}, u.Scripts[0].Before)
+
+	// The history before the block is unavailable, the values are left empty.
+	u, err = newStateUpdateBuilder(proto.MainNetScheme, st, 20).build([]byte{1}, snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), u.Balances[0].AmountBefore)
+	assert.Equal(t, int64(0), u.LeasingForAddress[0].InBefore)
+	assert.Nil(t, u.DataEntries[0].DataEntryBefore)
+	assert.Nil(t, u.Scripts[0].Before)
+}
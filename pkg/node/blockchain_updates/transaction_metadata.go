@@ -0,0 +1,89 @@
+package blockchain_updates
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/events"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+type aliasResolver interface {
+	AddrByAlias(alias proto.Alias) (proto.WavesAddress, error)
+}
+
+func resolveRecipient(resolver aliasResolver, r proto.Recipient) ([]byte, error) {
+	if addr := r.Address(); addr != nil {
+		return addr.Bytes(), nil
+	}
+	alias := r.Alias()
+	if alias == nil {
+		return nil, errors.New("empty recipient")
+	}
+	addr, err := resolver.AddrByAlias(*alias)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve alias '%s'", alias.String())
+	}
+	return addr.Bytes(), nil
+}
+
+// transactionMetadata returns sender address and resolved recipients of the transaction.
+func transactionMetadata(
+	resolver aliasResolver, tx proto.Transaction, scheme proto.Scheme,
+) (*events.TransactionMetadata, error) {
+	sender, err := tx.GetSender(scheme)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get transaction sender")
+	}
+	res := &events.TransactionMetadata{SenderAddress: sender.Bytes()}
+	switch t := tx.(type) {
+	case *proto.TransferWithSig:
+		return withTransferMetadata(resolver, res, t.Recipient)
+	case *proto.TransferWithProofs:
+		return withTransferMetadata(resolver, res, t.Recipient)
+	case *proto.LeaseWithSig:
+		return withLeaseMetadata(resolver, res, t.Recipient)
+	case *proto.LeaseWithProofs:
+		return withLeaseMetadata(resolver, res, t.Recipient)
+	case *proto.MassTransferWithProofs:
+		recipients := make([][]byte, len(t.Transfers))
+		for i, tr := range t.Transfers {
+			r, rErr := resolveRecipient(resolver, tr.Recipient)
+			if rErr != nil {
+				return nil, rErr
+			}
+			recipients[i] = r
+		}
+		res.Metadata = &events.TransactionMetadata_MassTransfer{
+			MassTransfer: &events.TransactionMetadata_MassTransferMetadata{RecipientsAddresses: recipients},
+		}
+		return res, nil
+	default:
+		return res, nil
+	}
+}
+
+func withTransferMetadata(
+	resolver aliasResolver, md *events.TransactionMetadata, recipient proto.Recipient,
+) (*events.TransactionMetadata, error) {
+	r, err := resolveRecipient(resolver, recipient)
+	if err != nil {
+		return nil, err
+	}
+	md.Metadata = &events.TransactionMetadata_Transfer{
+		Transfer: &events.TransactionMetadata_TransferMetadata{RecipientAddress: r},
+	}
+	return md, nil
+}
+
+func withLeaseMetadata(
+	resolver aliasResolver, md *events.TransactionMetadata, recipient proto.Recipient,
+) (*events.TransactionMetadata, error) {
+	r, err := resolveRecipient(resolver, recipient)
+	if err != nil {
+		return nil, err
+	}
+	md.Metadata = &events.TransactionMetadata_Lease{
+		Lease: &events.TransactionMetadata_LeaseMetadata{RecipientAddress: r},
+	}
+	return md, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/qmuntal/stateless"
 
+	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/libs/microblock_cache"
 	"github.com/wavesplatform/gowaves/pkg/miner"
 	"github.com/wavesplatform/gowaves/pkg/miner/utxpool"
@@ -66,6 +67,7 @@ type BaseInfo struct {
 	scheduler types.Scheduler
 
 	microMiner         *miner.MicroMiner
+	signer             types.Signer
 	MicroBlockCache    services.MicroBlockCache
 	MicroBlockInvCache services.MicroBlockInvCache
 	microblockInterval time.Duration
@@ -168,6 +170,7 @@ func NewFSM(
 		scheduler: services.Scheduler,
 
 		microMiner: miner.NewMicroMiner(services),
+		signer:     services.Wallet,
 
 		MicroBlockCache:    services.MicroBlockCache,
 		MicroBlockInvCache: microblock_cache.NewMicroblockInvCache(),
@@ -248,11 +251,11 @@ func (f *FSM) Task(task tasks.AsyncTask) (Async, error) {
 func (f *FSM) MinedBlock(
 	block *proto.Block,
 	limits proto.MiningLimits,
-	keyPair proto.KeyPair,
+	pk crypto.PublicKey,
 	vrf []byte,
 ) (Async, error) {
 	asyncRes := &Async{}
-	err := f.fsm.Fire(MinedBlockEvent, asyncRes, block, limits, keyPair, vrf)
+	err := f.fsm.Fire(MinedBlockEvent, asyncRes, block, limits, pk, vrf)
 	return *asyncRes, err
 }
 
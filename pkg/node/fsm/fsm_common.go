@@ -11,10 +11,14 @@ import (
 	"github.com/qmuntal/stateless"
 	"go.uber.org/zap"
 
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/errs"
 	"github.com/wavesplatform/gowaves/pkg/libs/signatures"
 	"github.com/wavesplatform/gowaves/pkg/logging"
+	"github.com/wavesplatform/gowaves/pkg/miner/utxpool"
 	"github.com/wavesplatform/gowaves/pkg/node/fsm/sync_internal"
 	"github.com/wavesplatform/gowaves/pkg/node/fsm/tasks"
+	"github.com/wavesplatform/gowaves/pkg/node/peers/storage"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer/extension"
 	"github.com/wavesplatform/gowaves/pkg/proto"
@@ -47,7 +51,7 @@ func eventArgsTypes(event stateless.Trigger) []reflect.Type {
 	case MinedBlockEvent:
 		return []reflect.Type{
 			reflect.TypeOf(&Async{}), reflect.TypeOf(&proto.Block{}), reflect.TypeOf(proto.MiningLimits{}),
-			reflect.TypeOf(proto.KeyPair{}), reflect.TypeOf([]byte{}),
+			reflect.TypeOf(crypto.PublicKey{}), reflect.TypeOf([]byte{}),
 		}
 	case BlockIDsEvent:
 		return []reflect.Type{
@@ -142,25 +146,39 @@ func tryBroadcastTransaction(
 	if _, err = t.Validate(params); err != nil {
 		err = errors.Wrap(err, "failed to validate transaction")
 		if p != nil {
-			baseInfo.peers.AddToBlackList(p, time.Now(), err.Error())
+			baseInfo.peers.UpdateReputation(p, storage.InvalidTransaction, err.Error())
 		}
 		return fsm, nil, err
 	}
 
 	if err = baseInfo.utx.Add(t); err != nil {
 		err = errors.Wrap(err, "failed to add transaction to utx")
+		// Rejection by pool's limits is not the sender's fault, unlike the transaction failed against the state.
+		var re *utxpool.RejectionError
+		if p != nil && !errors.As(err, &re) {
+			baseInfo.peers.UpdateReputation(p, storage.UselessTransaction, err.Error())
+		}
 		return fsm, nil, err
 	}
+	if p != nil {
+		baseInfo.peers.UpdateReputation(p, storage.ValidTransaction, "transaction added to UTX")
+	}
 	baseInfo.BroadcastTransaction(t, p)
 	return fsm, nil, nil
 }
 
+// isValidationError reports whether the error is caused by the invalid data received from a peer.
+func isValidationError(err error) bool {
+	return errs.IsValidationError(err) || errs.IsValidationError(errors.Cause(err))
+}
+
 func fsmErrorf(state State, err error) error {
 	infoMsg := &proto.InfoMsg{}
+	// Original error is kept in chain to let callers check its type, e.g. a rejection reason of UTX pool.
 	if errors.As(err, &infoMsg) {
-		return proto.NewInfoMsg(errors.Errorf("[%s] %s", state.String(), err.Error()))
+		return proto.NewInfoMsg(fmt.Errorf("[%s] %w", state.String(), err))
 	}
-	return errors.Errorf("[%s] %s", state.String(), err.Error())
+	return fmt.Errorf("[%s] %w", state.String(), err)
 }
 
 func createPermitDynamicCallback(
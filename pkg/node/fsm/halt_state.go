@@ -3,6 +3,7 @@ package fsm
 import (
 	"context"
 	stderrs "errors"
+	"io"
 
 	"github.com/pkg/errors"
 	"github.com/qmuntal/stateless"
@@ -31,6 +32,12 @@ func newHaltState(info BaseInfo) (State, Async, error) {
 		errs = append(errs, errors.Wrap(err, "failed to close peers"))
 	}
 	zap.S().Named(logging.FSMNamespace).Debugf("[Halt] Peers closed")
+	if c, ok := info.utx.(io.Closer); ok { // UTX pool with journal has to be closed to flush it
+		if err := c.Close(); err != nil {
+			errs = append(errs, errors.Wrap(err, "failed to close UTX pool"))
+		}
+		zap.S().Named(logging.FSMNamespace).Debugf("[Halt] UTX pool closed")
+	}
 	err := info.storage.Close()
 	if err != nil {
 		errs = append(errs, errors.Wrap(err, "failed to close storage"))
@@ -2,15 +2,19 @@ package fsm
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/pkg/errors"
 	"github.com/qmuntal/stateless"
 	"go.uber.org/zap"
 
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/errs"
 	"github.com/wavesplatform/gowaves/pkg/logging"
 	"github.com/wavesplatform/gowaves/pkg/metrics"
 	"github.com/wavesplatform/gowaves/pkg/miner"
 	"github.com/wavesplatform/gowaves/pkg/node/fsm/tasks"
+	"github.com/wavesplatform/gowaves/pkg/node/peers/storage"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer/extension"
 	"github.com/wavesplatform/gowaves/pkg/proto"
@@ -68,7 +72,7 @@ func (a *NGState) Task(task tasks.AsyncTask) (State, Async, error) {
 			return a, nil, a.Errorf(errors.Errorf(
 				"unexpected type %T, expected 'tasks.MineMicroTaskData'", task.Data))
 		}
-		return a.mineMicro(t.Block, t.Limits, t.KeyPair, t.Vrf)
+		return a.mineMicro(t.Block, t.Limits, t.PublicKey, t.Vrf)
 	case tasks.SnapshotTimeout:
 		return a, nil, nil
 	default:
@@ -188,8 +192,12 @@ func (a *NGState) Block(peer peer.Peer, block *proto.Block) (State, Async, error
 		[]*proto.Block{block},
 	)
 	if err != nil {
+		if isValidationError(err) {
+			a.baseInfo.peers.UpdateReputation(peer, storage.InvalidBlock, err.Error())
+		}
 		return a, nil, a.Errorf(errors.Wrapf(err, "failed to apply block %s", block.BlockID()))
 	}
+	a.baseInfo.peers.UpdateReputation(peer, storage.ValidBlock, "block applied")
 	a.blocksCache.Clear()
 	a.blocksCache.AddBlockState(block)
 	a.baseInfo.scheduler.Reschedule()
@@ -201,7 +209,7 @@ func (a *NGState) Block(peer peer.Peer, block *proto.Block) (State, Async, error
 }
 
 func (a *NGState) MinedBlock(
-	block *proto.Block, limits proto.MiningLimits, keyPair proto.KeyPair, vrf []byte,
+	block *proto.Block, limits proto.MiningLimits, pk crypto.PublicKey, vrf []byte,
 ) (State, Async, error) {
 	metrics.FSMKeyBlockGenerated("ng", block)
 	err := a.baseInfo.storage.Map(func(state state.NonThreadSafeState) error {
@@ -227,7 +235,7 @@ func (a *NGState) MinedBlock(
 	a.baseInfo.actions.SendScore(a.baseInfo.storage)
 	a.baseInfo.CleanUtx()
 
-	return a, tasks.Tasks(tasks.NewMineMicroTask(0, block, limits, keyPair, vrf)), nil
+	return a, tasks.Tasks(tasks.NewMineMicroTask(0, block, limits, pk, vrf)), nil
 }
 
 func (a *NGState) MicroBlock(p peer.Peer, micro *proto.MicroBlock) (State, Async, error) {
@@ -236,8 +244,12 @@ func (a *NGState) MicroBlock(p peer.Peer, micro *proto.MicroBlock) (State, Async
 		block, err := a.checkAndAppendMicroBlock(micro) // the TopBlock() is used here
 		if err != nil {
 			metrics.FSMMicroBlockDeclined("ng", micro, err)
+			if isValidationError(err) {
+				a.baseInfo.peers.UpdateReputation(p, storage.InvalidMicroBlock, err.Error())
+			}
 			return a, nil, a.Errorf(err)
 		}
+		a.baseInfo.peers.UpdateReputation(p, storage.ValidBlock, "microblock applied")
 		zap.S().Named(logging.FSMNamespace).Debugf(
 			"[%s] Received microblock '%s' (referencing '%s') successfully applied to state",
 			a, block.BlockID(), micro.Reference,
@@ -264,13 +276,13 @@ func (a *NGState) MicroBlock(p peer.Peer, micro *proto.MicroBlock) (State, Async
 
 // mineMicro handles a new microblock generated by miner.
 func (a *NGState) mineMicro(
-	minedBlock *proto.Block, rest proto.MiningLimits, keyPair proto.KeyPair, vrf []byte,
+	minedBlock *proto.Block, rest proto.MiningLimits, pk crypto.PublicKey, vrf []byte,
 ) (State, Async, error) {
-	block, micro, rest, err := a.baseInfo.microMiner.Micro(minedBlock, rest, keyPair)
+	block, micro, rest, err := a.baseInfo.microMiner.Micro(minedBlock, rest, pk)
 	switch {
 	case errors.Is(err, miner.ErrNoTransactions):
 		zap.S().Named(logging.FSMNamespace).Debugf("[%s] No transactions to put in microblock: %v", a, err)
-		return a, tasks.Tasks(tasks.NewMineMicroTask(a.baseInfo.microblockInterval, minedBlock, rest, keyPair, vrf)), nil
+		return a, tasks.Tasks(tasks.NewMineMicroTask(a.baseInfo.microblockInterval, minedBlock, rest, pk, vrf)), nil
 	case errors.Is(err, miner.ErrStateChanged):
 		return a, nil, a.Errorf(proto.NewInfoMsg(err))
 	case err != nil:
@@ -295,7 +307,9 @@ func (a *NGState) mineMicro(
 		micro.SenderPK,
 		block.BlockID(),
 		micro.Reference)
-	err = inv.Sign(keyPair.Secret, a.baseInfo.scheme)
+	err = inv.SignWith(a.baseInfo.scheme, func(data []byte) (crypto.Signature, error) {
+		return a.baseInfo.signer.Sign(pk, data)
+	})
 	if err != nil {
 		return a, nil, a.Errorf(err)
 	}
@@ -324,7 +338,7 @@ func (a *NGState) mineMicro(
 	a.baseInfo.MicroBlockCache.AddMicroBlock(block.BlockID(), micro)
 	a.baseInfo.MicroBlockInvCache.Add(block.BlockID(), inv)
 
-	return a, tasks.Tasks(tasks.NewMineMicroTask(a.baseInfo.microblockInterval, block, rest, keyPair, vrf)), nil
+	return a, tasks.Tasks(tasks.NewMineMicroTask(a.baseInfo.microblockInterval, block, rest, pk, vrf)), nil
 }
 
 // checkAndAppendMicroBlock checks that microblock is appendable and appends it.
@@ -343,7 +357,8 @@ func (a *NGState) checkAndAppendMicroBlock(
 		return nil, err
 	}
 	if !ok {
-		return nil, errors.Errorf("microblock '%s' has invalid signature", micro.TotalBlockID.String())
+		return nil, errs.NewBlockValidationError(
+			fmt.Sprintf("microblock '%s' has invalid signature", micro.TotalBlockID.String()))
 	}
 	newTrs := top.Transactions.Join(micro.Transactions)
 	newBlock, err := proto.CreateBlock(newTrs, top.Timestamp, top.Parent, top.GeneratorPublicKey, top.NxtConsensus,
@@ -357,7 +372,7 @@ func (a *NGState) checkAndAppendMicroBlock(
 		return nil, err
 	}
 	if !ok {
-		return nil, errors.New("incorrect signature for applied microblock")
+		return nil, errs.NewBlockValidationError("incorrect signature for applied microblock")
 	}
 	err = newBlock.GenerateBlockID(a.baseInfo.scheme)
 	if err != nil {
@@ -510,7 +525,7 @@ func initNGStateInFSM(state *StateData, fsm *stateless.StateMachine, info BaseIn
 						"unexpected type '%T' expected '*NGState'", state.State))
 				}
 				return a.MinedBlock(args[0].(*proto.Block), args[1].(proto.MiningLimits),
-					args[2].(proto.KeyPair), args[3].([]byte))
+					args[2].(crypto.PublicKey), args[3].([]byte))
 			})).
 		PermitDynamic(MicroBlockEvent,
 			createPermitDynamicCallback(MicroBlockEvent, state, func(args ...interface{}) (State, Async, error) {
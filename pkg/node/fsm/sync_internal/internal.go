@@ -2,9 +2,11 @@ package sync_internal
 
 import (
 	"errors"
+	"time"
 
 	"github.com/wavesplatform/gowaves/pkg/libs/ordered_blocks"
 	"github.com/wavesplatform/gowaves/pkg/libs/signatures"
+	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer/extension"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 )
@@ -23,9 +25,43 @@ type PeerExtension interface {
 	AskBlockSnapshot(id proto.BlockID)
 }
 
+// Downloader is a peer the blocks are requested from.
+type Downloader interface {
+	PeerExtension
+	Peer() peer.Peer
+}
+
+type downloader struct {
+	extension.PeerExtension
+	p peer.Peer
+}
+
+func (d downloader) Peer() peer.Peer {
+	return d.p
+}
+
+func NewDownloader(p peer.Peer, scheme proto.Scheme) Downloader {
+	return downloader{PeerExtension: extension.NewPeerExtension(p, scheme), p: p}
+}
+
+// request is the block requested from the downloader but not received yet.
+type request struct {
+	downloader Downloader
+	time       time.Time
+}
+
+// delivery holds the peers the block and its snapshot were received from.
+type delivery struct {
+	block    peer.Peer
+	snapshot peer.Peer
+}
+
 type Internal struct {
 	respondedSignatures  *signatures.BlockIDs
 	orderedBlocks        *ordered_blocks.OrderedBlocks
+	downloaders          []Downloader
+	requests             map[proto.BlockID]request
+	deliveries           map[proto.BlockID]delivery
 	waitingForSignatures bool
 	isLightNode          bool
 }
@@ -48,69 +84,168 @@ func NewInternal(
 	return Internal{
 		respondedSignatures:  respondedSignatures,
 		orderedBlocks:        orderedBlocks,
+		requests:             make(map[proto.BlockID]request),
+		deliveries:           make(map[proto.BlockID]delivery),
 		waitingForSignatures: waitingForSignatures,
 		isLightNode:          isLightNode,
 	}
 }
 
-func (a Internal) BlockIDs(p PeerExtension, ids []proto.BlockID) (Internal, error) {
+// BlockIDs requests the blocks with new IDs, requests are spread evenly across the downloaders.
+func (a Internal) BlockIDs(downloaders []Downloader, ids []proto.BlockID, now time.Time) (Internal, error) {
 	if !a.waitingForSignatures {
 		return a, NoSignaturesExpectedErr
 	}
+	if len(downloaders) == 0 {
+		return a, errors.New("no peers to download blocks from")
+	}
 	var newIDs []proto.BlockID
+	i := 0
 	for _, id := range ids {
 		if a.respondedSignatures.Exists(id) {
 			continue
 		}
 		newIDs = append(newIDs, id)
 		if a.orderedBlocks.Add(id) {
-			p.AskBlock(id)
-			if a.isLightNode {
-				p.AskBlockSnapshot(id)
-			}
+			a.ask(downloaders[i%len(downloaders)], id, now)
+			i++
+		}
+	}
+	a.respondedSignatures = signatures.NewSignatures(newIDs...).Revert()
+	a.downloaders = downloaders
+	a.deliveries = make(map[proto.BlockID]delivery) // Blocks of the previous batch are already applied.
+	a.waitingForSignatures = false
+	return a, nil
+}
+
+func (a Internal) ask(d Downloader, id proto.BlockID, now time.Time) {
+	d.AskBlock(id)
+	if a.isLightNode {
+		d.AskBlockSnapshot(id)
+	}
+	a.requests[id] = request{downloader: d, time: now}
+}
+
+// DownloadsFrom checks that the peer is one of the peers the blocks are downloaded from.
+func (a Internal) DownloadsFrom(p peer.Peer) bool {
+	for _, d := range a.downloaders {
+		if d.Peer().Equal(p) {
+			return true
 		}
 	}
-	respondedSignatures := signatures.NewSignatures(newIDs...).Revert()
-	return NewInternal(a.orderedBlocks, respondedSignatures, false, a.isLightNode), nil
+	return false
+}
+
+// Retry requests the blocks which were not received in time from the other peers. Peers failed to deliver
+// the blocks are excluded from downloading and returned. The sync peer is excluded from downloading too,
+// but never returned, because it's the peer the blocks IDs are received from and sync timeout takes care of it.
+// If there is no other peer to ask nothing happens.
+func (a Internal) Retry(now time.Time, timeout time.Duration, syncPeer peer.Peer) (Internal, []peer.Peer) {
+	slow := make(map[peer.ID]peer.Peer)
+	for _, r := range a.requests {
+		if r.time.Add(timeout).Before(now) {
+			p := r.downloader.Peer()
+			slow[p.ID()] = p
+		}
+	}
+	if len(slow) == 0 {
+		return a, nil
+	}
+	var healthy []Downloader
+	for _, d := range a.downloaders {
+		if _, ok := slow[d.Peer().ID()]; !ok {
+			healthy = append(healthy, d)
+		}
+	}
+	if len(healthy) == 0 {
+		return a, nil
+	}
+	i := 0
+	for _, id := range a.orderedBlocks.Requested() {
+		r, ok := a.requests[id]
+		if !ok {
+			continue
+		}
+		if _, ok = slow[r.downloader.Peer().ID()]; ok {
+			a.ask(healthy[i%len(healthy)], id, now)
+			i++
+		}
+	}
+	a.downloaders = healthy
+	out := make([]peer.Peer, 0, len(slow))
+	for _, p := range slow {
+		if syncPeer != nil && p.Equal(syncPeer) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return a, out
 }
 
 func (a Internal) WaitingForSignatures() bool {
 	return a.waitingForSignatures
 }
 
-func (a Internal) Block(block *proto.Block) (Internal, error) {
-	if !a.orderedBlocks.Contains(block.BlockID()) {
+// Block stores the block received from the peer.
+func (a Internal) Block(p peer.Peer, block *proto.Block) (Internal, error) {
+	id := block.BlockID()
+	if !a.orderedBlocks.Contains(id) {
 		return a, UnexpectedBlockErr
 	}
 	a.orderedBlocks.SetBlock(block)
+	d := a.deliveries[id]
+	d.block = p
+	a.deliveries[id] = d
+	a.received(id)
 	return a, nil
 }
 
-func (a Internal) SetSnapshot(blockID proto.BlockID, snapshot *proto.BlockSnapshot) (Internal, error) {
+// SetSnapshot stores the snapshot of the block received from the peer.
+func (a Internal) SetSnapshot(
+	p peer.Peer, blockID proto.BlockID, snapshot *proto.BlockSnapshot,
+) (Internal, error) {
 	if !a.orderedBlocks.Contains(blockID) {
 		return a, UnexpectedBlockErr
 	}
 	a.orderedBlocks.SetSnapshot(blockID, snapshot)
+	d := a.deliveries[blockID]
+	d.snapshot = p
+	a.deliveries[blockID] = d
+	a.received(blockID)
 	return a, nil
 }
 
+func (a Internal) received(id proto.BlockID) {
+	if a.orderedBlocks.Received(id, a.isLightNode) {
+		delete(a.requests, id)
+	}
+}
+
 type peerExtension interface {
 	AskBlocksIDs(id []proto.BlockID)
 }
 
 func (a Internal) Blocks() (Internal, Blocks, Snapshots, Eof) {
 	if a.waitingForSignatures {
-		return NewInternal(a.orderedBlocks, a.respondedSignatures, a.waitingForSignatures, a.isLightNode), nil, nil, false
+		return a, nil, nil, false
 	}
 	if a.orderedBlocks.RequestedCount() > a.orderedBlocks.ReceivedCount(a.isLightNode) {
-		return NewInternal(a.orderedBlocks, a.respondedSignatures, a.waitingForSignatures, a.isLightNode), nil, nil, false
+		return a, nil, nil, false
 	}
 	if a.orderedBlocks.RequestedCount() < 100 {
 		bs, ss := a.orderedBlocks.PopAll(a.isLightNode)
-		return NewInternal(a.orderedBlocks, a.respondedSignatures, false, a.isLightNode), bs, ss, true
+		return a, bs, ss, true
 	}
 	bs, ss := a.orderedBlocks.PopAll(a.isLightNode)
-	return NewInternal(a.orderedBlocks, a.respondedSignatures, true, a.isLightNode), bs, ss, false
+	a.waitingForSignatures = true
+	return a, bs, ss, false
+}
+
+// DeliveredBy returns the peers the block and its snapshot were received from. Peers are known for the blocks
+// of the current batch only, including the blocks returned by the last call of Blocks.
+func (a Internal) DeliveredBy(id proto.BlockID) (block, snapshot peer.Peer) {
+	d := a.deliveries[id]
+	return d.block, d.snapshot
 }
 
 func (a Internal) AskBlocksIDs(p peerExtension) {
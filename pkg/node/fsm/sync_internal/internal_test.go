@@ -2,13 +2,17 @@ package sync_internal_test
 
 import (
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/libs/ordered_blocks"
 	"github.com/wavesplatform/gowaves/pkg/libs/signatures"
 	. "github.com/wavesplatform/gowaves/pkg/node/fsm/sync_internal"
+	"github.com/wavesplatform/gowaves/pkg/p2p/mock"
+	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 )
 
@@ -25,8 +29,27 @@ func (noopWrapper) AskBlockSnapshot(_ proto.BlockID) {
 
 }
 
+func (noopWrapper) Peer() peer.Peer {
+	return &mock.Peer{Addr: "127.0.0.1"}
+}
+
+type recordingDownloader struct {
+	noopWrapper
+	p     *mock.Peer
+	asked []proto.BlockID
+}
+
+func (d *recordingDownloader) AskBlock(id proto.BlockID) {
+	d.asked = append(d.asked, id)
+}
+
+func (d *recordingDownloader) Peer() peer.Peer {
+	return d.p
+}
+
 var sig1 = crypto.MustSignatureFromBase58("5syuWANDSgk8KyPxq2yQs2CYV23QfnrBoZMSv2LaciycxDYfBw6cLA2SqVnonnh1nFiFumzTgy2cPETnE7ZaZg5P")
 var sig2 = crypto.MustSignatureFromBase58("3kvbjSovZWLg1zdMyW5vGsCj1DR1jkHY3ALtu5VxoqscrXQq3nH2vS2V5dhVo6ff9bxtbFAkUkVQQqCFUAHmwnpX")
+var sig3 = crypto.MustSignatureFromBase58("5syuWANDSgk8KyPxq2yQs2CYV23QfnrBoZMSv2LaciycxDYfBw6cLA2SqVnonnh1nFiFumzTgy2cPETnE7ZaZg5Q")
 
 func blocksFromSigs(sigs ...crypto.Signature) []proto.BlockID {
 	var out []proto.BlockID
@@ -42,14 +65,14 @@ func TestSigFSM_Signatures(t *testing.T) {
 
 	t.Run("error on receive unexpected signatures", func(t *testing.T) {
 		fsm := NewInternal(or, sigs, false, false)
-		rs2, err := fsm.BlockIDs(nil, blocksFromSigs(sig1, sig2))
+		rs2, err := fsm.BlockIDs(nil, blocksFromSigs(sig1, sig2), time.Now())
 		require.Equal(t, NoSignaturesExpectedErr, err)
 		require.NotNil(t, rs2)
 	})
 
 	t.Run("successful receive signatures", func(t *testing.T) {
 		fsm := NewInternal(or, sigs, true, false)
-		rs2, err := fsm.BlockIDs(noopWrapper{}, blocksFromSigs(sig1, sig2))
+		rs2, err := fsm.BlockIDs([]Downloader{noopWrapper{}}, blocksFromSigs(sig1, sig2), time.Now())
 		require.NoError(t, err)
 		require.NotNil(t, rs2)
 		require.False(t, rs2.WaitingForSignatures())
@@ -68,10 +91,10 @@ func TestSigFSM_Block(t *testing.T) {
 	or := ordered_blocks.NewOrderedBlocks()
 	sigs := signatures.NewSignatures()
 	fsm := NewInternal(or, sigs, true, false)
-	fsm, _ = fsm.BlockIDs(noopWrapper{}, blocksFromSigs(sig1, sig2))
+	fsm, _ = fsm.BlockIDs([]Downloader{noopWrapper{}}, blocksFromSigs(sig1, sig2), time.Now())
 
-	fsm, _ = fsm.Block(block(sig1))
-	fsm, _ = fsm.Block(block(sig2))
+	fsm, _ = fsm.Block(noopWrapper{}.Peer(), block(sig1))
+	fsm, _ = fsm.Block(noopWrapper{}.Peer(), block(sig2))
 	require.Equal(t, 2, fsm.AvailableCount())
 
 	// no panic, cause `nearEnd` is True
@@ -85,3 +108,78 @@ func TestSigFSM_BlockGetSignatures(t *testing.T) {
 	_, bs, _, _ := NewInternal(or, sigs, false, false).Blocks()
 	require.Nil(t, bs)
 }
+
+func TestSigFSM_ParallelDownload(t *testing.T) {
+	d1 := &recordingDownloader{p: &mock.Peer{Addr: "10.0.0.1"}}
+	d2 := &recordingDownloader{p: &mock.Peer{Addr: "10.0.0.2"}}
+	start := time.Now()
+	fsm := NewInternal(ordered_blocks.NewOrderedBlocks(), signatures.NewSignatures(), true, false)
+	fsm, err := fsm.BlockIDs([]Downloader{d1, d2}, blocksFromSigs(sig1, sig2, sig3), start)
+	require.NoError(t, err)
+	assert.Equal(t, blocksFromSigs(sig1, sig3), d1.asked)
+	assert.Equal(t, blocksFromSigs(sig2), d2.asked)
+	assert.True(t, fsm.DownloadsFrom(d1.p))
+	assert.True(t, fsm.DownloadsFrom(d2.p))
+	assert.False(t, fsm.DownloadsFrom(&mock.Peer{Addr: "10.0.0.3"}))
+
+	// Blocks from the second peer arrive first, but are not available until the preceding blocks are received.
+	fsm, err = fsm.Block(d2.p, block(sig2))
+	require.NoError(t, err)
+	fsm, err = fsm.Block(d1.p, block(sig1))
+	require.NoError(t, err)
+	require.Equal(t, 2, fsm.AvailableCount())
+
+	// Nothing to retry before timeout.
+	fsm, slow := fsm.Retry(start.Add(time.Second), 10*time.Second, nil)
+	assert.Empty(t, slow)
+
+	// The last block was not delivered by the first peer in time, so it's requested from the second one.
+	fsm, slow = fsm.Retry(start.Add(11*time.Second), 10*time.Second, nil)
+	require.Len(t, slow, 1)
+	assert.Equal(t, d1.p, slow[0])
+	assert.Equal(t, blocksFromSigs(sig2, sig3), d2.asked)
+	assert.False(t, fsm.DownloadsFrom(d1.p))
+
+	fsm, err = fsm.Block(d2.p, block(sig3))
+	require.NoError(t, err)
+	_, blocks, _, eof := fsm.Blocks()
+	require.Len(t, blocks, 3)
+	assert.Equal(t, sig1, blocks[0].BlockSignature)
+	assert.Equal(t, sig2, blocks[1].BlockSignature)
+	assert.Equal(t, sig3, blocks[2].BlockSignature)
+	assert.True(t, eof)
+	for i, expected := range []peer.Peer{d1.p, d2.p, d2.p} {
+		bp, sp := fsm.DeliveredBy(blocks[i].BlockID())
+		assert.Equal(t, expected, bp)
+		assert.Nil(t, sp)
+	}
+}
+
+func TestSigFSM_RetryKeepsSyncPeer(t *testing.T) {
+	sync := &recordingDownloader{p: &mock.Peer{Addr: "10.0.0.1"}}
+	helper := &recordingDownloader{p: &mock.Peer{Addr: "10.0.0.2"}}
+	start := time.Now()
+	fsm := NewInternal(ordered_blocks.NewOrderedBlocks(), signatures.NewSignatures(), true, false)
+	fsm, err := fsm.BlockIDs([]Downloader{sync, helper}, blocksFromSigs(sig1, sig2), start)
+	require.NoError(t, err)
+	fsm, err = fsm.Block(helper.p, block(sig2))
+	require.NoError(t, err)
+
+	// The sync peer is late, its block is requested from the helper, but the sync peer is not reported as slow.
+	fsm, slow := fsm.Retry(start.Add(11*time.Second), 10*time.Second, sync.p)
+	assert.Empty(t, slow)
+	assert.Equal(t, blocksFromSigs(sig2, sig1), helper.asked)
+	assert.False(t, fsm.DownloadsFrom(sync.p))
+}
+
+func TestSigFSM_RetryWithoutOtherPeers(t *testing.T) {
+	d := &recordingDownloader{p: &mock.Peer{Addr: "10.0.0.1"}}
+	start := time.Now()
+	fsm := NewInternal(ordered_blocks.NewOrderedBlocks(), signatures.NewSignatures(), true, false)
+	fsm, err := fsm.BlockIDs([]Downloader{d}, blocksFromSigs(sig1), start)
+	require.NoError(t, err)
+	fsm, slow := fsm.Retry(start.Add(time.Minute), 10*time.Second, nil)
+	assert.Empty(t, slow)
+	assert.True(t, fsm.DownloadsFrom(d.p))
+	assert.Len(t, d.asked, 1)
+}
@@ -8,11 +8,12 @@ import (
 	"github.com/qmuntal/stateless"
 	"go.uber.org/zap"
 
-	"github.com/wavesplatform/gowaves/pkg/errs"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/logging"
 	"github.com/wavesplatform/gowaves/pkg/metrics"
 	"github.com/wavesplatform/gowaves/pkg/node/fsm/sync_internal"
 	"github.com/wavesplatform/gowaves/pkg/node/fsm/tasks"
+	"github.com/wavesplatform/gowaves/pkg/node/peers/storage"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer/extension"
 	"github.com/wavesplatform/gowaves/pkg/proto"
@@ -20,7 +21,14 @@ import (
 	"github.com/wavesplatform/gowaves/pkg/types"
 )
 
-const defaultMicroblockInterval = 5 * time.Second
+const (
+	defaultMicroblockInterval = 5 * time.Second
+	// blockRequestTimeout is the time given to a peer to deliver the requested block before it's requested
+	// from another peer.
+	blockRequestTimeout = 15 * time.Second
+	// maxDownloadPeers limits the number of peers the blocks are downloaded from simultaneously.
+	maxDownloadPeers = 4
+)
 
 type conf struct {
 	peerSyncWith peer.Peer
@@ -99,6 +107,7 @@ func (a *SyncState) Task(task tasks.AsyncTask) (State, Async, error) {
 		return a, nil, nil
 	case tasks.Ping:
 		zap.S().Named(logging.FSMNamespace).Debug("[Sync] Checking timeout")
+		a.retryBlockRequests()
 		timeout := a.conf.lastReceiveTime.Add(a.conf.timeout).Before(a.baseInfo.tm.Now())
 		if timeout {
 			zap.S().Named(logging.FSMNamespace).Debugf(
@@ -132,7 +141,7 @@ func (a *SyncState) BlockIDs(peer peer.Peer, signatures []proto.BlockID) (State,
 			peer.ID().String(), a.baseInfo.syncPeer.GetPeer().ID().String())
 		return a, nil, nil
 	}
-	internal, err := a.internal.BlockIDs(extension.NewPeerExtension(peer, a.baseInfo.scheme), signatures)
+	internal, err := a.internal.BlockIDs(a.downloaders(), signatures, a.baseInfo.tm.Now())
 	if err != nil {
 		zap.S().Named(logging.FSMNamespace).Debugf("[Sync] No signatures expected from peer '%s' but received",
 			peer.ID().String())
@@ -164,13 +173,13 @@ func (a *SyncState) Score(p peer.Peer, score *proto.Score) (State, Async, error)
 }
 
 func (a *SyncState) Block(p peer.Peer, block *proto.Block) (State, Async, error) {
-	if !p.Equal(a.conf.peerSyncWith) {
+	if !p.Equal(a.conf.peerSyncWith) && !a.internal.DownloadsFrom(p) {
 		return a, nil, nil
 	}
 	metrics.FSMKeyBlockReceived("sync", block, p.Handshake().NodeName)
 	zap.S().Named(logging.FSMNamespace).Debugf("[Sync][%s] Received block %s", p.ID(), block.ID.String())
 
-	internal, err := a.internal.Block(block)
+	internal, err := a.internal.Block(p, block)
 	if err != nil {
 		return newSyncState(a.baseInfo, a.conf, internal), nil, a.Errorf(err)
 	}
@@ -182,11 +191,11 @@ func (a *SyncState) BlockSnapshot(
 	blockID proto.BlockID,
 	snapshot proto.BlockSnapshot,
 ) (State, Async, error) {
-	if !p.Equal(a.conf.peerSyncWith) {
+	if !p.Equal(a.conf.peerSyncWith) && !a.internal.DownloadsFrom(p) {
 		return a, nil, nil
 	}
 	zap.S().Named(logging.FSMNamespace).Debugf("[Sync][%s] Received snapshot for block %s", p.ID(), blockID.String())
-	internal, err := a.internal.SetSnapshot(blockID, &snapshot)
+	internal, err := a.internal.SetSnapshot(p, blockID, &snapshot)
 	if err != nil {
 		return newSyncState(a.baseInfo, a.conf, internal), nil, a.Errorf(err)
 	}
@@ -194,7 +203,7 @@ func (a *SyncState) BlockSnapshot(
 }
 
 func (a *SyncState) MinedBlock(
-	block *proto.Block, limits proto.MiningLimits, keyPair proto.KeyPair, vrf []byte,
+	block *proto.Block, limits proto.MiningLimits, pk crypto.PublicKey, vrf []byte,
 ) (State, Async, error) {
 	metrics.FSMKeyBlockGenerated("sync", block)
 	zap.S().Named(logging.FSMNamespace).Infof("[Sync] New block '%s' mined", block.ID.String())
@@ -212,13 +221,40 @@ func (a *SyncState) MinedBlock(
 	// first we should send block
 	a.baseInfo.actions.SendBlock(block)
 	a.baseInfo.actions.SendScore(a.baseInfo.storage)
-	return a, tasks.Tasks(tasks.NewMineMicroTask(defaultMicroblockInterval, block, limits, keyPair, vrf)), nil
+	return a, tasks.Tasks(tasks.NewMineMicroTask(defaultMicroblockInterval, block, limits, pk, vrf)), nil
 }
 
 func (a *SyncState) Halt() (State, Async, error) {
 	return newHaltState(a.baseInfo)
 }
 
+// downloaders returns the peers to download blocks from: the sync peer and other peers with the same score.
+func (a *SyncState) downloaders() []sync_internal.Downloader {
+	r := []sync_internal.Downloader{sync_internal.NewDownloader(a.conf.peerSyncWith, a.baseInfo.scheme)}
+	for _, p := range a.baseInfo.peers.PeersWithSameScore(a.conf.peerSyncWith) {
+		if len(r) == maxDownloadPeers {
+			break
+		}
+		r = append(r, sync_internal.NewDownloader(p, a.baseInfo.scheme))
+	}
+	return r
+}
+
+// retryBlockRequests requests the blocks not received in time from other peers and lowers the reputation
+// of the peers which failed to deliver them. The sync peer is never penalized here, because low reputation
+// disconnects the peer, stalled sync peer is handled by sync timeout instead.
+func (a *SyncState) retryBlockRequests() {
+	now := a.baseInfo.tm.Now()
+	internal, slow := a.internal.Retry(now, blockRequestTimeout, a.conf.peerSyncWith)
+	a.internal = internal
+	for _, p := range slow {
+		zap.S().Named(logging.FSMNamespace).Debugf(
+			"[Sync] Peer '%s' failed to deliver blocks in %s",
+			p.ID().String(), blockRequestTimeout.String())
+		a.baseInfo.peers.UpdateReputation(p, storage.SyncTimeout, "block request timeout")
+	}
+}
+
 func (a *SyncState) isTimeToSwitchPeerWithMaxScore() bool {
 	now := a.baseInfo.tm.Now()
 	obsolescenceTime := now.Add(-a.baseInfo.obsolescence)
@@ -263,11 +299,12 @@ func (a *SyncState) applyBlocksWithSnapshots(
 	}
 
 	if err != nil {
-		if errs.IsValidationError(err) || errs.IsValidationError(errors.Cause(err)) {
-			zap.S().Named(logging.FSMNamespace).Debugf(
-				"[Sync] Suspending peer '%s' because of blocks application error: %v",
-				a.baseInfo.syncPeer.GetPeer().ID().String(), err)
-			a.baseInfo.peers.Suspend(conf.peerSyncWith, time.Now(), err.Error())
+		if isValidationError(err) {
+			for _, p := range a.invalidBlocksSenders(conf, internal, blocks) {
+				zap.S().Named(logging.FSMNamespace).Debugf("[Sync] Peer '%s' sent invalid blocks: %v",
+					p.ID().String(), err)
+				a.baseInfo.peers.UpdateReputation(p, storage.InvalidBlock, err.Error())
+			}
 		}
 		for _, b := range blocks {
 			metrics.FSMKeyBlockDeclined("sync", b, err)
@@ -277,6 +314,9 @@ func (a *SyncState) applyBlocksWithSnapshots(
 	for _, b := range blocks {
 		metrics.FSMKeyBlockApplied("sync", b)
 	}
+	for _, p := range a.blocksSenders(conf, internal, blocks) {
+		a.baseInfo.peers.UpdateReputation(p, storage.ValidBlock, "blocks applied")
+	}
 	a.baseInfo.scheduler.Reschedule()
 	a.baseInfo.actions.SendScore(a.baseInfo.storage)
 	should, err := a.baseInfo.storage.ShouldPersistAddressTransactions()
@@ -300,6 +340,66 @@ func (a *SyncState) applyBlocksWithSnapshots(
 	return newSyncState(baseInfo, conf, internal), nil, nil
 }
 
+// blocksSenders returns the distinct peers the blocks and, in light mode, their snapshots were received from.
+func (a *SyncState) blocksSenders(conf conf, internal sync_internal.Internal, blocks []*proto.Block) []peer.Peer {
+	var r []peer.Peer
+	for _, b := range blocks {
+		bp, sp := internal.DeliveredBy(b.BlockID())
+		r = appendPeer(r, bp)
+		if a.baseInfo.enableLightMode {
+			r = appendPeer(r, sp)
+		}
+	}
+	if len(r) == 0 {
+		r = appendPeer(r, conf.peerSyncWith)
+	}
+	return r
+}
+
+// invalidBlocksSenders returns the peers to blame for the blocks failed validation.
+// ID of the block before version 5 is its signature, so such block can be altered by the peer delivered it
+// without changing the ID, and the block of later version can be delivered with altered transactions.
+// Such blocks fail the check of the signature or the transactions root, and the peers delivered them are blamed.
+// Otherwise, the blocks are the ones signed by their generators, and the sync peer which advertised the chain
+// is blamed. Snapshots are not signed, so in light mode the peers delivered them are blamed as well.
+func (a *SyncState) invalidBlocksSenders(
+	conf conf, internal sync_internal.Internal, blocks []*proto.Block,
+) []peer.Peer {
+	var r []peer.Peer
+	for _, b := range blocks {
+		validSig, sErr := b.VerifySignature(a.baseInfo.scheme)
+		validRoot, rErr := b.VerifyTransactionsRoot(a.baseInfo.scheme)
+		if sErr != nil || rErr != nil || !validSig || !validRoot {
+			bp, _ := internal.DeliveredBy(b.BlockID())
+			r = appendPeer(r, bp)
+		}
+	}
+	if len(r) > 0 {
+		return r
+	}
+	r = appendPeer(r, conf.peerSyncWith)
+	if a.baseInfo.enableLightMode {
+		for _, b := range blocks {
+			_, sp := internal.DeliveredBy(b.BlockID())
+			r = appendPeer(r, sp)
+		}
+	}
+	return r
+}
+
+// appendPeer appends the peer to the list if it's not nil and not in the list yet.
+func appendPeer(ps []peer.Peer, p peer.Peer) []peer.Peer {
+	if p == nil {
+		return ps
+	}
+	for _, e := range ps {
+		if e.Equal(p) {
+			return ps
+		}
+	}
+	return append(ps, p)
+}
+
 func initSyncStateInFSM(state *StateData, fsm *stateless.StateMachine, info BaseInfo) {
 	syncSkipMessageList := proto.PeerMessageIDs{
 		proto.ContentIDTransaction,
@@ -386,7 +486,7 @@ func initSyncStateInFSM(state *StateData, fsm *stateless.StateMachine, info Base
 						"unexpected type '%T' expected '*SyncState'", state.State))
 				}
 				return a.MinedBlock(args[0].(*proto.Block), args[1].(proto.MiningLimits),
-					args[2].(proto.KeyPair), args[3].([]byte))
+					args[2].(crypto.PublicKey), args[3].([]byte))
 			})).
 		PermitDynamic(TransactionEvent,
 			createPermitDynamicCallback(TransactionEvent, state, func(args ...interface{}) (State, Async, error) {
@@ -0,0 +1,94 @@
+package fsm
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/libs/ordered_blocks"
+	"github.com/wavesplatform/gowaves/pkg/libs/signatures"
+	"github.com/wavesplatform/gowaves/pkg/node/fsm/sync_internal"
+	"github.com/wavesplatform/gowaves/pkg/node/peers"
+	"github.com/wavesplatform/gowaves/pkg/node/peers/storage"
+	"github.com/wavesplatform/gowaves/pkg/p2p/mock"
+	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+type fixedTime time.Time
+
+func (t fixedTime) Now() time.Time {
+	return time.Time(t)
+}
+
+// closingPeer records the disconnection of the peer.
+type closingPeer struct {
+	*mock.Peer
+	closed bool
+}
+
+func (*closingPeer) Direction() peer.Direction {
+	return peer.Outgoing
+}
+
+func (p *closingPeer) Close() error {
+	p.closed = true
+	return nil
+}
+
+func newClosingPeer(ip string) *closingPeer {
+	return &closingPeer{Peer: &mock.Peer{Addr: ip, RemoteAddress: proto.NewTCPAddr(net.ParseIP(ip), 6868)}}
+}
+
+func TestSyncStateBlockRequestTimeout(t *testing.T) {
+	ps, err := storage.NewCBORStorage(t.TempDir(), time.Now())
+	require.NoError(t, err)
+	pm := peers.NewPeerManager(nil, ps, 10, proto.Version{}, "wavesW", false, 10, time.Hour, peers.ConnectionRules{})
+	syncPeer, helper := newClosingPeer("10.0.0.1"), newClosingPeer("10.0.0.2")
+	sig1, sig2 := crypto.Signature{1}, crypto.Signature{2}
+	ids := []proto.BlockID{proto.NewBlockIDFromSignature(sig1), proto.NewBlockIDFromSignature(sig2)}
+	start := time.Now()
+
+	// timeout lets the helper miss the block request once.
+	timeout := func() {
+		internal, iErr := sync_internal.NewInternal(
+			ordered_blocks.NewOrderedBlocks(), signatures.NewSignatures(), true, false,
+		).BlockIDs([]sync_internal.Downloader{
+			sync_internal.NewDownloader(syncPeer, proto.MainNetScheme),
+			sync_internal.NewDownloader(helper, proto.MainNetScheme),
+		}, ids, start)
+		require.NoError(t, iErr)
+		// The sync peer delivers its block in time, the helper doesn't.
+		internal, iErr = internal.Block(syncPeer, &proto.Block{BlockHeader: proto.BlockHeader{BlockSignature: sig1}})
+		require.NoError(t, iErr)
+		s := &SyncState{
+			baseInfo: BaseInfo{peers: pm, tm: fixedTime(start.Add(blockRequestTimeout + time.Second))},
+			conf:     conf{peerSyncWith: syncPeer},
+			internal: internal,
+		}
+		s.retryBlockRequests()
+		assert.False(t, s.internal.DownloadsFrom(helper))
+	}
+
+	// Single timeout only lowers the reputation of the helper.
+	timeout()
+	assert.False(t, helper.closed)
+	rep := pm.Reputations()
+	require.Len(t, rep, 1)
+	assert.Equal(t, storage.IpFromIpPort(helper.RemoteAddr().ToIpPort()), rep[0].IP)
+	assert.InDelta(t, -30, rep[0].Score, 0.1)
+
+	// Repeated timeouts take the reputation down to the restriction.
+	for i := 0; i < 20 && !helper.closed; i++ {
+		timeout()
+	}
+	assert.True(t, helper.closed)
+	rep = pm.Reputations()
+	require.Len(t, rep, 1)
+	assert.LessOrEqual(t, rep[0].Score, float64(-300))
+	assert.False(t, syncPeer.closed)
+}
@@ -99,6 +99,24 @@ func (ap *activePeers) getPeerFromLargestPeerGroup(p peer.Peer) (peerInfo, bool)
 	return info, id != pid
 }
 
+// getPeersWithSameScore returns other active peers with the same score as the given one.
+func (ap *activePeers) getPeersWithSameScore(peerID peer.ID) []peerInfo {
+	info, ok := ap.m[peerID]
+	if !ok {
+		return nil
+	}
+	var r []peerInfo
+	for _, id := range ap.sortedByScore {
+		if id == peerID {
+			continue
+		}
+		if pi := ap.m[id]; pi.score.Cmp(info.score) == 0 {
+			r = append(r, pi)
+		}
+	}
+	return r
+}
+
 func (ap *activePeers) size() int {
 	return len(ap.m)
 }
@@ -136,3 +136,28 @@ func TestStableInsertion(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, peer2, info.peer)
 }
+
+func TestPeersWithSameScore(t *testing.T) {
+	active := newActivePeers()
+	peers := genPeers(4)
+	for _, p := range peers {
+		active.add(p)
+	}
+	assert.Len(t, active.getPeersWithSameScore(peers[0].ID()), 3)
+	assert.Empty(t, active.getPeersWithSameScore((&mock.Peer{Addr: "10.0.0.1"}).ID()))
+	require.NoError(t, active.updateScore(peers[0].ID(), big.NewInt(100)))
+	require.NoError(t, active.updateScore(peers[1].ID(), big.NewInt(50)))
+	require.NoError(t, active.updateScore(peers[2].ID(), big.NewInt(100)))
+	require.NoError(t, active.updateScore(peers[3].ID(), big.NewInt(100)))
+
+	infos := active.getPeersWithSameScore(peers[0].ID())
+	require.Len(t, infos, 2)
+	assert.Equal(t, peers[2], infos[0].peer)
+	assert.Equal(t, peers[3], infos[1].peer)
+	assert.Empty(t, active.getPeersWithSameScore(peers[1].ID()))
+
+	active.remove(peers[2].ID())
+	infos = active.getPeersWithSameScore(peers[0].ID())
+	require.Len(t, infos, 1)
+	assert.Equal(t, peers[3], infos[0].peer)
+}
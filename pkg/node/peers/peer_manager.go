@@ -3,8 +3,10 @@ package peers
 import (
 	"context"
 	"fmt"
+	"math"
 	"math/big"
 	"net"
+	"net/netip"
 	"sync"
 	"time"
 
@@ -23,6 +25,10 @@ const (
 	clearRestrictedPeersInterval = 1 * time.Minute
 )
 
+// restrictReputation is the reputation at which the peer is suspended or black listed. A peer is not
+// restricted for a single invalid block or transaction, but repeated misbehaviour takes it down quickly.
+const restrictReputation = -300
+
 type peerInfo struct {
 	score *big.Int
 	peer  peer.Peer
@@ -44,6 +50,14 @@ type PeerManager interface {
 	AddToBlackList(peer peer.Peer, blockTime time.Time, reason string)
 	BlackList() []storage.BlackListedPeer
 	ClearBlackList() error
+	// UpdateReputation changes the reputation of the peer's IP according to the peer's behaviour.
+	// The peer is disconnected on harmful behaviour and restricted if its reputation drops too low.
+	UpdateReputation(p peer.Peer, b storage.Behaviour, reason string)
+	Reputations() []storage.Reputation
+	// BanSubnet bans the subnet and disconnects the connected peers from it.
+	BanSubnet(subnet netip.Prefix, reason string) error
+	UnbanSubnet(subnet netip.Prefix) error
+	BannedSubnets() []storage.BannedSubnet
 	UpdateScore(p peer.Peer, score *proto.Score) error
 	KnownPeers() []storage.KnownPeer
 	UpdateKnownPeers([]storage.KnownPeer) error
@@ -59,6 +73,8 @@ type PeerManager interface {
 
 	CheckPeerWithMaxScore(p peer.Peer) (peer.Peer, bool)
 	CheckPeerInLargestScoreGroup(p peer.Peer) (peer.Peer, bool)
+	// PeersWithSameScore returns other connected peers having the same score as the given peer.
+	PeersWithSameScore(p peer.Peer) []peer.Peer
 
 	Disconnect(peer.Peer)
 }
@@ -75,11 +91,12 @@ type PeerManagerImpl struct {
 	newConnectionsLimit       int
 	version                   proto.Version
 	networkName               string
+	rules                     ConnectionRules
 }
 
 func NewPeerManager(spawner PeerSpawner, storage PeerStorage, limitConnections int, version proto.Version,
 	networkName string, enableOutboundConnections bool, newConnectionsLimit int,
-	blackListDuration time.Duration) *PeerManagerImpl {
+	blackListDuration time.Duration, rules ConnectionRules) *PeerManagerImpl {
 
 	return &PeerManagerImpl{
 		spawner:                   spawner,
@@ -92,6 +109,7 @@ func NewPeerManager(spawner PeerSpawner, storage PeerStorage, limitConnections i
 		newConnectionsLimit:       newConnectionsLimit,
 		version:                   version,
 		networkName:               networkName,
+		rules:                     rules,
 	}
 }
 
@@ -102,12 +120,21 @@ func (a *PeerManagerImpl) NewConnection(p peer.Peer) (err error) {
 		return errors.Errorf("already connected peer '%s'", p.ID())
 	}
 
+	addr := tcpAddrIP(p.RemoteAddr())
+	always := a.rules.isAlwaysConnect(addr)
+	if !always {
+		if rErr := a.checkConnectionRules(addr); rErr != nil {
+			_ = p.Close()
+			return proto.NewInfoMsg(errors.Wrapf(rErr, "peer '%s' is rejected", p.ID()))
+		}
+	}
+
 	now := time.Now()
-	if p.Direction() == peer.Outgoing && a.suspended(p, now) {
+	if !always && p.Direction() == peer.Outgoing && a.suspended(p, now) {
 		_ = p.Close()
 		return errors.Errorf("peer '%s' is suspended", p.ID())
 	}
-	if p.Direction() == peer.Incoming && a.blackListed(p, now) {
+	if !always && p.Direction() == peer.Incoming && a.blackListed(p, now) {
 		_ = p.Close()
 		return errors.Errorf("peer '%s' is in black list", p.ID())
 	}
@@ -133,7 +160,7 @@ func (a *PeerManagerImpl) NewConnection(p peer.Peer) (err error) {
 	in, out := a.countDirections()
 	switch p.Direction() {
 	case peer.Incoming:
-		if in >= a.limitConnections {
+		if in >= a.limitConnections && !always {
 			_ = p.Close()
 			return proto.NewInfoMsg(errors.Errorf("exceed incoming connections limit, incoming peer '%s'", p.ID()))
 		}
@@ -143,7 +170,7 @@ func (a *PeerManagerImpl) NewConnection(p peer.Peer) (err error) {
 			// TODO(nickeskov): maybe log error?
 			_ = a.peerStorage.AddOrUpdateKnown([]storage.KnownPeer{known}, now)
 		}
-		if out >= a.limitConnections {
+		if out >= a.limitConnections && !always {
 			_ = p.Close()
 			return proto.NewInfoMsg(errors.Errorf("exceed outgoing connections limit, outgoing peer '%s'", p.ID()))
 		}
@@ -223,6 +250,59 @@ func (a *PeerManagerImpl) ClearBlackList() error {
 	return a.peerStorage.DropBlackList()
 }
 
+func (a *PeerManagerImpl) UpdateReputation(p peer.Peer, b storage.Behaviour, reason string) {
+	delta, disconnect := b.ReputationDelta()
+	now := time.Now()
+	ip := storage.IpFromIpPort(p.RemoteAddr().ToIpPort())
+	score := a.peerStorage.UpdateReputation(ip, delta, now)
+	zap.S().Named(logging.NetworkNamespace).Debugf("[%s] Peer reputation changed by %d to %d on %s: %s",
+		p.ID(), delta, score, b, reason)
+	if delta < 0 && score <= restrictReputation {
+		a.restrict(p, now, fmt.Sprintf("low reputation %d, last %s: %s", score, b, reason))
+	}
+	if disconnect {
+		a.Disconnect(p)
+	}
+}
+
+func (a *PeerManagerImpl) Reputations() []storage.Reputation {
+	return a.peerStorage.Reputations(time.Now())
+}
+
+func (a *PeerManagerImpl) BanSubnet(subnet netip.Prefix, reason string) error {
+	banned := storage.NewBannedSubnet(subnet, time.Now().UnixMilli(), reason)
+	if err := a.peerStorage.AddBannedSubnets([]storage.BannedSubnet{banned}); err != nil {
+		return errors.Wrapf(err, "failed to ban subnet '%s'", banned.Subnet.String())
+	}
+	zap.S().Named(logging.NetworkNamespace).Debugf("Subnet '%s' is banned, reason: %s", banned.Subnet.String(), reason)
+
+	var toDisconnect []peer.Peer
+	a.mu.RLock()
+	a.active.forEach(func(_ peer.ID, info peerInfo) {
+		addr := tcpAddrIP(info.peer.RemoteAddr())
+		if banned.Subnet.Contains(addr) && !a.rules.isAlwaysConnect(addr) {
+			toDisconnect = append(toDisconnect, info.peer)
+		}
+	})
+	a.mu.RUnlock()
+	for _, p := range toDisconnect {
+		a.Disconnect(p)
+	}
+	return nil
+}
+
+func (a *PeerManagerImpl) UnbanSubnet(subnet netip.Prefix) error {
+	banned := storage.NewBannedSubnet(subnet, 0, "")
+	if err := a.peerStorage.DeleteBannedSubnets([]storage.BannedSubnet{banned}); err != nil {
+		return errors.Wrapf(err, "failed to unban subnet '%s'", banned.Subnet.String())
+	}
+	return nil
+}
+
+func (a *PeerManagerImpl) BannedSubnets() []storage.BannedSubnet {
+	return a.peerStorage.BannedSubnets()
+}
+
 func (a *PeerManagerImpl) UpdateScore(p peer.Peer, score *big.Int) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
@@ -255,6 +335,9 @@ func (a *PeerManagerImpl) Close() error {
 			_ = info.peer.Close()
 		},
 	)
+	if err := a.peerStorage.SyncReputation(); err != nil {
+		return errors.Wrap(err, "failed to save peers reputation")
+	}
 	return nil
 }
 
@@ -262,6 +345,8 @@ func (a *PeerManagerImpl) SpawnOutgoingConnections(ctx context.Context) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	a.unsafeSpawnAlwaysConnect(ctx)
+
 	if a.unsafeConnectedCount() > a.limitConnections*2 {
 		return
 	}
@@ -282,20 +367,17 @@ func (a *PeerManagerImpl) SpawnOutgoingConnections(ctx context.Context) {
 		return
 	}
 
-	known := a.KnownPeers()
+	now := time.Now()
+	// All known peers are examined because the most reputable ones are probably connected already.
+	known := a.peerStorage.KnownByReputation(math.MaxInt, now)
 
-	active := map[proto.IpPort]struct{}{}
-	a.active.forEach(func(_ peer.ID, info peerInfo) {
-		if info.peer.Direction() == peer.Outgoing {
-			active[info.peer.RemoteAddr().ToIpPort()] = struct{}{}
-		} else {
-			if !info.peer.Handshake().DeclaredAddr.Empty() {
-				active[info.peer.Handshake().DeclaredAddr.ToIpPort()] = struct{}{}
-			}
-		}
-	})
+	active := a.unsafeActiveAddresses()
 
+	spawned := 0
 	for _, knowPeer := range known {
+		if spawned >= a.newConnectionsLimit {
+			break
+		}
 		ipPort := knowPeer.IpPort()
 		if _, ok := active[ipPort]; ok {
 			continue
@@ -303,11 +385,18 @@ func (a *PeerManagerImpl) SpawnOutgoingConnections(ctx context.Context) {
 		if _, ok := a.spawned[ipPort]; ok {
 			continue
 		}
-		if a.peerStorage.IsSuspendedIP(knowPeer.IP(), time.Now()) {
+		if a.peerStorage.IsSuspendedIP(knowPeer.IP(), now) {
+			continue
+		}
+		if a.peerStorage.Reputation(knowPeer.IP(), now) <= restrictReputation {
+			continue
+		}
+		if ip := knowPeer.IP(); a.unsafeCheckConnectionRules(ip.Addr()) != nil {
 			continue
 		}
 
 		a.spawned[ipPort] = struct{}{}
+		spawned++
 
 		go func(ipPort proto.IpPort) {
 			addr := proto.NewTCPAddr(ipPort.Addr(), ipPort.Port())
@@ -324,7 +413,17 @@ func (a *PeerManagerImpl) SpawnOutgoingConnections(ctx context.Context) {
 	}
 }
 
+// SpawnIncomingConnection checks the connection against the connection rules before the handshake,
+// NewConnection checks it once again after the handshake.
 func (a *PeerManagerImpl) SpawnIncomingConnection(ctx context.Context, conn net.Conn) error {
+	if ap, err := netip.ParseAddrPort(conn.RemoteAddr().String()); err == nil {
+		if addr := ap.Addr().Unmap(); !a.rules.isAlwaysConnect(addr) {
+			if rErr := a.checkConnectionRules(addr); rErr != nil {
+				_ = conn.Close()
+				return errors.Wrap(rErr, "incoming connection is rejected")
+			}
+		}
+	}
 	return a.spawner.SpawnIncoming(ctx, conn)
 }
 
@@ -343,16 +442,7 @@ func (a *PeerManagerImpl) Connect(ctx context.Context, addr proto.TCPAddr) error
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	active := map[proto.IpPort]struct{}{}
-	a.active.forEach(func(_ peer.ID, info peerInfo) {
-		if info.peer.Direction() == peer.Outgoing {
-			active[info.peer.RemoteAddr().ToIpPort()] = struct{}{}
-		} else {
-			if !info.peer.Handshake().DeclaredAddr.Empty() {
-				active[info.peer.Handshake().DeclaredAddr.ToIpPort()] = struct{}{}
-			}
-		}
-	})
+	active := a.unsafeActiveAddresses()
 
 	if _, ok := active[addr.ToIpPort()]; ok {
 		return nil
@@ -416,6 +506,9 @@ func (a *PeerManagerImpl) Run(ctx context.Context) {
 			return
 		case <-ticker.C:
 			a.clearRestrictedPeers(time.Now())
+			if err := a.peerStorage.SyncReputation(); err != nil {
+				zap.S().Errorf("failed to save peers reputation: %v", err)
+			}
 		}
 	}
 }
@@ -485,6 +578,18 @@ func (a *PeerManagerImpl) CheckPeerInLargestScoreGroup(p peer.Peer) (peer.Peer,
 	return np.peer, true
 }
 
+func (a *PeerManagerImpl) PeersWithSameScore(p peer.Peer) []peer.Peer {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	infos := a.active.getPeersWithSameScore(p.ID())
+	r := make([]peer.Peer, len(infos))
+	for i, info := range infos {
+		r[i] = info.peer
+	}
+	return r
+}
+
 func (a *PeerManagerImpl) connected(p peer.Peer) (peer.Peer, bool) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -540,6 +645,76 @@ func (a *PeerManagerImpl) restrict(p peer.Peer, now time.Time, reason string) {
 	}
 }
 
+// unsafeActiveAddresses returns addresses of connected peers, declared addresses are used for incoming peers.
+func (a *PeerManagerImpl) unsafeActiveAddresses() map[proto.IpPort]struct{} {
+	active := map[proto.IpPort]struct{}{}
+	a.active.forEach(func(_ peer.ID, info peerInfo) {
+		if info.peer.Direction() == peer.Outgoing {
+			active[info.peer.RemoteAddr().ToIpPort()] = struct{}{}
+		} else {
+			if !info.peer.Handshake().DeclaredAddr.Empty() {
+				active[info.peer.Handshake().DeclaredAddr.ToIpPort()] = struct{}{}
+			}
+		}
+	})
+	return active
+}
+
+// unsafeSpawnAlwaysConnect spawns connections to always-connect peers which are not connected yet.
+func (a *PeerManagerImpl) unsafeSpawnAlwaysConnect(ctx context.Context) {
+	if len(a.rules.AlwaysConnect) == 0 {
+		return
+	}
+	active := a.unsafeActiveAddresses()
+	for _, addr := range a.rules.AlwaysConnect {
+		ipPort := addr.ToIpPort()
+		if _, ok := active[ipPort]; ok {
+			continue
+		}
+		if _, ok := a.spawned[ipPort]; ok {
+			continue
+		}
+		a.spawned[ipPort] = struct{}{}
+		go func(addr proto.TCPAddr) {
+			defer a.removeSpawned(addr)
+			if err := a.spawner.SpawnOutgoing(ctx, addr); err != nil {
+				zap.S().Named(logging.NetworkNamespace).Debugf(
+					"[%s] Failed to establish connection with always-connect peer: %v", addr.String(), err)
+			}
+		}(addr)
+	}
+}
+
+func (a *PeerManagerImpl) checkConnectionRules(addr netip.Addr) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.unsafeCheckConnectionRules(addr)
+}
+
+// unsafeCheckConnectionRules checks that a new connection with the address is allowed, not banned and
+// doesn't exceed the limit of connections per subnet. Rules are not applied to always-connect peers by the caller.
+func (a *PeerManagerImpl) unsafeCheckConnectionRules(addr netip.Addr) error {
+	if !a.rules.isAllowed(addr) {
+		return errors.Errorf("address '%s' is not allowed", addr.String())
+	}
+	if a.peerStorage.IsBannedIP(storage.IP(addr.As16())) {
+		return errors.Errorf("address '%s' is banned", addr.String())
+	}
+	if limit := a.rules.MaxConnectionsPerSubnet; limit > 0 {
+		subnet := subnetOf(addr)
+		n := 0
+		a.active.forEach(func(_ peer.ID, info peerInfo) {
+			if subnet.Contains(tcpAddrIP(info.peer.RemoteAddr())) {
+				n++
+			}
+		})
+		if n >= limit {
+			return errors.Errorf("exceed limit of %d connections per subnet '%s'", limit, subnet.String())
+		}
+	}
+	return nil
+}
+
 // countDirections counts connected peers by its directions and returns number of inbound and outbound connections.
 func (a *PeerManagerImpl) countDirections() (int, int) {
 	in, out := 0, 0
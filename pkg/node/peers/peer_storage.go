@@ -11,6 +11,7 @@ type PeerStorage interface {
 	AddOrUpdateKnown(known []storage.KnownPeer, now time.Time) error
 	DeleteKnown(known []storage.KnownPeer) error
 	DropKnown() error
+	KnownByReputation(limit int, now time.Time) []storage.KnownPeer
 
 	Suspended(now time.Time) []storage.SuspendedPeer
 	AddSuspended(suspended []storage.SuspendedPeer) error
@@ -28,5 +29,17 @@ type PeerStorage interface {
 	RefreshBlackList(now time.Time) error
 	DropBlackList() error
 
+	Reputation(ip storage.IP, now time.Time) int64
+	Reputations(now time.Time) []storage.Reputation
+	UpdateReputation(ip storage.IP, delta int64, now time.Time) int64
+	SyncReputation() error
+	DropReputation() error
+
+	BannedSubnets() []storage.BannedSubnet
+	AddBannedSubnets(banned []storage.BannedSubnet) error
+	DeleteBannedSubnets(banned []storage.BannedSubnet) error
+	IsBannedIP(ip storage.IP) bool
+	DropBannedSubnets() error
+
 	DropStorage() error
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/wavesplatform/gowaves/pkg/mock"
 	"github.com/wavesplatform/gowaves/pkg/node/peers/storage"
+	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 )
 
@@ -41,3 +42,48 @@ func TestPeerManagerImpl_Suspend(t *testing.T) {
 
 	manager.Suspend(p, now, reason)
 }
+
+func TestPeerManagerImpl_UpdateReputation(t *testing.T) {
+	tcpAddr := proto.NewTCPAddrFromString("32.34.46.1:4535")
+	ip := storage.IpFromIpPort(tcpAddr.ToIpPort())
+
+	t.Run("good behaviour", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		p := mock.NewMockPeer(ctrl)
+		p.EXPECT().RemoteAddr().Return(tcpAddr).AnyTimes()
+		p.EXPECT().ID().AnyTimes()
+		peerStorage := mock.NewMockPeerStorage(ctrl)
+		peerStorage.EXPECT().UpdateReputation(ip, int64(5), gomock.Any()).Return(int64(5))
+
+		manager := PeerManagerImpl{peerStorage: peerStorage}
+		manager.UpdateReputation(p, storage.ValidBlock, "block applied")
+	})
+
+	t.Run("bad behaviour disconnects", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		p := mock.NewMockPeer(ctrl)
+		p.EXPECT().RemoteAddr().Return(tcpAddr).AnyTimes()
+		p.EXPECT().ID().AnyTimes()
+		p.EXPECT().Close()
+		peerStorage := mock.NewMockPeerStorage(ctrl)
+		peerStorage.EXPECT().UpdateReputation(ip, int64(-200), gomock.Any()).Return(int64(-200))
+
+		manager := PeerManagerImpl{peerStorage: peerStorage}
+		manager.UpdateReputation(p, storage.InvalidBlock, "invalid block")
+	})
+
+	t.Run("low reputation suspends", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		p := mock.NewMockPeer(ctrl)
+		p.EXPECT().RemoteAddr().Return(tcpAddr).AnyTimes()
+		p.EXPECT().ID().AnyTimes()
+		p.EXPECT().Direction().Return(peer.Outgoing)
+		p.EXPECT().Close()
+		peerStorage := mock.NewMockPeerStorage(ctrl)
+		peerStorage.EXPECT().UpdateReputation(ip, int64(-5), gomock.Any()).Return(int64(restrictReputation))
+		peerStorage.EXPECT().AddSuspended(gomock.Len(1))
+
+		manager := PeerManagerImpl{peerStorage: peerStorage}
+		manager.UpdateReputation(p, storage.UselessTransaction, "useless transaction")
+	})
+}
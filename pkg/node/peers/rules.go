@@ -0,0 +1,86 @@
+package peers
+
+import (
+	"net/netip"
+
+	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+const (
+	ipv4SubnetBits = 24
+	ipv6SubnetBits = 64
+)
+
+// ConnectionRules restrict the set of peers the node connects to and accepts connections from.
+type ConnectionRules struct {
+	// AlwaysConnect peers are connected regardless of the connection limits, bans and restrictions,
+	// the node reconnects to them after disconnection.
+	AlwaysConnect []proto.TCPAddr
+	// AllowOnly limits connections to the peers from the given subnets and AlwaysConnect peers.
+	// Empty list allows connections to any peer.
+	AllowOnly []netip.Prefix
+	// MaxConnectionsPerSubnet limits the number of connections to peers from one /24 IPv4 or /64 IPv6 subnet.
+	// Zero value means no limit.
+	MaxConnectionsPerSubnet int
+}
+
+func (r ConnectionRules) isAlwaysConnect(addr netip.Addr) bool {
+	for _, a := range r.AlwaysConnect {
+		if tcpAddrIP(a) == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (r ConnectionRules) isAllowed(addr netip.Addr) bool {
+	if len(r.AllowOnly) == 0 {
+		return true
+	}
+	for _, p := range r.AllowOnly {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return r.isAlwaysConnect(addr)
+}
+
+// ParseSubnet parses the subnet in CIDR notation, a single IP address is parsed as the subnet of one address.
+// The host bits of the subnet are cleared.
+func ParseSubnet(s string) (netip.Prefix, error) {
+	if p, err := netip.ParsePrefix(s); err == nil {
+		if p.Addr().Is4In6() {
+			return netip.Prefix{}, errors.Errorf("IPv4-mapped IPv6 subnet %q is not supported", s)
+		}
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, errors.Errorf("invalid subnet or IP address %q", s)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// subnetOf returns /24 subnet for IPv4 address and /64 subnet for IPv6 address.
+func subnetOf(addr netip.Addr) netip.Prefix {
+	bits := ipv6SubnetBits
+	if addr.Is4() {
+		bits = ipv4SubnetBits
+	}
+	p, err := addr.Prefix(bits)
+	if err != nil { // Only for invalid address, which has no subnet.
+		return netip.Prefix{}
+	}
+	return p
+}
+
+func tcpAddrIP(a proto.TCPAddr) netip.Addr {
+	addr, ok := netip.AddrFromSlice(a.IP)
+	if !ok {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
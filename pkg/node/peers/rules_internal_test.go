@@ -0,0 +1,84 @@
+package peers
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/mock"
+	"github.com/wavesplatform/gowaves/pkg/node/peers/storage"
+	p2pMock "github.com/wavesplatform/gowaves/pkg/p2p/mock"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+func TestParseSubnet(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		out string
+	}{
+		{"13.3.4.1/24", "13.3.4.0/24"},
+		{"13.3.4.1", "13.3.4.1/32"},
+		{"::ffff:13.3.4.1", "13.3.4.1/32"},
+		{"2001:db8:1:2::7/64", "2001:db8:1:2::/64"},
+		{"2001:db8::1", "2001:db8::1/128"},
+	} {
+		s, err := ParseSubnet(tc.in)
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.out, s.String())
+	}
+	for _, in := range []string{"", "13.3.4", "13.3.4.1/33", "::ffff:13.3.4.1/120", "host"} {
+		_, err := ParseSubnet(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestSubnetOf(t *testing.T) {
+	assert.Equal(t, "13.3.4.0/24", subnetOf(netip.MustParseAddr("13.3.4.1")).String())
+	assert.Equal(t, "2001:db8:1:2::/64", subnetOf(netip.MustParseAddr("2001:db8:1:2:3:4:5:6")).String())
+}
+
+func TestConnectionRulesIsAllowed(t *testing.T) {
+	rules := ConnectionRules{}
+	assert.True(t, rules.isAllowed(netip.MustParseAddr("13.3.4.1")))
+
+	rules = ConnectionRules{
+		AlwaysConnect: []proto.TCPAddr{proto.NewTCPAddrFromString("42.54.1.6:6868")},
+		AllowOnly:     []netip.Prefix{netip.MustParsePrefix("13.3.4.0/24")},
+	}
+	assert.True(t, rules.isAllowed(netip.MustParseAddr("13.3.4.1")))
+	assert.True(t, rules.isAllowed(netip.MustParseAddr("42.54.1.6")))
+	assert.True(t, rules.isAlwaysConnect(netip.MustParseAddr("42.54.1.6")))
+	assert.False(t, rules.isAllowed(netip.MustParseAddr("13.3.5.1")))
+	assert.False(t, rules.isAlwaysConnect(netip.MustParseAddr("13.3.4.1")))
+}
+
+func TestPeerManagerImpl_CheckConnectionRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	banned := netip.MustParseAddr("3.54.1.9")
+	peerStorage := mock.NewMockPeerStorage(ctrl)
+	peerStorage.EXPECT().IsBannedIP(gomock.Any()).DoAndReturn(func(ip storage.IP) bool {
+		return ip.Addr() == banned
+	}).AnyTimes()
+
+	manager := PeerManagerImpl{
+		active:      newActivePeers(),
+		peerStorage: peerStorage,
+		rules: ConnectionRules{
+			AllowOnly:               []netip.Prefix{netip.MustParsePrefix("3.0.0.0/8"), netip.MustParsePrefix("13.3.4.0/24")},
+			MaxConnectionsPerSubnet: 2,
+		},
+	}
+	for _, addr := range []string{"13.3.4.1:6868", "13.3.4.2:6868"} {
+		manager.active.add(&p2pMock.Peer{Addr: addr, RemoteAddress: proto.NewTCPAddrFromString(addr)})
+	}
+
+	assert.NoError(t, manager.checkConnectionRules(netip.MustParseAddr("3.54.1.10")))
+	assert.ErrorContains(t, manager.checkConnectionRules(banned), "banned")
+	assert.ErrorContains(t, manager.checkConnectionRules(netip.MustParseAddr("42.54.1.6")), "not allowed")
+	assert.ErrorContains(t, manager.checkConnectionRules(netip.MustParseAddr("13.3.4.3")), "limit")
+}
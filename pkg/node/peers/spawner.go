@@ -3,20 +3,26 @@ package peers
 import (
 	"context"
 	"net"
+	"time"
 
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/wavesplatform/gowaves/pkg/logging"
+	"github.com/wavesplatform/gowaves/pkg/networking"
 	"github.com/wavesplatform/gowaves/pkg/node/messages"
-	"github.com/wavesplatform/gowaves/pkg/p2p/conn"
-	"github.com/wavesplatform/gowaves/pkg/p2p/incoming"
-	"github.com/wavesplatform/gowaves/pkg/p2p/outgoing"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
+	"github.com/wavesplatform/gowaves/pkg/p2p/secure"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 )
 
+const outgoingPeerDialTimeout = 5 * time.Second
+
 type DuplicateChecker interface {
 	Add([]byte) bool
 }
 
-func NewSkipFilter(list *messages.SkipMessageList) conn.SkipFilter {
+func NewSkipFilter(list *messages.SkipMessageList) peer.SkipFilter {
 	return func(header proto.Header) bool {
 		return func(h proto.Header, l *messages.SkipMessageList) bool {
 			for _, id := range l.List() {
@@ -36,51 +42,71 @@ type PeerSpawner interface {
 
 type PeerSpawnerImpl struct {
 	parent       peer.Parent
+	network      *networking.Network
+	protocol     *peer.Protocol
 	wavesNetwork string
 	declAddr     proto.TCPAddr
-	skipFunc     conn.SkipFilter
 	nodeName     string
 	nodeNonce    uint64
 	version      proto.Version
+	secure       *secure.Config
 }
 
-func NewPeerSpawner(parent peer.Parent, WavesNetwork string, declAddr proto.TCPAddr, nodeName string, nodeNonce uint64, version proto.Version) *PeerSpawnerImpl {
+// NewPeerSpawner creates the spawner of peers, nil secure configuration disables the secure transport.
+func NewPeerSpawner(parent peer.Parent, WavesNetwork string, declAddr proto.TCPAddr, nodeName string, nodeNonce uint64, version proto.Version, secureCfg *secure.Config) *PeerSpawnerImpl {
 	return &PeerSpawnerImpl{
-		skipFunc:     NewSkipFilter(parent.SkipMessageList),
 		parent:       parent,
+		network:      networking.NewNetwork(),
+		protocol:     peer.NewProtocol(NewSkipFilter(parent.SkipMessageList)),
 		wavesNetwork: WavesNetwork,
 		declAddr:     declAddr,
 		nodeName:     nodeName,
 		nodeNonce:    nodeNonce,
 		version:      version,
+		secure:       secureCfg,
 	}
 }
 
 func (a *PeerSpawnerImpl) SpawnOutgoing(ctx context.Context, address proto.TCPAddr) error {
-	params := outgoing.EstablishParams{
-		Address:      address,
-		WavesNetwork: a.wavesNetwork,
-		Parent:       a.parent,
-		DeclAddr:     a.declAddr,
-		Skip:         a.skipFunc,
-		NodeName:     a.nodeName,
-		NodeNonce:    a.nodeNonce,
+	addr := address.String()
+	dialer := net.Dialer{Timeout: outgoingPeerDialTimeout}
+	c, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		zap.S().Named(logging.NetworkNamespace).Debugf("Outgoing connection to address '%s' failed with error: %v",
+			addr, err)
+		return errors.Wrapf(err, "failed to dial with addr %q", addr)
 	}
-
-	return outgoing.EstablishConnection(ctx, params, a.version)
+	return a.runSession(ctx, c, peer.Outgoing)
 }
 
 func (a *PeerSpawnerImpl) SpawnIncoming(ctx context.Context, c net.Conn) error {
-	params := incoming.PeerParams{
-		WavesNetwork: a.wavesNetwork,
-		Conn:         c,
-		Skip:         a.skipFunc,
-		Parent:       a.parent,
-		DeclAddr:     a.declAddr,
+	return a.runSession(ctx, c, peer.Incoming)
+}
+
+func (a *PeerSpawnerImpl) runSession(ctx context.Context, c net.Conn, direction peer.Direction) error {
+	params := peer.SessionParams{
+		Conn:      c,
+		Direction: direction,
+		Parent:    a.parent,
+		Protocol:  a.protocol,
+		Handshake: a.handshake,
+		Secure:    a.secure,
+	}
+	if err := peer.RunSession(ctx, a.network, params); err != nil {
+		zap.S().Named(logging.NetworkNamespace).Debugf("%s connection with '%s' failed: %v",
+			direction, c.RemoteAddr(), err)
+		return err
+	}
+	return nil
+}
+
+func (a *PeerSpawnerImpl) handshake(_ *proto.Handshake) proto.Handshake {
+	return proto.Handshake{
+		AppName:      a.wavesNetwork,
+		Version:      a.version,
 		NodeName:     a.nodeName,
 		NodeNonce:    a.nodeNonce,
-		Version:      a.version,
+		DeclaredAddr: proto.HandshakeTCPAddr(a.declAddr),
+		Timestamp:    proto.NewTimestampFromTime(time.Now()),
 	}
-
-	return incoming.RunIncomingPeer(ctx, params)
 }
@@ -3,6 +3,7 @@ package storage
 import (
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -29,6 +30,11 @@ type CBORStorage struct {
 	blackListFilePath string
 	known             knownPeers // Map of all ever known peers with a publicly available declared address and the last connection attempt timestamp.
 	knownFilePath     string
+	reputation        reputations
+	reputationPath    string
+	reputationDirty   bool // Reputation in memory has changes not written to the file.
+	banned            bannedSubnets
+	bannedFilePath    string
 }
 
 type restrictedPeersID byte
@@ -82,6 +88,14 @@ func newCBORStorageInDir(storageDir string, now time.Time, currVersion int) (*CB
 	if err := createFileIfNotExist(blackListFile); err != nil {
 		return nil, errors.Wrapf(err, "failed to create black list peers storage file")
 	}
+	reputationFile := reputationFilePath(storageDir)
+	if err := createFileIfNotExist(reputationFile); err != nil {
+		return nil, errors.Wrap(err, "failed to create peers reputation storage file")
+	}
+	bannedFile := bannedFilePath(storageDir)
+	if err := createFileIfNotExist(bannedFile); err != nil {
+		return nil, errors.Wrap(err, "failed to create banned subnets storage file")
+	}
 
 	storage := &CBORStorage{
 		storageDir:        storageDir,
@@ -91,6 +105,10 @@ func newCBORStorageInDir(storageDir string, now time.Time, currVersion int) (*CB
 		blackListFilePath: blackListFile,
 		known:             knownPeers{},
 		knownFilePath:     knownFile,
+		reputation:        reputations{},
+		reputationPath:    reputationFile,
+		banned:            bannedSubnets{},
+		bannedFilePath:    bannedFile,
 	}
 
 	versionFile := storageVersionFilePath(storageDir)
@@ -126,6 +144,12 @@ func newCBORStorageInDir(storageDir string, now time.Time, currVersion int) (*CB
 	if err := unmarshalCborFromFile(blackListFile, &storage.blackList); err != nil && err != io.EOF {
 		return nil, errors.Wrapf(err, "failed to load black list peers from file %q", blackListFile)
 	}
+	if err := unmarshalCborFromFile(reputationFile, &storage.reputation); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "failed to load peers reputation from file %q", reputationFile)
+	}
+	if err := unmarshalCborFromFile(bannedFile, &storage.banned); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "failed to load banned subnets from file %q", bannedFile)
+	}
 
 	if len(storage.suspended) != 0 {
 		// Remove expired peers
@@ -375,7 +399,151 @@ func (bs *CBORStorage) DropBlackList() error {
 	return bs.dropRestricted(blackListedPeersID)
 }
 
+// KnownByReputation returns known peers with the best reputation first.
+func (bs *CBORStorage) KnownByReputation(limit int, now time.Time) []KnownPeer {
+	bs.rwMutex.RLock()
+	defer bs.rwMutex.RUnlock()
+	return bs.known.BestFirst(limit, bs.reputation, now)
+}
+
+// Reputation returns the reputation of the IP at the given time, the score of unknown IP is zero.
+func (bs *CBORStorage) Reputation(ip IP, now time.Time) int64 {
+	bs.rwMutex.RLock()
+	defer bs.rwMutex.RUnlock()
+	r, ok := bs.reputation[ip]
+	if !ok {
+		return 0
+	}
+	return r.ScoreAt(now)
+}
+
+// Reputations returns reputations of all peers with non-zero score at the given time.
+func (bs *CBORStorage) Reputations(now time.Time) []Reputation {
+	bs.rwMutex.RLock()
+	defer bs.rwMutex.RUnlock()
+
+	out := make([]Reputation, 0, len(bs.reputation))
+	for _, r := range bs.reputation {
+		if score := r.ScoreAt(now); score != 0 {
+			out = append(out, Reputation{IP: r.IP, Score: float64(score), UpdateTimestampMillis: r.UpdateTimestampMillis})
+		}
+	}
+	return out
+}
+
+// UpdateReputation changes the reputation of the IP by delta and returns the resulting score, which is limited
+// by MinReputation and MaxReputation. Reputation changes often, so it's updated only in memory and written
+// to the file by SyncReputation.
+func (bs *CBORStorage) UpdateReputation(ip IP, delta int64, now time.Time) int64 {
+	bs.rwMutex.Lock()
+	defer bs.rwMutex.Unlock()
+
+	r := bs.reputation[ip]
+	score := max(MinReputation, min(MaxReputation, r.decayedScore(now)+float64(delta)))
+	rounded := int64(math.Round(score))
+	if rounded == 0 {
+		delete(bs.reputation, ip)
+	} else {
+		bs.reputation[ip] = Reputation{IP: ip, Score: score, UpdateTimestampMillis: now.UnixMilli()}
+	}
+	bs.reputationDirty = true
+	return rounded
+}
+
+// SyncReputation writes the reputation changes made since the last sync to the storage file.
+func (bs *CBORStorage) SyncReputation() error {
+	bs.rwMutex.Lock()
+	defer bs.rwMutex.Unlock()
+
+	if !bs.reputationDirty {
+		return nil
+	}
+	if err := marshalToCborAndSyncToFile(bs.reputationPath, bs.reputation); err != nil {
+		return errors.Wrap(err, "failed to marshal peers reputation and sync storage")
+	}
+	bs.reputationDirty = false
+	return nil
+}
+
+// DropReputation clears reputation in memory cache and truncates reputation storage file.
+func (bs *CBORStorage) DropReputation() error {
+	bs.rwMutex.Lock()
+	defer bs.rwMutex.Unlock()
+	return bs.unsafeDropReputation()
+}
+
+// BannedSubnets returns all subnets banned by the node operator.
+func (bs *CBORStorage) BannedSubnets() []BannedSubnet {
+	bs.rwMutex.RLock()
+	defer bs.rwMutex.RUnlock()
+
+	out := make([]BannedSubnet, 0, len(bs.banned))
+	for _, b := range bs.banned {
+		out = append(out, b)
+	}
+	return out
+}
+
+// AddBannedSubnets adds or updates banned subnets in peers storage with strong error guarantees.
+func (bs *CBORStorage) AddBannedSubnets(banned []BannedSubnet) error {
+	if len(banned) == 0 {
+		return nil
+	}
+
+	bs.rwMutex.Lock()
+	defer bs.rwMutex.Unlock()
+
+	backup := bs.unsafeBannedIntersection(banned)
+	for _, b := range banned {
+		bs.banned[b.Subnet.String()] = b
+	}
+	if err := bs.unsafeSyncBanned(banned, backup); err != nil {
+		return errors.Wrap(err, "failed to add banned subnets")
+	}
+	return nil
+}
+
+// DeleteBannedSubnets removes the bans of subnets from peers storage with strong error guarantees.
+func (bs *CBORStorage) DeleteBannedSubnets(banned []BannedSubnet) error {
+	if len(banned) == 0 {
+		return nil
+	}
+
+	bs.rwMutex.Lock()
+	defer bs.rwMutex.Unlock()
+
+	backup := bs.unsafeBannedIntersection(banned)
+	for _, b := range banned {
+		delete(bs.banned, b.Subnet.String())
+	}
+	// newEntries is nil because there is no new entries
+	if err := bs.unsafeSyncBanned(nil, backup); err != nil {
+		return errors.Wrap(err, "failed to delete banned subnets")
+	}
+	return nil
+}
+
+// IsBannedIP checks that the IP belongs to any of banned subnets.
+func (bs *CBORStorage) IsBannedIP(ip IP) bool {
+	bs.rwMutex.RLock()
+	defer bs.rwMutex.RUnlock()
+	return bs.banned.contains(ip.Addr())
+}
+
+// DropBannedSubnets clears banned subnets in memory cache and truncates banned subnets storage file.
+func (bs *CBORStorage) DropBannedSubnets() error {
+	bs.rwMutex.Lock()
+	defer bs.rwMutex.Unlock()
+
+	if err := os.Truncate(bs.bannedFilePath, 0); err != nil {
+		return errors.Wrapf(err, "failed to drop banned subnets storage file %q", bs.bannedFilePath)
+	}
+	bs.banned = bannedSubnets{}
+	return nil
+}
+
 // DropStorage clear storage memory cache and truncates storage files.
+// Banned subnets are set by the node operator, so they are kept.
 // In case of error we can lose suspended peers storage file, but honestly it's almost impossible case.
 func (bs *CBORStorage) DropStorage() error {
 	bs.rwMutex.Lock()
@@ -390,9 +558,15 @@ func (bs *CBORStorage) DropStorage() error {
 		return errors.Wrap(err, "failed to drop black list peers storage")
 	}
 
+	reputationBackup := bs.reputation
+	if err := bs.unsafeDropReputation(); err != nil {
+		return errors.Wrap(err, "failed to drop peers reputation storage")
+	}
+
 	if err := bs.unsafeDropKnown(); err != nil {
 		bs.suspended = suspendedBackup
 		bs.blackList = blackListBackup
+		bs.reputation = reputationBackup
 		// It's almost impossible case, but if it happens we have inconsistency in suspended peers,
 		// but honestly it's not fatal error
 		if syncErr := marshalToCborAndSyncToFile(bs.suspendedFilePath, bs.suspended); syncErr != nil {
@@ -401,6 +575,9 @@ func (bs *CBORStorage) DropStorage() error {
 		if syncErr := marshalToCborAndSyncToFile(bs.blackListFilePath, bs.blackList); syncErr != nil {
 			return errors.Wrapf(err, "failed to sync black list peers storage from backup: %v", syncErr)
 		}
+		if syncErr := marshalToCborAndSyncToFile(bs.reputationPath, bs.reputation); syncErr != nil {
+			return errors.Wrapf(err, "failed to sync peers reputation storage from backup: %v", syncErr)
+		}
 		return errors.Wrap(err, "failed to drop known peers storage")
 	}
 	return nil
@@ -448,6 +625,40 @@ func (bs *CBORStorage) unsafeDropKnown() error {
 	return nil
 }
 
+func (bs *CBORStorage) unsafeBannedIntersection(banned []BannedSubnet) bannedSubnets {
+	intersection := bannedSubnets{}
+	for _, b := range banned {
+		key := b.Subnet.String()
+		if old, in := bs.banned[key]; in {
+			intersection[key] = old
+		}
+	}
+	return intersection
+}
+
+func (bs *CBORStorage) unsafeSyncBanned(newEntries []BannedSubnet, backup bannedSubnets) error {
+	if err := marshalToCborAndSyncToFile(bs.bannedFilePath, bs.banned); err != nil {
+		// In case of failure restore initial state from backup
+		for _, b := range newEntries {
+			delete(bs.banned, b.Subnet.String())
+		}
+		for k, v := range backup {
+			bs.banned[k] = v
+		}
+		return errors.Wrap(err, "failed to marshal banned subnets and sync storage")
+	}
+	return nil
+}
+
+func (bs *CBORStorage) unsafeDropReputation() error {
+	if err := os.Truncate(bs.reputationPath, 0); err != nil {
+		return errors.Wrapf(err, "failed to drop reputation storage file %q", bs.reputationPath)
+	}
+	bs.reputation = reputations{}
+	bs.reputationDirty = false
+	return nil
+}
+
 func (bs *CBORStorage) restrictedFilePathByID(restrictedID restrictedPeersID) string {
 	switch restrictedID {
 	case suspendedPeersID:
@@ -567,6 +778,14 @@ func blackListFilePath(storageDir string) string {
 	return filepath.Join(storageDir, "peers_black_list.cbor")
 }
 
+func reputationFilePath(storageDir string) string {
+	return filepath.Join(storageDir, "peers_reputation.cbor")
+}
+
+func bannedFilePath(storageDir string) string {
+	return filepath.Join(storageDir, "peers_banned_subnets.cbor")
+}
+
 func storageVersionFilePath(storageDir string) string {
 	return filepath.Join(storageDir, "peers_storage_version.txt")
 }
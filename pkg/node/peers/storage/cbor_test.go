@@ -2,6 +2,7 @@ package storage
 
 import (
 	"io"
+	"net/netip"
 	"os"
 	"path/filepath"
 	"testing"
@@ -617,3 +618,95 @@ func (s *binaryStorageCborSuite) TestCBORStorageDropsAndVersioning() {
 		checkKnownStorageFile()
 	})
 }
+
+func (s *binaryStorageCborSuite) TestCBORStorageReputation() {
+	now := s.now.Truncate(time.Millisecond)
+	ip1 := IPFromString("13.3.4.1")
+	ip2 := IPFromString("3.54.1.9")
+
+	s.Run("update and clamp reputation", func() {
+		assert.Equal(s.T(), int64(0), s.storage.Reputation(ip1, now))
+		assert.Equal(s.T(), int64(-200), s.storage.UpdateReputation(ip1, -200, now))
+		assert.Equal(s.T(), int64(MinReputation), s.storage.UpdateReputation(ip1, 2*MinReputation, now))
+		assert.Equal(s.T(), int64(MaxReputation), s.storage.UpdateReputation(ip2, 2*MaxReputation, now))
+		assert.Equal(s.T(), int64(MinReputation), s.storage.Reputation(ip1, now))
+		assert.Len(s.T(), s.storage.Reputations(now), 2)
+	})
+
+	s.Run("sync and load reputation", func() {
+		require.NoError(s.T(), s.storage.SyncReputation())
+		storage, err := newCBORStorageInDir(s.storage.storageDir, now, peersStorageCurrentVersion)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(MinReputation), storage.Reputation(ip1, now))
+		assert.Equal(s.T(), int64(MaxReputation), storage.Reputation(ip2, now))
+		assert.Equal(s.T(), int64(MaxReputation/2), storage.Reputation(ip2, now.Add(reputationHalfLife)))
+	})
+
+	s.Run("frequent updates do not stop decay", func() {
+		ip3 := IPFromString("7.7.7.7")
+		s.storage.UpdateReputation(ip3, 800, now)
+		var score int64
+		for i := 1; i <= 24; i++ {
+			score = s.storage.UpdateReputation(ip3, 1, now.Add(time.Duration(i)*reputationHalfLife/24))
+		}
+		// 800 halves in a half-life, every +1 is decayed since its update: 400 + sum(2^(-k/24)) for k in [0, 23] = 417.56.
+		assert.Equal(s.T(), int64(418), score)
+		s.storage.UpdateReputation(ip3, -score, now.Add(reputationHalfLife))
+	})
+
+	s.Run("zero reputation is removed", func() {
+		assert.Equal(s.T(), int64(0), s.storage.UpdateReputation(ip2, -MaxReputation, now))
+		rs := s.storage.Reputations(now)
+		require.Len(s.T(), rs, 1)
+		assert.Equal(s.T(), Reputation{IP: ip1, Score: MinReputation, UpdateTimestampMillis: now.UnixMilli()}, rs[0])
+	})
+
+	s.Run("drop reputation", func() {
+		require.NoError(s.T(), s.storage.DropReputation())
+		var unmarshalled reputations
+		require.Equal(s.T(), io.EOF, unmarshalCborFromFile(s.storage.reputationPath, &unmarshalled))
+		assert.Empty(s.T(), s.storage.Reputations(now))
+	})
+}
+
+func (s *binaryStorageCborSuite) TestCBORStorageBannedSubnets() {
+	now := s.now.Truncate(time.Millisecond)
+	banned := []BannedSubnet{
+		NewBannedSubnet(netip.MustParsePrefix("13.3.4.1/24"), now.UnixMilli(), "some reason #1"),
+		NewBannedSubnet(netip.MustParsePrefix("2001:db8:1:2::/64"), now.UnixMilli(), "some reason #2"),
+		NewBannedSubnet(netip.MustParsePrefix("42.54.1.6/32"), now.UnixMilli(), ""),
+	}
+
+	s.Run("add and check banned subnets", func() {
+		require.NoError(s.T(), s.storage.AddBannedSubnets(banned))
+		assert.ElementsMatch(s.T(), banned, s.storage.BannedSubnets())
+		assert.Equal(s.T(), "13.3.4.0/24", banned[0].Subnet.String())
+		assert.True(s.T(), s.storage.IsBannedIP(IPFromString("13.3.4.200")))
+		assert.True(s.T(), s.storage.IsBannedIP(IPFromString("2001:db8:1:2::5")))
+		assert.True(s.T(), s.storage.IsBannedIP(IPFromString("42.54.1.6")))
+		assert.False(s.T(), s.storage.IsBannedIP(IPFromString("13.3.5.1")))
+		assert.False(s.T(), s.storage.IsBannedIP(IPFromString("2001:db8:1:3::5")))
+		assert.False(s.T(), s.storage.IsBannedIP(IPFromString("42.54.1.7")))
+	})
+
+	s.Run("banned subnets are loaded and survive storage drop", func() {
+		require.NoError(s.T(), s.storage.DropStorage())
+		storage, err := newCBORStorageInDir(s.storage.storageDir, now, peersStorageCurrentVersion)
+		require.NoError(s.T(), err)
+		assert.ElementsMatch(s.T(), banned, storage.BannedSubnets())
+		assert.True(s.T(), storage.IsBannedIP(IPFromString("13.3.4.200")))
+	})
+
+	s.Run("delete banned subnets", func() {
+		require.NoError(s.T(), s.storage.DeleteBannedSubnets(banned[:1]))
+		assert.ElementsMatch(s.T(), banned[1:], s.storage.BannedSubnets())
+		assert.False(s.T(), s.storage.IsBannedIP(IPFromString("13.3.4.200")))
+	})
+
+	s.Run("drop banned subnets", func() {
+		require.NoError(s.T(), s.storage.DropBannedSubnets())
+		var unmarshalled bannedSubnets
+		require.Equal(s.T(), io.EOF, unmarshalCborFromFile(s.storage.bannedFilePath, &unmarshalled))
+		assert.Empty(s.T(), s.storage.BannedSubnets())
+	})
+}
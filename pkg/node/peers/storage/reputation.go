@@ -0,0 +1,59 @@
+package storage
+
+import "fmt"
+
+// Behaviour is the observed behaviour of a peer, which changes the peer's reputation.
+type Behaviour byte
+
+const (
+	ValidBlock         Behaviour = iota + 1 // Block or microblock from the peer was applied
+	ValidTransaction                        // Transaction from the peer was added to UTX pool
+	InvalidBlock                            // Block from the peer failed validation
+	InvalidMicroBlock                       // Microblock from the peer failed validation
+	InvalidTransaction                      // Transaction from the peer is malformed
+	UselessTransaction                      // Transaction from the peer can't be applied to the state
+	SyncTimeout                             // Peer failed to deliver requested blocks in time
+)
+
+func (b Behaviour) String() string {
+	switch b {
+	case ValidBlock:
+		return "ValidBlock"
+	case ValidTransaction:
+		return "ValidTransaction"
+	case InvalidBlock:
+		return "InvalidBlock"
+	case InvalidMicroBlock:
+		return "InvalidMicroBlock"
+	case InvalidTransaction:
+		return "InvalidTransaction"
+	case UselessTransaction:
+		return "UselessTransaction"
+	case SyncTimeout:
+		return "SyncTimeout"
+	default:
+		return fmt.Sprintf("Behaviour(%d)", byte(b))
+	}
+}
+
+// ReputationDelta returns the change of reputation for the behaviour and whether the peer should be disconnected.
+func (b Behaviour) ReputationDelta() (int64, bool) {
+	switch b {
+	case ValidBlock:
+		return 5, false
+	case ValidTransaction:
+		return 1, false
+	case InvalidBlock:
+		return -200, true
+	case InvalidMicroBlock:
+		return -100, true
+	case InvalidTransaction:
+		return -50, true
+	case UselessTransaction:
+		return -5, false
+	case SyncTimeout: // A slow peer is not disconnected for a single timeout, only the low reputation restricts it.
+		return -30, false
+	default:
+		return 0, false
+	}
+}
@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"math"
 	"net"
+	"net/netip"
 	"sort"
 	"time"
 
@@ -14,6 +16,11 @@ func (i *IP) String() string {
 	return net.IP(i[:]).String()
 }
 
+// Addr converts the IP into netip.Addr, IPv4 addresses are unmapped from IPv6.
+func (i *IP) Addr() netip.Addr {
+	return netip.AddrFrom16(*i).Unmap()
+}
+
 func IPFromString(s string) IP {
 	parsed := net.ParseIP(s)
 	ip := IP{}
@@ -123,3 +130,84 @@ func (a knownPeers) OldestFirst(limit int) []KnownPeer {
 	}
 	return r
 }
+
+const (
+	// MinReputation and MaxReputation limit the reputation score of a peer.
+	MinReputation = -1000
+	MaxReputation = 1000
+	// reputationHalfLife is the period in which the reputation score loses half of its absolute value,
+	// so peers are forgiven for old misbehaviour and have to confirm their good reputation.
+	reputationHalfLife = 24 * time.Hour
+)
+
+// Reputation is the score of the peer's behaviour, positive for useful peers and negative for harmful ones.
+// Score is fractional to keep the continuous decay exact between frequent updates.
+type Reputation struct {
+	IP                    IP      `cbor:"0,keyasint,omitempty"`
+	Score                 float64 `cbor:"1,keyasint,omitempty"`
+	UpdateTimestampMillis int64   `cbor:"2,keyasint,omitempty"`
+}
+
+func (r *Reputation) UpdateTime() time.Time {
+	return time.UnixMilli(r.UpdateTimestampMillis)
+}
+
+// ScoreAt returns the score decayed towards zero to the given time and rounded to integer.
+func (r *Reputation) ScoreAt(now time.Time) int64 {
+	return int64(math.Round(r.decayedScore(now)))
+}
+
+// decayedScore returns the score multiplied by 2^(-elapsed/halfLife), where elapsed is the time since the update.
+func (r *Reputation) decayedScore(now time.Time) float64 {
+	elapsed := now.Sub(r.UpdateTime())
+	if elapsed <= 0 {
+		return r.Score
+	}
+	return r.Score * math.Exp2(-float64(elapsed)/float64(reputationHalfLife))
+}
+
+type reputations map[IP]Reputation
+
+// BestFirst returns known peers ordered by the reputation of their IPs, the least recently tried peers go first
+// among peers with equal reputation.
+func (a knownPeers) BestFirst(limit int, rs reputations, now time.Time) []KnownPeer {
+	all := a.OldestFirst(len(a))
+	scores := make(map[IP]int64, len(rs))
+	for ip, r := range rs {
+		scores[ip] = r.ScoreAt(now)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return scores[all[i].IP()] > scores[all[j].IP()]
+	})
+	if len(all) > limit {
+		all = all[:limit]
+	}
+	return all
+}
+
+// BannedSubnet is a range of IP addresses banned by the node operator. Unlike black list, bans never expire.
+type BannedSubnet struct {
+	Subnet             netip.Prefix `cbor:"0,keyasint,omitempty"`
+	BanTimestampMillis int64        `cbor:"1,keyasint,omitempty"`
+	Reason             string       `cbor:"2,keyasint,omitempty"`
+}
+
+func NewBannedSubnet(subnet netip.Prefix, banTimestampMillis int64, reason string) BannedSubnet {
+	return BannedSubnet{
+		Subnet:             subnet.Masked(),
+		BanTimestampMillis: banTimestampMillis,
+		Reason:             reason,
+	}
+}
+
+// bannedSubnets is a map of banned subnets by the subnet in CIDR notation.
+type bannedSubnets map[string]BannedSubnet
+
+func (a bannedSubnets) contains(addr netip.Addr) bool {
+	for _, b := range a {
+		if b.Subnet.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -46,3 +47,33 @@ func TestKnownOldestFirst(t *testing.T) {
 	expected := []KnownPeer{p4, p3, p2, p1}
 	assert.Equal(t, expected, r)
 }
+
+func TestReputationScoreAt(t *testing.T) {
+	now := time.Now()
+	r := Reputation{IP: IPFromString("1.2.3.4"), Score: -400, UpdateTimestampMillis: now.UnixMilli()}
+	assert.Equal(t, int64(-400), r.ScoreAt(now.Add(-time.Hour)))
+	assert.Equal(t, int64(-283), r.ScoreAt(now.Add(reputationHalfLife/2)))
+	assert.Equal(t, int64(-200), r.ScoreAt(now.Add(reputationHalfLife)))
+	assert.Equal(t, int64(-100), r.ScoreAt(now.Add(2*reputationHalfLife)))
+	assert.Equal(t, int64(0), r.ScoreAt(now.Add(100*reputationHalfLife)))
+}
+
+func TestKnownBestFirst(t *testing.T) {
+	now := time.Now()
+	p1 := KnownPeer(proto.NewIpPortFromTcpAddr(proto.NewTCPAddrFromString("1.1.1.1:1")))
+	p2 := KnownPeer(proto.NewIpPortFromTcpAddr(proto.NewTCPAddrFromString("2.2.2.2:2")))
+	p3 := KnownPeer(proto.NewIpPortFromTcpAddr(proto.NewTCPAddrFromString("3.3.3.3:3")))
+	p4 := KnownPeer(proto.NewIpPortFromTcpAddr(proto.NewTCPAddrFromString("4.4.4.4:4")))
+	ps := knownPeers{}
+	ps[p1] = 3
+	ps[p2] = 2
+	ps[p3] = 1
+	ps[p4] = 0
+	rs := reputations{
+		p1.IP(): {IP: p1.IP(), Score: 50, UpdateTimestampMillis: now.UnixMilli()},
+		p3.IP(): {IP: p3.IP(), Score: -10, UpdateTimestampMillis: now.UnixMilli()},
+	}
+
+	assert.Equal(t, []KnownPeer{p1, p4, p2, p3}, ps.BestFirst(10, rs, now))
+	assert.Equal(t, []KnownPeer{p1, p4}, ps.BestFirst(2, rs, now))
+}
@@ -3,7 +3,6 @@ package mock
 import (
 	"sync"
 
-	"github.com/wavesplatform/gowaves/pkg/p2p/conn"
 	"github.com/wavesplatform/gowaves/pkg/p2p/peer"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 )
@@ -50,10 +49,6 @@ func (*Peer) Close() error {
 	panic("implement me")
 }
 
-func (*Peer) Connection() conn.Connection {
-	panic("implement me")
-}
-
 func (a *Peer) SendMessage(m proto.Message) {
 	a.mu.Lock()
 	a.SendMessageCalledWith = append(a.SendMessageCalledWith, m)
@@ -1,10 +1,7 @@
 package peer
 
 import (
-	"github.com/valyala/bytebufferpool"
-
 	"github.com/wavesplatform/gowaves/pkg/node/messages"
-	"github.com/wavesplatform/gowaves/pkg/p2p/conn"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 )
 
@@ -13,20 +10,6 @@ const (
 	chSizeInLightMode = defaultChSize * 2
 )
 
-type Remote struct {
-	ToCh   chan []byte
-	FromCh chan *bytebufferpool.ByteBuffer
-	ErrCh  chan error
-}
-
-func NewRemote() Remote {
-	return Remote{
-		ToCh:   make(chan []byte, chSizeInLightMode),
-		FromCh: make(chan *bytebufferpool.ByteBuffer, chSizeInLightMode),
-		ErrCh:  make(chan error, 10),
-	}
-}
-
 type Parent struct {
 	MessageCh       chan ProtoMessage
 	InfoCh          chan InfoMessage
@@ -52,7 +35,6 @@ type Peer interface {
 	Close() error
 	SendMessage(proto.Message)
 	ID() ID
-	Connection() conn.Connection
 	Handshake() proto.Handshake
 	RemoteAddr() proto.TCPAddr
 	Equal(Peer) bool
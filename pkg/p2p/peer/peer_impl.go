@@ -10,7 +10,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/wavesplatform/gowaves/pkg/logging"
-	"github.com/wavesplatform/gowaves/pkg/p2p/conn"
+	"github.com/wavesplatform/gowaves/pkg/networking"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 )
 
@@ -38,26 +38,40 @@ func (id peerImplID) String() string {
 	return fmt.Sprintf("%s-%d", addr.String(), id.nonce)
 }
 
+// PeerImpl is the peer on top of the networking session.
 type PeerImpl struct {
 	handshake proto.Handshake
-	conn      conn.Connection
+	session   *networking.Session
 	direction Direction
-	remote    Remote
 	id        peerImplID
+	remote    proto.TCPAddr
+	local     net.Addr
+	sendCh    chan []byte
+	fail      func(error)
 	cancel    context.CancelFunc
 }
 
-func NewPeerImpl(handshake proto.Handshake, conn conn.Connection, direction Direction, remote Remote, cancel context.CancelFunc) (*PeerImpl, error) {
-	id, err := newPeerImplID(conn.Conn().RemoteAddr(), handshake.NodeNonce)
+func newPeerImpl(
+	handshake proto.Handshake, session *networking.Session, c net.Conn, direction Direction,
+	fail func(error), cancel context.CancelFunc,
+) (*PeerImpl, error) {
+	id, err := newPeerImplID(c.RemoteAddr(), handshake.NodeNonce)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create new peer")
 	}
+	var remote proto.TCPAddr
+	if addr, ok := c.RemoteAddr().(*net.TCPAddr); ok {
+		remote = proto.TCPAddr(*addr)
+	}
 	return &PeerImpl{
 		handshake: handshake,
-		conn:      conn,
+		session:   session,
 		direction: direction,
-		remote:    remote,
 		id:        id,
+		remote:    remote,
+		local:     c.LocalAddr(),
+		sendCh:    make(chan []byte, chSizeInLightMode),
+		fail:      fail,
 		cancel:    cancel,
 	}, nil
 }
@@ -66,14 +80,15 @@ func (a *PeerImpl) Direction() Direction {
 	return a.direction
 }
 
+// Close cancels the context of the peer before closing the session, so the closing of the connection
+// is not reported as an error to the parent.
 func (a *PeerImpl) Close() error {
-	defer a.cancel()
-	return a.conn.Close()
+	a.cancel()
+	return a.session.Close()
 }
 
-// SendMessage marshals provided message and sends it to its internal Remote.ToCh channel.
-// It sends the error to internal Remote.ErrCh if Remote.ToCh is full.
-// That notifies Handle to propagate this error to FMS through Parent.InfoCh.
+// SendMessage marshals provided message and puts it into the send queue.
+// It reports the error to the parent through Parent.InfoCh if the queue is full.
 func (a *PeerImpl) SendMessage(m proto.Message) {
 	b, err := m.MarshalBinary()
 	if err != nil {
@@ -82,18 +97,29 @@ func (a *PeerImpl) SendMessage(m proto.Message) {
 	}
 	zap.S().Named(logging.NetworkDataNamespace).Debugf("[%s] Sending to network: %s", a.id, proto.B64Bytes(b))
 	select {
-	case a.remote.ToCh <- b:
+	case a.sendCh <- b:
 	default:
-		a.remote.ErrCh <- errors.Errorf("remote channel overflow on peer '%s'", a.id)
+		a.fail(errors.Errorf("send queue overflow on peer '%s'", a.id))
 	}
 }
 
-func (a *PeerImpl) ID() ID {
-	return a.id
+// sendLoop writes queued messages to the session until the context is canceled or a write fails.
+func (a *PeerImpl) sendLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case b := <-a.sendCh:
+			if _, err := a.session.Write(b); err != nil {
+				a.fail(errors.Wrapf(err, "failed to send message to peer '%s'", a.id))
+				return
+			}
+		}
+	}
 }
 
-func (a *PeerImpl) Connection() conn.Connection {
-	return a.conn
+func (a *PeerImpl) ID() ID {
+	return a.id
 }
 
 func (a *PeerImpl) Handshake() proto.Handshake {
@@ -101,8 +127,12 @@ func (a *PeerImpl) Handshake() proto.Handshake {
 }
 
 func (a *PeerImpl) RemoteAddr() proto.TCPAddr {
-	addr := a.Connection().Conn().RemoteAddr().(*net.TCPAddr)
-	return proto.TCPAddr(*addr)
+	return a.remote
+}
+
+// LocalAddr returns the local address of the connection.
+func (a *PeerImpl) LocalAddr() net.Addr {
+	return a.local
 }
 
 func (a *PeerImpl) Equal(other Peer) bool {
@@ -4,7 +4,6 @@
 package peer
 
 import (
-	"github.com/wavesplatform/gowaves/pkg/p2p/conn"
 	"github.com/wavesplatform/gowaves/pkg/proto"
 	"sync"
 )
@@ -22,9 +21,6 @@ var _ Peer = &mockPeer{}
 //			CloseFunc: func() error {
 //				panic("mock out the Close method")
 //			},
-//			ConnectionFunc: func() conn.Connection {
-//				panic("mock out the Connection method")
-//			},
 //			DirectionFunc: func() Direction {
 //				panic("mock out the Direction method")
 //			},
@@ -53,9 +49,6 @@ type mockPeer struct {
 	// CloseFunc mocks the Close method.
 	CloseFunc func() error
 
-	// ConnectionFunc mocks the Connection method.
-	ConnectionFunc func() conn.Connection
-
 	// DirectionFunc mocks the Direction method.
 	DirectionFunc func() Direction
 
@@ -79,9 +72,6 @@ type mockPeer struct {
 		// Close holds details about calls to the Close method.
 		Close []struct {
 		}
-		// Connection holds details about calls to the Connection method.
-		Connection []struct {
-		}
 		// Direction holds details about calls to the Direction method.
 		Direction []struct {
 		}
@@ -106,7 +96,6 @@ type mockPeer struct {
 		}
 	}
 	lockClose       sync.RWMutex
-	lockConnection  sync.RWMutex
 	lockDirection   sync.RWMutex
 	lockEqual       sync.RWMutex
 	lockHandshake   sync.RWMutex
@@ -142,33 +131,6 @@ func (mock *mockPeer) CloseCalls() []struct {
 	return calls
 }
 
-// Connection calls ConnectionFunc.
-func (mock *mockPeer) Connection() conn.Connection {
-	if mock.ConnectionFunc == nil {
-		panic("mockPeer.ConnectionFunc: method is nil but Peer.Connection was just called")
-	}
-	callInfo := struct {
-	}{}
-	mock.lockConnection.Lock()
-	mock.calls.Connection = append(mock.calls.Connection, callInfo)
-	mock.lockConnection.Unlock()
-	return mock.ConnectionFunc()
-}
-
-// ConnectionCalls gets all the calls that were made to Connection.
-// Check the length with:
-//
-//	len(mockedPeer.ConnectionCalls())
-func (mock *mockPeer) ConnectionCalls() []struct {
-} {
-	var calls []struct {
-	}
-	mock.lockConnection.RLock()
-	calls = mock.calls.Connection
-	mock.lockConnection.RUnlock()
-	return calls
-}
-
 // Direction calls DirectionFunc.
 func (mock *mockPeer) Direction() Direction {
 	if mock.DirectionFunc == nil {
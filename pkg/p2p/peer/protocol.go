@@ -0,0 +1,68 @@
+package peer
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/networking"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+const maxMessageSize = 100 << 20 // 100 MiB
+
+// SkipFilter reports whether the message with the given header should be discarded without reading.
+type SkipFilter func(proto.Header) bool
+
+// Protocol implements networking.Protocol for the Waves handshake and message framing.
+// The handshake is not validated here, the owner of the connection decides whether to keep the peer.
+type Protocol struct {
+	skip SkipFilter
+}
+
+// NewProtocol creates the protocol, messages accepted by the skip filter are discarded. Nil filter accepts all.
+func NewProtocol(skip SkipFilter) *Protocol {
+	return &Protocol{skip: skip}
+}
+
+func (p *Protocol) EmptyHandshake() networking.Handshake {
+	return &proto.Handshake{}
+}
+
+func (p *Protocol) EmptyHeader() networking.Header {
+	return &header{}
+}
+
+// Ping returns GetPeers message, it's used to check the connection, answers are just the usual peers messages.
+func (p *Protocol) Ping() ([]byte, error) {
+	return (&proto.GetPeersMessage{}).MarshalBinary()
+}
+
+func (p *Protocol) IsAcceptableHandshake(_ *networking.Session, h networking.Handshake) bool {
+	_, ok := h.(*proto.Handshake)
+	return ok
+}
+
+func (p *Protocol) IsAcceptableMessage(_ *networking.Session, h networking.Header) bool {
+	hdr, ok := h.(*header)
+	if !ok {
+		return false
+	}
+	return p.skip == nil || !p.skip(hdr.Header)
+}
+
+// header limits the size of incoming messages, a session fails on reading of a too long message.
+type header struct {
+	proto.Header
+}
+
+func (h *header) ReadFrom(r io.Reader) (int64, error) {
+	n, err := h.Header.ReadFrom(r)
+	if err != nil {
+		return n, err
+	}
+	if l := uint64(h.HeaderLength()) + uint64(h.PayloadLength()); l > maxMessageSize {
+		return n, errors.Errorf("received too long message, size=%d > max=%d", l, maxMessageSize)
+	}
+	return n, nil
+}
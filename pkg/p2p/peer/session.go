@@ -0,0 +1,210 @@
+package peer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/exp/zapslog"
+
+	"github.com/wavesplatform/gowaves/pkg/logging"
+	"github.com/wavesplatform/gowaves/pkg/networking"
+	"github.com/wavesplatform/gowaves/pkg/p2p/secure"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+const (
+	sessionWriteTimeout      = 15 * time.Second
+	sessionKeepAliveInterval = 1 * time.Minute
+	sessionReadTimeout       = 5 * time.Minute
+)
+
+var errConnectionClosed = errors.New("connection closed")
+
+// HandshakeFunc creates the handshake to send to the remote side. For incoming connections it receives
+// the handshake of the remote side, for outgoing connections the handshake is sent first and the argument is nil.
+type HandshakeFunc func(remote *proto.Handshake) proto.Handshake
+
+// SessionParams describes the connection to run a session on.
+type SessionParams struct {
+	Conn      net.Conn
+	Direction Direction
+	Parent    Parent
+	Protocol  *Protocol
+	Handshake HandshakeFunc
+	// Secure enables the secure transport with the peers supporting it, nil disables it.
+	Secure *secure.Config
+}
+
+// RunSession runs the Waves protocol session over the connection no matter outgoing or incoming it is.
+// After the handshake the peer is announced to the parent with Connected message, received messages are sent
+// to Parent.MessageCh and the first failure of the session is reported with InternalErr message.
+// RunSession consumes the connection and closes it when the function ends. It returns an error only if the
+// session failed before the handshake, otherwise it returns after the peer is closed or the failure is reported.
+func RunSession(ctx context.Context, n *networking.Network, params SessionParams) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	handshakeSent := false
+	if params.Secure != nil {
+		c, err := negotiateTransport(params)
+		if err != nil {
+			_ = params.Conn.Close()
+			return errors.Wrapf(err, "%s transport negotiation with '%s' failed", params.Direction,
+				params.Conn.RemoteAddr())
+		}
+		params.Conn = c
+		handshakeSent = true
+	}
+	h := &sessionHandler{
+		ctx: ctx, cancel: cancel, params: params, handshakeSent: handshakeSent, errCh: make(chan error, 1),
+	}
+	s, err := n.NewSession(ctx, params.Conn, newSessionConfig(params, h))
+	if err != nil {
+		_ = params.Conn.Close()
+		return errors.Wrap(err, "failed to start session")
+	}
+	defer func() {
+		if clErr := s.Close(); clErr != nil {
+			zap.S().Named(logging.NetworkNamespace).Debugf("Failed to close %s session with '%s': %v",
+				params.Direction, s.RemoteAddr(), clErr)
+		}
+	}()
+
+	if params.Direction == Outgoing && !handshakeSent {
+		if wErr := writeHandshake(s, params.Handshake(nil)); wErr != nil {
+			return wErr
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return nil
+	case sErr := <-h.errCh:
+		if ctx.Err() != nil { // The peer was closed by the owner.
+			return nil
+		}
+		p := h.peer.Load()
+		if p == nil {
+			return errors.Wrapf(sErr, "%s session with '%s' failed", params.Direction, s.RemoteAddr())
+		}
+		select {
+		case params.Parent.InfoCh <- InfoMessage{Peer: p, Value: &InternalErr{Err: sErr}}:
+		case <-ctx.Done():
+		}
+		return nil
+	}
+}
+
+func newSessionConfig(params SessionParams, h networking.Handler) *networking.Config {
+	return networking.NewConfig().
+		WithProtocol(params.Protocol).
+		WithHandler(h).
+		WithSlogHandler(zapslog.NewHandler(zap.L().Core(), zapslog.WithName(logging.NetworkNamespace))).
+		WithWriteTimeout(sessionWriteTimeout).
+		WithKeepAliveInterval(sessionKeepAliveInterval).
+		WithReadTimeout(sessionReadTimeout).
+		WithSlogAttribute(slog.String("direction", params.Direction.String()))
+}
+
+func writeHandshake(s *networking.Session, hs proto.Handshake) error {
+	buf := new(bytes.Buffer)
+	if _, err := hs.WriteTo(buf); err != nil {
+		return errors.Wrap(err, "failed to marshal handshake")
+	}
+	if _, err := s.Write(buf.Bytes()); err != nil {
+		return errors.Wrapf(err, "failed to send handshake to '%s'", s.RemoteAddr())
+	}
+	return nil
+}
+
+// sessionHandler connects the session to the parent. The handler never blocks the receive loop of the session
+// for long, all failures are passed to RunSession, which reports the first one.
+type sessionHandler struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	params SessionParams
+	// handshakeSent is set if the handshake was sent during the transport negotiation.
+	handshakeSent bool
+	peer          atomic.Pointer[PeerImpl]
+	failed        atomic.Bool
+	errCh         chan error
+}
+
+func (h *sessionHandler) fail(err error) {
+	h.failed.Store(true)
+	select {
+	case h.errCh <- err:
+	default: // The first error is already waiting to be reported.
+	}
+}
+
+func (h *sessionHandler) OnHandshake(s *networking.Session, hs networking.Handshake) {
+	remote := clearSecureTransportFlag(*hs.(*proto.Handshake)) // The type is checked by the Protocol.
+	if h.params.Direction == Incoming && !h.handshakeSent {
+		if err := writeHandshake(s, h.params.Handshake(&remote)); err != nil {
+			h.fail(err)
+			return
+		}
+	}
+	p, err := newPeerImpl(remote, s, h.params.Conn, h.params.Direction, h.fail, h.cancel)
+	if err != nil {
+		h.fail(err)
+		return
+	}
+	h.peer.Store(p)
+	go p.sendLoop(h.ctx)
+	select {
+	case h.params.Parent.InfoCh <- InfoMessage{Peer: p, Value: &Connected{Peer: p}}: // notify parent
+	case <-h.ctx.Done():
+	}
+}
+
+func (h *sessionHandler) OnHandshakeFailed(_ *networking.Session, _ networking.Handshake) {
+	h.fail(errors.New("unacceptable handshake"))
+}
+
+func (h *sessionHandler) OnReceive(_ *networking.Session, r io.Reader) {
+	p := h.peer.Load()
+	if p == nil || h.failed.Load() {
+		return
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		h.fail(err)
+		return
+	}
+	zap.S().Named(logging.NetworkDataNamespace).Debugf("[%s] Receiving from network: %s", p.ID(), proto.B64Bytes(b))
+	if mErr := bytesToMessage(b, h.params.Parent.MessageCh, p); mErr != nil {
+		h.fail(mErr)
+	}
+}
+
+func (h *sessionHandler) OnClose(_ *networking.Session) {
+	h.fail(errConnectionClosed)
+}
+
+func bytesToMessage(data []byte, resendTo chan ProtoMessage, p Peer) error {
+	m, err := proto.UnmarshalMessage(data)
+	if err != nil {
+		return err
+	}
+
+	mess := ProtoMessage{
+		ID:      p,
+		Message: m,
+	}
+
+	select {
+	case resendTo <- mess:
+	default:
+		zap.S().Named(logging.NetworkNamespace).Debugf(
+			"[%s] Failed to resend message of type '%T' because upstream channel is full", p.ID(), m)
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package peer
+
+import (
+	"context"
+	"crypto/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/networking"
+	"github.com/wavesplatform/gowaves/pkg/p2p/secure"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+const testTimeout = 5 * time.Second
+
+func testConnPair(t *testing.T) (net.Conn, net.Conn) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, aErr := l.Accept()
+		if aErr != nil {
+			close(accepted)
+			return
+		}
+		accepted <- c
+	}()
+	out, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	in, ok := <-accepted
+	require.True(t, ok)
+	return in, out
+}
+
+func testHandshake(nonce uint64) HandshakeFunc {
+	return func(_ *proto.Handshake) proto.Handshake {
+		return proto.Handshake{
+			AppName:   "wavesT",
+			Version:   proto.ProtocolVersion(),
+			NodeName:  "test",
+			NodeNonce: nonce,
+			Timestamp: proto.NewTimestampFromTime(time.Now()),
+		}
+	}
+}
+
+func receiveInfo(t *testing.T, parent Parent) InfoMessage {
+	select {
+	case m := <-parent.InfoCh:
+		return m
+	case <-time.After(testTimeout):
+		require.Fail(t, "no info message received")
+		return InfoMessage{}
+	}
+}
+
+func runTestSession(ctx context.Context, n *networking.Network, params SessionParams) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- RunSession(ctx, n, params)
+	}()
+	return done
+}
+
+func TestRunSession(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := networking.NewNetwork()
+	in, out := testConnPair(t)
+	inParent, outParent := NewParent(false), NewParent(false)
+	protocol := NewProtocol(nil)
+
+	inDone := runTestSession(ctx, n, SessionParams{
+		Conn: in, Direction: Incoming, Parent: inParent, Protocol: protocol, Handshake: testHandshake(1),
+	})
+	outDone := runTestSession(ctx, n, SessionParams{
+		Conn: out, Direction: Outgoing, Parent: outParent, Protocol: protocol, Handshake: testHandshake(2),
+	})
+
+	inPeer := receiveInfo(t, inParent).Value.(*Connected).Peer
+	assert.Equal(t, Incoming, inPeer.Direction())
+	assert.Equal(t, uint64(2), inPeer.Handshake().NodeNonce)
+	outPeer := receiveInfo(t, outParent).Value.(*Connected).Peer
+	assert.Equal(t, Outgoing, outPeer.Direction())
+	assert.Equal(t, uint64(1), outPeer.Handshake().NodeNonce)
+	assert.Equal(t, out.RemoteAddr().String(), outPeer.RemoteAddr().String())
+
+	outPeer.SendMessage(&proto.GetPeersMessage{})
+	select {
+	case m := <-inParent.MessageCh:
+		assert.Equal(t, inPeer, m.ID)
+		assert.IsType(t, &proto.GetPeersMessage{}, m.Message)
+	case <-time.After(testTimeout):
+		require.Fail(t, "no message received")
+	}
+
+	// Closing of the peer is not reported to its own parent, but the other side is notified.
+	require.NoError(t, outPeer.Close())
+	require.NoError(t, <-outDone)
+	m := receiveInfo(t, inParent)
+	assert.Equal(t, inPeer, m.Peer)
+	assert.IsType(t, &InternalErr{}, m.Value)
+	require.NoError(t, <-inDone)
+	assert.Empty(t, outParent.InfoCh)
+}
+
+func TestRunSessionSkipsMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := networking.NewNetwork()
+	in, out := testConnPair(t)
+	inParent, outParent := NewParent(false), NewParent(false)
+	skip := func(h proto.Header) bool { return h.ContentID == proto.ContentIDGetPeers }
+
+	runTestSession(ctx, n, SessionParams{
+		Conn: in, Direction: Incoming, Parent: inParent, Protocol: NewProtocol(skip), Handshake: testHandshake(1),
+	})
+	runTestSession(ctx, n, SessionParams{
+		Conn: out, Direction: Outgoing, Parent: outParent, Protocol: NewProtocol(nil), Handshake: testHandshake(2),
+	})
+	receiveInfo(t, inParent)
+	outPeer := receiveInfo(t, outParent).Value.(*Connected).Peer
+
+	outPeer.SendMessage(&proto.GetPeersMessage{})
+	outPeer.SendMessage(&proto.PeersMessage{})
+	select {
+	case m := <-inParent.MessageCh:
+		assert.IsType(t, &proto.PeersMessage{}, m.Message)
+	case <-time.After(testTimeout):
+		require.Fail(t, "no message received")
+	}
+}
+
+func TestRunSessionFailsBeforeHandshake(t *testing.T) {
+	n := networking.NewNetwork()
+	in, out := testConnPair(t)
+	parent := NewParent(false)
+
+	done := runTestSession(context.Background(), n, SessionParams{
+		Conn: in, Direction: Incoming, Parent: parent, Protocol: NewProtocol(nil), Handshake: testHandshake(1),
+	})
+	require.NoError(t, out.Close())
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(testTimeout):
+		require.Fail(t, "session is still running")
+	}
+	assert.Empty(t, parent.InfoCh)
+}
+
+func testIdentityKey(t *testing.T) (crypto.SecretKey, crypto.PublicKey) {
+	seed := make([]byte, 32)
+	_, err := rand.Read(seed)
+	require.NoError(t, err)
+	sk, pk, err := crypto.GenerateKeyPair(seed)
+	require.NoError(t, err)
+	return sk, pk
+}
+
+func testSecureConfig(t *testing.T, trusted ...crypto.PublicKey) (*secure.Config, crypto.PublicKey) {
+	sk, pk := testIdentityKey(t)
+	return secure.NewConfig(sk, trusted), pk
+}
+
+func TestRunSessionTransportNegotiation(t *testing.T) {
+	inSecure, inKey := testSecureConfig(t)
+	outSecure, outKey := testSecureConfig(t)
+	requiredIn, _ := testSecureConfig(t, outKey)
+	requiredOut, _ := testSecureConfig(t, inKey)
+	for _, test := range []struct {
+		name      string
+		inSecure  *secure.Config
+		outSecure *secure.Config
+	}{
+		{"both secure", inSecure, outSecure},
+		{"incoming secure only", inSecure, nil},
+		{"outgoing secure only", nil, outSecure},
+		{"incoming requires trusted key", requiredIn, outSecure},
+		{"outgoing requires trusted key", inSecure, requiredOut},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			n := networking.NewNetwork()
+			in, out := testConnPair(t)
+			inParent, outParent := NewParent(false), NewParent(false)
+			protocol := NewProtocol(nil)
+
+			runTestSession(ctx, n, SessionParams{
+				Conn: in, Direction: Incoming, Parent: inParent, Protocol: protocol, Handshake: testHandshake(1),
+				Secure: test.inSecure,
+			})
+			runTestSession(ctx, n, SessionParams{
+				Conn: out, Direction: Outgoing, Parent: outParent, Protocol: protocol, Handshake: testHandshake(2),
+				Secure: test.outSecure,
+			})
+			inPeer := receiveInfo(t, inParent).Value.(*Connected).Peer
+			outPeer := receiveInfo(t, outParent).Value.(*Connected).Peer
+			// The secure transport flag is not visible in handshakes.
+			assert.Equal(t, proto.ProtocolVersion(), inPeer.Handshake().Version)
+			assert.Equal(t, proto.ProtocolVersion(), outPeer.Handshake().Version)
+
+			outPeer.SendMessage(&proto.GetPeersMessage{})
+			select {
+			case m := <-inParent.MessageCh:
+				assert.IsType(t, &proto.GetPeersMessage{}, m.Message)
+			case <-time.After(testTimeout):
+				require.Fail(t, "no message received")
+			}
+		})
+	}
+}
+
+func TestRunSessionSecureTransportRequired(t *testing.T) {
+	_, trusted := testSecureConfig(t)
+	required, _ := testSecureConfig(t, trusted)
+	untrusted, _ := testSecureConfig(t)
+	for _, test := range []struct {
+		name     string
+		remote   *secure.Config
+		expected string
+	}{
+		{"not supported", nil, "secure transport is not supported"},
+		{"untrusted key", untrusted, "is not trusted"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			n := networking.NewNetwork()
+			in, out := testConnPair(t)
+			runTestSession(ctx, n, SessionParams{
+				Conn: out, Direction: Outgoing, Parent: NewParent(false), Protocol: NewProtocol(nil),
+				Handshake: testHandshake(2), Secure: test.remote,
+			})
+			parent := NewParent(false)
+			done := runTestSession(ctx, n, SessionParams{
+				Conn: in, Direction: Incoming, Parent: parent, Protocol: NewProtocol(nil), Handshake: testHandshake(1),
+				Secure: required,
+			})
+			select {
+			case err := <-done:
+				assert.ErrorContains(t, err, test.expected)
+			case <-time.After(testTimeout):
+				require.Fail(t, "session is still running")
+			}
+			assert.Empty(t, parent.InfoCh)
+		})
+	}
+}
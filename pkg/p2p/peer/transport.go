@@ -0,0 +1,137 @@
+package peer
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/wavesplatform/gowaves/pkg/logging"
+	"github.com/wavesplatform/gowaves/pkg/p2p/secure"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// secureTransportFlag is set in the patch number of the handshake version to announce the support of secure
+// transport. Nodes check only major and minor numbers of the version, so the flag is invisible for other nodes.
+// The flag is sent in plaintext, so an active man in the middle can clear it in the handshakes of both sides
+// and downgrade the connection to plaintext, the prologue of the secure handshake detects only the flag cleared
+// in one direction. Also, without the trusted keys any identity key is accepted, so the man in the middle can
+// establish secure connections with both sides. Only the secure transport required with the trusted keys
+// (see secure.Config.Required) protects against the active attacker.
+const secureTransportFlag uint32 = 1 << 30
+
+const transportNegotiationTimeout = 30 * time.Second
+
+// negotiateTransport exchanges handshakes over the plain connection and switches the connection to the secure
+// transport if both sides support it. The returned connection replays the handshake of the remote side,
+// so the session reads it as usual.
+func negotiateTransport(params SessionParams) (net.Conn, error) {
+	c := params.Conn
+	if err := c.SetDeadline(time.Now().Add(transportNegotiationTimeout)); err != nil {
+		return nil, errors.Wrap(err, "failed to set deadline")
+	}
+	var local, remote []byte
+	var remoteHS proto.Handshake
+	var err error
+	switch params.Direction {
+	case Outgoing:
+		if local, err = writeRawHandshake(c, params.Handshake(nil)); err != nil {
+			return nil, err
+		}
+		if remote, remoteHS, err = readRawHandshake(c); err != nil {
+			return nil, err
+		}
+	case Incoming:
+		if remote, remoteHS, err = readRawHandshake(c); err != nil {
+			return nil, err
+		}
+		cleaned := clearSecureTransportFlag(remoteHS)
+		if local, err = writeRawHandshake(c, params.Handshake(&cleaned)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("unexpected direction %d", params.Direction)
+	}
+
+	if remoteHS.Version.Patch()&secureTransportFlag == 0 {
+		if params.Secure.Required() {
+			return nil, errors.Errorf("secure transport is not supported by '%s'", c.RemoteAddr())
+		}
+		zap.S().Named(logging.NetworkNamespace).Debugf("Secure transport is not supported by '%s'", c.RemoteAddr())
+		if err = c.SetDeadline(time.Time{}); err != nil {
+			return nil, errors.Wrap(err, "failed to reset deadline")
+		}
+		return &replayConn{Conn: c, replay: remote}, nil
+	}
+
+	var sc *secure.Conn
+	if params.Direction == Outgoing {
+		sc, err = secure.Client(c, params.Secure, prologue(local, remote))
+	} else {
+		sc, err = secure.Server(c, params.Secure, prologue(remote, local))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "secure handshake with '%s' failed", c.RemoteAddr())
+	}
+	if err = c.SetDeadline(time.Time{}); err != nil {
+		return nil, errors.Wrap(err, "failed to reset deadline")
+	}
+	zap.S().Named(logging.NetworkNamespace).Debugf("Secure transport established with '%s', identity key '%s'",
+		c.RemoteAddr(), sc.RemotePublicKey())
+	return &replayConn{Conn: sc, replay: remote}, nil
+}
+
+// prologue binds the secure handshake to the plain handshakes of the initiator and the responder.
+func prologue(initiator, responder []byte) []byte {
+	p := make([]byte, 0, len(initiator)+len(responder))
+	p = append(p, initiator...)
+	return append(p, responder...)
+}
+
+// writeRawHandshake sends the handshake with the secure transport flag and returns the sent bytes.
+func writeRawHandshake(w io.Writer, hs proto.Handshake) ([]byte, error) {
+	v := hs.Version
+	hs.Version = proto.NewVersion(v.Major(), v.Minor(), v.Patch()|secureTransportFlag)
+	buf := new(bytes.Buffer)
+	if _, err := hs.WriteTo(buf); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal handshake")
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return nil, errors.Wrap(err, "failed to send handshake")
+	}
+	return buf.Bytes(), nil
+}
+
+// readRawHandshake reads the handshake and returns it along with the received bytes.
+func readRawHandshake(r io.Reader) ([]byte, proto.Handshake, error) {
+	buf := new(bytes.Buffer)
+	var hs proto.Handshake
+	if _, err := hs.ReadFrom(io.TeeReader(r, buf)); err != nil {
+		return nil, proto.Handshake{}, errors.Wrap(err, "failed to read handshake")
+	}
+	return buf.Bytes(), hs, nil
+}
+
+func clearSecureTransportFlag(hs proto.Handshake) proto.Handshake {
+	v := hs.Version
+	hs.Version = proto.NewVersion(v.Major(), v.Minor(), v.Patch()&^secureTransportFlag)
+	return hs
+}
+
+// replayConn returns the replayed data before reading from the connection.
+type replayConn struct {
+	net.Conn
+	replay []byte
+}
+
+func (c *replayConn) Read(b []byte) (int, error) {
+	if len(c.replay) > 0 {
+		n := copy(b, c.replay)
+		c.replay = c.replay[n:]
+		return n, nil
+	}
+	return c.Conn.Read(b)
+}
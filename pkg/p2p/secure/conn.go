@@ -0,0 +1,368 @@
+package secure
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/curve25519"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+)
+
+const (
+	frameHeaderSize = 2
+	maxFrameSize    = math.MaxUint16
+	maxPlaintext    = maxFrameSize - tagSize
+)
+
+// Config holds the identity key of the node and the identity keys of nodes allowed to connect.
+type Config struct {
+	sk      crypto.SecretKey
+	pk      crypto.PublicKey
+	trusted map[crypto.PublicKey]struct{}
+	rand    io.Reader // Source of ephemeral keys, crypto/rand is used if nil.
+}
+
+// NewConfig creates the configuration of secure transport. If the list of trusted keys is not empty
+// only the nodes with these identity keys are accepted and the secure transport is required with all peers.
+func NewConfig(sk crypto.SecretKey, trusted []crypto.PublicKey) *Config {
+	t := make(map[crypto.PublicKey]struct{}, len(trusted))
+	for _, pk := range trusted {
+		t[pk] = struct{}{}
+	}
+	return &Config{sk: sk, pk: crypto.GeneratePublicKey(sk), trusted: t}
+}
+
+// PublicKey returns the identity public key of the node.
+func (c *Config) PublicKey() crypto.PublicKey {
+	return c.pk
+}
+
+// Required reports whether the connections without secure transport should be rejected.
+// The secure transport can't be required only with the trusted nodes, because the identity key of a peer
+// is unknown until the secure handshake is completed. So if the trusted keys are set, all plaintext
+// connections are rejected along with the secure connections with the nodes having other identity keys.
+func (c *Config) Required() bool {
+	return len(c.trusted) > 0
+}
+
+func (c *Config) random() io.Reader {
+	if c.rand != nil {
+		return c.rand
+	}
+	return rand.Reader
+}
+
+func (c *Config) checkTrusted(pk crypto.PublicKey) error {
+	if !c.Required() {
+		return nil
+	}
+	if _, ok := c.trusted[pk]; !ok {
+		return errors.Errorf("identity key '%s' is not trusted", pk.String())
+	}
+	return nil
+}
+
+// Conn is the connection with encrypted and authenticated data stream.
+type Conn struct {
+	net.Conn
+	remote crypto.PublicKey
+
+	rmu     sync.Mutex
+	recv    *cipherState
+	frame   []byte
+	pending []byte // decrypted data which is not read yet
+
+	wmu  sync.Mutex
+	send *cipherState
+	out  []byte
+}
+
+// Client runs the handshake on the connection as initiator. Prologue is the data both sides agreed on before
+// the handshake, the handshake fails if the prologues are different.
+func Client(conn net.Conn, cfg *Config, prologue []byte) (*Conn, error) {
+	hs, err := newHandshakeState(cfg, prologue)
+	if err != nil {
+		return nil, err
+	}
+	// -> e
+	msg := hs.writeE(nil)
+	if msg, err = hs.writePayload(msg); err != nil {
+		return nil, err
+	}
+	if err = writeFrame(conn, msg); err != nil {
+		return nil, err
+	}
+	// <- e, ee, s, es
+	if msg, err = readFrame(conn, nil); err != nil {
+		return nil, err
+	}
+	if len(msg) != keySize+keySize+tagSize+tagSize {
+		return nil, errors.Errorf("invalid handshake message size %d", len(msg))
+	}
+	if err = hs.readE(msg[:keySize]); err != nil {
+		return nil, err
+	}
+	if err = hs.mixDH(hs.e, hs.re); err != nil {
+		return nil, err
+	}
+	if err = hs.readS(msg[keySize : 2*keySize+tagSize]); err != nil {
+		return nil, err
+	}
+	if err = hs.mixDH(hs.e, hs.rs); err != nil {
+		return nil, err
+	}
+	if err = hs.readPayload(msg[2*keySize+tagSize:]); err != nil {
+		return nil, err
+	}
+	if err = cfg.checkTrusted(hs.rs); err != nil {
+		return nil, err
+	}
+	// -> s, se
+	if msg, err = hs.writeS(nil); err != nil {
+		return nil, err
+	}
+	if err = hs.mixDH(hs.s, hs.re); err != nil {
+		return nil, err
+	}
+	if msg, err = hs.writePayload(msg); err != nil {
+		return nil, err
+	}
+	if err = writeFrame(conn, msg); err != nil {
+		return nil, err
+	}
+	send, recv, err := hs.ss.split()
+	if err != nil {
+		return nil, err
+	}
+	return newConn(conn, hs.rs, send, recv), nil
+}
+
+// Server runs the handshake on the connection as responder.
+func Server(conn net.Conn, cfg *Config, prologue []byte) (*Conn, error) {
+	hs, err := newHandshakeState(cfg, prologue)
+	if err != nil {
+		return nil, err
+	}
+	// -> e
+	msg, err := readFrame(conn, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg) != keySize {
+		return nil, errors.Errorf("invalid handshake message size %d", len(msg))
+	}
+	if err = hs.readE(msg); err != nil {
+		return nil, err
+	}
+	if err = hs.readPayload(nil); err != nil {
+		return nil, err
+	}
+	// <- e, ee, s, es
+	msg = hs.writeE(nil)
+	if err = hs.mixDH(hs.e, hs.re); err != nil {
+		return nil, err
+	}
+	if msg, err = hs.writeS(msg); err != nil {
+		return nil, err
+	}
+	if err = hs.mixDH(hs.s, hs.re); err != nil {
+		return nil, err
+	}
+	if msg, err = hs.writePayload(msg); err != nil {
+		return nil, err
+	}
+	if err = writeFrame(conn, msg); err != nil {
+		return nil, err
+	}
+	// -> s, se
+	if msg, err = readFrame(conn, nil); err != nil {
+		return nil, err
+	}
+	if len(msg) != keySize+tagSize+tagSize {
+		return nil, errors.Errorf("invalid handshake message size %d", len(msg))
+	}
+	if err = hs.readS(msg[:keySize+tagSize]); err != nil {
+		return nil, err
+	}
+	if err = hs.mixDH(hs.e, hs.rs); err != nil {
+		return nil, err
+	}
+	if err = hs.readPayload(msg[keySize+tagSize:]); err != nil {
+		return nil, err
+	}
+	if err = cfg.checkTrusted(hs.rs); err != nil {
+		return nil, err
+	}
+	recv, send, err := hs.ss.split()
+	if err != nil {
+		return nil, err
+	}
+	return newConn(conn, hs.rs, send, recv), nil
+}
+
+func newConn(conn net.Conn, remote crypto.PublicKey, send, recv *cipherState) *Conn {
+	return &Conn{
+		Conn:   conn,
+		remote: remote,
+		recv:   recv,
+		frame:  make([]byte, maxFrameSize),
+		send:   send,
+		out:    make([]byte, 0, frameHeaderSize+maxFrameSize),
+	}
+}
+
+// RemotePublicKey returns the identity key of the remote node.
+func (c *Conn) RemotePublicKey() crypto.PublicKey {
+	return c.remote
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+
+	for len(c.pending) == 0 {
+		frame, err := readFrame(c.Conn, c.frame)
+		if err != nil {
+			return 0, err
+		}
+		plaintext, err := c.recv.decrypt(frame[:0], nil, frame)
+		if err != nil {
+			return 0, err
+		}
+		c.pending = plaintext
+	}
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *Conn) Write(b []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	n := 0
+	for len(b) > 0 {
+		chunk := b[:min(len(b), maxPlaintext)]
+		out := binary.BigEndian.AppendUint16(c.out[:0], uint16(len(chunk)+tagSize)) // #nosec: chunk is limited
+		out, err := c.send.encrypt(out, nil, chunk)
+		if err != nil {
+			return n, err
+		}
+		if _, err = c.Conn.Write(out); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		b = b[len(chunk):]
+	}
+	return n, nil
+}
+
+type handshakeState struct {
+	ss *symmetricState
+	s  crypto.SecretKey
+	sp crypto.PublicKey
+	e  [keySize]byte
+	ep [keySize]byte
+	re [keySize]byte
+	rs crypto.PublicKey
+}
+
+func newHandshakeState(cfg *Config, prologue []byte) (*handshakeState, error) {
+	hs := &handshakeState{ss: newSymmetricState(prologue), s: cfg.sk, sp: cfg.pk}
+	if _, err := io.ReadFull(cfg.random(), hs.e[:]); err != nil {
+		return nil, errors.Wrap(err, "failed to generate ephemeral key")
+	}
+	ep, err := curve25519.X25519(hs.e[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate ephemeral key")
+	}
+	copy(hs.ep[:], ep)
+	return hs, nil
+}
+
+func (hs *handshakeState) writeE(msg []byte) []byte {
+	hs.ss.mixHash(hs.ep[:])
+	return append(msg, hs.ep[:]...)
+}
+
+func (hs *handshakeState) readE(b []byte) error {
+	copy(hs.re[:], b)
+	hs.ss.mixHash(hs.re[:])
+	return nil
+}
+
+func (hs *handshakeState) writeS(msg []byte) ([]byte, error) {
+	ct, err := hs.ss.encryptAndHash(hs.sp[:])
+	if err != nil {
+		return nil, err
+	}
+	return append(msg, ct...), nil
+}
+
+func (hs *handshakeState) readS(b []byte) error {
+	pk, err := hs.ss.decryptAndHash(b)
+	if err != nil {
+		return errors.Wrap(err, "failed to read remote identity key")
+	}
+	copy(hs.rs[:], pk)
+	return nil
+}
+
+func (hs *handshakeState) writePayload(msg []byte) ([]byte, error) {
+	ct, err := hs.ss.encryptAndHash(nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(msg, ct...), nil
+}
+
+func (hs *handshakeState) readPayload(b []byte) error {
+	if _, err := hs.ss.decryptAndHash(b); err != nil {
+		return errors.Wrap(err, "failed to read handshake payload")
+	}
+	return nil
+}
+
+func (hs *handshakeState) mixDH(sk, pk [keySize]byte) error {
+	k, err := dh(sk, pk)
+	if err != nil {
+		return err
+	}
+	return hs.ss.mixKey(k)
+}
+
+func writeFrame(w io.Writer, data []byte) error {
+	if len(data) > maxFrameSize {
+		return errors.Errorf("frame is too long, size=%d > max=%d", len(data), maxFrameSize)
+	}
+	buf := make([]byte, 0, frameHeaderSize+len(data))
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(data))) // #nosec: the length is checked above
+	buf = append(buf, data...)
+	if _, err := w.Write(buf); err != nil {
+		return errors.Wrap(err, "failed to write frame")
+	}
+	return nil
+}
+
+// readFrame reads the frame into the buffer, a new buffer is allocated if the given one is too small.
+func readFrame(r io.Reader, buf []byte) ([]byte, error) {
+	var hdr [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	size := int(binary.BigEndian.Uint16(hdr[:]))
+	if cap(buf) < size {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
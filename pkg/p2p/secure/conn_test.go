@@ -0,0 +1,270 @@
+package secure
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+)
+
+func newTestKey(t *testing.T) (crypto.SecretKey, crypto.PublicKey) {
+	seed := make([]byte, 32)
+	_, err := rand.Read(seed)
+	require.NoError(t, err)
+	sk, pk, err := crypto.GenerateKeyPair(seed)
+	require.NoError(t, err)
+	return sk, pk
+}
+
+type handshakeResult struct {
+	conn *Conn
+	err  error
+}
+
+func runHandshake(t *testing.T, clientCfg, serverCfg *Config, clientPrologue, serverPrologue []byte) (
+	handshakeResult, handshakeResult,
+) {
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() {
+		_ = c1.Close()
+		_ = c2.Close()
+	})
+	serverCh := make(chan handshakeResult, 1)
+	go func() {
+		sc, err := Server(c2, serverCfg, serverPrologue)
+		if err != nil {
+			_ = c2.Close() // Unblock the client.
+		}
+		serverCh <- handshakeResult{conn: sc, err: err}
+	}()
+	cc, err := Client(c1, clientCfg, clientPrologue)
+	if err != nil {
+		_ = c1.Close() // Unblock the server.
+	}
+	return handshakeResult{conn: cc, err: err}, <-serverCh
+}
+
+func TestHandshakeAndTransfer(t *testing.T) {
+	sk1, pk1 := newTestKey(t)
+	sk2, pk2 := newTestKey(t)
+	client, server := runHandshake(t, NewConfig(sk1, nil), NewConfig(sk2, []crypto.PublicKey{pk1}),
+		[]byte("prologue"), []byte("prologue"))
+	require.NoError(t, client.err)
+	require.NoError(t, server.err)
+	assert.Equal(t, pk2, client.conn.RemotePublicKey())
+	assert.Equal(t, pk1, server.conn.RemotePublicKey())
+
+	data := make([]byte, 3*maxPlaintext+100) // Data is split into several frames.
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	go func() {
+		_, wErr := client.conn.Write(data)
+		assert.NoError(t, wErr)
+	}()
+	received := make([]byte, len(data))
+	_, err = io.ReadFull(server.conn, received)
+	require.NoError(t, err)
+	assert.Equal(t, data, received)
+
+	go func() {
+		_, wErr := server.conn.Write([]byte("reply"))
+		assert.NoError(t, wErr)
+	}()
+	reply := make([]byte, 5)
+	_, err = io.ReadFull(client.conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("reply"), reply)
+}
+
+func TestHandshakeUntrustedKey(t *testing.T) {
+	sk1, _ := newTestKey(t)
+	sk2, _ := newTestKey(t)
+	_, other := newTestKey(t)
+
+	client, server := runHandshake(t, NewConfig(sk1, nil), NewConfig(sk2, []crypto.PublicKey{other}), nil, nil)
+	assert.NoError(t, client.err) // The client doesn't know that the server rejected it.
+	assert.ErrorContains(t, server.err, "is not trusted")
+
+	client, server = runHandshake(t, NewConfig(sk1, []crypto.PublicKey{other}), NewConfig(sk2, nil), nil, nil)
+	assert.ErrorContains(t, client.err, "is not trusted")
+	assert.Error(t, server.err)
+}
+
+func TestHandshakeDifferentPrologues(t *testing.T) {
+	sk1, _ := newTestKey(t)
+	sk2, _ := newTestKey(t)
+	client, server := runHandshake(t, NewConfig(sk1, nil), NewConfig(sk2, nil), []byte("one"), []byte("two"))
+	assert.Error(t, client.err)
+	assert.Error(t, server.err)
+}
+
+// recordConn remembers the frames written to the connection.
+type recordConn struct {
+	net.Conn
+	mu     sync.Mutex
+	frames [][]byte
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	c.frames = append(c.frames, bytes.Clone(b[frameHeaderSize:]))
+	c.mu.Unlock()
+	return c.Conn.Write(b)
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+// TestHandshakeVectors checks the handshake and transport messages against the Noise_XX_25519_ChaChaPoly_SHA256
+// test vectors with empty handshake payloads from github.com/flynn/noise (vectors.txt).
+func TestHandshakeVectors(t *testing.T) {
+	const (
+		initStatic    = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+		respStatic    = "0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20"
+		initEphemeral = "202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f"
+		respEphemeral = "4142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f60"
+		msg0          = "358072d6365880d1aeea329adf9121383851ed21a28e3b75e965d0d2cd166254"
+		msg1Prefix    = "64b101b1d0be5a8704bd078f9895001fc03e8e9f9522f188dd128d9846d484663414af878d3e46a2f58911a8" +
+			"16d6e8346d4ea17a6f2a0bb4ef4ed56c133cff45"
+		msg2Prefix = "87f864c11ba449f46a0a4f4e2eacbb7b0457784f4fca1937f572c93603e9c4d9"
+		payload3   = "79656c6c6f777375626d6172696e65"
+		msg3       = "a52ef02ba60e12696d1d6b9ef4245c88fca757b6134ad6e76b56e310a6adf6"
+		payload4   = "7375626d6172696e6579656c6c6f77"
+		msg4       = "2445aa438ebd649281c636cc7269ca82f1d9023d72520943aeabf909cdf521"
+	)
+	for _, test := range []struct {
+		name     string
+		prologue string
+		msg1Tag  string
+		msg2Tags string
+	}{
+		{
+			name:     "empty prologue",
+			prologue: "",
+			msg1Tag:  "60a34e36ea82109f26cf2e5a5caf992b608d55c747f615e5a3425a7a19eefb8f",
+			msg2Tags: "7e5ea11b16f3968710b23a3be3202dc1b5e1ce3c963347491e74f5c0768a9b42",
+		},
+		{
+			name:     "prologue",
+			prologue: "6e6f74736563726574",
+			msg1Tag:  "88f043d1e49a3289b1beeab8f96b0551a48cddf9f38b1a12e46c6908644198f3",
+			msg2Tags: "5a04fa1f1c41fb3f00d496f242c1e44ce5b749b3d54bf74cea2dad086d601fb6",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var sk1, sk2 crypto.SecretKey
+			copy(sk1[:], mustHex(t, initStatic))
+			copy(sk2[:], mustHex(t, respStatic))
+			clientCfg := NewConfig(sk1, nil)
+			clientCfg.rand = bytes.NewReader(mustHex(t, initEphemeral))
+			serverCfg := NewConfig(sk2, nil)
+			serverCfg.rand = bytes.NewReader(mustHex(t, respEphemeral))
+
+			c1, c2 := net.Pipe()
+			t.Cleanup(func() {
+				_ = c1.Close()
+				_ = c2.Close()
+			})
+			cr := &recordConn{Conn: c1}
+			sr := &recordConn{Conn: c2}
+			prologue := mustHex(t, test.prologue)
+			serverCh := make(chan handshakeResult, 1)
+			go func() {
+				sc, err := Server(sr, serverCfg, prologue)
+				serverCh <- handshakeResult{conn: sc, err: err}
+			}()
+			client, err := Client(cr, clientCfg, prologue)
+			require.NoError(t, err)
+			server := <-serverCh
+			require.NoError(t, server.err)
+
+			go func() {
+				_, wErr := client.Write(mustHex(t, payload3))
+				assert.NoError(t, wErr)
+			}()
+			received := make([]byte, len(payload3)/2)
+			_, err = io.ReadFull(server.conn, received)
+			require.NoError(t, err)
+			assert.Equal(t, mustHex(t, payload3), received)
+			go func() {
+				_, wErr := server.conn.Write(mustHex(t, payload4))
+				assert.NoError(t, wErr)
+			}()
+			_, err = io.ReadFull(client, received)
+			require.NoError(t, err)
+			assert.Equal(t, mustHex(t, payload4), received)
+
+			cr.mu.Lock()
+			defer cr.mu.Unlock()
+			sr.mu.Lock()
+			defer sr.mu.Unlock()
+			require.Len(t, cr.frames, 3)
+			require.Len(t, sr.frames, 2)
+			assert.Equal(t, msg0, hex.EncodeToString(cr.frames[0]))
+			assert.Equal(t, msg1Prefix+test.msg1Tag, hex.EncodeToString(sr.frames[0]))
+			assert.Equal(t, msg2Prefix+test.msg2Tags, hex.EncodeToString(cr.frames[1]))
+			assert.Equal(t, msg3, hex.EncodeToString(cr.frames[2]))
+			assert.Equal(t, msg4, hex.EncodeToString(sr.frames[1]))
+		})
+	}
+}
+
+// tamperConn flips a bit in the last byte of every write.
+type tamperConn struct {
+	net.Conn
+}
+
+func (c *tamperConn) Write(b []byte) (int, error) {
+	tampered := bytes.Clone(b)
+	tampered[len(tampered)-1] ^= 0x01
+	return c.Conn.Write(tampered)
+}
+
+func TestTamperedFrame(t *testing.T) {
+	sk1, _ := newTestKey(t)
+	sk2, _ := newTestKey(t)
+	client, server := runHandshake(t, NewConfig(sk1, nil), NewConfig(sk2, nil), nil, nil)
+	require.NoError(t, client.err)
+	require.NoError(t, server.err)
+
+	client.conn.Conn = &tamperConn{Conn: client.conn.Conn}
+	go func() {
+		_, _ = client.conn.Write([]byte("message"))
+	}()
+	_, err := server.conn.Read(make([]byte, 16))
+	assert.Error(t, err)
+}
+
+func TestConfigRequired(t *testing.T) {
+	sk, pk := newTestKey(t)
+	assert.False(t, NewConfig(sk, nil).Required())
+	cfg := NewConfig(sk, []crypto.PublicKey{pk})
+	assert.True(t, cfg.Required())
+	assert.Equal(t, pk, cfg.PublicKey())
+}
+
+func TestLoadIdentityKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identity.key")
+	sk, err := LoadIdentityKey(path)
+	require.NoError(t, err)
+	loaded, err := LoadIdentityKey(path)
+	require.NoError(t, err)
+	assert.Equal(t, sk, loaded)
+
+	require.NoError(t, os.WriteFile(path, []byte("invalid"), 0600))
+	_, err = LoadIdentityKey(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package secure
+
+import (
+	"crypto/rand"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+)
+
+const identityKeyFileMode = 0600
+
+// LoadIdentityKey reads the Base58 encoded identity secret key from the file. If the file does not exist,
+// a new key is generated and saved to the file.
+func LoadIdentityKey(path string) (crypto.SecretKey, error) {
+	b, err := os.ReadFile(path) // #nosec: in this case check for prevent G304 (CWE-22) is not necessary
+	switch {
+	case err == nil:
+		sk, kErr := crypto.NewSecretKeyFromBase58(strings.TrimSpace(string(b)))
+		if kErr != nil {
+			return crypto.SecretKey{}, errors.Wrapf(kErr, "invalid identity key in file '%s'", path)
+		}
+		return sk, nil
+	case errors.Is(err, fs.ErrNotExist):
+		seed := make([]byte, crypto.KeySize)
+		if _, rErr := rand.Read(seed); rErr != nil {
+			return crypto.SecretKey{}, errors.Wrap(rErr, "failed to generate identity key")
+		}
+		sk, _, gErr := crypto.GenerateKeyPair(seed)
+		if gErr != nil {
+			return crypto.SecretKey{}, errors.Wrap(gErr, "failed to generate identity key")
+		}
+		if wErr := os.WriteFile(path, []byte(sk.String()), identityKeyFileMode); wErr != nil {
+			return crypto.SecretKey{}, errors.Wrap(wErr, "failed to save identity key")
+		}
+		return sk, nil
+	default:
+		return crypto.SecretKey{}, errors.Wrap(err, "failed to read identity key")
+	}
+}
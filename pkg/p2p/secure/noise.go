@@ -0,0 +1,166 @@
+// Package secure implements the encrypted and authenticated transport for connections between nodes.
+// The transport is established with the Noise XX handshake (Noise_XX_25519_ChaChaPoly_SHA256), where static
+// keys are the identity keys of nodes, after that all data is sent in frames encrypted by ChaCha20-Poly1305.
+package secure
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+)
+
+const (
+	protocolName = "Noise_XX_25519_ChaChaPoly_SHA256" // exactly 32 bytes, so it's used as initial hash as is
+	keySize      = 32
+	tagSize      = chacha20poly1305.Overhead
+)
+
+var errNonceExhausted = errors.New("nonce exhausted")
+
+// cipherState encrypts or decrypts messages with the key and the counter as nonce.
+type cipherState struct {
+	aead  cipher.AEAD
+	n     uint64
+	nonce [chacha20poly1305.NonceSize]byte
+}
+
+func newCipherState(k [keySize]byte) (*cipherState, error) {
+	aead, err := chacha20poly1305.New(k[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+	return &cipherState{aead: aead}, nil
+}
+
+func (c *cipherState) nextNonce() ([]byte, error) {
+	if c.n == math.MaxUint64 { // The maximum value is reserved by Noise specification.
+		return nil, errNonceExhausted
+	}
+	binary.LittleEndian.PutUint64(c.nonce[4:], c.n)
+	c.n++
+	return c.nonce[:], nil
+}
+
+// encrypt appends the encrypted plaintext to dst.
+func (c *cipherState) encrypt(dst, ad, plaintext []byte) ([]byte, error) {
+	nonce, err := c.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(dst, nonce, plaintext, ad), nil
+}
+
+// decrypt appends the decrypted ciphertext to dst.
+func (c *cipherState) decrypt(dst, ad, ciphertext []byte) ([]byte, error) {
+	nonce, err := c.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := c.aead.Open(dst, nonce, ciphertext, ad)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt")
+	}
+	return plaintext, nil
+}
+
+// symmetricState keeps the hash of the handshake transcript and the chaining key.
+type symmetricState struct {
+	cs *cipherState // nil until the first key is mixed
+	ck [keySize]byte
+	h  [sha256.Size]byte
+}
+
+func newSymmetricState(prologue []byte) *symmetricState {
+	s := &symmetricState{}
+	copy(s.h[:], protocolName)
+	s.ck = s.h
+	s.mixHash(prologue)
+	return s
+}
+
+func (s *symmetricState) mixHash(data []byte) {
+	h := sha256.New()
+	h.Write(s.h[:])
+	h.Write(data)
+	h.Sum(s.h[:0])
+}
+
+func (s *symmetricState) mixKey(ikm []byte) error {
+	var k [keySize]byte
+	s.ck, k = hkdf(s.ck[:], ikm)
+	cs, err := newCipherState(k)
+	if err != nil {
+		return err
+	}
+	s.cs = cs
+	return nil
+}
+
+func (s *symmetricState) encryptAndHash(plaintext []byte) ([]byte, error) {
+	if s.cs == nil {
+		s.mixHash(plaintext)
+		return plaintext, nil
+	}
+	ciphertext, err := s.cs.encrypt(nil, s.h[:], plaintext)
+	if err != nil {
+		return nil, err
+	}
+	s.mixHash(ciphertext)
+	return ciphertext, nil
+}
+
+func (s *symmetricState) decryptAndHash(ciphertext []byte) ([]byte, error) {
+	if s.cs == nil {
+		s.mixHash(ciphertext)
+		return ciphertext, nil
+	}
+	plaintext, err := s.cs.decrypt(nil, s.h[:], ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	s.mixHash(ciphertext)
+	return plaintext, nil
+}
+
+// split returns the cipher states for the initiator to responder and the responder to initiator directions.
+func (s *symmetricState) split() (*cipherState, *cipherState, error) {
+	k1, k2 := hkdf(s.ck[:], nil)
+	c1, err := newCipherState(k1)
+	if err != nil {
+		return nil, nil, err
+	}
+	c2, err := newCipherState(k2)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c1, c2, nil
+}
+
+// hkdf is the HKDF function of Noise specification with two outputs.
+func hkdf(ck, ikm []byte) ([keySize]byte, [keySize]byte) {
+	var out1, out2 [keySize]byte
+	temp := hmacSHA256(ck, ikm)
+	copy(out1[:], hmacSHA256(temp, []byte{0x01}))
+	copy(out2[:], hmacSHA256(temp, append(out1[:], 0x02)))
+	return out1, out2
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write(data)
+	return m.Sum(nil)
+}
+
+func dh(sk, pk [keySize]byte) ([]byte, error) {
+	r, err := curve25519.X25519(sk[:], pk[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid public key")
+	}
+	return r, nil
+}
@@ -658,6 +658,13 @@ func (b *Block) Clone() *Block {
 }
 
 func (b *Block) Sign(scheme Scheme, secret crypto.SecretKey) error {
+	return b.SignWith(scheme, func(data []byte) (crypto.Signature, error) {
+		return crypto.Sign(secret, data)
+	})
+}
+
+// SignWith signs the block using the signing function, it allows to sign the block without the secret key.
+func (b *Block) SignWith(scheme Scheme, sign func(data []byte) (crypto.Signature, error)) error {
 	var bb []byte
 	if b.Version >= ProtobufBlockVersion {
 		b, err := b.MarshalHeaderToProtobufWithoutSignature(scheme)
@@ -673,11 +680,11 @@ func (b *Block) Sign(scheme Scheme, secret crypto.SecretKey) error {
 		}
 		bb = buf.Bytes()
 	}
-	sign, err := crypto.Sign(secret, bb)
+	sig, err := sign(bb)
 	if err != nil {
 		return err
 	}
-	b.BlockSignature = sign
+	b.BlockSignature = sig
 	return nil
 }
 
@@ -111,6 +111,25 @@ func (bs *BlockSnapshot) UnmarshalBinaryImport(data []byte, scheme Scheme) error
 	return nil
 }
 
+// MarshalBinaryImport marshals block snapshot to the binary format of the snapshots import file.
+// Block snapshot size is not written, it's the inverse of UnmarshalBinaryImport.
+func (bs BlockSnapshot) MarshalBinaryImport() ([]byte, error) {
+	txSnapshots, err := bs.ToProtobuf()
+	if err != nil {
+		return nil, err
+	}
+	var result []byte
+	for _, ts := range txSnapshots {
+		tsBytes, mErr := ts.MarshalVTStrict()
+		if mErr != nil {
+			return nil, mErr
+		}
+		result = binary.BigEndian.AppendUint32(result, uint32(len(tsBytes)))
+		result = append(result, tsBytes...)
+	}
+	return result, nil
+}
+
 func (bs BlockSnapshot) ToProtobuf() ([]*g.TransactionStateSnapshot, error) {
 	data := make([]g.TransactionStateSnapshot, len(bs.TxSnapshots))
 	res := make([]*g.TransactionStateSnapshot, len(bs.TxSnapshots))
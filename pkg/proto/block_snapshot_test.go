@@ -359,3 +359,35 @@ func Test_txSnapshotJSON_MarshalJSON_UnmarshalJSON(t *testing.T) {
 	assert.Len(t, unmEmptyBs.TxSnapshots, 0)
 	assert.Nil(t, unmEmptyBs.TxSnapshots)
 }
+
+func TestBlockSnapshot_MarshalBinaryImport(t *testing.T) {
+	pk, err := crypto.NewPublicKeyFromBase58("9KFDEPnavEUzmiYbQw81VC4Niu526mjECQUnn8wrVW4Q")
+	require.NoError(t, err)
+	addr, err := proto.NewAddressFromPublicKey(proto.TestNetScheme, pk)
+	require.NoError(t, err)
+	bs := proto.BlockSnapshot{TxSnapshots: [][]proto.AtomicSnapshot{
+		{
+			&proto.TransactionStatusSnapshot{Status: proto.TransactionSucceeded},
+			&proto.WavesBalanceSnapshot{Address: addr, Balance: 49315021748316},
+			&proto.AliasSnapshot{Address: addr, Alias: "foobar"},
+		},
+		{
+			&proto.TransactionStatusSnapshot{Status: proto.TransactionElided},
+		},
+	}}
+	data, err := bs.MarshalBinaryImport()
+	require.NoError(t, err)
+	var unmBs proto.BlockSnapshot
+	require.NoError(t, unmBs.UnmarshalBinaryImport(data, proto.TestNetScheme))
+	require.Len(t, unmBs.TxSnapshots, len(bs.TxSnapshots))
+	for i := range bs.TxSnapshots {
+		assert.ElementsMatch(t, bs.TxSnapshots[i], unmBs.TxSnapshots[i])
+	}
+
+	// Empty block snapshot is marshaled to empty data.
+	data, err = proto.BlockSnapshot{}.MarshalBinaryImport()
+	require.NoError(t, err)
+	assert.Empty(t, data)
+	require.NoError(t, unmBs.UnmarshalBinaryImport(data, proto.TestNetScheme))
+	assert.Empty(t, unmBs.TxSnapshots)
+}
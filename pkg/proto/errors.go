@@ -17,3 +17,7 @@ func (im *InfoMsg) Error() string {
 func (im *InfoMsg) IsNil() bool {
 	return im.err == nil
 }
+
+func (im *InfoMsg) Unwrap() error {
+	return im.err
+}
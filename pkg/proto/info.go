@@ -98,6 +98,12 @@ func (i *FullAssetInfo) ToProtobuf(scheme Scheme) (*g.AssetInfoResponse, error)
 	return res, nil
 }
 
+// AssetHolding is the balance of an asset holder.
+type AssetHolding struct {
+	Address WavesAddress
+	Balance uint64
+}
+
 type AssetConstInfo struct {
 	ID          crypto.Digest
 	IssueHeight Height
@@ -182,13 +182,20 @@ func (a *MicroBlock) VerifySignature(scheme Scheme) (bool, error) {
 }
 
 func (a *MicroBlock) Sign(scheme Scheme, secret crypto.SecretKey) error {
+	return a.SignWith(scheme, func(data []byte) (crypto.Signature, error) {
+		return crypto.Sign(secret, data)
+	})
+}
+
+// SignWith signs the microblock using the signing function, it allows to sign the microblock without the secret key.
+func (a *MicroBlock) SignWith(scheme Scheme, sign func(data []byte) (crypto.Signature, error)) error {
 	buf := bytebufferpool.Get()
 	defer bytebufferpool.Put(buf)
 	_, err := a.WriteWithoutSignature(scheme, buf)
 	if err != nil {
 		return err
 	}
-	sig, err := crypto.Sign(secret, buf.Bytes())
+	sig, err := sign(buf.Bytes())
 	if err != nil {
 		return err
 	}
@@ -489,13 +496,20 @@ func (a *MicroBlockInv) WriteTo(w io.Writer) (int64, error) {
 }
 
 func (a *MicroBlockInv) Sign(key crypto.SecretKey, schema Scheme) error {
+	return a.SignWith(schema, func(data []byte) (crypto.Signature, error) {
+		return crypto.Sign(key, data)
+	})
+}
+
+// SignWith signs the inv using the signing function, it allows to sign the inv without the secret key.
+func (a *MicroBlockInv) SignWith(schema Scheme, sign func(data []byte) (crypto.Signature, error)) error {
 	buf := bytebufferpool.Get()
 	defer bytebufferpool.Put(buf)
 	err := a.bodyBytes(buf, schema)
 	if err != nil {
 		return err
 	}
-	a.Signature, err = crypto.Sign(key, buf.Bytes())
+	a.Signature, err = sign(buf.Bytes())
 	return err
 }
 
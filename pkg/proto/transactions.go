@@ -243,6 +243,93 @@ func MarshalTxBody(scheme Scheme, tx Transaction) ([]byte, error) {
 	return tx.BodyMarshalBinary(scheme)
 }
 
+// SignTransactionWith signs the transaction using the signing function and sets the transaction ID.
+// It allows to sign transactions without the secret key, for example, by external signer.
+// Genesis, Payment and Ethereum transactions are not supported.
+func SignTransactionWith(scheme Scheme, tx Transaction, sign func(data []byte) (crypto.Signature, error)) error {
+	var (
+		sig    **crypto.Signature
+		proofs **ProofsV1
+		id     **crypto.Digest
+	)
+	switch t := tx.(type) {
+	case *IssueWithSig:
+		sig, id = &t.Signature, &t.ID
+	case *TransferWithSig:
+		sig, id = &t.Signature, &t.ID
+	case *ReissueWithSig:
+		sig, id = &t.Signature, &t.ID
+	case *BurnWithSig:
+		sig, id = &t.Signature, &t.ID
+	case *ExchangeWithSig:
+		sig, id = &t.Signature, &t.ID
+	case *LeaseWithSig:
+		sig, id = &t.Signature, &t.ID
+	case *LeaseCancelWithSig:
+		sig, id = &t.Signature, &t.ID
+	case *CreateAliasWithSig:
+		sig, id = &t.Signature, &t.ID
+	case *IssueWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *TransferWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *ReissueWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *BurnWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *ExchangeWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *LeaseWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *LeaseCancelWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *CreateAliasWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *MassTransferWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *DataWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *SetScriptWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *SponsorshipWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *SetAssetScriptWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *InvokeScriptWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *InvokeExpressionTransactionWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	case *UpdateAssetInfoWithProofs:
+		proofs, id = &t.Proofs, &t.ID
+	default:
+		return errors.Errorf("signing of transaction type %T is not supported", tx)
+	}
+	b, err := MarshalTxBody(scheme, tx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to sign %T transaction", tx)
+	}
+	if sig != nil {
+		s, sErr := sign(b)
+		if sErr != nil {
+			return errors.Wrapf(sErr, "failed to sign %T transaction", tx)
+		}
+		*sig = &s
+	} else {
+		if *proofs == nil {
+			*proofs = NewProofs()
+		}
+		if sErr := (*proofs).SignWith(sign, b); sErr != nil {
+			return errors.Wrapf(sErr, "failed to sign %T transaction", tx)
+		}
+	}
+	d, err := crypto.FastHash(b)
+	if err != nil {
+		return errors.Wrapf(err, "failed to sign %T transaction", tx)
+	}
+	*id = &d
+	return nil
+}
+
 // TransactionToProtobufCommon converts to protobuf structure with fields
 // that are common for all of the transaction types.
 func TransactionToProtobufCommon(scheme Scheme, senderPublicKey []byte, tx Transaction) *g.Transaction {
@@ -492,6 +492,36 @@ func TestIssueWithSigSigningRoundTrip(t *testing.T) {
 	}
 }
 
+func TestSignTransactionWith(t *testing.T) {
+	sk, pk, err := crypto.GenerateKeyPair([]byte("external signer"))
+	require.NoError(t, err)
+	sign := func(data []byte) (crypto.Signature, error) {
+		return crypto.Sign(sk, data)
+	}
+	ts := uint64(time.Now().UnixMilli())
+	rcp := NewRecipientFromAddress(MustAddressFromPublicKey(TestNetScheme, pk))
+	for _, tx := range []interface {
+		Transaction
+		Verify(Scheme, crypto.PublicKey) (bool, error)
+	}{
+		NewUnsignedIssueWithSig(pk, "TOKEN", "", 1000, 0, false, ts, 100000),
+		NewUnsignedIssueWithProofs(2, pk, "TOKEN", "", 1000, 0, false, nil, ts, 100000),
+		NewUnsignedTransferWithProofs(3, pk, NewOptionalAssetWaves(), NewOptionalAssetWaves(), ts, 1, 100000, rcp, nil),
+	} {
+		require.NoError(t, SignTransactionWith(TestNetScheme, tx, sign))
+		ok, vErr := tx.Verify(TestNetScheme, pk)
+		require.NoError(t, vErr)
+		assert.True(t, ok, "%T", tx)
+		body, mErr := MarshalTxBody(TestNetScheme, tx)
+		require.NoError(t, mErr)
+		id, idErr := tx.GetID(TestNetScheme)
+		require.NoError(t, idErr)
+		assert.Equal(t, crypto.MustFastHash(body).Bytes(), id)
+	}
+	err = SignTransactionWith(TestNetScheme, NewUnsignedPayment(pk, MustAddressFromPublicKey(TestNetScheme, pk), 1, 1, ts), sign)
+	assert.ErrorContains(t, err, "not supported")
+}
+
 func TestIssueWithSigToJSON(t *testing.T) {
 	if s, err := base58.Decode("3TUPTbbpiM5UmZDhMmzdsKKNgMvyHwZQncKWfJrxk3bc"); assert.NoError(t, err) {
 		sk, pk, err := crypto.GenerateKeyPair(s)
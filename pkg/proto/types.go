@@ -2259,6 +2259,23 @@ func (p *ProofsV1) Sign(key crypto.SecretKey, data []byte) error {
 	return nil
 }
 
+// SignWith puts the signature made by the signing function at the first position.
+func (p *ProofsV1) SignWith(sign func(data []byte) (crypto.Signature, error), data []byte) error {
+	if len(p.Proofs) != 0 && len(p.Proofs[0]) != 0 {
+		return errors.New("unable to overwrite non-empty proof at position 0")
+	}
+	s, err := sign(data)
+	if err != nil {
+		return err
+	}
+	if len(p.Proofs) == 0 {
+		p.Proofs = []B58Bytes{s[:]}
+	} else {
+		p.Proofs[0] = s[:]
+	}
+	return nil
+}
+
 // Verify checks that the proof at first position is a valid signature.
 func (p *ProofsV1) Verify(key crypto.PublicKey, data []byte) (bool, error) {
 	sig, err := p.ExtractSignature()
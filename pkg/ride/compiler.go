@@ -3,7 +3,6 @@ package ride
 //go:generate go run ./generate
 
 import (
-	"bytes"
 	"encoding/binary"
 	"math"
 
@@ -11,23 +10,21 @@ import (
 	"github.com/wavesplatform/gowaves/pkg/ride/ast"
 )
 
+// Compile translates the tree of the script into the bytecode of Ride virtual machine.
+// Declarations are not resolved at compile time, the VM resolves them in runtime the same way as the tree evaluator
+// does, only IDs of global constants are precalculated. So the compiled script doesn't depend on activated features
+// and can be cached.
 func Compile(tree *ast.Tree) (RideScript, error) {
-	fCheck, err := selectFunctionChecker(tree.LibVersion)
-	if err != nil {
-		return nil, errors.Wrap(err, "compile")
-	}
-	cCheck, err := selectConstantsChecker(tree.LibVersion)
+	check, err := selectConstantsChecker(tree.LibVersion)
 	if err != nil {
 		return nil, errors.Wrap(err, "compile")
 	}
 	c := &compiler{
+		code:          make([]byte, 0, 64),
 		constants:     newRideConstants(),
-		checkFunction: fCheck,
-		checkConstant: cCheck,
-		values:        make([]rideValue, 0),
-		functions:     make([]*localFunction, 0),
-		declarations:  make([]rideDeclaration, 0),
-		patcher:       newPatcher(),
+		functions:     make([]function, 0),
+		segments:      make([]segment, 0),
+		checkConstant: check,
 	}
 	if tree.IsDApp() {
 		return c.compileDAppScript(tree)
@@ -35,451 +32,309 @@ func Compile(tree *ast.Tree) (RideScript, error) {
 	return c.compileSimpleScript(tree)
 }
 
+type segmentKind byte
+
+const (
+	valueSegment       segmentKind = iota // Expression of a local value, position is written to the OpLet parameter
+	functionSegment                       // Body of a user function, position is set as the function's entry point
+	declarationSegment                    // Expression of a global value of DApp, position is set to the declaration
+)
+
+// segment is a piece of code that is compiled after the main code, it's an expression of a lazy value
+// or a body of user function.
+type segment struct {
+	node ast.Node
+	kind segmentKind
+	ref  int // Position of patch, index of function or index of declaration depending on segment kind
+}
+
 type compiler struct {
+	code          []byte
 	constants     *rideConstants
-	checkFunction func(string) (uint16, bool)
+	functions     []function
+	declarations  []declaration
+	segments      []segment
 	checkConstant func(string) (uint16, bool)
-	values        []rideValue
-	functions     []*localFunction
-	declarations  []rideDeclaration
-	patcher       *patcher
-	callable      *rideCallable
 }
 
 func (c *compiler) compileSimpleScript(tree *ast.Tree) (*SimpleScript, error) {
-	bb := new(bytes.Buffer)
-	err := c.compile(bb, tree.Verifier)
-	if err != nil {
+	if err := c.compile(tree.Verifier); err != nil {
 		return nil, err
 	}
-	bb.WriteByte(OpHalt)
-	for _, d := range c.declarations {
-		pos := bb.Len()
-		c.patcher.setOrigin(d.buffer(), pos)
-		bb.Write(patchedCode(d.buffer(), pos))
-		bb.WriteByte(OpReturn)
-		for _, ref := range d.references() {
-			c.patcher.setPosition(ref, uint16(pos))
-		}
-	}
-	code := bb.Bytes()
-	patches, err := c.patcher.get()
-	if err != nil {
+	c.code = append(c.code, OpHalt)
+	if err := c.compileSegments(); err != nil {
 		return nil, err
 	}
-	for pos, addr := range patches {
-		binary.BigEndian.PutUint16(code[pos:], addr)
-	}
 	return &SimpleScript{
 		LibVersion: tree.LibVersion,
 		EntryPoint: 0,
-		Code:       bb.Bytes(),
+		Code:       c.code,
 		Constants:  c.constants.items,
+		Functions:  c.functions,
 	}, nil
 }
 
 func (c *compiler) compileDAppScript(tree *ast.Tree) (*DAppScript, error) {
-	// Compile global declarations.
-	// Each declaration goes to the declaration stack with code compiled in its own buffer.
-	functions := make(map[string]callable)
-	bb := new(bytes.Buffer)
+	c.declarations = make([]declaration, 0, len(tree.Declarations))
 	for _, node := range tree.Declarations {
-		err := c.compile(bb, node)
-		if err != nil {
-			return nil, err
+		switch d := node.(type) {
+		case *ast.AssignmentNode:
+			c.segments = append(c.segments, segment{node: d.Expression, kind: declarationSegment, ref: len(c.declarations)})
+			c.declarations = append(c.declarations, declaration{name: d.Name, function: -1})
+		case *ast.FunctionDeclarationNode:
+			id, err := c.function(d)
+			if err != nil {
+				return nil, err
+			}
+			c.declarations = append(c.declarations, declaration{name: d.Name, function: id})
+		default:
+			return nil, errors.Errorf("invalid declaration type '%T'", node)
 		}
 	}
+	entryPoints := make(map[string]callable, len(tree.Functions)+1)
 	for _, n := range tree.Functions {
 		fn, ok := n.(*ast.FunctionDeclarationNode)
 		if !ok {
-			return nil, errors.Errorf("invalid node type %T", n)
+			return nil, errors.Errorf("invalid node type '%T' of callable function", n)
 		}
-		c.callable = &rideCallable{
-			name:      fn.Name,
-			parameter: fn.InvocationParameter,
+		entryPoints[fn.Name] = callable{
+			entryPoint:    len(c.code),
+			parameterName: fn.InvocationParameter,
+			arguments:     fn.Arguments,
 		}
-		err := c.compile(bb, fn)
-		if err != nil {
+		if err := c.compile(fn.Body); err != nil {
 			return nil, err
 		}
+		c.code = append(c.code, OpHalt)
 	}
 	if tree.HasVerifier() {
 		v, ok := tree.Verifier.(*ast.FunctionDeclarationNode)
 		if !ok {
 			return nil, errors.Errorf("invalid node type for DApp's verifier '%T'", tree.Verifier)
 		}
-		c.callable = &rideCallable{
-			name:      "", // Verifier has empty name
-			parameter: v.InvocationParameter,
-		}
-		err := c.compile(bb, v)
-		if err != nil {
-			return nil, err
-		}
-		functions[""] = callable{
-			entryPoint:    0,
+		entryPoints[""] = callable{ // Verifier has empty name
+			entryPoint:    len(c.code),
 			parameterName: v.InvocationParameter,
 		}
-	}
-	// All declarations go here after verifier and public functions
-	for _, d := range c.declarations {
-		pos := bb.Len()
-		c.patcher.setOrigin(d.buffer(), pos)
-		bb.Write(patchedCode(d.buffer(), pos))
-		if c := d.callable(); c != nil {
-			bb.WriteByte(OpHalt)
-			// Add reference to entry point
-			functions[c.name] = callable{
-				entryPoint:    pos,
-				parameterName: c.parameter,
-			}
-		} else {
-			bb.WriteByte(OpReturn)
-		}
-		for _, ref := range d.references() {
-			c.patcher.setPosition(ref, uint16(pos))
+		if err := c.compile(v.Body); err != nil {
+			return nil, err
 		}
+		c.code = append(c.code, OpHalt)
 	}
-	code := bb.Bytes()
-	patches, err := c.patcher.get()
-	if err != nil {
+	if err := c.compileSegments(); err != nil {
 		return nil, err
 	}
-	for pos, addr := range patches {
-		binary.BigEndian.PutUint16(code[pos:], addr)
-	}
 	return &DAppScript{
-		LibVersion:  tree.LibVersion,
-		Code:        bb.Bytes(),
-		Constants:   c.constants.items,
-		EntryPoints: functions,
+		LibVersion:   tree.LibVersion,
+		Code:         c.code,
+		Constants:    c.constants.items,
+		Functions:    c.functions,
+		Declarations: c.declarations,
+		EntryPoints:  entryPoints,
 	}, nil
 }
 
-func (c *compiler) compile(bb *bytes.Buffer, node ast.Node) error {
+func (c *compiler) compileSegments() error {
+	for len(c.segments) > 0 {
+		s := c.segments[0]
+		c.segments = c.segments[1:]
+		if err := c.compileSegment(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *compiler) compileSegment(s segment) error {
+	pos := len(c.code)
+	if pos > math.MaxUint32 {
+		return errors.New("max code size reached")
+	}
+	switch s.kind {
+	case valueSegment:
+		binary.BigEndian.PutUint32(c.code[s.ref:], uint32(pos))
+	case functionSegment:
+		c.functions[s.ref].entryPoint = pos
+	case declarationSegment:
+		c.declarations[s.ref].entryPoint = pos
+	}
+	if err := c.compile(s.node); err != nil {
+		return err
+	}
+	c.code = append(c.code, OpReturn)
+	return nil
+}
+
+func (c *compiler) compile(node ast.Node) error {
 	switch n := node.(type) {
 	case *ast.LongNode:
-		return c.longNode(bb, n)
+		return c.push(rideInt(n.Value))
 	case *ast.BytesNode:
-		return c.bytesNode(bb, n)
+		return c.push(rideByteVector(n.Value))
 	case *ast.StringNode:
-		return c.stringNode(bb, n)
+		return c.push(rideString(n.Value))
 	case *ast.BooleanNode:
-		return c.booleanNode(bb, n)
+		if n.Value {
+			c.code = append(c.code, OpTrue)
+		} else {
+			c.code = append(c.code, OpFalse)
+		}
+		return nil
 	case *ast.ConditionalNode:
-		return c.conditionalNode(bb, n)
+		return c.conditionalNode(n)
 	case *ast.AssignmentNode:
-		return c.assignmentNode(bb, n)
+		return c.assignmentNode(n)
 	case *ast.ReferenceNode:
-		return c.referenceNode(bb, n)
+		if err := c.withName(OpRef, n.Name); err != nil {
+			return err
+		}
+		// Global constant is used only if the name is not declared in scope, so both IDs are stored.
+		var gid uint16
+		if id, ok := c.checkConstant(n.Name); ok {
+			gid = id + 1
+		}
+		c.code = binary.BigEndian.AppendUint16(c.code, gid)
+		return nil
 	case *ast.FunctionDeclarationNode:
-		return c.functionDeclarationNode(bb, n)
+		return c.functionDeclarationNode(n)
 	case *ast.FunctionCallNode:
-		return c.callNode(bb, n)
+		return c.callNode(n)
 	case *ast.PropertyNode:
-		return c.propertyNode(bb, n)
+		if err := c.withName(OpProperty, n.Name); err != nil {
+			return err
+		}
+		if err := c.compile(n.Object); err != nil {
+			return err
+		}
+		c.code = append(c.code, OpPropertyEnd)
+		return nil
 	default:
 		return errors.Errorf("unexpected node type '%T'", node)
 	}
 }
 
-func (c *compiler) longNode(bb *bytes.Buffer, node *ast.LongNode) error {
-	cid, err := c.constants.put(rideInt(node.Value))
+func (c *compiler) push(value rideType) error {
+	id, err := c.constants.put(value)
 	if err != nil {
 		return err
 	}
-	bb.WriteByte(OpPush)
-	bb.Write(encode(cid))
+	c.code = append(c.code, OpPush)
+	c.code = binary.BigEndian.AppendUint16(c.code, id)
 	return nil
 }
 
-func (c *compiler) bytesNode(bb *bytes.Buffer, node *ast.BytesNode) error {
-	cid, err := c.constants.put(rideByteVector(node.Value))
+func (c *compiler) withName(op byte, name string) error {
+	id, err := c.constants.put(rideString(name))
 	if err != nil {
 		return err
 	}
-	bb.WriteByte(OpPush)
-	bb.Write(encode(cid))
+	c.code = append(c.code, op)
+	c.code = binary.BigEndian.AppendUint16(c.code, id)
 	return nil
 }
 
-func (c *compiler) stringNode(bb *bytes.Buffer, node *ast.StringNode) error {
-	cid, err := c.constants.put(rideString(node.Value))
-	if err != nil {
-		return err
-	}
-	bb.WriteByte(OpPush)
-	bb.Write(encode(cid))
-	return nil
+// address writes placeholder for an address and returns its position to patch it later.
+func (c *compiler) address() int {
+	pos := len(c.code)
+	c.code = append(c.code, 0xff, 0xff, 0xff, 0xff)
+	return pos
 }
 
-func (c *compiler) booleanNode(bb *bytes.Buffer, node *ast.BooleanNode) error {
-	if node.Value {
-		bb.WriteByte(OpTrue)
-	} else {
-		bb.WriteByte(OpFalse)
+func (c *compiler) patch(pos int) error {
+	if len(c.code) > math.MaxUint32 {
+		return errors.New("max code size reached")
 	}
+	binary.BigEndian.PutUint32(c.code[pos:], uint32(len(c.code)))
 	return nil
 }
 
-func (c *compiler) conditionalNode(bb *bytes.Buffer, node *ast.ConditionalNode) error {
-	err := c.compile(bb, node.Condition)
-	if err != nil {
+func (c *compiler) conditionalNode(node *ast.ConditionalNode) error {
+	c.code = append(c.code, OpCond)
+	if err := c.compile(node.Condition); err != nil {
 		return err
 	}
-	bb.WriteByte(OpJumpIfFalse)
-	otherwise := bb.Len()
-	bb.Write([]byte{0xff, 0xff})
-
-	// Truthy branch
-	bb.WriteByte(OpPop) // Remove condition result from stack
-
-	err = c.compile(bb, node.TrueExpression)
-	if err != nil {
+	c.code = append(c.code, OpJumpIfFalse)
+	otherwise := c.address()
+	if err := c.compile(node.TrueExpression); err != nil {
 		return err
 	}
-
-	bb.WriteByte(OpJump)
-	end := bb.Len()
-	bb.Write([]byte{0xff, 0xff})
-
-	// Patch jump to alternative branch
-	code := bb.Bytes()
-	binary.BigEndian.PutUint16(code[otherwise:], uint16(bb.Len()))
-
-	// Alternative branch
-	bb.WriteByte(OpPop) // Remove condition result from stack
-	err = c.compile(bb, node.FalseExpression)
-	if err != nil {
+	c.code = append(c.code, OpJump)
+	end := c.address()
+	if err := c.patch(otherwise); err != nil {
 		return err
 	}
-
-	// Patch jump to the end of alternative branch
-	code = bb.Bytes()
-	binary.BigEndian.PutUint16(code[end:], uint16(bb.Len()))
-
-	return nil
-}
-
-func (c *compiler) assignmentNode(bb *bytes.Buffer, node *ast.AssignmentNode) error {
-	err := c.pushGlobalValue(node.Name, node.Expression, c.callable)
-	if err != nil {
+	if err := c.compile(node.FalseExpression); err != nil {
 		return err
 	}
-	if node.Block != nil {
-		err = c.compile(bb, node.Block)
-		if err != nil {
-			return err
-		}
-		err = c.popValue()
-		if err != nil {
-			return err
-		}
-	} else {
-		err = c.peekValue()
-		if err != nil {
-			return err
-		}
+	if err := c.patch(end); err != nil {
+		return err
 	}
+	c.code = append(c.code, OpCondEnd)
 	return nil
 }
 
-func (c *compiler) referenceNode(bb *bytes.Buffer, node *ast.ReferenceNode) error {
-	if id, ok := c.checkConstant(node.Name); ok {
-		//Globally declared constant
-		bb.WriteByte(OpGlobal)
-		bb.Write(encode(id))
-		return nil
-	} else {
-		v, err := c.lookupValue(node.Name)
-		if err != nil {
-			return err
-		}
-		switch value := v.(type) {
-		case *localValue:
-			bb.WriteByte(OpLoadLocal)
-			bb.Write(encode(value.position))
-			return nil
-		case *globalValue:
-			bb.WriteByte(OpLoad)
-			value.refer(c.patcher.add(bb))
-			bb.Write([]byte{0xff, 0xff})
-			return nil
-		default:
-			return errors.Errorf("unexpected value type '%T'", value)
-		}
-	}
-}
-
-func (c *compiler) functionDeclarationNode(bb *bytes.Buffer, node *ast.FunctionDeclarationNode) error {
-	var args []string
-	if c.callable != nil { // DApp callable function
-		args = make([]string, len(node.Arguments)+1)
-		args[0] = c.callable.parameter
-		copy(args[1:], node.Arguments)
-	} else {
-		args = node.Arguments
-	}
-	err := c.pushFunction(node.Name, args, node.Body, c.callable)
-	if err != nil {
+func (c *compiler) assignmentNode(node *ast.AssignmentNode) error {
+	if err := c.withName(OpLet, node.Name); err != nil {
 		return err
 	}
-	if node.Block != nil {
-		err = c.compile(bb, node.Block)
-		if err != nil {
-			return err
-		}
-		err = c.popFunction()
-		if err != nil {
-			return err
-		}
-	} else {
-		err = c.peekFunction()
-		if err != nil {
-			return err
-		}
+	c.segments = append(c.segments, segment{node: node.Expression, kind: valueSegment, ref: c.address()})
+	if err := c.compile(node.Block); err != nil {
+		return err
 	}
+	c.code = append(c.code, OpLetEnd)
 	return nil
 }
 
-func (c *compiler) callNode(bb *bytes.Buffer, node *ast.FunctionCallNode) error {
-	for _, arg := range node.Arguments {
-		err := c.compile(bb, arg)
-		if err != nil {
-			return err
-		}
+func (c *compiler) function(node *ast.FunctionDeclarationNode) (int, error) {
+	id := len(c.functions)
+	if id >= math.MaxUint16 {
+		return 0, errors.New("max number of functions reached")
 	}
-	cnt := encode(uint16(len(node.Arguments)))
-
-	if id, ok := c.checkFunction(node.Function.Name()); ok {
-		//External function
-		bb.WriteByte(OpExternalCall)
-		bb.Write(encode(id))
-		bb.Write(cnt)
-	} else {
-		//Internal function
-		decl, err := c.lookupFunction(node.Function.Name())
-		if err != nil {
-			return err
-		}
-		bb.WriteByte(OpCall)
-		decl.call(c.patcher.add(bb))
-		bb.Write([]byte{0xff, 0xff})
-		bb.Write(cnt)
-	}
-	return nil
+	c.functions = append(c.functions, function{name: node.Name, arguments: node.Arguments})
+	c.segments = append(c.segments, segment{node: node.Body, kind: functionSegment, ref: id})
+	return id, nil
 }
 
-func (c *compiler) propertyNode(bb *bytes.Buffer, node *ast.PropertyNode) error {
-	err := c.compile(bb, node.Object)
+func (c *compiler) functionDeclarationNode(node *ast.FunctionDeclarationNode) error {
+	id, err := c.function(node)
 	if err != nil {
 		return err
 	}
-	id, err := c.constants.put(rideString(node.Name))
-	if err != nil {
+	c.code = append(c.code, OpFunc)
+	c.code = binary.BigEndian.AppendUint16(c.code, uint16(id))
+	if err := c.compile(node.Block); err != nil {
 		return err
 	}
-	bb.WriteByte(OpProperty)
-	bb.Write(encode(id))
-	return nil
-}
-
-func (c *compiler) pushGlobalValue(name string, node ast.Node, annex *rideCallable) error {
-	d := newGlobalValue(name, annex)
-	c.callable = nil
-	c.values = append(c.values, d)
-	return c.compile(d.bb, node)
-}
-
-func (c *compiler) popValue() error {
-	l := len(c.values)
-	if l == 0 {
-		return errors.New("failed to pop value from empty stack")
-	}
-	var v rideValue
-	v, c.values = c.values[l-1], c.values[:l-1]
-	if d, ok := v.(rideDeclaration); ok {
-		c.declarations = append(c.declarations, d)
-	}
+	c.code = append(c.code, OpFuncEnd)
 	return nil
 }
 
-func (c *compiler) peekValue() error {
-	l := len(c.values)
-	if l == 0 {
-		return errors.New("failed to peek value from empty stack")
+func (c *compiler) callNode(node *ast.FunctionCallNode) error {
+	var op byte
+	switch node.Function.(type) {
+	case ast.NativeFunction:
+		op = OpExternalCall
+	case ast.UserFunction:
+		op = OpCall
+	default:
+		return errors.Errorf("unknown function type: %s", node.Function.Type())
 	}
-	v := c.values[l-1]
-	if d, ok := v.(rideDeclaration); ok {
-		c.declarations = append(c.declarations, d)
+	if len(node.Arguments) > math.MaxUint16 {
+		return errors.Errorf("too many arguments of function '%s'", node.Function.Name())
 	}
-	return nil
-}
-
-func (c *compiler) lookupValue(name string) (rideValue, error) {
-	for i := len(c.values) - 1; i >= 0; i-- {
-		if c.values[i].id() == name {
-			return c.values[i], nil
-		}
-	}
-	return nil, errors.Errorf("value '%s' is not declared", name)
-}
-
-func (c *compiler) pushFunction(name string, args []string, node ast.Node, annex *rideCallable) error {
-	d := newLocalFunction(name, args, annex)
-	c.functions = append(c.functions, d)
-	for i, a := range args {
-		c.values = append(c.values, newLocalValue(a, i))
-	}
-	c.callable = nil
-	err := c.compile(d.bb, node)
-	if err != nil {
+	if err := c.withName(op, node.Function.Name()); err != nil {
 		return err
 	}
-	for range args {
-		err := c.popValue()
-		if err != nil {
+	c.code = binary.BigEndian.AppendUint16(c.code, uint16(len(node.Arguments)))
+	for _, arg := range node.Arguments {
+		if err := c.compile(arg); err != nil {
 			return err
 		}
 	}
+	c.code = append(c.code, OpCallEnd)
 	return nil
 }
 
-func (c *compiler) popFunction() error {
-	l := len(c.functions)
-	if l == 0 {
-		return errors.New("failed to pop function from empty stack")
-	}
-	var f *localFunction
-	f, c.functions = c.functions[l-1], c.functions[:l-1]
-	c.declarations = append(c.declarations, f)
-	return nil
-}
-
-func (c *compiler) peekFunction() error {
-	l := len(c.functions)
-	if l == 0 {
-		return errors.New("failed to peek function from empty stack")
-	}
-	f := c.functions[l-1]
-	c.declarations = append(c.declarations, f)
-	return nil
-}
-
-func (c *compiler) lookupFunction(name string) (*localFunction, error) {
-	for i := len(c.functions) - 1; i >= 0; i-- {
-		if c.functions[i].name == name {
-			return c.functions[i], nil
-		}
-	}
-	return nil, errors.Errorf("function '%s' is not declared", name)
-}
-
-type rideCallable struct {
-	name      string
-	parameter string
-}
-
 type rideConstants struct {
 	items   []rideType
 	strings map[string]uint16
@@ -521,197 +376,3 @@ func (c *rideConstants) append(value rideType) (uint16, error) {
 	c.items = append(c.items, value)
 	return uint16(len(c.items) - 1), nil
 }
-
-type rideDeclaration interface {
-	buffer() *bytes.Buffer
-	references() []int
-	callable() *rideCallable
-}
-
-type rideValue interface {
-	id() string
-}
-
-type localValue struct {
-	name     string
-	position uint16
-}
-
-func newLocalValue(name string, pos int) *localValue {
-	return &localValue{
-		name:     name,
-		position: uint16(pos),
-	}
-}
-
-func (v *localValue) id() string {
-	return v.name
-}
-
-type globalValue struct {
-	name   string
-	annex  *rideCallable
-	bb     *bytes.Buffer
-	usages []int
-	using  []*globalValue
-}
-
-func newGlobalValue(name string, annex *rideCallable) *globalValue {
-	return &globalValue{
-		name:   name,
-		annex:  annex,
-		bb:     new(bytes.Buffer),
-		usages: make([]int, 0),
-		using:  make([]*globalValue, 0),
-	}
-}
-
-func (v *globalValue) buffer() *bytes.Buffer {
-	return v.bb
-}
-
-func (v *globalValue) references() []int {
-	return v.usages
-}
-
-func (v *globalValue) id() string {
-	return v.name
-}
-
-func (v *globalValue) refer(patch int) {
-	v.usages = append(v.usages, patch)
-}
-
-func (v *globalValue) callable() *rideCallable {
-	return v.annex
-}
-
-type localFunction struct {
-	name  string
-	annex *rideCallable
-	bb    *bytes.Buffer
-	calls []int
-	args  []string
-}
-
-func newLocalFunction(name string, args []string, annex *rideCallable) *localFunction {
-	return &localFunction{
-		name:  name,
-		annex: annex,
-		bb:    new(bytes.Buffer),
-		calls: make([]int, 0),
-		args:  args,
-	}
-}
-
-func (f *localFunction) buffer() *bytes.Buffer {
-	return f.bb
-}
-
-func (f *localFunction) references() []int {
-	return f.calls
-}
-
-func (f *localFunction) call(pos int) {
-	f.calls = append(f.calls, pos)
-}
-
-func (f *localFunction) callable() *rideCallable {
-	return f.annex
-}
-
-type patch struct {
-	id     int
-	origin int
-	pos    int
-	addr   uint16
-}
-
-type patcher struct {
-	count   int
-	buffers map[*bytes.Buffer][]patch
-}
-
-func newPatcher() *patcher {
-	return &patcher{
-		count:   0,
-		buffers: make(map[*bytes.Buffer][]patch),
-	}
-}
-
-func (p *patcher) add(bb *bytes.Buffer) int {
-	pt := patch{
-		id:  p.count,
-		pos: bb.Len(),
-	}
-	if bps, ok := p.buffers[bb]; ok {
-		p.buffers[bb] = append(bps, pt)
-	} else {
-		p.buffers[bb] = []patch{pt}
-	}
-	p.count++
-	return pt.id
-}
-
-func (p *patcher) setOrigin(bb *bytes.Buffer, origin int) {
-	if ps, ok := p.buffers[bb]; ok {
-		for i := range ps {
-			ps[i].origin = origin
-		}
-	}
-}
-
-func (p *patcher) setPosition(id int, addr uint16) {
-	for _, v := range p.buffers {
-		for i := range v {
-			if v[i].id == id {
-				v[i].addr = addr
-			}
-		}
-	}
-}
-
-func (p *patcher) get() (map[int]uint16, error) {
-	r := make(map[int]uint16)
-	for _, v := range p.buffers {
-		for i := range v {
-			abs := v[i].origin + v[i].pos
-			if _, ok := r[abs]; ok {
-				return nil, errors.Errorf("duplicate patch at position %d", abs)
-			}
-			r[abs] = v[i].addr
-		}
-	}
-	return r, nil
-}
-
-func encode(v uint16) []byte {
-	b := make([]byte, 2)
-	binary.BigEndian.PutUint16(b, v)
-	return b
-}
-
-func patchedCode(bb *bytes.Buffer, origin int) []byte {
-	bts := bb.Bytes()
-	i := 0
-	for i < bb.Len() {
-		switch bts[i] {
-		case OpJumpIfFalse, OpJump:
-			addr := bts[i+1 : i+3]
-			update(addr, origin)
-			i += 3
-		case OpPush, OpProperty, OpLoad, OpLoadLocal, OpGlobal:
-			i += 3
-		case OpCall, OpExternalCall:
-			i += 5
-		default:
-			i++
-		}
-	}
-	return bts
-}
-
-func update(b []byte, n int) {
-	v := binary.BigEndian.Uint16(b)
-	binary.BigEndian.PutUint16(b, uint16(int(v)+n))
-}
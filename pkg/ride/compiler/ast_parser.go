@@ -79,6 +79,8 @@ type astParser struct {
 	importPaths []importPath
 	isLibrary   bool
 	fileName    string
+
+	declarations []ast.Node // Declarations of other script available to the code.
 }
 
 func newASTParser(node *node32, buffer []rune) astParser {
@@ -170,6 +172,7 @@ func (p *astParser) ruleDAppRootHandler(node *node32) {
 		p.stdObjects = s.ObjectsByVersion()[p.tree.LibVersion]
 		p.stdTypes = s.DefaultTypes()[p.tree.LibVersion]
 		p.loadBuildInVarsToStackByVersion()
+		p.loadDeclarationsToStack()
 	}
 	p.loadImport()
 	curNode = skipToNextRule(curNode)
@@ -251,6 +254,7 @@ func (p *astParser) ruleScriptRootHandler(node *node32) {
 		p.stdObjects = s.ObjectsByVersion()[p.tree.LibVersion]
 		p.stdTypes = s.DefaultTypes()[p.tree.LibVersion]
 		p.loadBuildInVarsToStackByVersion()
+		p.loadDeclarationsToStack()
 	}
 	p.loadImport()
 	var decls []ast.Node
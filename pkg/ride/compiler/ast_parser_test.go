@@ -897,6 +897,36 @@ func cursed() = [][0]`,
 	}
 }
 
+func TestCompileWithDeclarations(t *testing.T) {
+	dApp, errs := CompileToTree(DappV6Directive + `
+let limit = 10
+func double(x: Int) = x * 2
+
+@Callable(i)
+func call() = []`)
+	require.Empty(t, errs)
+	const directives = "{-# STDLIB_VERSION 6 #-}\n{-# CONTENT_TYPE EXPRESSION #-}\n{-# SCRIPT_TYPE ACCOUNT #-}\n"
+
+	tree, errs := CompileToTreeWithDeclarations(directives+"double(limit) + 1 > limit", dApp.Declarations)
+	require.Empty(t, errs)
+	assert.Empty(t, tree.Declarations)
+	gt, ok := tree.Verifier.(*ast.FunctionCallNode)
+	require.True(t, ok)
+	sum, ok := gt.Arguments[0].(*ast.FunctionCallNode)
+	require.True(t, ok)
+	assert.Equal(t, &ast.FunctionCallNode{
+		Function:  ast.UserFunction("double"),
+		Arguments: []ast.Node{ast.NewReferenceNode("limit")},
+	}, sum.Arguments[0])
+
+	_, errs = CompileToTreeWithDeclarations(directives+"double(limit, 1) > 0", dApp.Declarations)
+	require.NotEmpty(t, errs)
+	assert.Contains(t, errs[0].Error(), "Function 'double' requires 1 arguments, but 2 are provided")
+	_, errs = CompileToTree(directives + "double(limit) > 0")
+	require.NotEmpty(t, errs)
+	assert.Contains(t, errs[0].Error(), "Variable 'limit' doesn't exist")
+}
+
 func TestBuiltInVarsWithCompaction(t *testing.T) {
 	tests := []struct {
 		code     string
@@ -8,6 +8,16 @@ import (
 //go:generate peg -output=parser.peg.go ride.peg
 
 func CompileToTree(code string) (*ast.Tree, []error) {
+	return compileToTree(code, nil)
+}
+
+// CompileToTreeWithDeclarations compiles the code with the given global declarations of other script in scope.
+// The declarations are not added to the resulting tree, types of declarations are inferred where possible.
+func CompileToTreeWithDeclarations(code string, declarations []ast.Node) (*ast.Tree, []error) {
+	return compileToTree(code, declarations)
+}
+
+func compileToTree(code string, declarations []ast.Node) (*ast.Tree, []error) {
 	pp := Parser{Buffer: code}
 	err := pp.Init()
 	if err != nil {
@@ -18,6 +28,7 @@ func CompileToTree(code string) (*ast.Tree, []error) {
 		return nil, []error{err}
 	}
 	ap := newASTParser(pp.AST(), pp.buffer)
+	ap.declarations = declarations
 	ap.parse()
 	if len(ap.errorsList) > 0 {
 		return nil, ap.errorsList
@@ -0,0 +1,122 @@
+package compiler
+
+import (
+	"github.com/wavesplatform/gowaves/pkg/ride/ast"
+	s "github.com/wavesplatform/gowaves/pkg/ride/compiler/stdlib"
+)
+
+// operatorTypes are the result types of functions the operators are compiled to,
+// they are not listed in the standard library.
+var operatorTypes = map[ast.Function]s.Type{
+	ast.NativeFunction("0"):   s.BooleanType, // ==
+	ast.UserFunction("!="):    s.BooleanType,
+	ast.UserFunction("!"):     s.BooleanType,
+	ast.UserFunction("-"):     s.IntType,
+	ast.NativeFunction("100"): s.IntType,
+	ast.NativeFunction("101"): s.IntType,
+	ast.NativeFunction("102"): s.BooleanType,
+	ast.NativeFunction("103"): s.BooleanType,
+	ast.NativeFunction("104"): s.IntType,
+	ast.NativeFunction("105"): s.IntType,
+	ast.NativeFunction("106"): s.IntType,
+	ast.NativeFunction("203"): s.ByteVectorType,
+	ast.NativeFunction("300"): s.StringType,
+	ast.NativeFunction("311"): s.BigIntType,
+	ast.NativeFunction("312"): s.BigIntType,
+	ast.NativeFunction("313"): s.BigIntType,
+	ast.NativeFunction("314"): s.BigIntType,
+	ast.NativeFunction("315"): s.BigIntType,
+	ast.NativeFunction("318"): s.BigIntType,
+	ast.NativeFunction("319"): s.BooleanType,
+	ast.NativeFunction("320"): s.BooleanType,
+}
+
+// loadDeclarationsToStack makes the declarations of other script available to the code.
+// Compiled declarations don't keep types, so types of variables and results of functions are inferred from
+// the declared expressions where possible, Any is used otherwise. Types of function arguments are always Any.
+func (p *astParser) loadDeclarationsToStack() {
+	for _, d := range p.declarations {
+		p.pushDeclaration(d)
+	}
+}
+
+func (p *astParser) pushDeclaration(node ast.Node) {
+	switch d := node.(type) {
+	case *ast.AssignmentNode:
+		p.stack.pushVariable(s.Variable{Name: d.Name, Type: p.inferType(d.Expression)})
+	case *ast.FunctionDeclarationNode:
+		p.stack.addFrame()
+		args := make([]s.Type, len(d.Arguments))
+		for i, name := range d.Arguments {
+			args[i] = s.AnyType
+			p.stack.pushVariable(s.Variable{Name: name, Type: s.AnyType})
+		}
+		retType := p.inferType(d.Body)
+		p.stack.dropFrame()
+		p.stack.pushFunc(s.FunctionParams{
+			ID:         ast.UserFunction(d.Name),
+			Arguments:  args,
+			ReturnType: retType,
+		})
+	}
+}
+
+func (p *astParser) inferType(node ast.Node) s.Type {
+	switch n := node.(type) {
+	case *ast.LongNode:
+		return s.IntType
+	case *ast.StringNode:
+		return s.StringType
+	case *ast.BytesNode:
+		return s.ByteVectorType
+	case *ast.BooleanNode:
+		return s.BooleanType
+	case *ast.ReferenceNode:
+		if v, ok := p.stack.variable(n.Name); ok {
+			return v.Type
+		}
+	case *ast.ConditionalNode:
+		return s.JoinTypes(p.inferType(n.TrueExpression), p.inferType(n.FalseExpression))
+	case *ast.AssignmentNode:
+		return p.inferBlockType(n, n.Block)
+	case *ast.FunctionDeclarationNode:
+		return p.inferBlockType(n, n.Block)
+	case *ast.PropertyNode:
+		if t, ok := p.stdObjects.GetField(p.inferType(n.Object), n.Name); ok {
+			return t
+		}
+	case *ast.FunctionCallNode:
+		return p.inferCallType(n)
+	}
+	return s.AnyType
+}
+
+func (p *astParser) inferBlockType(declaration, block ast.Node) s.Type {
+	p.stack.addFrame()
+	defer p.stack.dropFrame()
+	p.pushDeclaration(declaration)
+	return p.inferType(block)
+}
+
+func (p *astParser) inferCallType(call *ast.FunctionCallNode) s.Type {
+	if t, ok := operatorTypes[call.Function]; ok {
+		return t
+	}
+	if _, ok := call.Function.(ast.UserFunction); ok {
+		name := call.Function.Name()
+		if f, fok := p.stack.function(name); fok {
+			return f.ReturnType
+		}
+		if p.stdObjects.IsExist(name) { // Constructors of objects are called by the name of type.
+			return s.SimpleType{Type: name}
+		}
+	}
+	for _, overloaded := range p.stdFuncs.Funcs {
+		for _, f := range overloaded {
+			if f.ID == call.Function {
+				return f.ReturnType
+			}
+		}
+	}
+	return s.AnyType
+}
@@ -20,63 +20,89 @@ func TestSimpleScriptsCompilation(t *testing.T) {
 		source    string
 		code      string
 		constants []rideType
+		functions []function
 	}{
-		{`V1: true`, "AQa3b8tH", "0400", nil},
-		{`V3: let x = 1; true`, "AwQAAAABeAAAAAAAAAAAAQbtAkXn", "040002000001", c(rideInt(1))},
-		{`V3: let x = "abc"; true`, "AwQAAAABeAIAAAADYWJjBrpUkE4=", "040002000001", c(rideString("abc"))},
+		{`V1: true`, "AQa3b8tH",
+			"0300",
+			nil, nil},
+		{`V3: let x = 1; true`, "AwQAAAABeAAAAAAAAAAAAQbtAkXn",
+			"0900000000000a030a0002000101",
+			c(rideString("x"), rideInt(1)), nil},
+		{`V3: let x = "abc"; true`, "AwQAAAABeAIAAAADYWJjBrpUkE4=",
+			"0900000000000a030a0002000101",
+			c(rideString("x"), rideString("abc")), nil},
 		{`V3: func A() = 1; func B() = 2; true`, "AwoBAAAAAUEAAAAAAAAAAAAAAAABCgEAAAABQgAAAAAAAAAAAAAAAAIG+N0aQQ==",
-			"04000200010102000001", c(rideInt(1), rideInt(2))},
+			"0b00000b0001030c0c000200000102000101",
+			c(rideInt(1), rideInt(2)), []function{{"A", []string{}, 10}, {"B", []string{}, 14}}},
 		{`V3: func A() = 1; func B() = 2; A() != B()`, "AwoBAAAAAUEAAAAAAAAAAAAAAAABCgEAAAABQgAAAAAAAAAAAAAAAAIJAQAAAAIhPQAAAAIJAQAAAAFBAAAAAAkBAAAAAUIAAAAAv/Pmkg==",
-			"0a001400000a001000000900010002000200010102000001", c(rideInt(1), rideInt(2))},
+			"0b00000b00011000000002100001000012100002000012120c0c000200030102000401",
+			c(rideString("!="), rideString("A"), rideString("B"), rideInt(1), rideInt(2)), []function{{"A", []string{}, 27}, {"B", []string{}, 31}}},
 		{`V1: let i = 1; let s = "string"; toString(i) == s`, "AQQAAAABaQAAAAAAAAAAAQQAAAABcwIAAAAGc3RyaW5nCQAAAAAAAAIJAAGkAAAAAQUAAAABaQUAAAABcwIsH74=",
-			"0c001509002700010c00110900030002000200010102000001", c(rideInt(1), rideString("string"))},
+			"090000000000270900010000002b110002000211000300010d00000000120d00010000120a0a000200040102000501",
+			c(rideString("i"), rideString("s"), rideString("0"), rideString("420"), rideInt(1), rideString("string")), nil},
 		{`V3: if true then if true then true else false else false`, "AwMGAwYGBwdYjCji",
-			"04070013030407000e03040600100305060015030500", nil},
+			"0703060000001b070306000000140305000000150408050000001c040800",
+			nil, nil},
 		{`V3: if (true) then {let r = true; r} else {let r = false; r}`, "AwMGBAAAAAFyBgUAAAABcgQAAAABcgcFAAAAAXJ/ok0E",
-			"0407000b030c001006000f030c00120004010501", nil},
+			"07030600000019090000000000280d000000000a05000000260900000000002a0d000000000a080003010401",
+			c(rideString("r")), nil},
 		{`V3: if (let a = 1; a == 0) then {let a = 2; a == 0} else {let a = 0; a == 0}`, "AwMEAAAAAWEAAAAAAAAAAAEJAAAAAAAAAgUAAAABYQAAAAAAAAAAAAQAAAABYQAAAAAAAAAAAgkAAAAAAAACBQAAAAFhAAAAAAAAAAAABAAAAAFhAAAAAAAAAAAACQAAAAAAAAIFAAAAAWEAAAAAAAAAAAB3u9Yb",
-			"0c002a020001090003000207001d030c002e0200030900030002060029030c0032020005090003000200020000010200020102000401", c(rideInt(1), rideInt(0), rideInt(2), rideInt(0), rideInt(0), rideInt(0))},
-		{`let a = 1; let b = a; let c = b; a == c`,
-			"AwQAAAABYQAAAAAAAAAAAQQAAAABYgUAAAABYQQAAAABYwUAAAABYgkAAAAAAAACBQAAAAFhBQAAAAFjUFI1Og==",
-			"0c00140c000c0900030002000c0010010c00140102000001", c(rideInt(1))},
-		{`let x = addressFromString("3PJaDyprvekvPXPuAtxrapacuDJopgJRaU3"); let a = x; let b = a; let c = b; let d = c; let e = d; let f = e; f == e`,
-			"AQQAAAABeAkBAAAAEWFkZHJlc3NGcm9tU3RyaW5nAAAAAQIAAAAjM1BKYUR5cHJ2ZWt2UFhQdUF0eHJhcGFjdURKb3BnSlJhVTMEAAAAAWEFAAAAAXgEAAAAAWIFAAAAAWEEAAAAAWMFAAAAAWIEAAAAAWQFAAAAAWMEAAAAAWUFAAAAAWQEAAAAAWYFAAAAAWUJAAAAAAAAAgUAAAABZgUAAAABZS5FHzs=",
-			"0c000c0c00100900030002000c0010010c0014010c0018010c001c010c0020010c002401020000090046000101", c(rideString("3PJaDyprvekvPXPuAtxrapacuDJopgJRaU3"))},
-		{`V3: let x = { let y = 1; y == 0 }; let y = { let z = 2; z == 0 } x == y`,
-			"AwQAAAABeAQAAAABeQAAAAAAAAAAAQkAAAAAAAACBQAAAAF5AAAAAAAAAAAABAAAAAF5BAAAAAF6AAAAAAAAAAACCQAAAAAAAAIFAAAAAXoAAAAAAAAAAAAJAAAAAAAAAgUAAAABeAUAAAABedn8HVg=",
-			"0c00200c001409000300020002000001020002010c00100200030900030002010c000c020001090003000201", c(rideInt(1), rideInt(0), rideInt(2), rideInt(0))},
-		{`V3: let z = 0; let a = {let b = 1; b == z}; let b = {let c = 2; c == z}; a == b`,
-			"AwQAAAABegAAAAAAAAAAAAQAAAABYQQAAAABYgAAAAAAAAAAAQkAAAAAAAACBQAAAAFiBQAAAAF6BAAAAAFiBAAAAAFjAAAAAAAAAAACCQAAAAAAAAIFAAAAAWMFAAAAAXoJAAAAAAAAAgUAAAABYQUAAAABYnau3I8=",
-			"0c00200c001409000300020002000101020002010c00100c002c0900030002010c000c0c002c09000300020102000001", c(rideInt(0), rideInt(1), rideInt(2))},
+			"070900000000004f11000100020d00000000020002120a06000000370900000000005311000100020d00000000020003120a050000004d0900000000005711000100020d00000000020004120a0800020005010200060102000701",
+			c(rideString("a"), rideString("0"), rideInt(0), rideInt(0), rideInt(0), rideInt(1), rideInt(2), rideInt(0)), nil},
+		{`let a = 1; let b = a; let c = b; a == c`, "AwQAAAABYQAAAAAAAAAAAQQAAAABYgUAAAABYQQAAAABYwUAAAABYgkAAAAAAAACBQAAAAFhBQAAAAFjUFI1Og==",
+			"090000000000290900010000002d0900020000003311000300020d000000000d00020000120a0a0a00020004010d00000000010d0001000001",
+			c(rideString("a"), rideString("b"), rideString("c"), rideString("0"), rideInt(1)), nil},
+		{`let x = addressFromString("3PJaDyprvekvPXPuAtxrapacuDJopgJRaU3"); let a = x; let b = a; let c = b; let d = c; let e = d; let f = e; f == e`, "AQQAAAABeAkBAAAAEWFkZHJlc3NGcm9tU3RyaW5nAAAAAQIAAAAjM1BKYUR5cHJ2ZWt2UFhQdUF0eHJhcGFjdURKb3BnSlJhVTMEAAAAAWEFAAAAAXgEAAAAAWIFAAAAAWEEAAAAAWMFAAAAAWIEAAAAAWQFAAAAAWMEAAAAAWUFAAAAAWQEAAAAAWYFAAAAAWUJAAAAAAAAAgUAAAABZgUAAAABZS5FHzs=",
+			"0900000000004909000100000053090002000000590900030000005f090004000000650900050000006b0900060000007111000700020d000600000d00050000120a0a0a0a0a0a0a00100008000102000912010d00000000010d00010000010d00020000010d00030000010d00040000010d0005000001",
+			c(rideString("x"), rideString("a"), rideString("b"), rideString("c"), rideString("d"), rideString("e"), rideString("f"), rideString("0"), rideString("addressFromString"), rideString("3PJaDyprvekvPXPuAtxrapacuDJopgJRaU3")), nil},
+		{`V3: let x = { let y = 1; y == 0 }; let y = { let z = 2; z == 0 } x == y`, "AwQAAAABeAQAAAABeQAAAAAAAAAAAQkAAAAAAAACBQAAAAF5AAAAAAAAAAAABAAAAAF5BAAAAAF6AAAAAAAAAAACCQAAAAAAAAIFAAAAAXoAAAAAAAAAAAAJAAAAAAAAAgUAAAABeAUAAAABedn8HVg=",
+			"090000000000210900010000003811000200020d000000000d00010000120a0a000900010000004f11000200020d00010000020003120a010900040000005311000200020d00040000020005120a010200060102000701",
+			c(rideString("x"), rideString("y"), rideString("0"), rideInt(0), rideString("z"), rideInt(0), rideInt(1), rideInt(2)), nil},
+		{`V3: let z = 0; let a = {let b = 1; b == z}; let b = {let c = 2; c == z}; a == b`, "AwQAAAABegAAAAAAAAAAAAQAAAABYQQAAAABYgAAAAAAAAAAAQkAAAAAAAACBQAAAAFiBQAAAAF6BAAAAAFiBAAAAAFjAAAAAAAAAAACCQAAAAAAAAIFAAAAAWMFAAAAAXoJAAAAAAAAAgUAAAABYQUAAAABYnau3I8=",
+			"090000000000290900010000002d0900020000004611000300020d000100000d00020000120a0a0a00020004010900020000005f11000300020d000200000d00000000120a010900050000006311000300020d000500000d00000000120a010200060102000701",
+			c(rideString("z"), rideString("a"), rideString("b"), rideString("0"), rideInt(0), rideString("c"), rideInt(1), rideInt(2)), nil},
 		{`V3: func abs(i:Int) = if (i >= 0) then i else -i; abs(-10) == 10`, "AwoBAAAAA2FicwAAAAEAAAABaQMJAABnAAAAAgUAAAABaQAAAAAAAAAAAAUAAAABaQkBAAAAAS0AAAABBQAAAAFpCQAAAAAAAAIJAQAAAANhYnMAAAABAP/////////2AAAAAAAAAAAKmp8BWw==",
-			"0200010a001100010200020900030002000d000002000009000d0002070026030d000006002f030d0000090002000101", c(rideInt(0), rideInt(-10), rideInt(10))},
-		{`V3: if (true) then {if (false) then {func XX() = true; XX()} else {func XX() = false; XX()}} else {if (true) then {let x = false; x} else {let x = true; x}}`,
-			"AwMGAwcKAQAAAAJYWAAAAAAGCQEAAAACWFgAAAAACgEAAAACWFgAAAAABwkBAAAAAlhYAAAAAAMGBAAAAAF4BwUAAAABeAQAAAABeAYFAAAAAXgYYeMi",
-			"0407001b0305070012030a002c0000060018030a002e000006002b0304070027030c003006002b030c0032000401050105010401", nil},
+			"0b00001100000002100001000102000212020003120c000711000400020d000500000200061206000000350d00050000050000004010000700010d00050000120801",
+			c(rideString("0"), rideString("abs"), rideInt(-10), rideInt(10), rideString("103"), rideString("i"), rideInt(0), rideString("-")), []function{{"abs", []string{"i"}, 23}}},
+		{`V3: if (true) then {if (false) then {func XX() = true; XX()} else {func XX() = false; XX()}} else {if (true) then {let x = false; x} else {let x = true; x}}`, "AwMGAwcKAQAAAAJYWAAAAAAGCQEAAAACWFgAAAAACgEAAAACWFgAAAAABwkBAAAAAlhYAAAAAAMGBAAAAAF4BwUAAAABeAQAAAABeAYFAAAAAXgYYeMi",
+			"0703060000002d0704060000001d0b00001000000000120c05000000270b00011000000000120c080500000054070306000000460900010000005a0d000100000a05000000530900010000005c0d000100000a0808000301040104010301",
+			c(rideString("XX"), rideString("x")), []function{{"XX", []string{}, 86}, {"XX", []string{}, 88}}},
 		{`tx.sender == Address(base58'11111111111111111')`, "AwkAAAAAAAACCAUAAAACdHgAAAAGc2VuZGVyCQEAAAAHQWRkcmVzcwAAAAEBAAAAEQAAAAAAAAAAAAAAAAAAAAAAWc7d/w==",
-			"0b00180800000200010900510001090003000200", c(rideString("sender"), rideByteVector{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})},
+			"11000000020e00010d000200190f1000030001020004121200",
+			c(rideString("0"), rideString("sender"), rideString("tx"), rideString("Address"), rideByteVector{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}), nil},
 		{`func b(x: Int) = {func a(y: Int) = x + y; a(1) + a(2)}; b(2) + b(3) == 0`, "AwoBAAAAAWIAAAABAAAAAXgKAQAAAAFhAAAAAQAAAAF5CQAAZAAAAAIFAAAAAXgFAAAAAXkJAABkAAAAAgkBAAAAAWEAAAABAAAAAAAAAAABCQEAAAABYQAAAAEAAAAAAAAAAAIJAAAAAAAAAgkAAGQAAAACCQEAAAABYgAAAAEAAAAAAAAAAAIJAQAAAAFiAAAAAQAAAAAAAAAAAwAAAAAAAAAAAPsZlhQ=",
-			"0200020a002a00010200030a002a000109000500020200040900030002000d00000d00000900050002010200000a001e00010200010a001e0001090005000201", c(rideInt(1), rideInt(2), rideInt(2), rideInt(3), rideInt(0))},
+			"0b00001100000002110001000210000200010200031210000200010200041212020005120c000b00011100010002100006000102000712100006000102000812120c0111000100020d000900000d000a00001201",
+			c(rideString("0"), rideString("100"), rideString("b"), rideInt(2), rideInt(3), rideInt(0), rideString("a"), rideInt(1), rideInt(2), rideString("x"), rideString("y")), []function{{"b", []string{"x"}, 38}, {"a", []string{"y"}, 67}}},
 		{`func first(a: Int, b: Int) = {let x = a + b; x}; first(1, 2) == 0`, "AwoBAAAABWZpcnN0AAAAAgAAAAFhAAAAAWIEAAAAAXgJAABkAAAAAgUAAAABYQUAAAABYgUAAAABeAkAAAAAAAACCQEAAAAFZmlyc3QAAAACAAAAAAAAAAABAAAAAAAAAAACAAAAAAAAAAAAm+QHtw==",
-			"0200000200010a002000020200020900030002000d00000d00010900050002010c001401", c(rideInt(1), rideInt(2), rideInt(0))},
+			"0b00001100000002100001000202000202000312020004120c00090005000000280d000500000a0111000600020d000700000d000800001201",
+			c(rideString("0"), rideString("first"), rideInt(1), rideInt(2), rideInt(0), rideString("x"), rideString("100"), rideString("a"), rideString("b")), []function{{"first", []string{"a", "b"}, 26}}},
 		{`func A(x: Int, y: Int) = {let r = x + y; r}; func B(x: Int, y: Int) = {let r = A(x, y); r}; B(1, 2) == 3`, "AwoBAAAAAUEAAAACAAAAAXgAAAABeQQAAAABcgkAAGQAAAACBQAAAAF4BQAAAAF5BQAAAAFyCgEAAAABQgAAAAIAAAABeAAAAAF5BAAAAAFyCQEAAAABQQAAAAIFAAAAAXgFAAAAAXkFAAAAAXIJAAAAAAAAAgkBAAAAAUIAAAACAAAAAAAAAAABAAAAAAAAAAACAAAAAAAAAAADSAdb8g==",
-			"0200000200010a002c00020200020900030002000d00000d00010900050002010d00000d00010a00300002010c0020010c001401", c(rideInt(1), rideInt(2), rideInt(3))},
+			"0b00000b00011100000002100001000202000202000312020004120c0c000900050000003a0d000500000a010900050000004b0d000500000a0111000600020d000700000d00080000120110000900020d000700000d000800001201",
+			c(rideString("0"), rideString("B"), rideInt(1), rideInt(2), rideInt(3), rideString("r"), rideString("100"), rideString("x"), rideString("y"), rideString("A")), []function{{"A", []string{"x", "y"}, 30}, {"B", []string{"x", "y"}, 44}}},
 		{`func f1(a: Int, b: Int) = a + b; func f2(a: Int, b: Int) = a - b; f2(f1(1, 2), 3) == 0`, "AwoBAAAAAmYxAAAAAgAAAAFhAAAAAWIJAABkAAAAAgUAAAABYQUAAAABYgoBAAAAAmYyAAAAAgAAAAFhAAAAAWIJAABlAAAAAgUAAAABYQUAAAABYgkAAAAAAAACCQEAAAACZjIAAAACCQEAAAACZjEAAAACAAAAAAAAAAABAAAAAAAAAAACAAAAAAAAAAADAAAAAAAAAAAALZ/RdA==",
-			"0200000200010a002800020200020a001c00020200030900030002000d00000d000109000b0002010d00000d0001090005000201", c(rideInt(1), rideInt(2), rideInt(3), rideInt(0))},
+			"0b00000b00011100000002100001000210000200020200030200041202000512020006120c0c0011000700020d000800000d00090000120111000a00020d000800000d000900001201",
+			c(rideString("0"), rideString("f2"), rideString("f1"), rideInt(1), rideInt(2), rideInt(3), rideInt(0), rideString("100"), rideString("a"), rideString("b"), rideString("101")), []function{{"f1", []string{"a", "b"}, 39}, {"f2", []string{"a", "b"}, 56}}},
 		{`func f1(a: Int, b: Int) = a + b; func f2(a: Int, b: Int) = a - b; let x = f1(1, 2); f2(x, 3) == 0`, "AwoBAAAAAmYxAAAAAgAAAAFhAAAAAWIJAABkAAAAAgUAAAABYQUAAAABYgoBAAAAAmYyAAAAAgAAAAFhAAAAAWIJAABlAAAAAgUAAAABYQUAAAABYgQAAAABeAkBAAAAAmYxAAAAAgAAAAAAAAAAAQAAAAAAAAAAAgkAAAAAAAACCQEAAAACZjIAAAACBQAAAAF4AAAAAAAAAAADAAAAAAAAAAAAr1ooAg==",
-			"0c00140200020a002000020200030900030002000200000200010a002c0002010d00000d000109000b0002010d00000d0001090005000201", c(rideInt(1), rideInt(2), rideInt(3), rideInt(0))},
+			"0b00000b00010900000000004a110001000210000200020d0000000002000312020004120a0c0c0011000500020d000600000d00070000120111000800020d000600000d000700001201100009000202000a02000b1201",
+			c(rideString("x"), rideString("0"), rideString("f2"), rideInt(3), rideInt(0), rideString("100"), rideString("a"), rideString("b"), rideString("101"), rideString("f1"), rideInt(1), rideInt(2)), []function{{"f1", []string{"a", "b"}, 40}, {"f2", []string{"a", "b"}, 57}}},
 		{`func f1(a: Int, b: Int) = a + b; func f2(a: Int, b: Int) = b; f2(f1(1, 2), 3) == 3`, "AwoBAAAAAmYxAAAAAgAAAAFhAAAAAWIJAABkAAAAAgUAAAABYQUAAAABYgoBAAAAAmYyAAAAAgAAAAFhAAAAAWIFAAAAAWIJAAAAAAAAAgkBAAAAAmYyAAAAAgkBAAAAAmYxAAAAAgAAAAAAAAAAAQAAAAAAAAAAAgAAAAAAAAAAAwAAAAAAAAAAA1cKYN4=",
-			"0200000200010a002000020200020a001c00020200030900030002000d0001010d00000d0001090005000201", c(rideInt(1), rideInt(2), rideInt(3), rideInt(3))},
+			"0b00000b00011100000002100001000210000200020200030200041202000512020006120c0c0011000700020d000800000d0009000012010d0009000001",
+			c(rideString("0"), rideString("f2"), rideString("f1"), rideInt(1), rideInt(2), rideInt(3), rideInt(3), rideString("100"), rideString("a"), rideString("b")), []function{{"f1", []string{"a", "b"}, 39}, {"f2", []string{"a", "b"}, 56}}},
 		{`func f1(a: Int, b: Int) = a + b; func f2(a: Int, b: Int) = b; let x = f1(1, 2); f2(x, 3) == 3`, "AwoBAAAAAmYxAAAAAgAAAAFhAAAAAWIJAABkAAAAAgUAAAABYQUAAAABYgoBAAAAAmYyAAAAAgAAAAFhAAAAAWIFAAAAAWIEAAAAAXgJAQAAAAJmMQAAAAIAAAAAAAAAAAEAAAAAAAAAAAIJAAAAAAAAAgkBAAAAAmYyAAAAAgUAAAABeAAAAAAAAAAAAwAAAAAAAAAAA6avbPE=",
-			"0c00140200020a002000020200030900030002000200000200010a00240002010d0001010d00000d0001090005000201", c(rideInt(1), rideInt(2), rideInt(3), rideInt(3))},
+			"0b00000b00010900000000003f110001000210000200020d0000000002000312020004120a0c0c0011000500020d000600000d0007000012010d0007000001100008000202000902000a1201",
+			c(rideString("x"), rideString("0"), rideString("f2"), rideInt(3), rideInt(3), rideString("100"), rideString("a"), rideString("b"), rideString("f1"), rideInt(1), rideInt(2)), []function{{"f1", []string{"a", "b"}, 40}, {"f2", []string{"a", "b"}, 57}}},
 		{`let x = 1; func add(i: Int) = i + 1; add(x) == 2`, "AwQAAAABeAAAAAAAAAAAAQoBAAAAA2FkZAAAAAEAAAABaQkAAGQAAAACBQAAAAFpAAAAAAAAAAABCQAAAAAAAAIJAQAAAANhZGQAAAABBQAAAAF4AAAAAAAAAAACfr6U6w==",
-			"0c001d0a001100010200020900030002000d000002000109000500020102000001", c(rideInt(1), rideInt(1), rideInt(2))},
+			"090000000000210b0000110001000210000200010d0000000012020003120c0a000200040111000500020d000600000200071201",
+			c(rideString("x"), rideString("0"), rideString("add"), rideInt(2), rideInt(1), rideString("100"), rideString("i"), rideInt(1)), []function{{"add", []string{"i"}, 37}}},
 		{`let b = base16'0000000000000001'; func add(b: ByteVector) = toInt(b) + 1; add(b) == 2`, "AwQAAAABYgEAAAAIAAAAAAAAAAEKAQAAAANhZGQAAAABAAAAAWIJAABkAAAAAgkABLEAAAABBQAAAAFiAAAAAAAAAAABCQAAAAAAAAIJAQAAAANhZGQAAAABBQAAAAFiAAAAAAAAAAACX00biA==",
-			"0c00220a001100010200020900030002000d0000090020000102000109000500020102000001", c(rideByteVector{0, 0, 0, 0, 0, 0, 0, 1}, rideInt(1), rideInt(2))},
+			"090000000000210b0000110001000210000200010d0000000012020003120c0a0002000401110005000211000600010d00000000120200071201",
+			c(rideString("b"), rideString("0"), rideString("add"), rideInt(2), rideByteVector{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}, rideString("100"), rideString("1201"), rideInt(1)), []function{{"add", []string{"b"}, 37}}},
 		{`let b = base16'0000000000000001'; func add(v: ByteVector) = toInt(v) + 1; add(b) == 2`, "AwQAAAABYgEAAAAIAAAAAAAAAAEKAQAAAANhZGQAAAABAAAAAXYJAABkAAAAAgkABLEAAAABBQAAAAF2AAAAAAAAAAABCQAAAAAAAAIJAQAAAANhZGQAAAABBQAAAAFiAAAAAAAAAAACI7gYxg==",
-			"0c00220a001100010200020900030002000d0000090020000102000109000500020102000001", c(rideByteVector{0, 0, 0, 0, 0, 0, 0, 1}, rideInt(1), rideInt(2))},
+			"090000000000210b0000110001000210000200010d0000000012020003120c0a0002000401110005000211000600010d00070000120200081201",
+			c(rideString("b"), rideString("0"), rideString("add"), rideInt(2), rideByteVector{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}, rideString("100"), rideString("1201"), rideString("v"), rideInt(1)), []function{{"add", []string{"v"}, 37}}},
 		{`let b = base16'0000000000000001'; func add(v: ByteVector) = toInt(b) + 1; add(b) == 2`, "AwQAAAABYgEAAAAIAAAAAAAAAAEKAQAAAANhZGQAAAABAAAAAXYJAABkAAAAAgkABLEAAAABBQAAAAFiAAAAAAAAAAABCQAAAAAAAAIJAQAAAANhZGQAAAABBQAAAAFiAAAAAAAAAAAChRvwnQ==",
-			"0c00220a001100010200020900030002000c0022090020000102000109000500020102000001", c(rideByteVector{0, 0, 0, 0, 0, 0, 0, 1}, rideInt(1), rideInt(2))},
+			"090000000000210b0000110001000210000200010d0000000012020003120c0a0002000401110005000211000600010d00000000120200071201",
+			c(rideString("b"), rideString("0"), rideString("add"), rideInt(2), rideByteVector{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}, rideString("100"), rideString("1201"), rideInt(1)), []function{{"add", []string{"v"}, 37}}},
 	} {
 		src, err := base64.StdEncoding.DecodeString(test.source)
 		require.NoError(t, err, test.comment)
@@ -94,50 +120,63 @@ func TestSimpleScriptsCompilation(t *testing.T) {
 		code := hex.EncodeToString(script.Code)
 		assert.Equal(t, test.code, code, test.comment)
 		assert.ElementsMatch(t, test.constants, script.Constants, test.comment)
+		assert.ElementsMatch(t, test.functions, script.Functions, test.comment)
 	}
 }
 
 func TestDAppScriptsCompilation(t *testing.T) {
 	for _, test := range []struct {
-		comment   string
-		source    string
-		code      string
-		constants []rideType
-		entries   map[string]callable
+		comment      string
+		source       string
+		code         string
+		constants    []rideType
+		declarations []declaration
+		entries      map[string]callable
 	}{
 		{`@Verifier(tx) func verify() = false`, "AAIDAAAAAAAAAAIIAQAAAAAAAAAAAAAAAQAAAAJ0eAEAAAAGdmVyaWZ5AAAAAAcysh6J",
-			"0500", nil, map[string]callable{"": {0, "tx"}}},
+			"0400",
+			nil,
+			[]declaration{}, map[string]callable{"": {0, "tx", nil}}},
 		{`let a = 1\n@Verifier(tx) func verify() = false`, "AAIDAAAAAAAAAAIIAQAAAAEAAAAAAWEAAAAAAAAAAAEAAAAAAAAAAQAAAAJ0eAEAAAAGdmVyaWZ5AAAAAAdVrdkQ",
-			"020000010500", c(rideInt(1)), map[string]callable{"": {4, "tx"}}},
+			"040002000001",
+			c(rideInt(1)),
+			[]declaration{{"a", -1, 2}}, map[string]callable{"": {0, "tx", nil}}},
 		{`let a = 1\nfunc inc(v: Int) = {v + 1}\n@Verifier(tx) func verify() = false`, "AAIDAAAAAAAAAAIIAQAAAAIAAAAAAWEAAAAAAAAAAAEBAAAAA2luYwAAAAEAAAABdgkAAGQAAAACBQAAAAF2AAAAAAAAAAABAAAAAAAAAAEAAAACdHgBAAAABnZlcmlmeQAAAAAHDMc8rg==",
-			"020000010d00000200010900050002010500", c(rideInt(1), rideInt(1)), map[string]callable{"": {16, "tx"}}},
+			"04000200000111000100020d000200000200031201",
+			c(rideInt(1), rideString("100"), rideString("v"), rideInt(1)),
+			[]declaration{{"a", -1, 2}, {"inc", 0, 0}}, map[string]callable{"": {0, "tx", nil}}},
 		{`let a = 1\nfunc inc(v: Int) = {v + 1}\n@Verifier(tx) func verify() = inc(a) == 2`, "AAIDAAAAAAAAAAIIAQAAAAIAAAAAAWEAAAAAAAAAAAEBAAAAA2luYwAAAAEAAAABdgkAAGQAAAACBQAAAAF2AAAAAAAAAAABAAAAAAAAAAEAAAACdHgBAAAABnZlcmlmeQAAAAAJAAAAAAAAAgkBAAAAA2luYwAAAAEFAAAAAWEAAAAAAAAAAAJtD5WX",
-			"020000010d00000200010900050002010c00000a00040001020002090003000200", c(rideInt(1), rideInt(1), rideInt(2)),
-			map[string]callable{"": {16, "tx"}}},
+			"110000000210000100010d000200001202000312000200040111000500020d000600000200071201",
+			c(rideString("0"), rideString("inc"), rideString("a"), rideInt(2), rideInt(1), rideString("100"), rideString("v"), rideInt(1)),
+			[]declaration{{"a", -1, 21}, {"inc", 0, 0}}, map[string]callable{"": {0, "tx", nil}}},
 		{`let a = 1\nlet b = 1\nfunc inc(v: Int) = {v + 1}\nfunc add(x: Int, y: Int) = {x + y}\n@Verifier(tx) func verify() = inc(a) == add(a, b)`, "AAIDAAAAAAAAAAIIAQAAAAQAAAAAAWEAAAAAAAAAAAEAAAAAAWIAAAAAAAAAAAEBAAAAA2luYwAAAAEAAAABdgkAAGQAAAACBQAAAAF2AAAAAAAAAAABAQAAAANhZGQAAAACAAAAAXgAAAABeQkAAGQAAAACBQAAAAF4BQAAAAF5AAAAAAAAAAEAAAACdHgBAAAABnZlcmlmeQAAAAAJAAAAAAAAAgkBAAAAA2luYwAAAAEFAAAAAWEJAQAAAANhZGQAAAACBQAAAAFhBQAAAAFiDbIkmw==",
-			"02000001020001010d00000200020900050002010d00000d00010900050002010c00000a000800010c00000c00040a00140002090003000200",
-			c(rideInt(1), rideInt(1), rideInt(1)),
-			map[string]callable{"": {32, "tx"}}},
+			"110000000210000100010d000200001210000300020d000200000d00040000121200020005010200060111000700020d00080000020009120111000700020d000a00000d000b00001201",
+			c(rideString("0"), rideString("inc"), rideString("a"), rideString("add"), rideString("b"), rideInt(1), rideInt(1), rideString("100"), rideString("v"), rideInt(1), rideString("x"), rideString("y")),
+			[]declaration{{"a", -1, 34}, {"b", -1, 38}, {"inc", 0, 0}, {"add", 1, 0}}, map[string]callable{"": {0, "tx", nil}}},
 		{`let a = 1\nlet b = 1\nlet messages = ["INFO", "WARN"]\nfunc inc(v: Int) = {v + 1}\nfunc add(x: Int, y: Int) = {x + y}\nfunc msg(i: Int) = {messages[i]}\n@Verifier(tx) func verify() = if inc(a) == add(a, b) then throw(msg(a)) else throw(msg(b))`, "AAIDAAAAAAAAAAIIAQAAAAYAAAAAAWEAAAAAAAAAAAEAAAAAAWIAAAAAAAAAAAEAAAAACG1lc3NhZ2VzCQAETAAAAAICAAAABElORk8JAARMAAAAAgIAAAAEV0FSTgUAAAADbmlsAQAAAANpbmMAAAABAAAAAXYJAABkAAAAAgUAAAABdgAAAAAAAAAAAQEAAAADYWRkAAAAAgAAAAF4AAAAAXkJAABkAAAAAgUAAAABeAUAAAABeQEAAAADbXNnAAAAAQAAAAFpCQABkQAAAAIFAAAACG1lc3NhZ2VzBQAAAAFpAAAAAAAAAAEAAAACdHgBAAAABnZlcmlmeQAAAAADCQAAAAAAAAIJAQAAAANpbmMAAAABBQAAAAFhCQEAAAADYWRkAAAAAgUAAAABYQUAAAABYgkAAAIAAAABCQEAAAADbXNnAAAAAQUAAAABYQkAAAIAAAABCQEAAAADbXNnAAAAAQUAAAABYvi7IpM=",
-			"02000001020001010200020200030b001609001e000209001e0002010d00000200040900050002010d00000d00010900050002010c00080d00000900320002010c00000a001c00010c00000c00040a00280002090003000207006c030c00000a00340001090028000106007a030c00040a00340001090028000100",
-			c(rideInt(1), rideInt(1), rideString("INFO"), rideString("WARN"), rideInt(1)),
-			map[string]callable{"": {64, "tx"}}},
+			"07110000000210000100010d000200001210000300020d000200000d000400001212060000003d110005000110000600010d000200001212050000004e110005000110000600010d00040000121208000200070102000801110009000202000a110009000202000b0d000c001712120111000d00020d000e000002000f120111000d00020d001000000d00110000120111001200020d001300000d001400001201",
+			c(rideString("0"), rideString("inc"), rideString("a"), rideString("add"), rideString("b"), rideString("2"), rideString("msg"), rideInt(1), rideInt(1), rideString("1100"), rideString("INFO"), rideString("WARN"), rideString("nil"), rideString("100"), rideString("v"), rideInt(1), rideString("x"), rideString("y"), rideString("401"), rideString("messages"), rideString("i")),
+			[]declaration{{"a", -1, 80}, {"b", -1, 84}, {"messages", -1, 88}, {"inc", 0, 0}, {"add", 1, 0}, {"msg", 2, 0}}, map[string]callable{"": {0, "tx", nil}}},
 		{`@Callable(i)func f() = {WriteSet([DataEntry("YYY", "XXX")]}`, "AAIDAAAAAAAAAAQIARIAAAAAAAAAAAEAAAABaQEAAAABZgAAAAAJAQAAAAhXcml0ZVNldAAAAAEJAARMAAAAAgkBAAAACURhdGFFbnRyeQAAAAICAAAAA1lZWQIAAAADWFhYBQAAAANuaWwAAAAAeFguLA==",
-			"02000002000109005b00020b001609001e0002090082000100", c(rideString("YYY"), rideString("XXX")),
-			map[string]callable{"f": {0, "i"}}},
+			"100000000111000100021000020002020003020004120d00050017121200",
+			c(rideString("WriteSet"), rideString("1100"), rideString("DataEntry"), rideString("YYY"), rideString("XXX"), rideString("nil")),
+			[]declaration{}, map[string]callable{"f": {0, "i", []string{}}}},
 		{`@Callable(i)func f() = {let callerAddress = toBase58String(i.caller.bytes); WriteSet([DataEntry(callerAddress, "XXX")]}`, "AAIDAAAAAAAAAAQIARIAAAAAAAAAAAEAAAABaQEAAAABZgAAAAAEAAAADWNhbGxlckFkZHJlc3MJAAJYAAAAAQgIBQAAAAFpAAAABmNhbGxlcgAAAAVieXRlcwkBAAAACFdyaXRlU2V0AAAAAQkABEwAAAACCQEAAAAJRGF0YUVudHJ5AAAAAgUAAAANY2FsbGVyQWRkcmVzcwIAAAADWFhYBQAAAANuaWwAAAAAe3xtyw==",
-			"0d000008000008000109003d0001010c000002000209005b00020b001609001e0002090082000100",
-			c(rideString("caller"), rideString("bytes"), rideString("XXX")),
-			map[string]callable{"f": {15, "i"}}},
+			"090000000000281000010001110002000210000300020d00000000020004120d0005001712120a0011000600010e00070e00080d000900000f0f1201",
+			c(rideString("callerAddress"), rideString("WriteSet"), rideString("1100"), rideString("DataEntry"), rideString("XXX"), rideString("nil"), rideString("600"), rideString("bytes"), rideString("caller"), rideString("i")),
+			[]declaration{}, map[string]callable{"f": {0, "i", []string{}}}},
 		{`let messages = ["INFO", "WARN"]\nfunc msg(i: Int) = {messages[i]}\n@Callable(i)func tellme(x: Int) = {WriteSet([DataEntry("m", msg(x))]}`, "AAIDAAAAAAAAAAcIARIDCgEBAAAAAgAAAAAIbWVzc2FnZXMJAARMAAAAAgIAAAAESU5GTwkABEwAAAACAgAAAARXQVJOBQAAAANuaWwBAAAAA21zZwAAAAEAAAABaQkAAZEAAAACBQAAAAhtZXNzYWdlcwUAAAABaQAAAAEAAAABaQEAAAAGdGVsbG1lAAAAAQAAAAF4CQEAAAAIV3JpdGVTZXQAAAABCQAETAAAAAIJAQAAAAlEYXRhRW50cnkAAAACAgAAAAFtCQEAAAADbXNnAAAAAQUAAAABeAUAAAADbmlsAAAAAO4TltI=",
-			"0200000200010b001609001e000209001e0002010c00000d00000900320002010200020d00010a0014000109005b00020b001609001e0002090082000100", c(rideString("INFO"), rideString("WARN"), rideString("m")),
-			map[string]callable{"tellme": {32, "i"}}},
+			"10000000011100010002100002000202000310000400010d0005000012120d00060017121200110001000202000711000100020200080d0006001712120111000900020d000a00000d000b00001201",
+			c(rideString("WriteSet"), rideString("1100"), rideString("DataEntry"), rideString("m"), rideString("msg"), rideString("x"), rideString("nil"), rideString("INFO"), rideString("WARN"), rideString("401"), rideString("messages"), rideString("i")),
+			[]declaration{{"messages", -1, 38}, {"msg", 0, 0}}, map[string]callable{"tellme": {0, "i", []string{"x"}}}},
 		{`let messages = ["INFO", "WARN"]\nfunc msg(i: Int) = {messages[i]}\n@Callable(i)func tellme(x: Int, y: Int) = {WriteSet([DataEntry("m", msg(x))]}`, "AAIDAAAAAAAAAAgIARIECgIBAQAAAAIAAAAACG1lc3NhZ2VzCQAETAAAAAICAAAABElORk8JAARMAAAAAgIAAAAEV0FSTgUAAAADbmlsAQAAAANtc2cAAAABAAAAAWkJAAGRAAAAAgUAAAAIbWVzc2FnZXMFAAAAAWkAAAABAAAAAWkBAAAABnRlbGxtZQAAAAIAAAABeAAAAAF5CQEAAAAIV3JpdGVTZXQAAAABCQAETAAAAAIJAQAAAAlEYXRhRW50cnkAAAACAgAAAAFtCQEAAAADbXNnAAAAAQUAAAABeAUAAAADbmlsAAAAAD8Tlfs=",
-			"0200000200010b001609001e000209001e0002010c00000d00000900320002010200020d00010a0014000109005b00020b001609001e0002090082000100", c(rideString("INFO"), rideString("WARN"), rideString("m")),
-			map[string]callable{"tellme": {32, "i"}}},
+			"10000000011100010002100002000202000310000400010d0005000012120d00060017121200110001000202000711000100020200080d0006001712120111000900020d000a00000d000b00001201",
+			c(rideString("WriteSet"), rideString("1100"), rideString("DataEntry"), rideString("m"), rideString("msg"), rideString("x"), rideString("nil"), rideString("INFO"), rideString("WARN"), rideString("401"), rideString("messages"), rideString("i")),
+			[]declaration{{"messages", -1, 38}, {"msg", 0, 0}}, map[string]callable{"tellme": {0, "i", []string{"x", "y"}}}},
 		{`let a = 1; let messages = ["INFO", "WARN"]; func msg(i: Int) = {messages[i]}; @Callable(i)func tellme(x: Int) = {let m = msg(x); let callerAddress = toBase58String(i.caller.bytes); WriteSet([DataEntry(callerAddress + "-m", m)]}`, "AAIDAAAAAAAAAAcIARIDCgEBAAAAAwAAAAABYQAAAAAAAAAAAQAAAAAIbWVzc2FnZXMJAARMAAAAAgIAAAAESU5GTwkABEwAAAACAgAAAARXQVJOBQAAAANuaWwBAAAAA21zZwAAAAEAAAABaQkAAZEAAAACBQAAAAhtZXNzYWdlcwUAAAABaQAAAAEAAAABaQEAAAAGdGVsbG1lAAAAAQAAAAF4BAAAAAFtCQEAAAADbXNnAAAAAQUAAAABeAQAAAANY2FsbGVyQWRkcmVzcwkAAlgAAAABCAgFAAAAAWkAAAAGY2FsbGVyAAAABWJ5dGVzCQEAAAAIV3JpdGVTZXQAAAABCQAETAAAAAIJAQAAAAlEYXRhRW50cnkAAAACCQABLAAAAAIFAAAADWNhbGxlckFkZHJlc3MCAAAAAi1tBQAAAAFtBQAAAANuaWwAAAAAgveN3A==",
-			"020000010200010200020b001609001e000209001e0002010c00040d00000900320002010d000008000308000409003d0001010d00010a00180001010c002402000509002d00020c003309005b00020b001609001e0002090082000100", c(rideInt(1), rideString("INFO"), rideString("WARN"), rideString("caller"), rideString("bytes"), rideString("-m")),
-			map[string]callable{"tellme": {60, "i"}}},
+			"090000000000680900010000007410000200011100030002100004000211000500020d00010000020006120d00000000120d0007001712120a0a00020008011100030002020009110003000202000a0d0007001712120111000b00020d000c00000d000d0000120110000e00010d000f0000120111001000010e00110e00120d000d00000f0f1201",
+			c(rideString("m"), rideString("callerAddress"), rideString("WriteSet"), rideString("1100"), rideString("DataEntry"), rideString("300"), rideString("-m"), rideString("nil"), rideInt(1), rideString("INFO"), rideString("WARN"), rideString("401"), rideString("messages"), rideString("i"), rideString("msg"), rideString("x"), rideString("600"), rideString("bytes"), rideString("caller")),
+			[]declaration{{"a", -1, 59}, {"messages", -1, 63}, {"msg", 0, 0}}, map[string]callable{"tellme": {0, "i", []string{"x"}}}},
 	} {
 		src, err := base64.StdEncoding.DecodeString(test.source)
 		require.NoError(t, err, test.comment)
@@ -155,6 +194,7 @@ func TestDAppScriptsCompilation(t *testing.T) {
 		code := hex.EncodeToString(script.Code)
 		assert.Equal(t, test.code, code, test.comment)
 		assert.ElementsMatch(t, test.constants, script.Constants, test.comment)
+		assert.Equal(t, test.declarations, script.Declarations, test.comment)
 		assert.Equal(t, test.entries, script.EntryPoints, test.comment)
 	}
 }
@@ -0,0 +1,454 @@
+// Package decompiler renders a Ride AST back into the Ride source code.
+package decompiler
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/ride/ast"
+	"github.com/wavesplatform/gowaves/pkg/ride/compiler/stdlib"
+	"github.com/wavesplatform/gowaves/pkg/ride/meta"
+)
+
+const (
+	indentation = "    "
+	// maxBase58BytesLength is the max length of byte vector literal that is rendered in base58,
+	// longer byte vectors are rendered in base64.
+	maxBase58BytesLength = 64
+)
+
+// binaryOperators maps the IDs of native functions to infix operators of the language.
+var binaryOperators = map[string]string{
+	"0":    "==",
+	"100":  "+",
+	"101":  "-",
+	"102":  ">",
+	"103":  ">=",
+	"104":  "*",
+	"105":  "/",
+	"106":  "%",
+	"203":  "+",
+	"300":  "+",
+	"311":  "+",
+	"312":  "-",
+	"313":  "*",
+	"314":  "/",
+	"315":  "%",
+	"319":  ">",
+	"320":  ">=",
+	"1100": "::",
+	"1101": ":+",
+	"1102": "++",
+}
+
+const (
+	consFunctionID             = "1100"
+	getElementFunctionID       = "401"
+	unaryMinusBigIntFunction   = "318"
+	firstTupleConstructorID    = 1300
+	lastTupleConstructorID     = 1320
+	userNotEqualFunctionName   = "!="
+	userUnaryNotFunctionName   = "!"
+	userUnaryMinusFunctionName = "-"
+)
+
+// Decompile renders the tree as Ride source code with directives.
+func Decompile(tree *ast.Tree) (string, error) {
+	d, err := newDecompiler(tree.LibVersion)
+	if err != nil {
+		return "", err
+	}
+	return d.decompile(tree)
+}
+
+// ScriptType returns the value of SCRIPT_TYPE directive of the script. Compiled scripts don't keep the type,
+// so it's derived from the tree: dApps are always account scripts, and an expression is an asset script if it
+// reads fields of the asset from "this", which refers to the asset only in asset scripts.
+func ScriptType(tree *ast.Tree) string {
+	if !tree.IsDApp() && readsAssetFields(tree.Verifier) {
+		return "ASSET"
+	}
+	return "ACCOUNT"
+}
+
+func readsAssetFields(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.PropertyNode:
+		if ref, ok := n.Object.(*ast.ReferenceNode); ok && ref.Name == "this" && n.Name != "bytes" {
+			return true // The only field of address is "bytes".
+		}
+		return readsAssetFields(n.Object)
+	case *ast.ConditionalNode:
+		return readsAssetFields(n.Condition) || readsAssetFields(n.TrueExpression) ||
+			readsAssetFields(n.FalseExpression)
+	case *ast.AssignmentNode:
+		return readsAssetFields(n.Expression) || readsAssetFields(n.Block)
+	case *ast.FunctionDeclarationNode:
+		return readsAssetFields(n.Body) || readsAssetFields(n.Block)
+	case *ast.FunctionCallNode:
+		for _, a := range n.Arguments {
+			if readsAssetFields(a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type decompiler struct {
+	functionNames map[string]string
+	sb            strings.Builder
+}
+
+func newDecompiler(v ast.LibraryVersion) (*decompiler, error) {
+	funcs, ok := stdlib.FuncsByVersion()[v]
+	if !ok {
+		return nil, errors.Errorf("unsupported library version %d", v)
+	}
+	names := make([]string, 0, len(funcs.Funcs))
+	for name := range funcs.Funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names) // to make the choice between aliases deterministic
+	functionNames := make(map[string]string)
+	for _, name := range names {
+		for _, f := range funcs.Funcs[name] {
+			id := f.ID.Name()
+			if id == name {
+				continue
+			}
+			if _, ok := functionNames[id]; !ok {
+				functionNames[id] = name
+			}
+		}
+	}
+	return &decompiler{functionNames: functionNames}, nil
+}
+
+func (d *decompiler) decompile(tree *ast.Tree) (string, error) {
+	contentType := "EXPRESSION"
+	if tree.IsDApp() {
+		contentType = "DAPP"
+	}
+	d.writef("{-# STDLIB_VERSION %d #-}\n", tree.LibVersion)
+	d.writef("{-# SCRIPT_TYPE %s #-}\n", ScriptType(tree))
+	d.writef("{-# CONTENT_TYPE %s #-}\n", contentType)
+	if !tree.IsDApp() {
+		if err := d.block(tree.Verifier, 0); err != nil {
+			return "", err
+		}
+		d.sb.WriteString("\n")
+		return d.sb.String(), nil
+	}
+	for _, n := range tree.Declarations {
+		d.sb.WriteString("\n")
+		if err := d.declaration(n, nil, 0); err != nil {
+			return "", err
+		}
+		d.sb.WriteString("\n")
+	}
+	types := make(map[string][]meta.Type, len(tree.Meta.Functions))
+	for _, f := range tree.Meta.Functions {
+		types[f.Name] = f.Arguments
+	}
+	for _, n := range tree.Functions {
+		fn, ok := n.(*ast.FunctionDeclarationNode)
+		if !ok {
+			return "", errors.Errorf("unexpected callable function node type %T", n)
+		}
+		d.writef("\n@Callable(%s)\n", fn.InvocationParameter)
+		if err := d.declaration(fn, types[fn.Name], 0); err != nil {
+			return "", err
+		}
+		d.sb.WriteString("\n")
+	}
+	if tree.HasVerifier() {
+		fn, ok := tree.Verifier.(*ast.FunctionDeclarationNode)
+		if !ok {
+			return "", errors.Errorf("unexpected verifier node type %T", tree.Verifier)
+		}
+		d.writef("\n@Verifier(%s)\n", fn.InvocationParameter)
+		if err := d.declaration(fn, nil, 0); err != nil {
+			return "", err
+		}
+		d.sb.WriteString("\n")
+	}
+	return d.sb.String(), nil
+}
+
+func (d *decompiler) writef(format string, args ...any) {
+	_, _ = fmt.Fprintf(&d.sb, format, args...)
+}
+
+func (d *decompiler) indent(level int) {
+	d.sb.WriteString(strings.Repeat(indentation, level))
+}
+
+// declaration writes let or func declaration without the rest of the block.
+// Types of function arguments are known only for callable functions, they are taken from the meta.
+func (d *decompiler) declaration(n ast.Node, types []meta.Type, level int) error {
+	switch tn := n.(type) {
+	case *ast.AssignmentNode:
+		d.writef("let %s = ", tn.Name)
+		return d.body(tn.Expression, level)
+	case *ast.FunctionDeclarationNode:
+		args := make([]string, len(tn.Arguments))
+		for i, a := range tn.Arguments {
+			args[i] = a
+			if i < len(types) {
+				args[i] += ": " + typeString(types[i])
+			}
+		}
+		d.writef("func %s (%s) = ", tn.Name, strings.Join(args, ", "))
+		return d.body(tn.Body, level)
+	default:
+		return errors.Errorf("unexpected declaration node type %T", n)
+	}
+}
+
+// body writes the declaration body, wrapping it into braces if the body has its own declarations.
+func (d *decompiler) body(n ast.Node, level int) error {
+	if !isDeclaration(n) {
+		return d.expression(n, level)
+	}
+	d.sb.WriteString("{\n")
+	d.indent(level + 1)
+	if err := d.block(n, level+1); err != nil {
+		return err
+	}
+	d.sb.WriteString("\n")
+	d.indent(level)
+	d.sb.WriteString("}")
+	return nil
+}
+
+// block writes the sequence of declarations followed by the resulting expression.
+func (d *decompiler) block(n ast.Node, level int) error {
+	for isDeclaration(n) {
+		if err := d.declaration(n, nil, level); err != nil {
+			return err
+		}
+		d.sb.WriteString("\n")
+		d.indent(level)
+		switch tn := n.(type) {
+		case *ast.AssignmentNode:
+			n = tn.Block
+		case *ast.FunctionDeclarationNode:
+			n = tn.Block
+		}
+	}
+	return d.expression(n, level)
+}
+
+func (d *decompiler) expression(n ast.Node, level int) error {
+	switch tn := n.(type) {
+	case nil:
+		return errors.New("empty expression")
+	case *ast.LongNode:
+		d.sb.WriteString(strconv.FormatInt(tn.Value, 10))
+	case *ast.BooleanNode:
+		d.sb.WriteString(strconv.FormatBool(tn.Value))
+	case *ast.StringNode:
+		d.sb.WriteString(strconv.Quote(tn.Value))
+	case *ast.BytesNode:
+		if len(tn.Value) > maxBase58BytesLength {
+			d.writef("base64'%s'", base64.StdEncoding.EncodeToString(tn.Value))
+		} else {
+			d.writef("base58'%s'", base58.Encode(tn.Value))
+		}
+	case *ast.ReferenceNode:
+		d.sb.WriteString(tn.Name)
+	case *ast.PropertyNode:
+		if err := d.expression(tn.Object, level); err != nil {
+			return err
+		}
+		d.writef(".%s", tn.Name)
+	case *ast.ConditionalNode:
+		return d.conditional(tn, level)
+	case *ast.FunctionCallNode:
+		return d.functionCall(tn, level)
+	case *ast.AssignmentNode, *ast.FunctionDeclarationNode:
+		return d.body(tn, level)
+	default:
+		return errors.Errorf("unexpected node type %T", n)
+	}
+	return nil
+}
+
+func (d *decompiler) conditional(n *ast.ConditionalNode, level int) error {
+	// `a && b` and `a || b` are compiled into conditional expressions
+	if b, ok := n.FalseExpression.(*ast.BooleanNode); ok && !b.Value {
+		return d.infix("&&", []ast.Node{n.Condition, n.TrueExpression}, level)
+	}
+	if b, ok := n.TrueExpression.(*ast.BooleanNode); ok && b.Value {
+		return d.infix("||", []ast.Node{n.Condition, n.FalseExpression}, level)
+	}
+	d.sb.WriteString("if (")
+	if err := d.expression(n.Condition, level); err != nil {
+		return err
+	}
+	d.sb.WriteString(")\n")
+	d.indent(level + 1)
+	d.sb.WriteString("then ")
+	if err := d.body(n.TrueExpression, level+1); err != nil {
+		return err
+	}
+	d.sb.WriteString("\n")
+	d.indent(level + 1)
+	d.sb.WriteString("else ")
+	return d.body(n.FalseExpression, level+1)
+}
+
+func (d *decompiler) functionCall(n *ast.FunctionCallNode, level int) error {
+	id := n.Function.Name()
+	if _, ok := n.Function.(ast.NativeFunction); ok {
+		if elements, ok := listElements(n); ok {
+			d.sb.WriteString("[")
+			if err := d.sequence(elements, level); err != nil {
+				return err
+			}
+			d.sb.WriteString("]")
+			return nil
+		}
+		if op, ok := binaryOperators[id]; ok && len(n.Arguments) == 2 {
+			return d.infix(op, n.Arguments, level)
+		}
+		switch num, _ := strconv.Atoi(id); {
+		case id == getElementFunctionID && len(n.Arguments) == 2:
+			if err := d.expression(n.Arguments[0], level); err != nil {
+				return err
+			}
+			d.sb.WriteString("[")
+			if err := d.expression(n.Arguments[1], level); err != nil {
+				return err
+			}
+			d.sb.WriteString("]")
+			return nil
+		case id == unaryMinusBigIntFunction && len(n.Arguments) == 1:
+			return d.prefix("-", n.Arguments[0], level)
+		case firstTupleConstructorID <= num && num <= lastTupleConstructorID:
+			return d.arguments(n.Arguments, level)
+		}
+	} else {
+		switch {
+		case id == userNotEqualFunctionName && len(n.Arguments) == 2:
+			return d.infix("!=", n.Arguments, level)
+		case id == userUnaryNotFunctionName && len(n.Arguments) == 1:
+			return d.prefix("!", n.Arguments[0], level)
+		case id == userUnaryMinusFunctionName && len(n.Arguments) == 1:
+			return d.prefix("-", n.Arguments[0], level)
+		}
+	}
+	name, ok := d.functionNames[id]
+	if !ok {
+		if _, native := n.Function.(ast.NativeFunction); native {
+			return errors.Errorf("unknown native function '%s'", id)
+		}
+		name = id
+	}
+	d.sb.WriteString(name)
+	return d.arguments(n.Arguments, level)
+}
+
+func (d *decompiler) arguments(args []ast.Node, level int) error {
+	d.sb.WriteString("(")
+	if err := d.sequence(args, level); err != nil {
+		return err
+	}
+	d.sb.WriteString(")")
+	return nil
+}
+
+func (d *decompiler) sequence(nodes []ast.Node, level int) error {
+	for i, n := range nodes {
+		if i > 0 {
+			d.sb.WriteString(", ")
+		}
+		if err := d.expression(n, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decompiler) infix(op string, args []ast.Node, level int) error {
+	d.sb.WriteString("(")
+	if err := d.expression(args[0], level); err != nil {
+		return err
+	}
+	d.writef(" %s ", op)
+	if err := d.expression(args[1], level); err != nil {
+		return err
+	}
+	d.sb.WriteString(")")
+	return nil
+}
+
+func (d *decompiler) prefix(op string, arg ast.Node, level int) error {
+	d.sb.WriteString(op)
+	d.sb.WriteString("(")
+	if err := d.expression(arg, level); err != nil {
+		return err
+	}
+	d.sb.WriteString(")")
+	return nil
+}
+
+// listElements unfolds the chain of `a :: b :: nil` calls produced by the compiler for list literals.
+func listElements(n *ast.FunctionCallNode) ([]ast.Node, bool) {
+	var elements []ast.Node
+	for {
+		if n.Function.Name() != consFunctionID || len(n.Arguments) != 2 {
+			return nil, false
+		}
+		elements = append(elements, n.Arguments[0])
+		switch tail := n.Arguments[1].(type) {
+		case *ast.ReferenceNode:
+			return elements, tail.Name == "nil"
+		case *ast.FunctionCallNode:
+			n = tail
+		default:
+			return nil, false
+		}
+	}
+}
+
+func typeString(t meta.Type) string {
+	switch tt := t.(type) {
+	case meta.SimpleType:
+		switch tt {
+		case meta.Int:
+			return "Int"
+		case meta.Bytes:
+			return "ByteVector"
+		case meta.Boolean:
+			return "Boolean"
+		case meta.String:
+			return "String"
+		}
+	case meta.UnionType:
+		parts := make([]string, len(tt))
+		for i, st := range tt {
+			parts[i] = typeString(st)
+		}
+		return strings.Join(parts, "|")
+	case meta.ListType:
+		return "List[" + typeString(tt.Inner) + "]"
+	}
+	return "Any"
+}
+
+func isDeclaration(n ast.Node) bool {
+	switch n.(type) {
+	case *ast.AssignmentNode, *ast.FunctionDeclarationNode:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,107 @@
+package decompiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/ride/compiler"
+	"github.com/wavesplatform/gowaves/pkg/ride/serialization"
+)
+
+// Types of arguments of non-callable functions are lost during compilation,
+// so such scripts are checked against the expected source code instead of recompilation.
+func TestDecompileUserFunction(t *testing.T) {
+	code := `
+{-# STDLIB_VERSION 5 #-}
+{-# CONTENT_TYPE EXPRESSION #-}
+{-# SCRIPT_TYPE ACCOUNT #-}
+func check(v: Int) = if (v > 100) then "big" else "small"
+check(height) == "big"
+`
+	expected := `{-# STDLIB_VERSION 5 #-}
+{-# SCRIPT_TYPE ACCOUNT #-}
+{-# CONTENT_TYPE EXPRESSION #-}
+func check (v) = if ((v > 100))
+    then "big"
+    else "small"
+(check(height) == "big")
+`
+	tree, errs := compiler.CompileToTree(code)
+	require.Empty(t, errs)
+	src, err := Decompile(tree)
+	require.NoError(t, err)
+	assert.Equal(t, expected, src)
+}
+
+func TestDecompileRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		code string
+	}{
+		{"expression", `
+{-# STDLIB_VERSION 6 #-}
+{-# CONTENT_TYPE EXPRESSION #-}
+{-# SCRIPT_TYPE ACCOUNT #-}
+let a = 1 + 2 * 3
+let l = [1, 2, 3]
+let b = {
+    let y = a - 1
+    y % 2 == 0
+}
+(b || size(l) > 2) && l[0] != -a && !(toString(a) == "7") && base58'3Wd' == fromBase58String("3Wd")
+`},
+		{"dapp", `
+{-# STDLIB_VERSION 6 #-}
+{-# CONTENT_TYPE DAPP #-}
+{-# SCRIPT_TYPE ACCOUNT #-}
+let threshold = 100
+
+@Callable(i)
+func call(v: Int) = {
+    let t = (v, if (v > threshold) then "big" else "small")
+    [IntegerEntry("v", t._1), StringEntry("s", t._2 + "!")]
+}
+
+@Verifier(tx)
+func verify() = sigVerify(tx.bodyBytes, tx.proofs[0], tx.senderPublicKey)
+`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tree, errs := compiler.CompileToTree(test.code)
+			require.Empty(t, errs)
+			src, err := Decompile(tree)
+			require.NoError(t, err)
+
+			decompiled, errs := compiler.CompileToTree(src)
+			require.Empty(t, errs, src)
+			expected, err := serialization.SerializeTree(tree)
+			require.NoError(t, err)
+			actual, err := serialization.SerializeTree(decompiled)
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual, src)
+		})
+	}
+}
+
+func TestScriptType(t *testing.T) {
+	for _, test := range []struct {
+		code     string
+		expected string
+	}{
+		{"{-# STDLIB_VERSION 5 #-}\n{-# CONTENT_TYPE EXPRESSION #-}\n{-# SCRIPT_TYPE ASSET #-}\n" +
+			"let q = this.quantity\nq > 0", "ASSET"},
+		{"{-# STDLIB_VERSION 5 #-}\n{-# CONTENT_TYPE EXPRESSION #-}\n{-# SCRIPT_TYPE ACCOUNT #-}\n" +
+			"size(this.bytes) > 0", "ACCOUNT"},
+		{"{-# STDLIB_VERSION 5 #-}\n{-# CONTENT_TYPE DAPP #-}\n{-# SCRIPT_TYPE ACCOUNT #-}\n" +
+			"@Callable(i)\nfunc call() = []", "ACCOUNT"},
+	} {
+		tree, errs := compiler.CompileToTree(test.code)
+		require.Empty(t, errs)
+		assert.Equal(t, test.expected, ScriptType(tree))
+		src, err := Decompile(tree)
+		require.NoError(t, err)
+		assert.Contains(t, src, "{-# SCRIPT_TYPE "+test.expected+" #-}")
+	}
+}
@@ -0,0 +1,39 @@
+package ride
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Engine selects the way scripts are executed. DApps invoked from the script with 'invoke' and 'reentrantInvoke'
+// functions are executed by the same engine.
+type Engine byte
+
+const (
+	TreeEngine Engine = iota // Scripts are executed by walking the tree, default engine
+	VMEngine                 // Scripts are compiled to bytecode and executed by the Ride VM
+)
+
+func (e Engine) String() string {
+	switch e {
+	case TreeEngine:
+		return "tree"
+	case VMEngine:
+		return "vm"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseEngine returns the engine by its name.
+func ParseEngine(s string) (Engine, error) {
+	switch strings.ToLower(s) {
+	case "tree":
+		return TreeEngine, nil
+	case "vm":
+		return VMEngine, nil
+	default:
+		return 0, errors.Errorf("unknown Ride execution engine '%s'", s)
+	}
+}
@@ -29,6 +29,8 @@ type WrappedState struct {
 	rootScriptLibVersion      ast.LibraryVersion
 	rootActionsCountValidator proto.ActionsCountValidator
 	isLightNodeActivated      bool
+	// programs returns compiled scripts of invoked DApps, nil means that the DApp is executed by the tree evaluator.
+	programs func(tree *ast.Tree) RideScript
 }
 
 func newWrappedState(
@@ -1275,6 +1277,15 @@ func (e *EvaluationEnvironment) SetEthereumInvoke(tx *proto.EthereumTransaction,
 	return nil
 }
 
+// SetPrograms sets the source of compiled scripts of DApps invoked from the script. Invoked DApp is executed
+// by the Ride VM if its compiled script is returned and by the tree evaluator otherwise.
+// Only the environment with wrapped state is affected, because invocations are impossible without it.
+func (e *EvaluationEnvironment) SetPrograms(programs func(tree *ast.Tree) RideScript) {
+	if ws, ok := e.st.(*WrappedState); ok {
+		ws.programs = programs
+	}
+}
+
 func (e *EvaluationEnvironment) SetLimit(limit uint32) {
 	e.cc.setLimit(limit)
 }
@@ -9,6 +9,9 @@ func invokeFunctionFromDApp(env environment, tree *ast.Tree, fnName rideString,
 	if err != nil {
 		return nil, EvaluationFailure.Wrapf(err, "failed to invoke function '%s'", fnName)
 	}
+	if p := invokedProgram(env, tree); p != nil {
+		return runFunctionFromDApp(env, p, string(fnName), args)
+	}
 	e, err := treeFunctionEvaluator(env, tree, string(fnName), args)
 	if err != nil {
 		return nil, EvaluationFailure.Wrapf(err, "failed to call function '%s'", fnName)
@@ -20,3 +23,31 @@ func invokeFunctionFromDApp(env environment, tree *ast.Tree, fnName rideString,
 	}
 	return res, nil
 }
+
+// invokedProgram returns the compiled script of invoked DApp if it has to be executed by the Ride VM.
+func invokedProgram(env environment, tree *ast.Tree) RideScript {
+	ws, ok := env.state().(*WrappedState)
+	if !ok || ws.programs == nil {
+		return nil
+	}
+	return ws.programs(tree)
+}
+
+// runFunctionFromDApp is the counterpart of the tree evaluation in invokeFunctionFromDApp for the Ride VM.
+func runFunctionFromDApp(env environment, script RideScript, name string, args []rideType) (Result, error) {
+	m, entryPoint, err := functionVM(env, script, name, args)
+	if err != nil {
+		return nil, EvaluationFailure.Wrapf(err, "failed to call function '%s'", name)
+	}
+	defer m.release()
+	r, err := m.run(entryPoint)
+	var res Result
+	if err == nil {
+		res, err = evaluationResult(env, r)
+	}
+	if err != nil {
+		// Evaluation failed we have to add spent execution complexity to an error
+		return nil, EvaluationErrorSetComplexity(err, env.complexityCalculator().complexity())
+	}
+	return res, nil
+}
@@ -1,22 +1,28 @@
 package ride
 
 // Operation code is 1 byte.
-// Parameter is 2 bytes length
+// Parameters of operations are 2 bytes long, except addresses in code which are 4 bytes long.
+// Every node of the tree starts with its own operation, so the VM is able to reproduce the node by node evaluation
+// (complexity accounting, lazy values and dynamic scoping) of the tree evaluator.
 
 const (
-	OpHalt         byte = iota //00 - Halts program execution. No parameters.
-	OpReturn                   //01 - Returns from declaration to stored position. No parameters.
+	OpHalt         byte = iota //00 - Halts program execution, the result is on the top of stack. No parameters.
+	OpReturn                   //01 - Returns from expression of declaration or function body to stored position. No parameters.
 	OpPush                     //02 - Put constant on stack. One parameter: constant ID.
-	OpPop                      //03 - Removes value from stack. No parameters.
-	OpTrue                     //04 - Put True value on stack. No parameters.
-	OpFalse                    //05 - Put False value on stack. No parameters.
-	OpJump                     //06 - Moves instruction pointer to new position. One parameter: new position.
-	OpJumpIfFalse              //07 - Moves instruction pointer to new position if value on stack is False. One parameter: new position.
-	OpProperty                 //08 - Puts value of object's property on stack. One parameter: constant ID that holds name of the property.
-	OpExternalCall             //09 - Call a standard library function. Two parameters: function ID, number of arguments.
-	OpCall                     //0a - Call a function declared at given address. Two parameters: position of function declaration, number of arguments.
-	OpGlobal                   //0b - Load global constant. One parameter: global constant ID.
-	OpLoad                     //0c - Evaluates an expression that declared at address. One parameter: position of declaration.
-	OpLoadLocal                //0d - Load an argument of function call on stack. One parameter: argument number.
-	OpRef                      //0e - Put reference to expression/function on stack. One parameter: position of declaration.
+	OpTrue                     //03 - Put True value on stack. No parameters.
+	OpFalse                    //04 - Put False value on stack. No parameters.
+	OpJump                     //05 - Moves instruction pointer to new position. One parameter: new position (4 bytes).
+	OpJumpIfFalse              //06 - Pops the condition from stack, moves instruction pointer to new position if it's False. One parameter: new position (4 bytes).
+	OpCond                     //07 - Starts conditional expression. No parameters.
+	OpCondEnd                  //08 - Finishes conditional expression. No parameters.
+	OpLet                      //09 - Declares a lazy value. Two parameters: constant ID of the name, position of expression (4 bytes).
+	OpLetEnd                   //0a - Removes the value declared by the paired OpLet. No parameters.
+	OpFunc                     //0b - Declares a user function. One parameter: function ID.
+	OpFuncEnd                  //0c - Removes the function declared by the paired OpFunc. No parameters.
+	OpRef                      //0d - Puts the value of a declaration or a global constant on stack. Two parameters: constant ID of the name, global constant ID increased by 1 or 0 if there is no such global constant.
+	OpProperty                 //0e - Starts getting of an object's property. One parameter: constant ID of the name of property.
+	OpPropertyEnd              //0f - Pops an object from stack and puts its property on stack. No parameters.
+	OpCall                     //10 - Starts a call of user function, falls back to library function if no user function declared. Two parameters: constant ID of the name, number of arguments.
+	OpExternalCall             //11 - Starts a call of a standard library function. Two parameters: constant ID of the name, number of arguments.
+	OpCallEnd                  //12 - Pops arguments from stack and makes the call started by OpCall or OpExternalCall. No parameters.
 )
@@ -1,17 +1,35 @@
 package ride
 
 import (
-	"github.com/pkg/errors"
 	"github.com/wavesplatform/gowaves/pkg/ride/ast"
 )
 
+// callable describes an entry point of DApp's bytecode: a callable function or a verifier.
 type callable struct {
 	entryPoint    int
 	parameterName string
+	arguments     []string
 }
 
+// function describes a user function declared in a script.
+type function struct {
+	name       string
+	arguments  []string
+	entryPoint int
+}
+
+// declaration is a global declaration of DApp, a value or a function.
+type declaration struct {
+	name       string
+	function   int // Index of function in functions table, -1 for values
+	entryPoint int // Position of the value's expression
+}
+
+// RideScript is a script compiled into the bytecode of Ride virtual machine.
+// Compiled script doesn't depend on environment and can be executed multiple times concurrently.
 type RideScript interface {
 	Run(env environment) (Result, error)
+	LibraryVersion() ast.LibraryVersion
 	code() []byte
 }
 
@@ -20,37 +38,16 @@ type SimpleScript struct {
 	EntryPoint int
 	Code       []byte
 	Constants  []rideType
+	Functions  []function
 }
 
+// Run executes the script as a verifier.
 func (s *SimpleScript) Run(env environment) (Result, error) {
-	fs, err := selectFunctions(s.LibVersion)
-	if err != nil {
-		return nil, RuntimeError.Wrap(err, "simple script execution failed")
-	}
-	gcs, err := selectConstants(s.LibVersion)
-	if err != nil {
-		return nil, RuntimeError.Wrap(err, "simple script execution failed")
-	}
-	np, err := selectFunctionNameProvider(s.LibVersion)
-	if err != nil {
-		return nil, RuntimeError.Wrap(err, "simple script execution failed")
-	}
-	m := vm{
-		env:          env,
-		code:         s.Code,
-		ip:           0,
-		constants:    s.Constants,
-		functions:    fs,
-		globals:      gcs,
-		stack:        make([]rideType, 0, 2),
-		calls:        make([]frame, 0, 2),
-		functionName: np,
-	}
-	r, err := m.run()
-	if err != nil {
-		return nil, errors.Wrap(err, "simple script execution failed")
-	}
-	return r, nil
+	return RunVerifier(env, s)
+}
+
+func (s *SimpleScript) LibraryVersion() ast.LibraryVersion {
+	return s.LibVersion
 }
 
 func (s *SimpleScript) code() []byte {
@@ -58,44 +55,21 @@ func (s *SimpleScript) code() []byte {
 }
 
 type DAppScript struct {
-	LibVersion  ast.LibraryVersion
-	Code        []byte
-	Constants   []rideType
-	EntryPoints map[string]callable
+	LibVersion   ast.LibraryVersion
+	Code         []byte
+	Constants    []rideType
+	Functions    []function
+	Declarations []declaration
+	EntryPoints  map[string]callable // Verifier has an empty name
 }
 
+// Run executes the verifier of DApp.
 func (s *DAppScript) Run(env environment) (Result, error) {
-	if _, ok := s.EntryPoints[""]; !ok {
-		return nil, errors.Errorf("no verifier")
-	}
-	fs, err := selectFunctions(s.LibVersion)
-	if err != nil {
-		return nil, errors.Wrap(err, "script execution failed")
-	}
-	gcs, err := selectConstants(s.LibVersion)
-	if err != nil {
-		return nil, errors.Wrap(err, "script execution failed")
-	}
-	np, err := selectFunctionNameProvider(s.LibVersion)
-	if err != nil {
-		return nil, errors.Wrap(err, "script execution failed")
-	}
-	m := vm{
-		env:          env,
-		code:         s.Code,
-		ip:           0,
-		constants:    s.Constants,
-		functions:    fs,
-		globals:      gcs,
-		stack:        make([]rideType, 0, 2),
-		calls:        make([]frame, 0, 2),
-		functionName: np,
-	}
-	r, err := m.run()
-	if err != nil {
-		return nil, errors.Wrap(err, "script execution failed")
-	}
-	return r, nil
+	return RunVerifier(env, s)
+}
+
+func (s *DAppScript) LibraryVersion() ast.LibraryVersion {
+	return s.LibVersion
 }
 
 func (s *DAppScript) code() []byte {
@@ -52,3 +52,28 @@ func (r DAppResult) ScriptActions() []proto.ScriptAction {
 func (r DAppResult) Complexity() int {
 	return r.complexity
 }
+
+// ExpressionResult is a result of evaluation of an arbitrary expression, see EvaluateExpression.
+type ExpressionResult struct {
+	value      rideType
+	complexity int
+}
+
+func (r ExpressionResult) Result() bool {
+	if b, ok := r.value.(rideBoolean); ok {
+		return bool(b)
+	}
+	return true
+}
+
+func (r ExpressionResult) userResult() rideType {
+	return r.value
+}
+
+func (r ExpressionResult) ScriptActions() []proto.ScriptAction {
+	return nil
+}
+
+func (r ExpressionResult) Complexity() int {
+	return r.complexity
+}
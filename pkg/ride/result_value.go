@@ -0,0 +1,69 @@
+package ride
+
+import (
+	"strconv"
+
+	"github.com/mr-tron/base58"
+)
+
+const (
+	arrayTypeName = "Array"
+	tupleTypeName = "Tuple"
+)
+
+// ResultValue is a JSON friendly representation of Ride value, it is marshaled as `{"type": "...", "value": ...}`.
+// Values of simple types are converted to JSON values, lists and tuples are converted to arrays of ResultValue.
+// Byte vectors and addresses are encoded in Base58. Objects are represented by their text description.
+type ResultValue struct {
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// UserResultValue returns the value returned by the script or the callable function.
+// For callable functions the value is available only if the function returns a tuple of actions and a value.
+func UserResultValue(r Result) (ResultValue, bool) {
+	v := r.userResult()
+	if v == nil {
+		return ResultValue{}, false
+	}
+	return newResultValue(v), true
+}
+
+func newResultValue(v rideType) ResultValue {
+	switch tv := v.(type) {
+	case rideInt:
+		return ResultValue{Type: intTypeName, Value: int64(tv)}
+	case rideBigInt:
+		return ResultValue{Type: bigIntTypeName, Value: tv.v.String()}
+	case rideBoolean:
+		return ResultValue{Type: booleanTypeName, Value: bool(tv)}
+	case rideString:
+		return ResultValue{Type: stringTypeName, Value: string(tv)}
+	case rideByteVector:
+		return ResultValue{Type: byteVectorTypeName, Value: base58.Encode(tv)}
+	case rideAddress:
+		return ResultValue{Type: addressTypeName, Value: base58.Encode(tv[:])}
+	case rideAddressLike:
+		return ResultValue{Type: addressTypeName, Value: base58.Encode(tv)}
+	case rideUnit:
+		return ResultValue{Type: unitTypeName, Value: nil}
+	case rideList:
+		items := make([]ResultValue, len(tv))
+		for i, item := range tv {
+			items[i] = newResultValue(item)
+		}
+		return ResultValue{Type: arrayTypeName, Value: items}
+	case rideTuple:
+		items := make([]ResultValue, 0, tv.size())
+		for i := 1; i <= tv.size(); i++ {
+			item, err := tv.get("_" + strconv.Itoa(i))
+			if err != nil {
+				break // impossible, tuple always has all elements
+			}
+			items = append(items, newResultValue(item))
+		}
+		return ResultValue{Type: tupleTypeName, Value: items}
+	default:
+		return ResultValue{Type: v.instanceOf(), Value: v.String()}
+	}
+}
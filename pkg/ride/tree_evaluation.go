@@ -30,6 +30,15 @@ func CallFunction(env environment, tree *ast.Tree, fc proto.FunctionCall) (Resul
 	// After that instruction script/function is executed,
 	// so result of the execution and spent complexity should be considered outside.
 	rideResult, err := e.evaluate()
+	return functionCallResult(env, tree.LibVersion, name, rideResult, err)
+}
+
+// functionCallResult checks the result of callable function evaluation, sets spent complexity to the evaluation error
+// and adds the actions collected in wrapped state to the result.
+func functionCallResult(
+	env environment, v ast.LibraryVersion, name string, rideResult Result, err error,
+) (Result, error) {
+	complexity := env.complexityCalculator().complexity()
 	if err != nil {
 		// Evaluation failed we have to return a DAppResult that contains spent execution complexity
 		// Produced actions are not stored for failed transactions, no need to return them here
@@ -39,20 +48,20 @@ func CallFunction(env environment, tree *ast.Tree, fc proto.FunctionCall) (Resul
 				et.Wrap(err, "unhandled error"),
 				// Error was not handled in wrapped state properly,
 				// so we need to add both complexity from current evaluation and from internal invokes
-				e.complexity(),
+				complexity,
 			)
 		}
-		return nil, EvaluationErrorSetComplexity(err, e.complexity())
+		return nil, EvaluationErrorSetComplexity(err, complexity)
 	}
 	dAppResult, ok := rideResult.(DAppResult)
 	if !ok { // Unexpected result type
 		return nil, EvaluationErrorSetComplexity(
 			EvaluationFailure.Errorf("invalid result of call function '%s'", name),
 			// New error, both complexities should be added
-			e.complexity(),
+			complexity,
 		)
 	}
-	if tree.LibVersion < ast.LibV5 { // Shortcut because no wrapped state before version 5
+	if v < ast.LibV5 { // Shortcut because no wrapped state before version 5
 		return rideResult, nil
 	}
 	// Add actions from wrapped state
@@ -61,6 +70,27 @@ func CallFunction(env environment, tree *ast.Tree, fc proto.FunctionCall) (Resul
 	return dAppResult, nil
 }
 
+// EvaluateExpression evaluates the expression in the scope of global declarations of the script.
+// Unlike CallVerifier and CallFunction the resulting value is returned as is,
+// it's not required to be a boolean or a list of actions. Invocations of other dApps are disabled.
+func EvaluateExpression(env environment, tree *ast.Tree, expr ast.Node) (Result, error) {
+	s, err := newEvaluationScope(tree.LibVersion, env, false)
+	if err != nil {
+		return nil, EvaluationFailure.Wrap(err, "failed to create scope")
+	}
+	for _, declaration := range tree.Declarations {
+		if dErr := s.declare(declaration); dErr != nil {
+			return nil, EvaluationFailure.Wrap(dErr, "invalid declaration")
+		}
+	}
+	e := &treeEvaluator{dapp: tree.IsDApp(), f: expr, s: s, env: env}
+	r, err := e.walk(e.f)
+	if err != nil {
+		return nil, EvaluationErrorSetComplexity(err, e.complexity())
+	}
+	return ExpressionResult{value: r, complexity: e.complexity()}, nil
+}
+
 func wrappedStateActions(state types.SmartState) []proto.ScriptAction {
 	ws, ok := state.(*WrappedState)
 	if !ok {
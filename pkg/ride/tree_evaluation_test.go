@@ -77,6 +77,10 @@ func TestSimpleScriptEvaluation(t *testing.T) {
 		r, ok := res.(ScriptResult)
 		assert.True(t, ok, test.comment)
 		assert.Equal(t, test.res, r.Result(), test.comment)
+
+		assertEnginesParity(t, test.comment, tree, func() environment {
+			return test.tEnv.withLibVersion(tree.LibVersion).withComplexityLimit(2000).toEnv()
+		}, "", nil)
 	}
 }
 
@@ -265,6 +269,10 @@ func TestFunctionsEvaluation(t *testing.T) {
 			assert.True(t, ok, test.name)
 			assert.Equal(t, test.result, r.Result(), test.name)
 		}
+
+		assertEnginesParity(t, test.name, tree, func() environment {
+			return test.tEnv.withLibVersion(tree.LibVersion).withComplexityLimit(2000).toEnv()
+		}, "", nil)
 	}
 }
 
@@ -6408,3 +6416,38 @@ func TestUpdateGeneratingBalanceDuringScriptExecutionAfterLightNode(t *testing.T
 		assert.True(t, bool(isBalanceUpdated))
 	})
 }
+
+func TestEvaluateExpression(t *testing.T) {
+	dApp := newTestAccount(t, "DAPP")
+	src := `
+	{-# STDLIB_VERSION 6 #-}
+	{-# CONTENT_TYPE DAPP #-}
+	{-# SCRIPT_TYPE ACCOUNT #-}
+	let threshold = 10
+	func double(x: Int) = (x * 2, "doubled")
+
+	@Callable(i)
+	func call() = []
+	`
+	tree, errs := ridec.CompileToTree(src)
+	require.Empty(t, errs)
+
+	env := newTestEnv(t).withLibVersion(ast.LibV6).withComplexityLimit(2000).withThis(dApp).toEnv()
+	expr := ast.NewFunctionCallNode(ast.UserFunction("double"), []ast.Node{ast.NewReferenceNode("threshold")})
+	res, err := EvaluateExpression(env, tree, expr)
+	require.NoError(t, err)
+	assert.True(t, res.Result())
+	assert.Positive(t, res.Complexity())
+	v, ok := UserResultValue(res)
+	require.True(t, ok)
+	assert.Equal(t, ResultValue{Type: "Tuple", Value: []ResultValue{
+		{Type: "Int", Value: int64(20)},
+		{Type: "String", Value: "doubled"},
+	}}, v)
+
+	expr = ast.NewFunctionCallNode(ast.NativeFunction("2"), []ast.Node{ast.NewStringNode("boom")}) // throw("boom")
+	_, err = EvaluateExpression(env, tree, expr)
+	require.Error(t, err)
+	assert.Equal(t, UserError, GetEvaluationErrorType(err))
+	assert.EqualError(t, err, "boom")
+}
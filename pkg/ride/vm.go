@@ -2,223 +2,599 @@ package ride
 
 import (
 	"encoding/binary"
+	"sync"
 
 	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/ride/ast"
 )
 
+type frameKind byte
+
+const (
+	conditionalFrame frameKind = iota
+	letFrame
+	functionFrame
+	referenceFrame
+	propertyFrame
+	nativeCallFrame
+	userCallFrame
+)
+
+// frame is a node under evaluation that has to do something on completion or on failure,
+// e.g. add complexity of the node or extend the call stack of the error.
 type frame struct {
-	function bool
-	back     int
-	args     []rideType
+	kind       frameKind
+	name       string
+	done       bool // Condition is evaluated or arguments of the call are materialized
+	height     int  // Height of the stack at the beginning of the node
+	back       int  // Return position for the lazy value's expression or the function's body
+	frame, pos int  // Position of the lazy value in scope
+	cl         int  // Saved scope limit of the caller of user function
+	complexity int  // Complexity at the beginning of user function evaluation
+	fn         rideFunction
+	cost       int
+	argc       int
 }
 
-func newExpressionFrame(pos int) frame {
-	return frame{
-		back: pos,
-	}
+type vmValue struct {
+	id    string
+	value rideType
+	entry int // Position of the lazy value's expression
 }
 
-func newFunctionFrame(pos int, args []rideType) frame {
-	return frame{
-		function: true,
-		back:     pos,
-		args:     args,
-	}
+type vmFunction struct {
+	fn *function
+	sp int
 }
 
+// vm executes the bytecode of Ride in the same way the tree evaluator walks the tree.
+// Values and functions are looked up by name in scope with the same rules,
+// so the results and spent complexity of both engines are identical.
 type vm struct {
-	env          environment
-	code         []byte
-	ip           int
-	constants    []rideType
-	functions    func(int) rideFunction
-	globals      func(int) rideConstructor
-	stack        []rideType
-	calls        []frame
-	functionName func(int) string
+	env       environment
+	code      []byte
+	ip        int
+	constants []rideType
+	functions []function
+	system    func(string) (rideFunction, bool)
+	costs     map[string]int
+	provider  func(int) rideConstructor
+	globals   []rideType // Global constants are evaluated lazily and only once, as in the tree evaluator
+	param     string     // Name of the invocation parameter, it shadows the global constant with the same name
+	paramC    rideConstructor
+	paramV    rideType
+	values    [][]vmValue
+	user      []vmFunction
+	cl        int
+	stack     []rideType
+	frames    []frame
+}
+
+func newVM(
+	env environment, v ast.LibraryVersion, code []byte, constants []rideType, functions []function,
+	enableInvocation bool,
+) (*vm, error) {
+	names, err := selectConstantNames(v)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := selectConstants(v)
+	if err != nil {
+		return nil, err
+	}
+	system, err := selectFunctionsByName(v, enableInvocation)
+	if err != nil {
+		return nil, err
+	}
+	ev := 1
+	if env.rideV6Activated() {
+		ev = 2
+	}
+	costs, err := selectEvaluationCostsProvider(v, ev)
+	if err != nil {
+		return nil, err
+	}
+	m := vmPool.Get().(*vm)
+	m.env = env
+	m.code = code
+	m.constants = constants
+	m.functions = functions
+	m.system = system
+	m.costs = costs
+	m.provider = provider
+	if cap(m.globals) < len(names) {
+		m.globals = make([]rideType, len(names))
+	}
+	m.globals = m.globals[:len(names)]
+	return m, nil
+}
+
+// VMs are pooled to reuse the memory of stacks and scopes, it's crucial for the verification of blocks.
+var vmPool = sync.Pool{
+	New: func() any {
+		return &vm{
+			values: [][]vmValue{make([]vmValue, 0, 8)},
+			stack:  make([]rideType, 0, 16),
+			frames: make([]frame, 0, 16),
+		}
+	},
 }
 
-func (m *vm) run() (Result, error) {
-	if m.stack != nil {
-		m.stack = m.stack[0:0]
+// release clears the VM and puts it back to the pool, the VM must not be used after release.
+func (m *vm) release() {
+	clear(m.globals)
+	values := m.values[:1]
+	clear(values[0][:cap(values[0])])
+	values[0] = values[0][:0]
+	clear(m.values[1:cap(m.values)])
+	clear(m.stack[:cap(m.stack)])
+	clear(m.frames[:cap(m.frames)])
+	clear(m.user[:cap(m.user)])
+	*m = vm{
+		globals: m.globals[:0],
+		values:  values,
+		user:    m.user[:0],
+		stack:   m.stack[:0],
+		frames:  m.frames[:0],
 	}
-	if m.calls != nil {
-		m.calls = m.calls[0:0]
+	vmPool.Put(m)
+}
+
+// setParameter declares the invocation parameter of the entry point.
+func (m *vm) setParameter(name string, c rideConstructor) {
+	m.param = name
+	m.paramC = c
+}
+
+func (m *vm) declare(d declaration) {
+	if d.function >= 0 {
+		m.pushUserFunction(&m.functions[d.function])
+		return
 	}
-	m.ip = 0
+	m.pushExpression(d.name, d.entryPoint)
+}
+
+func (m *vm) complexity() int {
+	return m.env.complexityCalculator().complexity()
+}
+
+func (m *vm) run(entryPoint int) (rideType, error) {
+	m.ip = entryPoint
 	for m.ip < len(m.code) {
 		op := m.code[m.ip]
 		m.ip++
+		var err error
 		switch op {
+		case OpHalt:
+			if len(m.stack) != 1 {
+				return nil, EvaluationFailure.Errorf("invalid stack size %d after script execution", len(m.stack))
+			}
+			return m.pop(), nil
+		case OpReturn:
+			err = m.ret()
 		case OpPush:
-			m.push(m.constant())
-		case OpPop:
-			_, err := m.pop()
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to pop value")
+			c := m.constant()
+			if err = m.walk(op, c); err == nil {
+				m.push(c)
 			}
 		case OpTrue:
-			m.push(rideBoolean(true))
+			if err = m.walk(op, nil); err == nil {
+				m.push(rideBoolean(true))
+			}
 		case OpFalse:
-			m.push(rideBoolean(false))
+			if err = m.walk(op, nil); err == nil {
+				m.push(rideBoolean(false))
+			}
 		case OpJump:
-			pos := m.arg16()
-			m.ip = pos
+			m.ip = m.address()
 		case OpJumpIfFalse:
-			pos := m.arg16()
-			v, ok := m.current().(rideBoolean)
+			pos := m.address()
+			m.frames[len(m.frames)-1].done = true
+			cr, ok := m.pop().(rideBoolean)
 			if !ok {
-				return nil, errors.Errorf("not a boolean value '%v' of type '%T'", m.current(), m.current())
-			}
-			if !v {
+				err = RuntimeError.New("conditional is not a boolean")
+			} else if !cr {
 				m.ip = pos
 			}
-		case OpProperty:
-			obj, err := m.pop()
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to get object")
-			}
-			prop := m.constant()
-			p, ok := prop.(rideString)
-			if !ok {
-				return nil, errors.Errorf("invalid property name type '%s'", prop.instanceOf())
+		case OpCond:
+			err = m.conditional()
+		case OpCondEnd:
+			m.popFrame()
+			m.env.complexityCalculator().addConditionalComplexity()
+		case OpLet:
+			name := m.name()
+			pos := m.address()
+			if err = m.walk(op, nil); err == nil {
+				m.pushExpression(name, pos)
+				m.frames = append(m.frames, frame{kind: letFrame, name: name, height: len(m.stack)})
 			}
-			v, err := obj.get(string(p))
-			if err != nil {
-				return nil, err
+		case OpLetEnd:
+			m.popValue()
+			m.popFrame()
+		case OpFunc:
+			fn := &m.functions[m.arg16()]
+			if err = m.walk(op, nil); err == nil {
+				m.pushUserFunction(fn)
+				m.frames = append(m.frames, frame{kind: functionFrame, name: fn.name, height: len(m.stack)})
 			}
-			m.push(v)
+		case OpFuncEnd:
+			m.popUserFunction()
+			m.popFrame()
+		case OpRef:
+			name := m.name()
+			err = m.reference(name, m.arg16())
+		case OpProperty:
+			err = m.property(m.name())
+		case OpPropertyEnd:
+			err = m.propertyEnd()
 		case OpCall:
-			pos := m.arg16()
-			cnt := m.arg16()
-			in := make([]rideType, cnt)
-			for i := cnt - 1; i >= 0; i-- {
-				v, err := m.pop()
-				if err != nil {
-					return nil, errors.Wrapf(err, "failed to call function at position %d", pos)
+			name := m.name()
+			argc := m.arg16()
+			if err = m.walk(op, nil); err == nil {
+				if _, _, found := m.userFunction(name); found {
+					m.frames = append(m.frames, frame{kind: userCallFrame, name: name, height: len(m.stack), argc: argc})
+				} else {
+					err = m.nativeCall(name, argc)
 				}
-				in[i] = v
 			}
-			frame := newFunctionFrame(m.ip, in) // Creating new function frame with return position
-			m.calls = append(m.calls, frame)
-			m.ip = pos // Continue to function
 		case OpExternalCall:
-			// Before calling external function all parameters must be evaluated and placed on stack
-			id := m.arg16()
-			cnt := m.arg16()
-			in := make([]rideType, cnt) // Prepare input parameters for external call
-			for i := cnt - 1; i >= 0; i-- {
-				v, err := m.pop()
-				if err != nil {
-					return nil, errors.Wrapf(err, "failed to call external function '%s'", m.functionName(id))
-				}
-				in[i] = v
-			}
-			fn := m.functions(id)
-			if fn == nil {
-				return nil, errors.Errorf("external function '%s' not implemented", m.functionName(id))
+			name := m.name()
+			argc := m.arg16()
+			if err = m.walk(op, nil); err == nil {
+				err = m.nativeCall(name, argc)
 			}
-			res, err := fn(m.env, in...)
-			if err != nil {
-				return nil, err
-			}
-			m.push(res)
-		case OpLoad: // Evaluate expression behind a LET declaration
-			pos := m.arg16()
-			frame := newExpressionFrame(m.ip) // Creating new function frame with return position
-			m.calls = append(m.calls, frame)
-			m.ip = pos // Continue to expression
-		case OpLoadLocal:
-			n := m.arg16()
-			for i := len(m.calls) - 1; i >= 0; i-- {
+		case OpCallEnd:
+			err = m.callEnd()
+		default:
+			err = EvaluationFailure.Errorf("unknown code %#x", op)
+		}
+		if err != nil {
+			return nil, m.unwind(err)
+		}
+	}
+	return nil, EvaluationFailure.New("broken code")
+}
 
+// walk checks the state of complexity calculator at the beginning of each node as the tree evaluator does.
+func (m *vm) walk(op byte, c rideType) error {
+	if err := m.env.complexityCalculator().error(); err != nil {
+		return wrapComplexityError(err, "failed to walk node '%s'", nodeTypeName(op, c))
+	}
+	return nil
+}
+
+func (m *vm) conditional() error {
+	if err := m.walk(OpCond, nil); err != nil {
+		return err
+	}
+	if err := m.env.complexityCalculator().testConditionalComplexity(); err != nil {
+		return wrapComplexityError(err, "failed to test conditional complexity")
+	}
+	m.frames = append(m.frames, frame{kind: conditionalFrame, height: len(m.stack)})
+	return nil
+}
+
+func (m *vm) reference(id string, global int) error {
+	if err := m.walk(OpRef, nil); err != nil {
+		return err
+	}
+	cc := m.env.complexityCalculator()
+	if err := cc.testReferenceComplexity(); err != nil {
+		return wrapComplexityError(err, "failed to test reference complexity")
+	}
+	v, ok, f, p := m.value(id)
+	if !ok {
+		defer m.env.complexityCalculator().addReferenceComplexity()
+		if c, ok := m.global(id, global); ok {
+			m.push(c)
+			return nil
+		}
+		return RuntimeError.Errorf("value '%s' not found", id)
+	}
+	if v.value != nil {
+		m.push(v.value)
+		cc.addReferenceComplexity()
+		return nil
+	}
+	// Evaluate the expression of lazy value in current scope, the result is stored in scope on return.
+	m.frames = append(m.frames, frame{kind: referenceFrame, name: id, height: len(m.stack), back: m.ip, frame: f, pos: p})
+	m.ip = v.entry
+	return nil
+}
+
+func (m *vm) property(name string) error {
+	if err := m.walk(OpProperty, nil); err != nil {
+		return err
+	}
+	if err := m.env.complexityCalculator().testPropertyComplexity(); err != nil {
+		return wrapComplexityError(err, "failed to test property complexity")
+	}
+	m.frames = append(m.frames, frame{kind: propertyFrame, name: name, height: len(m.stack)})
+	return nil
+}
+
+func (m *vm) propertyEnd() error {
+	obj := m.pop()
+	fr := m.popFrame()
+	v, err := obj.get(fr.name)
+	m.env.complexityCalculator().addPropertyComplexity()
+	if err != nil {
+		return EvaluationErrorPushf(err, "failed to get property '%s'", fr.name)
+	}
+	m.push(v)
+	return nil
+}
+
+func (m *vm) nativeCall(name string, argc int) error {
+	f, ok := m.system(name)
+	if !ok {
+		return EvaluationFailure.Errorf("failed to find system function '%s'", name)
+	}
+	cost, ok := m.costs[name]
+	if !ok {
+		return EvaluationFailure.Errorf("failed to get cost of system function '%s'", name)
+	}
+	m.frames = append(m.frames, frame{kind: nativeCallFrame, name: name, height: len(m.stack), fn: f, cost: cost, argc: argc})
+	return nil
+}
+
+func (m *vm) callEnd() error {
+	fr := m.popFrame()
+	args := make([]rideType, fr.argc) // Arguments are copied because functions may keep them in results
+	copy(args, m.stack[fr.height:])
+	m.stack = m.stack[:fr.height]
+	cc := m.env.complexityCalculator()
+	if fr.kind == nativeCallFrame {
+		if err := cc.testNativeFunctionComplexity(fr.name, fr.cost); err != nil {
+			return wrapComplexityError(err, "failed to test complexity of system function")
+		}
+		r, err := fr.fn(m.env, args...)
+		m.env.complexityCalculator().addNativeFunctionComplexity(fr.name, fr.cost)
+		if err != nil {
+			return EvaluationErrorPushf(err, "failed to call system function '%s'", fr.name)
+		}
+		m.push(r)
+		return nil
+	}
+	fr.complexity = cc.complexity()
+	uf, sp, found := m.userFunction(fr.name)
+	if !found {
+		cc.addAdditionalUserFunctionComplexity(fr.name, fr.complexity)
+		return RuntimeError.Errorf("user function '%s' not found", fr.name)
+	}
+	if len(args) != len(uf.arguments) {
+		cc.addAdditionalUserFunctionComplexity(fr.name, fr.complexity)
+		return RuntimeError.Errorf("mismatched arguments number of user function '%s'", fr.name)
+	}
+	avs := make([]vmValue, len(args))
+	for i, arg := range args {
+		avs[i] = vmValue{id: uf.arguments[i], value: arg}
+	}
+	m.values = append(m.values, avs)
+	fr.done = true
+	fr.cl, m.cl = m.cl, sp
+	fr.back = m.ip
+	m.frames = append(m.frames, fr)
+	m.ip = uf.entryPoint
+	return nil
+}
+
+func (m *vm) ret() error {
+	r := m.pop()
+	fr := m.popFrame()
+	switch fr.kind {
+	case referenceFrame:
+		m.updateValue(fr.frame, fr.pos, fr.name, r)
+		m.env.complexityCalculator().addReferenceComplexity()
+	case userCallFrame:
+		m.values = m.values[:len(m.values)-1]
+		m.cl = fr.cl
+		cc := m.env.complexityCalculator()
+		err := cc.testAdditionalUserFunctionComplexity(fr.name, fr.complexity)
+		m.env.complexityCalculator().addAdditionalUserFunctionComplexity(fr.name, fr.complexity)
+		if err != nil {
+			return wrapComplexityError(err, "failed to test complexity of user function")
+		}
+	default:
+		return EvaluationFailure.Errorf("unexpected return from frame of kind %d", fr.kind)
+	}
+	m.push(r)
+	m.ip = fr.back
+	return nil
+}
+
+// unwind completes the nodes under evaluation in reverse order after a failure,
+// adding their complexities and extending the call stack of the error in the same way the tree evaluator does.
+func (m *vm) unwind(err error) error {
+	h := len(m.stack)
+	for i := len(m.frames) - 1; i >= 0; i-- {
+		fr := m.frames[i]
+		switch fr.kind {
+		case conditionalFrame:
+			if !fr.done {
+				err = EvaluationErrorPushf(err, "failed to estimate the condition of if")
 			}
-			l := len(m.calls)
-			if l == 0 {
-				return nil, errors.New("failed to load argument on stack")
-			}
-			frame := m.calls[l-1]
-			if l := len(frame.args); l < n+1 {
-				return nil, errors.New("invalid arguments count")
-			}
-			m.push(frame.args[n])
-		case OpReturn:
-			l := len(m.calls)
-			var f frame
-			f, m.calls = m.calls[l-1], m.calls[:l-1]
-			m.ip = f.back
-		case OpHalt:
-			if len(m.stack) > 0 {
-				v, err := m.pop()
-				if err != nil {
-					return nil, errors.Wrap(err, "failed to get result value")
-				}
-				switch tv := v.(type) {
-				case rideBoolean:
-					return ScriptResult{res: bool(tv)}, nil
-				default:
-					return nil, errors.Errorf("unexpected result value '%v' of type '%T'", v, v)
-				}
+			m.env.complexityCalculator().addConditionalComplexity()
+		case letFrame:
+			err = EvaluationErrorPushf(err, "failed to evaluate block after declaration of variable '%s'", fr.name)
+		case functionFrame:
+			err = EvaluationErrorPushf(err, "failed to evaluate block after declaration of function '%s'", fr.name)
+		case referenceFrame:
+			err = EvaluationErrorPushf(err, "failed to evaluate expression of scope value '%s'", fr.name)
+			m.env.complexityCalculator().addReferenceComplexity()
+		case propertyFrame:
+			err = EvaluationErrorPushf(err, "failed to evaluate an object to get property '%s' on it", fr.name)
+			m.env.complexityCalculator().addPropertyComplexity()
+		case nativeCallFrame:
+			err = EvaluationErrorPushf(err, "failed to materialize argument %d", h-fr.height+1)
+			err = EvaluationErrorPushf(err, "failed to call system function '%s'", fr.name)
+		case userCallFrame:
+			if !fr.done {
+				err = EvaluationErrorPushf(err, "failed to materialize argument %d", h-fr.height+1)
+				err = EvaluationErrorPushf(err, "failed to evaluate function '%s' body", fr.name)
+				break
 			}
-			return nil, errors.New("no result after script execution")
-		case OpGlobal:
-			id := m.arg16()
-			constructor := m.globals(id)
-			v := constructor(m.env)
-			m.push(v)
-		default:
-			return nil, errors.Errorf("unknown code %#x", op)
+			err = EvaluationErrorPushf(err, "failed to evaluate function '%s' body", fr.name)
+			m.env.complexityCalculator().addAdditionalUserFunctionComplexity(fr.name, fr.complexity)
+		}
+		h = fr.height
+	}
+	m.frames = m.frames[:0]
+	m.stack = m.stack[:0]
+	return err
+}
+
+// global returns the value of the invocation parameter or the global constant, global is the constant ID increased by 1.
+func (m *vm) global(id string, global int) (rideType, bool) {
+	if m.param != "" && id == m.param {
+		if m.paramV == nil {
+			m.paramV = m.paramC(m.env)
 		}
+		return m.paramV, true
+	}
+	if global == 0 {
+		return nil, false
 	}
-	return nil, errors.New("broken code")
+	i := global - 1
+	if m.globals[i] == nil {
+		m.globals[i] = m.provider(i)(m.env)
+	}
+	return m.globals[i], true
 }
 
 func (m *vm) push(v rideType) {
 	m.stack = append(m.stack, v)
 }
 
-func (m *vm) pop() (rideType, error) {
-	if len(m.stack) == 0 {
-		return nil, errors.New("empty callStack")
-	}
-	value := m.stack[len(m.stack)-1]
+func (m *vm) pop() rideType {
+	v := m.stack[len(m.stack)-1]
 	m.stack = m.stack[:len(m.stack)-1]
-	return value, nil
+	return v
 }
 
-func (m *vm) current() rideType {
-	return m.stack[len(m.stack)-1]
+func (m *vm) popFrame() frame {
+	fr := m.frames[len(m.frames)-1]
+	m.frames = m.frames[:len(m.frames)-1]
+	return fr
 }
 
 func (m *vm) arg16() int {
-	//TODO: add check
 	res := binary.BigEndian.Uint16(m.code[m.ip : m.ip+2])
 	m.ip += 2
 	return int(res)
 }
 
+func (m *vm) address() int {
+	res := binary.BigEndian.Uint32(m.code[m.ip : m.ip+4])
+	m.ip += 4
+	return int(res)
+}
+
 func (m *vm) constant() rideType {
-	//TODO: add check
 	return m.constants[m.arg16()]
 }
 
-//func (m *vm) scope() (*frame, int) {
-//	n := len(m.calls) - 1
-//	if n < 0 {
-//		return nil, n
-//	}
-//	return &m.calls[n], n
-//}
-
-//func (m *vm) resolve(name string) (int, error) {
-//	_ = name
-//	//TODO: implement
-//	return 0, errors.New("not implemented")
-//}
-
-//func (m *vm) returnPosition() int {
-//	if l := len(m.calls); l > 0 {
-//		return m.calls[l-1].back
-//	}
-//	return len(m.code)
-//}
+func (m *vm) name() string {
+	return string(m.constant().(rideString))
+}
+
+func (m *vm) pushExpression(id string, entry int) {
+	m.values[len(m.values)-1] = append(m.values[len(m.values)-1], vmValue{id: id, entry: entry})
+}
+
+func (m *vm) pushValue(id string, v rideType) {
+	m.values[len(m.values)-1] = append(m.values[len(m.values)-1], vmValue{id: id, value: v})
+}
+
+func (m *vm) updateValue(frame, pos int, id string, v rideType) {
+	if ev := m.values[frame][pos]; ev.id == id && ev.value == nil {
+		m.values[frame][pos] = vmValue{id: id, value: v}
+	}
+}
+
+func (m *vm) popValue() {
+	m.values[len(m.values)-1] = m.values[len(m.values)-1][:len(m.values[len(m.values)-1])-1]
+}
+
+func lookupVMValue(s []vmValue, id string) (vmValue, bool, int) {
+	for i := len(s) - 1; i >= 0; i-- {
+		if v := s[i]; v.id == id {
+			return v, true, i
+		}
+	}
+	return vmValue{}, false, 0
+}
+
+func (m *vm) value(id string) (vmValue, bool, int, int) {
+	if i := len(m.values) - 1; i >= 0 {
+		v, ok, p := lookupVMValue(m.values[i], id)
+		if ok {
+			return v, true, i, p
+		}
+	}
+	for i := m.cl - 1; i >= 0; i-- {
+		v, ok, p := lookupVMValue(m.values[i], id)
+		if ok {
+			return v, true, i, p
+		}
+	}
+	return vmValue{}, false, 0, 0
+}
+
+func (m *vm) pushUserFunction(fn *function) {
+	m.user = append(m.user, vmFunction{fn: fn, sp: len(m.values)})
+}
+
+func (m *vm) popUserFunction() {
+	m.user = m.user[:len(m.user)-1]
+}
+
+func (m *vm) userFunction(id string) (*function, int, bool) {
+	for i := len(m.user) - 1; i >= 0; i-- {
+		uf := m.user[i]
+		if uf.fn.name == id {
+			return uf.fn, uf.sp, true
+		}
+	}
+	return nil, 0, false
+}
+
+func wrapComplexityError(err error, format string, args ...interface{}) error {
+	eet := Undefined
+	if ccErr := complexityCalculatorError(nil); errors.As(err, &ccErr) {
+		eet = ccErr.EvaluationErrorWrapType()
+	}
+	return eet.Wrapf(err, format, args...)
+}
+
+// nodeTypeName returns the type of the tree node that is compiled into the given operation.
+func nodeTypeName(op byte, c rideType) string {
+	switch op {
+	case OpPush:
+		switch c.(type) {
+		case rideInt:
+			return "*ast.LongNode"
+		case rideByteVector:
+			return "*ast.BytesNode"
+		default:
+			return "*ast.StringNode"
+		}
+	case OpTrue, OpFalse:
+		return "*ast.BooleanNode"
+	case OpCond:
+		return "*ast.ConditionalNode"
+	case OpLet:
+		return "*ast.AssignmentNode"
+	case OpRef:
+		return "*ast.ReferenceNode"
+	case OpFunc:
+		return "*ast.FunctionDeclarationNode"
+	case OpCall, OpExternalCall:
+		return "*ast.FunctionCallNode"
+	case OpProperty:
+		return "*ast.PropertyNode"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,99 @@
+package ride
+
+import (
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// RunVerifier executes the compiled verifier, it's the counterpart of CallVerifier for the Ride VM.
+func RunVerifier(env environment, script RideScript) (Result, error) {
+	m, entryPoint, err := verifierVM(env, script)
+	if err != nil {
+		return nil, RuntimeError.Wrap(err, "failed to call verifier")
+	}
+	defer m.release()
+	r, err := m.run(entryPoint)
+	if err != nil {
+		return nil, err
+	}
+	return evaluationResult(env, r)
+}
+
+// RunFunction executes the compiled callable function of DApp, it's the counterpart of CallFunction for the Ride VM.
+func RunFunction(env environment, script RideScript, fc proto.FunctionCall) (Result, error) {
+	var (
+		name = fc.Name()
+		args = fc.Arguments()
+	)
+	arguments, err := convertProtoArguments(args)
+	if err != nil {
+		return nil, EvaluationFailure.Wrapf(err, "failed to call function '%s'", name)
+	}
+	m, entryPoint, err := functionVM(env, script, name, arguments)
+	if err != nil {
+		return nil, EvaluationFailure.Wrapf(err, "failed to call function '%s'", name)
+	}
+	defer m.release()
+	r, err := m.run(entryPoint)
+	var rideResult Result
+	if err == nil {
+		rideResult, err = evaluationResult(env, r)
+	}
+	return functionCallResult(env, script.LibraryVersion(), name, rideResult, err)
+}
+
+func verifierVM(env environment, script RideScript) (*vm, int, error) {
+	switch s := script.(type) {
+	case *SimpleScript:
+		// Invocation is disabled for expression calls
+		m, err := newVM(env, s.LibVersion, s.Code, s.Constants, s.Functions, false)
+		if err != nil {
+			return nil, 0, EvaluationFailure.Wrap(err, "failed to create scope")
+		}
+		return m, s.EntryPoint, nil
+	case *DAppScript:
+		m, err := newVM(env, s.LibVersion, s.Code, s.Constants, s.Functions, false)
+		if err != nil {
+			return nil, 0, EvaluationFailure.Wrap(err, "failed to create scope")
+		}
+		verifier, ok := s.EntryPoints[""]
+		if !ok {
+			m.release()
+			return nil, 0, EvaluationFailure.New("no verifier declaration")
+		}
+		for _, d := range s.Declarations {
+			m.declare(d)
+		}
+		m.setParameter(verifier.parameterName, newTx)
+		return m, verifier.entryPoint, nil
+	default:
+		return nil, 0, EvaluationFailure.Errorf("unsupported script type '%T'", script)
+	}
+}
+
+func functionVM(env environment, script RideScript, name string, args []rideType) (*vm, int, error) {
+	s, ok := script.(*DAppScript)
+	if !ok {
+		return nil, 0, EvaluationFailure.Errorf("unable to call function '%s' on simple script", name)
+	}
+	m, err := newVM(env, s.LibVersion, s.Code, s.Constants, s.Functions, true)
+	if err != nil {
+		return nil, 0, EvaluationFailure.Wrap(err, "failed to create scope")
+	}
+	for _, d := range s.Declarations {
+		m.declare(d)
+	}
+	fn, ok := s.EntryPoints[name]
+	if !ok || name == "" { // Verifier can't be called as a function
+		m.release()
+		return nil, 0, EvaluationFailure.Errorf("function '%s' not found", name)
+	}
+	m.setParameter(fn.parameterName, newInvocation)
+	if l := len(args); l != len(fn.arguments) {
+		m.release()
+		return nil, 0, EvaluationFailure.Errorf("invalid arguments count %d for function '%s'", l, name)
+	}
+	for i, arg := range args {
+		m.pushValue(fn.arguments[i], arg)
+	}
+	return m, fn.entryPoint, nil
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"github.com/wavesplatform/gowaves/pkg/node/blockchain_updates"
 	"github.com/wavesplatform/gowaves/pkg/node/messages"
 	"github.com/wavesplatform/gowaves/pkg/node/peers"
 	"github.com/wavesplatform/gowaves/pkg/proto"
@@ -57,4 +58,6 @@ type Services struct {
 	InternalChannel chan messages.InternalMessage
 	MinPeersMining  int
 	SkipMessageList *messages.SkipMessageList
+	// BlockchainUpdates is nil if blockchain updates are not published.
+	BlockchainUpdates *blockchain_updates.Publisher
 }
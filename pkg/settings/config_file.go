@@ -0,0 +1,115 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat is the format of node configuration file.
+type ConfigFormat byte
+
+const (
+	ConfigFormatYAML ConfigFormat = iota
+	ConfigFormatHOCON
+)
+
+// ConfigFormatFromPath detects the format of configuration file by its extension: '.yaml' or '.yml' for YAML,
+// '.conf' or '.hocon' for Scala node configuration in HOCON.
+func ConfigFormatFromPath(path string) (ConfigFormat, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		return ConfigFormatYAML, nil
+	case ".conf", ".hocon":
+		return ConfigFormatHOCON, nil
+	default:
+		return 0, errors.Errorf("unsupported configuration file extension %q", ext)
+	}
+}
+
+// ConfigOptions are the options of the node from configuration file. Options are named as the command line flags
+// of the node, values are in the format of the flags, lists are joined with commas.
+type ConfigOptions map[string]string
+
+// ReadConfigFile reads the configuration file, the format of the file is detected by extension.
+func ReadConfigFile(path string) (ConfigOptions, error) {
+	format, err := ConfigFormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path) // #nosec: in this case check for prevent G304 (CWE-22) is not necessary
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read configuration file")
+	}
+	opts, err := ParseConfig(format, data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse configuration file '%s'", path)
+	}
+	return opts, nil
+}
+
+// ParseConfig parses the configuration. YAML configuration is a flat list of options named as
+// the command line flags of the node. Only the options of Scala node configuration which have counterparts
+// in the Go node are read from HOCON configuration, other options are ignored.
+func ParseConfig(format ConfigFormat, data []byte) (ConfigOptions, error) {
+	switch format {
+	case ConfigFormatYAML:
+		return parseYAML(data)
+	case ConfigFormatHOCON:
+		values, err := parseHOCON(data)
+		if err != nil {
+			return nil, err
+		}
+		return scalaOptions(values)
+	default:
+		return nil, errors.Errorf("unsupported configuration format %d", format)
+	}
+}
+
+func parseYAML(data []byte) (ConfigOptions, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	opts := make(ConfigOptions)
+	if len(doc.Content) == 0 { // Empty document.
+		return opts, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.Errorf("line %d: mapping of options expected", root.Line)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		k, v := root.Content[i], root.Content[i+1]
+		if _, ok := opts[k.Value]; ok {
+			return nil, errors.Errorf("line %d: duplicate option '%s'", k.Line, k.Value)
+		}
+		switch v.Kind {
+		case yaml.ScalarNode:
+			opts[k.Value] = yamlScalar(v)
+		case yaml.SequenceNode:
+			items := make([]string, 0, len(v.Content))
+			for _, item := range v.Content {
+				if item.Kind != yaml.ScalarNode {
+					return nil, errors.Errorf("line %d: option '%s' should be a list of simple values",
+						item.Line, k.Value)
+				}
+				items = append(items, yamlScalar(item))
+			}
+			opts[k.Value] = strings.Join(items, ",")
+		default:
+			return nil, errors.Errorf("line %d: option '%s' should be a simple value or a list", v.Line, k.Value)
+		}
+	}
+	return opts, nil
+}
+
+func yamlScalar(n *yaml.Node) string {
+	if n.Tag == "!!null" {
+		return ""
+	}
+	return n.Value
+}
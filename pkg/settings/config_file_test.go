@@ -0,0 +1,161 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigYAML(t *testing.T) {
+	data := `
+# Node configuration
+state-path: /var/lib/gowaves
+blockchain-type: testnet
+limit-connections: 40
+enable-grpc-api: true
+blacklist-residence-time: 10m
+peers:
+  - 10.0.0.1:6863
+  - 10.0.0.2:6863
+api-key:
+`
+	opts, err := ParseConfig(ConfigFormatYAML, []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, ConfigOptions{
+		"state-path":               "/var/lib/gowaves",
+		"blockchain-type":          "testnet",
+		"limit-connections":        "40",
+		"enable-grpc-api":          "true",
+		"blacklist-residence-time": "10m",
+		"peers":                    "10.0.0.1:6863,10.0.0.2:6863",
+		"api-key":                  "",
+	}, opts)
+
+	opts, err = ParseConfig(ConfigFormatYAML, nil)
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+
+	for _, invalid := range []string{
+		"- state-path",
+		"network:\n  peers: 10.0.0.1:6863",
+		"peers:\n  - [10.0.0.1:6863]",
+		"name: a\nname: b",
+	} {
+		_, err = ParseConfig(ConfigFormatYAML, []byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseConfigHOCON(t *testing.T) {
+	t.Setenv("TEST_NODE_NAME", "env-node")
+	data := `
+# Scala node configuration
+waves {
+  directory = "/var/lib/waves"
+  blockchain.type = TESTNET
+  network {
+    node-name = ${TEST_NODE_NAME}
+    declared-address = "1.2.3.4:6863"
+    port = 6863
+    known-peers = ["10.0.0.1:6863", "10.0.0.2:6863"]
+    black-list-residence-time = 15 minutes
+    max-inbound-connections = 30
+    max-outbound-connections: 20
+  }
+  rest-api {
+    enable = yes
+    bind-address = "127.0.0.1"
+    port = 6869
+    api-key-hash = ${?UNDEFINED_API_KEY_HASH}
+  }
+  // Miner settings
+  miner {
+    enable = no
+    quorum = 2
+    micro-block-interval = 3s
+  }
+  features.supported = [15, 16]
+  rewards.desired = 600000000
+  wallet.file = ${waves.directory}"/wallet.dat"
+}
+waves.grpc.port = 6870
+`
+	opts, err := ParseConfig(ConfigFormatHOCON, []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, ConfigOptions{
+		"blockchain-type":          "testnet",
+		"name":                     "env-node",
+		"declared-address":         "1.2.3.4:6863",
+		"bind-address":             "0.0.0.0:6863",
+		"peers":                    "10.0.0.1:6863,10.0.0.2:6863",
+		"blacklist-residence-time": "15m0s",
+		"limit-connections":        "50",
+		"api-address":              "127.0.0.1:6869",
+		"disable-miner":            "true",
+		"min-peers-mining":         "2",
+		"microblock-interval":      "3s",
+		"vote":                     "15,16",
+		"reward":                   "600000000",
+		"grpc-address":             "0.0.0.0:6870",
+		"enable-grpc-api":          "true",
+	}, opts)
+
+	values, err := parseHOCON([]byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, "/var/lib/waves/wallet.dat", values["waves.wallet.file"].String())
+
+	for _, invalid := range []string{
+		`include "other.conf"`,
+		"waves { network { port = 6863 }",
+		"waves.network.port 6863",
+		"waves.network.node-name = ${UNDEFINED_NODE_NAME}",
+		"waves.features.supported += 15",
+		"waves.miner.enable = maybe",
+		"waves.network.black-list-residence-time = 5 weeks",
+	} {
+		_, err = ParseConfig(ConfigFormatHOCON, []byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestHOCONRootBraces(t *testing.T) {
+	opts, err := ParseConfig(ConfigFormatHOCON, []byte(`{ "waves": { "network": { "node-name": "node" } } }`))
+	require.NoError(t, err)
+	assert.Equal(t, ConfigOptions{"name": "node"}, opts)
+}
+
+func TestHOCONDuration(t *testing.T) {
+	for _, test := range []struct {
+		value    string
+		expected string
+	}{
+		{"500", "500ms"},
+		{"500ms", "500ms"},
+		{"30 seconds", "30s"},
+		{"1.5h", "1h30m0s"},
+		{"2d", "48h0m0s"},
+	} {
+		d, err := hoconDuration(test.value)
+		require.NoError(t, err, test.value)
+		assert.Equal(t, test.expected, d)
+	}
+	_, err := hoconDuration("minutes")
+	assert.Error(t, err)
+}
+
+func TestReadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "node.yml")
+	require.NoError(t, os.WriteFile(path, []byte("name: node"), 0600))
+	opts, err := ReadConfigFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, ConfigOptions{"name": "node"}, opts)
+
+	_, err = ReadConfigFile(filepath.Join(dir, "node.json"))
+	assert.ErrorContains(t, err, "unsupported configuration file extension")
+	_, err = ReadConfigFile(filepath.Join(dir, "absent.yml"))
+	assert.Error(t, err)
+}
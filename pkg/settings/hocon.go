@@ -0,0 +1,315 @@
+package settings
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// hoconValue is the simple value or the list of simple values.
+type hoconValue []string
+
+func (v hoconValue) String() string {
+	return strings.Join(v, ",")
+}
+
+// hoconParser parses the subset of HOCON format used in Scala node configuration files: nested objects,
+// dotted keys, quoted and unquoted strings, arrays of simple values, comments and substitutions of previously
+// defined values or environment variables. Includes, arrays of objects and '+=' are not supported.
+// The values are collected by full paths, for example 'waves.network.port'.
+type hoconParser struct {
+	s      []rune
+	pos    int
+	line   int
+	values map[string]hoconValue
+}
+
+func parseHOCON(data []byte) (map[string]hoconValue, error) {
+	p := &hoconParser{s: []rune(string(data)), line: 1, values: make(map[string]hoconValue)}
+	if err := p.parseRoot(); err != nil {
+		return nil, errors.Wrapf(err, "line %d", p.line)
+	}
+	return p.values, nil
+}
+
+func (p *hoconParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *hoconParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *hoconParser) next() rune {
+	r := p.s[p.pos]
+	p.pos++
+	if r == '\n' {
+		p.line++
+	}
+	return r
+}
+
+func (p *hoconParser) hasPrefix(prefix string) bool {
+	i := p.pos
+	for _, r := range prefix {
+		if i >= len(p.s) || p.s[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func (p *hoconParser) isCommentStart() bool {
+	return p.peek() == '#' || p.hasPrefix("//")
+}
+
+func (p *hoconParser) skipComment() {
+	for !p.eof() && p.peek() != '\n' {
+		p.next()
+	}
+}
+
+// skipInlineSpace skips whitespaces except new lines.
+func (p *hoconParser) skipInlineSpace() {
+	for !p.eof() && p.peek() != '\n' && unicode.IsSpace(p.peek()) {
+		p.next()
+	}
+}
+
+// skipSeparators skips whitespaces, new lines, comments and commas between fields or array elements.
+func (p *hoconParser) skipSeparators() {
+	for !p.eof() {
+		switch {
+		case unicode.IsSpace(p.peek()) || p.peek() == ',':
+			p.next()
+		case p.isCommentStart():
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *hoconParser) parseRoot() error {
+	p.skipSeparators()
+	if p.peek() == '{' {
+		p.next()
+		if err := p.parseObject("", true); err != nil {
+			return err
+		}
+		p.skipSeparators()
+		if !p.eof() {
+			return errors.Errorf("unexpected %q after root object", p.peek())
+		}
+		return nil
+	}
+	return p.parseObject("", false)
+}
+
+func (p *hoconParser) parseObject(prefix string, braces bool) error {
+	for {
+		p.skipSeparators()
+		if p.eof() {
+			if braces {
+				return errors.New("unclosed object")
+			}
+			return nil
+		}
+		if p.peek() == '}' {
+			if !braces {
+				return errors.New("unexpected '}'")
+			}
+			p.next()
+			return nil
+		}
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+		if key == "include" {
+			p.skipInlineSpace()
+			if p.peek() == '"' || p.hasPrefix("file(") || p.hasPrefix("classpath(") || p.hasPrefix("url(") {
+				return errors.New("includes are not supported")
+			}
+		}
+		path := joinHOCONPath(prefix, key)
+		p.skipInlineSpace()
+		switch {
+		case p.peek() == '{':
+			p.next()
+			if err = p.parseObject(path, true); err != nil {
+				return err
+			}
+			continue
+		case p.hasPrefix("+="):
+			return errors.Errorf("'+=' is not supported, key '%s'", path)
+		case p.peek() == ':' || p.peek() == '=':
+			p.next()
+		default:
+			return errors.Errorf("':' or '=' expected after key '%s'", path)
+		}
+		p.skipInlineSpace()
+		switch p.peek() {
+		case '{':
+			p.next()
+			err = p.parseObject(path, true)
+		case '[':
+			p.next()
+			err = p.parseArray(path)
+		default:
+			var v string
+			if v, err = p.parseScalar(); err == nil {
+				p.values[path] = hoconValue{v}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// parseKey parses the path expression of the key, dots of quoted parts are not escaped.
+func (p *hoconParser) parseKey() (string, error) {
+	var sb strings.Builder
+	for !p.eof() {
+		r := p.peek()
+		switch {
+		case r == '"':
+			s, err := p.parseQuoted()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(s)
+		case unicode.IsSpace(r) || strings.ContainsRune(":={}[],#+\"", r) || p.hasPrefix("//"):
+			if sb.Len() == 0 {
+				return "", errors.Errorf("key expected, got %q", r)
+			}
+			return sb.String(), nil
+		default:
+			sb.WriteRune(p.next())
+		}
+	}
+	if sb.Len() == 0 {
+		return "", errors.New("key expected")
+	}
+	return sb.String(), nil
+}
+
+func (p *hoconParser) parseArray(path string) error {
+	items := make(hoconValue, 0)
+	for {
+		p.skipSeparators()
+		if p.eof() {
+			return errors.Errorf("unclosed array '%s'", path)
+		}
+		switch p.peek() {
+		case ']':
+			p.next()
+			p.values[path] = items
+			return nil
+		case '{', '[':
+			return errors.Errorf("only arrays of simple values are supported, key '%s'", path)
+		}
+		v, err := p.parseScalar()
+		if err != nil {
+			return err
+		}
+		items = append(items, v)
+	}
+}
+
+// parseScalar parses the concatenation of quoted and unquoted strings and substitutions till the end of line,
+// comma or closing bracket. Whitespaces between the parts are kept.
+func (p *hoconParser) parseScalar() (string, error) {
+	var sb strings.Builder
+	for !p.eof() {
+		r := p.peek()
+		switch {
+		case r == '\n' || r == ',' || r == '}' || r == ']' || p.isCommentStart():
+			return strings.TrimSpace(sb.String()), nil
+		case r == '"':
+			s, err := p.parseQuoted()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(s)
+		case p.hasPrefix("${"):
+			s, err := p.parseSubstitution()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(s)
+		case r == '{' || r == '[':
+			return "", errors.Errorf("unexpected %q in value", r)
+		default:
+			sb.WriteRune(p.next())
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func (p *hoconParser) parseQuoted() (string, error) {
+	if p.hasPrefix(`"""`) {
+		return "", errors.New("multiline strings are not supported")
+	}
+	start := p.pos
+	p.next()
+	for !p.eof() && p.peek() != '"' && p.peek() != '\n' {
+		if p.next() == '\\' && !p.eof() {
+			p.next()
+		}
+	}
+	if p.peek() != '"' {
+		return "", errors.New("unclosed string")
+	}
+	p.next()
+	s, err := strconv.Unquote(string(p.s[start:p.pos]))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid string")
+	}
+	return s, nil
+}
+
+// parseSubstitution resolves the substitution with the previously defined value or the environment variable.
+// Optional substitution of undefined value is resolved to an empty string.
+func (p *hoconParser) parseSubstitution() (string, error) {
+	p.pos += 2
+	optional := false
+	if p.peek() == '?' {
+		optional = true
+		p.next()
+	}
+	var sb strings.Builder
+	for !p.eof() && p.peek() != '}' && p.peek() != '\n' {
+		sb.WriteRune(p.next())
+	}
+	if p.peek() != '}' {
+		return "", errors.New("unclosed substitution")
+	}
+	p.next()
+	path := strings.TrimSpace(sb.String())
+	if v, ok := p.values[path]; ok {
+		return v.String(), nil
+	}
+	if v, ok := os.LookupEnv(path); ok {
+		return v, nil
+	}
+	if optional {
+		return "", nil
+	}
+	return "", errors.Errorf("unresolved substitution '%s'", path)
+}
+
+func joinHOCONPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
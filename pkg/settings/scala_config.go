@@ -0,0 +1,160 @@
+package settings
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultScalaBindAddress = "0.0.0.0"
+
+// scalaOption maps the option of Scala node configuration to the option of Go node.
+type scalaOption struct {
+	path    string
+	name    string
+	convert func(string) (string, error)
+}
+
+var scalaSimpleOptions = []scalaOption{
+	{path: "waves.blockchain.type", name: "blockchain-type", convert: lowerCase},
+	{path: "waves.network.node-name", name: "name"},
+	{path: "waves.network.declared-address", name: "declared-address"},
+	{path: "waves.network.known-peers", name: "peers"},
+	{path: "waves.network.black-list-residence-time", name: "blacklist-residence-time", convert: hoconDuration},
+	{path: "waves.miner.quorum", name: "min-peers-mining"},
+	{path: "waves.miner.micro-block-interval", name: "microblock-interval", convert: hoconDuration},
+	{path: "waves.features.supported", name: "vote"},
+	{path: "waves.rewards.desired", name: "reward"},
+}
+
+// scalaOptions selects the options of Scala node configuration which have counterparts in Go node.
+func scalaOptions(values map[string]hoconValue) (ConfigOptions, error) {
+	opts := make(ConfigOptions)
+	for _, o := range scalaSimpleOptions {
+		v, ok := values[o.path]
+		if !ok {
+			continue
+		}
+		s := v.String()
+		if o.convert != nil {
+			var err error
+			if s, err = o.convert(s); err != nil {
+				return nil, errors.Wrapf(err, "invalid value of '%s'", o.path)
+			}
+		}
+		opts[o.name] = s
+	}
+	if addr, ok := scalaAddress(values, "waves.network.bind-address", "waves.network.port"); ok {
+		opts["bind-address"] = addr
+	}
+	restAPI, err := scalaEnabled(values, "waves.rest-api.enable")
+	if err != nil {
+		return nil, err
+	}
+	if addr, ok := scalaAddress(values, "waves.rest-api.bind-address", "waves.rest-api.port"); ok && restAPI {
+		opts["api-address"] = addr
+	}
+	if addr, ok := scalaAddress(values, "waves.grpc.host", "waves.grpc.port"); ok {
+		opts["grpc-address"] = addr
+		opts["enable-grpc-api"] = "true"
+	}
+	miner, err := scalaEnabled(values, "waves.miner.enable")
+	if err != nil {
+		return nil, err
+	}
+	if !miner {
+		opts["disable-miner"] = "true"
+	}
+	in, inOK := values["waves.network.max-inbound-connections"]
+	out, outOK := values["waves.network.max-outbound-connections"]
+	if inOK && outOK {
+		total, sErr := sumInts(in.String(), out.String())
+		if sErr != nil {
+			return nil, errors.Wrap(sErr, "invalid maximum number of connections")
+		}
+		opts["limit-connections"] = total
+	}
+	return opts, nil
+}
+
+// scalaEnabled returns the value of boolean option, absent option is enabled.
+func scalaEnabled(values map[string]hoconValue, path string) (bool, error) {
+	v, ok := values[path]
+	if !ok {
+		return true, nil
+	}
+	switch v.String() {
+	case "true", "yes", "on":
+		return true, nil
+	case "false", "no", "off":
+		return false, nil
+	default:
+		return false, errors.Errorf("invalid boolean value %q of '%s'", v.String(), path)
+	}
+}
+
+// scalaAddress joins host and port, the default bind address is used if only the port is set.
+func scalaAddress(values map[string]hoconValue, hostPath, portPath string) (string, bool) {
+	port, ok := values[portPath]
+	if !ok {
+		return "", false
+	}
+	host := defaultScalaBindAddress
+	if h, found := values[hostPath]; found {
+		host = h.String()
+	}
+	return net.JoinHostPort(host, port.String()), true
+}
+
+func sumInts(a, b string) (string, error) {
+	x, err := strconv.Atoi(a)
+	if err != nil {
+		return "", err
+	}
+	y, err := strconv.Atoi(b)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(x + y), nil
+}
+
+func lowerCase(s string) (string, error) {
+	return strings.ToLower(s), nil
+}
+
+// hoconDuration converts HOCON duration, for example '5 minutes' or '500ms', to Go duration string.
+// A number without unit is the number of milliseconds.
+func hoconDuration(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return "", errors.Errorf("invalid duration %q", s)
+	}
+	var unit time.Duration
+	switch u := strings.TrimSpace(s[i:]); u {
+	case "ns", "nano", "nanos", "nanosecond", "nanoseconds":
+		unit = time.Nanosecond
+	case "us", "micro", "micros", "microsecond", "microseconds":
+		unit = time.Microsecond
+	case "", "ms", "milli", "millis", "millisecond", "milliseconds":
+		unit = time.Millisecond
+	case "s", "second", "seconds":
+		unit = time.Second
+	case "m", "minute", "minutes":
+		unit = time.Minute
+	case "h", "hour", "hours":
+		unit = time.Hour
+	case "d", "day", "days":
+		unit = 24 * time.Hour
+	default:
+		return "", errors.Errorf("invalid duration unit %q", u)
+	}
+	return time.Duration(n * float64(unit)).String(), nil
+}
@@ -0,0 +1,148 @@
+package signer
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/types"
+	"github.com/wavesplatform/gowaves/pkg/wallet"
+)
+
+var _ types.Signer = (*Remote)(nil)
+
+// Remote is the client of the remote signer. It keeps one connection to the signer and reconnects if
+// the connection is broken. Remote is safe for concurrent use, requests are serialized.
+type Remote struct {
+	network string
+	address string
+	token   []byte
+	timeout time.Duration
+
+	mu   sync.Mutex
+	sess *session
+}
+
+// NewRemote creates the client of the signer listening on the network address. Every request,
+// including connection establishment, is limited by the timeout.
+func NewRemote(network, address string, token []byte, timeout time.Duration) *Remote {
+	return &Remote{network: network, address: address, token: token, timeout: timeout}
+}
+
+// PublicKeys returns public keys of the accounts available in the signer.
+func (r *Remote) PublicKeys() ([]crypto.PublicKey, error) {
+	res, err := r.call([]byte{byte(opPublicKeys)})
+	if err != nil {
+		return nil, err
+	}
+	if len(res)%crypto.PublicKeySize != 0 {
+		return nil, errors.Errorf("invalid public keys response size %d", len(res))
+	}
+	pks := make([]crypto.PublicKey, len(res)/crypto.PublicKeySize)
+	for i := range pks {
+		copy(pks[i][:], res[i*crypto.PublicKeySize:])
+	}
+	return pks, nil
+}
+
+// Sign requests the signature of the data by the account.
+func (r *Remote) Sign(pk crypto.PublicKey, data []byte) (crypto.Signature, error) {
+	res, err := r.call(request(opSign, pk, data))
+	if err != nil {
+		return crypto.Signature{}, err
+	}
+	sig, err := crypto.NewSignatureFromBytes(res)
+	if err != nil {
+		return crypto.Signature{}, errors.Wrap(err, "invalid signature response")
+	}
+	return sig, nil
+}
+
+// SignVRF requests VRF proof of the message by the account.
+func (r *Remote) SignVRF(pk crypto.PublicKey, msg []byte) ([]byte, error) {
+	return r.call(request(opSignVRF, pk, msg))
+}
+
+// Close closes the connection to the signer.
+func (r *Remote) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sess == nil {
+		return nil
+	}
+	err := r.sess.close()
+	r.sess = nil
+	return err
+}
+
+func request(op operation, pk crypto.PublicKey, data []byte) []byte {
+	req := make([]byte, 0, 1+crypto.PublicKeySize+len(data))
+	req = append(req, byte(op))
+	req = append(req, pk.Bytes()...)
+	return append(req, data...)
+}
+
+// call sends the request and returns the response payload. The request is retried once on a new connection
+// if the existing one turns out to be broken.
+func (r *Remote) call(req []byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reused := r.sess != nil
+	res, err := r.roundTrip(req)
+	if err != nil && reused && !errors.Is(err, errAuthentication) {
+		res, err = r.roundTrip(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, errors.New("empty signer response")
+	}
+	switch status(res[0]) {
+	case statusOK:
+		return res[1:], nil
+	case statusUnknownPublicKey:
+		return nil, wallet.ErrPublicKeyNotFound
+	default:
+		return nil, errors.Errorf("signer error: %s", res[1:])
+	}
+}
+
+func (r *Remote) roundTrip(req []byte) ([]byte, error) {
+	if r.sess == nil {
+		conn, err := net.DialTimeout(r.network, r.address, r.timeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to connect to signer")
+		}
+		if dlErr := conn.SetDeadline(time.Now().Add(r.timeout)); dlErr != nil {
+			_ = conn.Close()
+			return nil, dlErr
+		}
+		sess, err := clientHandshake(conn, r.token)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		r.sess = sess
+	}
+	res, err := r.exchange(req)
+	if err != nil {
+		_ = r.sess.close()
+		r.sess = nil
+		return nil, errors.Wrap(err, "signer request failed")
+	}
+	return res, nil
+}
+
+func (r *Remote) exchange(req []byte) ([]byte, error) {
+	if err := r.sess.conn.SetDeadline(time.Now().Add(r.timeout)); err != nil {
+		return nil, err
+	}
+	if err := r.sess.write(req); err != nil {
+		return nil, err
+	}
+	return r.sess.read()
+}
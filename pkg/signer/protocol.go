@@ -0,0 +1,200 @@
+// Package signer implements the remote signer protocol, which allows to keep account secret keys
+// in a separate process or on a separate host.
+//
+// The protocol works over a stream connection (Unix socket or TCP). After connection the server sends
+// a random nonce and the client replies with its own random nonce. Both sides derive the session key
+// as HMAC-SHA256 of the nonces with the shared token. Every following frame is
+//
+//	length (4) | sequence number (8) | body | HMAC-SHA256 (32)
+//
+// where HMAC is calculated with the session key over the direction byte, the sequence number and the body.
+// Frames with invalid HMAC or unexpected sequence number break the connection. The protocol doesn't
+// encrypt the frames, only signatures and public keys are transferred, but TCP connections should be
+// used only inside trusted networks.
+package signer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	nonceSize    = 32
+	macSize      = sha256.Size
+	seqSize      = 8
+	maxFrameSize = 4 << 20
+	minTokenSize = 16
+
+	sessionLabel = "gowaves-signer-v1"
+)
+
+type direction byte
+
+const (
+	clientToServer direction = iota
+	serverToClient
+)
+
+type operation byte
+
+const (
+	opPublicKeys operation = iota + 1
+	opSign
+	opSignVRF
+)
+
+type status byte
+
+const (
+	statusOK status = iota
+	statusUnknownPublicKey
+	statusError
+)
+
+var errAuthentication = errors.New("signer frame authentication failed")
+
+// session is an authenticated connection between the signer client and server.
+type session struct {
+	conn    net.Conn
+	key     []byte
+	out, in direction
+	outSeq  uint64
+	inSeq   uint64
+}
+
+func newNonce() ([]byte, error) {
+	n := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand.Reader, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func sessionKey(token, serverNonce, clientNonce []byte) []byte {
+	m := hmac.New(sha256.New, token)
+	m.Write([]byte(sessionLabel))
+	m.Write(serverNonce)
+	m.Write(clientNonce)
+	return m.Sum(nil)
+}
+
+// clientHandshake performs the client side of the handshake.
+func clientHandshake(conn net.Conn, token []byte) (*session, error) {
+	serverNonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(conn, serverNonce); err != nil {
+		return nil, errors.Wrap(err, "failed to read server nonce")
+	}
+	clientNonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+	if _, wErr := conn.Write(clientNonce); wErr != nil {
+		return nil, errors.Wrap(wErr, "failed to write client nonce")
+	}
+	return &session{
+		conn: conn, key: sessionKey(token, serverNonce, clientNonce), out: clientToServer, in: serverToClient,
+	}, nil
+}
+
+// serverHandshake performs the server side of the handshake.
+func serverHandshake(conn net.Conn, token []byte) (*session, error) {
+	serverNonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+	if _, wErr := conn.Write(serverNonce); wErr != nil {
+		return nil, errors.Wrap(wErr, "failed to write server nonce")
+	}
+	clientNonce := make([]byte, nonceSize)
+	if _, rErr := io.ReadFull(conn, clientNonce); rErr != nil {
+		return nil, errors.Wrap(rErr, "failed to read client nonce")
+	}
+	return &session{
+		conn: conn, key: sessionKey(token, serverNonce, clientNonce), out: serverToClient, in: clientToServer,
+	}, nil
+}
+
+func (s *session) mac(d direction, seq []byte, body []byte) []byte {
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte{byte(d)})
+	m.Write(seq)
+	m.Write(body)
+	return m.Sum(nil)
+}
+
+func (s *session) write(body []byte) error {
+	size := seqSize + len(body) + macSize
+	if size > maxFrameSize {
+		return errors.Errorf("signer frame size %d exceeds limit", size)
+	}
+	frame := make([]byte, 4, 4+size)
+	binary.BigEndian.PutUint32(frame, uint32(size)) // #nosec: size is checked above
+	frame = binary.BigEndian.AppendUint64(frame, s.outSeq)
+	frame = append(frame, body...)
+	frame = append(frame, s.mac(s.out, frame[4:4+seqSize], body)...)
+	s.outSeq++
+	_, err := s.conn.Write(frame)
+	return err
+}
+
+func (s *session) read() ([]byte, error) {
+	var h [4]byte
+	if _, err := io.ReadFull(s.conn, h[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(h[:])
+	if size < seqSize+macSize || size > maxFrameSize {
+		return nil, errors.Errorf("invalid signer frame size %d", size)
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(s.conn, frame); err != nil {
+		return nil, err
+	}
+	seq, body, mac := frame[:seqSize], frame[seqSize:len(frame)-macSize], frame[len(frame)-macSize:]
+	if !hmac.Equal(mac, s.mac(s.in, seq, body)) || binary.BigEndian.Uint64(seq) != s.inSeq {
+		return nil, errAuthentication
+	}
+	s.inSeq++
+	return body, nil
+}
+
+func (s *session) close() error {
+	return s.conn.Close()
+}
+
+// ParseAddress parses the signer address in the form 'unix:///path/to/socket' or 'tcp://host:port'
+// and returns the network and the address for net.Dial and net.Listen.
+func ParseAddress(addr string) (string, string, error) {
+	network, address, ok := strings.Cut(addr, "://")
+	if !ok || address == "" {
+		return "", "", errors.Errorf("invalid signer address '%s'", addr)
+	}
+	switch network {
+	case "unix", "tcp":
+		return network, address, nil
+	default:
+		return "", "", errors.Errorf("unsupported signer network '%s'", network)
+	}
+}
+
+// LoadToken reads the shared authentication token from the file, surrounding whitespaces are ignored.
+func LoadToken(path string) ([]byte, error) {
+	b, err := os.ReadFile(path) // #nosec: in this case check for prevent G304 (CWE-22) is not necessary
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read signer token")
+	}
+	token := bytes.TrimSpace(b)
+	if len(token) < minTokenSize {
+		return nil, errors.Errorf("signer token is too short, at least %d bytes required", minTokenSize)
+	}
+	return token, nil
+}
@@ -0,0 +1,150 @@
+package signer
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/types"
+	"github.com/wavesplatform/gowaves/pkg/wallet"
+)
+
+const handshakeTimeout = 5 * time.Second
+
+// Server serves the requests of remote signer clients using the local signer.
+type Server struct {
+	signer types.Signer
+	token  []byte
+}
+
+// NewServer creates the server which signs with the signer and accepts only the clients knowing the token.
+func NewServer(signer types.Signer, token []byte) *Server {
+	return &Server{signer: signer, token: token}
+}
+
+// Serve accepts connections on the listener until the context is canceled or the listener fails.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	var mu sync.Mutex
+	conns := make(map[net.Conn]struct{})
+	go func() {
+		<-ctx.Done()
+		_ = l.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for c := range conns {
+			_ = c.Close()
+		}
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "failed to accept signer connection")
+		}
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handle(conn)
+			mu.Lock()
+			delete(conns, conn)
+			mu.Unlock()
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+	remote := conn.RemoteAddr().String()
+	if err := conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return
+	}
+	sess, err := serverHandshake(conn, s.token)
+	if err != nil {
+		zap.S().Debugf("Signer handshake with '%s' failed: %v", remote, err)
+		return
+	}
+	if dlErr := conn.SetDeadline(time.Time{}); dlErr != nil {
+		return
+	}
+	for {
+		req, rErr := sess.read()
+		if rErr != nil {
+			if errors.Is(rErr, errAuthentication) {
+				zap.S().Warnf("Signer client '%s' failed authentication", remote)
+			} else if !errors.Is(rErr, io.EOF) && !errors.Is(rErr, net.ErrClosed) {
+				zap.S().Debugf("Signer connection with '%s' failed: %v", remote, rErr)
+			}
+			return
+		}
+		if wErr := sess.write(s.process(req)); wErr != nil {
+			zap.S().Debugf("Signer connection with '%s' failed: %v", remote, wErr)
+			return
+		}
+	}
+}
+
+func (s *Server) process(req []byte) []byte {
+	if len(req) == 0 {
+		return failure(errors.New("empty request"))
+	}
+	op := operation(req[0])
+	if op == opPublicKeys {
+		pks, err := s.signer.PublicKeys()
+		if err != nil {
+			return failure(err)
+		}
+		res := make([]byte, 0, 1+len(pks)*crypto.PublicKeySize)
+		res = append(res, byte(statusOK))
+		for _, pk := range pks {
+			res = append(res, pk.Bytes()...)
+		}
+		return res
+	}
+	if len(req) < 1+crypto.PublicKeySize {
+		return failure(errors.New("invalid request size"))
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], req[1:1+crypto.PublicKeySize])
+	data := req[1+crypto.PublicKeySize:]
+	var (
+		res []byte
+		err error
+	)
+	switch op {
+	case opSign:
+		var sig crypto.Signature
+		sig, err = s.signer.Sign(pk, data)
+		res = sig.Bytes()
+	case opSignVRF:
+		res, err = s.signer.SignVRF(pk, data)
+	default:
+		return failure(errors.Errorf("unknown operation %d", op))
+	}
+	if errors.Is(err, wallet.ErrPublicKeyNotFound) {
+		return []byte{byte(statusUnknownPublicKey)}
+	}
+	if err != nil {
+		return failure(err)
+	}
+	zap.S().Debugf("Signer: operation %d for public key '%s' on %d bytes", op, pk.String(), len(data))
+	return append([]byte{byte(statusOK)}, res...)
+}
+
+func failure(err error) []byte {
+	return append([]byte{byte(statusError)}, err.Error()...)
+}
@@ -0,0 +1,152 @@
+package signer
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/wallet"
+)
+
+var testToken = []byte("0123456789abcdef0123456789abcdef")
+
+func startServer(t *testing.T, socket string, w *wallet.EmbeddedWalletImpl) context.CancelFunc {
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(w, testToken).Serve(ctx, l)
+	}()
+	stopped := false
+	stop := func() {
+		if stopped {
+			return
+		}
+		stopped = true
+		cancel()
+		assert.NoError(t, <-done)
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+func TestRemoteSigner(t *testing.T) {
+	seeds := [][]byte{[]byte("seed1"), []byte("seed2")}
+	local := wallet.NewEmbeddedWallet(nil, wallet.Stub{S: seeds}, proto.TestNetScheme)
+	socket := filepath.Join(t.TempDir(), "signer.sock")
+	startServer(t, socket, local)
+
+	r := NewRemote("unix", socket, testToken, time.Second)
+	defer func() {
+		assert.NoError(t, r.Close())
+	}()
+	pks, err := r.PublicKeys()
+	require.NoError(t, err)
+	expected, err := local.PublicKeys()
+	require.NoError(t, err)
+	require.Equal(t, expected, pks)
+
+	data := []byte("data to sign")
+	sig, err := r.Sign(pks[1], data)
+	require.NoError(t, err)
+	assert.True(t, crypto.Verify(pks[1], sig, data))
+
+	proof, err := r.SignVRF(pks[0], data)
+	require.NoError(t, err)
+	ok, _, err := crypto.VerifyVRF(pks[0], data, proof)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = r.Sign(crypto.PublicKey{}, data)
+	assert.ErrorIs(t, err, wallet.ErrPublicKeyNotFound)
+
+	// Transactions are signed by the wallet backed by remote signer.
+	w := wallet.NewExternalWallet(r, proto.TestNetScheme)
+	tx := proto.NewUnsignedTransferWithProofs(3, pks[0], proto.NewOptionalAssetWaves(), proto.NewOptionalAssetWaves(),
+		uint64(time.Now().UnixMilli()), 1, 100000,
+		proto.NewRecipientFromAddress(proto.MustAddressFromPublicKey(proto.TestNetScheme, pks[1])), nil)
+	require.NoError(t, w.SignTransactionWith(pks[0], tx))
+	ok, err = tx.Verify(proto.TestNetScheme, pks[0])
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, w.AccountSeeds())
+	assert.Error(t, w.Load([]byte("password")))
+}
+
+func TestRemoteSignerReconnect(t *testing.T) {
+	local := wallet.NewEmbeddedWallet(nil, wallet.Stub{S: [][]byte{[]byte("seed")}}, proto.TestNetScheme)
+	socket := filepath.Join(t.TempDir(), "signer.sock")
+	stop := startServer(t, socket, local)
+
+	r := NewRemote("unix", socket, testToken, time.Second)
+	defer func() {
+		assert.NoError(t, r.Close())
+	}()
+	_, err := r.PublicKeys()
+	require.NoError(t, err)
+
+	stop()
+	_, err = r.PublicKeys()
+	require.Error(t, err)
+
+	startServer(t, socket, local)
+	pks, err := r.PublicKeys()
+	require.NoError(t, err)
+	assert.Len(t, pks, 1)
+}
+
+func TestRemoteSignerWrongToken(t *testing.T) {
+	local := wallet.NewEmbeddedWallet(nil, wallet.Stub{S: [][]byte{[]byte("seed")}}, proto.TestNetScheme)
+	socket := filepath.Join(t.TempDir(), "signer.sock")
+	startServer(t, socket, local)
+
+	r := NewRemote("unix", socket, []byte("fedcba9876543210fedcba9876543210"), time.Second)
+	defer func() {
+		assert.NoError(t, r.Close())
+	}()
+	_, err := r.PublicKeys()
+	assert.Error(t, err)
+}
+
+func TestParseAddress(t *testing.T) {
+	for _, test := range []struct {
+		addr, network, address string
+		ok                     bool
+	}{
+		{"unix:///var/run/signer.sock", "unix", "/var/run/signer.sock", true},
+		{"tcp://127.0.0.1:6870", "tcp", "127.0.0.1:6870", true},
+		{"udp://127.0.0.1:6870", "", "", false},
+		{"127.0.0.1:6870", "", "", false},
+		{"unix://", "", "", false},
+	} {
+		network, address, err := ParseAddress(test.addr)
+		if !test.ok {
+			assert.Error(t, err, test.addr)
+			continue
+		}
+		require.NoError(t, err, test.addr)
+		assert.Equal(t, test.network, network)
+		assert.Equal(t, test.address, address)
+	}
+}
+
+func TestLoadToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, append(testToken, '\n'), 0600))
+	token, err := LoadToken(path)
+	require.NoError(t, err)
+	assert.Equal(t, testToken, token)
+
+	require.NoError(t, os.WriteFile(path, []byte("short"), 0600))
+	_, err = LoadToken(path)
+	assert.ErrorContains(t, err, "too short")
+}
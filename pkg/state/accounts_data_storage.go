@@ -347,6 +347,34 @@ func (s *accountsDataStorage) retrieveEntry(addr proto.Address, key string) (pro
 	return entry, nil
 }
 
+// retrieveEntryAtHeight returns the entry that was actual at the given height.
+func (s *accountsDataStorage) retrieveEntryAtHeight(
+	addr proto.Address, key string, height proto.Height,
+) (proto.DataEntry, error) {
+	addrNum, err := s.addrToNum(addr)
+	if err != nil {
+		return nil, err
+	}
+	storKey := accountsDataStorKey{addrNum, key}
+	recordBytes, err := s.hs.historicalEntryDataAtHeight(storKey.bytes(), dataEntry, height)
+	if err != nil {
+		return nil, err
+	}
+	var record dataEntryRecord
+	if err := record.unmarshalBinary(recordBytes); err != nil {
+		return nil, err
+	}
+	entry, err := proto.NewDataEntryFromValueBytes(record.value)
+	if err != nil {
+		return nil, err
+	}
+	if entry.GetValueType() == proto.DataDelete {
+		return nil, errors.Wrapf(keyvalue.ErrNotFound, "entry '%s' was removed", key)
+	}
+	entry.SetKey(key)
+	return entry, nil
+}
+
 func (s *accountsDataStorage) retrieveNewestIntegerEntry(addr proto.Address, key string) (*proto.IntegerDataEntry, error) {
 	id := entryId{addr.ID(), key}
 	if entry, ok := s.uncertainEntries[id]; ok {
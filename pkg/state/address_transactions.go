@@ -98,15 +98,22 @@ func (i *txIter) Release() {
 	i.iter.release()
 }
 
-func manageFile(file *os.File, db keyvalue.IterableKeyVal) error {
-	var properFileSize uint64
+// persistedFileSize returns the size of address_transactions file saved to the database.
+func persistedFileSize(db keyvalue.KeyValue) (uint64, error) {
 	fileSizeBytes, err := db.Get(fileSizeKeyBytes)
 	switch {
 	case errors.Is(err, keyvalue.ErrNotFound):
-		properFileSize = 0
+		return 0, nil
 	case err == nil:
-		properFileSize = binary.BigEndian.Uint64(fileSizeBytes)
+		return binary.BigEndian.Uint64(fileSizeBytes), nil
 	default:
+		return 0, err
+	}
+}
+
+func manageFile(file *os.File, db keyvalue.IterableKeyVal) error {
+	properFileSize, err := persistedFileSize(db)
+	if err != nil {
 		return err
 	}
 
@@ -10,6 +10,7 @@ import (
 	"github.com/wavesplatform/gowaves/pkg/keyvalue"
 	"github.com/wavesplatform/gowaves/pkg/libs/ntptime"
 	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/ride"
 	"github.com/wavesplatform/gowaves/pkg/ride/ast"
 	"github.com/wavesplatform/gowaves/pkg/settings"
 	"github.com/wavesplatform/gowaves/pkg/types"
@@ -48,8 +49,16 @@ type StateInfo interface {
 	// FullWavesBalance returns complete Waves balance record.
 	FullWavesBalance(account proto.Recipient) (*proto.FullWavesBalance, error)
 	GeneratingBalance(account proto.Recipient, height proto.Height) (uint64, error)
+	// WavesBalanceWithConfirmations returns minimal regular Waves balance over the last `confirmations` blocks.
+	WavesBalanceWithConfirmations(account proto.Recipient, confirmations uint64) (uint64, error)
 	// AssetBalance retrieves balance of account in specific currency, asset is asset's ID.
 	AssetBalance(account proto.Recipient, assetID proto.AssetID) (uint64, error)
+	// WavesBalanceAtHeight and AssetBalanceAtHeight return regular balances at the given height.
+	// Results are exact within the rollback window, deeper heights require the historical index.
+	WavesBalanceAtHeight(account proto.Recipient, height proto.Height) (uint64, error)
+	AssetBalanceAtHeight(account proto.Recipient, assetID proto.AssetID, height proto.Height) (uint64, error)
+	// LeaseBalanceAtHeight returns leased in and out amounts at the given height, see WavesBalanceAtHeight.
+	LeaseBalanceAtHeight(account proto.Recipient, height proto.Height) (leaseIn, leaseOut int64, err error)
 	// WavesAddressesNumber returns total number of Waves addresses in state.
 	// It is extremely slow, so it is recommended to only use for testing purposes.
 	WavesAddressesNumber() (uint64, error)
@@ -82,6 +91,8 @@ type StateInfo interface {
 	// Accounts data storage.
 	RetrieveEntries(account proto.Recipient) ([]proto.DataEntry, error)
 	RetrieveEntry(account proto.Recipient, key string) (proto.DataEntry, error)
+	// RetrieveEntryAtHeight returns data entry at the given height, see WavesBalanceAtHeight for limitations.
+	RetrieveEntryAtHeight(account proto.Recipient, key string, height proto.Height) (proto.DataEntry, error)
 	RetrieveIntegerEntry(account proto.Recipient, key string) (*proto.IntegerDataEntry, error)
 	RetrieveBooleanEntry(account proto.Recipient, key string) (*proto.BooleanDataEntry, error)
 	RetrieveStringEntry(account proto.Recipient, key string) (*proto.StringDataEntry, error)
@@ -103,12 +114,20 @@ type StateInfo interface {
 	FullAssetInfo(assetID proto.AssetID) (*proto.FullAssetInfo, error)
 	EnrichedFullAssetInfo(assetID proto.AssetID) (*proto.EnrichedFullAssetInfo, error)
 	NFTList(account proto.Recipient, limit uint64, afterAssetID *proto.AssetID) ([]*proto.FullAssetInfo, error)
+	// AssetDistribution returns up to limit holders of the asset with their balances at the given height,
+	// holders follow the given address if it is set. The second result reports if there are more holders.
+	AssetDistribution(
+		assetID proto.AssetID, height proto.Height, after *proto.WavesAddress, limit int,
+	) ([]proto.AssetHolding, bool, error)
 	// Script information.
 	ScriptBasicInfoByAccount(account proto.Recipient) (*proto.ScriptBasicInfo, error)
 	ScriptInfoByAccount(account proto.Recipient) (*proto.ScriptInfo, error)
 	ScriptInfoByAsset(assetID proto.AssetID) (*proto.ScriptInfo, error)
 	NewestScriptByAccount(account proto.Recipient) (*ast.Tree, error)
 	NewestScriptBytesByAccount(account proto.Recipient) (proto.Script, error)
+	// ScriptBytesByAccountAtHeight returns the script of the account at the given height, empty script means
+	// no script. Scripts are available only within the rollback window.
+	ScriptBytesByAccountAtHeight(account proto.Recipient, height proto.Height) (proto.Script, error)
 
 	// Leases.
 	IsActiveLeasing(leaseID crypto.Digest) (bool, error)
@@ -119,6 +138,8 @@ type StateInfo interface {
 	ProvidesExtendedApi() (bool, error)
 	// True if state stores and calculates state hashes for each block height.
 	ProvidesStateHashes() (bool, error)
+	// ArchivalHeight returns the height since which the full history is kept, zero if archival mode is disabled.
+	ArchivalHeight() (proto.Height, error)
 
 	// State hashes.
 	LegacyStateHashAtHeight(height proto.Height) (*proto.StateHash, error)
@@ -126,6 +147,9 @@ type StateInfo interface {
 	// CreateNextSnapshotHash creates snapshot hash for next block in the context of current state.
 	CreateNextSnapshotHash(block *proto.Block) (crypto.Digest, error)
 
+	// NewCheckpoint captures a consistent view of the state at the current height, see Checkpoint.
+	NewCheckpoint() (*Checkpoint, error)
+
 	// Map on readable state. Way to apply multiple operations under same lock.
 	MapR(func(StateInfo) (interface{}, error)) (interface{}, error)
 
@@ -154,6 +178,15 @@ type StateInfo interface {
 
 	// SnapshotsAtHeight returns block snapshots at the given height.
 	SnapshotsAtHeight(height proto.Height) (proto.BlockSnapshot, error)
+
+	// EvaluateExpression evaluates the expression in the context of the script of the given account.
+	// The state is not changed by the evaluation.
+	EvaluateExpression(account proto.WavesAddress, expr ast.Node) (ride.Result, error)
+	// CallFunctionReadOnly calls the callable function of dApp without applying its results to the state.
+	CallFunctionReadOnly(dApp proto.WavesAddress, fc proto.FunctionCall) (ride.Result, error)
+
+	// MinimalFee returns the minimal fee of the transaction in Waves or in the given sponsored asset.
+	MinimalFee(tx proto.Transaction, feeAsset proto.OptionalAsset) (uint64, error)
 }
 
 // StateModifier contains all the methods needed to modify node's state.
@@ -277,6 +310,8 @@ func DefaultTestingStorageParams() StorageParams {
 type ValidationParams struct {
 	VerificationGoroutinesNum int
 	Time                      types.Time
+	// RideEngine selects the engine that executes scripts of accounts, assets and DApps.
+	RideEngine ride.Engine
 }
 
 type StateParams struct {
@@ -288,6 +323,12 @@ type StateParams struct {
 	ProvideExtendedApi bool
 	// BuildStateHashes enables building and storing state hashes by height.
 	BuildStateHashes bool
+	// StoreHistoricalIndex enables the index of balances and data entries changes, that allows to query them
+	// at heights beyond the rollback window. The index covers only the blocks applied after it was enabled.
+	StoreHistoricalIndex bool
+	// Archival enables archival mode, in which the history of entities is not cut beyond the rollback window.
+	// For an existing state the full history is kept since the start of the rollback window at the moment of enabling.
+	Archival bool
 }
 
 func DefaultStateParams() StateParams {
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"io"
 	"math"
+	"slices"
 	"sort"
 
 	"github.com/fxamacker/cbor/v2"
@@ -21,8 +22,16 @@ import (
 const (
 	wavesBalanceRecordSize = 8 + 8 + 8
 	assetBalanceRecordSize = 8
+	assetHolderRecordSize  = 1
 )
 
+// assetHolderMark is the record of the asset holders index. The index contains every address that has ever had
+// a non-zero balance of the asset, actual balances are taken from the asset balances.
+var assetHolderMark = []byte{1}
+
+// assetHoldersIndexFlushSize is the number of index records written in one batch while building the index.
+const assetHoldersIndexFlushSize = 100000
+
 type wavesValue struct {
 	profile       balanceProfile
 	leaseChange   bool
@@ -106,6 +115,11 @@ func (r *assetBalanceRecord) unmarshalBinary(data []byte) error {
 	return nil
 }
 
+type assetHolding struct {
+	address proto.AddressID
+	balance uint64
+}
+
 type heights []proto.Height
 
 func (h heights) Len() int { return len(h) }
@@ -604,6 +618,27 @@ func minEffectiveBalanceInRangeCommon(records [][]byte) (uint64, error) {
 	return minBalance, nil
 }
 
+// minBalanceInRange returns minimal regular Waves balance in range [startHeight, endHeight].
+func (s *balances) minBalanceInRange(addr proto.AddressID, startHeight, endHeight uint64) (uint64, error) {
+	key := wavesBalanceKey{address: addr}
+	records, err := s.hs.entriesDataInHeightRange(key.bytes(), startHeight, endHeight)
+	if err != nil {
+		return 0, err
+	}
+	minBalance := uint64(math.MaxUint64)
+	for _, recordBytes := range records {
+		var record wavesBalanceRecord
+		if err := record.unmarshalBinary(recordBytes); err != nil {
+			return 0, err
+		}
+		minBalance = min(minBalance, record.balance)
+	}
+	if minBalance == math.MaxUint64 { // No records means zero balance.
+		minBalance = 0
+	}
+	return minBalance, nil
+}
+
 func (s *balances) generatingBalance(addr proto.AddressID, height proto.Height) (uint64, error) {
 	startHeight, endHeight := s.sets.RangeForGeneratingBalanceByHeight(height)
 	gb, err := s.minEffectiveBalanceInRange(addr, startHeight, endHeight)
@@ -798,6 +833,18 @@ func (s *balances) assetBalance(addr proto.AddressID, assetID proto.AssetID) (ui
 	return s.assetBalanceFromRecordBytes(recordBytes)
 }
 
+// assetBalanceAtHeight returns asset balance that was actual at the given height.
+func (s *balances) assetBalanceAtHeight(addr proto.AddressID, assetID proto.AssetID, height proto.Height) (uint64, error) {
+	key := assetBalanceKey{address: addr, asset: assetID}
+	recordBytes, err := s.hs.historicalEntryDataAtHeight(key.bytes(), assetBalance, height)
+	if isNotFoundInHistoryOrDBErr(err) {
+		return 0, nil // No records means zero balance.
+	} else if err != nil {
+		return 0, err
+	}
+	return s.assetBalanceFromRecordBytes(recordBytes)
+}
+
 func (s *balances) newestAssetBalance(addr proto.AddressID, asset proto.AssetID) (uint64, error) {
 	key := assetBalanceKey{address: addr, asset: asset}
 	recordBytes, err := s.hs.newestTopEntryData(key.bytes())
@@ -861,6 +908,22 @@ func (s *balances) wavesBalance(addr proto.AddressID) (balanceProfile, error) {
 	return r.balanceProfile, nil
 }
 
+// wavesBalanceAtHeight returns waves balanceProfile that was actual at the given height.
+func (s *balances) wavesBalanceAtHeight(addr proto.AddressID, height proto.Height) (balanceProfile, error) {
+	key := wavesBalanceKey{address: addr}
+	recordBytes, err := s.hs.historicalEntryDataAtHeight(key.bytes(), wavesBalance, height)
+	if isNotFoundInHistoryOrDBErr(err) {
+		return balanceProfile{}, nil // No records means empty profile.
+	} else if err != nil {
+		return balanceProfile{}, err
+	}
+	var record wavesBalanceRecord
+	if err := record.unmarshalBinary(recordBytes); err != nil {
+		return balanceProfile{}, errors.Wrapf(err, "failed to unmarshal data to %T", record)
+	}
+	return record.balanceProfile, nil
+}
+
 func (s *balances) calculateStateHashesAssetBalance(addr proto.AddressID, assetID proto.AssetID,
 	balance uint64, blockID proto.BlockID, keyStr string) error {
 	info, err := s.assets.newestConstInfo(assetID)
@@ -899,7 +962,131 @@ func (s *balances) setAssetBalance(addr proto.AddressID, assetID proto.AssetID,
 			return shErr
 		}
 	}
-	return s.hs.addNewEntry(assetBalance, keyBytes, recordBytes, blockID)
+	if err := s.hs.addNewEntry(assetBalance, keyBytes, recordBytes, blockID); err != nil {
+		return err
+	}
+	if balance == 0 {
+		return nil
+	}
+	return s.addAssetHolder(addr, assetID, blockID)
+}
+
+// addAssetHolder puts the address to the index of asset holders if it is not there yet.
+func (s *balances) addAssetHolder(addr proto.AddressID, assetID proto.AssetID, blockID proto.BlockID) error {
+	key := assetHolderKey{asset: assetID, address: addr}
+	keyBytes := key.bytes()
+	_, err := s.hs.newestTopEntryData(keyBytes)
+	switch {
+	case err == nil:
+		return nil
+	case isNotFoundInHistoryOrDBErr(err):
+		return s.hs.addNewEntry(assetHolder, keyBytes, assetHolderMark, blockID)
+	default:
+		return err
+	}
+}
+
+// assetDistribution returns up to limit holders with non-zero balances of the asset at the given height.
+// Holders are sorted by address ID and start after the given address if it is set.
+// The second result reports if there are more holders after the returned ones.
+func (s *balances) assetDistribution(
+	assetID proto.AssetID, height proto.Height, after *proto.AddressID, limit int,
+) (_ []assetHolding, _ bool, err error) {
+	key := assetHolderKey{asset: assetID}
+	var start []byte // Iteration starts from the first holder of the asset or from the given address.
+	if after != nil {
+		start = (&assetHolderKey{asset: assetID, address: *after}).bytes()
+	}
+	iter, err := s.hs.newTopEntryIteratorFrom(key.assetPrefix(), start)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() {
+		iter.Release()
+		if iErr := iter.Error(); iErr != nil && err == nil {
+			err = iErr
+		}
+	}()
+	res := make([]assetHolding, 0, limit)
+	for iter.Next() {
+		if uErr := key.unmarshal(iter.Key()); uErr != nil {
+			return nil, false, uErr
+		}
+		if after != nil && key.address == *after {
+			continue
+		}
+		balance, bErr := s.assetBalanceAtHeight(key.address, assetID, height)
+		if bErr != nil {
+			return nil, false, bErr
+		}
+		if balance == 0 {
+			continue
+		}
+		if len(res) == limit {
+			return res, true, nil
+		}
+		res = append(res, assetHolding{address: key.address, balance: balance})
+	}
+	return res, false, nil
+}
+
+// buildAssetHoldersIndex fills the index of asset holders from the stored asset balances.
+// It is used to migrate the state that was created before the index.
+func (s *balances) buildAssetHoldersIndex() (err error) {
+	iter, err := s.hs.db.NewKeyIterator([]byte{assetBalanceKeyPrefix})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		iter.Release()
+		if iErr := iter.Error(); iErr != nil && err == nil {
+			err = iErr
+		}
+	}()
+	batch, err := s.hs.db.NewBatch()
+	if err != nil {
+		return err
+	}
+	var (
+		bk    assetBalanceKey
+		r     assetBalanceRecord
+		count int
+	)
+	for iter.Next() {
+		history, hErr := newHistoryRecordFromBytes(iter.Value())
+		if hErr != nil {
+			return hErr
+		}
+		if _, nErr := s.hs.fmt.normalize(history, s.hs.amend); nErr != nil {
+			return nErr
+		}
+		// The address became a holder with the first non-zero balance in the kept history.
+		i := slices.IndexFunc(history.entries, func(e historyEntry) bool {
+			return r.unmarshalBinary(e.data) == nil && r.balance != 0
+		})
+		if i < 0 {
+			continue
+		}
+		if uErr := bk.unmarshal(iter.Key()); uErr != nil {
+			return uErr
+		}
+		hk := assetHolderKey{asset: bk.asset, address: bk.address}
+		holder := historyRecord{
+			entityType: assetHolder,
+			entries:    []historyEntry{{data: assetHolderMark, blockNum: history.entries[i].blockNum}},
+		}
+		holderBytes, mErr := holder.marshalBinary()
+		if mErr != nil {
+			return mErr
+		}
+		batch.Put(hk.bytes(), holderBytes)
+		if count++; count%assetHoldersIndexFlushSize == 0 {
+			if fErr := s.hs.db.Flush(batch); fErr != nil {
+				return fErr
+			}
+		}
+	}
+	return s.hs.db.Flush(batch)
 }
 
 func (s *balances) calculateStateHashesWavesBalance(addr proto.AddressID, balance wavesValue,
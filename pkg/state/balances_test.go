@@ -1,6 +1,8 @@
 package state
 
 import (
+	"bytes"
+	"slices"
 	"strconv"
 	"testing"
 
@@ -243,6 +245,138 @@ func TestMinBalanceInRange(t *testing.T) {
 	if minBalance != 99 {
 		t.Errorf("Invalid minimum balance in range: need %d, got %d.", 99, minBalance)
 	}
+	minRegular, err := to.balances.minBalanceInRange(addr.ID(), 99, 150)
+	require.NoError(t, err, "minBalanceInRange() failed")
+	assert.Equal(t, uint64(99), minRegular)
+}
+
+func TestBalancesAtHeight(t *testing.T) {
+	to := createBalances(t)
+	to.stor.hs.enableHistoricalIndex(1)
+
+	addr, err := proto.NewAddressFromString(addr0)
+	require.NoError(t, err)
+	holder, err := proto.NewAddressFromString(addr1)
+	require.NoError(t, err)
+	asset := genAsset(1)
+	to.stor.createAssetAtBlock(t, asset, genBlockId(1))
+	assetID := proto.AssetIDFromDigest(asset)
+	for i := 2; i <= totalBlocksNumber+10; i++ { // Balance is equal to height
+		blockID := genBlockId(byte(i))
+		to.stor.addBlock(t, blockID)
+		err = to.balances.setWavesBalance(addr.ID(), newWavesValueFromProfile(balanceProfile{uint64(i), 0, 0}), blockID)
+		require.NoError(t, err)
+		if i == 10 {
+			err = to.balances.setAssetBalance(holder.ID(), assetID, 500, blockID)
+			require.NoError(t, err)
+			to.stor.flush(t)
+		}
+	}
+	to.stor.flush(t)
+	require.NoError(t, to.stor.stateDB.setRollbackMinHeight(150))
+	require.NoError(t, to.stor.stateDB.flushBatch())
+
+	for _, test := range []struct {
+		height  proto.Height
+		balance uint64
+	}{
+		{1, 0}, {2, 2}, {10, 10}, {100, 100}, {149, 149}, {150, 150}, {210, 210},
+	} {
+		profile, bErr := to.balances.wavesBalanceAtHeight(addr.ID(), test.height)
+		require.NoError(t, bErr)
+		assert.Equal(t, test.balance, profile.balance, "height %d", test.height)
+	}
+	// Value unchanged since the start of index is taken from history.
+	balance, err := to.balances.assetBalanceAtHeight(holder.ID(), assetID, 100)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(500), balance)
+	balance, err = to.balances.assetBalanceAtHeight(holder.ID(), assetID, 5)
+	require.NoError(t, err)
+	assert.Zero(t, balance)
+
+	// Without index only the rollback window is available.
+	to.stor.hs.enableHistoricalIndex(0)
+	profile, err := to.balances.wavesBalanceAtHeight(addr.ID(), 150)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(150), profile.balance)
+	_, err = to.balances.wavesBalanceAtHeight(addr.ID(), 100)
+	assert.ErrorIs(t, err, errHistoryUnavailable)
+
+	// In archival mode the history is available since the start of archival mode.
+	to.stor.hs.enableArchivalMode(50)
+	profile, err = to.balances.wavesBalanceAtHeight(addr.ID(), 100)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), profile.balance)
+	_, err = to.balances.wavesBalanceAtHeight(addr.ID(), 40)
+	assert.ErrorIs(t, err, errHistoryUnavailable)
+}
+
+func TestAssetDistribution(t *testing.T) {
+	to := createBalances(t)
+
+	addrs := make([]proto.AddressID, 4)
+	for i, s := range []string{addr0, addr1, addr2, addr3} {
+		addr, err := proto.NewAddressFromString(s)
+		require.NoError(t, err)
+		addrs[i] = addr.ID()
+	}
+	asset := genAsset(1)
+	to.stor.createAssetAtBlock(t, asset, genBlockId(1))
+	assetID := proto.AssetIDFromDigest(asset)
+	setBalances := func(blockID proto.BlockID, balances map[proto.AddressID]uint64) {
+		to.stor.addBlock(t, blockID)
+		for addr, balance := range balances {
+			require.NoError(t, to.balances.setAssetBalance(addr, assetID, balance, blockID))
+		}
+		to.stor.flush(t)
+	}
+	sorted := func(holdings ...assetHolding) []assetHolding {
+		slices.SortFunc(holdings, func(a, b assetHolding) int { return bytes.Compare(a.address[:], b.address[:]) })
+		return holdings
+	}
+	distribution := func(height proto.Height) []assetHolding {
+		res, hasNext, err := to.balances.assetDistribution(assetID, height, nil, 10)
+		require.NoError(t, err)
+		assert.False(t, hasNext)
+		return res
+	}
+
+	setBalances(genBlockId(2), map[proto.AddressID]uint64{addrs[0]: 100, addrs[1]: 50})
+	setBalances(genBlockId(3), map[proto.AddressID]uint64{addrs[1]: 0, addrs[2]: 10})
+	atHeight2 := sorted(assetHolding{addrs[0], 100}, assetHolding{addrs[1], 50})
+	atHeight3 := sorted(assetHolding{addrs[0], 100}, assetHolding{addrs[2], 10})
+	assert.Empty(t, distribution(1))
+	assert.Equal(t, atHeight2, distribution(2))
+	assert.Equal(t, atHeight3, distribution(3))
+
+	// Pagination.
+	page, hasNext, err := to.balances.assetDistribution(assetID, 2, nil, 1)
+	require.NoError(t, err)
+	assert.True(t, hasNext)
+	assert.Equal(t, atHeight2[:1], page)
+	page, hasNext, err = to.balances.assetDistribution(assetID, 2, &page[0].address, 1)
+	require.NoError(t, err)
+	assert.False(t, hasNext)
+	assert.Equal(t, atHeight2[1:], page)
+
+	// Holder of rolled back block is removed from index.
+	setBalances(genBlockId(4), map[proto.AddressID]uint64{addrs[3]: 5})
+	assert.Equal(t, sorted(append(slices.Clone(atHeight3), assetHolding{addrs[3], 5})...), distribution(4))
+	to.stor.rollbackBlock(t, genBlockId(4))
+	holderKey := assetHolderKey{asset: assetID, address: addrs[3]}
+	_, err = to.stor.hs.topEntryData(holderKey.bytes())
+	assert.True(t, isNotFoundInHistoryOrDBErr(err))
+	assert.Equal(t, atHeight3, distribution(3))
+
+	// Index is restored from balances after removal.
+	for _, addr := range addrs {
+		k := assetHolderKey{asset: assetID, address: addr}
+		require.NoError(t, to.stor.db.Delete(k.bytes()))
+	}
+	assert.Empty(t, distribution(2))
+	require.NoError(t, to.balances.buildAssetHoldersIndex())
+	assert.Equal(t, atHeight2, distribution(2))
+	assert.Equal(t, atHeight3, distribution(3))
 }
 
 func TestBalancesChangesByStoredChallenge(t *testing.T) {
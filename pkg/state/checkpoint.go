@@ -0,0 +1,449 @@
+package state
+
+import (
+	"encoding/json"
+	stderrs "errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/keyvalue"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+)
+
+const checkpointManifestFile = "checkpoint.json"
+
+// ErrCheckpointInvalidated is returned when the state was changed in a way that made the checkpoint inconsistent
+// (e.g. rolled back below the checkpoint height) before the checkpoint was written. Checkpoint should be retaken.
+var ErrCheckpointInvalidated = stderrs.New("checkpoint was invalidated by state modification, retry")
+
+// CheckpointInfo describes the state checkpoint. It is stored as a manifest inside the checkpoint directory.
+type CheckpointInfo struct {
+	Height            proto.Height   `json:"height"`
+	BlockID           proto.BlockID  `json:"blockId"`
+	StateVersion      int            `json:"stateVersion"`
+	Scheme            proto.Scheme   `json:"scheme"`
+	ExtendedAPI       bool           `json:"extendedApi"`
+	StateHashes       bool           `json:"stateHashes"`
+	LegacyStateHash   *crypto.Digest `json:"legacyStateHash,omitempty"`
+	SnapshotStateHash *crypto.Digest `json:"snapshotStateHash,omitempty"`
+	Timestamp         uint64         `json:"timestamp"`
+}
+
+// checkpointFile is a file of blocks storage that is copied to the checkpoint up to the given size.
+type checkpointFile struct {
+	name string
+	size uint64
+}
+
+// Checkpoint is a consistent view of the state at some height.
+// Creation of the checkpoint is cheap, the data is copied by WriteTo method.
+// Checkpoint must be released after use.
+type Checkpoint struct {
+	info        CheckpointInfo
+	files       []checkpointFile
+	snap        *keyvalue.Snapshot
+	registry    *checkpointsRegistry
+	invalidated atomic.Bool
+	releaseOnce sync.Once
+}
+
+// Info returns the description of the checkpoint.
+func (c *Checkpoint) Info() CheckpointInfo {
+	return c.info
+}
+
+// WriteTo writes the checkpoint to the given directory, which must be absent or empty.
+// The resulting directory can be used as node's state directory after RestoreCheckpoint.
+func (c *Checkpoint) WriteTo(dir string) (err error) {
+	if c.invalidated.Load() {
+		return ErrCheckpointInvalidated
+	}
+	if mkErr := makeEmptyDir(dir); mkErr != nil {
+		return mkErr
+	}
+	defer func() {
+		if err != nil {
+			if rmErr := removeStateFiles(dir); rmErr != nil {
+				err = stderrs.Join(err, rmErr)
+			}
+		}
+	}()
+	blocksDir := filepath.Join(dir, blocksStorDir)
+	if mkErr := os.Mkdir(blocksDir, 0750); mkErr != nil {
+		return errors.Wrap(mkErr, "failed to create blocks storage directory")
+	}
+	for _, f := range c.files {
+		src := filepath.Join(c.registry.blockStorageDir, f.name)
+		if cpErr := copyFile(src, filepath.Join(blocksDir, f.name), f.size); cpErr != nil {
+			return errors.Wrapf(cpErr, "failed to copy file '%s'", f.name)
+		}
+	}
+	if wErr := c.snap.WriteTo(filepath.Join(dir, keyvalueDir)); wErr != nil {
+		return errors.Wrap(wErr, "failed to write database")
+	}
+	// Files could be truncated by rollback during the copying, so check validity again.
+	if c.invalidated.Load() {
+		return ErrCheckpointInvalidated
+	}
+	data, err := json.MarshalIndent(c.info, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal checkpoint info")
+	}
+	if wErr := os.WriteFile(filepath.Join(dir, checkpointManifestFile), data, 0600); wErr != nil {
+		return errors.Wrap(wErr, "failed to write checkpoint manifest")
+	}
+	return nil
+}
+
+// Release frees resources held by the checkpoint.
+func (c *Checkpoint) Release() {
+	c.releaseOnce.Do(func() {
+		c.registry.unregister(c)
+		c.snap.Release()
+	})
+}
+
+// checkpointsRegistry keeps track of active checkpoints to invalidate them on destructive state modifications.
+type checkpointsRegistry struct {
+	db              *keyvalue.KeyVal
+	blockStorageDir string
+
+	mu     sync.Mutex
+	active map[*Checkpoint]struct{}
+}
+
+func newCheckpointsRegistry(db *keyvalue.KeyVal, blockStorageDir string) *checkpointsRegistry {
+	return &checkpointsRegistry{
+		db:              db,
+		blockStorageDir: blockStorageDir,
+		active:          make(map[*Checkpoint]struct{}),
+	}
+}
+
+func (r *checkpointsRegistry) newCheckpoint(info CheckpointInfo, files []checkpointFile) (*Checkpoint, error) {
+	if r == nil {
+		return nil, errors.New("checkpoints are not supported")
+	}
+	snap, err := r.db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	c := &Checkpoint{info: info, files: files, snap: snap, registry: r}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active[c] = struct{}{}
+	return c, nil
+}
+
+func (r *checkpointsRegistry) unregister(c *Checkpoint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.active, c)
+}
+
+// invalidateAbove invalidates checkpoints which will be affected by the rollback to the given height.
+func (r *checkpointsRegistry) invalidateAbove(height proto.Height) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for c := range r.active {
+		if c.info.Height > height {
+			c.invalidated.Store(true)
+		}
+	}
+}
+
+func (r *checkpointsRegistry) invalidateAll() {
+	r.invalidateAbove(0)
+}
+
+// NewCheckpoint captures the state at the current height. Capturing is cheap and requires only
+// a database snapshot and sizes of block storage files, the actual copying is done by Checkpoint.WriteTo.
+func (s *stateManager) NewCheckpoint() (*Checkpoint, error) {
+	height, err := s.Height()
+	if err != nil {
+		return nil, wrapErr(RetrievalError, err)
+	}
+	blockID, err := s.HeightToBlockID(height)
+	if err != nil {
+		return nil, wrapErr(RetrievalError, err)
+	}
+	meta, err := s.rw.blockMetaByHeight(height)
+	if err != nil {
+		return nil, wrapErr(RetrievalError, err)
+	}
+	atxSize, err := persistedFileSize(s.stateDB.db)
+	if err != nil {
+		return nil, wrapErr(RetrievalError, err)
+	}
+	extendedAPI, err := s.stateDB.stateStoresApiData()
+	if err != nil {
+		return nil, wrapErr(RetrievalError, err)
+	}
+	stateHashes, err := s.stateDB.stateStoresHashes()
+	if err != nil {
+		return nil, wrapErr(RetrievalError, err)
+	}
+	info := CheckpointInfo{
+		Height:       height,
+		BlockID:      blockID,
+		StateVersion: StateVersion,
+		Scheme:       s.settings.AddressSchemeCharacter,
+		ExtendedAPI:  extendedAPI,
+		StateHashes:  stateHashes,
+		Timestamp:    uint64(time.Now().UnixMilli()), // #nosec: current time is always positive
+	}
+	if stateHashes {
+		sh, shErr := s.stor.stateHashes.legacyStateHash(height)
+		if shErr != nil {
+			return nil, wrapErr(RetrievalError, shErr)
+		}
+		info.LegacyStateHash = &sh.SumHash
+	}
+	switch sh, shErr := s.stor.stateHashes.snapshotStateHash(height); {
+	case shErr == nil:
+		info.SnapshotStateHash = &sh
+	case !isNotFoundInHistoryOrDBErr(shErr):
+		return nil, wrapErr(RetrievalError, shErr)
+	}
+	files := []checkpointFile{
+		{name: "blockchain", size: meta.txEndOffset},
+		{name: "headers", size: meta.headerEndOffset},
+		{name: "block_height_to_id", size: s.rw.heightToIDOffset(height)},
+		{name: "address_transactions", size: atxSize},
+	}
+	c, err := s.checkpoints.newCheckpoint(info, files)
+	if err != nil {
+		return nil, wrapErr(Other, err)
+	}
+	return c, nil
+}
+
+// ReadCheckpointInfo reads the manifest of the checkpoint stored in the given directory.
+func ReadCheckpointInfo(dir string) (*CheckpointInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, checkpointManifestFile)) // #nosec: the path is provided by the user
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read checkpoint manifest")
+	}
+	info := new(CheckpointInfo)
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal checkpoint manifest")
+	}
+	return info, nil
+}
+
+// RestoreCheckpoint copies the checkpoint into the empty state directory and verifies the restored state
+// against the block ID and the state hashes stored in the checkpoint manifest.
+func RestoreCheckpoint(
+	checkpointDir, dataDir string,
+	params StateParams,
+	settings *settings.BlockchainSettings,
+) (_ *CheckpointInfo, err error) {
+	info, err := ReadCheckpointInfo(checkpointDir)
+	if err != nil {
+		return nil, err
+	}
+	if vErr := info.validate(settings); vErr != nil {
+		return nil, vErr
+	}
+	if mkErr := makeEmptyDir(dataDir); mkErr != nil {
+		return nil, mkErr
+	}
+	defer func() {
+		if err != nil {
+			if rmErr := removeStateFiles(dataDir); rmErr != nil {
+				err = stderrs.Join(err, rmErr)
+			}
+		}
+	}()
+	for _, name := range []string{keyvalueDir, blocksStorDir} {
+		if cpErr := copyDir(filepath.Join(checkpointDir, name), filepath.Join(dataDir, name)); cpErr != nil {
+			return nil, errors.Wrapf(cpErr, "failed to copy '%s'", name)
+		}
+	}
+	if vErr := verifyRestoredState(dataDir, info, params, settings); vErr != nil {
+		return nil, vErr
+	}
+	return info, nil
+}
+
+func (info *CheckpointInfo) validate(settings *settings.BlockchainSettings) error {
+	if info.StateVersion != StateVersion {
+		return errors.Wrapf(ErrIncompatibleStateParams, "checkpoint state version %d, want %d",
+			info.StateVersion, StateVersion,
+		)
+	}
+	if info.Scheme != settings.AddressSchemeCharacter {
+		return errors.Errorf("checkpoint scheme '%c' does not match blockchain scheme '%c'",
+			info.Scheme, settings.AddressSchemeCharacter,
+		)
+	}
+	if info.LegacyStateHash == nil && info.SnapshotStateHash == nil {
+		return errors.New("checkpoint has no state hashes to verify the state")
+	}
+	return nil
+}
+
+// verifyRestoredState opens the restored state and checks it against the checkpoint info.
+func verifyRestoredState(
+	dataDir string,
+	info *CheckpointInfo,
+	params StateParams,
+	settings *settings.BlockchainSettings,
+) (err error) {
+	params.StoreExtendedApiData = info.ExtendedAPI
+	params.BuildStateHashes = info.StateHashes
+	s, err := newStateManager(dataDir, true, params, settings, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to open restored state")
+	}
+	defer func() {
+		if clErr := s.Close(); clErr != nil {
+			err = stderrs.Join(err, errors.Wrap(clErr, "failed to close restored state"))
+		}
+	}()
+	if vErr := checkStateMatchesCheckpoint(s, info); vErr != nil {
+		return errors.Wrap(vErr, "restored state verification failed")
+	}
+	return nil
+}
+
+func checkStateMatchesCheckpoint(s *stateManager, info *CheckpointInfo) error {
+	height, err := s.Height()
+	if err != nil {
+		return err
+	}
+	if height != info.Height {
+		return errors.Errorf("height %d, want %d", height, info.Height)
+	}
+	blockID, err := s.HeightToBlockID(height)
+	if err != nil {
+		return err
+	}
+	if blockID != info.BlockID {
+		return errors.Errorf("block ID '%s', want '%s'", blockID.String(), info.BlockID.String())
+	}
+	if info.LegacyStateHash != nil {
+		sh, shErr := s.LegacyStateHashAtHeight(height)
+		if shErr != nil {
+			return shErr
+		}
+		if sh.SumHash != *info.LegacyStateHash {
+			return errors.Errorf("legacy state hash '%s', want '%s'", sh.SumHash.String(), info.LegacyStateHash.String())
+		}
+	}
+	if info.SnapshotStateHash != nil {
+		sh, shErr := s.SnapshotStateHashAtHeight(height)
+		if shErr != nil {
+			return shErr
+		}
+		if sh != *info.SnapshotStateHash {
+			return errors.Errorf("snapshot state hash '%s', want '%s'", sh.String(), info.SnapshotStateHash.String())
+		}
+		// Since light node activation the snapshot state hash is a part of the block header.
+		header, hErr := s.HeaderByHeight(height)
+		if hErr != nil {
+			return hErr
+		}
+		if hsh, ok := header.GetStateHash(); ok && hsh != sh {
+			return errors.Errorf("snapshot state hash '%s' differs from block header state hash '%s'",
+				sh.String(), hsh.String(),
+			)
+		}
+	}
+	return nil
+}
+
+// makeEmptyDir creates the directory if it does not exist or checks that the existing directory is empty.
+func makeEmptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if mkErr := os.MkdirAll(dir, 0750); mkErr != nil {
+			return errors.Wrapf(mkErr, "failed to create directory '%s'", dir)
+		}
+		return nil
+	case err != nil:
+		return errors.Wrapf(err, "failed to read directory '%s'", dir)
+	case len(entries) != 0:
+		return errors.Errorf("directory '%s' is not empty", dir)
+	default:
+		return nil
+	}
+}
+
+// removeStateFiles removes partially written state files from the directory.
+func removeStateFiles(dir string) error {
+	var errs []error
+	for _, name := range []string{checkpointManifestFile, keyvalueDir, blocksStorDir} {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 0 {
+		zap.S().Warnf("Failed to clean up directory '%s'", dir)
+	}
+	return stderrs.Join(errs...)
+}
+
+// copyFile copies the first size bytes of the src file to the new dst file.
+func copyFile(src, dst string, size uint64) (err error) {
+	in, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if clErr := in.Close(); clErr != nil {
+			err = stderrs.Join(err, clErr)
+		}
+	}()
+	out, err := os.OpenFile(filepath.Clean(dst), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if clErr := out.Close(); clErr != nil {
+			err = stderrs.Join(err, clErr)
+		}
+	}()
+	if _, cpErr := io.CopyN(out, in, int64(size)); cpErr != nil { // #nosec: file size always fits int64
+		return cpErr
+	}
+	return out.Sync()
+}
+
+// copyDir copies the regular files of the src directory into the new dst directory.
+func copyDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if mkErr := os.Mkdir(dst, 0750); mkErr != nil {
+		return mkErr
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		fi, iErr := e.Info()
+		if iErr != nil {
+			return iErr
+		}
+		if cpErr := copyFile(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name()), uint64(fi.Size())); cpErr != nil {
+			return cpErr
+		}
+	}
+	return nil
+}
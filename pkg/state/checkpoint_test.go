@@ -0,0 +1,135 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/importer"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+)
+
+// applyTestBlocks applies MainNet blocks from test data until the state reaches the given height.
+func applyTestBlocks(t *testing.T, manager *stateManager, bs *settings.BlockchainSettings, height proto.Height) {
+	blocksPath, err := blocksPath()
+	require.NoError(t, err)
+	current, err := manager.Height()
+	require.NoError(t, err)
+	err = importer.ApplyFromFile(
+		context.Background(),
+		importer.ImportParams{Schema: bs.AddressSchemeCharacter, BlockchainPath: blocksPath, LightNodeMode: false},
+		manager, height-1, current)
+	require.NoError(t, err, "ApplyFromFile() failed")
+}
+
+func TestCheckpointWriteAndRestore(t *testing.T) {
+	bs := settings.MustMainNetSettings()
+	params := DefaultTestingStateParams()
+	params.BuildStateHashes = true
+	manager := newTestStateManager(t, true, params, bs)
+	applyTestBlocks(t, manager, bs, 100)
+
+	cp, err := manager.NewCheckpoint()
+	require.NoError(t, err)
+	defer cp.Release()
+	info := cp.Info()
+	assert.Equal(t, proto.Height(100), info.Height)
+	assert.True(t, info.StateHashes)
+	require.NotNil(t, info.LegacyStateHash)
+	require.NotNil(t, info.SnapshotStateHash)
+	expectedID, err := manager.HeightToBlockID(100)
+	require.NoError(t, err)
+	assert.Equal(t, expectedID, info.BlockID)
+
+	// State keeps going while the checkpoint is not written.
+	applyTestBlocks(t, manager, bs, 150)
+	height, err := manager.Height()
+	require.NoError(t, err)
+	require.Equal(t, proto.Height(150), height)
+	// Rollback above the checkpoint height doesn't affect it.
+	require.NoError(t, manager.RollbackToHeight(120))
+
+	cpDir := filepath.Join(t.TempDir(), "checkpoint")
+	require.NoError(t, cp.WriteTo(cpDir))
+	assert.Error(t, cp.WriteTo(cpDir), "writing to non-empty directory must fail")
+
+	dataDir := t.TempDir()
+	restored, err := RestoreCheckpoint(cpDir, dataDir, DefaultTestingStateParams(), bs)
+	require.NoError(t, err)
+	assert.Equal(t, info.Height, restored.Height)
+	assert.Equal(t, info.BlockID, restored.BlockID)
+
+	// Restored state can be opened and continues from the checkpoint height.
+	rs, err := newStateManager(dataDir, true, params, bs, false)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, rs.Close())
+	}()
+	height, err = rs.Height()
+	require.NoError(t, err)
+	assert.Equal(t, info.Height, height)
+	expectedBlock, err := manager.BlockByHeight(info.Height)
+	require.NoError(t, err)
+	block, err := rs.BlockByHeight(info.Height)
+	require.NoError(t, err)
+	assert.Equal(t, expectedBlock, block)
+	applyTestBlocks(t, rs, bs, 110)
+	expectedHash, err := manager.LegacyStateHashAtHeight(110)
+	require.NoError(t, err)
+	hash, err := rs.LegacyStateHashAtHeight(110)
+	require.NoError(t, err)
+	assert.Equal(t, expectedHash, hash)
+}
+
+func TestCheckpointInvalidatedByRollback(t *testing.T) {
+	bs := settings.MustMainNetSettings()
+	manager := newTestStateManager(t, true, DefaultTestingStateParams(), bs)
+	applyTestBlocks(t, manager, bs, 100)
+
+	cp, err := manager.NewCheckpoint()
+	require.NoError(t, err)
+	defer cp.Release()
+	require.NoError(t, manager.RollbackToHeight(50))
+
+	cpDir := t.TempDir()
+	assert.ErrorIs(t, cp.WriteTo(cpDir), ErrCheckpointInvalidated)
+	entries, err := os.ReadDir(cpDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRestoreCheckpointVerification(t *testing.T) {
+	bs := settings.MustMainNetSettings()
+	manager := newTestStateManager(t, true, DefaultTestingStateParams(), bs)
+	applyTestBlocks(t, manager, bs, 50)
+
+	cp, err := manager.NewCheckpoint()
+	require.NoError(t, err)
+	defer cp.Release()
+	require.Nil(t, cp.Info().LegacyStateHash)
+	require.NotNil(t, cp.Info().SnapshotStateHash)
+	cpDir := t.TempDir()
+	require.NoError(t, cp.WriteTo(cpDir))
+
+	// Tamper with the manifest.
+	info, err := ReadCheckpointInfo(cpDir)
+	require.NoError(t, err)
+	info.SnapshotStateHash = &crypto.Digest{}
+	data, err := json.Marshal(info)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(cpDir, checkpointManifestFile), data, 0600))
+
+	dataDir := t.TempDir()
+	_, err = RestoreCheckpoint(cpDir, dataDir, DefaultTestingStateParams(), bs)
+	assert.ErrorContains(t, err, "snapshot state hash")
+	entries, err := os.ReadDir(dataDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "restored files must be removed on failure")
+}
@@ -24,6 +24,11 @@ type stateInfo struct {
 	Amend              bool   `cbor:"1,keyasint,omitemtpy"`
 	HasExtendedApiData bool   `cbor:"2,keyasint,omitemtpy"`
 	HasStateHashes     bool   `cbor:"3,keyasint,omitemtpy"`
+	// HistoricalIndexHeight is the height since which the historical index is maintained, zero if it is not.
+	HistoricalIndexHeight uint64 `cbor:"4,keyasint,omitemtpy"`
+	HasAssetHolders       bool   `cbor:"5,keyasint,omitemtpy"`
+	// ArchivalHeight is the height since which the full history is kept, zero if archival mode is disabled.
+	ArchivalHeight uint64 `cbor:"6,keyasint,omitemtpy"`
 }
 
 func (inf *stateInfo) marshalBinary() ([]byte, error) {
@@ -46,6 +51,7 @@ func saveStateInfo(db keyvalue.KeyValue, params StateParams) error {
 		Version:            StateVersion,
 		HasExtendedApiData: params.StoreExtendedApiData,
 		HasStateHashes:     params.BuildStateHashes,
+		HasAssetHolders:    true,
 	}
 	return putStateInfoToDB(db, info)
 }
@@ -252,6 +258,16 @@ func (s *stateDB) rollback(removalEdge proto.BlockID) error {
 		}
 	}
 	s.setHeight(curHeight)
+	// Rollback below the rollback window is possible in archival mode, the window starts at the new height then.
+	rollbackMinHeight, err := s.getRollbackMinHeight()
+	if err != nil {
+		return err
+	}
+	if curHeight < rollbackMinHeight {
+		if err := s.setRollbackMinHeight(curHeight); err != nil {
+			return err
+		}
+	}
 	if err := s.rw.cleanIDs(removalEdge); err != nil {
 		return err
 	}
@@ -345,6 +361,58 @@ func (s *stateDB) updateAmendFlag(amend bool) error {
 	return putStateInfoToDB(s.db, &info)
 }
 
+func (s *stateDB) historicalIndexHeight() (uint64, error) {
+	info, err := s.stateInfo()
+	if err != nil {
+		return 0, err
+	}
+	return info.HistoricalIndexHeight, nil
+}
+
+func (s *stateDB) updateHistoricalIndexHeight(height uint64) error {
+	info, err := s.stateInfo()
+	if err != nil {
+		return err
+	}
+	info.HistoricalIndexHeight = height
+	return putStateInfoToDB(s.db, &info)
+}
+
+func (s *stateDB) archivalHeight() (uint64, error) {
+	info, err := s.stateInfo()
+	if err != nil {
+		return 0, err
+	}
+	return info.ArchivalHeight, nil
+}
+
+func (s *stateDB) updateArchivalHeight(height uint64) error {
+	info, err := s.stateInfo()
+	if err != nil {
+		return err
+	}
+	info.ArchivalHeight = height
+	return putStateInfoToDB(s.db, &info)
+}
+
+// stateHasAssetHolders indicates if the index of asset holders is built.
+func (s *stateDB) stateHasAssetHolders() (bool, error) {
+	info, err := s.stateInfo()
+	if err != nil {
+		return false, err
+	}
+	return info.HasAssetHolders, nil
+}
+
+func (s *stateDB) setAssetHoldersFlag() error {
+	info, err := s.stateInfo()
+	if err != nil {
+		return err
+	}
+	info.HasAssetHolders = true
+	return putStateInfoToDB(s.db, &info)
+}
+
 // stateStoresHashes indicates if state hashes must be stored.
 func (s *stateDB) stateStoresHashes() (bool, error) {
 	info, err := s.stateInfo()
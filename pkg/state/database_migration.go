@@ -0,0 +1,60 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/wavesplatform/gowaves/pkg/keyvalue"
+)
+
+const migrationSuffix = ".migrating"
+
+// MigrateDatabase copies the state database to the new database of the given backend and replaces the old one.
+// If keepOld is true, the old database is kept in the state directory under the name suffixed with its backend.
+// The state must not be opened during the migration. Interrupted copying leaves the old database intact,
+// so the migration can be restarted.
+func MigrateDatabase(dataDir string, backend keyvalue.Backend, keepOld bool) error {
+	dbDir := filepath.Join(dataDir, keyvalueDir)
+	current, err := keyvalue.DetectBackend(dbDir)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		return errors.Errorf("no state database found in '%s'", dataDir)
+	}
+	if current == backend {
+		zap.S().Infof("State database already uses %s backend", backend)
+		return nil
+	}
+	tmpDir := dbDir + migrationSuffix
+	if rmErr := os.RemoveAll(tmpDir); rmErr != nil {
+		return errors.Wrap(rmErr, "failed to remove leftovers of previous migration")
+	}
+	zap.S().Infof("Copying state database from %s to %s backend, it may take a while...", current, backend)
+	n, err := keyvalue.CopyDatabase(dbDir, tmpDir, backend)
+	if err != nil {
+		if rmErr := os.RemoveAll(tmpDir); rmErr != nil {
+			zap.S().Errorf("Failed to remove incomplete database '%s': %v", tmpDir, rmErr)
+		}
+		return errors.Wrap(err, "failed to copy database")
+	}
+	zap.S().Infof("Copied %d records", n)
+	oldDir := dbDir + "." + current.String()
+	if rnErr := os.Rename(dbDir, oldDir); rnErr != nil {
+		return errors.Wrap(rnErr, "failed to move old database")
+	}
+	if rnErr := os.Rename(tmpDir, dbDir); rnErr != nil {
+		return errors.Wrapf(rnErr, "failed to move new database, old database is left in '%s'", oldDir)
+	}
+	if keepOld {
+		zap.S().Infof("Old database is kept in '%s'", oldDir)
+		return nil
+	}
+	if rmErr := os.RemoveAll(oldDir); rmErr != nil {
+		return errors.Wrap(rmErr, "failed to remove old database")
+	}
+	return nil
+}
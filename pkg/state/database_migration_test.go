@@ -0,0 +1,52 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/keyvalue"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+)
+
+func TestMigrateDatabase(t *testing.T) {
+	bs := settings.MustMainNetSettings()
+	params := DefaultTestingStateParams()
+	params.BuildStateHashes = true
+	reference := newTestStateManager(t, true, params, bs)
+	applyTestBlocks(t, reference, bs, 100)
+
+	dataDir := t.TempDir()
+	params.DbParams.Backend = keyvalue.Badger
+	s, err := newStateManager(dataDir, true, params, bs, false)
+	require.NoError(t, err)
+	applyTestBlocks(t, s, bs, 50)
+	require.NoError(t, s.Close())
+
+	params.DbParams.Backend = ""
+	for _, backend := range []keyvalue.Backend{keyvalue.LevelDB, keyvalue.Badger} {
+		require.NoError(t, MigrateDatabase(dataDir, backend, false))
+		detected, dErr := keyvalue.DetectBackend(filepath.Join(dataDir, keyvalueDir))
+		require.NoError(t, dErr)
+		assert.Equal(t, backend, detected)
+	}
+	require.NoError(t, MigrateDatabase(dataDir, keyvalue.LevelDB, true))
+	assert.DirExists(t, filepath.Join(dataDir, keyvalueDir+".badger"))
+
+	s, err = newStateManager(dataDir, true, params, bs, false)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, s.Close())
+	}()
+	applyTestBlocks(t, s, bs, 100)
+	for _, h := range []proto.Height{50, 100} {
+		expected, shErr := reference.LegacyStateHashAtHeight(h)
+		require.NoError(t, shErr)
+		actual, shErr := s.LegacyStateHashAtHeight(h)
+		require.NoError(t, shErr)
+		assert.Equal(t, expected.SumHash, actual.SumHash)
+	}
+}
@@ -0,0 +1,98 @@
+package state
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+)
+
+// feeAffectingAssets returns the assets of transaction which scripts can require extra fee.
+// The list of assets for each transaction type is the same as in transactionChecker.
+// The second result is true if the assets should be considered smart without checking it in storage.
+func feeAffectingAssets(tx proto.Transaction) ([]proto.OptionalAsset, bool, error) {
+	switch t := tx.(type) {
+	case *proto.TransferWithSig:
+		return []proto.OptionalAsset{t.AmountAsset}, false, nil
+	case *proto.TransferWithProofs:
+		return []proto.OptionalAsset{t.AmountAsset}, false, nil
+	case *proto.ReissueWithSig:
+		return []proto.OptionalAsset{*proto.NewOptionalAssetFromDigest(t.AssetID)}, false, nil
+	case *proto.ReissueWithProofs:
+		return []proto.OptionalAsset{*proto.NewOptionalAssetFromDigest(t.AssetID)}, false, nil
+	case *proto.BurnWithSig:
+		return []proto.OptionalAsset{*proto.NewOptionalAssetFromDigest(t.AssetID)}, false, nil
+	case *proto.BurnWithProofs:
+		return []proto.OptionalAsset{*proto.NewOptionalAssetFromDigest(t.AssetID)}, false, nil
+	case *proto.MassTransferWithProofs:
+		return []proto.OptionalAsset{t.Asset}, false, nil
+	case *proto.UpdateAssetInfoWithProofs:
+		return []proto.OptionalAsset{*proto.NewOptionalAssetFromDigest(t.AssetID)}, false, nil
+	case *proto.SetAssetScriptWithProofs:
+		return []proto.OptionalAsset{*proto.NewOptionalAssetFromDigest(t.AssetID)}, true, nil
+	case *proto.InvokeScriptWithProofs:
+		assets := make([]proto.OptionalAsset, len(t.Payments))
+		for i := range t.Payments {
+			assets[i] = t.Payments[i].Asset
+		}
+		return assets, false, nil
+	case *proto.ExchangeWithSig, *proto.ExchangeWithProofs:
+		pair := tx.(proto.Exchange).GetOrder1().GetAssetPair()
+		if pair.AmountAsset == pair.PriceAsset {
+			return []proto.OptionalAsset{pair.AmountAsset}, false, nil
+		}
+		return []proto.OptionalAsset{pair.AmountAsset, pair.PriceAsset}, false, nil
+	case *proto.EthereumTransaction:
+		return nil, false, errors.New("fee calculation is not supported for Ethereum transactions")
+	default:
+		return nil, false, nil
+	}
+}
+
+// MinimalFee calculates the minimal fee of the transaction in Waves or, if the fee asset is present,
+// in the sponsored asset. Fee asset of the transaction itself is ignored.
+func (s *stateManager) MinimalFee(tx proto.Transaction, feeAsset proto.OptionalAsset) (uint64, error) {
+	assets, smart, err := feeAffectingAssets(tx)
+	if err != nil {
+		return 0, wrapErr(InvalidInputError, err)
+	}
+	var smartAssets []crypto.Digest
+	for _, asset := range assets {
+		if !asset.Present {
+			continue
+		}
+		if !smart {
+			scripted, sErr := s.stor.scriptsStorage.newestIsSmartAsset(proto.AssetIDFromDigest(asset.ID))
+			if sErr != nil {
+				return 0, wrapErr(RetrievalError, sErr)
+			}
+			if !scripted {
+				continue
+			}
+		}
+		smartAssets = append(smartAssets, asset.ID)
+	}
+	rideV5Activated, err := s.stor.features.newestIsActivated(int16(settings.RideV5))
+	if err != nil {
+		return 0, wrapErr(RetrievalError, err)
+	}
+	params := &feeValidationParams{
+		stor:            s.stor,
+		settings:        s.settings,
+		txAssets:        &txAssets{feeAsset: feeAsset, smartAssets: smartAssets},
+		rideV5Activated: rideV5Activated,
+	}
+	if !feeAsset.Present {
+		minWaves, mErr := minFeeInWaves(tx, params)
+		if mErr != nil {
+			return 0, wrapErr(InvalidInputError, mErr)
+		}
+		return minWaves.total, nil
+	}
+	minAsset, _, err := minFeeInAsset(tx, feeAsset.ID, params)
+	if err != nil {
+		return 0, wrapErr(InvalidInputError, err)
+	}
+	return minAsset, nil
+}
@@ -0,0 +1,47 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+func TestFeeAffectingAssets(t *testing.T) {
+	transfer := createTransferWithSig(t)
+	assets, smart, err := feeAffectingAssets(transfer)
+	require.NoError(t, err)
+	assert.False(t, smart)
+	assert.Equal(t, []proto.OptionalAsset{transfer.AmountAsset}, assets)
+
+	setAssetScript := createSetAssetScriptWithProofs(t)
+	assets, smart, err = feeAffectingAssets(setAssetScript)
+	require.NoError(t, err)
+	assert.True(t, smart)
+	assert.Equal(t, []proto.OptionalAsset{*proto.NewOptionalAssetFromDigest(setAssetScript.AssetID)}, assets)
+
+	payments := proto.ScriptPayments{
+		{Amount: 1, Asset: *proto.NewOptionalAssetFromDigest(testGlobal.asset0.asset.ID)},
+		{Amount: 1, Asset: proto.NewOptionalAssetWaves()},
+	}
+	invoke := createInvokeScriptWithProofs(t, payments, proto.FunctionCall{}, proto.NewOptionalAssetWaves(), FeeUnit)
+	assets, smart, err = feeAffectingAssets(invoke)
+	require.NoError(t, err)
+	assert.False(t, smart)
+	assert.Equal(t, []proto.OptionalAsset{payments[0].Asset, payments[1].Asset}, assets)
+
+	exchange := createExchangeWithProofs(t)
+	assets, _, err = feeAffectingAssets(exchange)
+	require.NoError(t, err)
+	pair := exchange.GetOrder1().GetAssetPair()
+	assert.Equal(t, []proto.OptionalAsset{pair.AmountAsset, pair.PriceAsset}, assets)
+
+	assets, _, err = feeAffectingAssets(createCreateAliasWithSig(t))
+	require.NoError(t, err)
+	assert.Empty(t, assets)
+
+	_, _, err = feeAffectingAssets(&proto.EthereumTransaction{})
+	assert.Error(t, err)
+}
@@ -13,7 +13,7 @@ import (
 )
 
 const (
-	scriptExtraFee = 400000
+	ScriptExtraFee = 400000
 	FeeUnit        = 100000
 
 	SetScriptTransactionV6Fee = 1
@@ -218,8 +218,8 @@ type txCosts struct {
 }
 
 func newTxCosts(smartAssets, smartAccounts uint64, isSmartAssetsFree, isSmartAccountFree bool) *txCosts {
-	smartAssetsFee := smartAssets * scriptExtraFee
-	smartAccountsFee := smartAccounts * scriptExtraFee
+	smartAssetsFee := smartAssets * ScriptExtraFee
+	smartAccountsFee := smartAccounts * ScriptExtraFee
 	if isSmartAssetsFree {
 		smartAssetsFee = 0
 	}
@@ -332,24 +332,33 @@ func checkMinFeeWaves(tx proto.Transaction, params *feeValidationParams) error {
 	return nil
 }
 
-func checkMinFeeAsset(tx proto.Transaction, feeAssetID crypto.Digest, params *feeValidationParams) error {
+// minFeeInAsset returns minimal fee in sponsored asset and the costs of the transaction in Waves.
+func minFeeInAsset(tx proto.Transaction, feeAssetID crypto.Digest, params *feeValidationParams) (uint64, *txCosts, error) {
 	shortFeeAssetID := proto.AssetIDFromDigest(feeAssetID)
 	isSponsored, err := params.stor.sponsoredAssets.newestIsSponsored(shortFeeAssetID)
 	if err != nil {
-		return errors.Errorf("newestIsSponsored: %v", err)
+		return 0, nil, errors.Errorf("newestIsSponsored: %v", err)
 	}
 	if !isSponsored {
-		return errs.NewTxValidationError(fmt.Sprintf("Asset %s is not sponsored, cannot be used to pay fees",
+		return 0, nil, errs.NewTxValidationError(fmt.Sprintf("Asset %s is not sponsored, cannot be used to pay fees",
 			feeAssetID.String(),
 		))
 	}
 	minWaves, err := minFeeInWaves(tx, params)
 	if err != nil {
-		return errors.Errorf("failed to calculate min fee in Waves: %v", err)
+		return 0, nil, errors.Errorf("failed to calculate min fee in Waves: %v", err)
 	}
 	minAsset, err := params.stor.sponsoredAssets.wavesToSponsoredAsset(shortFeeAssetID, minWaves.total)
 	if err != nil {
-		return errors.Errorf("wavesToSponsoredAsset() failed: %v", err)
+		return 0, nil, errors.Errorf("wavesToSponsoredAsset() failed: %v", err)
+	}
+	return minAsset, minWaves, nil
+}
+
+func checkMinFeeAsset(tx proto.Transaction, feeAssetID crypto.Digest, params *feeValidationParams) error {
+	minAsset, minWaves, err := minFeeInAsset(tx, feeAssetID, params)
+	if err != nil {
+		return err
 	}
 	fee := tx.GetFee()
 	if fee < minAsset {
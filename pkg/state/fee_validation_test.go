@@ -27,7 +27,7 @@ func TestAssetScriptExtraFee(t *testing.T) {
 	to.stor.createSmartAsset(t, tx.AssetID)
 
 	// This fee would be valid for simple Smart Account (without Smart asset).
-	tx.Fee = 1*FeeUnit + scriptExtraFee
+	tx.Fee = 1*FeeUnit + ScriptExtraFee
 	params := &feeValidationParams{
 		stor:            to.stor.entities,
 		settings:        settings.MustMainNetSettings(),
@@ -37,7 +37,7 @@ func TestAssetScriptExtraFee(t *testing.T) {
 	err = checkMinFeeWaves(tx, params) // it doesn't matter for these tests what version estimator is
 	assert.Error(t, err, "checkMinFeeWaves() did not fail with invalid Burn fee")
 	// One more extra fee for asset script must be added.
-	tx.Fee += scriptExtraFee
+	tx.Fee += ScriptExtraFee
 	err = checkMinFeeWaves(tx, params)
 	assert.NoError(t, err, "checkMinFeeWaves() failed with valid Burn fee")
 }
@@ -145,7 +145,7 @@ func TestAccountScriptExtraFee(t *testing.T) {
 	}
 	err = checkMinFeeWaves(tx, params)
 	assert.Error(t, err, "checkMinFeeWaves() did not fail with invalid Burn fee")
-	tx.Fee += scriptExtraFee
+	tx.Fee += ScriptExtraFee
 	err = checkMinFeeWaves(tx, params)
 	assert.NoError(t, err, "checkMinFeeWaves() failed with valid Burn fee")
 }
@@ -215,6 +215,14 @@ func TestCheckMinFeeAsset(t *testing.T) {
 	tx.Fee -= 1
 	err = checkMinFeeAsset(tx, tx.FeeAsset.ID, params)
 	assert.Error(t, err, "checkMinFeeAsset() did not fail with invalid Transfer transaction fee in asset")
+
+	minAsset, minWaves, err := minFeeInAsset(tx, tx.FeeAsset.ID, params)
+	require.NoError(t, err)
+	assert.Equal(t, assetCost, minAsset)
+	assert.Equal(t, uint64(FeeUnit), minWaves.total)
+
+	_, _, err = minFeeInAsset(tx, testGlobal.asset1.asset.ID, params)
+	assert.Error(t, err, "minFeeInAsset() did not fail with not sponsored asset")
 }
 
 func TestNFTMinFee(t *testing.T) {
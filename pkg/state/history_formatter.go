@@ -9,8 +9,11 @@ import (
 // from the beginning of the history.
 // `Filter` removes invalid blocks from the end of the history. Blocks become invalid when they are rolled back.
 // It simply looks at the list of valid blocks, and considers block as invalid if its unique number is not in this list.
+// In archival mode `cut` keeps all the entries since the start of archival mode.
 type historyFormatter struct {
 	db *stateDB
+	// archivalHeight is the height since which the full history is kept, zero if archival mode is disabled.
+	archivalHeight uint64
 }
 
 func newHistoryFormatter(db *stateDB) *historyFormatter {
@@ -45,13 +48,26 @@ func (hfmt *historyFormatter) filter(history *historyRecord) (bool, error) {
 	return changed, nil
 }
 
-func (hfmt *historyFormatter) calculateMinAcceptableBlockNum() (uint32, error) {
+// historyStartHeight returns the height since which histories are complete. It is the start of rollback window
+// or, in archival mode, the start of archival mode.
+func (hfmt *historyFormatter) historyStartHeight() (uint64, error) {
 	rollbackMinHeight, err := hfmt.db.getRollbackMinHeight()
 	if err != nil {
 		return 0, err
 	}
+	if hfmt.archivalHeight != 0 {
+		return min(hfmt.archivalHeight, rollbackMinHeight), nil
+	}
+	return rollbackMinHeight, nil
+}
+
+func (hfmt *historyFormatter) calculateMinAcceptableBlockNum() (uint32, error) {
+	startHeight, err := hfmt.historyStartHeight()
+	if err != nil {
+		return 0, err
+	}
 
-	minAcceptableBlockNum, err := hfmt.db.blockNumByHeight(rollbackMinHeight)
+	minAcceptableBlockNum, err := hfmt.db.blockNumByHeight(startHeight)
 	if err != nil {
 		return 0, err
 	}
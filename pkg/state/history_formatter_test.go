@@ -105,3 +105,44 @@ func TestNormalize(t *testing.T) {
 		t.Errorf("History formatter did not cut old blocks.")
 	}
 }
+
+func TestNormalizeArchival(t *testing.T) {
+	const archivalHeight = 1000
+	to := createHistory(t)
+	to.fmt.archivalHeight = archivalHeight
+
+	// Create history record and add blocks.
+	ids := genRandBlockIds(t, totalBlocks)
+	history := newHistoryRecord(alias)
+	for _, id := range ids {
+		to.stor.addBlock(t, id)
+		blockNum, err := to.stor.stateDB.newestBlockIdToNum(id)
+		assert.NoError(t, err, "blockIdToNum() failed")
+		entry := historyEntry{nil, blockNum}
+		err = history.appendEntry(entry)
+		assert.NoError(t, err, "appendEntry() failed")
+	}
+	to.stor.flush(t)
+	rollbackMinHeight, err := to.stor.stateDB.getRollbackMinHeight()
+	assert.NoError(t, err, "getRollbackMinHeight() failed")
+	assert.Greater(t, rollbackMinHeight, uint64(archivalHeight))
+
+	// Normalize and check that only the entries before archival height are cut.
+	changed, err := to.fmt.normalize(history, true)
+	assert.NoError(t, err, "normalize() failed")
+	assert.Equal(t, true, changed)
+	oldRecordNumber, archivalRecordNumber := 0, 0
+	for _, entry := range history.entries {
+		blockID, err := to.stor.stateDB.blockNumToId(entry.blockNum)
+		assert.NoError(t, err, "blockNumToId() failed")
+		entryHeight, err := to.stor.rw.newestHeightByBlockID(blockID)
+		assert.NoError(t, err, "newestHeightByBlockID failed")
+		if entryHeight < archivalHeight {
+			oldRecordNumber++
+		} else {
+			archivalRecordNumber++
+		}
+	}
+	assert.Equal(t, 1, oldRecordNumber)
+	assert.Equal(t, totalBlocks-archivalHeight+1, archivalRecordNumber)
+}
@@ -45,8 +45,19 @@ const (
 	snapshots
 	patches
 	challengedAddress
+	assetHolder
 )
 
+// historicalIndexEntities are the entities whose changes are kept in the historical index beyond
+// the rollback window.
+var historicalIndexEntities = map[blockchainEntity]struct{}{
+	wavesBalance: {},
+	assetBalance: {},
+	dataEntry:    {},
+}
+
+var errHistoryUnavailable = errors.New("history is not available at the requested height")
+
 type blockchainEntityProperties struct {
 	needToFilter bool
 	needToCut    bool
@@ -212,6 +223,12 @@ var properties = map[blockchainEntity]blockchainEntityProperties{
 		needToCut:    true,
 		fixedSize:    false,
 	},
+	assetHolder: {
+		needToFilter: true,
+		needToCut:    true,
+		fixedSize:    true,
+		recordSize:   assetHolderRecordSize + 4,
+	},
 }
 
 type historyEntry struct {
@@ -364,6 +381,16 @@ func (hr *historyRecord) appendEntry(entry historyEntry) error {
 	return nil
 }
 
+// lastEntryBefore returns the latest entry that is not newer than the given block.
+func (hr *historyRecord) lastEntryBefore(blockNum uint32) (historyEntry, bool) {
+	for i := len(hr.entries) - 1; i >= 0; i-- {
+		if hr.entries[i].blockNum <= blockNum {
+			return hr.entries[i], true
+		}
+	}
+	return historyEntry{}, false
+}
+
 func (hr *historyRecord) topEntry() (historyEntry, error) {
 	if len(hr.entries) == 0 {
 		return historyEntry{}, errEmptyHist
@@ -499,6 +526,8 @@ type historyStorage struct {
 	amend     bool // if true, the records will be filtered which is important after rollback
 	stor      *localHistoryStorage
 	fmt       *historyFormatter
+	// indexHeight is the height since which the historical index is maintained, zero if the index is disabled.
+	indexHeight uint64
 }
 
 func newHistoryStorage(
@@ -520,6 +549,17 @@ func newHistoryStorage(
 	}
 }
 
+// enableHistoricalIndex turns on the historical index of balances and data entries that is maintained
+// since the given height.
+func (hs *historyStorage) enableHistoricalIndex(height uint64) {
+	hs.indexHeight = height
+}
+
+// enableArchivalMode turns off cutting of histories since the given height, zero height disables archival mode.
+func (hs *historyStorage) enableArchivalMode(height uint64) {
+	hs.fmt.archivalHeight = height
+}
+
 func (hs *historyStorage) newTopEntryIteratorByPrefix(prefix []byte) (*topEntryIterator, error) {
 	dbIter, err := hs.db.NewKeyIterator(prefix)
 	if err != nil {
@@ -528,6 +568,16 @@ func (hs *historyStorage) newTopEntryIteratorByPrefix(prefix []byte) (*topEntryI
 	return &topEntryIterator{dbIter: dbIter, fmt: hs.fmt, amend: hs.amend}, nil
 }
 
+// newTopEntryIteratorFrom returns the iterator over the top entries of the keys with the prefix
+// starting from the start key.
+func (hs *historyStorage) newTopEntryIteratorFrom(prefix, start []byte) (*topEntryIterator, error) {
+	dbIter, err := hs.db.NewKeyIteratorFrom(prefix, start)
+	if err != nil {
+		return nil, err
+	}
+	return &topEntryIterator{dbIter: dbIter, fmt: hs.fmt, amend: hs.amend}, nil
+}
+
 func (hs *historyStorage) newTopEntryIterator(entity blockchainEntity) (*topEntryIterator, error) {
 	prefix, err := prefixByEntity(entity)
 	if err != nil {
@@ -727,6 +777,88 @@ func (hs *historyStorage) entryDataAtHeight(key []byte, height uint64) ([]byte,
 	return hs.entryDataWithHeightFilter(key, height, cmp)
 }
 
+// historicalEntryDataAtHeight() returns bytes of the entry that was actual at the given height.
+// The result is exact within the rollback window or since the start of archival mode, beyond it the historical index
+// is used if it is maintained since the given height for the entity, otherwise errHistoryUnavailable is returned.
+// Errors keyvalue.ErrNotFound or errEmptyHist mean that the entry did not exist at the height.
+func (hs *historyStorage) historicalEntryDataAtHeight(
+	key []byte, entity blockchainEntity, height uint64,
+) ([]byte, error) {
+	limitBlockNum, err := hs.stateDB.blockNumByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	history, err := hs.getHistory(key, false)
+	if err != nil && !isNotFoundInHistoryOrDBErr(err) {
+		return nil, err
+	}
+	if history != nil {
+		// Cut removes only the oldest entries, so the found entry is the actual one for the height.
+		if entry, ok := history.lastEntryBefore(limitBlockNum); ok {
+			return entry.data, nil
+		}
+	}
+	startHeight, err := hs.fmt.historyStartHeight()
+	if err != nil {
+		return nil, err
+	}
+	if height >= startHeight {
+		// History always keeps the last entry before its start, in archival mode it is the full history.
+		return nil, keyvalue.ErrNotFound
+	}
+	if _, ok := historicalIndexEntities[entity]; !ok || hs.indexHeight == 0 || height < hs.indexHeight {
+		return nil, errHistoryUnavailable
+	}
+	return hs.historicalIndexEntryData(key, limitBlockNum)
+}
+
+// historicalIndexEntryData looks for the latest entry of the historical index that is not newer than the given
+// block. Entries of the rolled back blocks are skipped.
+func (hs *historyStorage) historicalIndexEntryData(key []byte, limitBlockNum uint32) ([]byte, error) {
+	ik := historicalIndexKey{entityKey: key}
+	prefix := ik.prefix()
+	iter, err := hs.db.NewKeyIterator(prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Release()
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		k := iter.Key()
+		if len(k) != len(prefix)+4 { // Key of other entity with the same prefix
+			continue
+		}
+		blockNum := binary.BigEndian.Uint32(k[len(prefix):])
+		if blockNum > limitBlockNum {
+			continue
+		}
+		valid, vErr := hs.stateDB.isValidBlock(blockNum)
+		if vErr != nil {
+			return nil, vErr
+		}
+		if !valid {
+			continue
+		}
+		return keyvalue.SafeValue(iter), nil
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return nil, keyvalue.ErrNotFound
+}
+
+// putToHistoricalIndex writes to the historical index the new entries of combined history
+// along with the previous top entry, so the index always knows the value before the first indexed change.
+func (hs *historyStorage) putToHistoricalIndex(key []byte, history *historyRecord, newEntries int) {
+	if _, ok := historicalIndexEntities[history.entityType]; !ok {
+		return
+	}
+	start := max(len(history.entries)-newEntries-1, 0)
+	for _, e := range history.entries[start:] {
+		ik := historicalIndexKey{entityKey: key, blockNum: e.blockNum}
+		hs.dbBatch.Put(ik.bytes(), e.data)
+	}
+}
+
 // blockRangeEntries() returns list of entries corresponding to given block interval.
 // IMPORTANTLY, it does not simply return list of entries with block nums between startBlockNum and endBlockNum,
 // instead this function returns values which are relevant for this block range.
@@ -797,10 +929,14 @@ func (hs *historyStorage) flush() error {
 	entries := hs.stor.getEntries()
 	sortEntries(entries)
 	for _, entry := range entries {
+		newEntries := len(entry.value.entries)
 		newEntry, err := hs.combineHistories(entry.key, entry.value)
 		if err != nil {
 			return err
 		}
+		if hs.indexHeight != 0 {
+			hs.putToHistoricalIndex(entry.key, newEntry, newEntries)
+		}
 		newEntryBytes, err := newEntry.marshalBinary()
 		if err != nil {
 			return err
@@ -1130,7 +1130,7 @@ func (ia *invokeApplier) checkFullFee(tx proto.Transaction, scriptRuns, issuedAs
 		return nil
 	}
 	minIssueFee := feeConstants[proto.IssueTransaction] * FeeUnit * issuedAssetsCount
-	minWavesFee := scriptExtraFee*scriptRuns + feeConstants[proto.InvokeScriptTransaction]*FeeUnit + minIssueFee
+	minWavesFee := ScriptExtraFee*scriptRuns + feeConstants[proto.InvokeScriptTransaction]*FeeUnit + minIssueFee
 
 	wavesFee := tx.GetFee()
 
@@ -18,6 +18,7 @@ const (
 
 	wavesBalanceKeySize      = 1 + proto.AddressIDSize
 	assetBalanceKeySize      = 1 + proto.AddressIDSize + proto.AssetIDSize
+	assetHolderKeySize       = 1 + proto.AssetIDSize + proto.AddressIDSize
 	leaseKeySize             = 1 + crypto.DigestSize
 	aliasKeySize             = 1 + 2 + proto.AliasMaxLength
 	addressToAliasesKeySize  = 1 + proto.AddressIDSize
@@ -135,6 +136,12 @@ const (
 	patchKeyPrefix
 
 	challengedAddressKeyPrefix
+
+	// Historical index of balances and data entries.
+	historicalIndexKeyPrefix
+
+	// Holders of assets.
+	assetHolderKeyPrefix
 )
 
 var (
@@ -200,11 +207,46 @@ func prefixByEntity(entity blockchainEntity) ([]byte, error) {
 		return []byte{patchKeyPrefix}, nil
 	case challengedAddress:
 		return []byte{challengedAddressKeyPrefix}, nil
+	case assetHolder:
+		return []byte{assetHolderKeyPrefix}, nil
 	default:
 		return nil, errors.New("bad entity type")
 	}
 }
 
+// assetHolderKey is the key of the asset holders index, asset goes first to iterate over the holders of asset.
+type assetHolderKey struct {
+	asset   proto.AssetID
+	address proto.AddressID
+}
+
+func (k *assetHolderKey) assetPrefix() []byte {
+	buf := make([]byte, 1+proto.AssetIDSize)
+	buf[0] = assetHolderKeyPrefix
+	copy(buf[1:], k.asset[:])
+	return buf
+}
+
+func (k *assetHolderKey) bytes() []byte {
+	buf := make([]byte, assetHolderKeySize)
+	buf[0] = assetHolderKeyPrefix
+	copy(buf[1:], k.asset[:])
+	copy(buf[1+proto.AssetIDSize:], k.address[:])
+	return buf
+}
+
+func (k *assetHolderKey) unmarshal(data []byte) error {
+	if len(data) != assetHolderKeySize {
+		return errInvalidDataSize
+	}
+	if data[0] != assetHolderKeyPrefix {
+		return errInvalidPrefix
+	}
+	copy(k.asset[:], data[1:1+proto.AssetIDSize])
+	copy(k.address[:], data[1+proto.AssetIDSize:])
+	return nil
+}
+
 type wavesBalanceKey struct {
 	address proto.AddressID
 }
@@ -761,3 +803,21 @@ func (k *challengedAddressKey) bytes() []byte {
 	copy(buf[1:], k.address[:])
 	return buf
 }
+
+// historicalIndexKey is the key of entity's data in the historical index. Block number is stored in big endian
+// to keep the records of entity sorted in the order of blocks.
+type historicalIndexKey struct {
+	entityKey []byte
+	blockNum  uint32
+}
+
+func (k *historicalIndexKey) prefix() []byte {
+	buf := make([]byte, 1+len(k.entityKey))
+	buf[0] = historicalIndexKeyPrefix
+	copy(buf[1:], k.entityKey)
+	return buf
+}
+
+func (k *historicalIndexKey) bytes() []byte {
+	return binary.BigEndian.AppendUint32(k.prefix(), k.blockNum)
+}
@@ -0,0 +1,14 @@
+package state
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var metricScriptCompilationFailures = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "ride_script_compilation_failures",
+		Help: "The number of scripts failed to compile to bytecode and executed by the tree evaluator instead.",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(metricScriptCompilationFailures)
+}
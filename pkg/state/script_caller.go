@@ -1,8 +1,11 @@
 package state
 
 import (
+	"sync"
+
 	"github.com/mr-tron/base58/base58"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 
 	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/errs"
@@ -13,12 +16,22 @@ import (
 	"github.com/wavesplatform/gowaves/pkg/types"
 )
 
+const (
+	maxProgramsCacheSize = 10000
+	// Programs are not weighed, so we use 1 per each.
+	programSize = 1
+)
+
 type scriptCaller struct {
 	state types.EnrichedSmartState
 
 	stor     *blockchainEntitiesStorage
 	settings *settings.BlockchainSettings
 
+	engine     ride.Engine
+	programsMu sync.Mutex
+	programs   *lru[compiledProgram] // Compiled scripts by digest of script bytes
+
 	totalComplexity    uint64
 	recentTxComplexity uint64
 }
@@ -27,14 +40,84 @@ func newScriptCaller(
 	state types.EnrichedSmartState,
 	stor *blockchainEntitiesStorage,
 	settings *settings.BlockchainSettings,
+	engine ride.Engine,
 ) (*scriptCaller, error) {
+	programs, err := newLru[compiledProgram](maxProgramsCacheSize, maxProgramsCacheSize*programSize)
+	if err != nil {
+		return nil, err
+	}
 	return &scriptCaller{
 		state:    state,
 		stor:     stor,
 		settings: settings,
+		engine:   engine,
+		programs: programs,
 	}, nil
 }
 
+// compiledProgram is the result of script compilation.
+type compiledProgram struct {
+	script ride.RideScript
+	err    error
+}
+
+// program returns the compiled script. Programs are cached by the digest of script bytes, so the cache
+// is never invalidated: a changed script has another digest. Compilation errors are cached as well.
+func (a *scriptCaller) program(tree *ast.Tree) (ride.RideScript, error) {
+	if tree.Digest == [32]byte{} { // Tree was built not from script bytes
+		return nil, nil
+	}
+	a.programsMu.Lock()
+	defer a.programsMu.Unlock()
+	if p, ok := a.programs.get(tree.Digest[:]); ok {
+		return p.script, p.err
+	}
+	p, err := ride.Compile(tree)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to compile script with digest '%s'", base58.Encode(tree.Digest[:]))
+	}
+	a.programs.set(tree.Digest[:], compiledProgram{script: p, err: err}, programSize)
+	return p, err
+}
+
+// vmProgram returns the compiled script if VM engine is selected. Nil is returned for the scripts that
+// can't be compiled, they are executed by the tree evaluator. Such failures are logged and counted,
+// because the VM must be able to compile any valid script.
+func (a *scriptCaller) vmProgram(tree *ast.Tree) ride.RideScript {
+	if a.engine != ride.VMEngine {
+		return nil
+	}
+	p, err := a.program(tree)
+	if err != nil {
+		metricScriptCompilationFailures.Inc()
+		zap.S().Errorf("Script is executed by tree evaluator instead of VM: %v", err)
+		return nil
+	}
+	return p
+}
+
+// callVerifier executes the verifier of the script with the selected engine.
+func (a *scriptCaller) callVerifier(env *ride.EvaluationEnvironment, tree *ast.Tree) (ride.Result, error) {
+	if p := a.vmProgram(tree); p != nil {
+		return ride.RunVerifier(env, p)
+	}
+	return ride.CallVerifier(env, tree)
+}
+
+// callFunction executes the callable function of the DApp with the selected engine.
+// DApps invoked from the script are executed with the same engine.
+func (a *scriptCaller) callFunction(
+	env *ride.EvaluationEnvironment, tree *ast.Tree, fc proto.FunctionCall,
+) (ride.Result, error) {
+	if a.engine == ride.VMEngine {
+		env.SetPrograms(a.vmProgram)
+	}
+	if p := a.vmProgram(tree); p != nil {
+		return ride.RunFunction(env, p, fc)
+	}
+	return ride.CallFunction(env, tree, fc)
+}
+
 // callAccountScriptWithOrder calls account script. This method must not be called for proto.EthereumAddress.
 func (a *scriptCaller) callAccountScriptWithOrder(order proto.Order, lastBlockInfo *proto.BlockInfo, info *fallibleValidationParams) error {
 	senderAddr, err := order.GetSender(a.settings.AddressSchemeCharacter)
@@ -78,7 +161,7 @@ func (a *scriptCaller) callAccountScriptWithOrder(order proto.Order, lastBlockIn
 	if err = env.SetTransactionFromOrder(order, tree.LibVersion); err != nil {
 		return errors.Wrap(err, "failed to convert order")
 	}
-	r, err := ride.CallVerifier(env, tree)
+	r, err := a.callVerifier(env, tree)
 	if err != nil {
 		return errors.Errorf("account script on order '%s' thrown error with message: %s", base58.Encode(id), err.Error())
 	}
@@ -142,7 +225,7 @@ func (a *scriptCaller) callAccountScriptWithTx(tx proto.Transaction, params *app
 	if err := env.SetTransaction(tx); err != nil {
 		return errors.Wrapf(err, "failed to call account script on transaction '%s'", base58.Encode(id))
 	}
-	r, err := ride.CallVerifier(env, tree)
+	r, err := a.callVerifier(env, tree)
 	if err != nil {
 		return errors.Errorf("account script on transaction '%s' failed with error: %v", base58.Encode(id), err.Error())
 	}
@@ -195,7 +278,7 @@ func (a *scriptCaller) callAssetScriptCommon(env *ride.EvaluationEnvironment, se
 	if err := env.SetLastBlockFromBlockInfo(params.blockInfo); err != nil {
 		return nil, err
 	}
-	r, err := ride.CallVerifier(env, tree)
+	r, err := a.callVerifier(env, tree)
 	if err != nil {
 		return nil, errs.NewTransactionNotAllowedByScript(err.Error(), assetID.Bytes())
 	}
@@ -439,7 +522,7 @@ func (a *scriptCaller) invokeFunctionByInvokeWithProofsTx(
 
 	functionCall := tx.FunctionCall
 
-	r, err := ride.CallFunction(env, tree, functionCall)
+	r, err := a.callFunction(env, tree, functionCall)
 	if err != nil {
 		complexity := ride.EvaluationErrorSpentComplexity(err)
 		appendErr := a.appendFunctionComplexity(complexity, scriptAddress, scriptEstimationUpdate, functionCall, info)
@@ -496,7 +579,7 @@ func (a *scriptCaller) invokeFunctionByEthereumTx(
 	}
 	functionCall := proto.NewFunctionCall(decodedData.Name, arguments)
 
-	r, err := ride.CallFunction(env, tree, functionCall)
+	r, err := a.callFunction(env, tree, functionCall)
 	if err != nil {
 		complexity := ride.EvaluationErrorSpentComplexity(err)
 		appendErr := a.appendFunctionComplexity(complexity, scriptAddress, scriptEstimationUpdate, functionCall, info)
@@ -534,7 +617,7 @@ func (a *scriptCaller) invokeFunctionByInvokeExpressionWithProofsTx(
 		}
 	}
 
-	r, err := ride.CallVerifier(env, tree)
+	r, err := a.callVerifier(env, tree)
 	if err != nil {
 		complexity := ride.EvaluationErrorSpentComplexity(err)
 		appendErr := a.appendFunctionComplexity(complexity, scriptAddress, scriptEstimationUpdate, functionCall, info)
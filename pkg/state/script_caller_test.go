@@ -0,0 +1,66 @@
+package state
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/ride"
+	"github.com/wavesplatform/gowaves/pkg/ride/serialization"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+)
+
+func TestScriptCallerProgramsCache(t *testing.T) {
+	sc, err := newScriptCaller(nil, nil, settings.MustMainNetSettings(), ride.VMEngine)
+	require.NoError(t, err)
+
+	tree, err := serialization.Parse(testGlobal.scriptBytes)
+	require.NoError(t, err)
+	p1, err := sc.program(tree)
+	require.NoError(t, err)
+	require.NotNil(t, p1)
+	assert.Equal(t, tree.LibVersion, p1.LibraryVersion())
+
+	// The same script parsed again is taken from cache.
+	other, err := serialization.Parse(testGlobal.scriptBytes)
+	require.NoError(t, err)
+	p2, err := sc.program(other)
+	require.NoError(t, err)
+	assert.Same(t, p1, p2)
+
+	// Trees that are not built from script bytes are not compiled.
+	tree.Digest = [32]byte{}
+	p, err := sc.program(tree)
+	require.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func compilationFailures(t *testing.T) float64 {
+	var m dto.Metric
+	require.NoError(t, metricScriptCompilationFailures.Write(&m))
+	return m.GetCounter().GetValue()
+}
+
+func TestScriptCallerCompilationFailure(t *testing.T) {
+	sc, err := newScriptCaller(nil, nil, settings.MustMainNetSettings(), ride.VMEngine)
+	require.NoError(t, err)
+	tree, err := serialization.Parse(testGlobal.scriptBytes)
+	require.NoError(t, err)
+	tree.LibVersion = 0 // Invalid version fails compilation.
+
+	// Failure is reported every time the script is executed, not only on the first compilation.
+	before := compilationFailures(t)
+	for range 2 {
+		_, err = sc.program(tree)
+		assert.ErrorContains(t, err, "failed to compile script")
+		assert.Nil(t, sc.vmProgram(tree))
+	}
+	assert.Equal(t, before+2, compilationFailures(t))
+
+	// Tree evaluator doesn't compile scripts.
+	sc.engine = ride.TreeEngine
+	assert.Nil(t, sc.vmProgram(tree))
+	assert.Equal(t, before+2, compilationFailures(t))
+}
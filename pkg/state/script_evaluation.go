@@ -0,0 +1,123 @@
+package state
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/ride"
+	"github.com/wavesplatform/gowaves/pkg/ride/ast"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+)
+
+// readOnlyInvokeFee is the fee of the synthetic Invoke transaction used to call a function of dApp in read-only mode.
+const readOnlyInvokeFee = 500000
+
+// newReadOnlyEnvironment creates RIDE environment for evaluation in the context of the script of the given account
+// on top of the newest state, an empty expression script is used for account without script. Evaluation never
+// alters the state, all changes made by the script are collected in the wrapped state of the environment and
+// discarded after evaluation.
+func (s *stateManager) newReadOnlyEnvironment(
+	account proto.WavesAddress,
+) (*ride.EvaluationEnvironment, *ast.Tree, *proto.BlockInfo, error) {
+	tree, err := s.stor.scriptsStorage.newestScriptByAddr(account)
+	switch {
+	case isNotFoundInHistoryOrDBErr(err) || errors.Is(err, proto.ErrNotFound):
+		// Account without script, the evaluation is done in the empty context of the newest library version.
+		tree = ast.NewTree(ast.ContentTypeExpression, ast.CurrentMaxLibraryVersion())
+	case err != nil:
+		return nil, nil, nil, errors.Wrapf(err, "failed to get script of account '%s'", account.String())
+	}
+	activated := make(map[settings.Feature]bool)
+	for _, f := range []settings.Feature{
+		settings.BlockV5, settings.RideV5, settings.RideV6, settings.ConsensusImprovements,
+		settings.BlockRewardDistribution, settings.LightNode,
+	} {
+		ok, fErr := s.stor.features.newestIsActivated(int16(f))
+		if fErr != nil {
+			return nil, nil, nil, errors.Wrapf(fErr, "failed to check activation of feature %d", f)
+		}
+		activated[f] = ok
+	}
+	env, err := ride.NewEnvironment(
+		s.settings.AddressSchemeCharacter,
+		s,
+		s.settings.InternalInvokePaymentsValidationAfterHeight,
+		s.settings.PaymentsFixAfterHeight,
+		activated[settings.BlockV5],
+		activated[settings.RideV6],
+		activated[settings.ConsensusImprovements],
+		activated[settings.BlockRewardDistribution],
+		activated[settings.LightNode],
+	)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to create RIDE environment")
+	}
+	height, err := s.Height()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	blockInfo, err := s.NewestBlockInfoByHeight(height)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to get last block info")
+	}
+	env.SetThisFromAddress(account)
+	env.ChooseSizeCheck(tree.LibVersion)
+	if err := env.SetLastBlockFromBlockInfo(blockInfo); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to create RIDE environment")
+	}
+	env.SetTimestamp(blockInfo.Timestamp)
+	env.ChooseTakeString(activated[settings.RideV5])
+	env.ChooseMaxDataEntriesSize(activated[settings.RideV5])
+	limit, err := ride.MaxChainInvokeComplexityByVersion(tree.LibVersion)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to set complexity limit")
+	}
+	env.SetLimit(limit)
+	return env, tree, blockInfo, nil
+}
+
+// EvaluateExpression evaluates the expression in the scope of global declarations of the account script.
+func (s *stateManager) EvaluateExpression(account proto.WavesAddress, expr ast.Node) (ride.Result, error) {
+	env, tree, _, err := s.newReadOnlyEnvironment(account)
+	if err != nil {
+		return nil, err
+	}
+	if tree.LibVersion >= ast.LibV5 {
+		env, err = ride.NewEnvironmentWithWrappedState(env, s, nil, account, true, tree.LibVersion, false)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create RIDE environment with wrapped state")
+		}
+	}
+	return ride.EvaluateExpression(env, tree, expr)
+}
+
+// CallFunctionReadOnly calls the callable function of dApp on behalf of dApp itself without payments.
+// Resulting actions are returned but not applied to the state.
+func (s *stateManager) CallFunctionReadOnly(dApp proto.WavesAddress, fc proto.FunctionCall) (ride.Result, error) {
+	env, tree, blockInfo, err := s.newReadOnlyEnvironment(dApp)
+	if err != nil {
+		return nil, err
+	}
+	if !tree.IsDApp() {
+		return nil, errors.Errorf("script of account '%s' is not a dApp", dApp.String())
+	}
+	pk, err := s.NewestScriptPKByAddr(dApp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get public key of dApp '%s'", dApp.String())
+	}
+	tx := proto.NewUnsignedInvokeScriptWithProofs(2, pk, proto.NewRecipientFromAddress(dApp), fc, nil,
+		proto.NewOptionalAssetWaves(), readOnlyInvokeFee, blockInfo.Timestamp)
+	if err := env.SetTransaction(tx); err != nil {
+		return nil, errors.Wrap(err, "failed to set transaction")
+	}
+	if err := env.SetInvoke(tx, tree.LibVersion); err != nil {
+		return nil, errors.Wrap(err, "failed to set invocation")
+	}
+	if tree.LibVersion >= ast.LibV5 {
+		env, err = ride.NewEnvironmentWithWrappedState(env, s, nil, dApp, true, tree.LibVersion, false)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create RIDE environment with wrapped state")
+		}
+	}
+	return ride.CallFunction(env, tree, fc)
+}
@@ -2,38 +2,35 @@ package state
 
 import (
 	"github.com/pkg/errors"
-	"github.com/wavesplatform/gowaves/pkg/ride/ast"
 )
 
-type element struct {
+type element[V any] struct {
 	key        string
-	value      ast.Tree
-	prev, next *element
+	value      V
+	prev, next *element[V]
 	bytes      uint64
 }
 
-var defaultValue ast.Tree
-
-type lru struct {
+type lru[V any] struct {
 	maxSize, maxBytes, size, bytesUsed uint64
 
-	m              map[string]*element
-	newest, oldest *element
-	removed        *element // Created in del(), removed in set().
+	m              map[string]*element[V]
+	newest, oldest *element[V]
+	removed        *element[V] // Created in del(), removed in set().
 }
 
-func newLru(maxSize, maxBytes uint64) (*lru, error) {
+func newLru[V any](maxSize, maxBytes uint64) (*lru[V], error) {
 	if maxSize == 0 || maxBytes == 0 {
 		return nil, errors.Errorf("cache size must be > 0")
 	}
-	return &lru{
+	return &lru[V]{
 		maxSize:  maxSize,
 		maxBytes: maxBytes,
-		m:        make(map[string]*element),
+		m:        make(map[string]*element[V]),
 	}, nil
 }
 
-func (l *lru) cut(e *element) {
+func (l *lru[V]) cut(e *element[V]) {
 	prev := e.prev
 	next := e.next
 	e.prev = nil
@@ -56,7 +53,7 @@ func (l *lru) cut(e *element) {
 	}
 }
 
-func (l *lru) setNewest(e *element) {
+func (l *lru[V]) setNewest(e *element[V]) {
 	if l.newest == nil {
 		l.newest = e
 		l.oldest = e
@@ -67,23 +64,24 @@ func (l *lru) setNewest(e *element) {
 	}
 }
 
-func (l *lru) del(e *element) {
+func (l *lru[V]) del(e *element[V]) {
 	delete(l.m, e.key)
 	l.cut(e)
 	l.size -= 1
 	l.bytesUsed -= e.bytes
-	e.value = defaultValue
+	var zero V
+	e.value = zero
 	l.removed = e
 }
 
-func (l *lru) makeFreeSpace(bytes uint64) {
+func (l *lru[V]) makeFreeSpace(bytes uint64) {
 	for l.size+1 > l.maxSize || (l.size > 0 && l.bytesUsed+bytes > l.maxBytes) {
 		l.del(l.oldest)
 	}
 }
 
-func (l *lru) get(key []byte) (value ast.Tree, has bool) {
-	var e *element
+func (l *lru[V]) get(key []byte) (value V, has bool) {
+	var e *element[V]
 	e, has = l.m[string(key)]
 	if !has {
 		return
@@ -93,7 +91,7 @@ func (l *lru) get(key []byte) (value ast.Tree, has bool) {
 	return e.value, true
 }
 
-func (l *lru) set(key []byte, value ast.Tree, bytes uint64) (existed bool) {
+func (l *lru[V]) set(key []byte, value V, bytes uint64) (existed bool) {
 	keyStr := string(key)
 	e, has := l.m[keyStr]
 	if has {
@@ -102,7 +100,7 @@ func (l *lru) set(key []byte, value ast.Tree, bytes uint64) (existed bool) {
 	l.makeFreeSpace(bytes)
 	e = l.removed
 	if e == nil {
-		e = &element{}
+		e = &element[V]{}
 	}
 	e.key = keyStr
 	e.value = value
@@ -115,7 +113,7 @@ func (l *lru) set(key []byte, value ast.Tree, bytes uint64) (existed bool) {
 	return has
 }
 
-func (l *lru) deleteIfExists(key []byte) (existed bool) {
+func (l *lru[V]) deleteIfExists(key []byte) (existed bool) {
 	e, has := l.m[string(key)]
 	if has {
 		l.del(e)
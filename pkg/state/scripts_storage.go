@@ -176,7 +176,7 @@ type assetScriptRecordWithAssetIDTail struct {
 // which makes it inefficient.
 type scriptsStorage struct {
 	hs    *historyStorage
-	cache *lru
+	cache *lru[ast.Tree]
 
 	accountScriptsHasher *stateHasher
 	assetScriptsHasher   *stateHasher
@@ -187,7 +187,7 @@ type scriptsStorage struct {
 }
 
 func newScriptsStorage(hs *historyStorage, scheme proto.Scheme, calcHashes bool) (*scriptsStorage, error) {
-	cache, err := newLru(maxCacheSize, maxCacheBytes)
+	cache, err := newLru[ast.Tree](maxCacheSize, maxCacheBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -397,6 +397,20 @@ func (ss *scriptsStorage) newestScriptBytesByAddr(addr proto.WavesAddress) (prot
 	return ss.newestScriptBytesByKey(key.bytes())
 }
 
+// accountScriptBytesAtHeight returns the script of the account that was actual at the given height.
+// Empty script means that the account had no script. Account scripts are not kept in the historical index,
+// so the history is available only within the rollback window.
+func (ss *scriptsStorage) accountScriptBytesAtHeight(addr proto.WavesAddress, height proto.Height) (proto.Script, error) {
+	key := accountScriptKey{addr.ID()}
+	script, err := ss.hs.historicalEntryDataAtHeight(key.bytes(), accountScript, height)
+	if isNotFoundInHistoryOrDBErr(err) {
+		return proto.Script{}, nil
+	} else if err != nil {
+		return proto.Script{}, err
+	}
+	return script, nil
+}
+
 func (ss *scriptsStorage) setAccountScript(addr proto.WavesAddress, script proto.Script, pk crypto.PublicKey, blockID proto.BlockID) error {
 	key := accountScriptKey{addr.ID()}
 	if ss.calculateHashes {
@@ -588,7 +602,7 @@ func (ss *scriptsStorage) scriptBytesByAddr(addr proto.WavesAddress) (proto.Scri
 
 func (ss *scriptsStorage) clearCache() error {
 	var err error
-	ss.cache, err = newLru(maxCacheSize, maxCacheBytes)
+	ss.cache, err = newLru[ast.Tree](maxCacheSize, maxCacheBytes)
 	return err
 }
 
@@ -20,6 +20,7 @@ type scriptStorageState interface {
 	scriptBytesByAsset(assetID proto.AssetID) (proto.Script, error)
 	newestScriptBytesByAsset(assetID proto.AssetID) (proto.Script, error)
 	newestScriptBytesByAddr(addr proto.WavesAddress) (proto.Script, error)
+	accountScriptBytesAtHeight(addr proto.WavesAddress, height proto.Height) (proto.Script, error)
 	setAccountScript(addr proto.WavesAddress, script proto.Script, pk crypto.PublicKey, blockID proto.BlockID) error
 	newestAccountIsDApp(addr proto.WavesAddress) (bool, error)
 	accountIsDApp(addr proto.WavesAddress) (bool, error)
@@ -29,6 +29,9 @@ var _ scriptStorageState = &mockScriptStorageState{}
 //			accountIsDAppFunc: func(addr proto.WavesAddress) (bool, error) {
 //				panic("mock out the accountIsDApp method")
 //			},
+//			accountScriptBytesAtHeightFunc: func(addr proto.WavesAddress, height proto.Height) (proto.Script, error) {
+//				panic("mock out the accountScriptBytesAtHeight method")
+//			},
 //			clearCacheFunc: func() error {
 //				panic("mock out the clearCache method")
 //			},
@@ -123,6 +126,9 @@ type mockScriptStorageState struct {
 	// accountIsDAppFunc mocks the accountIsDApp method.
 	accountIsDAppFunc func(addr proto.WavesAddress) (bool, error)
 
+	// accountScriptBytesAtHeightFunc mocks the accountScriptBytesAtHeight method.
+	accountScriptBytesAtHeightFunc func(addr proto.WavesAddress, height proto.Height) (proto.Script, error)
+
 	// clearCacheFunc mocks the clearCache method.
 	clearCacheFunc func() error
 
@@ -218,6 +224,13 @@ type mockScriptStorageState struct {
 			// Addr is the addr argument value.
 			Addr proto.WavesAddress
 		}
+		// accountScriptBytesAtHeight holds details about calls to the accountScriptBytesAtHeight method.
+		accountScriptBytesAtHeight []struct {
+			// Addr is the addr argument value.
+			Addr proto.WavesAddress
+			// Height is the height argument value.
+			Height proto.Height
+		}
 		// clearCache holds details about calls to the clearCache method.
 		clearCache []struct {
 		}
@@ -352,6 +365,7 @@ type mockScriptStorageState struct {
 	lockaccountHasScript                 sync.RWMutex
 	lockaccountHasVerifier               sync.RWMutex
 	lockaccountIsDApp                    sync.RWMutex
+	lockaccountScriptBytesAtHeight       sync.RWMutex
 	lockclearCache                       sync.RWMutex
 	lockcommitUncertain                  sync.RWMutex
 	lockdropUncertain                    sync.RWMutex
@@ -476,6 +490,42 @@ func (mock *mockScriptStorageState) accountIsDAppCalls() []struct {
 	return calls
 }
 
+// accountScriptBytesAtHeight calls accountScriptBytesAtHeightFunc.
+func (mock *mockScriptStorageState) accountScriptBytesAtHeight(addr proto.WavesAddress, height proto.Height) (proto.Script, error) {
+	if mock.accountScriptBytesAtHeightFunc == nil {
+		panic("mockScriptStorageState.accountScriptBytesAtHeightFunc: method is nil but scriptStorageState.accountScriptBytesAtHeight was just called")
+	}
+	callInfo := struct {
+		Addr   proto.WavesAddress
+		Height proto.Height
+	}{
+		Addr:   addr,
+		Height: height,
+	}
+	mock.lockaccountScriptBytesAtHeight.Lock()
+	mock.calls.accountScriptBytesAtHeight = append(mock.calls.accountScriptBytesAtHeight, callInfo)
+	mock.lockaccountScriptBytesAtHeight.Unlock()
+	return mock.accountScriptBytesAtHeightFunc(addr, height)
+}
+
+// accountScriptBytesAtHeightCalls gets all the calls that were made to accountScriptBytesAtHeight.
+// Check the length with:
+//
+//	len(mockedscriptStorageState.accountScriptBytesAtHeightCalls())
+func (mock *mockScriptStorageState) accountScriptBytesAtHeightCalls() []struct {
+	Addr   proto.WavesAddress
+	Height proto.Height
+} {
+	var calls []struct {
+		Addr   proto.WavesAddress
+		Height proto.Height
+	}
+	mock.lockaccountScriptBytesAtHeight.RLock()
+	calls = mock.calls.accountScriptBytesAtHeight
+	mock.lockaccountScriptBytesAtHeight.RUnlock()
+	return calls
+}
+
 // clearCache calls clearCacheFunc.
 func (mock *mockScriptStorageState) clearCache() error {
 	if mock.clearCacheFunc == nil {
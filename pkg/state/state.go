@@ -272,6 +272,80 @@ func handleAmendFlag(stateDB *stateDB, amend bool) (bool, error) {
 	return storedAmend, nil
 }
 
+// handleHistoricalIndex returns the height since which the historical index is maintained.
+// The index is started at the current height when it is enabled and is dropped when it is disabled,
+// because the blocks applied without index make it incomplete.
+func handleHistoricalIndex(stateDB *stateDB, enable bool) (uint64, error) {
+	indexHeight, err := stateDB.historicalIndexHeight()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get historical index height")
+	}
+	switch {
+	case enable && indexHeight == 0:
+		height, hErr := stateDB.getHeight()
+		if hErr != nil {
+			return 0, errors.Wrap(hErr, "failed to get height")
+		}
+		indexHeight = max(height, 1)
+	case !enable && indexHeight != 0:
+		indexHeight = 0
+	default:
+		return indexHeight, nil
+	}
+	if err := stateDB.updateHistoricalIndexHeight(indexHeight); err != nil {
+		return 0, errors.Wrap(err, "failed to update historical index height")
+	}
+	return indexHeight, nil
+}
+
+// handleAssetHoldersIndex builds the index of asset holders for the state that was created without it.
+func handleAssetHoldersIndex(stateDB *stateDB, balances *balances) error {
+	has, err := stateDB.stateHasAssetHolders()
+	if err != nil {
+		return errors.Wrap(err, "failed to check asset holders index")
+	}
+	if has {
+		return nil
+	}
+	zap.S().Info("Building the index of asset holders, it may take a while...")
+	if err := balances.buildAssetHoldersIndex(); err != nil {
+		return errors.Wrap(err, "failed to build asset holders index")
+	}
+	if err := stateDB.setAssetHoldersFlag(); err != nil {
+		return errors.Wrap(err, "failed to set asset holders flag")
+	}
+	zap.S().Info("The index of asset holders is built")
+	return nil
+}
+
+// handleArchivalMode returns the height since which the full history is kept, zero if archival mode is disabled.
+// Archival mode of an existing state starts at the beginning of the rollback window, because the older history
+// is already cut. If archival mode is disabled, the history is cut again as usual.
+func handleArchivalMode(stateDB *stateDB, enable bool) (uint64, error) {
+	archivalHeight, err := stateDB.archivalHeight()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get archival height")
+	}
+	switch {
+	case enable && archivalHeight == 0:
+		rollbackMinHeight, rErr := stateDB.getRollbackMinHeight()
+		if rErr != nil {
+			return 0, errors.Wrap(rErr, "failed to get rollback min height")
+		}
+		archivalHeight = max(rollbackMinHeight, 1)
+		zap.S().Infof("Archival mode is enabled, the full history is kept since height %d", archivalHeight)
+	case !enable && archivalHeight != 0:
+		zap.S().Warnf("Archival mode is disabled, the history before the rollback window will be removed")
+		archivalHeight = 0
+	default:
+		return archivalHeight, nil
+	}
+	if err := stateDB.updateArchivalHeight(archivalHeight); err != nil {
+		return 0, errors.Wrap(err, "failed to update archival height")
+	}
+	return archivalHeight, nil
+}
+
 type newBlocks struct {
 	binary    bool
 	binBlocks [][]byte
@@ -434,6 +508,8 @@ type stateManager struct {
 
 	newBlocks *newBlocks
 
+	checkpoints *checkpointsRegistry
+
 	enableLightNode bool
 }
 
@@ -567,10 +643,23 @@ func newStateManager(
 	}()
 	sdb.setRw(rw)
 	hs := newHistoryStorage(db, dbBatch, sdb, handledAmend)
+	indexHeight, err := handleHistoricalIndex(sdb, params.StoreHistoricalIndex)
+	if err != nil {
+		return nil, wrapErr(Other, err)
+	}
+	hs.enableHistoricalIndex(indexHeight)
+	archivalHeight, err := handleArchivalMode(sdb, params.Archival)
+	if err != nil {
+		return nil, wrapErr(Other, err)
+	}
+	hs.enableArchivalMode(archivalHeight)
 	stor, err := newBlockchainEntitiesStorage(hs, settings, rw, params.BuildStateHashes)
 	if err != nil {
 		return nil, wrapErr(Other, errors.Errorf("failed to create blockchain entities storage: %v", err))
 	}
+	if err := handleAssetHoldersIndex(sdb, stor.balances); err != nil {
+		return nil, wrapErr(Other, err)
+	}
 	atxParams := &addressTransactionsParams{
 		dir:                 blockStorageDir,
 		batchedStorMemLimit: AddressTransactionsMemLimit,
@@ -598,12 +687,13 @@ func newStateManager(
 		atx:                       atx,
 		verificationGoroutinesNum: params.VerificationGoroutinesNum,
 		newBlocks:                 newNewBlocks(rw, settings),
+		checkpoints:               newCheckpointsRegistry(db, blockStorageDir),
 		enableLightNode:           enableLightNode,
 	}
 	// Set fields which depend on state.
 	// Consensus validator is needed to check block headers.
 	snapshotApplier := newBlockSnapshotsApplier(nil, newSnapshotApplierStorages(stor, rw))
-	appender, err := newTxAppender(state, rw, stor, settings, sdb, atx, &snapshotApplier)
+	appender, err := newTxAppender(state, rw, stor, settings, sdb, atx, &snapshotApplier, params.RideEngine)
 	if err != nil {
 		return nil, wrapErr(Other, err)
 	}
@@ -653,6 +743,23 @@ func (s *stateManager) NewestScriptBytesByAccount(account proto.Recipient) (prot
 	return script, nil
 }
 
+func (s *stateManager) ScriptBytesByAccountAtHeight(
+	account proto.Recipient, height proto.Height,
+) (proto.Script, error) {
+	if err := s.checkHistoricalHeight(height); err != nil {
+		return nil, err
+	}
+	addr, err := s.recipientToAddress(account)
+	if err != nil {
+		return nil, wrapErr(RetrievalError, err)
+	}
+	script, err := s.stor.scriptsStorage.accountScriptBytesAtHeight(addr, height)
+	if err != nil {
+		return nil, historicalErr(err)
+	}
+	return script, nil
+}
+
 func (s *stateManager) NewestScriptByAsset(asset crypto.Digest) (*ast.Tree, error) {
 	assetID := proto.AssetIDFromDigest(asset)
 	return s.stor.scriptsStorage.newestScriptByAsset(assetID)
@@ -1025,6 +1132,32 @@ func (s *stateManager) GeneratingBalance(account proto.Recipient, height proto.H
 	return s.stor.balances.generatingBalance(addr.ID(), height)
 }
 
+// WavesBalanceWithConfirmations returns minimal regular Waves balance of the account
+// over the last `confirmations` blocks.
+func (s *stateManager) WavesBalanceWithConfirmations(account proto.Recipient, confirmations uint64) (uint64, error) {
+	if confirmations > rollbackMaxBlocks {
+		return 0, wrapErr(InvalidInputError,
+			errors.Errorf("confirmations %d exceed maximum %d", confirmations, rollbackMaxBlocks))
+	}
+	addr, err := s.recipientToAddress(account)
+	if err != nil {
+		return 0, errs.Extend(err, "failed convert recipient to address")
+	}
+	height, err := s.Height()
+	if err != nil {
+		return 0, wrapErr(RetrievalError, err)
+	}
+	startHeight := uint64(1)
+	if height > confirmations {
+		startHeight = height - confirmations
+	}
+	balance, err := s.stor.balances.minBalanceInRange(addr.ID(), startHeight, height)
+	if err != nil {
+		return 0, wrapErr(RetrievalError, err)
+	}
+	return balance, nil
+}
+
 // NewestMinerGeneratingBalance returns the generating balance of the miner at the given height.
 // This method includes the challenger bonus if the block has a challenged header.
 func (s *stateManager) NewestMinerGeneratingBalance(header *proto.BlockHeader, height proto.Height) (uint64, error) {
@@ -1213,6 +1346,75 @@ func (s *stateManager) AssetBalance(account proto.Recipient, assetID proto.Asset
 	return balance, nil
 }
 
+// checkHistoricalHeight checks that height of historical query is in the range of applied blocks.
+func (s *stateManager) checkHistoricalHeight(height proto.Height) error {
+	current, err := s.Height()
+	if err != nil {
+		return wrapErr(RetrievalError, err)
+	}
+	if height < 1 || height > current {
+		return wrapErr(InvalidInputError, errors.Errorf("invalid height %d, current height is %d", height, current))
+	}
+	return nil
+}
+
+// historicalErr reports queries of unavailable history as invalid input.
+func historicalErr(err error) error {
+	if errors.Is(err, errHistoryUnavailable) {
+		return wrapErr(InvalidInputError, err)
+	}
+	return wrapErr(RetrievalError, err)
+}
+
+func (s *stateManager) WavesBalanceAtHeight(account proto.Recipient, height proto.Height) (uint64, error) {
+	if err := s.checkHistoricalHeight(height); err != nil {
+		return 0, err
+	}
+	addr, err := s.recipientToAddress(account)
+	if err != nil {
+		return 0, wrapErr(RetrievalError, err)
+	}
+	profile, err := s.stor.balances.wavesBalanceAtHeight(addr.ID(), height)
+	if err != nil {
+		return 0, historicalErr(err)
+	}
+	return profile.balance, nil
+}
+
+func (s *stateManager) LeaseBalanceAtHeight(
+	account proto.Recipient, height proto.Height,
+) (int64, int64, error) {
+	if err := s.checkHistoricalHeight(height); err != nil {
+		return 0, 0, err
+	}
+	addr, err := s.recipientToAddress(account)
+	if err != nil {
+		return 0, 0, wrapErr(RetrievalError, err)
+	}
+	profile, err := s.stor.balances.wavesBalanceAtHeight(addr.ID(), height)
+	if err != nil {
+		return 0, 0, historicalErr(err)
+	}
+	return profile.leaseIn, profile.leaseOut, nil
+}
+
+func (s *stateManager) AssetBalanceAtHeight(
+	account proto.Recipient, assetID proto.AssetID, height proto.Height,
+) (uint64, error) {
+	if err := s.checkHistoricalHeight(height); err != nil {
+		return 0, err
+	}
+	addr, err := s.recipientToAddress(account)
+	if err != nil {
+		return 0, wrapErr(RetrievalError, err)
+	}
+	balance, err := s.stor.balances.assetBalanceAtHeight(addr.ID(), assetID, height)
+	if err != nil {
+		return 0, historicalErr(err)
+	}
+	return balance, nil
+}
+
 func (s *stateManager) WavesAddressesNumber() (uint64, error) {
 	res, err := s.stor.balances.wavesAddressesNumber()
 	if err != nil {
@@ -2024,7 +2226,8 @@ func (s *stateManager) checkRollbackHeight(height uint64) error {
 	if err != nil {
 		return err
 	}
-	minRollbackHeight, err := s.stateDB.getRollbackMinHeight()
+	// Histories are complete since the start of rollback window or, in archival mode, since the archival height.
+	minRollbackHeight, err := s.stor.hs.fmt.historyStartHeight()
 	if err != nil {
 		return err
 	}
@@ -2054,6 +2257,12 @@ func (s *stateManager) RollbackToHeight(height uint64) error {
 }
 
 func (s *stateManager) rollbackToImpl(removalEdge proto.BlockID) error {
+	// Checkpoints above the new height can't be written after the block storage is truncated.
+	if height, err := s.rw.heightByBlockID(removalEdge); err == nil {
+		s.checkpoints.invalidateAbove(height)
+	} else {
+		s.checkpoints.invalidateAll()
+	}
 	// The database part of rollback.
 	if err := s.stateDB.rollback(removalEdge); err != nil {
 		return wrapErr(RollbackError, err)
@@ -2394,6 +2603,23 @@ func (s *stateManager) RetrieveEntry(account proto.Recipient, key string) (proto
 	return entry, nil
 }
 
+func (s *stateManager) RetrieveEntryAtHeight(
+	account proto.Recipient, key string, height proto.Height,
+) (proto.DataEntry, error) {
+	if err := s.checkHistoricalHeight(height); err != nil {
+		return nil, err
+	}
+	addr, err := s.recipientToAddress(account)
+	if err != nil {
+		return nil, wrapErr(RetrievalError, err)
+	}
+	entry, err := s.stor.accountsDataStor.retrieveEntryAtHeight(addr, key, height)
+	if err != nil {
+		return nil, historicalErr(err)
+	}
+	return entry, nil
+}
+
 func (s *stateManager) RetrieveNewestIntegerEntry(account proto.Recipient, key string) (*proto.IntegerDataEntry, error) {
 	addr, err := s.NewestRecipientToAddress(account)
 	if err != nil {
@@ -2819,6 +3045,32 @@ func (s *stateManager) NFTList(account proto.Recipient, limit uint64, afterAsset
 	return infos, nil
 }
 
+func (s *stateManager) AssetDistribution(
+	assetID proto.AssetID, height proto.Height, after *proto.WavesAddress, limit int,
+) ([]proto.AssetHolding, bool, error) {
+	if err := s.checkHistoricalHeight(height); err != nil {
+		return nil, false, err
+	}
+	var afterID *proto.AddressID
+	if after != nil {
+		id := after.ID()
+		afterID = &id
+	}
+	holdings, hasNext, err := s.stor.balances.assetDistribution(assetID, height, afterID, limit)
+	if err != nil {
+		return nil, false, historicalErr(err)
+	}
+	res := make([]proto.AssetHolding, len(holdings))
+	for i, h := range holdings {
+		addr, err := h.address.ToWavesAddress(s.settings.AddressSchemeCharacter)
+		if err != nil {
+			return nil, false, wrapErr(RetrievalError, err)
+		}
+		res[i] = proto.AssetHolding{Address: addr, Balance: h.balance}
+	}
+	return res, hasNext, nil
+}
+
 func (s *stateManager) ScriptBasicInfoByAccount(account proto.Recipient) (*proto.ScriptBasicInfo, error) {
 	addr, err := s.recipientToAddress(account)
 	if err != nil {
@@ -2952,6 +3204,14 @@ func (s *stateManager) ProvidesExtendedApi() (bool, error) {
 	return s.atx.providesData(), nil
 }
 
+func (s *stateManager) ArchivalHeight() (proto.Height, error) {
+	height, err := s.stateDB.archivalHeight()
+	if err != nil {
+		return 0, wrapErr(RetrievalError, err)
+	}
+	return height, nil
+}
+
 func (s *stateManager) ProvidesStateHashes() (bool, error) {
 	provides, err := s.stateDB.stateStoresHashes()
 	if err != nil {
@@ -2995,6 +3255,8 @@ func (s *stateManager) StartProvidingExtendedApi() error {
 }
 
 func (s *stateManager) PersistAddressTransactions() error {
+	// Persisting truncates address transactions file, so it can't be copied by checkpoints anymore.
+	s.checkpoints.invalidateAll()
 	return s.atx.persist()
 }
 
@@ -0,0 +1,397 @@
+package state
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	stderrs "errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/keyvalue"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+)
+
+// State snapshot is a portable archive of the state used to bootstrap a node without full import.
+// It is a gzipped tar archive with the following entries:
+//   - blocks_storage/<name> files which are copied as is;
+//   - state/<number>.kv chunks of database records, each record is a key and a value prefixed with uvarint lengths;
+//   - manifest.json, the last entry, which contains StateSnapshotManifest.
+//
+// Database records are stored independently of the database engine, so the archive can be restored
+// into any supported storage.
+const (
+	// StateSnapshotFormatVersion is the current version of the state snapshot format.
+	StateSnapshotFormatVersion = 1
+
+	stateSnapshotManifestName = "manifest.json"
+	stateSnapshotChunksDir    = "state"
+	stateSnapshotChunkSize    = 64 << 20 // 64 MiB
+	maxStateSnapshotRecordLen = 64 << 20 // Sanity limit for the key or value length.
+	maxStateSnapshotManifest  = 1 << 20
+)
+
+// StateSnapshotFile describes an entry of the state snapshot archive.
+type StateSnapshotFile struct {
+	Name   string `json:"name"`
+	Size   uint64 `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// StateSnapshotManifest describes the content of the state snapshot archive.
+type StateSnapshotManifest struct {
+	FormatVersion int `json:"formatVersion"`
+	CheckpointInfo
+	Files []StateSnapshotFile `json:"files"`
+}
+
+// ExportStateSnapshot writes the checkpoint as a state snapshot archive.
+func (c *Checkpoint) ExportStateSnapshot(w io.Writer) error {
+	if c.invalidated.Load() {
+		return ErrCheckpointInvalidated
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	m := StateSnapshotManifest{FormatVersion: StateSnapshotFormatVersion, CheckpointInfo: c.info}
+	for _, f := range c.files {
+		entry, err := c.exportFile(tw, f)
+		if err != nil {
+			return errors.Wrapf(err, "failed to export file '%s'", f.name)
+		}
+		m.Files = append(m.Files, entry)
+	}
+	chunks, err := c.exportRecords(tw)
+	if err != nil {
+		return errors.Wrap(err, "failed to export database records")
+	}
+	m.Files = append(m.Files, chunks...)
+	// Files could be truncated by rollback during the export, so check validity again.
+	if c.invalidated.Load() {
+		return ErrCheckpointInvalidated
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal manifest")
+	}
+	if _, wErr := writeTarEntry(tw, stateSnapshotManifestName, bytes.NewReader(data), uint64(len(data))); wErr != nil {
+		return errors.Wrap(wErr, "failed to write manifest")
+	}
+	if clErr := tw.Close(); clErr != nil {
+		return errors.Wrap(clErr, "failed to close archive")
+	}
+	if clErr := gz.Close(); clErr != nil {
+		return errors.Wrap(clErr, "failed to close archive")
+	}
+	return nil
+}
+
+func (c *Checkpoint) exportFile(tw *tar.Writer, f checkpointFile) (_ StateSnapshotFile, err error) {
+	src, err := os.Open(filepath.Join(c.registry.blockStorageDir, f.name))
+	if err != nil {
+		return StateSnapshotFile{}, err
+	}
+	defer func() {
+		if clErr := src.Close(); clErr != nil {
+			err = stderrs.Join(err, clErr)
+		}
+	}()
+	return writeTarEntry(tw, path.Join(blocksStorDir, f.name), src, f.size)
+}
+
+func (c *Checkpoint) exportRecords(tw *tar.Writer) ([]StateSnapshotFile, error) {
+	iter := c.snap.NewIterator()
+	defer iter.Release()
+	var (
+		files []StateSnapshotFile
+		buf   bytes.Buffer
+		lb    [binary.MaxVarintLen64]byte
+	)
+	flush := func() error {
+		name := stateSnapshotChunkName(len(files))
+		entry, err := writeTarEntry(tw, name, &buf, uint64(buf.Len()))
+		if err != nil {
+			return errors.Wrapf(err, "failed to write chunk '%s'", name)
+		}
+		files = append(files, entry)
+		buf.Reset()
+		return nil
+	}
+	for iter.Next() {
+		for _, b := range [][]byte{iter.Key(), iter.Value()} {
+			n := binary.PutUvarint(lb[:], uint64(len(b)))
+			buf.Write(lb[:n])
+			buf.Write(b)
+		}
+		if buf.Len() >= stateSnapshotChunkSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	if buf.Len() > 0 || len(files) == 0 {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func stateSnapshotChunkName(n int) string {
+	return path.Join(stateSnapshotChunksDir, fmt.Sprintf("%06d.kv", n))
+}
+
+func writeTarEntry(tw *tar.Writer, name string, r io.Reader, size uint64) (StateSnapshotFile, error) {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0600,
+		Size:     int64(size), // #nosec: file size always fits int64
+		ModTime:  time.Unix(0, 0),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return StateSnapshotFile{}, err
+	}
+	h := sha256.New()
+	if _, err := io.CopyN(io.MultiWriter(tw, h), r, hdr.Size); err != nil {
+		return StateSnapshotFile{}, err
+	}
+	return StateSnapshotFile{Name: name, Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// StateSnapshotAnchor checks the manifest of the state snapshot against the values obtained independently of
+// the archive, for example, the block ID pinned by the operator or the state hash reported by a trusted node.
+// The manifest is a part of the archive, so the checks against it prove only that the archive is consistent,
+// the anchor ties the manifest to the blockchain.
+type StateSnapshotAnchor func(m *StateSnapshotManifest) error
+
+// PinnedBlockAnchor returns the anchor which accepts only the snapshot made at the block with the given ID.
+func PinnedBlockAnchor(blockID proto.BlockID) StateSnapshotAnchor {
+	return func(m *StateSnapshotManifest) error {
+		if m.BlockID != blockID {
+			return errors.Errorf("state snapshot is made at block '%s', want pinned block '%s'",
+				m.BlockID.String(), blockID.String())
+		}
+		return nil
+	}
+}
+
+// ImportStateSnapshot restores the state from the state snapshot archive into the empty data directory.
+// Integrity of the archive is checked against the manifest, the manifest is checked by the anchor, then
+// the restored state is opened and checked against the block ID and the state hashes from the manifest.
+// The database records are not re-executed and the state hashes are read from the restored state, so
+// the anchor confirms the block and hashes the snapshot claims, but the archive itself must be obtained
+// from a source trusted to export a genuine state.
+func ImportStateSnapshot(
+	r io.Reader,
+	dataDir string,
+	params StateParams,
+	settings *settings.BlockchainSettings,
+	anchor StateSnapshotAnchor,
+) (_ *StateSnapshotManifest, err error) {
+	if anchor == nil {
+		return nil, errors.New("state snapshot can't be imported without trusted anchor")
+	}
+	if mkErr := makeEmptyDir(dataDir); mkErr != nil {
+		return nil, mkErr
+	}
+	defer func() {
+		if err != nil {
+			if rmErr := removeStateFiles(dataDir); rmErr != nil {
+				err = stderrs.Join(err, rmErr)
+			}
+		}
+	}()
+	m, files, err := unpackStateSnapshot(r, dataDir, params.DbParams.Backend)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unpack state snapshot")
+	}
+	if m.FormatVersion != StateSnapshotFormatVersion {
+		return nil, errors.Errorf("unsupported state snapshot format version %d", m.FormatVersion)
+	}
+	if !slices.Equal(m.Files, files) {
+		return nil, errors.New("state snapshot content does not match the manifest")
+	}
+	if vErr := m.validate(settings); vErr != nil {
+		return nil, vErr
+	}
+	if aErr := anchor(m); aErr != nil {
+		return nil, errors.Wrap(aErr, "state snapshot is not confirmed by trusted anchor")
+	}
+	if vErr := verifyRestoredState(dataDir, &m.CheckpointInfo, params, settings); vErr != nil {
+		return nil, vErr
+	}
+	return m, nil
+}
+
+func unpackStateSnapshot(
+	r io.Reader,
+	dataDir string,
+	backend keyvalue.Backend,
+) (_ *StateSnapshotManifest, _ []StateSnapshotFile, err error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	blocksDir := filepath.Join(dataDir, blocksStorDir)
+	if mkErr := os.Mkdir(blocksDir, 0750); mkErr != nil {
+		return nil, nil, mkErr
+	}
+	db, err := keyvalue.NewBulkWriter(filepath.Join(dataDir, keyvalueDir), backend)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if clErr := db.Close(); clErr != nil {
+			err = stderrs.Join(err, clErr)
+		}
+	}()
+	var (
+		tr     = tar.NewReader(gz)
+		files  []StateSnapshotFile
+		chunks int
+		m      *StateSnapshotManifest
+	)
+	for {
+		hdr, nErr := tr.Next()
+		if errors.Is(nErr, io.EOF) {
+			break
+		}
+		if nErr != nil {
+			return nil, nil, nErr
+		}
+		if m != nil {
+			return nil, nil, errors.Errorf("unexpected entry '%s' after manifest", hdr.Name)
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Size < 0 {
+			return nil, nil, errors.Errorf("invalid entry '%s'", hdr.Name)
+		}
+		h := sha256.New()
+		entryReader := io.TeeReader(tr, h)
+		dir, name := path.Split(hdr.Name)
+		switch {
+		case hdr.Name == stateSnapshotManifestName:
+			m, err = readStateSnapshotManifest(tr, hdr.Size)
+			if err != nil {
+				return nil, nil, err
+			}
+			continue
+		case dir == blocksStorDir+"/" && isBlocksStorageFile(name):
+			err = writeFile(filepath.Join(blocksDir, name), entryReader)
+		case hdr.Name == stateSnapshotChunkName(chunks):
+			err = importRecords(db, entryReader)
+			chunks++
+		default:
+			return nil, nil, errors.Errorf("unexpected entry '%s'", hdr.Name)
+		}
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to unpack '%s'", hdr.Name)
+		}
+		files = append(files, newStateSnapshotFile(hdr, h))
+	}
+	if m == nil {
+		return nil, nil, errors.New("manifest not found, archive is incomplete")
+	}
+	return m, files, nil
+}
+
+func readStateSnapshotManifest(r io.Reader, size int64) (*StateSnapshotManifest, error) {
+	if size > maxStateSnapshotManifest {
+		return nil, errors.Errorf("manifest is too big: %d bytes", size)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	m := new(StateSnapshotManifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal manifest")
+	}
+	return m, nil
+}
+
+func newStateSnapshotFile(hdr *tar.Header, h hash.Hash) StateSnapshotFile {
+	return StateSnapshotFile{
+		Name:   hdr.Name,
+		Size:   uint64(hdr.Size), // #nosec: checked for negative values before
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}
+}
+
+func isBlocksStorageFile(name string) bool {
+	switch name {
+	case "blockchain", "headers", "block_height_to_id", "address_transactions":
+		return true
+	default:
+		return false
+	}
+}
+
+func writeFile(name string, r io.Reader) (err error) {
+	f, err := os.OpenFile(filepath.Clean(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if clErr := f.Close(); clErr != nil {
+			err = stderrs.Join(err, clErr)
+		}
+	}()
+	if _, cpErr := io.Copy(f, r); cpErr != nil {
+		return cpErr
+	}
+	return f.Sync()
+}
+
+func importRecords(db *keyvalue.BulkWriter, r io.Reader) error {
+	br := bufio.NewReader(r)
+	readBytes := func() ([]byte, error) {
+		l, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		if l > maxStateSnapshotRecordLen {
+			return nil, errors.Errorf("record length %d exceeds the limit", l)
+		}
+		b := make([]byte, l)
+		if _, err := io.ReadFull(br, b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	for {
+		key, err := readBytes()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		value, err := readBytes()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if err := db.Put(key, value); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package state
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+)
+
+func exportTestStateSnapshot(t *testing.T, manager *stateManager) ([]byte, CheckpointInfo) {
+	cp, err := manager.NewCheckpoint()
+	require.NoError(t, err)
+	defer cp.Release()
+	var buf bytes.Buffer
+	require.NoError(t, cp.ExportStateSnapshot(&buf))
+	return buf.Bytes(), cp.Info()
+}
+
+// rewriteStateSnapshot unpacks the archive and packs it again passing entries through the modify function.
+// Entry is dropped if modify returns nil.
+func rewriteStateSnapshot(t *testing.T, archive []byte, modify func(name string, data []byte) []byte) []byte {
+	gzr, err := gzip.NewReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+	tr := tar.NewReader(gzr)
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for {
+		hdr, nErr := tr.Next()
+		if nErr == io.EOF {
+			break
+		}
+		require.NoError(t, nErr)
+		data, rErr := io.ReadAll(tr)
+		require.NoError(t, rErr)
+		data = modify(hdr.Name, data)
+		if data == nil {
+			continue
+		}
+		hdr.Size = int64(len(data))
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, wErr := tw.Write(data)
+		require.NoError(t, wErr)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return buf.Bytes()
+}
+
+func TestStateSnapshotExportImport(t *testing.T) {
+	bs := settings.MustMainNetSettings()
+	params := DefaultTestingStateParams()
+	params.BuildStateHashes = true
+	manager := newTestStateManager(t, true, params, bs)
+	applyTestBlocks(t, manager, bs, 100)
+
+	archive, info := exportTestStateSnapshot(t, manager)
+
+	dataDir := t.TempDir()
+	m, err := ImportStateSnapshot(bytes.NewReader(archive), dataDir, DefaultTestingStateParams(), bs,
+		PinnedBlockAnchor(info.BlockID))
+	require.NoError(t, err)
+	assert.Equal(t, StateSnapshotFormatVersion, m.FormatVersion)
+	assert.Equal(t, info, m.CheckpointInfo)
+
+	// Restored state continues from the snapshot height.
+	rs, err := newStateManager(dataDir, true, params, bs, false)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, rs.Close())
+	}()
+	applyTestBlocks(t, rs, bs, 120)
+	applyTestBlocks(t, manager, bs, 120)
+	for h := proto.Height(100); h <= 120; h++ {
+		expected, shErr := manager.SnapshotStateHashAtHeight(h)
+		require.NoError(t, shErr)
+		actual, shErr := rs.SnapshotStateHashAtHeight(h)
+		require.NoError(t, shErr)
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestStateSnapshotImportCorrupted(t *testing.T) {
+	bs := settings.MustMainNetSettings()
+	manager := newTestStateManager(t, true, DefaultTestingStateParams(), bs)
+	applyTestBlocks(t, manager, bs, 30)
+	archive, info := exportTestStateSnapshot(t, manager)
+
+	for _, test := range []struct {
+		name    string
+		modify  func(name string, data []byte) []byte
+		errText string
+	}{
+		{
+			name: "corrupted chunk",
+			modify: func(name string, data []byte) []byte {
+				if name == stateSnapshotChunkName(0) {
+					data[len(data)-1] ^= 0xff
+				}
+				return data
+			},
+			errText: "does not match the manifest",
+		},
+		{
+			name: "truncated block storage file",
+			modify: func(name string, data []byte) []byte {
+				if name == "blocks_storage/headers" {
+					return data[:len(data)-1]
+				}
+				return data
+			},
+			errText: "does not match the manifest",
+		},
+		{
+			name: "missing manifest",
+			modify: func(name string, data []byte) []byte {
+				if name == stateSnapshotManifestName {
+					return nil
+				}
+				return data
+			},
+			errText: "manifest not found",
+		},
+		{
+			name: "missing block storage file",
+			modify: func(name string, data []byte) []byte {
+				if name == "blocks_storage/headers" {
+					return nil
+				}
+				return data
+			},
+			errText: "does not match the manifest",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			_, err := ImportStateSnapshot(bytes.NewReader(rewriteStateSnapshot(t, archive, test.modify)),
+				dataDir, DefaultTestingStateParams(), bs, PinnedBlockAnchor(info.BlockID))
+			assert.ErrorContains(t, err, test.errText)
+			entries, err := os.ReadDir(dataDir)
+			require.NoError(t, err)
+			assert.Empty(t, entries, "unpacked files must be removed on failure")
+		})
+	}
+}
+
+func TestStateSnapshotImportAnchor(t *testing.T) {
+	bs := settings.MustMainNetSettings()
+	manager := newTestStateManager(t, true, DefaultTestingStateParams(), bs)
+	applyTestBlocks(t, manager, bs, 30)
+	archive, _ := exportTestStateSnapshot(t, manager)
+	otherID, err := manager.HeightToBlockID(10)
+	require.NoError(t, err)
+
+	_, err = ImportStateSnapshot(bytes.NewReader(archive), t.TempDir(), DefaultTestingStateParams(), bs, nil)
+	assert.ErrorContains(t, err, "without trusted anchor")
+
+	dataDir := t.TempDir()
+	_, err = ImportStateSnapshot(bytes.NewReader(archive), dataDir, DefaultTestingStateParams(), bs,
+		PinnedBlockAnchor(otherID))
+	assert.ErrorContains(t, err, "not confirmed by trusted anchor")
+	entries, err := os.ReadDir(dataDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "unpacked files must be removed on failure")
+}
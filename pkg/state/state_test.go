@@ -39,6 +39,17 @@ type testCase struct {
 	path   string
 }
 
+// runForBackends runs the test with the state stored by each database backend.
+func runForBackends(t *testing.T, test func(t *testing.T, params StateParams)) {
+	for _, backend := range []keyvalue.Backend{keyvalue.LevelDB, keyvalue.Badger} {
+		t.Run(backend.String(), func(t *testing.T) {
+			params := DefaultTestingStateParams()
+			params.DbParams.Backend = backend
+			test(t, params)
+		})
+	}
+}
+
 func bigFromStr(s string) *big.Int {
 	var i big.Int
 	i.SetString(s, 10)
@@ -97,6 +108,43 @@ func TestHandleAmendFlag(t *testing.T) {
 	assert.True(t, newManager.stor.hs.amend)
 }
 
+func TestHandleArchivalMode(t *testing.T) {
+	dataDir := t.TempDir()
+	bs := settings.MustMainNetSettings()
+	// first open without archival mode
+	manager, err := newStateManager(dataDir, true, DefaultTestingStateParams(), bs, false)
+	require.NoError(t, err, "newStateManager() failed")
+	t.Cleanup(func() {
+		assert.NoError(t, manager.Close(), "manager.Close() failed")
+	})
+	archivalHeight, err := manager.ArchivalHeight()
+	require.NoError(t, err)
+	assert.Zero(t, archivalHeight)
+	assert.Zero(t, manager.stor.hs.fmt.archivalHeight)
+
+	// existing state is migrated to archival mode since the start of rollback window
+	params := DefaultTestingStateParams()
+	params.Archival = true
+	require.NoError(t, manager.Close(), "manager.Close() failed")
+	manager, err = newStateManager(dataDir, true, params, bs, false)
+	require.NoError(t, err, "newStateManager() failed")
+	rollbackMinHeight, err := manager.stateDB.getRollbackMinHeight()
+	require.NoError(t, err)
+	archivalHeight, err = manager.ArchivalHeight()
+	require.NoError(t, err)
+	assert.Equal(t, max(rollbackMinHeight, 1), archivalHeight)
+	assert.Equal(t, archivalHeight, manager.stor.hs.fmt.archivalHeight)
+
+	// archival mode is turned off
+	require.NoError(t, manager.Close(), "manager.Close() failed")
+	manager, err = newStateManager(dataDir, true, DefaultTestingStateParams(), bs, false)
+	require.NoError(t, err, "newStateManager() failed")
+	archivalHeight, err = manager.ArchivalHeight()
+	require.NoError(t, err)
+	assert.Zero(t, archivalHeight)
+	assert.Zero(t, manager.stor.hs.fmt.archivalHeight)
+}
+
 func TestGenesisConfig(t *testing.T) {
 	ss := &settings.BlockchainSettings{
 		Type:                  settings.Custom,
@@ -178,6 +226,10 @@ func TestValidationWithoutBlocks(t *testing.T) {
 }
 
 func TestStateRollback(t *testing.T) {
+	runForBackends(t, testStateRollback)
+}
+
+func testStateRollback(t *testing.T, params StateParams) {
 	dir, err := getLocalDir()
 	if err != nil {
 		t.Fatalf("Failed to get local dir: %v\n", err)
@@ -185,7 +237,7 @@ func TestStateRollback(t *testing.T) {
 	blocksPath, err := blocksPath()
 	assert.NoError(t, err)
 	bs := settings.MustMainNetSettings()
-	manager := newTestStateManager(t, true, DefaultTestingStateParams(), bs)
+	manager := newTestStateManager(t, true, params, bs)
 
 	tests := []struct {
 		nextHeight        uint64
@@ -229,7 +281,41 @@ func TestStateRollback(t *testing.T) {
 	}
 }
 
+func TestStateRollbackArchival(t *testing.T) {
+	dir, err := getLocalDir()
+	require.NoError(t, err)
+	blocksPath, err := blocksPath()
+	require.NoError(t, err)
+	bs := settings.MustMainNetSettings()
+	params := DefaultTestingStateParams()
+	params.Archival = true
+	manager := newTestStateManager(t, true, params, bs)
+	importParams := importer.ImportParams{Schema: bs.AddressSchemeCharacter, BlockchainPath: blocksPath}
+
+	// Rollback window starts at height 1001, archival mode keeps the history since height 1.
+	require.NoError(t, importer.ApplyFromFile(context.Background(), importParams, manager, 3000, 1))
+	rollbackMinHeight, err := manager.stateDB.getRollbackMinHeight()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1001), rollbackMinHeight)
+
+	require.NoError(t, manager.RollbackToHeight(901))
+	require.NoError(t, importer.CheckBalances(manager, filepath.Join(dir, "testdata", "accounts-901")))
+	rollbackMinHeight, err = manager.stateDB.getRollbackMinHeight()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(901), rollbackMinHeight)
+
+	require.NoError(t, importer.ApplyFromFile(context.Background(), importParams, manager, 1000, 901))
+	require.NoError(t, importer.CheckBalances(manager, filepath.Join(dir, "testdata", "accounts-1001")))
+	require.NoError(t, manager.RollbackToHeight(31))
+	require.NoError(t, importer.CheckBalances(manager, filepath.Join(dir, "testdata", "accounts-31")))
+	assert.Error(t, manager.RollbackToHeight(0))
+}
+
 func TestStateIntegrated(t *testing.T) {
+	runForBackends(t, testStateIntegrated)
+}
+
+func testStateIntegrated(t *testing.T, params StateParams) {
 	dir, err := getLocalDir()
 	if err != nil {
 		t.Fatalf("Failed to get local dir: %v\n", err)
@@ -238,7 +324,7 @@ func TestStateIntegrated(t *testing.T) {
 	assert.NoError(t, err)
 	balancesPath := filepath.Join(dir, "testdata", "accounts-1001")
 	bs := settings.MustMainNetSettings()
-	manager := newTestStateManager(t, true, DefaultTestingStateParams(), bs)
+	manager := newTestStateManager(t, true, params, bs)
 
 	tests := []testCase{
 		{height: 901, score: bigFromStr("26588533320520"), path: filepath.Join(dir, "testdata", "accounts-901")},
@@ -523,6 +609,7 @@ func createMockStateManager(t *testing.T, bs *settings.BlockchainSettings) (*sta
 		state.stateDB,
 		state.atx,
 		&snapshotApplier,
+		ride.TreeEngine,
 	)
 	require.NoError(t, err, "newTxAppender() failed")
 	state.appender = appender
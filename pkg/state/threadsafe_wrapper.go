@@ -7,6 +7,7 @@ import (
 
 	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/ride"
 	"github.com/wavesplatform/gowaves/pkg/ride/ast"
 	"github.com/wavesplatform/gowaves/pkg/settings"
 )
@@ -92,6 +93,14 @@ func (a *ThreadSafeReadWrapper) GeneratingBalance(account proto.Recipient, heigh
 	return a.s.GeneratingBalance(account, height)
 }
 
+func (a *ThreadSafeReadWrapper) WavesBalanceWithConfirmations(
+	account proto.Recipient, confirmations uint64,
+) (uint64, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.WavesBalanceWithConfirmations(account, confirmations)
+}
+
 func (a *ThreadSafeReadWrapper) WavesBalance(account proto.Recipient) (uint64, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -104,6 +113,28 @@ func (a *ThreadSafeReadWrapper) AssetBalance(account proto.Recipient, asset prot
 	return a.s.AssetBalance(account, asset)
 }
 
+func (a *ThreadSafeReadWrapper) WavesBalanceAtHeight(account proto.Recipient, height proto.Height) (uint64, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.WavesBalanceAtHeight(account, height)
+}
+
+func (a *ThreadSafeReadWrapper) LeaseBalanceAtHeight(
+	account proto.Recipient, height proto.Height,
+) (int64, int64, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.LeaseBalanceAtHeight(account, height)
+}
+
+func (a *ThreadSafeReadWrapper) AssetBalanceAtHeight(
+	account proto.Recipient, asset proto.AssetID, height proto.Height,
+) (uint64, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.AssetBalanceAtHeight(account, asset, height)
+}
+
 func (a *ThreadSafeReadWrapper) WavesAddressesNumber() (uint64, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -212,6 +243,14 @@ func (a *ThreadSafeReadWrapper) RetrieveEntry(account proto.Recipient, key strin
 	return a.s.RetrieveEntry(account, key)
 }
 
+func (a *ThreadSafeReadWrapper) RetrieveEntryAtHeight(
+	account proto.Recipient, key string, height proto.Height,
+) (proto.DataEntry, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.RetrieveEntryAtHeight(account, key, height)
+}
+
 func (a *ThreadSafeReadWrapper) RetrieveIntegerEntry(account proto.Recipient, key string) (*proto.IntegerDataEntry, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -298,6 +337,14 @@ func (a *ThreadSafeReadWrapper) NFTList(account proto.Recipient, limit uint64, a
 	return a.s.NFTList(account, limit, afterAssetID)
 }
 
+func (a *ThreadSafeReadWrapper) AssetDistribution(
+	assetID proto.AssetID, height proto.Height, after *proto.WavesAddress, limit int,
+) ([]proto.AssetHolding, bool, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.AssetDistribution(assetID, height, after, limit)
+}
+
 func (a *ThreadSafeReadWrapper) ScriptBasicInfoByAccount(account proto.Recipient) (*proto.ScriptBasicInfo, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -328,6 +375,14 @@ func (a *ThreadSafeReadWrapper) NewestScriptBytesByAccount(recipient proto.Recip
 	return a.s.NewestScriptBytesByAccount(recipient)
 }
 
+func (a *ThreadSafeReadWrapper) ScriptBytesByAccountAtHeight(
+	recipient proto.Recipient, height proto.Height,
+) (proto.Script, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.ScriptBytesByAccountAtHeight(recipient, height)
+}
+
 func (a *ThreadSafeReadWrapper) IsActiveLeasing(leaseID crypto.Digest) (bool, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -340,6 +395,18 @@ func (a *ThreadSafeReadWrapper) InvokeResultByID(invokeID crypto.Digest) (*proto
 	return a.s.InvokeResultByID(invokeID)
 }
 
+func (a *ThreadSafeReadWrapper) ArchivalHeight() (proto.Height, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.ArchivalHeight()
+}
+
+func (a *ThreadSafeReadWrapper) NewCheckpoint() (*Checkpoint, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.NewCheckpoint()
+}
+
 func (a *ThreadSafeReadWrapper) ProvidesStateHashes() (bool, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -412,6 +479,24 @@ func (a *ThreadSafeReadWrapper) IsActiveLightNodeNewBlocksFields(blockHeight pro
 	return a.s.IsActiveLightNodeNewBlocksFields(blockHeight)
 }
 
+func (a *ThreadSafeReadWrapper) EvaluateExpression(account proto.WavesAddress, expr ast.Node) (ride.Result, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.EvaluateExpression(account, expr)
+}
+
+func (a *ThreadSafeReadWrapper) CallFunctionReadOnly(dApp proto.WavesAddress, fc proto.FunctionCall) (ride.Result, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.CallFunctionReadOnly(dApp, fc)
+}
+
+func (a *ThreadSafeReadWrapper) MinimalFee(tx proto.Transaction, feeAsset proto.OptionalAsset) (uint64, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.s.MinimalFee(tx, feeAsset)
+}
+
 func NewThreadSafeReadWrapper(mu *sync.RWMutex, s StateInfo) StateInfo {
 	return &ThreadSafeReadWrapper{
 		mu: mu,
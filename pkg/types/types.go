@@ -185,7 +185,7 @@ type InvRequester interface {
 type BaseTarget = uint64
 
 type Miner interface {
-	MineKeyBlock(ctx context.Context, t proto.Timestamp, k proto.KeyPair, parent proto.BlockID, baseTarget BaseTarget, gs []byte, vrf []byte) (*proto.Block, proto.MiningLimits, error)
+	MineKeyBlock(ctx context.Context, t proto.Timestamp, pk crypto.PublicKey, parent proto.BlockID, baseTarget BaseTarget, gs []byte, vrf []byte) (*proto.Block, proto.MiningLimits, error)
 }
 
 type Time interface {
@@ -201,7 +201,19 @@ type MinerConsensus interface {
 	IsMiningAllowed() bool
 }
 
+// Signer signs blocks, microblocks and transactions and generates VRF proofs on behalf of accounts,
+// it allows to keep secret keys out of the node.
+type Signer interface {
+	// PublicKeys returns public keys of the accounts available for signing.
+	PublicKeys() ([]crypto.PublicKey, error)
+	// Sign signs the data with the secret key of the account.
+	Sign(pk crypto.PublicKey, data []byte) (crypto.Signature, error)
+	// SignVRF calculates VRF proof of the message with the secret key of the account.
+	SignVRF(pk crypto.PublicKey, msg []byte) ([]byte, error)
+}
+
 type EmbeddedWallet interface {
+	Signer
 	SignTransactionWith(pk crypto.PublicKey, tx proto.Transaction) error
 	Load(password []byte) error
 	AccountSeeds() [][]byte
@@ -4,16 +4,134 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"encoding/binary"
 	"io"
 
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/argon2"
 )
 
+const (
+	maxPlaintextSize = 1024 * 1024
+
+	keySize   = 32 // AES-256
+	saltSize  = 32
+	minSalt   = 16
+	maxMemory = 4 * 1024 * 1024 // 4 GiB in KiB, protects from unreasonable parameters in corrupted files
+	maxTime   = 64
+)
+
+// kdfParams are the parameters of Argon2id key derivation, they are stored in the wallet file.
+type kdfParams struct {
+	time    uint32
+	memory  uint32 // KiB
+	threads uint8
+}
+
+var defaultKDFParams = kdfParams{time: 4, memory: 64 * 1024, threads: 4}
+
+func (p kdfParams) validate() error {
+	if p.time == 0 || p.time > maxTime {
+		return errors.Errorf("invalid KDF time parameter %d", p.time)
+	}
+	if p.memory < 8*uint32(p.threads) || p.memory > maxMemory {
+		return errors.Errorf("invalid KDF memory parameter %d", p.memory)
+	}
+	if p.threads == 0 {
+		return errors.New("invalid KDF threads parameter 0")
+	}
+	return nil
+}
+
+// sealV2 derives the key from the password with the random salt and encrypts the plaintext with AES-256-GCM.
+// The result has the following layout:
+//
+//	version (4) | time (4) | memory (4) | threads (1) | salt length (1) | salt | nonce (12) | ciphertext with tag
+//
+// The whole header is authenticated as additional data.
+func sealV2(password, plaintext []byte, params kdfParams) ([]byte, error) {
+	if len(plaintext) > maxPlaintextSize {
+		return nil, errors.New("too big plaintext len for encrypting, 1MB limit exceeded")
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(password, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, 14+len(salt)+aead.NonceSize())
+	header = binary.BigEndian.AppendUint32(header, versionV2)
+	header = binary.BigEndian.AppendUint32(header, params.time)
+	header = binary.BigEndian.AppendUint32(header, params.memory)
+	header = append(header, params.threads, byte(len(salt)))
+	header = append(header, salt...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	header = append(header, nonce...)
+	return aead.Seal(header, nonce, plaintext, header), nil
+}
+
+// openV2 decrypts the data produced by sealV2. ErrInvalidPassword is returned if the data can't be authenticated.
+func openV2(password, data []byte) ([]byte, error) {
+	const fixed = 14
+	if len(data) < fixed {
+		return nil, errors.Errorf("invalid wallet data size %d", len(data))
+	}
+	params := kdfParams{
+		time:    binary.BigEndian.Uint32(data[4:8]),
+		memory:  binary.BigEndian.Uint32(data[8:12]),
+		threads: data[12],
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	saltLen := int(data[13])
+	if saltLen < minSalt {
+		return nil, errors.Errorf("invalid salt size %d", saltLen)
+	}
+	if len(data) < fixed+saltLen {
+		return nil, errors.Errorf("invalid wallet data size %d", len(data))
+	}
+	salt := data[fixed : fixed+saltLen]
+	aead, err := newAEAD(password, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	headerLen := fixed + saltLen + aead.NonceSize()
+	if len(data) < headerLen+aead.Overhead() {
+		return nil, errors.Errorf("invalid wallet data size %d", len(data))
+	}
+	header := data[:headerLen]
+	nonce := header[fixed+saltLen:]
+	plaintext, err := aead.Open(nil, nonce, data[headerLen:], header)
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+	return plaintext, nil
+}
+
+func newAEAD(password, salt []byte, params kdfParams) (cipher.AEAD, error) {
+	key := argon2.IDKey(password, salt, params.time, params.memory, params.threads, keySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
 type crypt struct {
 	key []byte
 }
 
+// NewCrypt creates the cipher of version 1 wallet format. The key is derived with the fixed salt and
+// the data is encrypted with unauthenticated AES-CFB, the format is kept only to read old wallets.
 func NewCrypt(key []byte) *crypt {
 	salt := []byte("E84265D411C08F99E092AE237F4EC250B2F20B2EAB7CFB2FCB0857880983DF44")
 	pass := argon2.IDKey(key, salt, 4, 64*1024, 4, 32)
@@ -23,7 +141,7 @@ func NewCrypt(key []byte) *crypt {
 }
 
 func (a *crypt) Encrypt(plaintext []byte) ([]byte, error) {
-	if len(plaintext) > 1024*1024 {
+	if len(plaintext) > maxPlaintextSize {
 		return nil, errors.New("too big plaintext len for encrypting, 1MB limit exceeded")
 	}
 
@@ -3,36 +3,99 @@ package wallet
 import (
 	"sync"
 
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
 	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/types"
 )
 
 type seeder interface {
 	AccountSeeds() [][]byte
 }
 
+// EmbeddedWalletImpl keeps account seeds in memory and signs with them. If the external signer is set,
+// the seeds are not available and all signing is delegated to the signer.
 type EmbeddedWalletImpl struct {
-	loader Loader
-	seeder seeder
-	scheme proto.Scheme
-	mu     sync.Mutex
+	loader   Loader
+	seeder   seeder
+	external types.Signer
+	scheme   proto.Scheme
+	mu       sync.Mutex
 }
 
 func (a *EmbeddedWalletImpl) SignTransactionWith(pk crypto.PublicKey, tx proto.Transaction) error {
-	seeds := a.seeder.AccountSeeds()
+	if a.external != nil {
+		return proto.SignTransactionWith(a.scheme, tx, func(data []byte) (crypto.Signature, error) {
+			return a.external.Sign(pk, data)
+		})
+	}
+	secret, err := a.secretKey(pk)
+	if err != nil {
+		return err
+	}
+	return tx.Sign(a.scheme, secret)
+}
+
+// PublicKeys returns public keys of the wallet accounts.
+func (a *EmbeddedWalletImpl) PublicKeys() ([]crypto.PublicKey, error) {
+	if a.external != nil {
+		return a.external.PublicKeys()
+	}
+	seeds := a.AccountSeeds()
+	pks := make([]crypto.PublicKey, 0, len(seeds))
 	for _, s := range seeds {
+		_, public, err := crypto.GenerateKeyPair(s)
+		if err != nil {
+			return nil, err
+		}
+		pks = append(pks, public)
+	}
+	return pks, nil
+}
+
+// Sign signs the data with the secret key of the account.
+func (a *EmbeddedWalletImpl) Sign(pk crypto.PublicKey, data []byte) (crypto.Signature, error) {
+	if a.external != nil {
+		return a.external.Sign(pk, data)
+	}
+	secret, err := a.secretKey(pk)
+	if err != nil {
+		return crypto.Signature{}, err
+	}
+	return crypto.Sign(secret, data)
+}
+
+// SignVRF calculates VRF proof of the message with the secret key of the account.
+func (a *EmbeddedWalletImpl) SignVRF(pk crypto.PublicKey, msg []byte) ([]byte, error) {
+	if a.external != nil {
+		return a.external.SignVRF(pk, msg)
+	}
+	secret, err := a.secretKey(pk)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.SignVRF(secret, msg)
+}
+
+func (a *EmbeddedWalletImpl) secretKey(pk crypto.PublicKey) (crypto.SecretKey, error) {
+	for _, s := range a.AccountSeeds() {
 		secret, public, err := crypto.GenerateKeyPair(s)
 		if err != nil {
-			return err
+			return crypto.SecretKey{}, err
 		}
 		if public == pk {
-			return tx.Sign(a.scheme, secret)
+			return secret, nil
 		}
 	}
-	return ErrPublicKeyNotFound
+	return crypto.SecretKey{}, ErrPublicKeyNotFound
 }
 
 func (a *EmbeddedWalletImpl) Load(password []byte) error {
+	if a.external != nil {
+		return errors.New("wallet is managed by external signer")
+	}
 	bts, err := a.loader.Load()
 	if err != nil {
 		return err
@@ -41,15 +104,41 @@ func (a *EmbeddedWalletImpl) Load(password []byte) error {
 	if err != nil {
 		return err
 	}
+	if NeedsUpgrade(bts) {
+		a.upgrade(w, password)
+	}
 	a.mu.Lock()
 	a.seeder = w
 	a.mu.Unlock()
 	return nil
 }
 
+// upgrade re-encodes the wallet of outdated format if the loader is able to save it.
+// Failure to upgrade is not fatal, the wallet stays usable.
+func (a *EmbeddedWalletImpl) upgrade(w Wallet, password []byte) {
+	s, ok := a.loader.(saver)
+	if !ok {
+		return
+	}
+	bts, err := w.Encode(password)
+	if err != nil {
+		zap.S().Warnf("Failed to upgrade wallet: %v", err)
+		return
+	}
+	if err := s.Save(bts); err != nil {
+		zap.S().Warnf("Failed to save upgraded wallet: %v", err)
+		return
+	}
+	zap.S().Infof("Wallet has been upgraded to format version %d", curVersion)
+}
+
+// AccountSeeds returns seeds of the wallet accounts, there are no seeds if the external signer is used.
 func (a *EmbeddedWalletImpl) AccountSeeds() [][]byte {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	if a.seeder == nil {
+		return nil
+	}
 	return a.seeder.AccountSeeds()
 }
 
@@ -60,3 +149,11 @@ func NewEmbeddedWallet(path Loader, seeder seeder, scheme proto.Scheme) *Embedde
 		scheme: scheme,
 	}
 }
+
+// NewExternalWallet creates the wallet which delegates signing to the external signer.
+func NewExternalWallet(signer types.Signer, scheme proto.Scheme) *EmbeddedWalletImpl {
+	return &EmbeddedWalletImpl{
+		external: signer,
+		scheme:   scheme,
+	}
+}
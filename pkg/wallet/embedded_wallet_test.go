@@ -2,6 +2,8 @@ package wallet
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -70,3 +72,46 @@ func TestEmbeddedWalletImpl_Load(t *testing.T) {
 		require.Errorf(t, w.Load(nil), "loaderr")
 	})
 }
+
+func TestEmbeddedWalletImpl_LoadUpgrade(t *testing.T) {
+	wal := NewWallet()
+	require.NoError(t, wal.AddAccountSeed([]byte("seed")))
+	path := filepath.Join(t.TempDir(), "wallet")
+	require.NoError(t, os.WriteFile(path, encodeV1(t, wal, []byte("pass")), 0600))
+
+	w := NewEmbeddedWallet(NewLoader(path), nil, proto.TestNetScheme)
+	require.NoError(t, w.Load([]byte("pass")))
+	require.Equal(t, [][]byte{[]byte("seed")}, w.AccountSeeds())
+
+	bts, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.False(t, NeedsUpgrade(bts))
+	w2, err := Decode(bts, []byte("pass"))
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("seed")}, w2.AccountSeeds())
+}
+
+func TestEmbeddedWalletImpl_Signer(t *testing.T) {
+	w := NewEmbeddedWallet(nil, seederTest("test"), proto.TestNetScheme)
+	_, pub, err := crypto.GenerateKeyPair([]byte("test"))
+	require.NoError(t, err)
+
+	pks, err := w.PublicKeys()
+	require.NoError(t, err)
+	require.Equal(t, []crypto.PublicKey{pub}, pks)
+
+	sig, err := w.Sign(pub, []byte("data"))
+	require.NoError(t, err)
+	require.True(t, crypto.Verify(pub, sig, []byte("data")))
+
+	proof, err := w.SignVRF(pub, []byte("msg"))
+	require.NoError(t, err)
+	ok, _, err := crypto.VerifyVRF(pub, []byte("msg"), proof)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, err = w.Sign(crypto.PublicKey{}, []byte("data"))
+	require.ErrorIs(t, err, ErrPublicKeyNotFound)
+	_, err = w.SignVRF(crypto.PublicKey{}, []byte("msg"))
+	require.ErrorIs(t, err, ErrPublicKeyNotFound)
+}
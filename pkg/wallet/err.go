@@ -2,4 +2,7 @@ package wallet
 
 import "errors"
 
-var ErrPublicKeyNotFound = errors.New("public key not found")
+var (
+	ErrPublicKeyNotFound = errors.New("public key not found")
+	ErrInvalidPassword   = errors.New("invalid password")
+)
@@ -10,6 +10,11 @@ type Loader interface {
 	Load() ([]byte, error)
 }
 
+// saver is implemented by loaders that can store the upgraded wallet back.
+type saver interface {
+	Save(data []byte) error
+}
+
 type LoaderImpl struct {
 	path string
 }
@@ -19,13 +24,38 @@ func NewLoader(path string) LoaderImpl {
 }
 
 func (a LoaderImpl) Load() ([]byte, error) {
+	p, err := a.walletPath()
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(p) // #nosec: in this case check for prevent G304 (CWE-22) is not necessary
+}
+
+// Save replaces the wallet file with the data. The data is written to the temporary file first, so
+// the wallet is not corrupted if saving is interrupted.
+func (a LoaderImpl) Save(data []byte) error {
+	p, err := a.walletPath()
+	if err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if wErr := os.WriteFile(tmp, data, 0600); wErr != nil {
+		return wErr
+	}
+	if rnErr := os.Rename(tmp, p); rnErr != nil {
+		_ = os.Remove(tmp)
+		return rnErr
+	}
+	return nil
+}
+
+func (a LoaderImpl) walletPath() (string, error) {
 	if a.path != "" {
-		return os.ReadFile(a.path)
-	} else {
-		u, err := user.Current()
-		if err != nil {
-			return nil, err
-		}
-		return os.ReadFile(filepath.Join(u.HomeDir, ".waves"))
+		return a.path, nil
+	}
+	u, err := user.Current()
+	if err != nil {
+		return "", err
 	}
+	return filepath.Join(u.HomeDir, ".waves"), nil
 }
@@ -0,0 +1,112 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Parameters of the Scala node wallet file encryption. The key is derived with PBKDF2-HMAC-SHA512 and
+// the JSON is encrypted with AES-128 in ECB mode with PKCS#5 padding and stored as Base64 text.
+const (
+	scalaKeySalt    = "0495c728-1614-41f6-8ac3-966c22b4a62d"
+	scalaIterations = 999999
+	scalaKeySize    = 16
+)
+
+// ScalaWallet is the content of the Scala node wallet file.
+type ScalaWallet struct {
+	// Seed is the wallet seed, usually the bytes of the seed phrase.
+	Seed []byte
+	// AccountSeeds are the seeds of generated accounts, they are the same as gowaves account seeds.
+	AccountSeeds [][]byte
+	// Nonce is the number of accounts generated from the seed.
+	Nonce int
+}
+
+type scalaWalletData struct {
+	Seed         string   `json:"seed"`
+	AccountSeeds []string `json:"accountSeeds"`
+	Nonce        int      `json:"nonce"`
+}
+
+// DecodeScalaWallet decrypts the wallet.dat file of the Scala node with the node's wallet password.
+// Only the JSON wallet files of Scala node 1.0 and later are supported.
+func DecodeScalaWallet(data, password []byte) (*ScalaWallet, error) {
+	return decodeScalaWallet(data, password, scalaIterations)
+}
+
+func decodeScalaWallet(data, password []byte, iterations int) (*ScalaWallet, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid Scala wallet file")
+	}
+	key := pbkdf2.Key(password, []byte(scalaKeySalt), iterations, scalaKeySize, sha512.New)
+	plaintext, err := decryptECB(key, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	var wd scalaWalletData
+	if jsErr := json.Unmarshal(plaintext, &wd); jsErr != nil {
+		return nil, errors.Wrap(jsErr, "invalid Scala wallet data")
+	}
+	seed, err := decodeByteStr(wd.Seed)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid Scala wallet seed")
+	}
+	r := &ScalaWallet{Seed: seed, AccountSeeds: make([][]byte, len(wd.AccountSeeds)), Nonce: wd.Nonce}
+	for i, s := range wd.AccountSeeds {
+		as, dErr := decodeByteStr(s)
+		if dErr != nil {
+			return nil, errors.Wrapf(dErr, "invalid Scala wallet account seed %d", i)
+		}
+		r.AccountSeeds[i] = as
+	}
+	return r, nil
+}
+
+// decryptECB decrypts AES-ECB ciphertext and removes PKCS#5 padding. Invalid padding means
+// that the key, thus the password, is wrong.
+func decryptECB(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, errors.Errorf("invalid cipher size %d", len(ciphertext))
+	}
+	plaintext := make([]byte, len(ciphertext))
+	for i := 0; i < len(ciphertext); i += aes.BlockSize {
+		block.Decrypt(plaintext[i:i+aes.BlockSize], ciphertext[i:i+aes.BlockSize])
+	}
+	pad := int(plaintext[len(plaintext)-1])
+	if pad == 0 || pad > aes.BlockSize {
+		return nil, ErrInvalidPassword
+	}
+	for _, b := range plaintext[len(plaintext)-pad:] {
+		if int(b) != pad {
+			return nil, ErrInvalidPassword
+		}
+	}
+	return plaintext[:len(plaintext)-pad], nil
+}
+
+// decodeByteStr decodes the string representation of Scala ByteStr, which is Base58 by default
+// and Base64 with the prefix for long values.
+func decodeByteStr(s string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(s, "base64:"):
+		return base64.StdEncoding.DecodeString(strings.TrimPrefix(s, "base64:"))
+	case strings.HasPrefix(s, "base58:"):
+		return base58.Decode(strings.TrimPrefix(s, "base58:"))
+	default:
+		return base58.Decode(s)
+	}
+}
@@ -0,0 +1,60 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+)
+
+const testScalaIterations = 1000
+
+// encryptScalaWallet encrypts the JSON the same way as Scala node does.
+func encryptScalaWallet(t *testing.T, js string, password []byte) []byte {
+	key := pbkdf2.Key(password, []byte(scalaKeySalt), testScalaIterations, scalaKeySize, sha512.New)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	pad := aes.BlockSize - len(js)%aes.BlockSize
+	plaintext := []byte(js)
+	for range pad {
+		plaintext = append(plaintext, byte(pad))
+	}
+	ciphertext := make([]byte, len(plaintext))
+	for i := 0; i < len(plaintext); i += aes.BlockSize {
+		block.Encrypt(ciphertext[i:i+aes.BlockSize], plaintext[i:i+aes.BlockSize])
+	}
+	return []byte(base64.StdEncoding.EncodeToString(ciphertext) + "\n")
+}
+
+func TestDecodeScalaWallet(t *testing.T) {
+	seed := []byte("seed phrase of the scala wallet")
+	accountSeed := func(nonce uint32) []byte {
+		d, err := crypto.SecureHash(append(binary.BigEndian.AppendUint32(nil, nonce), seed...))
+		require.NoError(t, err)
+		return d.Bytes()
+	}
+	js := `{"seed":"` + base58.Encode(seed) + `","accountSeeds":["` + base58.Encode(accountSeed(0)) +
+		`","base64:` + base64.StdEncoding.EncodeToString(accountSeed(1)) + `"],"nonce":2}`
+	data := encryptScalaWallet(t, js, []byte("password"))
+
+	w, err := decodeScalaWallet(data, []byte("password"), testScalaIterations)
+	require.NoError(t, err)
+	assert.Equal(t, seed, w.Seed)
+	assert.Equal(t, [][]byte{accountSeed(0), accountSeed(1)}, w.AccountSeeds)
+	assert.Equal(t, 2, w.Nonce)
+
+	_, err = decodeScalaWallet(data, []byte("wrong"), testScalaIterations)
+	assert.Error(t, err)
+	_, err = decodeScalaWallet([]byte("not base64!"), []byte("password"), testScalaIterations)
+	assert.ErrorContains(t, err, "invalid Scala wallet file")
+	_, err = decodeScalaWallet(data[:10], []byte("password"), testScalaIterations)
+	assert.Error(t, err)
+}
@@ -8,7 +8,15 @@ import (
 	"github.com/wavesplatform/gowaves/pkg/util/common"
 )
 
-const curVersion = 1
+const (
+	// versionV1 wallets are encrypted with AES-CFB using the key derived with the fixed salt.
+	versionV1 = 1
+	// versionV2 wallets are encrypted with AES-GCM using the key derived with the random salt,
+	// the KDF parameters are stored in the wallet.
+	versionV2 = 2
+
+	curVersion = versionV2
+)
 
 type WalletFormat struct {
 	Seed [][]byte `json:"seeds"`
@@ -31,7 +39,8 @@ func (a *WalletImpl) AccountSeeds() [][]byte {
 
 func NewWallet() *WalletImpl {
 	return &WalletImpl{
-		format: WalletFormat{},
+		Version: curVersion,
+		format:  WalletFormat{},
 	}
 }
 
@@ -41,28 +50,36 @@ func (a *WalletImpl) AddAccountSeed(seed []byte) error {
 	return nil
 }
 
+// Encode encrypts the wallet with the password using the current format version.
 func (a *WalletImpl) Encode(password []byte) ([]byte, error) {
+	return a.encode(password, defaultKDFParams)
+}
 
-	crypt := NewCrypt(password)
+func (a *WalletImpl) encode(password []byte, params kdfParams) ([]byte, error) {
 	walletData, err := json.Marshal(a.format)
 	if err != nil {
 		return nil, err
 	}
+	return sealV2(password, walletData, params)
+}
 
-	rs, err := crypt.Encrypt(walletData)
+// Decode decrypts the wallet of any supported format version.
+// ErrInvalidPassword is returned if the password doesn't match the wallet.
+func Decode(walletData []byte, password []byte) (Wallet, error) {
+	version, err := formatVersion(walletData)
 	if err != nil {
 		return nil, err
 	}
-	rs = append(make([]byte, 4), rs...)
-	binary.BigEndian.PutUint32(rs[:4], curVersion)
-	return rs, nil
-}
-
-func Decode(walletData []byte, password []byte) (Wallet, error) {
-	version := binary.BigEndian.Uint32(walletData[:4])
-	walletData = walletData[4:]
-	crypt := NewCrypt(password)
-	bts, err := crypt.Decrypt(walletData)
+	var bts []byte
+	switch version {
+	case versionV1:
+		crypt := NewCrypt(password)
+		bts, err = crypt.Decrypt(walletData[4:])
+	case versionV2:
+		bts, err = openV2(password, walletData)
+	default:
+		return nil, errors.Errorf("unsupported wallet version %d", version)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -70,10 +87,27 @@ func Decode(walletData []byte, password []byte) (Wallet, error) {
 	format := WalletFormat{}
 	err = json.Unmarshal(bts, &format)
 	if err != nil {
-		return nil, errors.New("invalid password")
+		if version == versionV1 { // Unauthenticated encryption, the wrong password results in garbage.
+			return nil, ErrInvalidPassword
+		}
+		return nil, errors.Wrap(err, "invalid wallet data")
 	}
 	return &WalletImpl{
 		Version: version,
 		format:  format,
 	}, nil
 }
+
+// NeedsUpgrade reports whether the wallet data is encoded with the outdated format version and should be
+// re-encoded.
+func NeedsUpgrade(walletData []byte) bool {
+	version, err := formatVersion(walletData)
+	return err == nil && version < curVersion
+}
+
+func formatVersion(walletData []byte) (uint32, error) {
+	if len(walletData) < 4 {
+		return 0, errors.Errorf("invalid wallet data size %d", len(walletData))
+	}
+	return binary.BigEndian.Uint32(walletData[:4]), nil
+}
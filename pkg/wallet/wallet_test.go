@@ -1,6 +1,9 @@
 package wallet
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -46,3 +49,74 @@ func TestWallet_EncodeDecodeMultipleAccountSeeds(t *testing.T) {
 	_, err = Decode(bts, []byte("unknown password"))
 	require.Error(t, err)
 }
+
+var testKDFParams = kdfParams{time: 1, memory: 64, threads: 1}
+
+// encodeV1 encodes the wallet in the legacy format.
+func encodeV1(t *testing.T, w *WalletImpl, password []byte) []byte {
+	data, err := json.Marshal(w.format)
+	require.NoError(t, err)
+	ct, err := NewCrypt(password).Encrypt(data)
+	require.NoError(t, err)
+	return append(binary.BigEndian.AppendUint32(nil, versionV1), ct...)
+}
+
+func TestWallet_EncodeV2(t *testing.T) {
+	password := []byte("123456")
+	w := NewWallet()
+	require.NoError(t, w.AddAccountSeed([]byte("seed")))
+
+	bts1, err := w.encode(password, testKDFParams)
+	require.NoError(t, err)
+	bts2, err := w.encode(password, testKDFParams)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(versionV2), binary.BigEndian.Uint32(bts1[:4]))
+	assert.NotEqual(t, bts1, bts2, "salt and nonce must be random")
+	assert.False(t, NeedsUpgrade(bts1))
+
+	w2, err := Decode(bts1, password)
+	require.NoError(t, err)
+	assert.Equal(t, w.AccountSeeds(), w2.AccountSeeds())
+	assert.Equal(t, uint32(versionV2), w2.(*WalletImpl).Version)
+
+	_, err = Decode(bts1, []byte("unknown password"))
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+
+	// Any modification of the header or the ciphertext is detected.
+	for _, i := range []int{5, 14, len(bts1) - 1} {
+		tampered := bytes.Clone(bts1)
+		tampered[i] ^= 0x01
+		_, err = Decode(tampered, password)
+		assert.Error(t, err, "byte %d", i)
+	}
+	_, err = Decode(bts1[:20], password)
+	assert.ErrorContains(t, err, "invalid wallet data size")
+	_, err = Decode([]byte{0, 0}, password)
+	assert.ErrorContains(t, err, "invalid wallet data size")
+	_, err = Decode(binary.BigEndian.AppendUint32(nil, 3), password)
+	assert.ErrorContains(t, err, "unsupported wallet version 3")
+}
+
+func TestWallet_DecodeV1(t *testing.T) {
+	password := []byte("123456")
+	w := NewWallet()
+	require.NoError(t, w.AddAccountSeed([]byte("seed1")))
+	require.NoError(t, w.AddAccountSeed([]byte("seed2")))
+	old := encodeV1(t, w, password)
+	assert.True(t, NeedsUpgrade(old))
+
+	w2, err := Decode(old, password)
+	require.NoError(t, err)
+	assert.Equal(t, w.AccountSeeds(), w2.AccountSeeds())
+	assert.Equal(t, uint32(versionV1), w2.(*WalletImpl).Version)
+
+	_, err = Decode(old, []byte("unknown password"))
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+
+	upgraded, err := w2.Encode(password)
+	require.NoError(t, err)
+	assert.False(t, NeedsUpgrade(upgraded))
+	w3, err := Decode(upgraded, password)
+	require.NoError(t, err)
+	assert.Equal(t, w.AccountSeeds(), w3.AccountSeeds())
+}